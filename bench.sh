@@ -0,0 +1,74 @@
+#!/bin/bash
+
+# Benchmarks de pkg/nfe comparados com a linha de base (bench-base.txt)
+#
+#   ./bench.sh             roda e compara: falha se algum benchmark ficou mais
+#                          lento que LIMITE% ou passou a alocar mais
+#   ./bench.sh -atualizar  regrava a linha de base (rode na máquina de referência)
+#
+# Variáveis: LIMITE (padrão 15, em %), CONTAGEM (padrão 5 execuções por benchmark),
+# BENCH (filtro -bench, padrão ".").
+# A saída é a do go test, compatível com o benchstat.
+
+LIMITE="${LIMITE:-15}"
+CONTAGEM="${CONTAGEM:-5}"
+BENCH="${BENCH:-.}"
+BASE="bench-base.txt"
+ATUAL="$(mktemp /tmp/bench_atual_XXXXXX)"
+trap 'rm -f "$ATUAL"' EXIT
+
+cd "$(dirname "$0")" || exit 1
+
+echo "Rodando benchmarks ($CONTAGEM execuções cada)..."
+if ! go test -run '^$' -bench "$BENCH" -benchmem -count "$CONTAGEM" ./pkg/nfe > "$ATUAL"; then
+    cat "$ATUAL"
+    echo "❌ FALHA: os benchmarks não rodaram."
+    exit 1
+fi
+
+if [ "$1" = "-atualizar" ]; then
+    cp "$ATUAL" "$BASE"
+    echo "✅ Linha de base regravada em $BASE."
+    exit 0
+fi
+
+if [ ! -f "$BASE" ]; then
+    echo "❌ FALHA: $BASE não existe. Gere com ./bench.sh -atualizar"
+    exit 1
+fi
+
+# Menor ns/op e menor allocs/op de cada benchmark, de cada arquivo: a
+# execução mais rápida é a menos afetada por outros processos na máquina
+minimos() {
+    awk '/^Benchmark/ {
+        nome = $1; sub(/-[0-9]+$/, "", nome)
+        for (i = 3; i < NF; i++) {
+            if ($(i+1) == "ns/op") ns[nome] = ns[nome] " " $i
+            if ($(i+1) == "allocs/op") al[nome] = al[nome] " " $i
+        }
+    }
+    function minimo(lista,   v, n, i, m) {
+        n = split(lista, v, " ")
+        m = v[1] + 0
+        for (i = 2; i <= n; i++) if (v[i] + 0 < m) m = v[i] + 0
+        return m
+    }
+    END { for (nome in ns) print nome, minimo(ns[nome]), minimo(al[nome]) }' "$1" | sort
+}
+
+join <(minimos "$BASE") <(minimos "$ATUAL") | awk -v limite="$LIMITE" '
+    BEGIN { printf "%-28s %14s %14s %8s %10s %10s\n", "benchmark", "base ns/op", "atual ns/op", "delta", "base aloc", "atual aloc" }
+    {
+        delta = ($4 - $2) / $2 * 100
+        marca = ""
+        if (delta > limite) { marca = "  ❌ mais lento"; falhas++ }
+        if ($5 > $3 * 1.01) { marca = marca "  ❌ mais alocações"; falhas++ }
+        printf "%-28s %14.0f %14.0f %+7.1f%% %10.0f %10.0f%s\n", $1, $2, $4, delta, $3, $5, marca
+    }
+    END { exit falhas > 0 }'
+
+if [ $? -ne 0 ]; then
+    echo "❌ FALHA: regressão acima de ${LIMITE}% no tempo ou aumento nas alocações."
+    exit 1
+fi
+echo "✅ Sem regressão em relação a $BASE."
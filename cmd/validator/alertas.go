@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/internal/alert"
+	"github.com/fabyo/go-nfe-validator/internal/store"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// Variáveis de ambiente dos alertas (evitam senhas e URLs secretas no "ps")
+const (
+	envAlertas        = "NFE_ALERTS"
+	envSegredoAlertas = "NFE_ALERT_SECRET"
+)
+
+// tempoAlerta limita a entrega de cada alerta, por destino
+const tempoAlerta = 30 * time.Second
+
+// registrarFlagAlertas adiciona -alert aos modos que validam XMLs
+func registrarFlagAlertas(flags *flag.FlagSet) *string {
+	return flags.String("alert", "", "Destinos do alerta de nota autorizada que aparece cancelada, denegada ou inexistente, separados por vírgula: smtp://..., https://hooks.slack.com/... ou https://... (padrão: $"+envAlertas+")")
+}
+
+// alertador entrega os alertas a todos os destinos configurados
+type alertador struct {
+	destinos  []alert.Destino
+	descricao []string // URLs sem senha, para os logs
+}
+
+// criarAlertador monta os destinos a partir da flag, da variável de ambiente e do arquivo de configuração
+//
+// Retorna nil (alertas desligados) quando nenhum destino foi configurado.
+func criarAlertador(destinos string, arq *arquivoConfig) (*alertador, error) {
+	preencher(&destinos, arq.getenv(envAlertas))
+	preencher(&destinos, strings.Join(arq.Alertas.Destinos, ","))
+	segredo := arq.getenv(envSegredoAlertas)
+	preencher(&segredo, arq.Alertas.Segredo)
+
+	a := &alertador{}
+	for _, u := range strings.Split(destinos, ",") {
+		if u = strings.TrimSpace(u); u == "" {
+			continue
+		}
+		d, err := alert.Open(u, segredo)
+		if err != nil {
+			return nil, err
+		}
+		a.destinos = append(a.destinos, d)
+		a.descricao = append(a.descricao, alert.OcultarSenha(u))
+	}
+	if len(a.destinos) == 0 {
+		return nil, nil
+	}
+
+	logInfo("🚨 Alertas de cancelamento/denegação: %s", strings.Join(a.descricao, ", "))
+	return a, nil
+}
+
+// alertar avisa os destinos quando uma nota autorizada aparece cancelada, denegada ou inexistente
+//
+// Só a consulta feita agora conta (não a reaproveitada pelo -dedup skip), e
+// a autorização anterior vem do protNFe do próprio XML ou do histórico
+// (-store). Com o histórico, uma nota já alertada com o mesmo cStat não é
+// alertada de novo.
+func (o *opcoesValidacao) alertar(ctx context.Context, xmlData []byte, r resultado) {
+	if o.alertas == nil || r.Sefaz.Consulta != validation.ConsultaRealizada || r.Sefaz.Autorizado {
+		return
+	}
+	if r.Duplicata != nil && r.Duplicata.SefazReaproveitada {
+		return
+	}
+	motivo := alert.Classificar(r.Sefaz.Codigo)
+	if motivo == "" {
+		return
+	}
+
+	// Iniciada, a entrega termina mesmo se o contexto for cancelado no meio
+	ctx = context.WithoutCancel(ctx)
+	autorizadaPor := o.autorizacaoAnterior(ctx, xmlData, r)
+	if autorizadaPor == "" {
+		return
+	}
+
+	a := alert.Alerta{
+		Chave:         r.ChaveAcesso,
+		Motivo:        motivo,
+		CStat:         r.Sefaz.Codigo,
+		Mensagem:      r.Sefaz.Mensagem,
+		Origem:        o.origem,
+		AutorizadaPor: autorizadaPor,
+		DetectadoEm:   time.Now(),
+	}
+	if r.DadosXML != nil {
+		a.EmitenteCNPJ = r.DadosXML.EmitCNPJ
+		a.EmitenteRazao = r.DadosXML.EmitRazao
+		a.Valor = r.DadosXML.ValorTotalNF
+	}
+
+	logAviso("🚨 %s", a.Assunto())
+	for i, d := range o.alertas.destinos {
+		ctxEnvio, cancel := context.WithTimeout(ctx, tempoAlerta)
+		if err := d.Enviar(ctxEnvio, a); err != nil {
+			logErro("❌ Alerta de %s não entregue em %s: %v", a.Chave, o.alertas.descricao[i], err)
+		}
+		cancel()
+	}
+}
+
+// autorizacaoAnterior indica como se sabe que a nota estava autorizada (vazio = não se sabe)
+func (o *opcoesValidacao) autorizacaoAnterior(ctx context.Context, xmlData []byte, r resultado) string {
+	if o.historico != nil && r.ChaveAcesso != "" {
+		ctxBusca, cancel := context.WithTimeout(ctx, tempoGravacao)
+		ultimo, ok, err := store.Ultimo(ctxBusca, o.historico, r.ChaveAcesso)
+		cancel()
+		switch {
+		case err != nil:
+			logAviso("⚠️ Alertas: %v", err)
+		case ok && ultimo.Autorizado:
+			return alert.AutorizadaNoHistorico
+		case ok && ultimo.Consulta == validation.ConsultaRealizada && ultimo.CStat == r.Sefaz.Codigo:
+			// Mesma situação da validação anterior: o alerta já foi dado
+			return ""
+		}
+	}
+
+	if proc, err := nfe.ParseProcNFe(xmlData); err == nil && proc.Autorizada() {
+		return alert.AutorizadaPeloProtocolo
+	}
+	return ""
+}
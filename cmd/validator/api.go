@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limites do upload multipart
+const (
+	// tamanhoMaxUploadPadrao limita o corpo inteiro da requisição (todos os arquivos); ver -max-body-mb
+	tamanhoMaxUploadPadrao = 200 << 20 // 200 MB
+
+	// memoriaMaxUpload é o quanto do upload fica em memória; o restante vai para arquivos temporários
+	memoriaMaxUpload = 32 << 20 // 32 MB
+)
+
+// campoArquivos é o campo do formulário multipart com os XMLs e pacotes
+const campoArquivos = "arquivos"
+
+// apiHTTP implementa a API REST do modo serve sobre o pipeline do CLI
+type apiHTTP struct {
+	// opts são as opções do servidor; cada requisição deriva as suas (ver derivar)
+	opts *opcoesValidacao
+
+	// workers é o paralelismo de cada upload com vários XMLs
+	workers int
+
+	// webhook recebe cada resultado validado pela API (nil = desligado)
+	webhook *webhook
+
+	// lotes guarda os lotes assíncronos de POST /v1/batches
+	lotes *filaLotes
+
+	// auth confere as credenciais das rotas /v1 (nil = sem autenticação)
+	auth *autenticador
+
+	// limites protege as rotas /v1 por IP e por requisições simultâneas (nil = sem limites)
+	limites *limitesServe
+
+	// maxCorpo é o tamanho máximo do corpo de cada requisição, em bytes
+	maxCorpo int64
+}
+
+// respostaUpload é o corpo JSON do POST /v1/validate/upload
+type respostaUpload struct {
+	Resumo     resumoLote  `json:"resumo"`
+	Resultados []resultado `json:"resultados"`
+}
+
+// respostaErro é o corpo JSON das requisições recusadas pela API
+type respostaErro struct {
+	Erro string `json:"erro"`
+}
+
+// handlerUpload valida os arquivos enviados em multipart/form-data (POST /v1/validate/upload)
+//
+// Cada parte do campo "arquivos" pode ser um .xml ou um pacote .zip/.tar.gz/.tgz
+// (cada *.xml do pacote vira um resultado, ex: "lote.zip:2025/07/nota.xml").
+// As fases seguem as do servidor; os campos (ou parâmetros de query)
+// xsd_only, skip_sefaz, offline e disable_rules desligam fases, como no gRPC.
+// Com "Accept: text/event-stream", cada resultado sai como evento SSE assim
+// que fica pronto (ver validarUploadEmEventos).
+//
+// Exemplo:
+//
+//	curl -F arquivos=@nota.xml -F arquivos=@lote.zip -F offline=true \
+//	    http://localhost:8080/v1/validate/upload
+//	curl -N -H 'Accept: text/event-stream' -F arquivos=@lote.zip http://localhost:8080/v1/validate/upload
+func (a *apiHTTP) handlerUpload(w http.ResponseWriter, r *http.Request) {
+	arquivos, ok := lerFormularioUpload(w, r, a.maxCorpo)
+	if !ok {
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	opts, err := a.opcoes(r)
+	if err != nil {
+		responderJSON(w, http.StatusBadRequest, respostaErro{Erro: err.Error()})
+		return
+	}
+
+	itens := itensDoUpload(arquivosDoFormulario(arquivos))
+	if aceitaEventos(r) {
+		resumo := a.validarUploadEmEventos(w, itens, opts)
+		logInfo("📤 Upload (eventos): %d arquivo(s), %d XML(s) validados", len(arquivos), resumo.Total)
+		return
+	}
+
+	inicio := time.Now()
+	results := validarLote(itens, opts, a.workers, a.webhook.notificar)
+	resumo := novoResumo(results, time.Since(inicio))
+	logInfo("📤 Upload: %d arquivo(s), %d XML(s) validados", len(arquivos), resumo.Total)
+
+	responderJSON(w, http.StatusOK, respostaUpload{Resumo: resumo, Resultados: results})
+}
+
+// lerFormularioUpload lê o multipart e devolve os arquivos do campo "arquivos"
+//
+// Com ok false a resposta de erro (400 ou 413) já foi escrita.
+func lerFormularioUpload(w http.ResponseWriter, r *http.Request, maxCorpo int64) (arquivos []*multipart.FileHeader, ok bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCorpo)
+	if err := r.ParseMultipartForm(memoriaMaxUpload); err != nil {
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			responderJSON(w, http.StatusRequestEntityTooLarge, respostaErro{Erro: fmt.Sprintf("upload maior que %d MB", maxCorpo>>20)})
+			return nil, false
+		}
+		responderJSON(w, http.StatusBadRequest, respostaErro{Erro: fmt.Sprintf("formulário multipart inválido: %v", err)})
+		return nil, false
+	}
+
+	arquivos = r.MultipartForm.File[campoArquivos]
+	if len(arquivos) == 0 {
+		r.MultipartForm.RemoveAll()
+		responderJSON(w, http.StatusBadRequest, respostaErro{Erro: fmt.Sprintf("nenhum arquivo no campo '%s'", campoArquivos)})
+		return nil, false
+	}
+	return arquivos, true
+}
+
+// opcoes deriva as opções da requisição a partir das do servidor
+//
+// Os campos seguem os nomes do gRPC (nfepb.Opcoes): xsd_only, skip_sefaz,
+// offline (booleanos) e disable_rules (IDs separados por vírgula).
+// Credenciais sem o escopo sefaz validam sempre sem a consulta à SEFAZ.
+func (a *apiHTTP) opcoes(r *http.Request) (*opcoesValidacao, error) {
+	var fases [3]bool
+	for i, campo := range []string{"xsd_only", "skip_sefaz", "offline"} {
+		valor := r.FormValue(campo)
+		if valor == "" {
+			continue
+		}
+		ligado, err := strconv.ParseBool(valor)
+		if err != nil {
+			return nil, fmt.Errorf("valor inválido em '%s': %q (use true ou false)", campo, valor)
+		}
+		fases[i] = ligado
+	}
+
+	skipSefaz := fases[1] || !identidadeDe(r.Context()).pode(escopoSefaz)
+	opts := a.opts.derivar(fases[0], skipSefaz, fases[2], splitList(r.FormValue("disable_rules")))
+	opts.ctx = r.Context()
+	return opts, nil
+}
+
+// arquivoUpload é um arquivo recebido pela API: parte do multipart ou conteúdo já em memória
+type arquivoUpload struct {
+	nome    string
+	tamanho int64
+	abrir   func() (multipart.File, error)
+}
+
+// arquivosDoFormulario lista os arquivos do multipart (lidos do formulário até o fim da requisição)
+func arquivosDoFormulario(fhs []*multipart.FileHeader) []arquivoUpload {
+	arquivos := make([]arquivoUpload, len(fhs))
+	for i, fh := range fhs {
+		arquivos[i] = arquivoUpload{nome: fh.Filename, tamanho: fh.Size, abrir: fh.Open}
+	}
+	return arquivos
+}
+
+// arquivoEmMemoria monta um arquivoUpload sobre o conteúdo já lido (ex: lotes assíncronos)
+func arquivoEmMemoria(nome string, data []byte) arquivoUpload {
+	return arquivoUpload{
+		nome:    nome,
+		tamanho: int64(len(data)),
+		abrir:   func() (multipart.File, error) { return conteudoMemoria{bytes.NewReader(data)}, nil },
+	}
+}
+
+// conteudoMemoria adapta o bytes.Reader a multipart.File
+type conteudoMemoria struct{ *bytes.Reader }
+
+// Close implementa io.Closer (não há o que liberar)
+func (conteudoMemoria) Close() error { return nil }
+
+// itensDoUpload transforma os arquivos enviados em itens de validação, abrindo os pacotes
+func itensDoUpload(arquivos []arquivoUpload) iter.Seq[itemLote] {
+	return func(yield func(itemLote) bool) {
+		for _, arquivo := range arquivos {
+			if !ehPacote(arquivo.nome) {
+				if !yield(itemLote{nome: arquivo.nome, ler: lerUpload(arquivo)}) {
+					return
+				}
+				continue
+			}
+
+			err := itensDoPacoteUpload(arquivo, yield)
+			if errors.Is(err, errInterrompido) {
+				return
+			}
+			if err != nil {
+				// Pacote ilegível vira um resultado com erro, sem interromper o upload
+				falha := err
+				if !yield(itemLote{nome: arquivo.nome, ler: func() ([]byte, error) { return nil, falha }}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// itensDoPacoteUpload entrega cada *.xml do pacote enviado
+func itensDoPacoteUpload(arquivo arquivoUpload, yield func(itemLote) bool) error {
+	f, err := arquivo.abrir()
+	if err != nil {
+		return fmt.Errorf("erro ao abrir upload: %w", err)
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(strings.ToLower(arquivo.nome), ".zip") {
+		return itensDoZipReader(arquivo.nome, f, arquivo.tamanho, yield)
+	}
+	return itensDoTarGzReader(arquivo.nome, f, yield)
+}
+
+// lerUpload lê o XML enviado apenas quando o item for validado
+func lerUpload(arquivo arquivoUpload) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if !isXML(arquivo.nome) {
+			return nil, fmt.Errorf("tipo de arquivo não suportado: '%s' (envie .xml, .zip, .tar.gz ou .tgz)", arquivo.nome)
+		}
+		f, err := arquivo.abrir()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return lerLimitado(f)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/fabyo/go-nfe-validator/internal/archive"
+)
+
+// tempoArquivamento limita cada gravação no arquivo de XMLs
+const tempoArquivamento = 30 * time.Second
+
+// intervaloExpurgo é o intervalo entre as verificações de retenção do arquivo de XMLs
+const intervaloExpurgo = 24 * time.Hour
+
+// flagsArquivamento são as flags -archive* dos modos que validam XMLs
+type flagsArquivamento struct {
+	destino  *string
+	retencao *int
+	endpoint *string
+}
+
+// registrarFlagsArquivamento adiciona as flags do arquivo de XMLs ao FlagSet
+func registrarFlagsArquivamento(flags *flag.FlagSet) *flagsArquivamento {
+	return &flagsArquivamento{
+		destino:  flags.String("archive", "", "Arquivar os XMLs aprovados neste diretório ou em s3://bucket/prefixo (<CNPJ>/<AAAA>/<MM>/<chave>.xml)"),
+		retencao: flags.Int("archive-retention", 0, fmt.Sprintf("Anos de guarda dos XMLs arquivados, contados do ano seguinte ao da emissão; os mais antigos são expurgados (0 = nunca; mínimo %d)", archive.RetencaoMinima)),
+		endpoint: flags.String("archive-endpoint", "", "Endpoint S3 alternativo para -archive s3:// (ex: MinIO, LocalStack); usa path-style"),
+	}
+}
+
+// arquivamento guarda os XMLs aprovados e aplica a retenção
+type arquivamento struct {
+	destino  archive.Archive
+	local    string
+	retencao int
+}
+
+// criar monta o arquivamento a partir das flags e do arquivo de configuração
+//
+// Retorna nil (arquivamento desligado) quando nenhum destino foi configurado.
+func (f *flagsArquivamento) criar(flags *flag.FlagSet, arq *arquivoConfig) (*arquivamento, error) {
+	a := &arquivamento{local: *f.destino, retencao: *f.retencao}
+	endpoint := *f.endpoint
+
+	preencher(&a.local, arq.Arquivamento.Destino)
+	preencher(&endpoint, arq.Arquivamento.Endpoint)
+	if !flagInformada(flags, "archive-retention") {
+		a.retencao = arq.Arquivamento.RetencaoAnos
+	}
+
+	if a.local == "" {
+		return nil, nil
+	}
+	if a.retencao != 0 && a.retencao < archive.RetencaoMinima {
+		return nil, fmt.Errorf("-archive-retention deve ser 0 (nunca expurgar) ou ao menos %d anos, o prazo legal de guarda (recebido %d)", archive.RetencaoMinima, a.retencao)
+	}
+
+	if u, ok := strings.CutPrefix(a.local, "s3://"); ok {
+		bucket, prefixo, _ := strings.Cut(u, "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("destino de arquivamento inválido '%s' (use s3://bucket/prefixo)", a.local)
+		}
+		cfgAWS, err := awsconfig.LoadDefaultConfig(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("falha ao carregar a configuração AWS: %w", err)
+		}
+		cliente := s3.NewFromConfig(cfgAWS, func(o *s3.Options) {
+			if endpoint != "" {
+				o.BaseEndpoint = aws.String(endpoint)
+				o.UsePathStyle = true
+			}
+		})
+		a.destino = archive.NewS3(cliente, bucket, prefixo)
+	} else {
+		dir, err := archive.NewDir(a.local)
+		if err != nil {
+			return nil, err
+		}
+		a.destino = dir
+	}
+
+	if a.retencao > 0 {
+		logInfo("🗃️ Arquivo de XMLs: %s (retenção de %d anos)", a.local, a.retencao)
+	} else {
+		logInfo("🗃️ Arquivo de XMLs: %s", a.local)
+	}
+	return a, nil
+}
+
+// manterRetencao expurga os XMLs fora do prazo agora e a cada 24h, em segundo plano
+//
+// Não faz nada com o arquivamento desligado (nil) ou sem -archive-retention.
+func (a *arquivamento) manterRetencao(ctx context.Context) {
+	if a == nil || a.retencao == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(intervaloExpurgo)
+		defer ticker.Stop()
+		for {
+			a.expurgar(ctx)
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// expurgar remove os XMLs emitidos antes do ano limite da retenção
+func (a *arquivamento) expurgar(ctx context.Context) {
+	ano := archive.AnoLimite(a.retencao, time.Now())
+	n, err := a.destino.Expurgar(ctx, ano)
+	if err != nil {
+		if ctx.Err() == nil {
+			logAviso("⚠️ Expurgo do arquivo de XMLs: %v", err)
+		}
+		return
+	}
+	if n > 0 {
+		logInfo("🗑️ Retenção: %d XML(s) emitidos antes de %d expurgados de %s", n, ano, a.local)
+	}
+}
+
+// arquivar guarda o XML aprovado; não faz nada com o arquivamento desligado
+//
+// Só entram XMLs aprovados em todas as fases executadas e com chave de
+// acesso. A falha vira aviso: o resultado segue para a saída normalmente.
+func (o *opcoesValidacao) arquivar(ctx context.Context, xmlData []byte, r resultado) {
+	if o.arquivamento == nil || !r.aprovado() || r.ChaveAcesso == "" || conferirPrazo(o.contexto()) != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tempoArquivamento)
+	defer cancel()
+
+	g, err := o.arquivamento.destino.Guardar(ctx, r.ChaveAcesso, xmlData)
+	if err != nil {
+		logAviso("⚠️ XML de %s não arquivado: %v", r.ChaveAcesso, err)
+		return
+	}
+	if g.Novo {
+		logDetalhe("🗃️ XML arquivado em %s (sha256 %s)", g.Local, g.SHA256)
+	} else {
+		logDebug("XML já arquivado em %s", g.Local)
+	}
+}
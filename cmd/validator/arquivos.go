@@ -0,0 +1,182 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path"
+	"strings"
+)
+
+// tamanhoMaxXML limita o tamanho de cada XML extraído de um pacote (proteção contra zip bomb)
+const tamanhoMaxXML = 50 << 20 // 50 MB
+
+// itemLote é um XML a validar: arquivo no disco ou entrada de um pacote .zip/.tar.gz
+type itemLote struct {
+	// nome exibido no resultado (ex: "notas.xml" ou "backup.zip:2025/07/nota.xml")
+	nome string
+
+	// ler devolve o conteúdo do XML
+	ler func() ([]byte, error)
+}
+
+// ehPacote indica se o arquivo é um pacote suportado (.zip, .tar.gz ou .tgz)
+func ehPacote(nome string) bool {
+	nome = strings.ToLower(nome)
+	return strings.HasSuffix(nome, ".zip") || strings.HasSuffix(nome, ".tar.gz") || strings.HasSuffix(nome, ".tgz")
+}
+
+// itensDoLote transforma os caminhos em itens de validação, abrindo os pacotes
+//
+// Arquivos comuns são lidos pelo worker que os valida. As entradas dos pacotes
+// são descompactadas em sequência, à medida que os workers consomem os itens,
+// e ficam apenas em memória (nada é extraído para o disco).
+func itensDoLote(caminhos []string) iter.Seq[itemLote] {
+	return func(yield func(itemLote) bool) {
+		for _, caminho := range caminhos {
+			if !ehPacote(caminho) {
+				if !yield(itemLote{nome: caminho, ler: lerArquivo(caminho)}) {
+					return
+				}
+				continue
+			}
+
+			var err error
+			if strings.HasSuffix(strings.ToLower(caminho), ".zip") {
+				err = itensDoZip(caminho, yield)
+			} else {
+				err = itensDoTarGz(caminho, yield)
+			}
+
+			if errors.Is(err, errInterrompido) {
+				return
+			}
+			if err != nil {
+				// Pacote ilegível vira um resultado com erro, sem interromper o lote
+				falha := err
+				if !yield(itemLote{nome: caminho, ler: func() ([]byte, error) { return nil, falha }}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// errInterrompido sinaliza que o consumidor parou de pedir itens
+var errInterrompido = errors.New("iteração interrompida")
+
+// itensDoZip entrega cada *.xml do .zip
+func itensDoZip(caminho string, yield func(itemLote) bool) error {
+	r, err := zip.OpenReader(caminho)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir pacote zip: %w", err)
+	}
+	defer r.Close()
+
+	return itensDoZipAberto(caminho, &r.Reader, yield)
+}
+
+// itensDoZipReader entrega cada *.xml de um .zip que não está no disco (ex: upload)
+func itensDoZipReader(nome string, ra io.ReaderAt, tamanho int64, yield func(itemLote) bool) error {
+	r, err := zip.NewReader(ra, tamanho)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir pacote zip: %w", err)
+	}
+	return itensDoZipAberto(nome, r, yield)
+}
+
+// itensDoZipAberto percorre as entradas do .zip já aberto
+func itensDoZipAberto(nome string, r *zip.Reader, yield func(itemLote) bool) error {
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !isXML(f.Name) {
+			continue
+		}
+
+		data, err := lerEntradaZip(f)
+		if !yield(itemEntrada(nome, f.Name, data, err)) {
+			return errInterrompido
+		}
+	}
+	return nil
+}
+
+// lerEntradaZip descompacta uma entrada do .zip respeitando tamanhoMaxXML
+func lerEntradaZip(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return lerLimitado(rc)
+}
+
+// itensDoTarGz entrega cada *.xml do .tar.gz
+func itensDoTarGz(caminho string, yield func(itemLote) bool) error {
+	file, err := os.Open(caminho)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir pacote tar.gz: %w", err)
+	}
+	defer file.Close()
+
+	return itensDoTarGzReader(caminho, file, yield)
+}
+
+// itensDoTarGzReader entrega cada *.xml do .tar.gz lido de r (arquivo ou upload)
+func itensDoTarGzReader(nome string, r io.Reader, yield func(itemLote) bool) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir pacote tar.gz: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("pacote tar.gz corrompido: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !isXML(hdr.Name) {
+			continue
+		}
+
+		data, err := lerLimitado(tr)
+		if !yield(itemEntrada(nome, hdr.Name, data, err)) {
+			return errInterrompido
+		}
+	}
+}
+
+// itemEntrada monta o item de uma entrada de pacote já lida
+func itemEntrada(pacote, entrada string, data []byte, err error) itemLote {
+	return itemLote{
+		nome: pacote + ":" + path.Clean(entrada),
+		ler:  func() ([]byte, error) { return data, err },
+	}
+}
+
+// lerLimitado lê até tamanhoMaxXML bytes, falhando se a entrada for maior
+func lerLimitado(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, tamanhoMaxXML+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > tamanhoMaxXML {
+		return nil, fmt.Errorf("entrada maior que %d MB", tamanhoMaxXML>>20)
+	}
+	return data, nil
+}
+
+// lerArquivo lê o arquivo do disco apenas quando o item for validado
+func lerArquivo(caminho string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return os.ReadFile(caminho)
+	}
+}
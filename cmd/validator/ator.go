@@ -0,0 +1,164 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// autoresAtor mapeia o valor de -autor para o tpAutor do evento
+var autoresAtor = map[string]string{
+	"emitente":     sefaz.AutorEmitente,
+	"destinatario": sefaz.AutorDestinatario,
+}
+
+// resultadoAtor é a saída JSON do subcomando "ator-interessado"
+type resultadoAtor struct {
+	ChaveAcesso    string               `json:"chave_acesso"`
+	Transportador  string               `json:"transportador"`
+	Autor          string               `json:"autor"`
+	Subcontratados bool                 `json:"subcontratados"`
+	NSeqEvento     int                  `json:"n_seq_evento"`
+	Retorno        *sefaz.RetornoEvento `json:"retorno,omitempty"`
+	Erro           string               `json:"erro,omitempty"`
+}
+
+// runAtorInteressado executa o subcomando "ator-interessado": autoriza um transportador a baixar o XML da nota
+//
+// Envia ao Ambiente Nacional o evento 110150 assinado com o certificado
+// configurado; registrado o evento, o CNPJ/CPF do transportador recebe a nota
+// na distribuição DF-e.
+func runAtorInteressado(args []string) int {
+	flags := flag.NewFlagSet("ator-interessado", flag.ExitOnError)
+	doc := flags.String("doc", "", "CNPJ ou CPF do transportador autorizado (obrigatório)")
+	autor := flags.String("autor", "", "Quem autoriza: emitente ou destinatario (padrão: emitente se o CNPJ configurado for o da chave)")
+	subcontratados := flags.Bool("subcontratados", false, "Permite ao transportador autorizar subcontratados e redespachos")
+	seq := flags.Int("seq", 1, "Sequencial do evento: um por transportador da mesma nota (1 a 20)")
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s ator-interessado <44_digitos> -doc <CNPJ|CPF> [opções]\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Opções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator ator-interessado 35250732409620000175550010000037471011544648 -doc 12345678000199")
+		fmt.Fprintln(os.Stderr, "  ./validator ator-interessado 35250732409620000175550010000037471011544648 -doc 98765432000155 -seq 2 -subcontratados")
+	}
+	posicionais := parseIntercalado(flags, args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+
+	if len(posicionais) != 1 || *doc == "" {
+		flags.Usage()
+		return saidaErro
+	}
+
+	chave := validation.OnlyDigits(posicionais[0])
+	result := resultadoAtor{
+		ChaveAcesso:    chave,
+		Transportador:  *doc,
+		Subcontratados: *subcontratados,
+		NSeqEvento:     *seq,
+	}
+
+	logInfo("🚚 Modo: Ator interessado na NF-e (transportador)")
+	logInfo("Chave: %s | Transportador: %s", chave, *doc)
+
+	decomposta, err := nfe.DecomporChave(chave)
+	if err != nil {
+		result.Erro = fmt.Sprintf("Chave de acesso inválida: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaParse
+	}
+
+	if *autor != "" {
+		if _, ok := autoresAtor[*autor]; !ok {
+			logErro("❌ autor '%s' inválido (use emitente ou destinatario)", *autor)
+			flags.Usage()
+			return saidaErro
+		}
+	}
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	cfg, err := arq.carregarConfig()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	logInfo("Ambiente: %s | CNPJ: %s", cfg.Env, cfg.CNPJ)
+	if cfg.UF == "" {
+		logErro("❌ UF do autor não configurada: defina NFE_UF_IBGE (ou uf no validator.yaml)")
+		return saidaErro
+	}
+
+	result.Autor = *autor
+	if result.Autor == "" {
+		result.Autor = "destinatario"
+		if decomposta.Emitente == cfg.CNPJ {
+			result.Autor = "emitente"
+		}
+	}
+
+	evento, err := sefaz.NovoEventoAtorInteressado(chave, sefaz.AtorInteressado{
+		COrgaoAutor:    cfg.UF,
+		TpAutor:        autoresAtor[result.Autor],
+		Documento:      *doc,
+		Subcontratados: *subcontratados,
+		NSeqEvento:     *seq,
+	})
+	if err != nil {
+		logErro("❌ %v", err)
+		flags.Usage()
+		return saidaErro
+	}
+
+	client, err := sefaz.NewClient(*cfg)
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha ao configurar cliente SEFAZ: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaConectividade
+	}
+
+	logInfo("➡️ Enviando evento %s ao Ambiente Nacional...", evento.TpEvento)
+	retorno, err := client.EnviarEvento(evento)
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha no envio do evento: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaConectividade
+	}
+	result.Retorno = &retorno
+	printJSON(result)
+
+	if !retorno.Registrado {
+		logAviso("⚠️ Evento não registrado: %s - %s (lote %s - %s)", retorno.Codigo, retorno.Mensagem, retorno.CodigoLote, retorno.MensagemLote)
+		return saidaRejeitada
+	}
+	logInfo("✅ %s - %s | Protocolo %s", retorno.Codigo, retorno.Mensagem, retorno.Protocolo)
+	return saidaOK
+}
+
+// nomesAutoresAtor lista os valores aceitos por -autor, em ordem alfabética
+func nomesAutoresAtor() []string {
+	nomes := make([]string, 0, len(autoresAtor))
+	for n := range autoresAtor {
+		nomes = append(nomes, n)
+	}
+	sort.Strings(nomes)
+	return nomes
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Escopos concedidos às chaves de API e aos tokens JWT
+const (
+	// escopoValidar permite validar XMLs e chaves (sem a consulta SEFAZ)
+	escopoValidar = "validar"
+
+	// escopoSefaz permite também consultar a SEFAZ; sem ele a fase SEFAZ é pulada
+	escopoSefaz = "sefaz"
+)
+
+// escoposValidos são os escopos aceitos na configuração
+var escoposValidos = []string{escopoValidar, escopoSefaz}
+
+// envSegredoJWT é a variável de ambiente com o segredo HS256 dos tokens JWT
+const envSegredoJWT = "NFE_JWT_SECRET"
+
+// cabecalhoChaveAPI é o cabeçalho HTTP (ou metadado gRPC, em minúsculas) com a chave de API
+const cabecalhoChaveAPI = "X-API-Key"
+
+// chaveAPIConfig é uma chave de API em autenticacao.chaves do validator.yaml
+//
+// Informe sha256 (hash hexadecimal da chave, ex: "echo -n $CHAVE | sha256sum")
+// ou, em ambientes de teste, a própria chave.
+type chaveAPIConfig struct {
+	Nome            string   `yaml:"nome" toml:"nome"`
+	SHA256          string   `yaml:"sha256" toml:"sha256"`
+	Chave           string   `yaml:"chave" toml:"chave"`
+	Escopos         []string `yaml:"escopos" toml:"escopos"`
+	LimitePorMinuto int      `yaml:"limite_por_minuto" toml:"limite_por_minuto"`
+}
+
+// validarAutenticacao confere as chaves de API e o JWT do arquivo de configuração
+func (a *arquivoConfig) validarAutenticacao() []error {
+	var erros []error
+
+	nomes := map[string]bool{}
+	for i, c := range a.Autenticacao.Chaves {
+		if c.Nome == "" {
+			erros = append(erros, fmt.Errorf("autenticacao.chaves[%d] sem nome", i))
+		} else if nomes[c.Nome] {
+			erros = append(erros, fmt.Errorf("autenticacao.chaves: nome '%s' repetido", c.Nome))
+		}
+		nomes[c.Nome] = true
+
+		switch {
+		case (c.SHA256 == "") == (c.Chave == ""):
+			erros = append(erros, fmt.Errorf("autenticacao.chaves '%s': informe sha256 ou chave (apenas um)", c.Nome))
+		case c.SHA256 != "":
+			if h, err := hex.DecodeString(c.SHA256); err != nil || len(h) != sha256.Size {
+				erros = append(erros, fmt.Errorf("autenticacao.chaves '%s': sha256 deve ter 64 dígitos hexadecimais", c.Nome))
+			}
+		}
+
+		for _, e := range c.Escopos {
+			if !slices.Contains(escoposValidos, e) {
+				erros = append(erros, fmt.Errorf("autenticacao.chaves '%s': escopo '%s' inválido (use %s)", c.Nome, e, strings.Join(escoposValidos, " ou ")))
+			}
+		}
+		if c.LimitePorMinuto < 0 {
+			erros = append(erros, fmt.Errorf("autenticacao.chaves '%s': limite_por_minuto negativo", c.Nome))
+		}
+	}
+
+	if j := a.Autenticacao.JWT; j.Segredo != "" && j.ChavePublica != "" {
+		erros = append(erros, errors.New("autenticacao.jwt: informe segredo ou chave_publica (apenas um)"))
+	}
+	if a.Autenticacao.JWT.LimitePorMinuto < 0 {
+		erros = append(erros, errors.New("autenticacao.jwt: limite_por_minuto negativo"))
+	}
+	return erros
+}
+
+// identidade é quem fez a requisição: uma chave de API ou o subject de um token JWT
+type identidade struct {
+	nome    string
+	escopos []string
+
+	// limite controla as requisições da identidade (nil = sem limite)
+	limite *rate.Limiter
+}
+
+// pode indica se a identidade tem o escopo; sem autenticação (identidade nil) tudo é permitido
+func (i *identidade) pode(escopo string) bool {
+	return i == nil || slices.Contains(i.escopos, escopo)
+}
+
+// chaveIdentidade guarda a identidade no contexto da requisição
+type chaveIdentidade struct{}
+
+// identidadeDe retorna a identidade autenticada da requisição (nil sem autenticação)
+func identidadeDe(ctx context.Context) *identidade {
+	id, _ := ctx.Value(chaveIdentidade{}).(*identidade)
+	return id
+}
+
+// autenticador confere as credenciais do modo serve (REST e gRPC)
+//
+// Credenciais aceitas:
+//
+//	X-API-Key: <chave>                 chave de API de autenticacao.chaves
+//	Authorization: Bearer <chave>      idem
+//	Authorization: Bearer <jwt>        token assinado com autenticacao.jwt (HS256 com
+//	                                   segredo/$NFE_JWT_SECRET; RS*, ES* ou EdDSA com chave_publica)
+//
+// O token JWT precisa de "exp" e "sub"; os escopos vêm da claim "scope"
+// (separados por espaço, ex: "validar sefaz"). Cada chave de API tem o seu
+// limite_por_minuto; no JWT o limite vale para cada subject.
+//
+// Sem chaves e sem JWT configurados, o serve não exige autenticação.
+type autenticador struct {
+	// chaves indexadas pelo SHA-256 da chave de API
+	chaves map[[sha256.Size]byte]*identidade
+
+	// jwtChave é o segredo HS256 ou a chave pública; nil = JWT desligado
+	jwtChave  any
+	jwtParser *jwt.Parser
+
+	// limitesJWT guarda o limitador de cada subject, descartando os ociosos como os de -rate-limit (nil = sem limite)
+	limitesJWT *limitesServe
+}
+
+// claimsJWT são as claims lidas do token
+type claimsJWT struct {
+	Scope string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// novoAutenticador monta o autenticador a partir do validator.yaml (nil = autenticação desligada)
+func novoAutenticador(arq *arquivoConfig) (*autenticador, error) {
+	// $NFE_JWT_SECRET tem prioridade sobre o segredo do arquivo
+	cfgJWT := arq.Autenticacao.JWT
+	if segredo := arq.getenv(envSegredoJWT); segredo != "" {
+		cfgJWT.Segredo = segredo
+	}
+	if len(arq.Autenticacao.Chaves) == 0 && cfgJWT.Segredo == "" && cfgJWT.ChavePublica == "" {
+		return nil, nil
+	}
+
+	a := &autenticador{
+		chaves:     make(map[[sha256.Size]byte]*identidade),
+		limitesJWT: novosLimites(cfgJWT.LimitePorMinuto, 0),
+	}
+
+	for _, c := range arq.Autenticacao.Chaves {
+		var hash [sha256.Size]byte
+		if c.Chave != "" {
+			hash = sha256.Sum256([]byte(c.Chave))
+		} else {
+			h, _ := hex.DecodeString(c.SHA256)
+			copy(hash[:], h)
+		}
+
+		escopos := c.Escopos
+		if len(escopos) == 0 {
+			escopos = []string{escopoValidar}
+		}
+		a.chaves[hash] = &identidade{nome: c.Nome, escopos: escopos, limite: novoLimite(c.LimitePorMinuto)}
+	}
+
+	var metodos []string
+	switch {
+	case cfgJWT.ChavePublica != "":
+		chave, err := lerChavePublica(cfgJWT.ChavePublica)
+		if err != nil {
+			return nil, err
+		}
+		a.jwtChave = chave
+		switch chave.(type) {
+		case *rsa.PublicKey:
+			metodos = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+		case *ecdsa.PublicKey:
+			metodos = []string{"ES256", "ES384", "ES512"}
+		case ed25519.PublicKey:
+			metodos = []string{"EdDSA"}
+		}
+	case cfgJWT.Segredo != "":
+		a.jwtChave = []byte(cfgJWT.Segredo)
+		metodos = []string{"HS256"}
+	}
+
+	if a.jwtChave != nil {
+		opcoes := []jwt.ParserOption{
+			jwt.WithValidMethods(metodos),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(30 * time.Second),
+		}
+		if cfgJWT.Emissor != "" {
+			opcoes = append(opcoes, jwt.WithIssuer(cfgJWT.Emissor))
+		}
+		if cfgJWT.Audiencia != "" {
+			opcoes = append(opcoes, jwt.WithAudience(cfgJWT.Audiencia))
+		}
+		a.jwtParser = jwt.NewParser(opcoes...)
+	}
+	return a, nil
+}
+
+// lerChavePublica lê a chave pública PEM (RSA, ECDSA ou Ed25519) que assina os tokens
+func lerChavePublica(caminho string) (any, error) {
+	data, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler autenticacao.jwt.chave_publica: %w", err)
+	}
+	bloco, _ := pem.Decode(data)
+	if bloco == nil {
+		return nil, fmt.Errorf("nenhum bloco PEM em '%s'", caminho)
+	}
+
+	var chave any
+	if bloco.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(bloco.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("certificado inválido em '%s': %w", caminho, err)
+		}
+		chave = cert.PublicKey
+	} else if chave, err = x509.ParsePKIXPublicKey(bloco.Bytes); err != nil {
+		return nil, fmt.Errorf("chave pública inválida em '%s': %w", caminho, err)
+	}
+
+	switch chave.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return chave, nil
+	default:
+		return nil, fmt.Errorf("tipo de chave pública não suportado em '%s' (use RSA, ECDSA ou Ed25519)", caminho)
+	}
+}
+
+// novoLimite cria o limitador de N requisições por minuto (0 = sem limite)
+func novoLimite(porMinuto int) *rate.Limiter {
+	if porMinuto <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(float64(porMinuto)/60), porMinuto)
+}
+
+// Falhas de autenticação, convertidas em HTTP 401/403/429 ou nos códigos gRPC equivalentes
+var (
+	errSemCredencial  = errors.New("credencial ausente: envie X-API-Key ou Authorization: Bearer")
+	errCredencialRuim = errors.New("credencial inválida")
+	errSemEscopo      = errors.New("credencial sem o escopo necessário")
+	errLimiteExcedido = errors.New("limite de requisições da credencial excedido")
+)
+
+// autenticar identifica a credencial e confere escopo e limite de requisições
+//
+// espera é o tempo até a próxima requisição ser aceita (apenas com errLimiteExcedido).
+func (a *autenticador) autenticar(credencial, escopo string) (id *identidade, espera time.Duration, err error) {
+	if credencial == "" {
+		return nil, 0, errSemCredencial
+	}
+
+	if strings.Count(credencial, ".") == 2 && a.jwtParser != nil {
+		id, err = a.identidadeJWT(credencial)
+	} else if id = a.chaves[sha256.Sum256([]byte(credencial))]; id == nil {
+		err = errCredencialRuim
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !id.pode(escopo) {
+		return id, 0, fmt.Errorf("%w '%s' (%s)", errSemEscopo, escopo, id.nome)
+	}
+	if id.limite != nil {
+		if r := id.limite.Reserve(); r.Delay() > 0 {
+			espera = r.Delay()
+			r.Cancel()
+			return id, espera, fmt.Errorf("%w (%s)", errLimiteExcedido, id.nome)
+		}
+	}
+	return id, 0, nil
+}
+
+// identidadeJWT valida o token e monta a identidade do subject
+func (a *autenticador) identidadeJWT(token string) (*identidade, error) {
+	var claims claimsJWT
+	if _, err := a.jwtParser.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) { return a.jwtChave, nil }); err != nil {
+		return nil, fmt.Errorf("%w: %v", errCredencialRuim, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token sem 'sub'", errCredencialRuim)
+	}
+
+	var limite *rate.Limiter
+	if a.limitesJWT != nil {
+		limite = a.limitesJWT.limiteDe(claims.Subject)
+	}
+
+	return &identidade{nome: "jwt:" + claims.Subject, escopos: strings.Fields(claims.Scope), limite: limite}, nil
+}
+
+// credencialHTTP extrai a chave de API ou o token da requisição
+func credencialHTTP(h http.Header) string {
+	if chave := h.Get(cabecalhoChaveAPI); chave != "" {
+		return chave
+	}
+	if token, ok := strings.CutPrefix(h.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// exigir protege o handler HTTP com o escopo; sem autenticador, o handler é devolvido intacto
+func (a *autenticador) exigir(escopo string, next http.HandlerFunc) http.HandlerFunc {
+	if a == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, espera, err := a.autenticar(credencialHTTP(r.Header), escopo)
+		switch {
+		case errors.Is(err, errSemEscopo):
+			responderJSON(w, http.StatusForbidden, respostaErro{Erro: err.Error()})
+		case errors.Is(err, errLimiteExcedido):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(espera.Seconds()))))
+			responderJSON(w, http.StatusTooManyRequests, respostaErro{Erro: err.Error()})
+		case err != nil:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="nfe-validator"`)
+			responderJSON(w, http.StatusUnauthorized, respostaErro{Erro: err.Error()})
+		default:
+			logDetalhe("🔑 %s %s por %s", r.Method, r.URL.Path, id.nome)
+			next(w, r.WithContext(context.WithValue(r.Context(), chaveIdentidade{}, id)))
+		}
+	}
+}
+
+// credencialGRPC extrai a chave de API ou o token dos metadados da chamada
+func credencialGRPC(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(strings.ToLower(cabecalhoChaveAPI)); len(v) > 0 && v[0] != "" {
+		return v[0]
+	}
+	if v := md.Get("authorization"); len(v) > 0 {
+		if token, ok := strings.CutPrefix(v[0], "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+// contextoGRPC autentica a chamada gRPC (escopo validar) e devolve o contexto com a identidade
+func (a *autenticador) contextoGRPC(ctx context.Context, metodo string) (context.Context, error) {
+	id, espera, err := a.autenticar(credencialGRPC(ctx), escopoValidar)
+	switch {
+	case errors.Is(err, errSemEscopo):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, errLimiteExcedido):
+		return nil, status.Errorf(codes.ResourceExhausted, "%v; tente de novo em %s", err, espera.Round(time.Second))
+	case err != nil:
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	logDetalhe("🔑 gRPC %s por %s", metodo, id.nome)
+	return context.WithValue(ctx, chaveIdentidade{}, id), nil
+}
+
+// opcoesGRPC são os interceptors de autenticação do servidor gRPC (nenhum sem autenticador)
+func (a *autenticador) opcoesGRPC() []grpc.ServerOption {
+	if a == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx, err := a.contextoGRPC(ctx, info.FullMethod)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := a.contextoGRPC(ss.Context(), info.FullMethod)
+			if err != nil {
+				return err
+			}
+			return handler(srv, &streamAutenticado{ServerStream: ss, ctx: ctx})
+		}),
+	}
+}
+
+// streamAutenticado entrega ao handler o contexto com a identidade
+type streamAutenticado struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context implementa grpc.ServerStream
+func (s *streamAutenticado) Context() context.Context {
+	return s.ctx
+}
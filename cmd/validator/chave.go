@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// resultadoChave é a saída JSON do subcomando "chave"
+type resultadoChave struct {
+	ChaveAcesso string                  `json:"chave_acesso"`
+	Valida      bool                    `json:"valida"`
+	Componentes *nfe.ChaveDecomposta    `json:"componentes,omitempty"`
+	Sefaz       *validation.SefazStatus `json:"sefaz,omitempty"`
+	Erro        string                  `json:"erro,omitempty"`
+	CodigoErro  nfe.CodigoErro          `json:"codigo_erro,omitempty"`
+}
+
+// runChave executa o subcomando "chave": valida e decompõe uma chave de acesso
+//
+// Confere o dígito verificador, separa UF, período, emitente, modelo, série
+// e número e, com -sefaz, consulta a situação da nota na SEFAZ.
+func runChave(args []string) int {
+	flags := flag.NewFlagSet("chave", flag.ExitOnError)
+	consultar := flags.Bool("sefaz", false, "Consultar também a situação da nota na SEFAZ (requer certificado)")
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s chave [opções] <44_digitos>\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Opções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator chave 35250732409620000175550010000037471011544648")
+		fmt.Fprintln(os.Stderr, "  ./validator chave -sefaz 35250732409620000175550010000037471011544648")
+	}
+	flags.Parse(args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return saidaErro
+	}
+
+	return consultarChave(validation.OnlyDigits(flags.Arg(0)), *consultar, configOpts)
+}
+
+// consultarChave decompõe a chave, consulta a SEFAZ se pedido e imprime o resultado
+func consultarChave(chave string, consultar bool, configOpts *opcoesConfig) int {
+	logInfo("🔑 Modo: Consulta por chave de acesso")
+	logInfo("Chave: %s", chave)
+
+	result, codigo := verificarChave(chave, consultar, func() (*sefaz.Client, error) {
+		arq, err := configOpts.carregar()
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := arq.carregarConfig()
+		if err != nil {
+			return nil, err
+		}
+		logInfo("Ambiente: %s (UF %s)", cfg.Env, cfg.UF)
+		return novoClienteSefaz(cfg)
+	})
+	printJSON(result)
+	return codigo
+}
+
+// verificarChave decompõe a chave e, se pedido, consulta a situação na SEFAZ
+//
+// cliente só é chamado quando consultar = true. Retorna o resultado e o
+// código de saída correspondente.
+func verificarChave(chave string, consultar bool, cliente func() (*sefaz.Client, error)) (resultadoChave, int) {
+	result := resultadoChave{ChaveAcesso: chave}
+
+	componentes, err := nfe.DecomporChave(chave)
+	result.Componentes = componentes
+	if err != nil {
+		result.Erro = fmt.Sprintf("Chave de acesso inválida: %v", err)
+		result.CodigoErro = nfe.CodigoDe(err)
+		return result, saidaParse
+	}
+	result.Valida = true
+	logInfo("   ✅ Chave válida: %s %s, modelo %s, série %s, nº %s",
+		componentes.UF, componentes.Periodo, componentes.Modelo, componentes.Serie, componentes.Numero)
+
+	if !consultar {
+		return result, saidaOK
+	}
+
+	client, err := cliente()
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha ao configurar cliente SEFAZ: %v", err)
+		result.CodigoErro = nfe.CodigoDe(err)
+		return result, saidaConectividade
+	}
+
+	logInfo("➡️ Consultando SEFAZ...")
+	status, err := client.ConsultaSituacaoNFe(chave)
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha na consulta: %v", err)
+		result.CodigoErro = nfe.CodigoDe(err)
+		return result, saidaConectividade
+	}
+
+	logInfo("✅ Status %s - %s", status.Codigo, status.Mensagem)
+	result.Sefaz = &status
+
+	if !status.Autorizado {
+		result.CodigoErro = nfe.CodigoSefazRejeitada
+		return result, saidaRejeitada
+	}
+	return result, saidaOK
+}
+
+// printJSON imprime qualquer valor como JSON indentado no stdout
+func printJSON(v any) {
+	jsonOutput, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		logErro("❌ Erro ao gerar JSON: %v", err)
+		os.Exit(saidaErro)
+	}
+	fmt.Println(string(jsonOutput))
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+)
+
+// Tipos de argumento posicional de um subcomando (o que completar depois das flags)
+const (
+	argsArquivos  = "arquivos"  // XMLs, pacotes e diretórios
+	argsDiretorio = "diretorio" // apenas diretórios
+	argsPalavras  = "palavras"  // valores fixos (ver comandoCompletion.palavras)
+	argsChave     = "chave"     // chave de acesso (sem sugestões)
+	argsNenhum    = "nenhum"    // sem argumentos posicionais
+)
+
+// comandoCompletion descreve um subcomando para os scripts de completion
+//
+// As flags comuns (-config e as de log) são acrescentadas automaticamente a
+// partir de registrarFlagConfig/registrarFlagsLog; ao criar uma flag nova em
+// um subcomando, inclua-a aqui também.
+type comandoCompletion struct {
+	nome      string
+	descricao string
+	flags     []string
+	args      string
+	palavras  []string
+	semConfig bool // o subcomando não aceita -config
+}
+
+// flagsValidacao são as flags das fases de validação (modo legado, validate e watch)
+var flagsValidacao = []string{"schema", "xsd", "skip-sefaz", "offline", "disable-rules", "output-version"}
+
+// flagsWebhookCompletion são as flags -webhook* (serve e watch)
+var flagsWebhookCompletion = []string{"webhook", "webhook-secret", "webhook-retries", "webhook-timeout"}
+
+// comandosCompletion lista os subcomandos na ordem em que aparecem na ajuda
+func comandosCompletion() []comandoCompletion {
+	return []comandoCompletion{
+		{nome: "validate", descricao: "Validação em lote (arquivos, pacotes, diretórios, globs)",
+			flags: append(flagsValidacao, "workers", "format", "o", "only-failures", "only-unauthorized", "status", "store", "archive", "archive-retention", "archive-endpoint", "dedup", "dedup-cache", "dedup-ttl", "alert"), args: argsArquivos},
+		{nome: "watch", descricao: "Valida os XMLs que chegam em uma pasta monitorada",
+			flags: append(append(flagsValidacao, "settle", "shutdown-timeout", "store", "archive", "archive-retention", "archive-endpoint", "dedup", "dedup-cache", "dedup-ttl", "alert"), flagsWebhookCompletion...), args: argsDiretorio},
+		{nome: "chave", descricao: "Valida e decompõe uma chave de acesso",
+			flags: []string{"sefaz"}, args: argsChave},
+		{nome: "danfe", descricao: "Gera o DANFE em PDF de uma nota autorizada",
+			flags: []string{"o", "layout"}, args: argsArquivos, semConfig: true},
+		{nome: "status", descricao: "Consulta a disponibilidade da SEFAZ por UF",
+			args: argsPalavras, palavras: append(sefaz.UFs(), "todas")},
+		{nome: "dist", descricao: "Distribuição DF-e (notas destinadas ao CNPJ)",
+			flags: []string{"dest", "nsu", "max-lotes", "workers", "faixa"}, args: argsPalavras, palavras: []string{"sync"}},
+		{nome: "manifestar", descricao: "Registra a manifestação do destinatário",
+			flags: []string{"tipo", "just"}, args: argsChave},
+		{nome: "ator-interessado", descricao: "Autoriza um transportador a baixar o XML da nota (evento 110150)",
+			flags: []string{"doc", "autor", "subcontratados", "seq"}, args: argsChave},
+		{nome: "entrega", descricao: "Registra ou cancela o comprovante de entrega (eventos 110130/110131)",
+			flags: []string{"doc", "nome", "imagem", "hash", "data", "gps", "seq", "cancelar"}, args: argsChave},
+		{nome: "prorrogacao", descricao: "Pede ou cancela a prorrogação do prazo de retorno da remessa para industrialização (eventos 111500 a 111503)",
+			flags: []string{"prot", "itens", "prazo", "seq", "cancelar", "schema", "resposta"}, args: argsChave},
+		{nome: "recibo", descricao: "Consulta o resultado de um lote enviado pelo número do recibo",
+			flags: []string{"uf", "dest"}, args: argsNenhum},
+		{nome: "serve", descricao: "Publica o validador como serviço gRPC",
+			flags: append([]string{"grpc", "http", "ready-sefaz", "ready-sefaz-cache", "ready-cert-days", "batch-ttl", "batch-max-pending", "batch-max-stored", "batch-url-allow", "s3-endpoint", "rate-limit", "max-concurrent", "max-body-mb", "sefaz-rate", "shutdown-timeout", "store", "archive", "archive-retention", "archive-endpoint", "dedup", "dedup-cache", "dedup-ttl", "alert", "schema", "xsd", "skip-sefaz", "offline", "disable-rules", "workers"}, flagsWebhookCompletion...), args: argsNenhum},
+		{nome: "kafka", descricao: "Valida os XMLs de um tópico Kafka e produz os resultados",
+			flags: append(flagsValidacao, "brokers", "topic", "group", "output-topic", "dlq-topic", "batch", "workers", "shutdown-timeout", "store", "archive", "archive-retention", "archive-endpoint", "dedup", "dedup-cache", "dedup-ttl", "alert"), args: argsNenhum},
+		{nome: "rabbitmq", descricao: "Valida os XMLs de uma fila RabbitMQ e publica os resultados",
+			flags: append(flagsValidacao, "url", "queue", "exchange", "routing-key", "prefetch", "workers", "shutdown-timeout", "store", "archive", "archive-retention", "archive-endpoint", "dedup", "dedup-cache", "dedup-ttl", "alert"), args: argsNenhum},
+		{nome: "sqs", descricao: "Valida os XMLs enviados ao S3 a partir dos eventos no SQS",
+			flags: append(flagsValidacao, "queue-url", "results-prefix", "results-bucket", "region", "endpoint", "wait", "workers", "shutdown-timeout", "store", "archive", "archive-retention", "archive-endpoint", "dedup", "dedup-cache", "dedup-ttl", "alert"), args: argsNenhum},
+		{nome: "recheck", descricao: "Reconsulta na SEFAZ as notas pendentes e as autorizações recentes do histórico",
+			flags: []string{"schedule", "window", "limit", "once", "shutdown-timeout", "store", "alert"}, args: argsNenhum},
+		{nome: "completion", descricao: "Gera o script de autocompletar do shell",
+			args: argsPalavras, palavras: shellsCompletion, semConfig: true},
+	}
+}
+
+// flagsLegado são as flags do modo sem subcomando (validator [opções] nota.xml)
+var flagsLegado = []string{"xsd", "skip-sefaz", "offline", "chave", "disable-rules", "output-version"}
+
+// valoresFlag são os valores sugeridos para flags com opções fixas
+func valoresFlag() map[string][]string {
+	return map[string][]string{
+		"autor":          nomesAutoresAtor(),
+		"dedup":          modosDedup,
+		"format":         formatosSaida,
+		"layout":         layoutsDanfe(),
+		"log-format":     {"text", "json"},
+		"output-version": versoesSaida,
+		"redact":         {redactLogs, redactAll},
+		"tipo":           tiposManifestacao(),
+		"uf":             sefaz.UFs(),
+	}
+}
+
+// Flags cujo valor é um arquivo ou um diretório
+var (
+	flagsArquivo   = []string{"schema", "config", "o", "imagem", "resposta"}
+	flagsDiretorio = []string{"dest"}
+)
+
+// shellsCompletion são os shells suportados por "completion"
+var shellsCompletion = []string{"bash", "zsh", "fish"}
+
+// runCompletion executa o subcomando "completion": imprime o script do shell no stdout
+func runCompletion(args []string) int {
+	flags := flag.NewFlagSet("completion", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s completion bash|zsh|fish\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Exemplos:")
+		fmt.Fprintln(os.Stderr, "  source <(./validator completion bash)")
+		fmt.Fprintln(os.Stderr, "  ./validator completion zsh > \"${fpath[1]}/_validator\"")
+		fmt.Fprintln(os.Stderr, "  ./validator completion fish > ~/.config/fish/completions/validator.fish")
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return saidaErro
+	}
+
+	switch flags.Arg(0) {
+	case "bash":
+		fmt.Print(completionBash())
+	case "zsh":
+		fmt.Print(completionZsh())
+	case "fish":
+		fmt.Print(completionFish())
+	default:
+		fmt.Fprintf(os.Stderr, "❌ shell '%s' não suportado (use %s)\n", flags.Arg(0), strings.Join(shellsCompletion, ", "))
+		return saidaErro
+	}
+	return saidaOK
+}
+
+// flagsComando retorna todas as flags do subcomando (próprias + comuns), em ordem alfabética
+func flagsComando(c comandoCompletion) []string {
+	comuns := flag.NewFlagSet(c.nome, flag.ContinueOnError)
+	if !c.semConfig {
+		registrarFlagConfig(comuns)
+	}
+	if c.nome != "completion" {
+		registrarFlagsLog(comuns)
+	}
+
+	nomes := append([]string{}, c.flags...)
+	comuns.VisitAll(func(f *flag.Flag) { nomes = append(nomes, f.Name) })
+	sort.Strings(nomes)
+	return nomes
+}
+
+// comTraco prefixa cada flag com "-"
+func comTraco(flags []string) []string {
+	out := make([]string, len(flags))
+	for i, f := range flags {
+		out[i] = "-" + f
+	}
+	return out
+}
+
+// completionBash gera o script para bash (source <(validator completion bash))
+func completionBash() string {
+	var b strings.Builder
+	comandos := comandosCompletion()
+
+	b.WriteString("# bash completion para o validator: source <(validator completion bash)\n")
+	b.WriteString("_validator() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    local sub=\"${COMP_WORDS[1]}\"\n\n")
+
+	// Valores das flags
+	b.WriteString("    case \"$prev\" in\n")
+	valores := valoresFlag()
+	for _, nome := range chavesOrdenadas(valores) {
+		fmt.Fprintf(&b, "        -%s|--%s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")); return ;;\n", nome, nome, strings.Join(valores[nome], " "))
+	}
+	fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", padraoCase(flagsArquivo))
+	fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -d -- \"$cur\")); return ;;\n", padraoCase(flagsDiretorio))
+	b.WriteString("    esac\n\n")
+
+	// Subcomandos na primeira posição (ou um XML, no modo legado)
+	nomes := make([]string, len(comandos))
+	for i, c := range comandos {
+		nomes[i] = c.nome
+	}
+	b.WriteString("    if [[ $COMP_CWORD -eq 1 && \"$cur\" != -* ]]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\") $(compgen -f -- \"$cur\"))\n", strings.Join(nomes, " "))
+	b.WriteString("        return\n    fi\n\n")
+
+	b.WriteString("    local flags palavras args=arquivos\n")
+	b.WriteString("    case \"$sub\" in\n")
+	for _, c := range comandos {
+		fmt.Fprintf(&b, "        %s) flags=\"%s\"; palavras=\"%s\"; args=%s ;;\n",
+			c.nome, strings.Join(comTraco(flagsComando(c)), " "), strings.Join(c.palavras, " "), c.args)
+	}
+	legado := comandoCompletion{nome: "legado", flags: flagsLegado}
+	fmt.Fprintf(&b, "        *) flags=\"%s\" ;;\n", strings.Join(comTraco(flagsComando(legado)), " "))
+	b.WriteString("    esac\n\n")
+
+	b.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+	b.WriteString("        COMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n        return\n    fi\n")
+	b.WriteString("    case \"$args\" in\n")
+	fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")) ;;\n", argsArquivos)
+	fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -d -- \"$cur\")) ;;\n", argsDiretorio)
+	fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W \"$palavras\" -- \"$cur\")) ;;\n", argsPalavras)
+	b.WriteString("    esac\n")
+	b.WriteString("}\n")
+	b.WriteString("complete -o filenames -F _validator validator\n")
+	return b.String()
+}
+
+// completionZsh gera o script para zsh (arquivo _validator no $fpath)
+func completionZsh() string {
+	var b strings.Builder
+	comandos := comandosCompletion()
+
+	b.WriteString("#compdef validator\n")
+	b.WriteString("# zsh completion para o validator: validator completion zsh > \"${fpath[1]}/_validator\"\n\n")
+	b.WriteString("_validator() {\n")
+	b.WriteString("    local -a subcomandos\n")
+	b.WriteString("    subcomandos=(\n")
+	for _, c := range comandos {
+		fmt.Fprintf(&b, "        '%s:%s'\n", c.nome, c.descricao)
+	}
+	b.WriteString("    )\n\n")
+
+	b.WriteString("    if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then\n")
+	b.WriteString("        _describe 'subcomando' subcomandos\n")
+	b.WriteString("        _files\n")
+	b.WriteString("        return\n    fi\n\n")
+
+	b.WriteString("    case $words[2] in\n")
+	for _, c := range comandos {
+		// O subcomando sai de $words para que o _arguments comece nas flags dele
+		fmt.Fprintf(&b, "        %s)\n            shift words; (( CURRENT-- ))\n            _arguments \\\n", c.nome)
+		for _, f := range flagsComando(c) {
+			fmt.Fprintf(&b, "                %s \\\n", especZsh(f))
+		}
+		fmt.Fprintf(&b, "                %s\n            ;;\n", argsZsh(c))
+	}
+	legado := comandoCompletion{nome: "legado", flags: flagsLegado}
+	b.WriteString("        *)\n            _arguments \\\n")
+	for _, f := range flagsComando(legado) {
+		fmt.Fprintf(&b, "                %s \\\n", especZsh(f))
+	}
+	b.WriteString("                '*:arquivo:_files'\n            ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("}\n\n")
+	b.WriteString("compdef _validator validator\n")
+	return b.String()
+}
+
+// especZsh monta a especificação _arguments de uma flag
+func especZsh(f string) string {
+	switch {
+	case contem(flagsArquivo, f):
+		return fmt.Sprintf("'-%s:arquivo:_files'", f)
+	case contem(flagsDiretorio, f):
+		return fmt.Sprintf("'-%s:diretório:_files -/'", f)
+	}
+	if v, ok := valoresFlag()[f]; ok {
+		return fmt.Sprintf("'-%s:valor:(%s)'", f, strings.Join(v, " "))
+	}
+	if flagComValor(f) {
+		return fmt.Sprintf("'-%s:valor:'", f)
+	}
+	return fmt.Sprintf("'-%s'", f)
+}
+
+// argsZsh monta a especificação _arguments dos argumentos posicionais
+func argsZsh(c comandoCompletion) string {
+	switch c.args {
+	case argsArquivos:
+		return "'*:arquivo:_files'"
+	case argsDiretorio:
+		return "'1:diretório:_files -/'"
+	case argsPalavras:
+		return fmt.Sprintf("'*:valor:(%s)'", strings.Join(c.palavras, " "))
+	case argsNenhum:
+		return ""
+	}
+	return "'1:chave de acesso:'"
+}
+
+// completionFish gera o script para fish (~/.config/fish/completions/validator.fish)
+func completionFish() string {
+	var b strings.Builder
+	comandos := comandosCompletion()
+
+	nomes := make([]string, len(comandos))
+	for i, c := range comandos {
+		nomes[i] = c.nome
+	}
+
+	b.WriteString("# fish completion para o validator: validator completion fish > ~/.config/fish/completions/validator.fish\n")
+	for _, c := range comandos {
+		fmt.Fprintf(&b, "complete -c validator -n '__fish_use_subcommand' -f -a %s -d '%s'\n", c.nome, c.descricao)
+	}
+
+	for _, c := range comandos {
+		cond := "__fish_seen_subcommand_from " + c.nome
+		for _, f := range flagsComando(c) {
+			fmt.Fprintf(&b, "complete -c validator -n '%s' -o %s%s\n", cond, f, opcoesFish(f))
+		}
+		switch c.args {
+		case argsArquivos:
+			fmt.Fprintf(&b, "complete -c validator -n '%s' -F\n", cond)
+		case argsDiretorio:
+			fmt.Fprintf(&b, "complete -c validator -n '%s' -f -a '(__fish_complete_directories)'\n", cond)
+		case argsPalavras:
+			fmt.Fprintf(&b, "complete -c validator -n '%s' -f -a '%s'\n", cond, strings.Join(c.palavras, " "))
+		}
+	}
+
+	legado := comandoCompletion{nome: "legado", flags: flagsLegado}
+	cond := "not __fish_seen_subcommand_from " + strings.Join(nomes, " ")
+	for _, f := range flagsComando(legado) {
+		fmt.Fprintf(&b, "complete -c validator -n '%s' -o %s%s\n", cond, f, opcoesFish(f))
+	}
+	return b.String()
+}
+
+// opcoesFish retorna as opções do "complete" do fish para o valor de uma flag
+func opcoesFish(f string) string {
+	switch {
+	case contem(flagsArquivo, f):
+		return " -r -F"
+	case contem(flagsDiretorio, f):
+		return " -r -f -a '(__fish_complete_directories)'"
+	}
+	if v, ok := valoresFlag()[f]; ok {
+		return fmt.Sprintf(" -r -f -a '%s'", strings.Join(v, " "))
+	}
+	if flagComValor(f) {
+		return " -r"
+	}
+	return ""
+}
+
+// flagComValor indica se a flag espera um valor (as booleanas não esperam)
+func flagComValor(f string) bool {
+	switch f {
+	case "xsd", "skip-sefaz", "offline", "only-failures", "only-unauthorized", "sefaz", "quiet", "v", "vv":
+		return false
+	}
+	return true
+}
+
+// padraoCase monta o padrão de um case do bash para as flags ("-o|--o|-schema|--schema")
+func padraoCase(flags []string) string {
+	var partes []string
+	for _, f := range flags {
+		partes = append(partes, "-"+f, "--"+f)
+	}
+	return strings.Join(partes, "|")
+}
+
+// chavesOrdenadas retorna as chaves do mapa em ordem alfabética (script estável entre execuções)
+func chavesOrdenadas(m map[string][]string) []string {
+	chaves := make([]string, 0, len(m))
+	for k := range m {
+		chaves = append(chaves, k)
+	}
+	sort.Strings(chaves)
+	return chaves
+}
+
+// contem indica se s está na lista
+func contem(lista []string, s string) bool {
+	for _, item := range lista {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
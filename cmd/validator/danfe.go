@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fabyo/go-nfe-validator/pkg/danfe"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// runDanfe executa o subcomando "danfe": gera o PDF do DANFE de um procNFe autorizado
+//
+// Sem -o, o PDF é gravado ao lado do XML com a extensão .pdf. -layout
+// escolhe entre o DANFE completo, o simplificado e a etiqueta.
+func runDanfe(args []string) int {
+	flags := flag.NewFlagSet("danfe", flag.ExitOnError)
+	saida := flags.String("o", "", "Arquivo PDF de saída (padrão: o nome do XML com extensão .pdf)")
+	nomeLayout := flags.String("layout", string(danfe.LayoutCompleto), "Layout do DANFE: "+strings.Join(layoutsDanfe(), ", "))
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s danfe [opções] <procNFe.xml>\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Opções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator danfe nota.xml -o nota.pdf")
+		fmt.Fprintln(os.Stderr, "  ./validator danfe nota.xml   # grava nota.pdf")
+		fmt.Fprintln(os.Stderr, "  ./validator danfe -layout etiqueta nota.xml -o etiqueta.pdf")
+	}
+	posicionais := parseIntercalado(flags, args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+
+	if len(posicionais) != 1 {
+		flags.Usage()
+		return saidaErro
+	}
+	xmlPath := posicionais[0]
+
+	layout, err := danfe.ParseLayout(*nomeLayout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+
+	destino := *saida
+	if destino == "" {
+		destino = strings.TrimSuffix(xmlPath, filepath.Ext(xmlPath)) + ".pdf"
+	}
+
+	logInfo("🖨️ Modo: DANFE")
+	logInfo("XML: %s", xmlPath)
+	logInfo("Layout: %s", layout)
+
+	xmlData, err := os.ReadFile(xmlPath)
+	if err != nil {
+		logErro("❌ Erro ao ler XML: %v", err)
+		return saidaErro
+	}
+
+	proc, err := nfe.ParseProcNFe(xmlData)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaParse
+	}
+
+	arquivo, err := criarArquivoAtomico(destino)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+
+	if err := danfe.Gerar(arquivo, proc, danfe.WithLayout(layout)); err != nil {
+		arquivo.Descartar()
+		logErro("❌ %v", err)
+		if errors.Is(err, danfe.ErrNaoAutorizada) {
+			return saidaRejeitada
+		}
+		return saidaErro
+	}
+
+	if err := arquivo.Confirmar(); err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+
+	logInfo("✅ DANFE gerado: %s", destino)
+	return saidaOK
+}
+
+// layoutsDanfe são os nomes aceitos por -layout
+func layoutsDanfe() []string {
+	nomes := make([]string, len(danfe.Layouts))
+	for i, l := range danfe.Layouts {
+		nomes[i] = string(l)
+	}
+	return nomes
+}
+
+// parseIntercalado faz o parse das flags aceitando-as depois dos argumentos posicionais
+//
+// O pacote flag para no primeiro argumento que não é flag; assim
+// "danfe nota.xml -o nota.pdf" funciona igual a "danfe -o nota.pdf nota.xml".
+// Retorna os argumentos posicionais na ordem em que apareceram.
+func parseIntercalado(flags *flag.FlagSet, args []string) []string {
+	var posicionais []string
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return posicionais
+		}
+		posicionais = append(posicionais, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/internal/dedup"
+	"github.com/fabyo/go-nfe-validator/internal/store"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// Modos de -dedup
+const (
+	dedupDesligado = "off"
+	dedupMarcar    = "flag" // valida normalmente e marca o resultado como duplicata
+	dedupPular     = "skip" // reaproveita a consulta SEFAZ da validação anterior
+)
+
+// modosDedup são os valores aceitos em -dedup
+var modosDedup = []string{dedupDesligado, dedupMarcar, dedupPular}
+
+// Valores de -dedup-cache além das URLs redis:// e rediss://
+const (
+	cacheDedupMemoria = "memory"
+	cacheDedupStore   = "store"
+)
+
+// envCacheDedup é a variável de ambiente com o -dedup-cache (evita a senha do Redis no "ps")
+const envCacheDedup = "NFE_DEDUP_CACHE"
+
+// flagsDedup são as flags -dedup* dos modos que validam XMLs
+type flagsDedup struct {
+	modo  *string
+	cache *string
+	ttl   *time.Duration
+}
+
+// registrarFlagsDedup adiciona as flags de detecção de duplicatas ao FlagSet
+func registrarFlagsDedup(flags *flag.FlagSet) *flagsDedup {
+	return &flagsDedup{
+		modo:  flags.String("dedup", dedupDesligado, "Chave já validada dentro de -dedup-ttl: off, flag (marca o resultado como duplicata) ou skip (reaproveita a consulta SEFAZ anterior)"),
+		cache: flags.String("dedup-cache", "", "Onde procurar as chaves já validadas: memory, store (o banco de -store) ou redis://host:6379/0 (padrão: $"+envCacheDedup+"; store com -store, senão memory)"),
+		ttl:   flags.Duration("dedup-ttl", 24*time.Hour, "Por quanto tempo uma chave validada conta como duplicata"),
+	}
+}
+
+// deduplicador detecta as chaves já validadas (-dedup)
+type deduplicador struct {
+	modo  string
+	cache dedup.Cache
+}
+
+// duplicata marca o resultado de uma chave já validada dentro de -dedup-ttl
+type duplicata struct {
+	// ValidadoEm e Origem são da validação anterior
+	ValidadoEm time.Time `json:"validado_em"`
+	Origem     string    `json:"origem,omitempty"`
+
+	// SefazReaproveitada indica que a situação SEFAZ veio da validação anterior (-dedup skip)
+	SefazReaproveitada bool `json:"sefaz_reaproveitada"`
+}
+
+// criar monta o deduplicador; retorna nil com -dedup off
+//
+// O fechar devolvido libera a conexão com o Redis (não faz nada nos demais caches).
+func (f *flagsDedup) criar(historico store.ResultStore, arq *arquivoConfig) (d *deduplicador, fechar func(), err error) {
+	fechar = func() {}
+	switch *f.modo {
+	case dedupDesligado:
+		return nil, fechar, nil
+	case dedupMarcar, dedupPular:
+	default:
+		return nil, fechar, fmt.Errorf("-dedup inválido '%s' (use %s)", *f.modo, strings.Join(modosDedup, ", "))
+	}
+	if *f.ttl <= 0 {
+		return nil, fechar, fmt.Errorf("-dedup-ttl deve ser positivo (recebido %s)", *f.ttl)
+	}
+
+	cache := *f.cache
+	preencher(&cache, arq.getenv(envCacheDedup))
+	if cache == "" {
+		cache = cacheDedupMemoria
+		if historico != nil {
+			cache = cacheDedupStore
+		}
+	}
+
+	d = &deduplicador{modo: *f.modo}
+	descricao := cache
+	switch {
+	case cache == cacheDedupMemoria:
+		d.cache = dedup.NewMemoria(*f.ttl)
+	case cache == cacheDedupStore:
+		if historico == nil {
+			return nil, fechar, fmt.Errorf("-dedup-cache store exige o histórico (-store)")
+		}
+		d.cache = dedup.NewStore(historico, *f.ttl)
+	case strings.HasPrefix(cache, "redis://"), strings.HasPrefix(cache, "rediss://"):
+		r, err := dedup.NewRedis(cache, *f.ttl)
+		if err != nil {
+			return nil, fechar, err
+		}
+		d.cache, fechar = r, func() { r.Close() }
+		descricao = store.OcultarSenha(cache)
+	default:
+		return nil, fechar, fmt.Errorf("-dedup-cache inválido '%s' (use memory, store ou redis://...)", store.OcultarSenha(cache))
+	}
+
+	logInfo("🔁 Duplicatas: %s (cache %s, TTL %s)", d.modo, descricao, *f.ttl)
+	return d, fechar, nil
+}
+
+// buscarDuplicata procura a validação anterior da chave; nil = chave nova (ou -dedup off)
+func (o *opcoesValidacao) buscarDuplicata(ctx context.Context, chave string) *dedup.Anterior {
+	if o.dedup == nil || chave == "" {
+		return nil
+	}
+
+	anterior, ok, err := o.dedup.cache.Buscar(ctx, chave)
+	if err != nil {
+		logAviso("⚠️ Duplicatas: %v", err)
+		return nil
+	}
+	// Falha de conectividade não conta: a nota ainda não foi de fato validada
+	if !ok || anterior.CodigoSaida == saidaConectividade {
+		return nil
+	}
+	return &anterior
+}
+
+// reaproveitarSefaz indica se a consulta SEFAZ da validação anterior substitui uma nova (-dedup skip)
+func (o *opcoesValidacao) reaproveitarSefaz(anterior *dedup.Anterior) bool {
+	return anterior != nil && o.dedup.modo == dedupPular && anterior.Sefaz.Consulta == validation.ConsultaRealizada
+}
+
+// guardarChave registra a primeira validação da chave no cache de duplicatas
+//
+// Duplicatas não são guardadas (o TTL conta da primeira validação), nem as
+// validações sem chave, com falha de conectividade ou cortadas pelo prazo
+// de encerramento.
+func (o *opcoesValidacao) guardarChave(ctx context.Context, r resultado) {
+	if o.dedup == nil || r.Duplicata != nil || r.ChaveAcesso == "" || conferirPrazo(o.contexto()) != nil {
+		return
+	}
+	codigo := r.codigoSaida()
+	if codigo == saidaConectividade {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tempoGravacao)
+	defer cancel()
+
+	err := o.dedup.cache.Guardar(ctx, r.ChaveAcesso, dedup.Anterior{
+		ValidadoEm:  time.Now(),
+		Origem:      o.origem,
+		CodigoSaida: codigo,
+		Sefaz:       r.Sefaz,
+	})
+	if err != nil {
+		logAviso("⚠️ Duplicatas: %v", err)
+	}
+}
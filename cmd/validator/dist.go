@@ -0,0 +1,279 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+)
+
+// arquivoUltNSU guarda no diretório de destino o último NSU sincronizado (checkpoint)
+const arquivoUltNSU = ".ultnsu"
+
+// nsuInicial é o NSU de uma primeira sincronização (todos os documentos disponíveis)
+const nsuInicial = "000000000000000"
+
+// chNFeRegex encontra a chave de acesso em resNFe, procNFe e procEventoNFe
+var chNFeRegex = regexp.MustCompile(`<chNFe>(\d{44})</chNFe>`)
+
+// relatorioDist é a saída JSON do subcomando "dist sync"
+type relatorioDist struct {
+	Destino    string              `json:"destino"`
+	NSUInicial string              `json:"nsu_inicial"`
+	NSUFinal   string              `json:"nsu_final"`
+	MaxNSU     string              `json:"max_nsu"`
+	Lotes      int                 `json:"lotes"`
+	Total      int                 `json:"total_documentos"`
+	PorTipo    map[string]int      `json:"por_tipo"`
+	Codigo     string              `json:"codigo"`
+	Mensagem   string              `json:"mensagem"`
+	Documentos []documentoRecebido `json:"documentos"`
+	Erro       string              `json:"erro,omitempty"`
+}
+
+// documentoRecebido identifica um documento gravado pela sincronização
+type documentoRecebido struct {
+	NSU     string `json:"nsu"`
+	Tipo    string `json:"tipo"`
+	Chave   string `json:"chave_acesso,omitempty"`
+	Arquivo string `json:"arquivo"`
+}
+
+// runDist executa o subcomando "dist" (Distribuição DF-e)
+func runDist(args []string) int {
+	if len(args) == 0 || args[0] != "sync" {
+		fmt.Fprintf(os.Stderr, "Uso: %s dist sync [opções] -dest <diretório>\n", os.Args[0])
+		return saidaErro
+	}
+	return runDistSync(args[1:])
+}
+
+// runDistSync baixa os documentos destinados ao CNPJ a partir do último NSU salvo em -dest
+//
+// Cada documento é gravado já descompactado como <NSU>-<tipo>.xml e o
+// checkpoint (.ultnsu) é atualizado a cada lote, então uma sincronização
+// interrompida continua de onde parou. Com -workers, o checkpoint só avança
+// até onde todos os NSUs anteriores já estão gravados.
+func runDistSync(args []string) int {
+	flags := flag.NewFlagSet("dist sync", flag.ExitOnError)
+	dest := flags.String("dest", "", "Diretório onde os XMLs e o checkpoint (.ultnsu) são gravados (obrigatório)")
+	nsu := flags.String("nsu", "", "NSU inicial (padrão: o checkpoint do destino; 0 baixa tudo que estiver disponível)")
+	maxLotes := flags.Int("max-lotes", 0, "Número máximo de consultas nesta execução (0 = até alcançar o maxNSU)")
+	workers := flags.Int("workers", 1, "Faixas de NSU baixadas em paralelo depois do primeiro lote (1 = sequencial; a SEFAZ pode responder 656 a consultas demais)")
+	faixa := flags.Int("faixa", faixaNSUPadrao, "NSUs por faixa no download paralelo (-workers maior que 1)")
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s dist sync [opções] -dest <diretório>\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Opções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator dist sync -dest ./recebidas")
+		fmt.Fprintln(os.Stderr, "  ./validator dist sync -dest ./recebidas -nsu 0   # baixar tudo de novo")
+		fmt.Fprintln(os.Stderr, "  ./validator dist sync -dest ./recebidas -workers 4   # carga inicial de um CNPJ com muitas notas")
+	}
+	flags.Parse(args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+
+	if *dest == "" || flags.NArg() > 0 || *workers < 1 || *faixa < 1 {
+		flags.Usage()
+		return saidaErro
+	}
+	if err := os.MkdirAll(*dest, 0o755); err != nil {
+		logErro("❌ Erro ao criar diretório de destino: %v", err)
+		return saidaErro
+	}
+
+	ultNSU := *nsu
+	if ultNSU == "" {
+		var err error
+		if ultNSU, err = lerUltNSU(*dest); err != nil {
+			logErro("❌ %v", err)
+			return saidaErro
+		}
+	}
+	ultNSU = formatarNSU(ultNSU)
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	cfg, err := arq.carregarConfig()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+
+	logInfo("📥 Modo: Distribuição DF-e")
+	logInfo("Ambiente: %s | CNPJ: %s | Destino: %s | ultNSU: %s", cfg.Env, cfg.CNPJ, *dest, ultNSU)
+
+	client, err := sefaz.NewClient(*cfg)
+	if err != nil {
+		logErro("❌ Falha ao configurar cliente SEFAZ: %v", err)
+		return saidaConectividade
+	}
+
+	rel := relatorioDist{
+		Destino:    *dest,
+		NSUInicial: ultNSU,
+		NSUFinal:   ultNSU,
+		PorTipo:    map[string]int{},
+		Documentos: []documentoRecebido{},
+	}
+	codigo := sincronizar(client, *dest, *maxLotes, *workers, *faixa, &rel)
+
+	logInfo("📊 %d documento(s) em %d lote(s) | NSU %s → %s (máx. %s)", rel.Total, rel.Lotes, rel.NSUInicial, rel.NSUFinal, valorOuTraco(rel.MaxNSU))
+	printJSON(rel)
+	return codigo
+}
+
+// sincronizar consulta lote a lote até alcançar o maxNSU, gravando documentos e checkpoint
+//
+// Com workers > 1, o primeiro lote (que informa o maxNSU) é consultado
+// sozinho e o restante é baixado em faixas paralelas (ver sincronizarFaixas).
+func sincronizar(client *sefaz.Client, dest string, maxLotes, workers, faixa int, rel *relatorioDist) int {
+	for maxLotes <= 0 || rel.Lotes < maxLotes {
+		if codigo, fim := consultarLote(client, dest, rel); fim {
+			return codigo
+		}
+		if workers > 1 {
+			return sincronizarFaixas(client, dest, maxLotes, workers, faixa, rel)
+		}
+	}
+
+	logAviso("⚠️ Limite de %d lote(s) atingido; execute novamente para continuar do NSU %s", maxLotes, rel.NSUFinal)
+	return saidaOK
+}
+
+// consultarLote consulta um lote a partir do checkpoint, grava os documentos e avança o checkpoint
+//
+// fim indica que a sincronização terminou: maxNSU alcançado, nenhum documento
+// novo ou falha (codigo diferente de saidaOK).
+func consultarLote(client *sefaz.Client, dest string, rel *relatorioDist) (codigo int, fim bool) {
+	logDetalhe("➡️ Consultando distribuição a partir do NSU %s", rel.NSUFinal)
+	ret, err := client.DistribuicaoDFe(rel.NSUFinal)
+	if err != nil {
+		rel.Erro = fmt.Sprintf("Falha na consulta: %v", err)
+		logErro("❌ %s", rel.Erro)
+		return saidaConectividade, true
+	}
+	rel.Lotes++
+	rel.Codigo, rel.Mensagem, rel.MaxNSU = ret.Codigo, ret.Mensagem, ret.MaxNSU
+
+	switch ret.Codigo {
+	case sefaz.CStatNenhumDocumento:
+		logInfo("✅ %s - %s", ret.Codigo, ret.Mensagem)
+		return saidaOK, true
+	case sefaz.CStatDocumentoLocalizado:
+	default:
+		rel.Erro = fmt.Sprintf("SEFAZ recusou a consulta: %s - %s", ret.Codigo, ret.Mensagem)
+		logErro("❌ %s", rel.Erro)
+		return saidaRejeitada, true
+	}
+
+	for _, doc := range ret.Documentos {
+		recebido, err := gravarDocumento(dest, doc)
+		if err != nil {
+			rel.Erro = err.Error()
+			logErro("❌ %v", err)
+			return saidaErro, true
+		}
+		rel.registrar(recebido)
+	}
+
+	// O checkpoint só avança depois que todos os documentos do lote foram gravados
+	rel.NSUFinal = formatarNSU(ret.UltNSU)
+	if err := gravarUltNSU(dest, rel.NSUFinal); err != nil {
+		rel.Erro = err.Error()
+		logErro("❌ %v", err)
+		return saidaErro, true
+	}
+	logInfo("   📦 Lote %d: %d documento(s), ultNSU %s de %s", rel.Lotes, len(ret.Documentos), rel.NSUFinal, ret.MaxNSU)
+
+	return saidaOK, compararNSU(rel.NSUFinal, ret.MaxNSU) >= 0
+}
+
+// registrar soma um documento gravado ao relatório
+func (rel *relatorioDist) registrar(recebido documentoRecebido) {
+	rel.Documentos = append(rel.Documentos, recebido)
+	rel.PorTipo[recebido.Tipo]++
+	rel.Total++
+}
+
+// gravarDocumento grava o XML do documento no destino como <NSU>-<tipo>.xml
+func gravarDocumento(dest string, doc sefaz.DocumentoDFe) (documentoRecebido, error) {
+	recebido := documentoRecebido{NSU: formatarNSU(doc.NSU), Tipo: doc.Tipo()}
+	if m := chNFeRegex.FindSubmatch(doc.XML); m != nil {
+		recebido.Chave = string(m[1])
+	}
+	recebido.Arquivo = filepath.Join(dest, recebido.NSU+"-"+recebido.Tipo+".xml")
+
+	arquivo, err := criarArquivoAtomico(recebido.Arquivo)
+	if err != nil {
+		return recebido, err
+	}
+	if _, err := arquivo.Write(doc.XML); err != nil {
+		arquivo.Descartar()
+		return recebido, fmt.Errorf("erro ao gravar documento NSU %s: %w", doc.NSU, err)
+	}
+	return recebido, arquivo.Confirmar()
+}
+
+// lerUltNSU lê o checkpoint do destino (NSU inicial se ainda não existir)
+func lerUltNSU(dest string) (string, error) {
+	conteudo, err := os.ReadFile(filepath.Join(dest, arquivoUltNSU))
+	if errors.Is(err, os.ErrNotExist) {
+		return nsuInicial, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao ler checkpoint de NSU: %w", err)
+	}
+	return strings.TrimSpace(string(conteudo)), nil
+}
+
+// gravarUltNSU atualiza o checkpoint do destino de forma atômica
+func gravarUltNSU(dest, nsu string) error {
+	arquivo, err := criarArquivoAtomico(filepath.Join(dest, arquivoUltNSU))
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(arquivo, nsu); err != nil {
+		arquivo.Descartar()
+		return fmt.Errorf("erro ao gravar checkpoint de NSU: %w", err)
+	}
+	return arquivo.Confirmar()
+}
+
+// formatarNSU completa o NSU com zeros à esquerda (15 dígitos, como exige o distDFeInt)
+func formatarNSU(nsu string) string {
+	nsu = strings.TrimSpace(nsu)
+	if len(nsu) >= len(nsuInicial) {
+		return nsu
+	}
+	return strings.Repeat("0", len(nsuInicial)-len(nsu)) + nsu
+}
+
+// compararNSU compara dois NSUs numericamente (-1, 0 ou 1)
+func compararNSU(a, b string) int {
+	na, _ := strconv.ParseUint(strings.TrimSpace(a), 10, 64)
+	nb, _ := strconv.ParseUint(strings.TrimSpace(b), 10, 64)
+	switch {
+	case na < nb:
+		return -1
+	case na > nb:
+		return 1
+	}
+	return 0
+}
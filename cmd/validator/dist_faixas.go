@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+)
+
+// faixaNSUPadrao é o tamanho padrão de cada faixa do download paralelo (10 lotes de 50 documentos)
+const faixaNSUPadrao = 500
+
+// faixaNSU é um intervalo de NSUs (inicio, fim] baixado por um worker de sincronizarFaixas
+type faixaNSU struct {
+	inicio    uint64
+	fim       uint64
+	cursor    uint64 // Último NSU até o qual os documentos da faixa estão gravados
+	concluida bool
+}
+
+// loteFaixa é uma consulta feita por um worker, entregue ao coordenador de sincronizarFaixas
+type loteFaixa struct {
+	faixa      int
+	ret        sefaz.RetornoDistribuicao
+	documentos []documentoRecebido
+	cursor     uint64
+	concluida  bool
+	codigo     int    // Código de saída da falha (com erro preenchido)
+	erro       string // Falha da consulta ou da gravação
+}
+
+// sincronizarFaixas baixa do checkpoint até o maxNSU dividindo o intervalo em faixas consultadas em paralelo
+//
+// Cada worker percorre uma faixa com o distNSU, como a sincronização
+// sequencial, e grava os documentos dela (a descompactação também fica no
+// worker). O checkpoint avança na ordem dos NSUs: só até onde todas as
+// faixas anteriores estão gravadas, então uma execução interrompida retoma
+// sem perder documentos (os já gravados depois do checkpoint são regravados).
+func sincronizarFaixas(client *sefaz.Client, dest string, maxLotes, workers, tamanho int, rel *relatorioDist) int {
+	inicio, _ := strconv.ParseUint(rel.NSUFinal, 10, 64)
+	maxNSU, _ := strconv.ParseUint(strings.TrimSpace(rel.MaxNSU), 10, 64)
+	faixas := dividirFaixas(inicio, maxNSU, uint64(tamanho))
+	workers = min(workers, len(faixas))
+	logInfo("⚡ %d faixa(s) de até %d NSU com %d worker(s): NSU %s → %s", len(faixas), tamanho, workers, rel.NSUFinal, formatarNSU(rel.MaxNSU))
+
+	pendentes := make(chan int, len(faixas))
+	for i := range faixas {
+		pendentes <- i
+	}
+	close(pendentes)
+
+	var (
+		wg        sync.WaitGroup
+		parar     atomic.Bool  // Uma falha interrompe as faixas seguintes
+		consultas atomic.Int64 // Consultas feitas ou reservadas, para -max-lotes
+	)
+	consultas.Store(int64(rel.Lotes))
+	lotes := make(chan loteFaixa)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range pendentes {
+				baixarFaixa(client, dest, i, faixas[i], func() bool {
+					return !parar.Load() && (maxLotes <= 0 || consultas.Add(1) <= int64(maxLotes))
+				}, lotes)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(lotes)
+	}()
+
+	// Coordenador: relatório e checkpoint ficam numa única goroutine
+	codigo := saidaOK
+	for lote := range lotes {
+		if lote.erro != "" {
+			if codigo == saidaOK {
+				codigo, rel.Erro = lote.codigo, lote.erro
+				logErro("❌ %s", lote.erro)
+				parar.Store(true)
+			}
+			continue
+		}
+		rel.Lotes++
+		rel.Codigo, rel.Mensagem = lote.ret.Codigo, lote.ret.Mensagem
+		if compararNSU(lote.ret.MaxNSU, rel.MaxNSU) > 0 {
+			rel.MaxNSU = lote.ret.MaxNSU
+		}
+		for _, recebido := range lote.documentos {
+			rel.registrar(recebido)
+		}
+		faixas[lote.faixa].cursor, faixas[lote.faixa].concluida = lote.cursor, lote.concluida
+		logDetalhe("   📦 Faixa %d: %d documento(s), NSU %d de %d", lote.faixa+1, len(lote.documentos), lote.cursor, faixas[lote.faixa].fim)
+
+		if nsu := formatarNSU(strconv.FormatUint(checkpointFaixas(faixas), 10)); nsu != rel.NSUFinal {
+			if err := gravarUltNSU(dest, nsu); err != nil {
+				if codigo == saidaOK {
+					codigo, rel.Erro = saidaErro, err.Error()
+					logErro("❌ %v", err)
+					parar.Store(true)
+				}
+				continue
+			}
+			rel.NSUFinal = nsu
+		}
+	}
+
+	// Os lotes chegam fora de ordem: o relatório lista os documentos por NSU
+	slices.SortFunc(rel.Documentos, func(a, b documentoRecebido) int { return compararNSU(a.NSU, b.NSU) })
+
+	if codigo == saidaOK && compararNSU(rel.NSUFinal, strconv.FormatUint(maxNSU, 10)) < 0 {
+		logAviso("⚠️ Limite de %d lote(s) atingido; execute novamente para continuar do NSU %s", maxLotes, rel.NSUFinal)
+	}
+	return codigo
+}
+
+// baixarFaixa consulta a faixa lote a lote, gravando os documentos dela, e entrega cada lote ao coordenador
+//
+// consultar reserva a próxima consulta: false quando a sincronização foi
+// interrompida ou o -max-lotes acabou. Documentos além do fim da faixa
+// pertencem à seguinte e são ignorados.
+func baixarFaixa(client *sefaz.Client, dest string, indice int, f faixaNSU, consultar func() bool, lotes chan<- loteFaixa) {
+	cursor := f.inicio
+	for consultar() {
+		ret, err := client.DistribuicaoDFe(formatarNSU(strconv.FormatUint(cursor, 10)))
+		lote := loteFaixa{faixa: indice, ret: ret}
+		switch {
+		case err != nil:
+			lote.codigo, lote.erro = saidaConectividade, fmt.Sprintf("Falha na consulta: %v", err)
+		case ret.Codigo == sefaz.CStatNenhumDocumento:
+			lote.cursor, lote.concluida = f.fim, true
+		case ret.Codigo != sefaz.CStatDocumentoLocalizado:
+			lote.codigo, lote.erro = saidaRejeitada, fmt.Sprintf("SEFAZ recusou a consulta: %s - %s", ret.Codigo, ret.Mensagem)
+		default:
+			lote.cursor, lote.concluida, lote.erro = avancarFaixa(f, cursor, ret)
+			if lote.erro != "" {
+				lote.codigo = saidaRejeitada
+				break
+			}
+			for _, doc := range ret.Documentos {
+				if nsu, _ := strconv.ParseUint(strings.TrimSpace(doc.NSU), 10, 64); nsu <= cursor || nsu > f.fim {
+					continue
+				}
+				recebido, err := gravarDocumento(dest, doc)
+				if err != nil {
+					lote.codigo, lote.erro = saidaErro, err.Error()
+					break
+				}
+				lote.documentos = append(lote.documentos, recebido)
+			}
+		}
+
+		lotes <- lote
+		if lote.erro != "" || lote.concluida {
+			return
+		}
+		cursor = lote.cursor
+	}
+}
+
+// avancarFaixa calcula até onde a faixa foi coberta pelo lote e se ela terminou
+func avancarFaixa(f faixaNSU, cursor uint64, ret sefaz.RetornoDistribuicao) (uint64, bool, string) {
+	ult, err := strconv.ParseUint(strings.TrimSpace(ret.UltNSU), 10, 64)
+	if err != nil || ult <= cursor {
+		// Sem avanço, a faixa seria consultada para sempre
+		return cursor, false, fmt.Sprintf("SEFAZ devolveu ultNSU %q sem avançar do NSU %d", ret.UltNSU, cursor)
+	}
+	if ult >= f.fim || compararNSU(ret.UltNSU, ret.MaxNSU) >= 0 {
+		return f.fim, true, ""
+	}
+	return ult, false, ""
+}
+
+// dividirFaixas divide os NSUs (inicio, fim] em faixas de até tamanho NSUs
+func dividirFaixas(inicio, fim, tamanho uint64) []faixaNSU {
+	var faixas []faixaNSU
+	for a := inicio; a < fim; a += tamanho {
+		faixas = append(faixas, faixaNSU{inicio: a, fim: min(a+tamanho, fim), cursor: a})
+	}
+	return faixas
+}
+
+// checkpointFaixas devolve o maior NSU até o qual todos os documentos anteriores estão gravados
+func checkpointFaixas(faixas []faixaNSU) uint64 {
+	nsu := faixas[0].inicio
+	for _, f := range faixas {
+		nsu = max(nsu, f.cursor)
+		if !f.concluida {
+			break
+		}
+	}
+	return nsu
+}
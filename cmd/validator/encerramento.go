@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// prazoEncerramentoPadrao é o padrão de -shutdown-timeout: abaixo dos 30s que o
+// Kubernetes espera (terminationGracePeriodSeconds) antes do SIGKILL
+const prazoEncerramentoPadrao = 25 * time.Second
+
+// errPrazoEncerramento indica uma validação interrompida pelo fim do prazo de encerramento
+//
+// O resultado dela é descartado: a mensagem ou o arquivo fica para a próxima
+// execução, em vez de sair como falha de conectividade.
+var errPrazoEncerramento = errors.New("prazo de encerramento esgotado")
+
+// registrarFlagEncerramento adiciona -shutdown-timeout aos modos de longa duração (serve, watch, kafka, rabbitmq, sqs)
+func registrarFlagEncerramento(flags *flag.FlagSet) *time.Duration {
+	return flags.Duration("shutdown-timeout", prazoEncerramentoPadrao, "Prazo para concluir as validações em andamento e esvaziar as saídas após SIGTERM/Ctrl+C")
+}
+
+// contextoSinais é cancelado no primeiro SIGINT/SIGTERM
+//
+// Depois do primeiro sinal os sinais voltam ao comportamento padrão: um
+// segundo Ctrl+C encerra o processo na hora, sem esperar o prazo.
+func contextoSinais() (context.Context, context.CancelFunc) {
+	ctx, parar := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, parar)
+	return ctx, parar
+}
+
+// contextoTrabalho é o contexto das validações: cancelado prazo depois do sinal
+//
+// Até lá as validações em andamento terminam normalmente; depois, as
+// consultas à SEFAZ em andamento são canceladas e o modo descarta os
+// resultados interrompidos (ver errPrazoEncerramento).
+func contextoTrabalho(sinal context.Context, prazo time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancelar := context.WithCancel(context.Background())
+	context.AfterFunc(sinal, func() {
+		time.AfterFunc(prazo, cancelar)
+	})
+	return ctx, cancelar
+}
+
+// conferirPrazo retorna errPrazoEncerramento se a validação feita com ctx foi cortada pelo prazo
+func conferirPrazo(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return errPrazoEncerramento
+	}
+	return nil
+}
+
+// aguardarAte espera esperar() terminar até o fim do prazo de ctx
+//
+// Retorna false (com aviso no log) se o prazo venceu antes; esperar continua
+// em segundo plano.
+func aguardarAte(ctx context.Context, etapa string, esperar func()) bool {
+	feito := make(chan struct{})
+	go func() {
+		esperar()
+		close(feito)
+	}()
+
+	select {
+	case <-feito:
+		return true
+	case <-ctx.Done():
+		logAviso("⚠️ Prazo de encerramento esgotado aguardando %s", etapa)
+		return false
+	}
+}
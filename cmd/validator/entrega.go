@@ -0,0 +1,182 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// resultadoEntrega é a saída JSON do subcomando "entrega"
+type resultadoEntrega struct {
+	ChaveAcesso string               `json:"chave_acesso"`
+	TpEvento    string               `json:"tp_evento,omitempty"`
+	Hash        string               `json:"hash_comprovante,omitempty"`
+	Cancelado   string               `json:"protocolo_cancelado,omitempty"`
+	Retorno     *sefaz.RetornoEvento `json:"retorno,omitempty"`
+	Erro        string               `json:"erro,omitempty"`
+}
+
+// runEntrega executa o subcomando "entrega": registra (ou cancela) o Comprovante de Entrega da NF-e
+//
+// Envia ao Ambiente Nacional o evento 110130 com o recebedor, a data, o GPS
+// e o hash da imagem do comprovante; com -cancelar, o evento 110131 que
+// cancela o comprovante registrado com aquele protocolo.
+func runEntrega(args []string) int {
+	flags := flag.NewFlagSet("entrega", flag.ExitOnError)
+	doc := flags.String("doc", "", "Documento de quem recebeu (2 a 20 caracteres)")
+	nome := flags.String("nome", "", "Nome de quem recebeu (2 a 60 caracteres)")
+	imagem := flags.String("imagem", "", "Imagem do comprovante (assinatura, foto...) usada no hash")
+	hash := flags.String("hash", "", "hashComprovante já calculado (SHA-1 em Base64), no lugar de -imagem")
+	data := flags.String("data", "", "Data e hora da entrega, RFC 3339 (padrão: agora)")
+	gps := flags.String("gps", "", "Latitude,longitude da entrega (ex: -23.550520,-46.633308)")
+	seq := flags.Int("seq", 1, "Sequencial do evento")
+	cancelar := flags.String("cancelar", "", "Protocolo do comprovante a cancelar (evento 110131)")
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s entrega <44_digitos> -doc <documento> -nome \"...\" -imagem <arquivo> [opções]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "     %s entrega <44_digitos> -cancelar <protocolo>\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Opções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator entrega 35250732409620000175550010000037471011544648 -doc 12345678900 -nome \"Maria da Silva\" -imagem assinatura.png -gps -23.550520,-46.633308")
+		fmt.Fprintln(os.Stderr, "  ./validator entrega 35250732409620000175550010000037471011544648 -cancelar 891250000000001")
+	}
+	posicionais := parseIntercalado(flags, args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+
+	cancelamento := *cancelar != ""
+	if len(posicionais) != 1 || (cancelamento && (*doc != "" || *nome != "" || *imagem != "" || *hash != "" || *data != "" || *gps != "")) {
+		flags.Usage()
+		return saidaErro
+	}
+
+	chave := validation.OnlyDigits(posicionais[0])
+	result := resultadoEntrega{ChaveAcesso: chave, Cancelado: *cancelar}
+
+	logInfo("📦 Modo: Comprovante de Entrega da NF-e")
+	logInfo("Chave: %s", chave)
+
+	if _, err := nfe.DecomporChave(chave); err != nil {
+		result.Erro = fmt.Sprintf("Chave de acesso inválida: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaParse
+	}
+
+	comprovante := sefaz.ComprovanteEntrega{Documento: *doc, Nome: *nome, Hash: *hash, DataEntrega: time.Now(), NSeqEvento: *seq}
+	if !cancelamento {
+		if *data != "" {
+			t, err := time.Parse(time.RFC3339, *data)
+			if err != nil {
+				logErro("❌ -data '%s' inválida: use RFC 3339 (ex: 2025-07-10T14:30:00-03:00)", *data)
+				return saidaErro
+			}
+			comprovante.DataEntrega = t
+		}
+		if *gps != "" {
+			coord, err := parseGPS(*gps)
+			if err != nil {
+				logErro("❌ %v", err)
+				return saidaErro
+			}
+			comprovante.GPS = coord
+		}
+		if *imagem != "" {
+			b, err := os.ReadFile(*imagem)
+			if err != nil {
+				logErro("❌ erro ao ler a imagem do comprovante: %v", err)
+				return saidaErro
+			}
+			comprovante.Imagem = b
+		}
+	}
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	cfg, err := arq.carregarConfig()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	logInfo("Ambiente: %s | CNPJ: %s", cfg.Env, cfg.CNPJ)
+	if cfg.UF == "" {
+		logErro("❌ UF do emitente não configurada: defina NFE_UF_IBGE (ou uf no validator.yaml)")
+		return saidaErro
+	}
+
+	var evento sefaz.Evento
+	if cancelamento {
+		evento, err = sefaz.NovoEventoCancelamentoComprovanteEntrega(chave, cfg.UF, *cancelar)
+	} else {
+		comprovante.COrgaoAutor = cfg.UF
+		evento, err = sefaz.NovoEventoComprovanteEntrega(chave, comprovante)
+	}
+	if err != nil {
+		logErro("❌ %v", err)
+		flags.Usage()
+		return saidaErro
+	}
+	result.TpEvento = evento.TpEvento
+	if !cancelamento {
+		result.Hash = comprovante.Hash
+		if result.Hash == "" {
+			result.Hash = sefaz.HashComprovante(chave, comprovante.Imagem)
+		}
+	}
+
+	client, err := sefaz.NewClient(*cfg)
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha ao configurar cliente SEFAZ: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaConectividade
+	}
+
+	logInfo("➡️ Enviando evento %s ao Ambiente Nacional...", evento.TpEvento)
+	retorno, err := client.EnviarEvento(evento)
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha no envio do evento: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaConectividade
+	}
+	result.Retorno = &retorno
+	printJSON(result)
+
+	if !retorno.Registrado {
+		logAviso("⚠️ Evento não registrado: %s - %s (lote %s - %s)", retorno.Codigo, retorno.Mensagem, retorno.CodigoLote, retorno.MensagemLote)
+		return saidaRejeitada
+	}
+	logInfo("✅ %s - %s | Protocolo %s", retorno.Codigo, retorno.Mensagem, retorno.Protocolo)
+	return saidaOK
+}
+
+// parseGPS interpreta "latitude,longitude" em graus decimais
+func parseGPS(s string) (*sefaz.Coordenadas, error) {
+	lat, long, ok := strings.Cut(s, ",")
+	if !ok {
+		return nil, fmt.Errorf("-gps '%s' inválido: use latitude,longitude (ex: -23.550520,-46.633308)", s)
+	}
+	latitude, errLat := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	longitude, errLong := strconv.ParseFloat(strings.TrimSpace(long), 64)
+	if errLat != nil || errLong != nil {
+		return nil, fmt.Errorf("-gps '%s' inválido: use latitude,longitude (ex: -23.550520,-46.633308)", s)
+	}
+	return &sefaz.Coordenadas{Latitude: latitude, Longitude: longitude}, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Eventos SSE (text/event-stream) do acompanhamento de lotes e uploads
+const (
+	// eventoResultado traz um XML validado (contrato v2); o id é a posição no lote, a partir de 1
+	eventoResultado = "resultado"
+
+	// eventoProgresso traz o andamento do lote (como GET /v1/batches/{id}?resultados=false)
+	eventoProgresso = "progresso"
+
+	// eventoFim é o último evento: andamento final do lote ou resumo do upload
+	eventoFim = "fim"
+)
+
+// intervaloPing mantém a conexão viva em proxies enquanto nenhum XML termina
+const intervaloPing = 15 * time.Second
+
+// fluxoEventos escreve Server-Sent Events na resposta HTTP
+type fluxoEventos struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// aceitaEventos indica se o cliente pediu a resposta em text/event-stream (cabeçalho Accept)
+func aceitaEventos(r *http.Request) bool {
+	for _, tipo := range strings.Split(r.Header.Get("Accept"), ",") {
+		if t, _, err := mime.ParseMediaType(strings.TrimSpace(tipo)); err == nil && t == "text/event-stream" {
+			return true
+		}
+	}
+	return false
+}
+
+// novoFluxoEventos envia os cabeçalhos do text/event-stream e devolve o fluxo
+func novoFluxoEventos(w http.ResponseWriter) (*fluxoEventos, error) {
+	f := &fluxoEventos{w: w, rc: http.NewResponseController(w)}
+
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx: não acumular a resposta
+	w.WriteHeader(http.StatusOK)
+	if err := f.rc.Flush(); err != nil {
+		return nil, fmt.Errorf("a conexão não permite streaming: %w", err)
+	}
+	return f, nil
+}
+
+// enviar escreve um evento com o dado em JSON (id vazio = evento sem id)
+func (f *fluxoEventos) enviar(evento, id string, dado any) error {
+	data, err := json.Marshal(dado)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar JSON do evento %s: %w", evento, err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "event: %s\n", evento)
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", data)
+
+	if _, err := f.w.Write([]byte(b.String())); err != nil {
+		return err
+	}
+	return f.rc.Flush()
+}
+
+// ping escreve um comentário SSE (ignorado pelos clientes)
+func (f *fluxoEventos) ping() error {
+	if _, err := f.w.Write([]byte(": ping\n\n")); err != nil {
+		return err
+	}
+	return f.rc.Flush()
+}
+
+// handlerEventosLote acompanha o lote em Server-Sent Events (GET /v1/batches/{id}/events)
+//
+// Os resultados já prontos são enviados primeiro; depois, cada XML validado
+// gera um evento "resultado" seguido de um "progresso". O evento "fim" fecha
+// o fluxo quando o lote termina; no encerramento do serve o fluxo é fechado
+// antes. Ao reconectar, o EventSource do navegador envia Last-Event-ID e o
+// fluxo continua do resultado seguinte.
+//
+// Exemplo:
+//
+//	curl -N http://localhost:8080/v1/batches/3f9a.../events
+//
+//	event: resultado
+//	id: 1
+//	data: {"schema_version":"v2","arquivo":"julho.zip:nota1.xml",...}
+//
+//	event: progresso
+//	data: {"id":"3f9a...","status":"processando","processados":1,...}
+func (f *filaLotes) handlerEventosLote(w http.ResponseWriter, r *http.Request) {
+	l, ok := f.loteDaRequisicao(w, r)
+	if !ok {
+		return
+	}
+
+	// Assina antes de ler o estado: nenhuma mudança se perde entre a leitura e a espera
+	aviso, parar := l.acompanhar()
+	defer parar()
+
+	fluxo, err := novoFluxoEventos(w)
+	if err != nil {
+		logErro("❌ Eventos do lote %s: %v", l.id, err)
+		return
+	}
+
+	enviados := 0
+	if id, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil && id > 0 {
+		enviados = id
+	}
+
+	ping := time.NewTicker(intervaloPing)
+	defer ping.Stop()
+
+	status := ""
+	for {
+		v, novos := l.andamento(enviados)
+		for _, res := range novos {
+			enviados++
+			if err := fluxo.enviar(eventoResultado, strconv.Itoa(enviados), res); err != nil {
+				return
+			}
+		}
+		if len(novos) > 0 || v.Status != status {
+			status = v.Status
+			if err := fluxo.enviar(eventoProgresso, "", v); err != nil {
+				return
+			}
+		}
+		if v.terminado() {
+			fluxo.enviar(eventoFim, "", v)
+			return
+		}
+
+		select {
+		case <-aviso:
+		case <-ping.C:
+			if err := fluxo.ping(); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-f.encerrando:
+			return
+		}
+	}
+}
+
+// fimUpload é o dado do evento "fim" do upload em streaming
+type fimUpload struct {
+	Resumo resumoLote `json:"resumo"`
+}
+
+// validarUploadEmEventos valida o upload enviando cada resultado como evento assim que fica pronto
+//
+// Usado pelo POST /v1/validate/upload com "Accept: text/event-stream": os
+// eventos "resultado" saem na ordem dos arquivos e o "fim" traz o resumo.
+func (a *apiHTTP) validarUploadEmEventos(w http.ResponseWriter, itens iter.Seq[itemLote], opts *opcoesValidacao) resumoLote {
+	inicio := time.Now()
+	fluxo, err := novoFluxoEventos(w)
+	if err != nil {
+		logErro("❌ Upload: %v", err)
+		return novoResumo(nil, 0)
+	}
+
+	enviados := 0
+	results := validarLote(itens, opts, a.workers, func(res resultado) {
+		a.webhook.notificar(res)
+		enviados++
+		// Erro de escrita é o cliente que desconectou: o cancelamento chega pelo contexto da requisição
+		fluxo.enviar(eventoResultado, strconv.Itoa(enviados), res)
+	})
+
+	resumo := novoResumo(results, time.Since(inicio))
+	fluxo.enviar(eventoFim, "", fimUpload{Resumo: resumo})
+	return resumo
+}
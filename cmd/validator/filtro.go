@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// cStatRegex aceita os códigos informados em -status (cStat da SEFAZ, 3 dígitos)
+var cStatRegex = regexp.MustCompile(`^\d{3}$`)
+
+// filtroResultados seleciona quais resultados do lote vão para a saída
+//
+// Os critérios ativos são combinados com "e": -only-failures -status 101
+// mostra só as falhas cuja consulta SEFAZ retornou 101. O resumo e o código
+// de saída continuam considerando todas as notas.
+type filtroResultados struct {
+	soFalhas         bool            // -only-failures: notas reprovadas em qualquer fase ou com achado de erro
+	soNaoAutorizadas bool            // -only-unauthorized: SEFAZ respondeu e a nota não está autorizada
+	status           map[string]bool // -status: cStat da consulta SEFAZ
+}
+
+// novoFiltro monta o filtro a partir das flags (status = lista separada por vírgula)
+//
+// Exemplo:
+//
+//	filtro, err := novoFiltro(false, false, "101,110")
+func novoFiltro(soFalhas, soNaoAutorizadas bool, status string) (filtroResultados, error) {
+	f := filtroResultados{soFalhas: soFalhas, soNaoAutorizadas: soNaoAutorizadas}
+
+	for _, codigo := range splitList(status) {
+		if !cStatRegex.MatchString(codigo) {
+			return f, fmt.Errorf("código de status inválido '%s' em -status (use cStat de 3 dígitos, ex: 101,110)", codigo)
+		}
+		if f.status == nil {
+			f.status = make(map[string]bool)
+		}
+		f.status[codigo] = true
+	}
+	return f, nil
+}
+
+// ativo indica se algum critério foi informado
+func (f filtroResultados) ativo() bool {
+	return f.soFalhas || f.soNaoAutorizadas || len(f.status) > 0
+}
+
+// aceita indica se o resultado atende a todos os critérios ativos
+func (f filtroResultados) aceita(r resultado) bool {
+	if f.soFalhas && r.aprovado() {
+		return false
+	}
+	if f.soNaoAutorizadas && !(r.consultouSefaz() && !r.Sefaz.Autorizado) {
+		return false
+	}
+	if len(f.status) > 0 && !f.status[r.Sefaz.Codigo] {
+		return false
+	}
+	return true
+}
+
+// String descreve os critérios ativos para o log ("falhas, status 101/110")
+func (f filtroResultados) String() string {
+	var partes []string
+	if f.soFalhas {
+		partes = append(partes, "falhas")
+	}
+	if f.soNaoAutorizadas {
+		partes = append(partes, "não autorizadas")
+	}
+	if len(f.status) > 0 {
+		var codigos []string
+		for c := range f.status {
+			codigos = append(codigos, c)
+		}
+		sort.Strings(codigos)
+		partes = append(partes, "status "+strings.Join(codigos, "/"))
+	}
+	return strings.Join(partes, ", ")
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+	"github.com/fabyo/go-nfe-validator/pkg/nfepb"
+)
+
+// servicoGRPC implementa o nfepb.ValidatorServiceServer sobre o pipeline do CLI
+type servicoGRPC struct {
+	nfepb.UnimplementedValidatorServiceServer
+
+	// opts são as opções do servidor; cada requisição deriva as suas (ver derivar)
+	opts *opcoesValidacao
+
+	// workers é o paralelismo do ValidateBatch
+	workers int
+
+	// webhook recebe cada resultado de ValidateXML/ValidateBatch (nil = desligado)
+	webhook *webhook
+}
+
+// novoServidorGRPC cria o servidor gRPC com o ValidatorService registrado
+//
+// O traceparent recebido nos metadados é o pai dos spans da validação. extras
+// são as opções do serve (limites, autenticação, tamanho das mensagens).
+func novoServidorGRPC(opts *opcoesValidacao, workers int, wh *webhook, extras ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(append([]grpc.ServerOption{grpc.StatsHandler(otelgrpc.NewServerHandler())}, extras...)...)
+	nfepb.RegisterValidatorServiceServer(srv, &servicoGRPC{opts: opts, workers: workers, webhook: wh})
+	return srv
+}
+
+// ValidateXML valida um XML com as fases pedidas em Opcoes
+func (s *servicoGRPC) ValidateXML(ctx context.Context, req *nfepb.ValidateXMLRequest) (*nfepb.ValidationResult, error) {
+	if len(req.GetXml()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "xml vazio")
+	}
+
+	opts := s.opcoes(ctx, req.GetOpcoes())
+	result := validarXML(req.GetXml(), opts)
+	result.Arquivo = req.GetNome()
+	opts.gravar(result)
+	logDetalhe("📄 gRPC ValidateXML %s: código %d", valorOuTraco(req.GetNome()), result.codigoSaida())
+	s.webhook.notificar(result)
+	return resultadoPB(result), nil
+}
+
+// ValidateChave confere a chave e, com consultar_sefaz, consulta a situação da nota
+func (s *servicoGRPC) ValidateChave(ctx context.Context, req *nfepb.ValidateChaveRequest) (*nfepb.ChaveResult, error) {
+	chave := validation.OnlyDigits(req.GetChave())
+	if chave == "" {
+		return nil, status.Error(codes.InvalidArgument, "chave vazia")
+	}
+	if req.GetConsultarSefaz() && !identidadeDe(ctx).pode(escopoSefaz) {
+		return nil, status.Errorf(codes.PermissionDenied, "consultar_sefaz exige o escopo '%s'", escopoSefaz)
+	}
+
+	// A consulta da chave também respeita a cota de consultas à SEFAZ do servidor
+	cliente := func() (*sefaz.Client, error) {
+		if err := s.opts.esperarCotaSefaz(ctx); err != nil {
+			return nil, err
+		}
+		return s.opts.clienteSefaz()
+	}
+	result, _ := verificarChave(chave, req.GetConsultarSefaz(), cliente)
+	return chavePB(result), nil
+}
+
+// ValidateBatch valida o fluxo de XMLs com -workers em paralelo, respondendo na ordem de envio
+//
+// As opções da primeira mensagem valem para o fluxo inteiro.
+func (s *servicoGRPC) ValidateBatch(stream nfepb.ValidatorService_ValidateBatchServer) error {
+	primeira, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	opts := s.opcoes(stream.Context(), primeira.GetOpcoes())
+
+	var errRecv, errSend error
+	itens := func(yield func(itemLote) bool) {
+		for req := primeira; ; {
+			xml := req.GetXml()
+			if !yield(itemLote{nome: req.GetNome(), ler: func() ([]byte, error) { return xml, nil }}) {
+				return
+			}
+			if req, errRecv = stream.Recv(); errRecv != nil {
+				return
+			}
+		}
+	}
+
+	total := 0
+	validarLote(itens, opts, s.workers, func(r resultado) {
+		total++
+		s.webhook.notificar(r)
+		if errSend == nil {
+			errSend = stream.Send(resultadoPB(r))
+		}
+	})
+	logInfo("📦 gRPC ValidateBatch: %d XML(s) validados", total)
+
+	if errSend != nil {
+		return errSend
+	}
+	if errRecv != nil && !errors.Is(errRecv, io.EOF) {
+		return errRecv
+	}
+	return nil
+}
+
+// opcoes deriva as opções da requisição a partir das do servidor
+//
+// Credenciais sem o escopo sefaz validam sempre sem a consulta à SEFAZ.
+func (s *servicoGRPC) opcoes(ctx context.Context, op *nfepb.Opcoes) *opcoesValidacao {
+	skipSefaz := op.GetSkipSefaz() || !identidadeDe(ctx).pode(escopoSefaz)
+	opts := s.opts.derivar(op.GetXsdOnly(), skipSefaz, op.GetOffline(), op.GetRegrasDesabilitadas())
+	opts.ctx = ctx
+	return opts
+}
+
+// resultadoPB converte o resultado para a mensagem gRPC (mesmos campos do contrato JSON v2)
+func resultadoPB(r resultado) *nfepb.ValidationResult {
+	v2 := r.v2()
+
+	out := &nfepb.ValidationResult{
+		SchemaVersion: v2.SchemaVersion,
+		Nome:          v2.Arquivo,
+		Tipo:          v2.Tipo,
+		ChaveAcesso:   v2.ChaveAcesso,
+		Aprovado:      v2.Aprovado,
+		CodigoSaida:   int32(v2.CodigoSaida),
+		Fases: &nfepb.Fases{
+			Xsd:        v2.Fases.XSD,
+			Parse:      v2.Fases.Parse,
+			Assinatura: v2.Fases.Assinatura,
+			Regras:     v2.Fases.Regras,
+			Sefaz:      v2.Fases.Sefaz,
+		},
+		Sefaz: sefazPB(v2.Sefaz),
+		Erro:  v2.Erro,
+	}
+
+	if d := v2.DadosXML; d != nil {
+		out.DadosXml = &nfepb.DadosXML{
+			Modelo:           d.Modelo,
+			Serie:            d.Serie,
+			Numero:           d.Numero,
+			EmitenteCnpj:     d.EmitCNPJ,
+			EmitenteRazao:    d.EmitRazao,
+			DestinatarioDoc:  d.DestDoc,
+			DestinatarioNome: d.DestNome,
+			ValorTotalNota:   d.ValorTotalNF,
+		}
+	}
+
+	for _, a := range v2.Achados {
+		out.Achados = append(out.Achados, achadoPB(a))
+	}
+	return out
+}
+
+// chavePB converte o resultado do subcomando "chave" para a mensagem gRPC
+func chavePB(r resultadoChave) *nfepb.ChaveResult {
+	out := &nfepb.ChaveResult{
+		ChaveAcesso: r.ChaveAcesso,
+		Valida:      r.Valida,
+		Erro:        r.Erro,
+	}
+	if c := r.Componentes; c != nil && r.Valida {
+		out.Componentes = &nfepb.ChaveComponentes{
+			CUf:                  c.CUF,
+			Uf:                   c.UF,
+			Periodo:              c.Periodo,
+			Emitente:             c.Emitente,
+			Modelo:               c.Modelo,
+			Serie:                c.Serie,
+			Numero:               c.Numero,
+			TipoEmissao:          c.TipoEmissao,
+			DescricaoTipoEmissao: c.DescricaoTipoEmissao,
+			CodigoNumerico:       c.CodigoNumerico,
+			Dv:                   c.DigitoVerificador,
+		}
+	}
+	if r.Sefaz != nil {
+		out.Sefaz = sefazPB(*r.Sefaz)
+	}
+	return out
+}
+
+// sefazPB converte o status da consulta SEFAZ
+func sefazPB(s validation.SefazStatus) *nfepb.SefazStatus {
+	return &nfepb.SefazStatus{
+		Autorizado: s.Autorizado,
+		Codigo:     s.Codigo,
+		Mensagem:   s.Mensagem,
+		Consulta:   s.Consulta,
+	}
+}
+
+// achadoPB converte um achado das regras de negócio
+func achadoPB(a nfe.Achado) *nfepb.Achado {
+	return &nfepb.Achado{
+		Regra:      a.Regra,
+		Severidade: string(a.Severidade),
+		Codigo:     a.Codigo,
+		Item:       int32(a.Item),
+		Grupo:      a.Grupo,
+		Mensagem:   a.Mensagem,
+	}
+}
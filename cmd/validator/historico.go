@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/internal/store"
+)
+
+// envStoreDSN é a variável de ambiente com o DSN do histórico (evita a senha do banco no "ps")
+const envStoreDSN = "NFE_STORE_DSN"
+
+// tempoGravacao limita cada gravação no histórico; o resultado segue para a saída mesmo se ela falhar
+const tempoGravacao = 5 * time.Second
+
+// registrarFlagStore adiciona -store aos modos que validam XMLs (validate, serve, watch, kafka, rabbitmq, sqs)
+func registrarFlagStore(flags *flag.FlagSet) *string {
+	return flags.String("store", "", "Banco onde gravar o histórico das validações: sqlite:arquivo.db ou postgres://... (padrão: $"+envStoreDSN+")")
+}
+
+// abrirHistorico abre o banco do histórico (flag, variável de ambiente ou arquivo de configuração)
+//
+// Retorna nil (histórico desligado) quando nenhum DSN foi configurado.
+func abrirHistorico(dsn string, arq *arquivoConfig) (store.ResultStore, error) {
+	preencher(&dsn, arq.getenv(envStoreDSN))
+	preencher(&dsn, arq.Historico.DSN)
+	if dsn == "" {
+		return nil, nil
+	}
+
+	s, err := store.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	logInfo("🗄️ Histórico de validações: %s", store.OcultarSenha(dsn))
+	return s, nil
+}
+
+// gravar registra o resultado no histórico; não faz nada com o histórico desligado
+//
+// Validações interrompidas (prazo de encerramento ou cliente que
+// desconectou) não são gravadas: o resultado delas é uma falha de
+// conectividade, e o XML volta para a fila ou é reenviado.
+func (o *opcoesValidacao) gravar(r resultado) {
+	if o.historico == nil || conferirPrazo(o.contexto()) != nil {
+		return
+	}
+
+	// Iniciada, a gravação termina mesmo se o contexto for cancelado no meio
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(o.contexto()), tempoGravacao)
+	defer cancel()
+
+	if _, err := o.historico.Salvar(ctx, r.registro(o.origem)); err != nil {
+		logAviso("⚠️ Histórico: %v", err)
+	}
+}
+
+// registro converte o resultado para o formato do histórico
+func (r resultado) registro(origem string) store.Registro {
+	reg := store.Registro{
+		Chave:       r.ChaveAcesso,
+		Arquivo:     r.Arquivo,
+		Origem:      origem,
+		Aprovado:    r.aprovado(),
+		CodigoSaida: r.codigoSaida(),
+		ValidoXSD:   r.ValidoXSD,
+		Erro:        r.Erro,
+		Achados:     r.Achados,
+		CStat:       r.Sefaz.Codigo,
+		Mensagem:    r.Sefaz.Mensagem,
+		Consulta:    r.Sefaz.Consulta,
+		Autorizado:  r.Sefaz.Autorizado,
+		Protocolo:   r.Sefaz.Protocolo,
+		ValidadoEm:  time.Now(),
+	}
+	if r.DadosXML != nil {
+		reg.Modelo = r.DadosXML.Modelo
+		reg.EmitenteCNPJ = r.DadosXML.EmitCNPJ
+	}
+	return reg
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// novoServidorHTTP cria o servidor HTTP do modo serve
+//
+// Rotas:
+//
+//	GET  /metrics                  métricas no formato Prometheus (validações, SEFAZ, certificado, runtime Go)
+//	GET  /healthz                  liveness: 200 enquanto o processo responde
+//	GET  /readyz                   readiness: 200 com certificado, XSD e (opcional) SEFAZ ok; 503 caso contrário
+//	GET  /openapi.yaml             documento OpenAPI 3 da API REST (também em /openapi.json)
+//	POST /v1/validate/upload       valida XMLs e pacotes enviados em multipart/form-data
+//	POST /v1/batches               recebe um lote (multipart ou URL s3:// / https://) e valida em segundo plano
+//	GET  /v1/batches/{id}          andamento e resultados do lote
+//	GET  /v1/batches/{id}/events   andamento e resultados do lote em Server-Sent Events
+//
+// As rotas /v1 passam pelos limites por IP (ver limitesServe) e exigem
+// credencial quando há autenticador (ver autenticador); /metrics, /healthz,
+// /readyz e o documento OpenAPI continuam abertos para o Prometheus, o
+// Kubernetes e os geradores de SDK.
+func novoServidorHTTP(addr string, api *apiHTTP, pronto *prontidao) *http.Server {
+	registro := prometheus.NewRegistry()
+	registro.MustRegister(
+		api.opts.metricas,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.HandlerFor(registro, promhttp.HandlerOpts{}))
+	mux.HandleFunc("GET /healthz", handlerHealthz)
+	mux.HandleFunc("GET /readyz", pronto.handlerReadyz)
+	mux.HandleFunc("GET /openapi.yaml", handlerOpenAPIYAML)
+	mux.HandleFunc("GET /openapi.json", handlerOpenAPIJSON)
+	mux.HandleFunc("POST /v1/validate/upload", api.limites.http(api.auth.exigir(escopoValidar, api.handlerUpload)))
+	mux.HandleFunc("POST /v1/batches", api.limites.http(api.auth.exigir(escopoValidar, api.lotes.handlerCriarLote)))
+	mux.HandleFunc("GET /v1/batches/{id}", api.limites.http(api.auth.exigir(escopoValidar, api.lotes.handlerConsultarLote)))
+	mux.HandleFunc("GET /v1/batches/{id}/events", api.limites.httpAcompanhamento(api.auth.exigir(escopoValidar, api.lotes.handlerEventosLote)))
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// responderJSON escreve a resposta JSON sem cache
+func responderJSON(w http.ResponseWriter, status int, corpo any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(corpo)
+}
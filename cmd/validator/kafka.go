@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// Cabeçalhos acrescentados às mensagens produzidas pelo modo kafka
+const (
+	// cabecalhoOrigem identifica a mensagem consumida: "<tópico>/<partição>/<offset>"
+	cabecalhoOrigem = "nfe-origem"
+
+	// cabecalhoErroDLQ traz o motivo de a mensagem ter ido para a DLQ
+	cabecalhoErroDLQ = "nfe-erro"
+)
+
+// runKafka executa o subcomando "kafka": consome XMLs de um tópico e produz os resultados em outro
+//
+// Os offsets do consumer group só são confirmados depois que os resultados
+// do lote foram gravados (entrega at-least-once). Mensagens que não são um
+// XML (vazias, binárias, truncadas antes do elemento raiz) vão para a DLQ
+// com o motivo no cabeçalho "nfe-erro", sem travar a partição.
+func runKafka(args []string) int {
+	flags := flag.NewFlagSet("kafka", flag.ExitOnError)
+	brokers := flags.String("brokers", "localhost:9092", "Brokers Kafka, separados por vírgula")
+	topico := flags.String("topic", "", "Tópico com os XMLs a validar (obrigatório)")
+	grupo := flags.String("group", "nfe-validator", "Consumer group (os offsets são confirmados após produzir os resultados)")
+	topicoSaida := flags.String("output-topic", "", "Tópico que recebe o JSON de cada resultado (obrigatório)")
+	topicoDLQ := flags.String("dlq-topic", "", "Tópico das mensagens que não são XML (padrão: <topic>.dlq)")
+	tamanhoLote := flags.Int("batch", 100, "Máximo de mensagens por lote (validadas e confirmadas juntas)")
+	xsdPath := flags.String("schema", schemaPadrao, "Arquivo XSD usado na validação")
+	xsdOnly := flags.Bool("xsd", false, "Validar apenas contra XSD (sem consulta SEFAZ)")
+	skipSefaz := flags.Bool("skip-sefaz", false, "Pular consulta SEFAZ (valida XSD + parse dados)")
+	offline := flags.Bool("offline", false, "Validação local completa (XSD + parse + assinatura + regras); SEFAZ marcada como pulada")
+	disableRules := flags.String("disable-rules", "", "IDs de regras de negócio a desabilitar, separados por vírgula (ex: ncm,cfop)")
+	workers := flags.Int("workers", 4, "Mensagens validadas em paralelo")
+
+	versaoSaida := registrarFlagVersaoSaida(flags)
+	shutdownTimeout := registrarFlagEncerramento(flags)
+	storeDSN := registrarFlagStore(flags)
+	arquivamentoOpts := registrarFlagsArquivamento(flags)
+	dedupOpts := registrarFlagsDedup(flags)
+	alertasDestinos := registrarFlagAlertas(flags)
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s kafka -topic <tópico> -output-topic <tópico> [opções]\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Consome XMLs de um tópico Kafka, valida e produz o resultado em JSON.")
+		fmt.Fprintln(os.Stderr, "\nOpções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplo:")
+		fmt.Fprintln(os.Stderr, "  ./validator kafka -brokers kafka1:9092,kafka2:9092 -topic nfe.xml -output-topic nfe.resultados -offline")
+	}
+	flags.Parse(args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+	if err := validarVersaoSaida(*versaoSaida); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+	if flags.NArg() > 0 || *topico == "" || *topicoSaida == "" || *tamanhoLote < 1 {
+		flags.Usage()
+		return saidaErro
+	}
+	if *topicoDLQ == "" {
+		*topicoDLQ = *topico + ".dlq"
+	}
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if arq.caminho != "" {
+		logInfo("⚙️ Configuração: %s", arq.descricao())
+	}
+
+	opts := &opcoesValidacao{
+		xsdPath:       *xsdPath,
+		xsdOnly:       *xsdOnly,
+		skipSefaz:     *skipSefaz,
+		offline:       *offline,
+		versaoSaida:   *versaoSaida,
+		mascararSaida: logOpts.mascararSaida(),
+		regras:        arq.configRegras(splitList(*disableRules)),
+	}
+	if !flagInformada(flags, "schema") {
+		opts.xsdPath = arq.schema(schemaPadrao)
+	}
+	if opts.consultaSefaz() {
+		if opts.cfg, err = arq.carregarConfig(); err != nil {
+			logErro("❌ %v", err)
+			return saidaConectividade
+		}
+		logInfo("Ambiente ativo: %s (UF %s)", opts.cfg.Env, opts.cfg.UF)
+	}
+	opts.logNivel()
+
+	historico, err := abrirHistorico(*storeDSN, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if historico != nil {
+		defer historico.Close()
+	}
+	opts.historico, opts.origem = historico, "kafka"
+
+	arquivamento, err := arquivamentoOpts.criar(flags, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	arquivamento.manterRetencao(context.Background())
+	opts.arquivamento = arquivamento
+
+	dups, fecharDedup, err := dedupOpts.criar(historico, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	defer fecharDedup()
+	opts.dedup = dups
+
+	alertas, err := criarAlertador(*alertasDestinos, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	opts.alertas = alertas
+
+	xsd, err := validation.NewXSDValidator(opts.xsdPath)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	defer xsd.Close()
+	opts.xsd = xsd
+
+	cl, err := kgo.NewClient(
+		kgo.SeedBrokers(splitList(*brokers)...),
+		kgo.ClientID("go-nfe-validator"),
+		kgo.ConsumerGroup(*grupo),
+		kgo.ConsumeTopics(*topico),
+		kgo.DisableAutoCommit(),
+		// Sem rebalanceamento no meio de um lote: as partições só mudam de dono depois do commit
+		kgo.BlockRebalanceOnPoll(),
+	)
+	if err != nil {
+		logErro("❌ Falha ao configurar o cliente Kafka: %v", err)
+		return saidaErro
+	}
+	defer cl.Close()
+	defer cl.AllowRebalance() // antes do Close: libera a saída do grupo se o loop parou no meio de um lote
+
+	k := &consumidorKafka{cl: cl, opts: opts, workers: *workers, saida: *topicoSaida, dlq: *topicoDLQ}
+
+	// O sinal interrompe a espera por mensagens; o lote em andamento tem até
+	// -shutdown-timeout para terminar e ser confirmado
+	sinal, pararSinais := contextoSinais()
+	defer pararSinais()
+	trabalho, cancelarTrabalho := contextoTrabalho(sinal, *shutdownTimeout)
+	defer cancelarTrabalho()
+	opts.ctx = trabalho
+	// Parado antes de pararSinais (defer): saídas por erro não aparecem como encerramento
+	pararAviso := context.AfterFunc(sinal, func() {
+		logInfo("🛑 Encerrando kafka (até %s para concluir o lote em andamento)", *shutdownTimeout)
+	})
+	defer pararAviso()
+
+	logInfo("📨 Consumindo %s (grupo %s) ➜ %s, DLQ %s (Ctrl+C para sair)", *topico, *grupo, *topicoSaida, *topicoDLQ)
+
+	for {
+		fetches := cl.PollRecords(sinal, *tamanhoLote)
+		if fetches.IsClientClosed() || sinal.Err() != nil {
+			break
+		}
+		fetches.EachError(func(t string, p int32, err error) {
+			logAviso("⚠️ Kafka %s/%d: %v", t, p, err)
+		})
+
+		registros := fetches.Records()
+		if len(registros) > 0 {
+			if err := k.processar(registros); errors.Is(err, errPrazoEncerramento) {
+				logAviso("⚠️ %v: lote de %d mensagem(ns) sem commit, será entregue de novo ao grupo", err, len(registros))
+				break
+			} else if err != nil {
+				// Sem commit: o lote volta a ser entregue quando o consumidor reiniciar
+				logErro("❌ %v", err)
+				return saidaConectividade
+			}
+			if err := cl.CommitRecords(context.Background(), registros...); err != nil {
+				logAviso("⚠️ Falha ao confirmar offsets (o lote pode ser reprocessado): %v", err)
+			}
+		}
+		cl.AllowRebalance()
+	}
+
+	logInfo("📦 kafka encerrado (%d validadas, %d na DLQ)", k.validadas, k.descartadas)
+	return saidaOK
+}
+
+// consumidorKafka valida os lotes consumidos e produz resultados e DLQ
+type consumidorKafka struct {
+	cl      *kgo.Client
+	opts    *opcoesValidacao
+	workers int
+
+	// saida e dlq são os tópicos de destino
+	saida string
+	dlq   string
+
+	// contadores para o log de encerramento
+	validadas   int
+	descartadas int
+}
+
+// processar valida o lote e produz um registro por mensagem (resultado ou DLQ)
+//
+// Só retorna depois que todos os registros foram aceitos pelo broker; com
+// errPrazoEncerramento nada foi produzido e o lote não deve ser confirmado.
+func (k *consumidorKafka) processar(registros []*kgo.Record) error {
+	var saida []*kgo.Record
+	var validos []*kgo.Record
+
+	for _, r := range registros {
+		if err := conferirPayloadXML(r.Value); err != nil {
+			logAviso("⚠️ %s ➜ DLQ: %v", origemKafka(r), err)
+			saida = append(saida, k.registroDLQ(r, err))
+			continue
+		}
+		validos = append(validos, r)
+	}
+
+	itens := func(yield func(itemLote) bool) {
+		for _, r := range validos {
+			if !yield(itemLote{nome: origemKafka(r), ler: func() ([]byte, error) { return r.Value, nil }}) {
+				return
+			}
+		}
+	}
+
+	// validarLote emite na ordem dos itens: o i-ésimo resultado é do i-ésimo registro válido
+	i := 0
+	validarLote(itens, k.opts, k.workers, func(res resultado) {
+		r := validos[i]
+		i++
+
+		corpo, err := json.Marshal(res)
+		if err != nil {
+			saida = append(saida, k.registroDLQ(r, fmt.Errorf("erro ao serializar resultado: %w", err)))
+			return
+		}
+
+		chave := r.Key
+		if res.ChaveAcesso != "" {
+			chave = []byte(res.ChaveAcesso)
+		}
+		saida = append(saida, &kgo.Record{
+			Topic:   k.saida,
+			Key:     chave,
+			Value:   corpo,
+			Headers: comOrigem(r),
+		})
+		logDetalhe("📄 %s: código %d", origemKafka(r), res.codigoSaida())
+	})
+	if err := conferirPrazo(k.opts.contexto()); err != nil {
+		// Resultados de validações interrompidas não são produzidos: o lote inteiro é refeito
+		return err
+	}
+
+	if err := k.cl.ProduceSync(context.Background(), saida...).FirstErr(); err != nil {
+		return fmt.Errorf("erro ao produzir resultados: %w", err)
+	}
+
+	k.validadas += len(validos)
+	k.descartadas += len(registros) - len(validos)
+	logInfo("📦 Lote: %d validada(s), %d na DLQ", len(validos), len(registros)-len(validos))
+	return nil
+}
+
+// registroDLQ copia a mensagem original para a DLQ, com a origem e o motivo nos cabeçalhos
+func (k *consumidorKafka) registroDLQ(r *kgo.Record, motivo error) *kgo.Record {
+	return &kgo.Record{
+		Topic:   k.dlq,
+		Key:     r.Key,
+		Value:   r.Value,
+		Headers: append(comOrigem(r), kgo.RecordHeader{Key: cabecalhoErroDLQ, Value: []byte(motivo.Error())}),
+	}
+}
+
+// comOrigem devolve os cabeçalhos da mensagem original acrescidos do "nfe-origem"
+func comOrigem(r *kgo.Record) []kgo.RecordHeader {
+	headers := append([]kgo.RecordHeader{}, r.Headers...)
+	return append(headers, kgo.RecordHeader{Key: cabecalhoOrigem, Value: []byte(origemKafka(r))})
+}
+
+// origemKafka identifica a mensagem: "<tópico>/<partição>/<offset>"
+func origemKafka(r *kgo.Record) string {
+	return r.Topic + "/" + strconv.Itoa(int(r.Partition)) + "/" + strconv.FormatInt(r.Offset, 10)
+}
+
+// conferirPayloadXML rejeita mensagens que não podem ser tratadas como XML
+//
+// Basta chegar ao elemento raiz: um XML que começa bem e é inválido adiante
+// (ou no XSD) é um resultado de validação, não uma mensagem envenenada.
+func conferirPayloadXML(data []byte) error {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if len(bytes.TrimSpace(data)) == 0 {
+		return errors.New("mensagem vazia")
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			return errors.New("payload sem elemento raiz XML")
+		}
+		if err != nil {
+			return fmt.Errorf("payload não é XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			return nil
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) > 0 {
+				return errors.New("payload não é XML: texto antes do elemento raiz")
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ociosidadeCliente é o tempo sem requisições após o qual o limitador do cliente é descartado
+const ociosidadeCliente = 10 * time.Minute
+
+// Recusas dos limites do serve, convertidas em HTTP 429/503 ou nos códigos gRPC equivalentes
+var (
+	errLimiteCliente   = errors.New("limite de requisições do cliente excedido")
+	errServidorOcupado = errors.New("servidor ocupado: limite de requisições simultâneas atingido")
+)
+
+// limitesServe protege o serve (REST /v1 e gRPC) de clientes abusivos e de picos de carga
+//
+// Os limites valem para o IP de origem da conexão, antes da autenticação
+// (os limites por credencial ficam em autenticacao.chaves; o autenticador
+// usa um limitesServe só com porMinuto para os subjects JWT):
+//
+//	-rate-limit 120        até 120 requisições por minuto por IP
+//	-max-concurrent 32     até 32 requisições em andamento (as demais recebem 503/Unavailable)
+//
+// Um limitesServe nil não limita nada.
+type limitesServe struct {
+	porMinuto int
+
+	// vagas limita as requisições em andamento (nil = sem limite)
+	vagas chan struct{}
+
+	mu       sync.Mutex
+	clientes map[string]*limiteCliente
+	limpeza  time.Time
+}
+
+// limiteCliente é o token bucket de um IP (ou de um subject JWT)
+type limiteCliente struct {
+	limite *rate.Limiter
+	visto  time.Time
+}
+
+// novosLimites cria os limites do serve (nil quando ambos estão desligados)
+func novosLimites(porMinuto, simultaneas int) *limitesServe {
+	if porMinuto <= 0 && simultaneas <= 0 {
+		return nil
+	}
+	l := &limitesServe{porMinuto: porMinuto, clientes: make(map[string]*limiteCliente)}
+	if simultaneas > 0 {
+		l.vagas = make(chan struct{}, simultaneas)
+	}
+	return l
+}
+
+// entrar confere os limites para o cliente; com sucesso, liberar deve ser chamado ao fim da requisição
+//
+// espera é o tempo até o cliente poder tentar de novo (apenas com errLimiteCliente).
+func (l *limitesServe) entrar(cliente string) (liberar func(), espera time.Duration, err error) {
+	if l == nil {
+		return func() {}, 0, nil
+	}
+
+	if espera, err := l.conferirTaxa(cliente); err != nil {
+		return nil, espera, err
+	}
+
+	if l.vagas == nil {
+		return func() {}, 0, nil
+	}
+	select {
+	case l.vagas <- struct{}{}:
+		return func() { <-l.vagas }, 0, nil
+	default:
+		return nil, 0, errServidorOcupado
+	}
+}
+
+// conferirTaxa consome uma requisição da cota por minuto do cliente (-rate-limit)
+func (l *limitesServe) conferirTaxa(cliente string) (espera time.Duration, err error) {
+	if l.porMinuto <= 0 {
+		return 0, nil
+	}
+	if r := l.limiteDe(cliente).Reserve(); r.Delay() > 0 {
+		espera = r.Delay()
+		r.Cancel()
+		return espera, fmt.Errorf("%w (%s)", errLimiteCliente, cliente)
+	}
+	return 0, nil
+}
+
+// limiteDe retorna o limitador do cliente, descartando os ociosos de tempos em tempos
+func (l *limitesServe) limiteDe(cliente string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	agora := time.Now()
+	if agora.Sub(l.limpeza) > ociosidadeCliente {
+		for chave, c := range l.clientes {
+			if agora.Sub(c.visto) > ociosidadeCliente {
+				delete(l.clientes, chave)
+			}
+		}
+		l.limpeza = agora
+	}
+
+	c, ok := l.clientes[cliente]
+	if !ok {
+		c = &limiteCliente{limite: novoLimite(l.porMinuto)}
+		l.clientes[cliente] = c
+	}
+	c.visto = agora
+	return c.limite
+}
+
+// http aplica os limites ao handler REST; sem limites, o handler é devolvido intacto
+func (l *limitesServe) http(next http.HandlerFunc) http.HandlerFunc {
+	if l == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		liberar, espera, err := l.entrar(ipCliente(r.RemoteAddr))
+		switch {
+		case errors.Is(err, errLimiteCliente):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(espera.Seconds()))))
+			responderJSON(w, http.StatusTooManyRequests, respostaErro{Erro: err.Error()})
+		case err != nil:
+			w.Header().Set("Retry-After", "1")
+			responderJSON(w, http.StatusServiceUnavailable, respostaErro{Erro: err.Error()})
+		default:
+			defer liberar()
+			next(w, r)
+		}
+	}
+}
+
+// httpAcompanhamento aplica ao handler só o limite por IP
+//
+// Conexões longas que apenas acompanham o trabalho (eventos SSE dos lotes)
+// não ocupam vaga de -max-concurrent, reservada para quem valida.
+func (l *limitesServe) httpAcompanhamento(next http.HandlerFunc) http.HandlerFunc {
+	if l == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if espera, err := l.conferirTaxa(ipCliente(r.RemoteAddr)); err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(espera.Seconds()))))
+			responderJSON(w, http.StatusTooManyRequests, respostaErro{Erro: err.Error()})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// entrarGRPC aplica os limites à chamada gRPC
+func (l *limitesServe) entrarGRPC(ctx context.Context) (func(), error) {
+	cliente := "desconhecido"
+	if p, ok := peer.FromContext(ctx); ok {
+		cliente = ipCliente(p.Addr.String())
+	}
+
+	liberar, espera, err := l.entrar(cliente)
+	switch {
+	case errors.Is(err, errLimiteCliente):
+		return nil, status.Errorf(codes.ResourceExhausted, "%v; tente de novo em %s", err, espera.Round(time.Second))
+	case err != nil:
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return liberar, nil
+}
+
+// opcoesGRPC são os interceptors dos limites do servidor gRPC (nenhum sem limites)
+func (l *limitesServe) opcoesGRPC() []grpc.ServerOption {
+	if l == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			liberar, err := l.entrarGRPC(ctx)
+			if err != nil {
+				return nil, err
+			}
+			defer liberar()
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			liberar, err := l.entrarGRPC(ss.Context())
+			if err != nil {
+				return err
+			}
+			defer liberar()
+			return handler(srv, ss)
+		}),
+	}
+}
+
+// ipCliente extrai o IP de "host:porta" (o endereço inteiro, se não tiver porta)
+func ipCliente(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// esperarCotaSefaz aguarda a vez na cota de consultas à SEFAZ do servidor (-sefaz-rate)
+func (o *opcoesValidacao) esperarCotaSefaz(ctx context.Context) error {
+	if o.cotaSefaz == nil {
+		return nil
+	}
+	if err := o.cotaSefaz.Wait(ctx); err != nil {
+		return fmt.Errorf("espera pela cota de consultas SEFAZ interrompida: %w", err)
+	}
+	return nil
+}
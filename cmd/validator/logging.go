@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// Níveis de log do CLI (além dos níveis padrão do slog)
+const (
+	// nivelDetalhe: progresso de cada fase da validação (-v)
+	nivelDetalhe = slog.LevelDebug
+
+	// nivelDebug: tempos por fase e origem (arquivo:linha) de cada log (-vv)
+	nivelDebug = slog.LevelDebug - 4
+)
+
+// Valores de -redact (mascaramento LGPD)
+const (
+	// redactLogs mascara CPF/CNPJ, nomes e endereços nos logs
+	redactLogs = "logs"
+
+	// redactAll mascara também os resultados (stdout, -o, webhooks, filas e respostas do serve)
+	redactAll = "all"
+)
+
+// opcoesLog são as flags de log comuns a todos os modos do CLI
+type opcoesLog struct {
+	quiet     bool
+	verbose   bool
+	debug     bool
+	logFormat string
+	redact    string
+}
+
+// registrarFlagsLog adiciona -quiet, -v, -vv, -log-format e -redact ao FlagSet
+func registrarFlagsLog(flags *flag.FlagSet) *opcoesLog {
+	o := &opcoesLog{}
+	flags.BoolVar(&o.quiet, "quiet", false, "Não exibir logs (apenas o resultado no stdout e erros no stderr)")
+	flags.BoolVar(&o.verbose, "v", false, "Exibir o progresso de cada fase da validação")
+	flags.BoolVar(&o.debug, "vv", false, "Exibir também tempos por fase e a origem (arquivo:linha) de cada log")
+	flags.StringVar(&o.logFormat, "log-format", "text", "Formato dos logs no stderr: text ou json")
+	flags.StringVar(&o.redact, "redact", "", "Mascarar CPF/CNPJ, nomes e endereços (LGPD): logs, ou all para mascarar também os resultados")
+	return o
+}
+
+// mascararSaida indica se os resultados também são mascarados (-redact all)
+func (o *opcoesLog) mascararSaida() bool {
+	return o.redact == redactAll
+}
+
+// aplicar configura o logger padrão (slog e log) conforme as flags
+//
+// O pacote log também passa a escrever no mesmo handler, então os logs
+// dos pacotes internos seguem o mesmo formato e nível.
+func (o *opcoesLog) aplicar() error {
+	nivel := slog.LevelInfo
+	switch {
+	case o.quiet:
+		nivel = slog.LevelError
+	case o.debug:
+		nivel = nivelDebug
+	case o.verbose:
+		nivel = nivelDetalhe
+	}
+
+	var handler slog.Handler
+	switch o.logFormat {
+	case "text", "":
+		handler = &handlerTexto{w: os.Stderr, nivel: nivel, origem: o.debug, mu: &sync.Mutex{}}
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+			Level:       nivel,
+			AddSource:   o.debug,
+			ReplaceAttr: nomearNivel,
+		})
+	default:
+		return fmt.Errorf("formato de log inválido '%s' (use text ou json)", o.logFormat)
+	}
+
+	switch o.redact {
+	case "":
+	case redactLogs, redactAll:
+		handler = handlerMascara{handler}
+	default:
+		return fmt.Errorf("valor de -redact inválido '%s' (use %s ou %s)", o.redact, redactLogs, redactAll)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	// Logs do pacote log (config, sefaz) entram como info
+	log.SetFlags(0)
+
+	logInfo("⚡️ Iniciando Validador NF-e")
+	return nil
+}
+
+// logTexto indica se os logs estão no formato texto (padrão)
+func logTexto() bool {
+	handler := slog.Default().Handler()
+	if m, ok := handler.(handlerMascara); ok {
+		handler = m.Handler
+	}
+	_, ok := handler.(*handlerTexto)
+	return ok
+}
+
+// logErro registra uma falha (sempre exibida, mesmo com -quiet), com o código do erro entre os args (ver codigoArgs)
+func logErro(format string, args ...any) {
+	registrar(slog.LevelError, format, args...)
+}
+
+// logAviso registra um problema que não interrompe a execução
+func logAviso(format string, args ...any) {
+	registrar(slog.LevelWarn, format, args...)
+}
+
+// logInfo registra o andamento geral (arquivos, status final, resumo)
+func logInfo(format string, args ...any) {
+	registrar(slog.LevelInfo, format, args...)
+}
+
+// logDetalhe registra o progresso das fases (exibido com -v)
+func logDetalhe(format string, args ...any) {
+	registrar(nivelDetalhe, format, args...)
+}
+
+// logDebug registra informações de diagnóstico (exibido com -vv)
+func logDebug(format string, args ...any) {
+	registrar(nivelDebug, format, args...)
+}
+
+// registrar envia o log ao handler padrão, preservando o arquivo:linha de quem chamou
+func registrar(nivel slog.Level, format string, args ...any) {
+	logger := slog.Default()
+	if !logger.Enabled(context.Background(), nivel) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // runtime.Callers, registrar, logX
+	r := slog.NewRecord(time.Now(), nivel, fmt.Sprintf(format, args...), pcs[0])
+	if nivel == slog.LevelError {
+		if codigo := codigoArgs(args); codigo != "" {
+			r.AddAttrs(slog.String("codigo", string(codigo)))
+		}
+	}
+	_ = logger.Handler().Handle(context.Background(), r)
+}
+
+// codigoArgs é o código estável (nfe.CodigoDe) do primeiro error reconhecido entre os args de um logErro
+//
+// Assim as falhas que encerram o CLI podem ser agrupadas sem interpretar a
+// mensagem; erros sem classificação não levam código.
+func codigoArgs(args []any) nfe.CodigoErro {
+	for _, a := range args {
+		if err, ok := a.(error); ok {
+			if codigo := nfe.CodigoDe(err); codigo != nfe.CodigoDesconhecido {
+				return codigo
+			}
+		}
+	}
+	return ""
+}
+
+// nomearNivel dá nomes aos níveis próprios do CLI na saída JSON
+func nomearNivel(_ []string, a slog.Attr) slog.Attr {
+	if a.Key != slog.LevelKey {
+		return a
+	}
+	switch a.Value.Any().(slog.Level) {
+	case nivelDetalhe:
+		a.Value = slog.StringValue("DETALHE")
+	case nivelDebug:
+		a.Value = slog.StringValue("DEBUG")
+	}
+	return a
+}
+
+// handlerTexto mantém o formato clássico do CLI: "data hora [arquivo:linha:] mensagem"
+type handlerTexto struct {
+	w      io.Writer
+	nivel  slog.Level
+	origem bool
+	attrs  []slog.Attr
+	mu     *sync.Mutex
+}
+
+func (h *handlerTexto) Enabled(_ context.Context, nivel slog.Level) bool {
+	return nivel >= h.nivel
+}
+
+func (h *handlerTexto) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+
+	if h.origem && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		fmt.Fprintf(&b, "%s:%d: ", filepath.Base(frame.File), frame.Line)
+	}
+
+	b.WriteString(strings.TrimRight(r.Message, "\n"))
+
+	escreverAttr := func(a slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+		return true
+	}
+	for _, a := range h.attrs {
+		escreverAttr(a)
+	}
+	r.Attrs(escreverAttr)
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *handlerTexto) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &h2
+}
+
+func (h *handlerTexto) WithGroup(_ string) slog.Handler {
+	// O CLI não usa grupos; os atributos seguem no nível raiz
+	return h
+}
+
+// handlerMascara mascara os dados pessoais (nfe.MascararTexto) da mensagem e dos atributos antes do handler de saída (-redact)
+//
+// Cobre também o pacote log e a resposta bruta da SEFAZ registrada com -v.
+type handlerMascara struct {
+	slog.Handler
+}
+
+func (h handlerMascara) Handle(ctx context.Context, r slog.Record) error {
+	mascarado := slog.NewRecord(r.Time, r.Level, nfe.MascararTexto(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		mascarado.AddAttrs(mascararAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, mascarado)
+}
+
+func (h handlerMascara) WithAttrs(attrs []slog.Attr) slog.Handler {
+	mascarados := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		mascarados[i] = mascararAttr(a)
+	}
+	return handlerMascara{h.Handler.WithAttrs(mascarados)}
+}
+
+func (h handlerMascara) WithGroup(nome string) slog.Handler {
+	return handlerMascara{h.Handler.WithGroup(nome)}
+}
+
+// mascararAttr mascara os textos e os erros de um atributo (e dos grupos)
+func mascararAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, nfe.MascararTexto(v.String()))
+	case slog.KindGroup:
+		grupo := v.Group()
+		mascarados := make([]any, len(grupo))
+		for i, g := range grupo {
+			mascarados[i] = mascararAttr(g)
+		}
+		return slog.Group(a.Key, mascarados...)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, nfe.MascararTexto(err.Error()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
@@ -0,0 +1,671 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Estados de um lote assíncrono
+const (
+	lotePendente    = "pendente"    // aguardando vaga (ver lotesSimultaneos)
+	loteProcessando = "processando" // baixando a origem ou validando
+	loteConcluido   = "concluido"   // todos os XMLs validados (falhas de validação ficam nos resultados)
+	loteFalhou      = "falhou"      // a origem não pôde ser lida (ex: URL inacessível)
+)
+
+// lotesSimultaneos limita os lotes validados ao mesmo tempo; os demais ficam pendentes
+const lotesSimultaneos = 2
+
+// timeoutDownloadLote limita o download da origem http(s) de um lote
+const timeoutDownloadLote = 5 * time.Minute
+
+// Padrões de -batch-max-pending e -batch-max-stored
+const (
+	lotesAbertosPadrao   = 16   // cada lote de upload fica em memória até terminar (até -max-body-mb)
+	lotesGuardadosPadrao = 1000 // concluídos aguardando -batch-ttl mais os abertos
+)
+
+// loteAssincrono é um lote recebido em POST /v1/batches e validado em segundo plano
+type loteAssincrono struct {
+	id     string
+	origem string
+
+	// dono é a identidade que criou o lote; só ela o consulta (vazio sem autenticação)
+	dono string
+
+	mu         sync.Mutex
+	status     string
+	criado     time.Time
+	iniciado   time.Time
+	concluido  time.Time
+	resultados []resultado
+	erro       string
+
+	// avisos acorda os acompanhamentos em GET /v1/batches/{id}/events a cada mudança
+	avisos map[chan struct{}]struct{}
+}
+
+// visaoLote é o corpo JSON de POST /v1/batches e GET /v1/batches/{id}
+type visaoLote struct {
+	ID          string      `json:"id"`
+	Status      string      `json:"status"`
+	Origem      string      `json:"origem"`
+	CriadoEm    time.Time   `json:"criado_em"`
+	IniciadoEm  *time.Time  `json:"iniciado_em,omitempty"`
+	ConcluidoEm *time.Time  `json:"concluido_em,omitempty"`
+	Processados int         `json:"processados"`
+	Resumo      resumoLote  `json:"resumo"`
+	Resultados  []resultado `json:"resultados,omitempty"`
+	Erro        string      `json:"erro,omitempty"`
+}
+
+// visao copia o estado atual do lote; o resumo considera os resultados já prontos
+func (l *loteAssincrono) visao(comResultados bool) visaoLote {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.visaoTravada(comResultados)
+}
+
+// andamento copia o estado atual e os resultados a partir de desde (já enviados ao acompanhamento)
+func (l *loteAssincrono) andamento(desde int) (visaoLote, []resultado) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var novos []resultado
+	if desde < len(l.resultados) {
+		novos = append(novos, l.resultados[desde:]...)
+	}
+	return l.visaoTravada(false), novos
+}
+
+// visaoTravada monta a visão do lote; l.mu deve estar travado
+func (l *loteAssincrono) visaoTravada(comResultados bool) visaoLote {
+	v := visaoLote{
+		ID:          l.id,
+		Status:      l.status,
+		Origem:      l.origem,
+		CriadoEm:    l.criado,
+		Processados: len(l.resultados),
+		Erro:        l.erro,
+	}
+
+	fim := time.Now()
+	if !l.iniciado.IsZero() {
+		v.IniciadoEm = &l.iniciado
+	}
+	if !l.concluido.IsZero() {
+		v.ConcluidoEm = &l.concluido
+		fim = l.concluido
+	}
+	duracao := time.Duration(0)
+	if !l.iniciado.IsZero() {
+		duracao = fim.Sub(l.iniciado)
+	}
+	v.Resumo = novoResumo(l.resultados, duracao)
+
+	if comResultados {
+		v.Resultados = append([]resultado(nil), l.resultados...)
+	}
+	return v
+}
+
+// mudar atualiza o status do lote (registrando início e fim)
+func (l *loteAssincrono) mudar(status, erro string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.status = status
+	l.erro = erro
+	switch status {
+	case loteProcessando:
+		l.iniciado = time.Now()
+	case loteConcluido, loteFalhou:
+		l.concluido = time.Now()
+	}
+	l.avisar()
+}
+
+// adicionar registra um resultado validado
+func (l *loteAssincrono) adicionar(r resultado) {
+	l.mu.Lock()
+	l.resultados = append(l.resultados, r)
+	l.avisar()
+	l.mu.Unlock()
+}
+
+// acompanhar registra um acompanhamento do lote: aviso recebe um sinal a cada
+// resultado ou mudança de status (sinais seguidos se fundem em um só)
+func (l *loteAssincrono) acompanhar() (aviso <-chan struct{}, parar func()) {
+	c := make(chan struct{}, 1)
+
+	l.mu.Lock()
+	if l.avisos == nil {
+		l.avisos = make(map[chan struct{}]struct{})
+	}
+	l.avisos[c] = struct{}{}
+	l.mu.Unlock()
+
+	return c, func() {
+		l.mu.Lock()
+		delete(l.avisos, c)
+		l.mu.Unlock()
+	}
+}
+
+// avisar sinaliza os acompanhamentos sem bloquear; l.mu deve estar travado
+func (l *loteAssincrono) avisar() {
+	for c := range l.avisos {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// terminado indica se o lote não muda mais (concluído ou falhou)
+func (v visaoLote) terminado() bool {
+	return v.Status == loteConcluido || v.Status == loteFalhou
+}
+
+// filaLotes guarda os lotes assíncronos em memória e os executa em segundo plano
+//
+// Os lotes concluídos ficam disponíveis por retencao e depois são descartados;
+// reiniciar o serve descarta todos.
+type filaLotes struct {
+	api      *apiHTTP
+	retencao time.Duration
+
+	// vagas limita os lotes em execução (lotesSimultaneos)
+	vagas chan struct{}
+
+	mu    sync.Mutex
+	lotes map[string]*loteAssincrono
+
+	// abertos conta os lotes pendentes ou em execução, limitados a maxAbertos;
+	// lotes guarda no máximo maxGuardados (os concluídos mais antigos saem antes do prazo)
+	abertos      int
+	maxAbertos   int
+	maxGuardados int
+
+	// execucoes conta os lotes aceitos ainda não terminados (drenados no encerramento)
+	execucoes sync.WaitGroup
+
+	// encerrando é fechado no início do encerramento do servidor HTTP: os acompanhamentos SSE terminam
+	encerrando chan struct{}
+
+	// origens são as origens aceitas em lotes por URL (ver origensLote); vazio desliga as URLs
+	origens map[string]bool
+
+	// downloads baixa as origens http(s): sem redirecionamentos nem endereços internos (ver clienteDownloadLote)
+	downloads *http.Client
+
+	// endpointS3 substitui o endpoint do S3 (ex: MinIO, LocalStack); o cliente é criado no primeiro s3://
+	endpointS3 string
+	s3Once     sync.Once
+	s3         *s3.Client
+	s3Err      error
+}
+
+// configLotes são as opções do serve para os lotes assíncronos
+type configLotes struct {
+	retencao     time.Duration
+	maxAbertos   int
+	maxGuardados int
+	origens      map[string]bool
+	endpointS3   string
+}
+
+// novaFilaLotes cria a fila dos lotes assíncronos da API
+func novaFilaLotes(api *apiHTTP, cfg configLotes) *filaLotes {
+	return &filaLotes{
+		api:          api,
+		retencao:     cfg.retencao,
+		vagas:        make(chan struct{}, lotesSimultaneos),
+		lotes:        make(map[string]*loteAssincrono),
+		maxAbertos:   cfg.maxAbertos,
+		maxGuardados: cfg.maxGuardados,
+		encerrando:   make(chan struct{}),
+		origens:      cfg.origens,
+		downloads:    clienteDownloadLote(),
+		endpointS3:   cfg.endpointS3,
+	}
+}
+
+// origensLote lê -batch-url-allow: origens separadas por vírgula, como s3://bucket ou https://host[:porta]
+//
+// Só as URLs dessas origens são aceitas em POST /v1/batches; sem nenhuma, os
+// lotes precisam vir em multipart.
+func origensLote(lista string) (map[string]bool, error) {
+	origens := make(map[string]bool)
+	for _, item := range strings.Split(lista, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		u, err := url.Parse(item)
+		if err != nil {
+			return nil, fmt.Errorf("origem '%s' inválida: %w", item, err)
+		}
+		switch u.Scheme {
+		case "s3", "https", "http":
+		default:
+			return nil, fmt.Errorf("origem '%s' sem s3://, https:// ou http://", item)
+		}
+		if u.Host == "" || strings.Trim(u.Path, "/") != "" || u.User != nil || u.RawQuery != "" {
+			return nil, fmt.Errorf("origem '%s' deve ter só o esquema e o bucket/host (ex: s3://notas, https://arquivos.exemplo.com.br)", item)
+		}
+		origens[u.Scheme+"://"+strings.ToLower(u.Host)] = true
+	}
+	return origens, nil
+}
+
+// pedidoLote é o corpo JSON de POST /v1/batches com a origem remota dos XMLs
+type pedidoLote struct {
+	// URL do .xml ou pacote .zip/.tar.gz/.tgz: s3://bucket/chave, https:// ou http://
+	URL string `json:"url"`
+}
+
+// handlerCriarLote recebe um lote e responde 202 com o ID, sem esperar a validação (POST /v1/batches)
+//
+// O lote vem em multipart/form-data (campo "arquivos", como no upload) ou em
+// JSON com a URL de um XML ou pacote no S3 ou em http(s). As opções de fase
+// seguem as do upload (xsd_only, skip_sefaz, offline, disable_rules).
+//
+// Exemplo:
+//
+//	curl -F arquivos=@julho.zip http://localhost:8080/v1/batches
+//	curl -d '{"url": "s3://notas/2025/07.zip"}' -H 'Content-Type: application/json' \
+//	    'http://localhost:8080/v1/batches?offline=true'
+func (f *filaLotes) handlerCriarLote(w http.ResponseWriter, r *http.Request) {
+	var (
+		origem string
+		buscar func(ctx context.Context) ([]arquivoUpload, error)
+	)
+
+	if tipo, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); tipo == "application/json" {
+		var pedido pedidoLote
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&pedido); err != nil {
+			responderJSON(w, http.StatusBadRequest, respostaErro{Erro: fmt.Sprintf("JSON inválido: %v", err)})
+			return
+		}
+		if len(f.origens) == 0 {
+			responderJSON(w, http.StatusForbidden, respostaErro{Erro: "lotes por URL desligados neste servidor (ver -batch-url-allow); envie os arquivos em multipart/form-data"})
+			return
+		}
+		u, err := urlLote(pedido.URL)
+		if err != nil {
+			responderJSON(w, http.StatusBadRequest, respostaErro{Erro: err.Error()})
+			return
+		}
+		if !f.origens[u.Scheme+"://"+strings.ToLower(u.Host)] {
+			responderJSON(w, http.StatusForbidden, respostaErro{Erro: fmt.Sprintf("origem '%s://%s' fora das permitidas em -batch-url-allow", u.Scheme, u.Host)})
+			return
+		}
+		origem = origemURL(u)
+		buscar = func(ctx context.Context) ([]arquivoUpload, error) {
+			data, err := f.baixar(ctx, u)
+			if err != nil {
+				return nil, err
+			}
+			return []arquivoUpload{arquivoEmMemoria(origem, data)}, nil
+		}
+	} else {
+		fhs, ok := lerFormularioUpload(w, r, f.api.maxCorpo)
+		if !ok {
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		// Os temporários do multipart somem com a requisição: o lote guarda uma cópia em memória
+		arquivos := make([]arquivoUpload, 0, len(fhs))
+		for _, fh := range fhs {
+			data, err := lerParteUpload(fh)
+			if err != nil {
+				responderJSON(w, http.StatusBadRequest, respostaErro{Erro: fmt.Sprintf("erro ao ler '%s': %v", fh.Filename, err)})
+				return
+			}
+			arquivos = append(arquivos, arquivoEmMemoria(fh.Filename, data))
+		}
+		origem = fmt.Sprintf("upload (%d arquivo(s))", len(arquivos))
+		buscar = func(context.Context) ([]arquivoUpload, error) { return arquivos, nil }
+	}
+
+	opts, err := f.api.opcoes(r)
+	if err != nil {
+		responderJSON(w, http.StatusBadRequest, respostaErro{Erro: err.Error()})
+		return
+	}
+	// O lote continua depois da resposta: mantém o trace, mas não o cancelamento da requisição
+	opts.ctx = context.WithoutCancel(r.Context())
+
+	l := &loteAssincrono{id: novoIDLote(), origem: origem, dono: nomeIdentidade(r.Context()), status: lotePendente, criado: time.Now()}
+	if err := f.guardar(l); err != nil {
+		w.Header().Set("Retry-After", "60")
+		responderJSON(w, http.StatusServiceUnavailable, respostaErro{Erro: err.Error()})
+		return
+	}
+
+	f.execucoes.Add(1)
+	go func() {
+		defer f.execucoes.Done()
+		defer f.fechar()
+		f.executar(l, opts, buscar)
+	}()
+	logInfo("📥 Lote %s recebido: %s", l.id, origem)
+
+	w.Header().Set("Location", "/v1/batches/"+l.id)
+	responderJSON(w, http.StatusAccepted, l.visao(false))
+}
+
+// handlerConsultarLote responde o andamento e os resultados do lote (GET /v1/batches/{id})
+//
+// Com ?resultados=false só o andamento e o resumo são enviados (útil para polling de lotes grandes).
+func (f *filaLotes) handlerConsultarLote(w http.ResponseWriter, r *http.Request) {
+	l, ok := f.loteDaRequisicao(w, r)
+	if !ok {
+		return
+	}
+	responderJSON(w, http.StatusOK, l.visao(r.URL.Query().Get("resultados") != "false"))
+}
+
+// loteDaRequisicao busca o lote de {id}; com ok false a resposta 404 já foi escrita
+//
+// Lotes de outra credencial também respondem 404, sem revelar que existem.
+func (f *filaLotes) loteDaRequisicao(w http.ResponseWriter, r *http.Request) (l *loteAssincrono, ok bool) {
+	f.mu.Lock()
+	l = f.lotes[r.PathValue("id")]
+	f.mu.Unlock()
+
+	if l == nil || l.dono != nomeIdentidade(r.Context()) {
+		responderJSON(w, http.StatusNotFound, respostaErro{Erro: "lote não encontrado (IDs expiram após a retenção configurada em -batch-ttl)"})
+		return nil, false
+	}
+	return l, true
+}
+
+// executar busca a origem e valida o lote quando houver vaga
+func (f *filaLotes) executar(l *loteAssincrono, opts *opcoesValidacao, buscar func(ctx context.Context) ([]arquivoUpload, error)) {
+	defer time.AfterFunc(f.retencao, func() { f.descartar(l.id) })
+
+	f.vagas <- struct{}{}
+	defer func() { <-f.vagas }()
+
+	l.mudar(loteProcessando, "")
+	arquivos, err := buscar(opts.contexto())
+	if err != nil {
+		logErro("❌ Lote %s: %v", l.id, err)
+		l.mudar(loteFalhou, err.Error())
+		return
+	}
+
+	validarLote(itensDoUpload(arquivos), opts, f.api.workers, func(r resultado) {
+		l.adicionar(r)
+		f.api.webhook.notificar(r)
+	})
+	l.mudar(loteConcluido, "")
+
+	v := l.visao(false)
+	logInfo("📦 Lote %s concluído: %d XML(s), %d válido(s)", l.id, v.Resumo.Total, v.Resumo.Validos)
+}
+
+// encerrar encerra os acompanhamentos SSE (registrado em http.Server.RegisterOnShutdown)
+//
+// Sem isso, o Shutdown esperaria cada lote acompanhado terminar.
+func (f *filaLotes) encerrar() {
+	close(f.encerrando)
+}
+
+// aguardar espera os lotes já aceitos terminarem (inclusive os pendentes)
+func (f *filaLotes) aguardar() {
+	f.execucoes.Wait()
+}
+
+// guardar registra um lote novo, respeitando -batch-max-pending e -batch-max-stored
+//
+// Com o armazenamento cheio, o lote terminado mais antigo é descartado antes
+// do prazo de retenção; sem nenhum terminado, o lote novo é recusado.
+func (f *filaLotes) guardar(l *loteAssincrono) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxAbertos > 0 && f.abertos >= f.maxAbertos {
+		return fmt.Errorf("%d lote(s) já aguardam validação (-batch-max-pending); tente mais tarde", f.abertos)
+	}
+	if f.maxGuardados > 0 && len(f.lotes) >= f.maxGuardados {
+		var (
+			antigo    string
+			concluido time.Time
+		)
+		for id, outro := range f.lotes {
+			outro.mu.Lock()
+			fim := outro.concluido
+			outro.mu.Unlock()
+			if !fim.IsZero() && (antigo == "" || fim.Before(concluido)) {
+				antigo, concluido = id, fim
+			}
+		}
+		if antigo == "" {
+			return fmt.Errorf("%d lote(s) em memória, nenhum concluído (-batch-max-stored); tente mais tarde", len(f.lotes))
+		}
+		delete(f.lotes, antigo)
+	}
+
+	f.lotes[l.id] = l
+	f.abertos++
+	return nil
+}
+
+// fechar libera a vaga de um lote aberto (pendente ou em execução) que terminou
+func (f *filaLotes) fechar() {
+	f.mu.Lock()
+	f.abertos--
+	f.mu.Unlock()
+}
+
+// descartar remove o lote após o prazo de retenção
+func (f *filaLotes) descartar(id string) {
+	f.mu.Lock()
+	delete(f.lotes, id)
+	f.mu.Unlock()
+}
+
+// baixar lê o conteúdo da URL do lote, respeitando o tamanho máximo das requisições (-max-body-mb)
+func (f *filaLotes) baixar(ctx context.Context, u *url.URL) ([]byte, error) {
+	var corpo io.ReadCloser
+	if u.Scheme == "s3" {
+		cliente, err := f.clienteS3(ctx)
+		if err != nil {
+			return nil, err
+		}
+		chave := strings.TrimPrefix(u.Path, "/")
+		obj, err := cliente.GetObject(ctx, &s3.GetObjectInput{Bucket: &u.Host, Key: &chave})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao baixar %s: %w", origemURL(u), err)
+		}
+		corpo = obj.Body
+	} else {
+		ctx, cancelar := context.WithTimeout(ctx, timeoutDownloadLote)
+		defer cancelar()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao criar requisição: %w", err)
+		}
+		resp, err := f.downloads.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao baixar %s: %w", origemURL(u), err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("erro ao baixar %s: HTTP %d", origemURL(u), resp.StatusCode)
+		}
+		corpo = resp.Body
+	}
+	defer corpo.Close()
+
+	data, err := io.ReadAll(io.LimitReader(corpo, f.api.maxCorpo+1))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao baixar %s: %w", origemURL(u), err)
+	}
+	if int64(len(data)) > f.api.maxCorpo {
+		return nil, fmt.Errorf("%s maior que %d MB", origemURL(u), f.api.maxCorpo>>20)
+	}
+	return data, nil
+}
+
+// clienteS3 cria (apenas uma vez) o cliente S3 com a configuração AWS padrão
+func (f *filaLotes) clienteS3(ctx context.Context) (*s3.Client, error) {
+	f.s3Once.Do(func() {
+		cfgAWS, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			f.s3Err = fmt.Errorf("falha ao carregar a configuração AWS: %w", err)
+			return
+		}
+		f.s3 = s3.NewFromConfig(cfgAWS, func(o *s3.Options) {
+			if f.endpointS3 != "" {
+				o.BaseEndpoint = aws.String(f.endpointS3)
+				o.UsePathStyle = true
+			}
+		})
+	})
+	return f.s3, f.s3Err
+}
+
+// errRedirecionamento recusa os redirecionamentos no download dos lotes: o destino escaparia de -batch-url-allow
+var errRedirecionamento = errors.New("redirecionamento recusado (informe a URL final do arquivo)")
+
+// clienteDownloadLote cria o cliente http(s) das origens dos lotes
+//
+// A URL vem do cliente da API: o cliente não segue redirecionamentos, ignora
+// os proxies do ambiente e só conecta em endereços públicos. A checagem é
+// feita no IP já resolvido, então um DNS que aponte para a rede interna (ou
+// para o metadata da nuvem em 169.254.169.254) também é recusado.
+func clienteDownloadLote() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(_, endereco string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(endereco)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return fmt.Errorf("endereço '%s' inválido: %w", host, err)
+			}
+			if enderecoInterno(ip) {
+				return fmt.Errorf("endereço %s recusado: rede interna, loopback ou link-local", ip)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: time.Minute,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return errRedirecionamento
+		},
+	}
+}
+
+// faixasInternas são as faixas fora da internet pública que os métodos de netip.Addr não cobrem
+var faixasInternas = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "esta rede"
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT, comum em VPCs e Kubernetes
+	netip.MustParsePrefix("192.0.0.0/24"),  // atribuições do IETF
+	netip.MustParsePrefix("198.18.0.0/15"), // testes de benchmark
+	netip.MustParsePrefix("240.0.0.0/4"),   // reservado (inclui o broadcast)
+}
+
+// enderecoInterno indica se ip é loopback, privado, link-local, multicast ou reservado
+func enderecoInterno(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, faixa := range faixasInternas {
+		if faixa.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// urlLote valida a URL informada em POST /v1/batches
+func urlLote(bruta string) (*url.URL, error) {
+	if bruta == "" {
+		return nil, fmt.Errorf("informe 'url' (s3://bucket/chave ou https://...)")
+	}
+	u, err := url.Parse(bruta)
+	if err != nil {
+		return nil, fmt.Errorf("url inválida: %w", err)
+	}
+	switch u.Scheme {
+	case "s3", "https", "http":
+	default:
+		return nil, fmt.Errorf("url '%s' não suportada (use s3://, https:// ou http://)", bruta)
+	}
+	if u.Host == "" || path.Base(u.Path) == "/" || path.Base(u.Path) == "." {
+		return nil, fmt.Errorf("url '%s' sem bucket/host ou arquivo", bruta)
+	}
+	if ext := path.Base(u.Path); !isXML(ext) && !ehPacote(ext) {
+		return nil, fmt.Errorf("url '%s' não aponta para .xml, .zip, .tar.gz ou .tgz", bruta)
+	}
+	return u, nil
+}
+
+// origemURL é a URL sem query string nem credenciais (ex: URLs pré-assinadas), usada nos nomes dos resultados
+func origemURL(u *url.URL) string {
+	return u.Scheme + "://" + u.Host + u.Path
+}
+
+// lerParteUpload lê uma parte do multipart inteira para a memória
+func lerParteUpload(fh *multipart.FileHeader) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// nomeIdentidade é o nome da credencial da requisição (vazio sem autenticação)
+func nomeIdentidade(ctx context.Context) string {
+	if id := identidadeDe(ctx); id != nil {
+		return id.nome
+	}
+	return ""
+}
+
+// novoIDLote gera um ID aleatório (128 bits, hexadecimal) para o lote
+func novoIDLote() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
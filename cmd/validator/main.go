@@ -1,29 +1,87 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
-	"log"
 	"os"
+	"strings"
 
-	"github.com/fabyo/go-nfe-validator/internal/config"
-	"github.com/fabyo/go-nfe-validator/internal/sefaz"
 	"github.com/fabyo/go-nfe-validator/internal/validation"
 )
 
 func main() {
-	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-	log.Println("⚡️ Iniciando Validador NF-e")
+	// Tracing OpenTelemetry (ligado pelas variáveis OTEL_EXPORTER_OTLP_*; ver iniciarTracing)
+	iniciarTracing()
+
+	// --- SUBCOMANDOS ---
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			sair(runValidate(os.Args[2:]))
+		case "watch":
+			sair(runWatch(os.Args[2:]))
+		case "chave":
+			sair(runChave(os.Args[2:]))
+		case "danfe":
+			sair(runDanfe(os.Args[2:]))
+		case "status":
+			sair(runStatus(os.Args[2:]))
+		case "dist":
+			sair(runDist(os.Args[2:]))
+		case "manifestar":
+			sair(runManifestar(os.Args[2:]))
+		case "ator-interessado":
+			sair(runAtorInteressado(os.Args[2:]))
+		case "entrega":
+			sair(runEntrega(os.Args[2:]))
+		case "prorrogacao":
+			sair(runProrrogacao(os.Args[2:]))
+		case "recibo":
+			sair(runRecibo(os.Args[2:]))
+		case "serve":
+			sair(runServe(os.Args[2:]))
+		case "kafka":
+			sair(runKafka(os.Args[2:]))
+		case "rabbitmq":
+			sair(runRabbitMQ(os.Args[2:]))
+		case "sqs":
+			sair(runSQS(os.Args[2:]))
+		case "recheck":
+			sair(runRecheck(os.Args[2:]))
+		case "completion":
+			sair(runCompletion(os.Args[2:]))
+		}
+	}
 
 	// --- FLAGS DE LINHA DE COMANDO ---
 	xsdOnly := flag.Bool("xsd", false, "Validar apenas contra XSD (sem consulta SEFAZ)")
 	skipSefaz := flag.Bool("skip-sefaz", false, "Pular consulta SEFAZ (valida XSD + parse dados)")
-	chaveAcesso := flag.String("chave", "", "Consultar apenas pela chave de acesso (44 dígitos)")
+	offline := flag.Bool("offline", false, "Validação local completa (XSD + parse + assinatura + regras); SEFAZ marcada como pulada")
+	chaveAcesso := flag.String("chave", "", "Obsoleto: use o subcomando \"chave -sefaz <44_digitos>\"")
+	disableRules := flag.String("disable-rules", "", "IDs de regras de negócio a desabilitar, separados por vírgula (ex: ncm,cfop)")
+	versaoSaida := registrarFlagVersaoSaida(flag.CommandLine)
+	configOpts := registrarFlagConfig(flag.CommandLine)
+	logOpts := registrarFlagsLog(flag.CommandLine)
 	
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Uso: %s [opções] <arquivo_xml> <arquivo_xsd>\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "   ou: %s -chave=<44_digitos>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Uso: %s [opções] <arquivo_xml> [arquivo_xsd]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s chave [-sefaz] <44_digitos>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s danfe [-o nota.pdf] <procNFe.xml>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s status [UF...|todas]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s dist sync -dest <diretório>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s manifestar <44_digitos> -tipo ciencia|confirmacao|desconhecimento|nao-realizada [-just \"...\"]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s ator-interessado <44_digitos> -doc <CNPJ|CPF> [-subcontratados] [-seq N]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s entrega <44_digitos> -doc <documento> -nome \"...\" -imagem <arquivo> | -cancelar <protocolo>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s prorrogacao <44_digitos> -prot <nProt> -itens <nItem:qtde,...> | -cancelar <protocolo>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s recibo [-dest <diretório>] <nRec>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s validate [opções] <arquivo|diretório|glob>...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s watch [opções] <diretório>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s serve [-grpc :9090]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s kafka -topic <tópico> -output-topic <tópico>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s rabbitmq -queue <fila> -exchange <exchange>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s sqs -queue-url <url>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s recheck -store <dsn> [-schedule \"*/30 * * * *\"]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "   ou: %s completion bash|zsh|fish\n\n", os.Args[0])
 		fmt.Fprintln(os.Stderr, "Opções:")
 		flag.PrintDefaults()
 		fmt.Fprintln(os.Stderr, "\nExemplos:")
@@ -36,199 +94,149 @@ func main() {
 		fmt.Fprintln(os.Stderr, "  # XSD + Parse, sem consultar SEFAZ")
 		fmt.Fprintln(os.Stderr, "  ./validator -skip-sefaz nota.xml schema.xsd")
 		fmt.Fprintln(os.Stderr, "")
-		fmt.Fprintln(os.Stderr, "  # Consulta direta por chave de acesso (sem XML)")
-		fmt.Fprintln(os.Stderr, "  ./validator -chave=35250732409620000175550010000037471011544648")
+		fmt.Fprintln(os.Stderr, "  # Validação local completa, com assinatura digital (sem SEFAZ)")
+		fmt.Fprintln(os.Stderr, "  ./validator -offline nota.xml schema.xsd")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Apenas o JSON no stdout (sem logs), para scripts")
+		fmt.Fprintln(os.Stderr, "  ./validator -quiet -skip-sefaz nota.xml schema.xsd | jq .")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # XSD, certificados e regras vindos do validator.yaml")
+		fmt.Fprintln(os.Stderr, "  ./validator -config validator.yaml nota.xml")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Validar/decompor a chave de acesso e consultar na SEFAZ (sem XML)")
+		fmt.Fprintln(os.Stderr, "  ./validator chave -sefaz 35250732409620000175550010000037471011544648")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Gerar o DANFE (PDF) de uma nota autorizada")
+		fmt.Fprintln(os.Stderr, "  ./validator danfe nota.xml -o nota.pdf")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Disponibilidade da SEFAZ antes de um lote grande")
+		fmt.Fprintln(os.Stderr, "  ./validator status todas")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Baixar as notas destinadas ao CNPJ (continua do último NSU)")
+		fmt.Fprintln(os.Stderr, "  ./validator dist sync -dest ./recebidas")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Manifestar ciência da operação de uma nota recebida")
+		fmt.Fprintln(os.Stderr, "  ./validator manifestar 35250732409620000175550010000037471011544648 -tipo ciencia")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Autorizar a transportadora a baixar o XML da nota (evento 110150)")
+		fmt.Fprintln(os.Stderr, "  ./validator ator-interessado 35250732409620000175550010000037471011544648 -doc 12345678000199")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Registrar o comprovante de entrega (evento 110130)")
+		fmt.Fprintln(os.Stderr, "  ./validator entrega 35250732409620000175550010000037471011544648 -doc 12345678900 -nome \"Maria da Silva\" -imagem assinatura.png")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Pedir a prorrogação do prazo de retorno de uma remessa para industrialização")
+		fmt.Fprintln(os.Stderr, "  ./validator prorrogacao 35250732409620000175550010000037471011544648 -prot 135250000012345 -itens 1:150,3:12.5")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Recuperar os protocolos de um lote enviado cujo retorno se perdeu (timeout)")
+		fmt.Fprintln(os.Stderr, "  ./validator recibo 351000012345678 -dest ./protocolos")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Validação em lote (diretório ou glob, com recursão via **)")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -skip-sefaz './notas/**/*.xml'")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Serviço gRPC (ValidateXML, ValidateChave, ValidateBatch)")
+		fmt.Fprintln(os.Stderr, "  ./validator serve -grpc :9090")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Consumir XMLs de um tópico Kafka e produzir os resultados em outro")
+		fmt.Fprintln(os.Stderr, "  ./validator kafka -brokers localhost:9092 -topic nfe.xml -output-topic nfe.resultados")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Consumir XMLs de uma fila RabbitMQ e publicar os resultados em um exchange")
+		fmt.Fprintln(os.Stderr, "  ./validator rabbitmq -queue nfe.xml -exchange nfe.resultados")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Validar os XMLs enviados ao S3 (eventos ObjectCreated entregues no SQS)")
+		fmt.Fprintln(os.Stderr, "  ./validator sqs -queue-url https://sqs.sa-east-1.amazonaws.com/123456789012/nfe-xml")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Reconsultar na SEFAZ, a cada 6h, as notas pendentes e as autorizações recentes do histórico")
+		fmt.Fprintln(os.Stderr, "  ./validator recheck -store sqlite:validacoes.db -schedule '0 */6 * * *'")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  # Autocompletar subcomandos, flags e arquivos no bash")
+		fmt.Fprintln(os.Stderr, "  source <(./validator completion bash)")
 	}
 	
 	flag.Parse()
 
-	// --- MODO: CONSULTA APENAS POR CHAVE ---
-	if *chaveAcesso != "" {
-		validateByChave(*chaveAcesso)
-		return
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		sair(saidaErro)
 	}
-
-	// Validar argumentos para modo normal
-	if flag.NArg() < 2 {
-		flag.Usage()
-		os.Exit(1)
+	if err := validarVersaoSaida(*versaoSaida); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		sair(saidaErro)
 	}
 
-	xmlPath := flag.Arg(0)
-	xsdPath := flag.Arg(1)
-
-	// Carregar configuração
-	cfg := config.Load()
-	
-	log.Printf("Ambiente ativo: %s (UF %s)", cfg.Env, cfg.UF)
-	
-	if *xsdOnly {
-		log.Println("Nível de validação: XSD apenas")
-	} else if *skipSefaz {
-		log.Println("Nível de validação: XSD + Parse")
-	} else {
-		log.Println("Nível de validação: Completa (XSD + Parse + SEFAZ)")
-	}
-
-	// Resultado da validação
-	result := validation.ValidationResponse{
-		Tipo: "nfe",
-	}
-
-	// --- FASE 1: VALIDAÇÃO XSD (SEMPRE OBRIGATÓRIA) ---
-	log.Println("➡️ Fase 1: Validação XSD...")
-	
-	xmlData, err := os.ReadFile(xmlPath)
-	if err != nil {
-		result.ValidoXSD = false
-		result.Erro = fmt.Sprintf("Erro ao ler arquivo XML: %v", err)
-		printResult(result)
-		os.Exit(1)
-	}
-	
-	if err := validation.ValidateWithXSD(xmlData, xsdPath); err != nil {
-		result.ValidoXSD = false
-		result.Erro = fmt.Sprintf("Falha na validação XSD: %v", err)
-		printResult(result)
-		os.Exit(1)
-	}
-	result.ValidoXSD = true
-	log.Println("   ✅ XSD válido")
-
-	// Se apenas XSD, retornar aqui
-	if *xsdOnly {
-		log.Println("✅ Validação XSD concluída. Pulando fases 2 e 3 (--xsd ativo)")
-		printResult(result)
-		return
+	// --- MODO: CONSULTA APENAS POR CHAVE (obsoleto, mantido por compatibilidade) ---
+	if *chaveAcesso != "" {
+		logAviso("⚠️ -chave está obsoleto; use: %s chave -sefaz <44_digitos>", os.Args[0])
+		sair(consultarChave(validation.OnlyDigits(*chaveAcesso), true, configOpts))
 	}
 
-	// --- FASE 2: PARSE DO XML ---
-	log.Println("➡️ Fase 2: Parse do XML...")
-	nfe, err := validation.ParseNFe(xmlData)
+	arq, err := configOpts.carregar()
 	if err != nil {
-		result.Erro = fmt.Sprintf("Falha ao parsear XML: %v", err)
-		printResult(result)
-		os.Exit(1)
+		logErro("❌ %v", err)
+		sair(saidaConectividade)
 	}
-
-	// Extrair chave de acesso
-	result.ChaveAcesso = validation.ExtractChaveFromID(nfe.InfNFe.ID)
-	if result.ChaveAcesso == "" {
-		result.ChaveAcesso = nfe.InfNFe.ID
+	if arq.caminho != "" {
+		logInfo("⚙️ Configuração: %s", arq.descricao())
 	}
 
-	// Preencher dados do XML
-	result.DadosXML = &validation.DadosXMLNFe{
-		Modelo:       nfe.InfNFe.Ide.Modelo,
-		Serie:        nfe.InfNFe.Ide.Serie,
-		Numero:       nfe.InfNFe.Ide.NumNf,
-		EmitCNPJ:     nfe.InfNFe.Emit.CNPJ,
-		EmitRazao:    nfe.InfNFe.Emit.XNome,
-		DestDoc:      validation.ChooseFirstNonEmpty(nfe.InfNFe.Dest.CNPJ, nfe.InfNFe.Dest.CPF),
-		DestNome:     nfe.InfNFe.Dest.XNome,
-		ValorTotalNF: nfe.InfNFe.Total.ICMSTot.VNF,
+	// Validar argumentos para modo normal (o XSD pode vir do arquivo de configuração)
+	xsdPath := arq.schema("")
+	if flag.NArg() >= 2 {
+		xsdPath = flag.Arg(1)
 	}
-	log.Println("   ✅ XML parseado com sucesso")
-
-	// Se skip-sefaz, retornar aqui
-	if *skipSefaz {
-		log.Println("✅ Validação XSD + Parse concluída. Pulando fase 3 (--skip-sefaz ativo)")
-		result.Sefaz = validation.SefazStatus{
-			Autorizado: false,
-			Codigo:     "N/A",
-			Mensagem:   "Consulta SEFAZ não realizada (--skip-sefaz)",
-		}
-		printResult(result)
-		return
+	if flag.NArg() < 1 || xsdPath == "" {
+		flag.Usage()
+		sair(1)
 	}
 
-	// --- FASE 3: CONSULTA SEFAZ ---
-	log.Println("➡️ Fase 3: Consulta SEFAZ (mTLS)...")
+	xmlPath := flag.Arg(0)
 
-	client, err := sefaz.NewClient(cfg)
+	// Carregar configuração
+	cfg, err := arq.carregarConfig()
 	if err != nil {
-		result.Erro = fmt.Sprintf("Falha ao configurar cliente SEFAZ: %v", err)
-		printResult(result)
-		os.Exit(1)
+		logErro("❌ %v", err)
+		sair(saidaConectividade)
 	}
-
-	status, err := client.ConsultaSituacaoNFe(result.ChaveAcesso)
+	
+	logInfo("Ambiente ativo: %s (UF %s)", cfg.Env, cfg.UF)
+
+	opts := &opcoesValidacao{
+		xsdPath:       xsdPath,
+		xsdOnly:       *xsdOnly,
+		skipSefaz:     *skipSefaz,
+		offline:       *offline,
+		versaoSaida:   *versaoSaida,
+		mascararSaida: logOpts.mascararSaida(),
+		regras:        arq.configRegras(splitList(*disableRules)),
+		cfg:           cfg,
+	}
+	opts.logNivel()
+
+	// Carregar o XSD antes: schema ausente/inválido é erro de configuração, não do XML
+	xsd, err := validation.NewXSDValidator(xsdPath)
 	if err != nil {
-		result.Erro = fmt.Sprintf("Falha na consulta remota: %v", err)
-		result.Sefaz = validation.SefazStatus{
-			Autorizado: false,
-			Codigo:     "",
-			Mensagem:   "",
-		}
-		printResult(result)
-		os.Exit(1)
+		logErro("❌ %v", err)
+		sair(saidaConectividade)
 	}
+	opts.xsd = xsd
 
-	result.Sefaz = status
-	log.Printf("✅ FINAL: Status %s - %s", status.Codigo, status.Mensagem)
-
+	result := validarArquivo(xmlPath, opts)
 	printResult(result)
+	xsd.Close()
+	sair(result.codigoSaida())
 }
 
 // printResult imprime o resultado em JSON
-func printResult(result validation.ValidationResponse) {
-	jsonOutput, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		log.Fatalf("❌ Erro ao gerar JSON: %v", err)
-	}
-	fmt.Println(string(jsonOutput))
+func printResult(result resultado) {
+	printJSON(result)
 }
 
-// validateByChave consulta SEFAZ apenas com a chave de acesso (sem XML)
-func validateByChave(chave string) {
-	log.Println("🔑 Modo: Consulta por chave de acesso")
-	
-	// Validar formato da chave (44 dígitos)
-	if len(chave) != 44 {
-		log.Fatalf("❌ Chave de acesso inválida. Deve ter exatamente 44 dígitos. Recebido: %d dígitos", len(chave))
-	}
-
-	// Verificar se são todos números
-	chaveClean := validation.OnlyDigits(chave)
-	if len(chaveClean) != 44 {
-		log.Fatalf("❌ Chave de acesso inválida. Deve conter apenas números.")
-	}
-
-	log.Printf("Chave: %s", chave)
-
-	// Carregar configuração
-	cfg := config.Load()
-	log.Printf("Ambiente: %s (UF %s)", cfg.Env, cfg.UF)
-
-	// Configurar cliente SEFAZ
-	client, err := sefaz.NewClient(cfg)
-	if err != nil {
-		log.Fatalf("❌ Falha ao configurar cliente SEFAZ: %v", err)
-	}
-
-	log.Println("➡️ Consultando SEFAZ...")
-
-	status, err := client.ConsultaSituacaoNFe(chave)
-	
-	result := validation.ValidationResponse{
-		Tipo:        "nfe",
-		ChaveAcesso: chave,
-		ValidoXSD:   false,
-	}
-	
-	if err != nil {
-		result.Sefaz = validation.SefazStatus{
-			Autorizado: false,
-			Codigo:     "999",
-			Mensagem:   "Erro na consulta",
+// splitList separa uma lista separada por vírgulas, ignorando itens vazios
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
 		}
-		result.Erro = fmt.Sprintf("Falha na consulta: %v", err)
-		printResult(result)
-		os.Exit(1)
 	}
-
-	log.Printf("✅ Status %s - %s", status.Codigo, status.Mensagem)
-
-	result.Sefaz = status
-	printResult(result)
+	return out
 }
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// resultadoManifestacao é a saída JSON do subcomando "manifestar"
+type resultadoManifestacao struct {
+	ChaveAcesso string               `json:"chave_acesso"`
+	Tipo        string               `json:"tipo"`
+	TpEvento    string               `json:"tp_evento,omitempty"`
+	Retorno     *sefaz.RetornoEvento `json:"retorno,omitempty"`
+	Erro        string               `json:"erro,omitempty"`
+}
+
+// runManifestar executa o subcomando "manifestar": registra a manifestação do destinatário
+//
+// Envia ao Ambiente Nacional o evento de ciência, confirmação, desconhecimento
+// ou operação não realizada, assinado com o certificado configurado.
+func runManifestar(args []string) int {
+	flags := flag.NewFlagSet("manifestar", flag.ExitOnError)
+	tipo := flags.String("tipo", "", "Manifestação: "+strings.Join(tiposManifestacao(), ", ")+" (obrigatório)")
+	just := flags.String("just", "", "Justificativa (obrigatória em nao-realizada, 15 a 255 caracteres)")
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s manifestar <44_digitos> -tipo <tipo> [-just \"...\"]\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Opções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator manifestar 35250732409620000175550010000037471011544648 -tipo ciencia")
+		fmt.Fprintln(os.Stderr, "  ./validator manifestar 35250732409620000175550010000037471011544648 -tipo nao-realizada -just \"Mercadoria devolvida na portaria\"")
+	}
+	posicionais := parseIntercalado(flags, args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+
+	if len(posicionais) != 1 || *tipo == "" {
+		flags.Usage()
+		return saidaErro
+	}
+
+	chave := validation.OnlyDigits(posicionais[0])
+	result := resultadoManifestacao{ChaveAcesso: chave, Tipo: *tipo}
+
+	logInfo("📝 Modo: Manifestação do destinatário")
+	logInfo("Chave: %s | Tipo: %s", chave, *tipo)
+
+	if _, err := nfe.DecomporChave(chave); err != nil {
+		result.Erro = fmt.Sprintf("Chave de acesso inválida: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaParse
+	}
+
+	evento, err := sefaz.NovoEventoManifestacao(chave, *tipo, *just)
+	if err != nil {
+		logErro("❌ %v", err)
+		flags.Usage()
+		return saidaErro
+	}
+	result.TpEvento = evento.TpEvento
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	cfg, err := arq.carregarConfig()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	logInfo("Ambiente: %s | CNPJ: %s", cfg.Env, cfg.CNPJ)
+
+	client, err := sefaz.NewClient(*cfg)
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha ao configurar cliente SEFAZ: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaConectividade
+	}
+
+	logInfo("➡️ Enviando evento %s ao Ambiente Nacional...", evento.TpEvento)
+	retorno, err := client.EnviarEvento(evento)
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha no envio do evento: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaConectividade
+	}
+	result.Retorno = &retorno
+	printJSON(result)
+
+	if !retorno.Registrado {
+		logAviso("⚠️ Evento não registrado: %s - %s (lote %s - %s)", retorno.Codigo, retorno.Mensagem, retorno.CodigoLote, retorno.MensagemLote)
+		return saidaRejeitada
+	}
+	logInfo("✅ %s - %s | Protocolo %s", retorno.Codigo, retorno.Mensagem, retorno.Protocolo)
+	return saidaOK
+}
+
+// tiposManifestacao lista os tipos aceitos por -tipo, em ordem alfabética
+func tiposManifestacao() []string {
+	tipos := make([]string, 0, len(sefaz.TiposManifestacao))
+	for t := range sefaz.TiposManifestacao {
+		tipos = append(tipos, t)
+	}
+	sort.Strings(tipos)
+	return tipos
+}
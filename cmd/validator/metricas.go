@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/internal/config"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// observar registra a validação nas métricas, se ligadas
+func (o *opcoesValidacao) observar(r resultado, duracao time.Duration) {
+	if o.metricas == nil {
+		return
+	}
+	v := nfe.Validacao{Resultado: r.resultadoMetrica(), Duracao: duracao}
+	if v.Resultado == nfe.ResultadoXSDInvalido {
+		v.ErroXSD = errors.New(r.Erro)
+	}
+	o.metricas.ObservarValidacao(v)
+}
+
+// resultadoMetrica classifica o resultado nos nfe.Resultado* (rótulo das métricas)
+func (r resultado) resultadoMetrica() string {
+	switch r.codigoSaida() {
+	case saidaOK:
+		if nfe.TemErros(r.Achados) {
+			return nfe.ResultadoReprovada
+		}
+		return nfe.ResultadoAprovada
+	case saidaXSDInvalido:
+		return nfe.ResultadoXSDInvalido
+	case saidaParse:
+		return nfe.ResultadoParse
+	case saidaRejeitada:
+		return nfe.ResultadoRejeitada
+	case saidaConectividade:
+		return nfe.ResultadoConectividade
+	default:
+		return nfe.ResultadoErro
+	}
+}
+
+// certificadoConfig lê o certificado público configurado (NFE_CERT_DIR/NFE_CERT_PUB_FILE)
+//
+// Usado na métrica de validade sem precisar criar o cliente SEFAZ.
+func certificadoConfig(cfg *config.Config) (*x509.Certificate, error) {
+	if cfg.CertPubFile == "" {
+		return nil, errors.New("certificado não configurado (NFE_CERT_PUB_FILE)")
+	}
+	caminho := filepath.Join(cfg.CertDir, cfg.CertPubFile)
+	data, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler certificado: %w", err)
+	}
+	for {
+		var bloco *pem.Block
+		bloco, data = pem.Decode(data)
+		if bloco == nil {
+			return nil, fmt.Errorf("nenhum CERTIFICATE PEM em '%s'", caminho)
+		}
+		if bloco.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(bloco.Bytes)
+		}
+	}
+}
+
+// tenantMetrica é o rótulo tenant da validade do certificado no serve: o CNPJ configurado ou, sem ele, o titular
+//
+// O serve atende uma empresa por processo (um perfil do validator.yaml);
+// o CNPJ é o mesmo identificador usado como tenant no nfe.Gerenciador.
+func tenantMetrica(cfg *config.Config, cert *x509.Certificate) string {
+	if cfg.CNPJ != "" {
+		return cfg.CNPJ
+	}
+	return cert.Subject.CommonName
+}
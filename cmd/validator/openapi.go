@@ -0,0 +1,53 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// especificacaoOpenAPI é o documento OpenAPI 3 da API REST do serve
+//
+// Mantido à mão junto com os handlers: ao mudar uma rota ou um campo do
+// contrato v2 (resultadoV2, visaoLote, resumoLote, respostaErro), atualize
+// também o openapi.yaml. Geradores de SDK leem o documento direto do servidor:
+//
+//	openapi-generator-cli generate -g typescript-fetch \
+//	    -i http://localhost:8080/openapi.json -o sdk/
+//
+//go:embed openapi.yaml
+var especificacaoOpenAPI []byte
+
+// especificacaoOpenAPIJSON converte o documento para JSON (apenas na primeira chamada)
+var especificacaoOpenAPIJSON = sync.OnceValues(func() ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(especificacaoOpenAPI, &doc); err != nil {
+		return nil, fmt.Errorf("erro ao ler openapi.yaml: %w", err)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao converter openapi.yaml para JSON: %w", err)
+	}
+	return data, nil
+})
+
+// handlerOpenAPIYAML entrega o documento OpenAPI em YAML (GET /openapi.yaml)
+func handlerOpenAPIYAML(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+	w.Write(especificacaoOpenAPI)
+}
+
+// handlerOpenAPIJSON entrega o documento OpenAPI em JSON (GET /openapi.json)
+func handlerOpenAPIJSON(w http.ResponseWriter, _ *http.Request) {
+	data, err := especificacaoOpenAPIJSON()
+	if err != nil {
+		responderJSON(w, http.StatusInternalServerError, respostaErro{Erro: err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(data)
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// Formatos de saída aceitos por -format
+const (
+	formatoJSON   = "json"
+	formatoNDJSON = "ndjson"
+	formatoCSV    = "csv"
+	formatoTabela = "table"
+	formatoHTML   = "html"
+	formatoPDF    = "pdf"
+	formatoXLSX   = "xlsx"
+)
+
+// formatosSaida lista os formatos válidos (usado na ajuda e na validação da flag)
+var formatosSaida = []string{formatoJSON, formatoNDJSON, formatoCSV, formatoTabela, formatoHTML, formatoPDF, formatoXLSX}
+
+// saidaResultados escreve os resultados do lote em um formato específico
+//
+// Escrever é chamado uma vez por arquivo, na ordem de entrada; Fechar é
+// chamado ao final do lote com o resumo (formatos que não são streaming
+// escrevem tudo ali).
+type saidaResultados interface {
+	Escrever(r resultado) error
+	Fechar(resumo resumoLote) error
+}
+
+// novaSaida cria o escritor do formato informado
+//
+// Com resumo = true (saída em arquivo via -o), o formato json grava um objeto
+// {"schema_version": ..., "resultados": [...], "resumo": {...}} em vez do array puro.
+// versao é o contrato JSON (-output-version) dos envelopes; cada resultado
+// já traz a sua.
+func novaSaida(formato string, w io.Writer, resumo bool, versao string) (saidaResultados, error) {
+	switch formato {
+	case formatoJSON, "":
+		return &saidaJSON{w: w, comResumo: resumo, versao: versao}, nil
+	case formatoNDJSON:
+		return &saidaNDJSON{enc: json.NewEncoder(w), versao: versao}, nil
+	case formatoCSV:
+		return &saidaCSV{w: csv.NewWriter(w)}, nil
+	case formatoTabela:
+		return &saidaTabela{w: tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)}, nil
+	case formatoHTML:
+		return &saidaHTML{w: w}, nil
+	case formatoPDF:
+		return &saidaPDF{w: w}, nil
+	case formatoXLSX:
+		return &saidaXLSX{w: w}, nil
+	default:
+		return nil, fmt.Errorf("formato de saída inválido '%s' (use %s)", formato, strings.Join(formatosSaida, ", "))
+	}
+}
+
+// saidaJSON acumula os resultados e imprime um único array JSON indentado
+type saidaJSON struct {
+	w         io.Writer
+	comResumo bool
+	versao    string
+	results   []resultado
+}
+
+func (s *saidaJSON) Escrever(r resultado) error {
+	s.results = append(s.results, r)
+	return nil
+}
+
+// Fechar imprime o array de resultados (no stdout o resumo vai para o log, mantendo o formato do array)
+func (s *saidaJSON) Fechar(resumo resumoLote) error {
+	if s.results == nil {
+		s.results = []resultado{}
+	}
+
+	var v any = s.results
+	if s.comResumo {
+		v = struct {
+			SchemaVersion string      `json:"schema_version"`
+			Resultados    []resultado `json:"resultados"`
+			Resumo        resumoLote  `json:"resumo"`
+		}{s.versao, s.results, resumo}
+	}
+
+	jsonOutput, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro ao gerar JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(s.w, string(jsonOutput))
+	return err
+}
+
+// saidaNDJSON imprime um objeto JSON por linha assim que cada resultado fica pronto
+type saidaNDJSON struct {
+	enc    *json.Encoder
+	versao string
+}
+
+func (s *saidaNDJSON) Escrever(r resultado) error {
+	return s.enc.Encode(r)
+}
+
+// Fechar imprime o resumo como última linha: {"schema_version": ..., "resumo": {...}}
+func (s *saidaNDJSON) Fechar(resumo resumoLote) error {
+	return s.enc.Encode(struct {
+		SchemaVersion string     `json:"schema_version"`
+		Resumo        resumoLote `json:"resumo"`
+	}{s.versao, resumo})
+}
+
+// colunasCSV é o cabeçalho da saída CSV
+var colunasCSV = []string{
+	"arquivo", "chave_acesso", "modelo", "serie", "numero", "emitente_cnpj",
+	"valor_total_nota", "valido_xsd", "sefaz_autorizado", "sefaz_codigo",
+	"sefaz_mensagem", "achados_erro", "achados_aviso", "erro", "codigo_erro",
+}
+
+// saidaCSV imprime uma linha CSV por resultado (cabeçalho na primeira linha)
+type saidaCSV struct {
+	w         *csv.Writer
+	cabecalho bool
+}
+
+func (s *saidaCSV) Escrever(r resultado) error {
+	if !s.cabecalho {
+		s.cabecalho = true
+		if err := s.w.Write(colunasCSV); err != nil {
+			return err
+		}
+	}
+
+	r = r.mascarado()
+	var modelo, serie, numero, emitente, valor string
+	if r.DadosXML != nil {
+		modelo = r.DadosXML.Modelo
+		serie = r.DadosXML.Serie
+		numero = r.DadosXML.Numero
+		emitente = r.DadosXML.EmitCNPJ
+		valor = r.DadosXML.ValorTotalNF
+	}
+	erros, avisos := contarAchados(r.Achados)
+
+	if err := s.w.Write([]string{
+		r.Arquivo, r.ChaveAcesso, modelo, serie, numero, emitente, valor,
+		strconv.FormatBool(r.ValidoXSD), strconv.FormatBool(r.Sefaz.Autorizado),
+		r.Sefaz.Codigo, r.Sefaz.Mensagem,
+		strconv.Itoa(erros), strconv.Itoa(avisos), r.Erro, string(r.CodigoErro),
+	}); err != nil {
+		return err
+	}
+	// Flush a cada linha: a saída pode estar sendo acompanhada em tempo real
+	s.w.Flush()
+	return s.w.Error()
+}
+
+func (s *saidaCSV) Fechar(_ resumoLote) error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// saidaTabela imprime uma tabela alinhada para leitura no terminal
+type saidaTabela struct {
+	w         *tabwriter.Writer
+	cabecalho bool
+}
+
+// larguraMaxErro limita a coluna de erro da tabela (a mensagem completa está no JSON)
+const larguraMaxErro = 60
+
+func (s *saidaTabela) Escrever(r resultado) error {
+	if !s.cabecalho {
+		s.cabecalho = true
+		fmt.Fprintln(s.w, "ARQUIVO\tCHAVE\tXSD\tSEFAZ\tACHADOS\tERRO")
+	}
+
+	r = r.mascarado()
+	xsd := "ok"
+	if !r.ValidoXSD {
+		xsd = "falha"
+	}
+
+	sefaz := r.Sefaz.Codigo
+	if sefaz == "" {
+		sefaz = "-"
+	}
+
+	erros, avisos := contarAchados(r.Achados)
+	achados := "-"
+	if erros+avisos > 0 {
+		achados = fmt.Sprintf("%d erro(s), %d aviso(s)", erros, avisos)
+	}
+
+	erro := primeiraLinha(r.Erro)
+	if len([]rune(erro)) > larguraMaxErro {
+		erro = string([]rune(erro)[:larguraMaxErro-1]) + "…"
+	}
+	if erro == "" {
+		erro = "-"
+	}
+
+	_, err := fmt.Fprintf(s.w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+		r.Arquivo, valorOuTraco(r.ChaveAcesso), xsd, sefaz, achados, erro)
+	return err
+}
+
+func (s *saidaTabela) Fechar(_ resumoLote) error {
+	return s.w.Flush()
+}
+
+// contarAchados conta os achados de severidade erro e aviso
+func contarAchados(achados []nfe.Achado) (erros, avisos int) {
+	for _, a := range achados {
+		switch a.Severidade {
+		case nfe.SeveridadeErro:
+			erros++
+		case nfe.SeveridadeAviso:
+			avisos++
+		}
+	}
+	return erros, avisos
+}
+
+// primeiraLinha retorna apenas a primeira linha de uma mensagem
+func primeiraLinha(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// valorOuTraco retorna "-" para valores vazios (melhor leitura na tabela)
+func valorOuTraco(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// arquivoAtomico grava em um arquivo temporário e só o renomeia para o destino em Confirmar
+//
+// Quem lê o destino nunca vê um arquivo pela metade: ou o resultado anterior
+// ou o novo completo (rename é atômico no mesmo sistema de arquivos).
+type arquivoAtomico struct {
+	*os.File
+	destino string
+}
+
+// criarArquivoAtomico cria o temporário no mesmo diretório do destino
+func criarArquivoAtomico(destino string) (*arquivoAtomico, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(destino), "."+filepath.Base(destino)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo de saída: %w", err)
+	}
+	return &arquivoAtomico{File: tmp, destino: destino}, nil
+}
+
+// Confirmar grava no disco e substitui o destino pelo arquivo completo
+func (a *arquivoAtomico) Confirmar() error {
+	if err := a.Sync(); err != nil {
+		a.Descartar()
+		return fmt.Errorf("erro ao gravar arquivo de saída: %w", err)
+	}
+	if err := a.Close(); err != nil {
+		os.Remove(a.Name())
+		return fmt.Errorf("erro ao gravar arquivo de saída: %w", err)
+	}
+	if err := os.Chmod(a.Name(), 0o644); err != nil {
+		os.Remove(a.Name())
+		return fmt.Errorf("erro ao gravar arquivo de saída: %w", err)
+	}
+	if err := os.Rename(a.Name(), a.destino); err != nil {
+		os.Remove(a.Name())
+		return fmt.Errorf("erro ao mover arquivo de saída para '%s': %w", a.destino, err)
+	}
+	return nil
+}
+
+// Descartar remove o temporário sem tocar no destino
+func (a *arquivoAtomico) Descartar() {
+	a.Close()
+	os.Remove(a.Name())
+}
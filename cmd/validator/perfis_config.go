@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// chavePerfis é a seção do arquivo de configuração com os perfis
+const chavePerfis = "perfis"
+
+// lerArvoreConfig interpreta o YAML ou TOML como árvore de nós, antes de aplicar perfis e variáveis
+//
+// O TOML é convertido para a mesma árvore; só o YAML guarda as linhas de
+// cada valor para as mensagens de erro.
+func lerArvoreConfig(caminho string, data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	switch strings.ToLower(filepath.Ext(caminho)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		var valores map[string]any
+		if _, err := toml.Decode(string(data), &valores); err != nil {
+			return nil, err
+		}
+		if err := doc.Encode(valores); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("formato de configuração não suportado '%s' (use .yaml, .yml ou .toml)", caminho)
+	}
+
+	raiz := &doc
+	if raiz.Kind == yaml.DocumentNode && len(raiz.Content) > 0 {
+		raiz = raiz.Content[0]
+	}
+	switch raiz.Kind {
+	case yaml.MappingNode:
+		return raiz, nil
+	case 0, yaml.DocumentNode:
+		return &yaml.Node{Kind: yaml.MappingNode}, nil // Arquivo vazio
+	}
+	return nil, fmt.Errorf("linha %d: o arquivo deve ser um mapa de chaves (ex: ambiente: homologacao)", raiz.Line)
+}
+
+// aplicarPerfis tira a seção perfis da raiz e mescla nela os perfis pedidos, na ordem
+//
+// Sem perfis pedidos, aplica o perfil com o nome do ambiente (o informado,
+// vindo de $NFE_ENV, ou a chave ambiente do arquivo), se existir. Nos
+// perfis, mapas são mesclados chave a chave e os demais valores (inclusive
+// listas) substituem os da base.
+// Chaves desconhecidas são erro, na base e em todos os perfis (mesmo os não
+// aplicados). Devolve os perfis aplicados.
+func aplicarPerfis(raiz *yaml.Node, pedidos []string, ambiente string) ([]string, error) {
+	perfis := removerChave(raiz, chavePerfis)
+	if perfis != nil && perfis.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("linha %d: perfis deve ser um mapa de nome do perfil para configuração", perfis.Line)
+	}
+
+	tipo := reflect.TypeFor[arquivoConfig]()
+	erros := camposDesconhecidos(raiz, tipo, "")
+	for _, nome := range nomesChaves(perfis) {
+		if perfil := valorChave(perfis, nome); perfil.Kind == yaml.MappingNode {
+			erros = append(erros, camposDesconhecidos(perfil, tipo, chavePerfis+"."+nome)...)
+		}
+	}
+	if err := errors.Join(erros...); err != nil {
+		return nil, err
+	}
+
+	if len(pedidos) == 0 {
+		if no := valorChave(raiz, "ambiente"); ambiente == "" && no != nil {
+			ambiente, _ = expandir(no.Value)
+		}
+		if ambiente == "" || valorChave(perfis, ambiente) == nil {
+			return nil, nil
+		}
+		pedidos = []string{ambiente}
+	}
+
+	for _, nome := range pedidos {
+		perfil := valorChave(perfis, nome)
+		switch {
+		case perfil == nil:
+			return nil, fmt.Errorf("perfil '%s' não existe (disponíveis: %s)", nome, strings.Join(nomesChaves(perfis), ", "))
+		case perfil.Kind != yaml.MappingNode:
+			return nil, fmt.Errorf("linha %d: o perfil '%s' deve ser um mapa de chaves", perfil.Line, nome)
+		}
+		mesclarNos(raiz, perfil)
+	}
+	return pedidos, nil
+}
+
+// mesclarNos aplica o mapa sobre no mapa base: mapas nos dois lados são mesclados, o resto é substituído
+func mesclarNos(base, sobre *yaml.Node) {
+	for i := 0; i+1 < len(sobre.Content); i += 2 {
+		chave, valor := sobre.Content[i], sobre.Content[i+1]
+		atual := valorChave(base, chave.Value)
+		switch {
+		case atual == nil:
+			base.Content = append(base.Content, chave, valor)
+		case atual.Kind == yaml.MappingNode && valor.Kind == yaml.MappingNode:
+			mesclarNos(atual, valor)
+		default:
+			*atual = *valor
+		}
+	}
+}
+
+// valorChave devolve o nó do valor da chave no mapa (nil se o mapa não tem a chave)
+func valorChave(mapa *yaml.Node, chave string) *yaml.Node {
+	if mapa == nil || mapa.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapa.Content); i += 2 {
+		if mapa.Content[i].Value == chave {
+			return mapa.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// removerChave tira a chave do mapa e devolve o nó do valor (nil se o mapa não tem a chave)
+func removerChave(mapa *yaml.Node, chave string) *yaml.Node {
+	for i := 0; i+1 < len(mapa.Content); i += 2 {
+		if mapa.Content[i].Value == chave {
+			valor := mapa.Content[i+1]
+			mapa.Content = slices.Delete(mapa.Content, i, i+2)
+			return valor
+		}
+	}
+	return nil
+}
+
+// nomesChaves lista as chaves do mapa, em ordem
+func nomesChaves(mapa *yaml.Node) []string {
+	var nomes []string
+	if mapa != nil {
+		for i := 0; i < len(mapa.Content); i += 2 {
+			nomes = append(nomes, mapa.Content[i].Value)
+		}
+	}
+	slices.Sort(nomes)
+	return nomes
+}
+
+// variavelConfig encontra ${VAR}, ${VAR:-padrão}, ${VAR:?mensagem} e $$ nos valores
+var variavelConfig = regexp.MustCompile(`\$(?:\$|\{([A-Za-z_][A-Za-z0-9_]*)(?:(:-|:\?)([^}]*))?\})`)
+
+// interpolar substitui as variáveis de ambiente em todos os valores da árvore
+//
+// Um valor sem aspas volta a ter o tipo resolvido depois da substituição
+// (tentativas: ${WEBHOOK_TENTATIVAS:-3} vira número).
+func interpolar(no *yaml.Node) []error {
+	var erros []error
+	switch no.Kind {
+	case yaml.ScalarNode:
+		if !strings.Contains(no.Value, "$") {
+			return nil
+		}
+		valor, err := expandir(no.Value)
+		if err != nil {
+			return []error{fmt.Errorf("linha %d: %w", no.Line, err)}
+		}
+		no.Value = valor
+		if no.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			no.Tag = ""
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(no.Content); i += 2 {
+			erros = append(erros, interpolar(no.Content[i])...)
+		}
+	case yaml.SequenceNode, yaml.DocumentNode:
+		for _, filho := range no.Content {
+			erros = append(erros, interpolar(filho)...)
+		}
+	}
+	return erros
+}
+
+// expandir substitui as variáveis de ambiente do valor
+//
+// ${VAR} fica vazio se VAR não estiver definida; ${VAR:-padrão} usa o padrão
+// se VAR estiver vazia; ${VAR:?mensagem} é um erro se VAR estiver vazia; $$
+// é um $ literal.
+func expandir(valor string) (string, error) {
+	var erro error
+	resultado := variavelConfig.ReplaceAllStringFunc(valor, func(m string) string {
+		if m == "$$" {
+			return "$"
+		}
+		partes := variavelConfig.FindStringSubmatch(m)
+		nome, operador, argumento := partes[1], partes[2], partes[3]
+		v := os.Getenv(nome)
+		switch {
+		case v != "":
+			return v
+		case operador == ":-":
+			return argumento
+		case operador == ":?" && erro == nil:
+			if argumento == "" {
+				argumento = "obrigatória"
+			}
+			erro = fmt.Errorf("variável %s não definida: %s", nome, argumento)
+		}
+		return v
+	})
+	return resultado, erro
+}
+
+// camposDesconhecidos aponta as chaves do mapa que não correspondem a campos do tipo (erros de digitação)
+func camposDesconhecidos(no *yaml.Node, tipo reflect.Type, prefixo string) []error {
+	for tipo.Kind() == reflect.Pointer || tipo.Kind() == reflect.Slice {
+		if tipo.Kind() == reflect.Slice && no.Kind == yaml.SequenceNode {
+			var erros []error
+			for i, item := range no.Content {
+				erros = append(erros, camposDesconhecidos(item, tipo.Elem(), fmt.Sprintf("%s[%d]", prefixo, i))...)
+			}
+			return erros
+		}
+		tipo = tipo.Elem()
+	}
+	if tipo.Kind() != reflect.Struct || no.Kind != yaml.MappingNode {
+		return nil // Mapas livres (regras.severidades) e valores simples
+	}
+
+	var erros []error
+	for i := 0; i+1 < len(no.Content); i += 2 {
+		chave := no.Content[i]
+		nome := chave.Value
+		if prefixo != "" {
+			nome = prefixo + "." + nome
+		}
+		campo, ok := campoYAML(tipo, chave.Value)
+		if !ok {
+			erros = append(erros, fmt.Errorf("linha %d: chave '%s' desconhecida", chave.Line, nome))
+			continue
+		}
+		erros = append(erros, camposDesconhecidos(no.Content[i+1], campo.Type, nome)...)
+	}
+	return erros
+}
+
+// campoYAML encontra o campo exportado do struct com a tag yaml informada
+func campoYAML(tipo reflect.Type, nome string) (reflect.StructField, bool) {
+	for i := range tipo.NumField() {
+		campo := tipo.Field(i)
+		tag, _, _ := strings.Cut(campo.Tag.Get("yaml"), ",")
+		if campo.IsExported() && tag == nome {
+			return campo, true
+		}
+	}
+	return reflect.StructField{}, false
+}
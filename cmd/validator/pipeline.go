@@ -0,0 +1,447 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+
+	"github.com/fabyo/go-nfe-validator/internal/config"
+	"github.com/fabyo/go-nfe-validator/internal/dedup"
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/internal/store"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+	"github.com/fabyo/go-nfe-validator/pkg/nfemetrics"
+)
+
+// resultado é a saída JSON do CLI: resposta da validação + achados das regras de negócio
+type resultado struct {
+	// Arquivo é o caminho do XML validado (preenchido no modo lote)
+	Arquivo string `json:"arquivo,omitempty"`
+
+	validation.ValidationResponse
+	Achados []nfe.Achado `json:"achados,omitempty"`
+
+	// CodigoErro é o código estável da falha (ex: NFE-SEFAZ-TLS); vazio se aprovado
+	CodigoErro nfe.CodigoErro `json:"codigo_erro,omitempty"`
+
+	// Duplicata é preenchida quando a chave já foi validada dentro de -dedup-ttl
+	Duplicata *duplicata `json:"duplicata,omitempty"`
+
+	// saida é o código de saída da fase que falhou (ver codigoSaida)
+	saida int
+
+	// versao é o contrato JSON da saída (ver MarshalJSON)
+	versao string
+
+	// assinaturaConferida indica que a assinatura digital foi verificada (-offline)
+	assinaturaConferida bool
+
+	// mascarar esconde os dados pessoais na saída (-redact all, ver mascarado)
+	mascarar bool
+}
+
+// opcoesValidacao controla as fases executadas por validarArquivo
+type opcoesValidacao struct {
+	xsdPath   string
+	xsdOnly   bool
+	skipSefaz bool
+	offline   bool // XSD + parse + assinatura + regras; SEFAZ marcada como pulada
+	regras    nfe.ConfigRegras
+	cfg       *config.Config
+
+	// versaoSaida é o contrato JSON dos resultados (-output-version)
+	versaoSaida string
+
+	// mascararSaida mascara os dados pessoais dos resultados (-redact all)
+	mascararSaida bool
+
+	// XSD pré-carregado (modo lote); nil = carrega o XSD a cada arquivo
+	xsd *validation.XSDValidator
+
+	// metricas recebe as validações e as chamadas à SEFAZ (serve); nil = desligadas
+	metricas *nfemetrics.Coletor
+
+	// cotaSefaz limita as consultas à SEFAZ de todas as requisições do serve (-sefaz-rate); nil = sem limite
+	cotaSefaz *rate.Limiter
+
+	// historico grava cada resultado no banco (-store); nil = desligado
+	historico store.ResultStore
+
+	// origem é o modo que validou, gravado no histórico (validate, serve, watch...)
+	origem string
+
+	// arquivamento guarda os XMLs aprovados (-archive); nil = desligado
+	arquivamento *arquivamento
+
+	// dedup detecta as chaves já validadas (-dedup); nil = desligado
+	dedup *deduplicador
+
+	// alertas avisa quando uma nota autorizada aparece cancelada ou denegada (-alert); nil = desligado
+	alertas *alertador
+
+	// Cliente SEFAZ criado sob demanda e compartilhado entre os arquivos do lote
+	sefazOnce sync.Once
+	sefaz     *sefaz.Client
+	sefazErr  error
+
+	// pai são as opções do servidor de onde estas foram derivadas (ver derivar)
+	pai *opcoesValidacao
+
+	// ctx é o contexto da requisição (serve), pai dos spans de tracing; nil = context.Background()
+	ctx context.Context
+}
+
+// derivar cria opções para uma requisição a partir das do servidor
+//
+// As flags pedidas na requisição se somam às do servidor (não dá para
+// reativar uma fase que o servidor desligou) e o XSD e o cliente SEFAZ
+// continuam compartilhados.
+func (o *opcoesValidacao) derivar(xsdOnly, skipSefaz, offline bool, desabilitadas []string) *opcoesValidacao {
+	regras := o.regras
+	regras.Desabilitadas = append(append([]string{}, o.regras.Desabilitadas...), desabilitadas...)
+
+	return &opcoesValidacao{
+		xsdPath:       o.xsdPath,
+		xsdOnly:       o.xsdOnly || xsdOnly,
+		skipSefaz:     o.skipSefaz || skipSefaz,
+		offline:       o.offline || offline,
+		regras:        regras,
+		cfg:           o.cfg,
+		versaoSaida:   o.versaoSaida,
+		mascararSaida: o.mascararSaida,
+		xsd:           o.xsd,
+		metricas:      o.metricas,
+		cotaSefaz:     o.cotaSefaz,
+		historico:     o.historico,
+		origem:        o.origem,
+		arquivamento:  o.arquivamento,
+		dedup:         o.dedup,
+		alertas:       o.alertas,
+		pai:           o,
+	}
+}
+
+// clienteSefaz cria (apenas uma vez) o cliente SEFAZ com a configuração carregada
+func (o *opcoesValidacao) clienteSefaz() (*sefaz.Client, error) {
+	if o.pai != nil {
+		return o.pai.clienteSefaz()
+	}
+	o.sefazOnce.Do(func() {
+		o.sefaz, o.sefazErr = novoClienteSefaz(o.cfg)
+		if o.sefazErr == nil && o.metricas != nil {
+			o.sefaz.Observar(func(c sefaz.Chamada) { o.metricas.ObservarSefaz(nfe.ChamadaSefaz(c)) })
+		}
+	})
+	return o.sefaz, o.sefazErr
+}
+
+// novoClienteSefaz cria o cliente SEFAZ do CLI: a resposta bruta das consultas sai cortada com -v
+func novoClienteSefaz(cfg *config.Config) (*sefaz.Client, error) {
+	return sefaz.NewClientCom(*cfg, sefaz.Opcoes{Logger: slog.Default(), LogResposta: nfe.LimiteLogRespostaPadrao})
+}
+
+// contexto retorna o contexto da requisição, ou context.Background() fora do serve
+func (o *opcoesValidacao) contexto() context.Context {
+	if o.ctx != nil {
+		return o.ctx
+	}
+	return context.Background()
+}
+
+// consultaSefaz indica se a fase 3 (consulta SEFAZ) será executada
+func (o *opcoesValidacao) consultaSefaz() bool {
+	return !o.xsdOnly && !o.skipSefaz && !o.offline
+}
+
+// logNivel registra quais fases serão executadas
+func (o *opcoesValidacao) logNivel() {
+	if o.xsdOnly {
+		logInfo("Nível de validação: XSD apenas")
+	} else if o.offline {
+		logInfo("Nível de validação: Offline (XSD + Parse + Assinatura + Regras, sem SEFAZ)")
+	} else if o.skipSefaz {
+		logInfo("Nível de validação: XSD + Parse")
+	} else {
+		logInfo("Nível de validação: Completa (XSD + Parse + SEFAZ)")
+	}
+}
+
+// opcoesNfe traduz as flags de nível para as Options de nfe.Client.Validar
+func (o *opcoesValidacao) opcoesNfe() nfe.Options {
+	opcoes := nfe.Options{XSD: o.xsdPath, Assinatura: o.offline}
+	switch {
+	case o.xsdOnly:
+		opcoes.Nivel = nfe.NivelXSD
+	case !o.consultaSefaz():
+		opcoes.Nivel = nfe.NivelParse
+	}
+	// XSD pré-carregado (modo lote); um *XSDValidator nil não pode virar um Schema não nil
+	if o.xsd != nil {
+		opcoes.Schema = o.xsd
+	}
+	return opcoes
+}
+
+// validarArquivo lê o XML do disco e executa as fases de validação
+func validarArquivo(xmlPath string, opts *opcoesValidacao) resultado {
+	return validarItem(itemLote{nome: xmlPath, ler: lerArquivo(xmlPath)}, opts)
+}
+
+// validarItem lê o XML (arquivo ou entrada de pacote) e executa as fases de validação
+func validarItem(item itemLote, opts *opcoesValidacao) resultado {
+	xmlData, err := item.ler()
+	if err != nil {
+		result := resultado{
+			ValidationResponse: validation.ValidationResponse{Tipo: "nfe"},
+			versao:             opts.versaoSaida,
+			mascarar:           opts.mascararSaida,
+		}
+		result.Erro = fmt.Sprintf("Erro ao ler arquivo XML: %v", err)
+		result.CodigoErro = nfe.CodigoXMLIlegivel
+		result.saida = saidaErro
+		return result
+	}
+
+	return validarXML(xmlData, opts)
+}
+
+// validarXML executa as fases de validação (XSD, parse + regras, SEFAZ) sobre o XML em memória
+//
+// A primeira falha interrompe o fluxo e é registrada em resultado.Erro.
+func validarXML(xmlData []byte, opts *opcoesValidacao) resultado {
+	ctx, span := tracer.Start(opts.contexto(), "nfe.validar")
+	defer span.End()
+
+	inicio := time.Now()
+	result := executarFases(ctx, xmlData, opts)
+	opts.observar(result, time.Since(inicio))
+	opts.arquivar(ctx, xmlData, result)
+	opts.guardarChave(ctx, result)
+	opts.alertar(ctx, xmlData, result)
+	anotarValidacao(span, result)
+	return result
+}
+
+// executarFases é o corpo de validarXML, sem as métricas
+//
+// As fases são as de nfe.Client.Validar (cada uma um span filho de ctx); a
+// consulta SEFAZ passa por consultaCLI, que acrescenta o cliente
+// compartilhado, a cota (-sefaz-rate) e as duplicatas (-dedup).
+func executarFases(ctx context.Context, xmlData []byte, opts *opcoesValidacao) resultado {
+	result := resultado{
+		ValidationResponse: validation.ValidationResponse{Tipo: "nfe"},
+		versao:             opts.versaoSaida,
+		mascarar:           opts.mascararSaida,
+	}
+
+	consulta := &consultaCLI{opts: opts, ctx: ctx}
+	client, err := nfe.NewClient(nfe.Config{Regras: opts.regras}, nfe.WithConsulter(consulta))
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha ao configurar validação: %v", err)
+		result.CodigoErro = nfe.CodigoDe(err)
+		result.saida = saidaErro
+		return result
+	}
+	client.Use(hookLog)
+
+	// Sem cancelamento: uma consulta SEFAZ interrompida vira falha de
+	// conectividade no resultado, como as demais (consultaCLI usa o ctx original)
+	r, err := client.ValidarContext(context.WithoutCancel(ctx), xmlData, opts.opcoesNfe())
+	if err != nil {
+		result.Erro = err.Error()
+		result.CodigoErro = nfe.CodigoDe(err)
+		result.saida = saidaErro
+		return result
+	}
+
+	result.CodigoErro = r.CodigoErro()
+	result.ValidoXSD = r.ValidoXSD
+	result.ChaveAcesso = r.ChaveAcesso
+	result.Achados = r.Achados
+	if d := r.DadosNFe; d != nil {
+		result.DadosXML = &validation.DadosXMLNFe{
+			Modelo:       d.Modelo,
+			Serie:        d.Serie,
+			Numero:       d.Numero,
+			EmitCNPJ:     d.Emitente.Documento,
+			EmitRazao:    d.Emitente.Nome,
+			DestDoc:      d.Destinatario.Documento,
+			DestNome:     d.Destinatario.Nome,
+			ValorTotalNF: d.ValorTotal,
+		}
+	}
+
+	if r.DadosNFe != nil {
+		result.assinaturaConferida = opts.offline && opts.regras.Habilitada(nfe.RegraAssinatura)
+		logAchados(result)
+	}
+
+	if r.Erro != nil {
+		falha := errors.Unwrap(r.Erro)
+		switch r.Erro.Fase {
+		case nfe.FaseXSD:
+			result.Erro = fmt.Sprintf("Falha na validação XSD: %v", falha)
+			result.saida = saidaXSDInvalido
+		case nfe.FaseParse:
+			result.Erro = fmt.Sprintf("Falha ao parsear XML: %v", falha)
+			result.saida = saidaParse
+		default:
+			// A mensagem já vem com o contexto da etapa (ver consultaCLI)
+			result.Erro = falha.Error()
+			result.saida = saidaConectividade
+			result.Sefaz.Consulta = validation.ConsultaFalhou
+		}
+		return result
+	}
+
+	if opts.xsdOnly {
+		logDetalhe("✅ Validação XSD concluída. Pulando fases 2 e 3 (--xsd ativo)")
+		result.Sefaz = sefazPulada("-xsd")
+		return result
+	}
+
+	// Chave já validada dentro de -dedup-ttl (buscada antes da consulta SEFAZ, quando houve)
+	if !consulta.buscou {
+		consulta.anterior = opts.buscarDuplicata(ctx, result.ChaveAcesso)
+	}
+	if anterior := consulta.anterior; anterior != nil {
+		result.Duplicata = &duplicata{ValidadoEm: anterior.ValidadoEm, Origem: anterior.Origem}
+		logDetalhe("   🔁 Chave já validada em %s", anterior.ValidadoEm.Format(time.RFC3339))
+	}
+
+	switch {
+	case opts.offline:
+		logDetalhe("✅ Validação offline concluída. Pulando fase 3 (-offline ativo)")
+		result.Sefaz = sefazPulada("-offline")
+	case opts.skipSefaz:
+		logDetalhe("✅ Validação XSD + Parse concluída. Pulando fase 3 (--skip-sefaz ativo)")
+		result.Sefaz = sefazPulada("--skip-sefaz")
+	case consulta.reaproveitada:
+		result.Sefaz = consulta.status
+		result.Duplicata.SefazReaproveitada = true
+		logDetalhe("✅ FINAL: Status %s - %s (consulta anterior reaproveitada, -dedup skip)", result.Sefaz.Codigo, result.Sefaz.Mensagem)
+	default:
+		result.Sefaz = consulta.status
+		logDetalhe("✅ FINAL: Status %s - %s", result.Sefaz.Codigo, result.Sefaz.Mensagem)
+	}
+	return result
+}
+
+// inicioFases é o registro de início das fases numeradas (exibido com -v)
+var inicioFases = map[string]string{
+	nfe.FaseXSD:   "➡️ Fase 1: Validação XSD...",
+	nfe.FaseParse: "➡️ Fase 2: Parse do XML...",
+	nfe.FaseSefaz: "➡️ Fase 3: Consulta SEFAZ (mTLS)...",
+}
+
+// hookLog registra o andamento das fases enquanto a validação acontece (-v e -vv)
+var hookLog = nfe.Hook{
+	Antes: func(_ context.Context, fase string, _ *nfe.ValidationResult) error {
+		if msg, ok := inicioFases[fase]; ok {
+			logDetalhe("%s", msg)
+		}
+		return nil
+	},
+	Depois: func(_ context.Context, f nfe.ExecucaoFase, _ *nfe.ValidationResult) {
+		if f.Situacao == nfe.SituacaoOK {
+			switch f.Fase {
+			case nfe.FaseXSD:
+				logDetalhe("   ✅ XSD válido")
+			case nfe.FaseParse:
+				logDetalhe("   ✅ XML parseado com sucesso")
+			}
+		}
+		logDebug("   ⏱️ Fase %s (%s) em %s", f.Fase, f.Situacao, f.Duracao)
+	},
+}
+
+// logAchados registra a conferência da assinatura (-offline) e os achados das regras de negócio
+func logAchados(result resultado) {
+	if result.assinaturaConferida {
+		conferiu := true
+		for _, a := range result.Achados {
+			if a.Regra == nfe.RegraAssinatura {
+				logDetalhe("   ⚠️ Assinatura digital: %s", a.Inconsistencia.Mensagem)
+				conferiu = false
+				break
+			}
+		}
+		if conferiu {
+			logDetalhe("   ✅ Assinatura digital confere")
+		}
+	}
+	if len(result.Achados) > 0 {
+		logDetalhe("   ⚠️ Regras de negócio: %d achado(s)", len(result.Achados))
+	} else {
+		logDetalhe("   ✅ Regras de negócio sem achados")
+	}
+}
+
+// consultaCLI é o nfe.Consulter de uma validação do CLI
+//
+// Antes de consultar, procura a chave nas duplicatas (-dedup) e, em -dedup
+// skip, devolve a consulta anterior. Guarda o status completo da SEFAZ para
+// a saída JSON.
+type consultaCLI struct {
+	opts *opcoesValidacao
+	ctx  context.Context // Contexto da validação, com o cancelamento e o prazo originais
+
+	buscou        bool            // buscarDuplicata já foi chamada
+	anterior      *dedup.Anterior // Validação anterior da chave (-dedup); nil = primeira
+	reaproveitada bool            // status veio da validação anterior (-dedup skip)
+	status        validation.SefazStatus
+}
+
+// ConsultarSituacao consulta a chave com o cliente SEFAZ compartilhado, respeitando a cota
+func (c *consultaCLI) ConsultarSituacao(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+	c.buscou = true
+	c.anterior = c.opts.buscarDuplicata(c.ctx, chave)
+	if c.opts.reaproveitarSefaz(c.anterior) {
+		c.status, c.reaproveitada = c.anterior.Sefaz, true
+		return consultaNfe(c.status), nil
+	}
+
+	// O span da fase vem de ctx; o cancelamento e o prazo, da validação
+	ctx = trace.ContextWithSpan(c.ctx, trace.SpanFromContext(ctx))
+
+	client, err := c.opts.clienteSefaz()
+	if err != nil {
+		return nfe.ConsultaSefaz{}, fmt.Errorf("Falha ao configurar cliente SEFAZ: %w", err)
+	}
+	if err := c.opts.esperarCotaSefaz(ctx); err != nil {
+		return nfe.ConsultaSefaz{}, err
+	}
+	status, err := client.ConsultaSituacaoNFeContext(ctx, chave)
+	if err != nil {
+		return nfe.ConsultaSefaz{}, fmt.Errorf("Falha na consulta remota: %w", err)
+	}
+
+	c.status = status
+	return consultaNfe(status), nil
+}
+
+// consultaNfe converte o status SEFAZ do CLI para o do pacote nfe
+func consultaNfe(s validation.SefazStatus) nfe.ConsultaSefaz {
+	return nfe.ConsultaSefaz{
+		Autorizado: s.Autorizado,
+		Status:     nfe.StatusSefaz{Codigo: s.Codigo, Mensagem: s.Mensagem},
+		Resposta:   s.Protocolo,
+	}
+}
+
+// sefazPulada é o status da fase 3 quando a consulta não é executada
+func sefazPulada(flag string) validation.SefazStatus {
+	return validation.SefazStatus{
+		Autorizado: false,
+		Codigo:     "N/A",
+		Mensagem:   "Consulta SEFAZ não realizada (" + flag + ")",
+		Consulta:   validation.ConsultaPulada,
+	}
+}
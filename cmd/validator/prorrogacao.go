@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// resultadoProrrogacao é a saída JSON do subcomando "prorrogacao"
+type resultadoProrrogacao struct {
+	ChaveAcesso string               `json:"chave_acesso"`
+	TpEvento    string               `json:"tp_evento,omitempty"`
+	Prazo       int                  `json:"prazo"`
+	Retorno     *sefaz.RetornoEvento `json:"retorno,omitempty"`
+	Erro        string               `json:"erro,omitempty"`
+}
+
+// runProrrogacao executa o subcomando "prorrogacao": pede (ou cancela) a prorrogação do prazo de retorno da remessa para industrialização
+//
+// Envia ao autorizador da UF da chave o evento 111500/111501 com os itens e
+// quantidades a prorrogar, ou o 111502/111503 com -cancelar. Com -schema, o
+// envEvento assinado é conferido contra o XSD do evento antes do envio. Com
+// -resposta, só interpreta a resposta do Fisco (411500 a 411503) recebida.
+func runProrrogacao(args []string) int {
+	flags := flag.NewFlagSet("prorrogacao", flag.ExitOnError)
+	prot := flags.String("prot", "", "Protocolo de autorização da NF-e de remessa (nProt)")
+	itens := flags.String("itens", "", "Itens e quantidades a prorrogar: nItem:quantidade,... (ex: 1:150,3:12.5)")
+	prazo := flags.Int("prazo", 1, "Prazo prorrogado: 1 (primeiro) ou 2 (segundo)")
+	seq := flags.Int("seq", 1, "Sequencial do pedido (com -cancelar, o do pedido cancelado)")
+	cancelar := flags.String("cancelar", "", "Protocolo do pedido de prorrogação a cancelar")
+	schema := flags.String("schema", "", "XSD do envEvento do pedido de prorrogação, conferido antes do envio")
+	resposta := flags.String("resposta", "", "Interpreta a resposta do Fisco (procEventoNFe 411500 a 411503) e sai")
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s prorrogacao <44_digitos> -prot <nProt> -itens <nItem:qtde,...> [opções]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "     %s prorrogacao <44_digitos> -cancelar <protocolo do pedido> [-prazo 2] [-seq N]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "     %s prorrogacao -resposta <procEventoNFe.xml>\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Opções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator prorrogacao 35250732409620000175550010000037471011544648 -prot 135250000012345 -itens 1:150,3:12.5")
+		fmt.Fprintln(os.Stderr, "  ./validator prorrogacao 35250732409620000175550010000037471011544648 -cancelar 135250000067890")
+		fmt.Fprintln(os.Stderr, "  ./validator prorrogacao -resposta 411500-resposta.xml")
+	}
+	posicionais := parseIntercalado(flags, args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+
+	if *resposta != "" {
+		if len(posicionais) != 0 {
+			flags.Usage()
+			return saidaErro
+		}
+		return lerRespostaProrrogacao(*resposta)
+	}
+
+	cancelamento := *cancelar != ""
+	if len(posicionais) != 1 || (cancelamento && (*prot != "" || *itens != "")) || (!cancelamento && (*prot == "" || *itens == "")) {
+		flags.Usage()
+		return saidaErro
+	}
+
+	chave := validation.OnlyDigits(posicionais[0])
+	result := resultadoProrrogacao{ChaveAcesso: chave, Prazo: *prazo}
+
+	logInfo("⏳ Modo: Pedido de prorrogação de prazo (remessa para industrialização)")
+	logInfo("Chave: %s | Prazo: %d", chave, *prazo)
+
+	if _, err := nfe.DecomporChave(chave); err != nil {
+		result.Erro = fmt.Sprintf("Chave de acesso inválida: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaParse
+	}
+
+	var evento sefaz.Evento
+	var err error
+	if cancelamento {
+		evento, err = sefaz.NovoEventoCancelamentoProrrogacao(chave, *cancelar, *prazo, *seq)
+	} else {
+		var lista []sefaz.ItemProrrogacao
+		if lista, err = parseItensProrrogacao(*itens); err == nil {
+			evento, err = sefaz.NovoEventoPedidoProrrogacao(chave, validation.OnlyDigits(*prot), *prazo, *seq, lista)
+		}
+	}
+	if err != nil {
+		logErro("❌ %v", err)
+		flags.Usage()
+		return saidaErro
+	}
+	result.TpEvento = evento.TpEvento
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	cfg, err := arq.carregarConfig()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	logInfo("Ambiente: %s | CNPJ: %s", cfg.Env, cfg.CNPJ)
+
+	client, err := sefaz.NewClient(*cfg)
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha ao configurar cliente SEFAZ: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaConectividade
+	}
+
+	if *schema != "" {
+		xsd, err := validation.NewXSDValidator(*schema)
+		if err != nil {
+			logErro("❌ %v", err)
+			return saidaErro
+		}
+		defer xsd.Close()
+		client.ValidarEvento(evento.TpEvento, xsd)
+	}
+
+	logInfo("➡️ Enviando evento %s ao autorizador de %s...", evento.TpEvento, nfe.SiglaUF(evento.COrgao))
+	retorno, err := client.EnviarEvento(evento)
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha no envio do evento: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		if errors.Is(err, sefaz.ErrPayload) {
+			return saidaXSDInvalido
+		}
+		return saidaConectividade
+	}
+	result.Retorno = &retorno
+	printJSON(result)
+
+	if !retorno.Registrado {
+		logAviso("⚠️ Evento não registrado: %s - %s (lote %s - %s)", retorno.Codigo, retorno.Mensagem, retorno.CodigoLote, retorno.MensagemLote)
+		return saidaRejeitada
+	}
+	logInfo("✅ %s - %s | Protocolo %s", retorno.Codigo, retorno.Mensagem, retorno.Protocolo)
+	return saidaOK
+}
+
+// lerRespostaProrrogacao imprime a resposta do Fisco ao pedido (ou ao cancelamento) lida do arquivo
+func lerRespostaProrrogacao(caminho string) int {
+	data, err := os.ReadFile(caminho)
+	if err != nil {
+		logErro("❌ erro ao ler %s: %v", caminho, err)
+		return saidaErro
+	}
+	resp, err := sefaz.LerRespostaProrrogacao(data)
+	if err != nil {
+		logErro("❌ %s: %v", caminho, err)
+		return saidaParse
+	}
+	printJSON(resp)
+	return saidaOK
+}
+
+// parseItensProrrogacao interpreta -itens: "nItem:quantidade" separados por vírgula
+func parseItensProrrogacao(s string) ([]sefaz.ItemProrrogacao, error) {
+	var itens []sefaz.ItemProrrogacao
+	for _, parte := range strings.Split(s, ",") {
+		num, qtde, ok := strings.Cut(strings.TrimSpace(parte), ":")
+		n, errNum := strconv.Atoi(num)
+		q, errQtde := strconv.ParseFloat(qtde, 64)
+		if !ok || errNum != nil || errQtde != nil {
+			return nil, fmt.Errorf("-itens: '%s' inválido, use nItem:quantidade (ex: 1:150,3:12.5)", parte)
+		}
+		itens = append(itens, sefaz.ItemProrrogacao{NumItem: n, Quantidade: q})
+	}
+	return itens, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// resultadoRecibo é a saída JSON do subcomando "recibo"
+type resultadoRecibo struct {
+	Recibo   string               `json:"recibo"`
+	UF       string               `json:"uf"`
+	Retorno  *sefaz.RetornoRecibo `json:"retorno,omitempty"`
+	Arquivos []string             `json:"arquivos,omitempty"`
+	Erro     string               `json:"erro,omitempty"`
+}
+
+// runRecibo executa o subcomando "recibo": consulta no NFeRetAutorizacao4 o resultado de um lote pelo número do recibo
+//
+// Para o emissor que enviou o lote e perdeu a resposta (timeout depois do
+// recibo): lista o protocolo de cada nota e, com -dest, grava cada protNFe em
+// <chave>-protNFe.xml para montar o nfeProc sem reenviar o lote.
+func runRecibo(args []string) int {
+	flags := flag.NewFlagSet("recibo", flag.ExitOnError)
+	uf := flags.String("uf", "", "UF do emissor, sigla ou código IBGE (padrão: a da configuração)")
+	dest := flags.String("dest", "", "Diretório onde gravar o protNFe de cada nota (<chave>-protNFe.xml)")
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s recibo [opções] <nRec>\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Opções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator recibo 351000012345678")
+		fmt.Fprintln(os.Stderr, "  ./validator recibo 351000012345678 -dest ./protocolos")
+	}
+	posicionais := parseIntercalado(flags, args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+	if len(posicionais) != 1 {
+		flags.Usage()
+		return saidaErro
+	}
+
+	result := resultadoRecibo{Recibo: validation.OnlyDigits(posicionais[0])}
+	if len(result.Recibo) != 15 {
+		logErro("❌ Recibo inválido: deve ter 15 dígitos (tem %d)", len(result.Recibo))
+		return saidaErro
+	}
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	cfg, err := arq.carregarConfig()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+
+	result.UF = nfe.SiglaUF(cfg.UF)
+	if *uf != "" {
+		if result.UF = nfe.SiglaUF(*uf); result.UF == "" {
+			result.UF = *uf
+		}
+	}
+	if sefaz.Autorizador(result.UF) == "" {
+		logErro("❌ UF '%s' desconhecida: informe -uf (ou configure NFE_UF_IBGE / uf no validator.yaml)", result.UF)
+		return saidaErro
+	}
+
+	logInfo("🧾 Modo: Consulta de lote por recibo")
+	logInfo("Ambiente: %s | UF: %s (%s) | Recibo: %s", cfg.Env, result.UF, sefaz.Autorizador(result.UF), result.Recibo)
+
+	client, err := sefaz.NewClient(*cfg)
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha ao configurar cliente SEFAZ: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaConectividade
+	}
+
+	retorno, err := client.ConsultaRecibo(context.Background(), result.Recibo, result.UF, cfg.Producao())
+	if err != nil {
+		result.Erro = fmt.Sprintf("Falha na consulta do recibo: %v", err)
+		logErro("❌ %s", result.Erro)
+		printJSON(result)
+		return saidaConectividade
+	}
+	result.Retorno = &retorno
+
+	if *dest != "" && len(retorno.Protocolos) > 0 {
+		if result.Arquivos, err = gravarProtocolos(*dest, retorno.Protocolos); err != nil {
+			result.Erro = err.Error()
+			logErro("❌ %s", result.Erro)
+			printJSON(result)
+			return saidaErro
+		}
+	}
+	printJSON(result)
+
+	if retorno.Codigo != sefaz.CStatLoteProcessado {
+		logAviso("⚠️ Lote %s: %s - %s", result.Recibo, retorno.Codigo, retorno.Mensagem)
+		return saidaRejeitada
+	}
+
+	codigo := saidaOK
+	for _, p := range retorno.Protocolos {
+		if p.Autorizado {
+			logInfo("✅ %s: %s - %s | Protocolo %s", p.Chave, p.Codigo, p.Mensagem, p.Protocolo)
+			continue
+		}
+		logAviso("⚠️ %s: %s - %s", p.Chave, p.Codigo, p.Mensagem)
+		codigo = saidaRejeitada
+	}
+	logInfo("📊 %d nota(s) no lote", len(retorno.Protocolos))
+	return codigo
+}
+
+// gravarProtocolos grava o protNFe de cada nota em dest como <chave>-protNFe.xml
+func gravarProtocolos(dest string, protocolos []sefaz.ProtocoloNota) ([]string, error) {
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório %s: %w", dest, err)
+	}
+
+	var caminhos []string
+	for _, p := range protocolos {
+		chave := validation.OnlyDigits(p.Chave)
+		if len(chave) != 44 {
+			return caminhos, fmt.Errorf("protocolo %s sem chave de acesso válida: %q", p.Protocolo, p.Chave)
+		}
+		caminho := filepath.Join(dest, chave+"-protNFe.xml")
+		arquivo, err := criarArquivoAtomico(caminho)
+		if err != nil {
+			return caminhos, err
+		}
+		if _, err := arquivo.Write([]byte(p.XML)); err != nil {
+			arquivo.Descartar()
+			return caminhos, fmt.Errorf("erro ao gravar protocolo da chave %s: %w", p.Chave, err)
+		}
+		if err := arquivo.Confirmar(); err != nil {
+			return caminhos, err
+		}
+		caminhos = append(caminhos, caminho)
+	}
+	return caminhos, nil
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/fabyo/go-nfe-validator/internal/store"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// Padrões do subcomando recheck
+const (
+	agendaReconsultaPadrao = "*/30 * * * *"
+	janelaReconsultaPadrao = 7 * 24 * time.Hour
+	limiteReconsultaPadrao = 500
+)
+
+// cStatsTransitorios são as situações que ainda podem mudar sem nova ação do emitente
+//
+//	105      lote em processamento
+//	108, 109 serviço paralisado (momentaneamente ou sem previsão)
+var cStatsTransitorios = []string{"105", "108", "109"}
+
+// cStatsAutorizados são as autorizações reconsultadas enquanto recentes (a nota ainda pode ser cancelada)
+var cStatsAutorizados = []string{"100", "150"}
+
+// resumoReconsulta é o saldo de uma passada do recheck
+type resumoReconsulta struct {
+	consultadas int
+	alteradas   int
+	falhas      int
+}
+
+// runRecheck executa o subcomando "recheck": reconsulta na SEFAZ as notas do histórico com situação transitória ou autorização recente
+//
+// Cada passada busca no histórico (-store) a validação mais recente de cada
+// chave que terminou em falha de conectividade, lote em processamento (105),
+// serviço paralisado (108/109) ou autorização (100/150), validada dentro de
+// -window. A SEFAZ é consultada de novo, uma chave por vez, e o registro é
+// atualizado com a nova situação. As passadas seguem a agenda cron de
+// -schedule; -once faz uma só passada (para agendadores externos).
+func runRecheck(args []string) int {
+	flags := flag.NewFlagSet("recheck", flag.ExitOnError)
+	agenda := flags.String("schedule", agendaReconsultaPadrao, "Agenda das passadas no formato cron (minuto hora dia mês dia-da-semana) ou @every 1h, @hourly, @daily")
+	janela := flags.Duration("window", janelaReconsultaPadrao, "Reconsulta apenas as notas validadas neste período")
+	limite := flags.Int("limit", limiteReconsultaPadrao, "Máximo de notas de cada situação reconsultadas por passada")
+	umaVez := flags.Bool("once", false, "Faz uma passada e encerra (cron do sistema, Kubernetes CronJob)")
+	shutdownTimeout := registrarFlagEncerramento(flags)
+	storeDSN := registrarFlagStore(flags)
+	alertasDestinos := registrarFlagAlertas(flags)
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s recheck -store <dsn> [opções]\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Reconsulta na SEFAZ as notas pendentes e as autorizações recentes do histórico.")
+		fmt.Fprintln(os.Stderr, "\nOpções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator recheck -store sqlite:validacoes.db")
+		fmt.Fprintln(os.Stderr, "  ./validator recheck -store sqlite:validacoes.db -schedule '0 */6 * * *' -window 72h")
+		fmt.Fprintln(os.Stderr, "  ./validator recheck -once -alert https://hooks.slack.com/services/T000/B000/XXXX")
+	}
+	flags.Parse(args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return saidaErro
+	}
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if arq.caminho != "" {
+		logInfo("⚙️ Configuração: %s", arq.descricao())
+	}
+	if !flagInformada(flags, "schedule") && arq.Reconsulta.Agenda != "" {
+		*agenda = arq.Reconsulta.Agenda
+	}
+	if !flagInformada(flags, "window") && arq.Reconsulta.Janela != "" {
+		// Já conferido em arquivoConfig.validar
+		*janela, _ = time.ParseDuration(arq.Reconsulta.Janela)
+	}
+	if *janela <= 0 {
+		logErro("❌ -window deve ser positivo (recebido %s)", *janela)
+		return saidaErro
+	}
+
+	var proxima cron.Schedule
+	if !*umaVez {
+		if proxima, err = cron.ParseStandard(*agenda); err != nil {
+			logErro("❌ -schedule inválido '%s': %v", *agenda, err)
+			return saidaErro
+		}
+	}
+
+	historico, err := abrirHistorico(*storeDSN, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if historico == nil {
+		logErro("❌ O recheck precisa do histórico de validações: informe -store (ou $%s)", envStoreDSN)
+		return saidaErro
+	}
+	defer historico.Close()
+
+	cfg, err := arq.carregarConfig()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	opts := &opcoesValidacao{historico: historico, origem: "recheck", cfg: cfg}
+	logInfo("Ambiente ativo: %s (UF %s)", opts.cfg.Env, opts.cfg.UF)
+
+	alertas, err := criarAlertador(*alertasDestinos, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	opts.alertas = alertas
+
+	sinal, pararSinais := contextoSinais()
+	defer pararSinais()
+	trabalho, cancelarTrabalho := contextoTrabalho(sinal, *shutdownTimeout)
+	defer cancelarTrabalho()
+	opts.ctx = trabalho
+
+	if *umaVez {
+		resumo := reconsultar(sinal, opts, *janela, *limite)
+		if resumo.falhas > 0 {
+			return saidaConectividade
+		}
+		return saidaOK
+	}
+
+	logInfo("🔄 Reconsulta agendada: '%s', notas dos últimos %s (Ctrl+C para sair)", *agenda, *janela)
+	for {
+		quando := proxima.Next(time.Now())
+		logDetalhe("Próxima passada: %s", quando.Format(time.DateTime))
+
+		espera := time.NewTimer(time.Until(quando))
+		select {
+		case <-espera.C:
+			reconsultar(sinal, opts, *janela, *limite)
+		case <-sinal.Done():
+			espera.Stop()
+			logInfo("🛑 Encerrando recheck")
+			return saidaOK
+		}
+		if sinal.Err() != nil {
+			logInfo("🛑 Encerrando recheck")
+			return saidaOK
+		}
+	}
+}
+
+// reconsultar faz uma passada: busca as notas pendentes e recentes e consulta cada uma na SEFAZ
+//
+// Um sinal de encerramento (sinal cancelado) interrompe a passada entre uma
+// consulta e outra; a consulta em andamento termina dentro do prazo de opts.ctx.
+func reconsultar(sinal context.Context, opts *opcoesValidacao, janela time.Duration, limite int) resumoReconsulta {
+	var resumo resumoReconsulta
+	inicio := time.Now()
+
+	regs, err := notasParaReconsulta(opts.contexto(), opts.historico, time.Now().Add(-janela), limite)
+	if err != nil {
+		logErro("❌ Reconsulta: %v", err)
+		resumo.falhas++
+		return resumo
+	}
+	logInfo("🔄 Reconsulta: %d nota(s) pendente(s) ou recente(s)", len(regs))
+
+	for _, reg := range regs {
+		if sinal.Err() != nil {
+			break
+		}
+		alterada, err := opts.reconsultarRegistro(reg)
+		switch {
+		case err != nil:
+			logAviso("⚠️ Reconsulta de %s: %v", reg.Chave, err)
+			resumo.falhas++
+		case alterada:
+			resumo.alteradas++
+		}
+		resumo.consultadas++
+	}
+
+	logInfo("🔄 Reconsulta concluída em %s: %d consultada(s), %d com nova situação, %d falha(s)",
+		time.Since(inicio).Round(time.Millisecond), resumo.consultadas, resumo.alteradas, resumo.falhas)
+	return resumo
+}
+
+// notasParaReconsulta busca a validação mais recente de cada chave pendente ou recém-autorizada desde o instante
+func notasParaReconsulta(ctx context.Context, historico store.ResultStore, desde time.Time, limite int) ([]store.Registro, error) {
+	filtros := []store.Filtro{{Consulta: validation.ConsultaFalhou}}
+	for _, c := range cStatsTransitorios {
+		filtros = append(filtros, store.Filtro{CStat: c})
+	}
+	for _, c := range cStatsAutorizados {
+		filtros = append(filtros, store.Filtro{CStat: c})
+	}
+
+	var regs []store.Registro
+	for _, f := range filtros {
+		f.UltimoPorChave, f.Desde, f.Limite = true, desde, limite
+		encontrados, err := historico.Buscar(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range encontrados {
+			// Falha antes da fase 3 (sem chave) não tem o que reconsultar
+			if r.Chave != "" {
+				regs = append(regs, r)
+			}
+		}
+	}
+	return regs, nil
+}
+
+// reconsultarRegistro consulta a chave na SEFAZ e atualiza o registro; alterada = o cStat mudou
+//
+// Falha de conectividade não altera o registro (a nota continua na fila da
+// próxima passada). A mudança de uma nota autorizada para cancelada,
+// denegada ou inexistente dispara os alertas (-alert) antes da atualização.
+func (o *opcoesValidacao) reconsultarRegistro(reg store.Registro) (alterada bool, err error) {
+	client, err := o.clienteSefaz()
+	if err != nil {
+		return false, fmt.Errorf("falha ao configurar cliente SEFAZ: %w", err)
+	}
+
+	ctx := o.contexto()
+	status, err := client.ConsultaSituacaoNFeContext(ctx, reg.Chave)
+	if err != nil {
+		return false, fmt.Errorf("falha na consulta remota: %w", err)
+	}
+
+	// O resultado recalcula aprovação e código de saída com a nova situação
+	r := resultado{
+		ValidationResponse: validation.ValidationResponse{
+			Tipo:        "nfe",
+			ChaveAcesso: reg.Chave,
+			ValidoXSD:   reg.ValidoXSD,
+			Sefaz:       status,
+			DadosXML:    &validation.DadosXMLNFe{Modelo: reg.Modelo, EmitCNPJ: reg.EmitenteCNPJ},
+		},
+		Achados: reg.Achados,
+		Arquivo: reg.Arquivo,
+	}
+	o.alertar(ctx, nil, r)
+
+	alterada = status.Codigo != reg.CStat
+	if alterada {
+		logInfo("🔄 %s: %s → %s (%s)", reg.Chave, valorOuTraco(reg.CStat), status.Codigo, status.Mensagem)
+	} else {
+		logDetalhe("   %s: %s sem alteração", reg.Chave, status.Codigo)
+	}
+
+	reg.Aprovado = r.aprovado()
+	reg.CodigoSaida = r.codigoSaida()
+	reg.Erro = ""
+	reg.CStat, reg.Mensagem, reg.Consulta = status.Codigo, status.Mensagem, status.Consulta
+	// Autorizada só com 100 ou 150: o histórico não herda o critério do consultor (110 é denegação)
+	reg.Autorizado = slices.Contains(cStatsAutorizados, status.Codigo)
+	reg.Protocolo = status.Protocolo
+	reg.ReconsultadoEm = time.Now()
+
+	gravacao, cancel := context.WithTimeout(context.WithoutCancel(ctx), tempoGravacao)
+	defer cancel()
+	if err := o.historico.Atualizar(gravacao, reg); err != nil {
+		return alterada, err
+	}
+	return alterada, nil
+}
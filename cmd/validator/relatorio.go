@@ -0,0 +1,210 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// modeloRelatorio é o HTML do relatório do lote (-format html)
+//
+// CSS e JavaScript ficam no próprio arquivo: o relatório gerado é um único
+// HTML, que pode ser enviado por e-mail e aberto sem rede.
+//
+//go:embed relatorio.html
+var modeloRelatorio string
+
+// templateRelatorio é o modeloRelatorio compilado
+var templateRelatorio = template.Must(template.New("relatorio").Funcs(template.FuncMap{
+	"moeda":  moedaRelatorio,
+	"numero": numeroRelatorio,
+}).Parse(modeloRelatorio))
+
+// rotulosSituacao são os nomes das situações do resumo no relatório, na ordem do gráfico
+var rotulosSituacao = []struct{ situacao, rotulo string }{
+	{situacaoValida, "Válida"},
+	{situacaoFalhaXSD, "Falha no XSD"},
+	{situacaoErroParse, "Erro de leitura"},
+	{situacaoRejeitada, "Não autorizada"},
+	{situacaoCancelada, "Cancelada"},
+	{situacaoOutroErro, "Outro erro"},
+}
+
+// barraRelatorio é uma barra dos gráficos do relatório
+type barraRelatorio struct {
+	Rotulo     string
+	Classe     string // situação (cor da barra); vazio nos códigos SEFAZ
+	Quantidade int
+	Percentual float64
+}
+
+// notaRelatorio é uma linha da tabela de notas do relatório
+type notaRelatorio struct {
+	Arquivo    string
+	Chave      string
+	Numero     string
+	Emitente   string
+	Valor      string
+	Situacao   string
+	Rotulo     string
+	Aprovada   bool // passou em todas as fases e não tem achado de erro (ver resultado.aprovado)
+	Sefaz      string
+	Mensagem   string
+	Erro       string
+	CodigoErro nfe.CodigoErro
+	Erros      int
+	Avisos     int
+	Achados    []nfe.Achado
+}
+
+// dadosRelatorio é o que o modeloRelatorio recebe
+type dadosRelatorio struct {
+	Gerado    string
+	Resumo    resumoLote
+	Situacoes []barraRelatorio
+	Codigos   []barraRelatorio
+	Notas     []notaRelatorio
+}
+
+// saidaHTML acumula os resultados e grava o relatório HTML ao final do lote
+type saidaHTML struct {
+	w       io.Writer
+	results []resultado
+}
+
+func (s *saidaHTML) Escrever(r resultado) error {
+	s.results = append(s.results, r.mascarado())
+	return nil
+}
+
+// Fechar grava o relatório com o resumo, os gráficos por situação e por cStat e a tabela de notas
+func (s *saidaHTML) Fechar(resumo resumoLote) error {
+	if err := templateRelatorio.Execute(s.w, novoRelatorio(s.results, resumo, time.Now())); err != nil {
+		return fmt.Errorf("erro ao gerar relatório HTML: %w", err)
+	}
+	return nil
+}
+
+// novoRelatorio monta os dados do relatório a partir dos resultados exibidos
+//
+// Os gráficos contam as notas da saída (depois dos filtros); o resumo conta
+// o lote inteiro, como nos demais formatos.
+func novoRelatorio(results []resultado, resumo resumoLote, gerado time.Time) dadosRelatorio {
+	d := dadosRelatorio{
+		Gerado: gerado.Format("02/01/2006 15:04:05"),
+		Resumo: resumo,
+		Notas:  make([]notaRelatorio, 0, len(results)),
+	}
+
+	porSituacao := make(map[string]int)
+	porCodigo := make(map[string]int)
+	rotulos := make(map[string]string, len(rotulosSituacao))
+	for _, s := range rotulosSituacao {
+		rotulos[s.situacao] = s.rotulo
+	}
+
+	for _, r := range results {
+		situacao := situacaoResultado(r)
+		porSituacao[situacao]++
+		if r.consultouSefaz() {
+			porCodigo[r.Sefaz.Codigo+" - "+r.Sefaz.Mensagem]++
+		}
+
+		erros, avisos := contarAchados(r.Achados)
+		n := notaRelatorio{
+			Arquivo:    r.Arquivo,
+			Chave:      r.ChaveAcesso,
+			Situacao:   situacao,
+			Rotulo:     rotulos[situacao],
+			Aprovada:   r.aprovado(),
+			Sefaz:      r.Sefaz.Codigo,
+			Mensagem:   r.Sefaz.Mensagem,
+			Erro:       r.Erro,
+			CodigoErro: r.CodigoErro,
+			Erros:      erros,
+			Avisos:     avisos,
+			Achados:    r.Achados,
+		}
+		if dados := r.DadosXML; dados != nil {
+			n.Numero = juntarNumeroSerie(dados.Numero, dados.Serie)
+			n.Emitente = dados.EmitRazao
+			if n.Emitente == "" {
+				n.Emitente = dados.EmitCNPJ
+			}
+			n.Valor = dados.ValorTotalNF
+		}
+		d.Notas = append(d.Notas, n)
+	}
+
+	for _, s := range rotulosSituacao {
+		if q := porSituacao[s.situacao]; q > 0 {
+			d.Situacoes = append(d.Situacoes, barraRelatorio{Rotulo: s.rotulo, Classe: s.situacao, Quantidade: q, Percentual: percentual(q, len(results))})
+		}
+	}
+	for codigo, q := range porCodigo {
+		d.Codigos = append(d.Codigos, barraRelatorio{Rotulo: codigo, Quantidade: q, Percentual: percentual(q, len(results))})
+	}
+	sort.Slice(d.Codigos, func(i, j int) bool {
+		if d.Codigos[i].Quantidade != d.Codigos[j].Quantidade {
+			return d.Codigos[i].Quantidade > d.Codigos[j].Quantidade
+		}
+		return d.Codigos[i].Rotulo < d.Codigos[j].Rotulo
+	})
+
+	return d
+}
+
+// juntarNumeroSerie formata "número/série" da nota (vazio se não houver número)
+func juntarNumeroSerie(numero, serie string) string {
+	if numero == "" {
+		return ""
+	}
+	if serie == "" {
+		return numero
+	}
+	return numero + "/" + serie
+}
+
+// percentual calcula a parte do total, com uma casa decimal
+func percentual(parte, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(int64(float64(parte)*1000/float64(total)+0.5)) / 10
+}
+
+// numeroRelatorio formata o número com as casas informadas e vírgula decimal (66.7 -> "66,7")
+func numeroRelatorio(v float64, casas int) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', casas, 64), ".", ",", 1)
+}
+
+// moedaRelatorio formata o valor do XML no padrão brasileiro ("1234.5" -> "1.234,50")
+//
+// Valores que não são números são devolvidos como estão.
+func moedaRelatorio(s string) string {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return s
+	}
+
+	inteiro, fracao, _ := strings.Cut(strconv.FormatFloat(v, 'f', 2, 64), ".")
+	sinal := ""
+	if strings.HasPrefix(inteiro, "-") {
+		sinal, inteiro = "-", inteiro[1:]
+	}
+	var b strings.Builder
+	for i, c := range inteiro {
+		if i > 0 && (len(inteiro)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	return sinal + b.String() + "," + fracao
+}
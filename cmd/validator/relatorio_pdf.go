@@ -0,0 +1,378 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// Layout do resumo em PDF (A4 paisagem, em mm)
+const (
+	margemPDF      = 10.0
+	larguraPDF     = 297.0 - 2*margemPDF
+	alturaLinhaPDF = 5.0
+
+	// limiteMotivos é a quantidade de motivos de reprovação listados
+	limiteMotivos = 10
+)
+
+// colunaPDF é uma coluna da tabela de notas com problema
+type colunaPDF struct {
+	titulo  string
+	largura float64
+	alinh   string
+}
+
+// colunasNotasPDF são as colunas da lista de notas com problema (somam larguraPDF)
+var colunasNotasPDF = []colunaPDF{
+	{"Arquivo", 44, "L"},
+	{"Chave de acesso", 68, "L"},
+	{"Número/Série", 20, "L"},
+	{"Emitente", 48, "L"},
+	{"Valor (R$)", 22, "R"},
+	{"Situação", 25, "L"},
+	{"Motivo", 50, "L"},
+}
+
+// motivoReprovacao é uma linha do ranking de motivos de reprovação
+type motivoReprovacao struct {
+	Motivo     string // cStat da SEFAZ, código da falha ou regra de negócio
+	Exemplo    string // mensagem da primeira nota com o motivo
+	Quantidade int
+}
+
+// saidaPDF acumula os resultados e grava o resumo executivo em PDF ao final do lote
+//
+// Pensado para o fechamento fiscal do mês: totais por situação, principais
+// motivos de reprovação e a lista das notas com problema, sem o detalhe de
+// cada achado (para isso há o -format html).
+type saidaPDF struct {
+	w       io.Writer
+	results []resultado
+}
+
+func (s *saidaPDF) Escrever(r resultado) error {
+	s.results = append(s.results, r.mascarado())
+	return nil
+}
+
+// Fechar gera o PDF com o resumo do lote e os resultados exibidos
+func (s *saidaPDF) Fechar(resumo resumoLote) error {
+	pdf := novoResumoPDF(s.results, resumo, time.Now())
+	if err := pdf.Output(s.w); err != nil {
+		return fmt.Errorf("erro ao gerar relatório PDF: %w", err)
+	}
+	return nil
+}
+
+// resumoPDF guarda o estado da geração do resumo em PDF
+type resumoPDF struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string // converte UTF-8 para a codificação das fontes padrão do PDF
+}
+
+// novoResumoPDF monta o documento: cabeçalho, totais, motivos e notas com problema
+//
+// Como no -format html, totais e gráfico contam as notas da saída (depois dos
+// filtros) e os números do resumo contam o lote inteiro.
+func novoResumoPDF(results []resultado, resumo resumoLote, gerado time.Time) *fpdf.Fpdf {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(margemPDF, margemPDF, margemPDF)
+	pdf.SetAutoPageBreak(true, margemPDF+5)
+	pdf.AliasNbPages("{nb}")
+	pdf.SetCellMargin(1)
+	pdf.SetTitle("Resumo da validação de NF-e", true)
+	pdf.SetCreator("go-nfe-validator", true)
+
+	r := &resumoPDF{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+	dados := novoRelatorio(results, resumo, gerado)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-margemPDF - 3)
+		r.fonte("", 7)
+		pdf.SetTextColor(117, 117, 117)
+		pdf.CellFormat(larguraPDF/2, 4, r.tr("go-nfe-validator · gerado em "+dados.Gerado), "", 0, "L", false, 0, "")
+		pdf.CellFormat(larguraPDF/2, 4, r.tr(fmt.Sprintf("Página %d/{nb}", pdf.PageNo())), "", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+
+	pdf.AddPage()
+	r.cabecalho(dados)
+	r.totais(dados)
+	r.motivos(principaisMotivos(results, limiteMotivos))
+	r.notas(dados.Notas, results)
+	return pdf
+}
+
+// cabecalho escreve o título e a linha com data, quantidade e duração do lote
+func (r *resumoPDF) cabecalho(d dadosRelatorio) {
+	r.fonte("B", 16)
+	r.pdf.CellFormat(larguraPDF, 8, r.tr("Resumo da validação de NF-e"), "", 1, "L", false, 0, "")
+	r.fonte("", 9)
+	r.pdf.SetTextColor(97, 97, 97)
+	sub := fmt.Sprintf("Gerado em %s · %d nota(s) em %ss (%s notas/s)",
+		d.Gerado, d.Resumo.Total, numeroRelatorio(d.Resumo.DuracaoSegundos, 2), numeroRelatorio(d.Resumo.NotasPorSegundo, 1))
+	if d.Resumo.Omitidos > 0 {
+		sub += fmt.Sprintf(" · %d nota(s) fora da lista pelos filtros", d.Resumo.Omitidos)
+	}
+	r.pdf.CellFormat(larguraPDF, 5, r.tr(sub), "", 1, "L", false, 0, "")
+	r.pdf.SetTextColor(0, 0, 0)
+	r.pdf.Ln(3)
+}
+
+// totais escreve os quadros com os números do resumo e as barras por situação
+func (r *resumoPDF) totais(d dadosRelatorio) {
+	quadros := []struct {
+		rotulo string
+		valor  int
+	}{
+		{"Total", d.Resumo.Total},
+		{"Válidas", d.Resumo.Validos},
+		{"Falha no XSD", d.Resumo.FalhasXSD},
+		{"Erro de leitura", d.Resumo.ErrosParse},
+		{"Não autorizadas", d.Resumo.Rejeitadas},
+		{"Canceladas", d.Resumo.Canceladas},
+		{"Outros erros", d.Resumo.Erros},
+		{"Com achados", d.Resumo.ComAchados},
+	}
+	largura := larguraPDF / float64(len(quadros))
+	x, y := margemPDF, r.pdf.GetY()
+	for i, q := range quadros {
+		r.pdf.Rect(x+float64(i)*largura, y, largura-2, 14, "D")
+		r.pdf.SetXY(x+float64(i)*largura, y+1)
+		r.fonte("B", 14)
+		r.pdf.CellFormat(largura-2, 7, strconv.Itoa(q.valor), "", 2, "C", false, 0, "")
+		r.fonte("", 8)
+		r.pdf.CellFormat(largura-2, 5, r.tr(q.rotulo), "", 0, "C", false, 0, "")
+	}
+	r.pdf.SetXY(margemPDF, y+18)
+
+	r.titulo("Totais por situação")
+	for _, b := range d.Situacoes {
+		cor := coresSituacaoPDF[b.Classe]
+		y := r.pdf.GetY()
+		r.fonte("", 9)
+		r.pdf.CellFormat(45, alturaLinhaPDF, r.tr(b.Rotulo), "", 0, "L", false, 0, "")
+		r.pdf.SetFillColor(238, 238, 238)
+		r.pdf.Rect(margemPDF+45, y+1, 150, alturaLinhaPDF-2, "F")
+		r.pdf.SetFillColor(cor[0], cor[1], cor[2])
+		r.pdf.Rect(margemPDF+45, y+1, 150*b.Percentual/100, alturaLinhaPDF-2, "F")
+		r.pdf.SetX(margemPDF + 198)
+		r.pdf.CellFormat(40, alturaLinhaPDF, fmt.Sprintf("%d (%s%%)", b.Quantidade, numeroRelatorio(b.Percentual, 1)), "", 1, "L", false, 0, "")
+	}
+	if len(d.Situacoes) == 0 {
+		r.vazio("Nenhuma nota")
+	}
+
+	aprovadas, problemas := valoresPorAprovacao(d.Notas)
+	r.pdf.Ln(2)
+	r.fonte("", 9)
+	r.pdf.CellFormat(larguraPDF, alturaLinhaPDF, r.tr(fmt.Sprintf("Valor total das notas aprovadas: R$ %s · das notas com problema: R$ %s",
+		moedaRelatorio(aprovadas), moedaRelatorio(problemas))), "", 1, "L", false, 0, "")
+	r.pdf.Ln(3)
+}
+
+// motivos escreve o ranking dos motivos de reprovação
+func (r *resumoPDF) motivos(motivos []motivoReprovacao) {
+	r.titulo("Principais motivos de reprovação")
+	if len(motivos) == 0 {
+		r.vazio("Nenhuma nota reprovada")
+		r.pdf.Ln(3)
+		return
+	}
+
+	colunas := []colunaPDF{{"Notas", 15, "R"}, {"Motivo", 75, "L"}, {"Exemplo", larguraPDF - 90, "L"}}
+	r.linhaTitulos(colunas)
+	for _, m := range motivos {
+		r.linha(colunas, []string{strconv.Itoa(m.Quantidade), m.Motivo, m.Exemplo})
+	}
+	r.pdf.Ln(3)
+}
+
+// notas escreve a lista das notas reprovadas (ver resultado.aprovado)
+func (r *resumoPDF) notas(notas []notaRelatorio, results []resultado) {
+	r.titulo("Notas com problema")
+	linhas := 0
+	for i, n := range notas {
+		if n.Aprovada {
+			continue
+		}
+		if linhas == 0 || r.pdf.GetY()+alturaLinhaPDF > r.limite() {
+			if linhas > 0 {
+				r.pdf.AddPage()
+			}
+			r.linhaTitulos(colunasNotasPDF)
+		}
+		motivo, _ := motivoPrincipal(results[i])
+		valor := ""
+		if n.Valor != "" {
+			valor = moedaRelatorio(n.Valor)
+		}
+		r.linha(colunasNotasPDF, []string{n.Arquivo, n.Chave, n.Numero, n.Emitente, valor, n.Rotulo, motivo})
+		linhas++
+	}
+	if linhas == 0 {
+		r.vazio("Todas as notas foram aprovadas")
+	}
+}
+
+// titulo escreve o título de uma seção, em nova página se não couber com ao menos uma linha
+func (r *resumoPDF) titulo(s string) {
+	if r.pdf.GetY()+8+2*alturaLinhaPDF > r.limite() {
+		r.pdf.AddPage()
+	}
+	r.fonte("B", 11)
+	r.pdf.CellFormat(larguraPDF, 7, r.tr(s), "B", 1, "L", false, 0, "")
+	r.pdf.Ln(1)
+}
+
+// linhaTitulos escreve o cabeçalho de uma tabela
+func (r *resumoPDF) linhaTitulos(colunas []colunaPDF) {
+	r.fonte("B", 8)
+	r.pdf.SetFillColor(245, 245, 245)
+	for _, c := range colunas {
+		r.pdf.CellFormat(c.largura, alturaLinhaPDF, r.tr(c.titulo), "1", 0, c.alinh, true, 0, "")
+	}
+	r.pdf.Ln(-1)
+}
+
+// linha escreve uma linha de tabela, cortando os textos que não cabem na coluna
+func (r *resumoPDF) linha(colunas []colunaPDF, valores []string) {
+	r.fonte("", 7.5)
+	for i, c := range colunas {
+		r.pdf.CellFormat(c.largura, alturaLinhaPDF, r.cortar(valores[i], c.largura-2), "1", 0, c.alinh, false, 0, "")
+	}
+	r.pdf.Ln(-1)
+}
+
+// vazio escreve o aviso de seção sem dados
+func (r *resumoPDF) vazio(s string) {
+	r.fonte("I", 9)
+	r.pdf.SetTextColor(158, 158, 158)
+	r.pdf.CellFormat(larguraPDF, alturaLinhaPDF, r.tr(s), "", 1, "L", false, 0, "")
+	r.pdf.SetTextColor(0, 0, 0)
+}
+
+// cortar converte o texto e o encurta com "..." até caber na largura, na fonte atual
+func (r *resumoPDF) cortar(s string, largura float64) string {
+	t := r.tr(s)
+	if r.pdf.GetStringWidth(t) <= largura {
+		return t
+	}
+	runas := []rune(s)
+	for len(runas) > 0 {
+		runas = runas[:len(runas)-1]
+		if t = r.tr(string(runas) + "..."); r.pdf.GetStringWidth(t) <= largura {
+			return t
+		}
+	}
+	return ""
+}
+
+// fonte troca a fonte (Helvetica) mantendo a família em todo o documento
+func (r *resumoPDF) fonte(estilo string, tamanho float64) {
+	r.pdf.SetFont("Helvetica", estilo, tamanho)
+}
+
+// limite é o Y a partir do qual o conteúdo invade o rodapé
+func (r *resumoPDF) limite() float64 {
+	_, altura := r.pdf.GetPageSize()
+	return altura - margemPDF - 5
+}
+
+// coresSituacaoPDF são as cores das barras por situação (as mesmas do relatório HTML)
+var coresSituacaoPDF = map[string][3]int{
+	situacaoValida:    {46, 125, 50},
+	situacaoFalhaXSD:  {198, 40, 40},
+	situacaoErroParse: {173, 20, 87},
+	situacaoRejeitada: {239, 108, 0},
+	situacaoCancelada: {109, 76, 65},
+	situacaoOutroErro: {84, 110, 122},
+}
+
+// motivosResultado lista os motivos de reprovação da nota, cada um com uma mensagem de exemplo
+//
+// Falha em uma fase: o código estável (NFE-...). SEFAZ não autorizou: o cStat.
+// Passou nas fases mas tem achados de erro: cada regra, uma vez.
+func motivosResultado(r resultado) [][2]string {
+	if r.aprovado() {
+		return nil
+	}
+	if r.Erro != "" {
+		codigo := r.CodigoErro
+		if codigo == "" {
+			codigo = nfe.CodigoDesconhecido
+		}
+		return [][2]string{{string(codigo), r.Erro}}
+	}
+	if r.consultouSefaz() && !r.Sefaz.Autorizado {
+		return [][2]string{{"cStat " + r.Sefaz.Codigo, r.Sefaz.Mensagem}}
+	}
+
+	var motivos [][2]string
+	vistas := make(map[string]bool)
+	for _, a := range r.Achados {
+		if a.Severidade != nfe.SeveridadeErro || vistas[a.Regra] {
+			continue
+		}
+		vistas[a.Regra] = true
+		motivos = append(motivos, [2]string{"Regra " + a.Regra, a.Mensagem})
+	}
+	return motivos
+}
+
+// motivoPrincipal é o primeiro motivo de reprovação da nota (vazio se aprovada)
+func motivoPrincipal(r resultado) (motivo, exemplo string) {
+	if motivos := motivosResultado(r); len(motivos) > 0 {
+		return motivos[0][0], motivos[0][1]
+	}
+	return "", ""
+}
+
+// principaisMotivos conta as notas por motivo de reprovação e devolve os mais frequentes
+func principaisMotivos(results []resultado, limite int) []motivoReprovacao {
+	indice := make(map[string]int)
+	var motivos []motivoReprovacao
+	for _, r := range results {
+		for _, m := range motivosResultado(r) {
+			i, ok := indice[m[0]]
+			if !ok {
+				i = len(motivos)
+				indice[m[0]] = i
+				motivos = append(motivos, motivoReprovacao{Motivo: m[0], Exemplo: m[1]})
+			}
+			motivos[i].Quantidade++
+		}
+	}
+
+	sort.SliceStable(motivos, func(i, j int) bool {
+		return motivos[i].Quantidade > motivos[j].Quantidade
+	})
+	if len(motivos) > limite {
+		motivos = motivos[:limite]
+	}
+	return motivos
+}
+
+// valoresPorAprovacao soma o vNF das notas aprovadas e das reprovadas (como texto do XML, para moedaRelatorio)
+func valoresPorAprovacao(notas []notaRelatorio) (aprovadas, problemas string) {
+	var somaAprovadas, somaProblemas float64
+	for _, n := range notas {
+		v, err := strconv.ParseFloat(strings.TrimSpace(n.Valor), 64)
+		if err != nil {
+			continue
+		}
+		if n.Aprovada {
+			somaAprovadas += v
+		} else {
+			somaProblemas += v
+		}
+	}
+	return strconv.FormatFloat(somaAprovadas, 'f', 2, 64), strconv.FormatFloat(somaProblemas, 'f', 2, 64)
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// saidaXLSX acumula os resultados e grava uma planilha Excel ao final do lote
+//
+// A aba "Notas" tem uma linha por nota (com filtro e cabeçalho fixo) e a aba
+// "Resumo" traz os números do lote, as notas por situação e os principais
+// motivos de reprovação. O arquivo é montado direto no formato OOXML
+// (zip + XML), sem dependência de biblioteca de planilhas.
+type saidaXLSX struct {
+	w       io.Writer
+	results []resultado
+}
+
+func (s *saidaXLSX) Escrever(r resultado) error {
+	s.results = append(s.results, r.mascarado())
+	return nil
+}
+
+// Fechar grava a planilha com as abas Notas e Resumo
+func (s *saidaXLSX) Fechar(resumo resumoLote) error {
+	dados := novoRelatorio(s.results, resumo, time.Now())
+	abas := []abaXLSX{
+		abaNotasXLSX(s.results, dados),
+		abaResumoXLSX(s.results, dados),
+	}
+	if err := gravarXLSX(s.w, abas); err != nil {
+		return fmt.Errorf("erro ao gerar planilha XLSX: %w", err)
+	}
+	return nil
+}
+
+// Estilos das células (índices de cellXfs em estilosXLSX)
+const (
+	estiloPadrao = iota
+	estiloTitulo
+	estiloMoeda
+	estiloQuebra
+	estiloPercentual
+)
+
+// maxTextoCelula é o limite de caracteres de uma célula do Excel
+const maxTextoCelula = 32767
+
+// celulaXLSX é uma célula da planilha: texto, ou número quando numerico = true
+type celulaXLSX struct {
+	valor    string
+	numerico bool
+	estilo   int
+}
+
+// abaXLSX é uma aba da planilha
+type abaXLSX struct {
+	nome     string
+	larguras []float64 // largura de cada coluna, em caracteres
+	linhas   [][]celulaXLSX
+	filtro   bool // cabeçalho fixo e autofiltro na primeira linha
+}
+
+// celulaTexto cria uma célula de texto
+func celulaTexto(s string) celulaXLSX {
+	return celulaXLSX{valor: s}
+}
+
+// celulaInteiro cria uma célula numérica inteira
+func celulaInteiro(n int) celulaXLSX {
+	return celulaXLSX{valor: strconv.Itoa(n), numerico: true}
+}
+
+// celulaTitulo cria uma célula de cabeçalho (negrito)
+func celulaTitulo(s string) celulaXLSX {
+	return celulaXLSX{valor: s, estilo: estiloTitulo}
+}
+
+// celulaValor cria a célula de um valor monetário do XML; se não for número, fica como texto
+func celulaValor(s string) celulaXLSX {
+	s = strings.TrimSpace(s)
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return celulaTexto(s)
+	}
+	return celulaXLSX{valor: s, numerico: true, estilo: estiloMoeda}
+}
+
+// celulaSimNao escreve um booleano como "Sim"/"Não"
+func celulaSimNao(b bool) celulaXLSX {
+	if b {
+		return celulaTexto("Sim")
+	}
+	return celulaTexto("Não")
+}
+
+// colunasNotasXLSX são os títulos da aba Notas
+var colunasNotasXLSX = []struct {
+	titulo  string
+	largura float64
+}{
+	{"Arquivo", 40}, {"Chave de acesso", 48}, {"Modelo", 8}, {"Série", 7}, {"Número", 11},
+	{"CNPJ do emitente", 20}, {"Emitente", 36}, {"Valor total (R$)", 16}, {"XSD válido", 11},
+	{"Situação", 16}, {"Aprovada", 10}, {"cStat", 7}, {"Mensagem SEFAZ", 40},
+	{"Achados de erro", 10}, {"Avisos", 8}, {"Achados", 70}, {"Erro", 60}, {"Código do erro", 20},
+}
+
+// abaNotasXLSX monta a aba com uma linha por nota exibida
+func abaNotasXLSX(results []resultado, dados dadosRelatorio) abaXLSX {
+	aba := abaXLSX{nome: "Notas", filtro: true}
+	cabecalho := make([]celulaXLSX, len(colunasNotasXLSX))
+	for i, c := range colunasNotasXLSX {
+		cabecalho[i] = celulaTitulo(c.titulo)
+		aba.larguras = append(aba.larguras, c.largura)
+	}
+	aba.linhas = append(aba.linhas, cabecalho)
+
+	for i, r := range results {
+		n := dados.Notas[i]
+		var modelo, serie, numero, cnpj, razao string
+		if d := r.DadosXML; d != nil {
+			modelo, serie, numero, cnpj, razao = d.Modelo, d.Serie, d.Numero, d.EmitCNPJ, d.EmitRazao
+		}
+
+		aba.linhas = append(aba.linhas, []celulaXLSX{
+			celulaTexto(r.Arquivo), celulaTexto(r.ChaveAcesso), celulaTexto(modelo), celulaTexto(serie), celulaTexto(numero),
+			celulaTexto(cnpj), celulaTexto(razao), celulaValor(n.Valor), celulaSimNao(r.ValidoXSD),
+			celulaTexto(n.Rotulo), celulaSimNao(n.Aprovada), celulaTexto(r.Sefaz.Codigo), celulaTexto(r.Sefaz.Mensagem),
+			celulaInteiro(n.Erros), celulaInteiro(n.Avisos), {valor: achadosTexto(r), estilo: estiloQuebra},
+			celulaTexto(r.Erro), celulaTexto(string(r.CodigoErro)),
+		})
+	}
+	return aba
+}
+
+// achadosTexto lista os achados da nota, um por linha ("erro [regra]: mensagem")
+func achadosTexto(r resultado) string {
+	linhas := make([]string, 0, len(r.Achados))
+	for _, a := range r.Achados {
+		linha := fmt.Sprintf("%s [%s", a.Severidade, a.Regra)
+		if a.Item > 0 {
+			linha += fmt.Sprintf(", item %d", a.Item)
+		}
+		linhas = append(linhas, linha+"]: "+a.Mensagem)
+	}
+	return strings.Join(linhas, "\n")
+}
+
+// abaResumoXLSX monta a aba com os números do lote, as situações e os motivos de reprovação
+func abaResumoXLSX(results []resultado, dados dadosRelatorio) abaXLSX {
+	r := dados.Resumo
+	aba := abaXLSX{nome: "Resumo", larguras: []float64{36, 14, 12, 80}}
+	linha := func(celulas ...celulaXLSX) {
+		aba.linhas = append(aba.linhas, celulas)
+	}
+
+	linha(celulaTitulo("Resumo da validação de NF-e"))
+	linha(celulaTexto("Gerado em"), celulaTexto(dados.Gerado))
+	linha()
+	linha(celulaTitulo("Indicador"), celulaTitulo("Notas"))
+	for _, i := range []struct {
+		rotulo string
+		valor  int
+	}{
+		{"Total", r.Total},
+		{"Válidas", r.Validos},
+		{"Falha no XSD", r.FalhasXSD},
+		{"Erro de leitura", r.ErrosParse},
+		{"Não autorizadas", r.Rejeitadas},
+		{"Canceladas", r.Canceladas},
+		{"Outros erros", r.Erros},
+		{"Com achados", r.ComAchados},
+		{"Fora da aba Notas pelos filtros", r.Omitidos},
+	} {
+		linha(celulaTexto(i.rotulo), celulaInteiro(i.valor))
+	}
+	linha(celulaTexto("Duração (s)"), celulaXLSX{valor: strconv.FormatFloat(r.DuracaoSegundos, 'f', -1, 64), numerico: true})
+	linha(celulaTexto("Notas por segundo"), celulaXLSX{valor: strconv.FormatFloat(r.NotasPorSegundo, 'f', -1, 64), numerico: true})
+
+	aprovadas, problemas := valoresPorAprovacao(dados.Notas)
+	linha()
+	linha(celulaTitulo("Valor total"), celulaTitulo("R$"))
+	linha(celulaTexto("Notas aprovadas"), celulaValor(aprovadas))
+	linha(celulaTexto("Notas com problema"), celulaValor(problemas))
+
+	linha()
+	linha(celulaTitulo("Situação"), celulaTitulo("Notas"), celulaTitulo("%"))
+	for _, b := range dados.Situacoes {
+		linha(celulaTexto(b.Rotulo), celulaInteiro(b.Quantidade),
+			celulaXLSX{valor: strconv.FormatFloat(b.Percentual/100, 'f', -1, 64), numerico: true, estilo: estiloPercentual})
+	}
+
+	linha()
+	linha(celulaTitulo("Principais motivos de reprovação"), celulaTitulo("Notas"), celulaTexto(""), celulaTitulo("Exemplo"))
+	for _, m := range principaisMotivos(results, limiteMotivos) {
+		linha(celulaTexto(m.Motivo), celulaInteiro(m.Quantidade), celulaTexto(""), celulaTexto(m.Exemplo))
+	}
+	return aba
+}
+
+// gravarXLSX grava as abas como um pacote OOXML (SpreadsheetML)
+//
+// Os textos vão como inline strings, dispensando a tabela de strings
+// compartilhadas; Excel, LibreOffice e Google Planilhas abrem normalmente.
+func gravarXLSX(w io.Writer, abas []abaXLSX) error {
+	z := zip.NewWriter(w)
+
+	var tipos, planilhas, relacoes strings.Builder
+	for i, aba := range abas {
+		n := i + 1
+		fmt.Fprintf(&tipos, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&planilhas, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escaparXML(aba.nome), n, n)
+		fmt.Fprintf(&relacoes, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	fmt.Fprintf(&relacoes, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(abas)+1)
+
+	partes := []struct{ nome, conteudo string }{
+		{"[Content_Types].xml", cabecalhoXML + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			tipos.String() + `</Types>`},
+		{"_rels/.rels", cabecalhoXML + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", cabecalhoXML + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + planilhas.String() + `</sheets>` + definicoesFiltro(abas) + `</workbook>`},
+		{"xl/_rels/workbook.xml.rels", cabecalhoXML + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			relacoes.String() + `</Relationships>`},
+		{"xl/styles.xml", estilosXLSX},
+	}
+	for i, aba := range abas {
+		partes = append(partes, struct{ nome, conteudo string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), aba.xml()})
+	}
+
+	for _, p := range partes {
+		f, err := z.Create(p.nome)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.conteudo); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+// cabecalhoXML abre as partes do pacote
+const cabecalhoXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+// estilosXLSX define fontes e formatos na ordem das constantes estilo*
+//
+// numFmtId 4 é o formato embutido "#,##0.00" e 10 é "0.00%"; o separador
+// decimal segue a localidade de quem abre a planilha.
+const estilosXLSX = cabecalhoXML + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="5">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyAlignment="1"><alignment wrapText="1" vertical="top"/></xf>` +
+	`<xf numFmtId="10" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+// definicoesFiltro declara o intervalo do autofiltro das abas com filtro (exigido pelo Excel)
+func definicoesFiltro(abas []abaXLSX) string {
+	var b strings.Builder
+	for i, aba := range abas {
+		if aba.filtro && len(aba.linhas) > 0 {
+			fmt.Fprintf(&b, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">'%s'!%s</definedName>`,
+				i, escaparXML(aba.nome), aba.intervalo(true))
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "<definedNames>" + b.String() + "</definedNames>"
+}
+
+// xml gera a parte worksheet da aba
+func (a abaXLSX) xml() string {
+	var b strings.Builder
+	b.WriteString(cabecalhoXML + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if a.filtro {
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	if len(a.larguras) > 0 {
+		b.WriteString("<cols>")
+		for i, l := range a.larguras {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%g" customWidth="1"/>`, i+1, i+1, l)
+		}
+		b.WriteString("</cols>")
+	}
+
+	b.WriteString("<sheetData>")
+	for i, linha := range a.linhas {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, c := range linha {
+			ref := colunaXLSX(j) + strconv.Itoa(i+1)
+			estilo := ""
+			if c.estilo != estiloPadrao {
+				estilo = fmt.Sprintf(` s="%d"`, c.estilo)
+			}
+			switch {
+			case c.numerico:
+				fmt.Fprintf(&b, `<c r="%s"%s><v>%s</v></c>`, ref, estilo, c.valor)
+			case c.valor != "":
+				fmt.Fprintf(&b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, estilo, escaparXML(cortarCelula(c.valor)))
+			}
+		}
+		b.WriteString("</row>")
+	}
+	b.WriteString("</sheetData>")
+
+	if a.filtro && len(a.linhas) > 0 {
+		fmt.Fprintf(&b, `<autoFilter ref="%s"/>`, a.intervalo(false))
+	}
+	b.WriteString("</worksheet>")
+	return b.String()
+}
+
+// intervalo é a referência da tabela da aba (ex: A1:R10), absoluta ($A$1:$R$10) para os nomes definidos
+func (a abaXLSX) intervalo(absoluto bool) string {
+	colunas := 0
+	for _, linha := range a.linhas {
+		colunas = max(colunas, len(linha))
+	}
+	ultima := colunaXLSX(max(colunas-1, 0))
+	if absoluto {
+		return fmt.Sprintf("$A$1:$%s$%d", ultima, len(a.linhas))
+	}
+	return fmt.Sprintf("A1:%s%d", ultima, len(a.linhas))
+}
+
+// colunaXLSX converte o índice da coluna (0 = A) na letra da planilha (26 = AA)
+func colunaXLSX(i int) string {
+	var s string
+	for i++; i > 0; i = (i - 1) / 26 {
+		s = string(rune('A'+(i-1)%26)) + s
+	}
+	return s
+}
+
+// cortarCelula limita o texto ao máximo de caracteres de uma célula
+func cortarCelula(s string) string {
+	if r := []rune(s); len(r) > maxTextoCelula {
+		return string(r[:maxTextoCelula-1]) + "…"
+	}
+	return s
+}
+
+// escaparXML escapa o texto para o conteúdo ou atributo de um elemento
+func escaparXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Códigos SEFAZ de nota cancelada (consulta de situação)
+var cStatCancelada = map[string]bool{
+	"101": true, // Cancelamento de NF-e homologado
+	"151": true, // Cancelamento de NF-e homologado fora de prazo
+}
+
+// Situações de um resultado no resumo do lote (ver situacaoResultado)
+const (
+	situacaoValida    = "valida"
+	situacaoFalhaXSD  = "falha_xsd"
+	situacaoErroParse = "erro_parse"
+	situacaoRejeitada = "rejeitada"
+	situacaoCancelada = "cancelada"
+	situacaoOutroErro = "erro"
+)
+
+// situacaoResultado classifica o resultado na categoria do resumo pelo código de saída e pelo cStat
+func situacaoResultado(r resultado) string {
+	switch r.codigoSaida() {
+	case saidaOK:
+		return situacaoValida
+	case saidaXSDInvalido:
+		return situacaoFalhaXSD
+	case saidaParse:
+		return situacaoErroParse
+	case saidaRejeitada:
+		if cStatCancelada[r.Sefaz.Codigo] {
+			return situacaoCancelada
+		}
+		return situacaoRejeitada
+	default:
+		return situacaoOutroErro
+	}
+}
+
+// resumoLote é o resumo de uma execução em lote
+type resumoLote struct {
+	Total           int     `json:"total"`
+	Validos         int     `json:"validos"`
+	FalhasXSD       int     `json:"falhas_xsd"`
+	ErrosParse      int     `json:"erros_parse"`
+	Rejeitadas      int     `json:"rejeitadas"`
+	Canceladas      int     `json:"canceladas"`
+	Erros           int     `json:"erros"`
+	ComAchados      int     `json:"com_achados"`
+	Omitidos        int     `json:"omitidos,omitempty"` // resultados fora da saída por -only-failures/-only-unauthorized/-status
+	DuracaoSegundos float64 `json:"duracao_segundos"`
+	NotasPorSegundo float64 `json:"notas_por_segundo"`
+}
+
+// novoResumo classifica os resultados do lote e calcula tempo e vazão
+func novoResumo(results []resultado, duracao time.Duration) resumoLote {
+	r := resumoLote{
+		Total:           len(results),
+		DuracaoSegundos: arredondarDuracao(duracao.Seconds()),
+	}
+
+	for _, result := range results {
+		if len(result.Achados) > 0 {
+			r.ComAchados++
+		}
+
+		switch situacaoResultado(result) {
+		case situacaoValida:
+			r.Validos++
+		case situacaoFalhaXSD:
+			r.FalhasXSD++
+		case situacaoErroParse:
+			r.ErrosParse++
+		case situacaoRejeitada:
+			r.Rejeitadas++
+		case situacaoCancelada:
+			r.Canceladas++
+		default:
+			r.Erros++
+		}
+	}
+
+	if duracao > 0 {
+		r.NotasPorSegundo = arredondarDuracao(float64(len(results)) / duracao.Seconds())
+	}
+
+	return r
+}
+
+// registrar escreve o resumo no log (texto legível ou atributo JSON com -log-format json)
+func (r resumoLote) registrar() {
+	if !logTexto() {
+		slog.Info("📊 Resumo do lote", slog.Any("resumo", r))
+		return
+	}
+	logInfo("📊 Resumo do lote")
+	logInfo("   Total: %d | Válidos: %d | Falhas XSD: %d | Erros de parse: %d", r.Total, r.Validos, r.FalhasXSD, r.ErrosParse)
+	logInfo("   Rejeitadas: %d | Canceladas: %d | Outros erros: %d | Com achados: %d", r.Rejeitadas, r.Canceladas, r.Erros, r.ComAchados)
+	logInfo("   Tempo: %.2fs | Vazão: %.1f notas/s", r.DuracaoSegundos, r.NotasPorSegundo)
+}
+
+// arredondarDuracao mantém duas casas decimais nos números do resumo
+func arredondarDuracao(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
+}
+
+// barraProgresso desenha o andamento do lote em uma única linha do terminal
+//
+// Só é exibida quando o stderr é um terminal e os logs estão no nível padrão
+// em texto; em pipes, com -quiet, -v ou -log-format json nada é escrito.
+type barraProgresso struct {
+	w      io.Writer
+	total  int // -1 = desconhecido (pacotes .tar.gz só são contados ao descompactar)
+	feitos int
+	inicio time.Time
+}
+
+// larguraBarra é a quantidade de caracteres da barra
+const larguraBarra = 30
+
+// novaBarraProgresso cria a barra se o stderr for um terminal; senão retorna nil
+func novaBarraProgresso(total int) *barraProgresso {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	logger := slog.Default()
+	if !logTexto() || !logger.Enabled(context.Background(), slog.LevelInfo) || logger.Enabled(context.Background(), nivelDetalhe) {
+		return nil
+	}
+	return &barraProgresso{w: os.Stderr, total: total, inicio: time.Now()}
+}
+
+// avancar conta um item concluído e redesenha a barra
+func (b *barraProgresso) avancar() {
+	if b == nil {
+		return
+	}
+	b.feitos++
+
+	vazao := float64(b.feitos) / time.Since(b.inicio).Seconds()
+	if b.total <= 0 {
+		fmt.Fprintf(b.w, "\r\033[K⏳ %d processado(s) | %.1f notas/s", b.feitos, vazao)
+		return
+	}
+
+	cheio := b.feitos * larguraBarra / b.total
+	if cheio > larguraBarra {
+		cheio = larguraBarra
+	}
+	fmt.Fprintf(b.w, "\r\033[K⏳ [%s%s] %d/%d %3d%% | %.1f notas/s",
+		strings.Repeat("█", cheio), strings.Repeat("░", larguraBarra-cheio),
+		b.feitos, b.total, b.feitos*100/b.total, vazao)
+}
+
+// concluir apaga a linha da barra para os próximos logs
+func (b *barraProgresso) concluir() {
+	if b == nil {
+		return
+	}
+	fmt.Fprint(b.w, "\r\033[K")
+}
+
+// contarItens estima o total de XMLs do lote (-1 se houver .tar.gz, que exige descompactar)
+func contarItens(caminhos []string) int {
+	total := 0
+	for _, caminho := range caminhos {
+		if !ehPacote(caminho) {
+			total++
+			continue
+		}
+		if !strings.HasSuffix(strings.ToLower(caminho), ".zip") {
+			return -1
+		}
+
+		r, err := zip.OpenReader(caminho)
+		if err != nil {
+			total++ // pacote ilegível vira um resultado com erro
+			continue
+		}
+		for _, f := range r.File {
+			if !f.FileInfo().IsDir() && isXML(f.Name) {
+				total++
+			}
+		}
+		r.Close()
+	}
+	return total
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// Códigos de saída do processo, por classe de falha
+//
+// Permitem que scripts e pipelines de CI decidam o que fazer sem parsear o JSON:
+//
+//	./validator -skip-sefaz nota.xml schema.xsd
+//	case $? in
+//	    0) echo "ok" ;;
+//	    2) echo "XML fora do schema" ;;
+//	    4) echo "rejeitada/cancelada na SEFAZ" ;;
+//	esac
+//
+// Em lote (validate), o processo sai com o maior código entre os arquivos.
+const (
+	// saidaOK: todas as fases executadas passaram
+	saidaOK = 0
+
+	// saidaErro: uso incorreto da linha de comando ou arquivo ilegível
+	saidaErro = 1
+
+	// saidaXSDInvalido: XML não passou na validação XSD
+	saidaXSDInvalido = 2
+
+	// saidaParse: XML válido no XSD, mas não pôde ser interpretado como NF-e
+	saidaParse = 3
+
+	// saidaRejeitada: SEFAZ respondeu, mas a nota não está autorizada (rejeitada, cancelada, denegada...)
+	saidaRejeitada = 4
+
+	// saidaConectividade: falha de configuração (certificados, XSD, .env) ou de comunicação com a SEFAZ
+	saidaConectividade = 5
+)
+
+// codigoSaida retorna o código de saída correspondente ao resultado
+func (r resultado) codigoSaida() int {
+	if r.saida != saidaOK {
+		return r.saida
+	}
+	if r.Erro != "" {
+		return saidaErro
+	}
+	if r.consultouSefaz() && !r.Sefaz.Autorizado {
+		return saidaRejeitada
+	}
+	return saidaOK
+}
+
+// consultouSefaz indica se a SEFAZ respondeu à consulta deste resultado
+func (r resultado) consultouSefaz() bool {
+	return r.Sefaz.Codigo != "" && r.Sefaz.Codigo != "N/A"
+}
+
+// aprovado indica se a nota passou em todas as fases executadas
+//
+// Reprova quando o código de saída não é saidaOK ou quando alguma regra de
+// negócio gerou achado com severidade de erro.
+func (r resultado) aprovado() bool {
+	return r.codigoSaida() == saidaOK && !nfe.TemErros(r.Achados)
+}
+
+// codigoSaidaLote retorna o maior código de saída entre os resultados
+func codigoSaidaLote(results []resultado) int {
+	codigo := saidaOK
+	for _, r := range results {
+		if c := r.codigoSaida(); c > codigo {
+			codigo = c
+		}
+	}
+	return codigo
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// Estados de cada verificação do /readyz
+const (
+	verificacaoOK        = "ok"
+	verificacaoFalha     = "falha"
+	verificacaoDesligada = "desligada" // não se aplica à configuração do servidor
+)
+
+// verificacao é o estado de uma dependência do serve no /readyz
+type verificacao struct {
+	Status  string `json:"status"`
+	Detalhe string `json:"detalhe,omitempty"`
+}
+
+// respostaSaude é o corpo JSON do /healthz e do /readyz
+type respostaSaude struct {
+	Status       string                 `json:"status"`
+	Verificacoes map[string]verificacao `json:"verificacoes,omitempty"`
+}
+
+// prontidao decide se o serve pode receber tráfego (/readyz)
+//
+// Verificações:
+//
+//	certificado  chave e certificado carregados no cliente SEFAZ e dentro da validade,
+//	             com ao menos -ready-cert-days dias restantes
+//	             (desligada com -xsd, -skip-sefaz ou -offline)
+//	schemas      XSD compilado na inicialização
+//	sefaz        NfeStatusServico4 da UF da configuração em operação
+//	             (apenas com -ready-sefaz; a resposta fica em cache por -ready-sefaz-cache)
+type prontidao struct {
+	opts *opcoesValidacao
+
+	// diasCertificado é a validade mínima restante do certificado (0 = só o vencido falha)
+	diasCertificado int
+
+	// sondarSefaz liga a consulta ao status da SEFAZ; cache é a validade da última resposta
+	sondarSefaz bool
+	cache       time.Duration
+
+	// mu serializa as consultas: probes simultâneos esperam a mesma resposta
+	mu       sync.Mutex
+	sefaz    verificacao
+	consulta time.Time
+}
+
+// verificar executa as verificações; pronto é false se alguma falhou
+func (p *prontidao) verificar() (verificacoes map[string]verificacao, pronto bool) {
+	verificacoes = map[string]verificacao{
+		"certificado": p.certificado(),
+		"schemas":     p.schemas(),
+		"sefaz":       p.statusSefaz(),
+	}
+	pronto = true
+	for _, v := range verificacoes {
+		if v.Status == verificacaoFalha {
+			pronto = false
+		}
+	}
+	return verificacoes, pronto
+}
+
+// certificado verifica o certificado usado na consulta SEFAZ
+func (p *prontidao) certificado() verificacao {
+	if !p.opts.consultaSefaz() {
+		return verificacao{Status: verificacaoDesligada}
+	}
+	client, err := p.opts.clienteSefaz()
+	if err != nil {
+		return verificacao{Status: verificacaoFalha, Detalhe: err.Error()}
+	}
+	cert, err := client.Certificado()
+	if err != nil {
+		return verificacao{Status: verificacaoFalha, Detalhe: err.Error()}
+	}
+	if time.Now().After(cert.NotAfter) {
+		return verificacao{Status: verificacaoFalha, Detalhe: fmt.Sprintf("certificado vencido em %s", cert.NotAfter.Format(time.DateOnly))}
+	}
+	if dias := time.Until(cert.NotAfter).Hours() / 24; dias < float64(p.diasCertificado) {
+		return verificacao{Status: verificacaoFalha, Detalhe: fmt.Sprintf("certificado vence em %s (%d dias; mínimo %d)", cert.NotAfter.Format(time.DateOnly), int(dias), p.diasCertificado)}
+	}
+	return verificacao{Status: verificacaoOK, Detalhe: fmt.Sprintf("válido até %s", cert.NotAfter.Format(time.DateOnly))}
+}
+
+// schemas verifica se o XSD foi compilado
+func (p *prontidao) schemas() verificacao {
+	if p.opts.xsd == nil {
+		return verificacao{Status: verificacaoFalha, Detalhe: "XSD não carregado"}
+	}
+	return verificacao{Status: verificacaoOK, Detalhe: p.opts.xsdPath}
+}
+
+// statusSefaz consulta o NfeStatusServico4 da UF da configuração, reaproveitando a última resposta dentro do cache
+func (p *prontidao) statusSefaz() verificacao {
+	if !p.sondarSefaz {
+		return verificacao{Status: verificacaoDesligada}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.consulta.IsZero() && time.Since(p.consulta) < p.cache {
+		return p.sefaz
+	}
+
+	p.sefaz = p.consultarSefaz()
+	p.consulta = time.Now()
+	return p.sefaz
+}
+
+// consultarSefaz faz a consulta de status sem cache
+func (p *prontidao) consultarSefaz() verificacao {
+	uf := nfe.SiglaUF(p.opts.cfg.UF)
+	if uf == "" {
+		return verificacao{Status: verificacaoFalha, Detalhe: "UF não configurada (NFE_UF_IBGE / uf no validator.yaml)"}
+	}
+	client, err := p.opts.clienteSefaz()
+	if err != nil {
+		return verificacao{Status: verificacaoFalha, Detalhe: err.Error()}
+	}
+
+	r := consultarStatus(client, uf)
+	switch {
+	case r.Erro != "":
+		return verificacao{Status: verificacaoFalha, Detalhe: r.Erro}
+	case !r.Disponivel:
+		return verificacao{Status: verificacaoFalha, Detalhe: fmt.Sprintf("%s: %s - %s", uf, r.Status.Codigo, r.Status.Mensagem)}
+	default:
+		return verificacao{Status: verificacaoOK, Detalhe: fmt.Sprintf("%s: %s - %s", uf, r.Status.Codigo, r.Status.Mensagem)}
+	}
+}
+
+// handlerHealthz responde 200 enquanto o processo atende requisições (liveness)
+func handlerHealthz(w http.ResponseWriter, _ *http.Request) {
+	responderJSON(w, http.StatusOK, respostaSaude{Status: verificacaoOK})
+}
+
+// handlerReadyz responde 200 se todas as verificações passaram e 503 caso contrário (readiness)
+func (p *prontidao) handlerReadyz(w http.ResponseWriter, _ *http.Request) {
+	verificacoes, pronto := p.verificar()
+	if !pronto {
+		responderJSON(w, http.StatusServiceUnavailable, respostaSaude{Status: verificacaoFalha, Verificacoes: verificacoes})
+		return
+	}
+	responderJSON(w, http.StatusOK, respostaSaude{Status: verificacaoOK, Verificacoes: verificacoes})
+}
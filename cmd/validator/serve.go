@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfemetrics"
+)
+
+// runServe executa o subcomando "serve": publica o validador como serviço
+//
+// O XSD é carregado uma única vez e o cliente SEFAZ (mTLS) é compartilhado
+// entre as requisições. As flags de fase (-xsd, -skip-sefaz, -offline,
+// -disable-rules) são o padrão do servidor; cada requisição pode desligar
+// fases, mas não religar as que o servidor desligou.
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	grpcAddr := flags.String("grpc", ":9090", "Endereço do servidor gRPC (ValidatorService)")
+	httpAddr := flags.String("http", ":8080", "Endereço do servidor HTTP (API REST, /metrics, /healthz, /readyz); vazio desliga")
+	readySefaz := flags.Bool("ready-sefaz", false, "/readyz também exige o status da SEFAZ da UF em operação")
+	readySefazCache := flags.Duration("ready-sefaz-cache", time.Minute, "Tempo em que o status da SEFAZ do /readyz fica em cache")
+	readyCertDays := flags.Int("ready-cert-days", 0, "/readyz falha quando faltam menos dias que isso para o certificado vencer (0 = só vencido)")
+	xsdPath := flags.String("schema", schemaPadrao, "Arquivo XSD usado na validação")
+	xsdOnly := flags.Bool("xsd", false, "Validar apenas contra XSD (sem consulta SEFAZ)")
+	skipSefaz := flags.Bool("skip-sefaz", false, "Pular consulta SEFAZ (valida XSD + parse dados)")
+	offline := flags.Bool("offline", false, "Validação local completa (XSD + parse + assinatura + regras); SEFAZ marcada como pulada")
+	disableRules := flags.String("disable-rules", "", "IDs de regras de negócio a desabilitar, separados por vírgula (ex: ncm,cfop)")
+	workers := flags.Int("workers", 4, "XMLs validados em paralelo em cada ValidateBatch, upload e lote")
+	batchTTL := flags.Duration("batch-ttl", time.Hour, "Tempo em que os lotes de /v1/batches ficam disponíveis para consulta")
+	batchMaxPending := flags.Int("batch-max-pending", lotesAbertosPadrao, "Lotes de /v1/batches aguardando ou em validação; acima disso, 503 (0 = sem limite)")
+	batchMaxStored := flags.Int("batch-max-stored", lotesGuardadosPadrao, "Lotes de /v1/batches em memória; cheio, o concluído mais antigo sai antes do -batch-ttl (0 = sem limite)")
+	batchURLAllow := flags.String("batch-url-allow", "", "Origens aceitas em lotes por URL, separadas por vírgula (ex: s3://notas,https://arquivos.exemplo.com.br); vazio desliga")
+	s3Endpoint := flags.String("s3-endpoint", "", "Endpoint S3 alternativo para os lotes s3:// (ex: MinIO, LocalStack); usa path-style")
+	rateLimit := flags.Int("rate-limit", 0, "Requisições por minuto por IP na API REST /v1 e no gRPC (0 = sem limite)")
+	maxConcurrent := flags.Int("max-concurrent", 0, "Requisições simultâneas na API REST /v1 e no gRPC; acima disso, 503/Unavailable (0 = sem limite)")
+	maxBodyMB := flags.Int("max-body-mb", tamanhoMaxUploadPadrao>>20, "Tamanho máximo de cada requisição REST e mensagem gRPC, em MB")
+	sefazRate := flags.Float64("sefaz-rate", 0, "Consultas por segundo à SEFAZ, somando todas as requisições; as excedentes aguardam (0 = sem limite)")
+	shutdownTimeout := registrarFlagEncerramento(flags)
+	webhookOpts := registrarFlagsWebhook(flags)
+	storeDSN := registrarFlagStore(flags)
+	arquivamentoOpts := registrarFlagsArquivamento(flags)
+	dedupOpts := registrarFlagsDedup(flags)
+	alertasDestinos := registrarFlagAlertas(flags)
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s serve [opções]\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Opções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator serve -grpc :9090")
+		fmt.Fprintln(os.Stderr, "  ./validator serve -grpc 127.0.0.1:9090 -offline -workers 8")
+		fmt.Fprintln(os.Stderr, "  ./validator serve -http :9464    # Prometheus em http://localhost:9464/metrics")
+		fmt.Fprintln(os.Stderr, "  ./validator serve -rate-limit 120 -max-concurrent 32 -sefaz-rate 5")
+		fmt.Fprintln(os.Stderr, "  ./validator serve -batch-ttl 24h    # lotes de POST /v1/batches consultáveis por 24h")
+		fmt.Fprintln(os.Stderr, "  ./validator serve -batch-url-allow s3://notas    # aceita {\"url\": \"s3://notas/...\"} em POST /v1/batches")
+		fmt.Fprintln(os.Stderr, "  ./validator serve -ready-sefaz -ready-sefaz-cache 2m    # /readyz 503 se a SEFAZ da UF estiver fora")
+		fmt.Fprintln(os.Stderr, "  ./validator serve -ready-cert-days 15    # /readyz 503 a 15 dias do vencimento do certificado A1")
+		fmt.Fprintln(os.Stderr, "  ./validator serve -shutdown-timeout 50s    # SIGTERM: até 50s para concluir requisições, lotes e webhooks")
+		fmt.Fprintln(os.Stderr, "  NFE_WEBHOOK_SECRET=... ./validator serve -webhook https://erp.exemplo.com.br/nfe/validacoes")
+		fmt.Fprintln(os.Stderr, "  NFE_STORE_DSN=postgres://nfe:senha@db/nfe ./validator serve    # histórico das validações no Postgres")
+	}
+	flags.Parse(args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+	if flags.NArg() > 0 || *rateLimit < 0 || *maxConcurrent < 0 || *maxBodyMB <= 0 || *sefazRate < 0 || *readyCertDays < 0 || *batchMaxPending < 0 || *batchMaxStored < 0 {
+		flags.Usage()
+		return saidaErro
+	}
+	origensURL, err := origensLote(*batchURLAllow)
+	if err != nil {
+		logErro("❌ -batch-url-allow: %v", err)
+		return saidaErro
+	}
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if arq.caminho != "" {
+		logInfo("⚙️ Configuração: %s", arq.descricao())
+	}
+
+	// A configuração é sempre carregada: ValidateChave pode pedir a consulta SEFAZ
+	cfg, err := arq.carregarConfig()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	opts := &opcoesValidacao{
+		xsdPath:       *xsdPath,
+		xsdOnly:       *xsdOnly,
+		skipSefaz:     *skipSefaz,
+		offline:       *offline,
+		regras:        arq.configRegras(splitList(*disableRules)),
+		cfg:           cfg,
+		versaoSaida:   versaoSaidaV2,
+		mascararSaida: logOpts.mascararSaida(),
+		metricas:      nfemetrics.New(),
+	}
+	if !flagInformada(flags, "schema") {
+		opts.xsdPath = arq.schema(schemaPadrao)
+	}
+	logInfo("Ambiente ativo: %s (UF %s)", opts.cfg.Env, opts.cfg.UF)
+	opts.logNivel()
+
+	if cert, err := certificadoConfig(opts.cfg); err != nil {
+		logDebug("Métrica de validade do certificado desligada: %v", err)
+	} else {
+		opts.metricas.ObservarCertificado(cert)
+		opts.metricas.ObservarCertificadoTenant(tenantMetrica(opts.cfg, cert), cert)
+	}
+
+	auth, err := novoAutenticador(arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	if auth != nil {
+		logInfo("🔐 Autenticação: %d chave(s) de API, JWT: %t", len(auth.chaves), auth.jwtParser != nil)
+	}
+
+	wh, err := webhookOpts.criar(flags, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	if wh != nil {
+		logInfo("🔔 Webhook: %s", wh.url)
+	}
+	defer wh.fechar()
+
+	historico, err := abrirHistorico(*storeDSN, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if historico != nil {
+		defer historico.Close()
+	}
+	opts.historico, opts.origem = historico, "serve"
+
+	arquivamento, err := arquivamentoOpts.criar(flags, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	arquivamento.manterRetencao(context.Background())
+	opts.arquivamento = arquivamento
+
+	dups, fecharDedup, err := dedupOpts.criar(historico, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	defer fecharDedup()
+	opts.dedup = dups
+
+	alertas, err := criarAlertador(*alertasDestinos, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	opts.alertas = alertas
+
+	xsd, err := validation.NewXSDValidator(opts.xsdPath)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	defer xsd.Close()
+	opts.xsd = xsd
+
+	lis, err := net.Listen("tcp", *grpcAddr)
+	if err != nil {
+		logErro("❌ Falha ao abrir %s: %v", *grpcAddr, err)
+		return saidaErro
+	}
+
+	limites := novosLimites(*rateLimit, *maxConcurrent)
+	maxCorpo := int64(*maxBodyMB) << 20
+	if *sefazRate > 0 {
+		// Rajada de uma consulta: as excedentes ficam espaçadas em 1/sefaz-rate segundos
+		opts.cotaSefaz = rate.NewLimiter(rate.Limit(*sefazRate), 1)
+	}
+
+	// Os limites vêm antes da autenticação: credenciais inválidas também contam para o IP
+	extrasGRPC := append(limites.opcoesGRPC(), auth.opcoesGRPC()...)
+	extrasGRPC = append(extrasGRPC, grpc.MaxRecvMsgSize(int(maxCorpo)))
+	srv := novoServidorGRPC(opts, *workers, wh, extrasGRPC...)
+	erros := make(chan error, 2)
+	go func() { erros <- srv.Serve(lis) }()
+	logInfo("🚀 gRPC ouvindo em %s (Ctrl+C para sair)", lis.Addr())
+
+	var (
+		srvHTTP *http.Server
+		api     *apiHTTP
+	)
+	if *httpAddr != "" {
+		lisHTTP, err := net.Listen("tcp", *httpAddr)
+		if err != nil {
+			logErro("❌ Falha ao abrir %s: %v", *httpAddr, err)
+			srv.Stop()
+			return saidaErro
+		}
+		pronto := &prontidao{opts: opts, diasCertificado: *readyCertDays, sondarSefaz: *readySefaz, cache: *readySefazCache}
+		api = &apiHTTP{opts: opts, workers: *workers, webhook: wh, auth: auth, limites: limites, maxCorpo: maxCorpo}
+		api.lotes = novaFilaLotes(api, configLotes{
+			retencao:     *batchTTL,
+			maxAbertos:   *batchMaxPending,
+			maxGuardados: *batchMaxStored,
+			origens:      origensURL,
+			endpointS3:   *s3Endpoint,
+		})
+		srvHTTP = novoServidorHTTP(*httpAddr, api, pronto)
+		srvHTTP.RegisterOnShutdown(api.lotes.encerrar)
+		go func() {
+			if err := srvHTTP.Serve(lisHTTP); !errors.Is(err, http.ErrServerClosed) {
+				erros <- err
+			}
+		}()
+		logInfo("📈 HTTP ouvindo em %s (/v1, /metrics, /healthz, /readyz)", lisHTTP.Addr())
+	}
+
+	sinal, pararSinais := contextoSinais()
+	defer pararSinais()
+
+	select {
+	case err := <-erros:
+		logErro("❌ Servidor encerrado: %v", err)
+		return saidaErro
+	case <-sinal.Done():
+	}
+
+	logInfo("🛑 Encerrando serve (até %s para concluir as requisições em andamento)", *shutdownTimeout)
+	prazo, cancelar := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancelar()
+
+	// Os dois servidores param de aceitar conexões juntos; depois do prazo, as requisições restantes são cortadas
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if !aguardarAte(prazo, "as chamadas gRPC", srv.GracefulStop) {
+			srv.Stop()
+		}
+	}()
+	if srvHTTP != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := srvHTTP.Shutdown(prazo); err != nil {
+				logAviso("⚠️ Prazo de encerramento esgotado aguardando as requisições HTTP")
+				srvHTTP.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Lotes já aceitos (202) terminam dentro do prazo; por fim, as entregas do webhook
+	if api != nil {
+		aguardarAte(prazo, "os lotes assíncronos", api.lotes.aguardar)
+	}
+	wh.drenar(prazo)
+	return saidaOK
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// runSQS executa o subcomando "sqs": valida os XMLs enviados ao S3, avisado pelos eventos na fila SQS
+//
+// A fila recebe as notificações "s3:ObjectCreated:*" do bucket (direto ou
+// via SNS). Cada XML é baixado, validado e o resultado é gravado em
+// <results-prefix><chave do objeto>.json. A mensagem só é apagada da fila
+// depois que todos os objetos do evento foram processados; em caso de falha
+// ela volta a ficar visível e a redrive policy da fila cuida da DLQ.
+func runSQS(args []string) int {
+	flags := flag.NewFlagSet("sqs", flag.ExitOnError)
+	filaURL := flags.String("queue-url", "", "URL da fila SQS com os eventos do S3 (obrigatória)")
+	prefixo := flags.String("results-prefix", "resultados/", "Prefixo das chaves dos resultados (<prefixo><chave do XML>.json)")
+	bucketResultados := flags.String("results-bucket", "", "Bucket dos resultados (padrão: o mesmo do XML)")
+	regiao := flags.String("region", "", "Região AWS (padrão: AWS_REGION ou ~/.aws/config)")
+	endpoint := flags.String("endpoint", "", "Endpoint S3/SQS alternativo (ex: LocalStack, MinIO); usa path-style no S3")
+	espera := flags.Duration("wait", 20*time.Second, "Long polling de cada ReceiveMessage (máx. 20s)")
+	xsdPath := flags.String("schema", schemaPadrao, "Arquivo XSD usado na validação")
+	xsdOnly := flags.Bool("xsd", false, "Validar apenas contra XSD (sem consulta SEFAZ)")
+	skipSefaz := flags.Bool("skip-sefaz", false, "Pular consulta SEFAZ (valida XSD + parse dados)")
+	offline := flags.Bool("offline", false, "Validação local completa (XSD + parse + assinatura + regras); SEFAZ marcada como pulada")
+	disableRules := flags.String("disable-rules", "", "IDs de regras de negócio a desabilitar, separados por vírgula (ex: ncm,cfop)")
+	workers := flags.Int("workers", 4, "Mensagens processadas em paralelo (até 10 por ReceiveMessage)")
+
+	versaoSaida := registrarFlagVersaoSaida(flags)
+	shutdownTimeout := registrarFlagEncerramento(flags)
+	storeDSN := registrarFlagStore(flags)
+	arquivamentoOpts := registrarFlagsArquivamento(flags)
+	dedupOpts := registrarFlagsDedup(flags)
+	alertasDestinos := registrarFlagAlertas(flags)
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s sqs -queue-url <url> [opções]\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Valida os XMLs criados no S3 a partir dos eventos entregues na fila SQS.")
+		fmt.Fprintln(os.Stderr, "\nOpções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplo:")
+		fmt.Fprintln(os.Stderr, "  ./validator sqs -queue-url https://sqs.sa-east-1.amazonaws.com/123456789012/nfe-xml -offline")
+	}
+	flags.Parse(args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+	if err := validarVersaoSaida(*versaoSaida); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+	if flags.NArg() > 0 || *filaURL == "" || *espera < 0 || *espera > 20*time.Second {
+		flags.Usage()
+		return saidaErro
+	}
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if arq.caminho != "" {
+		logInfo("⚙️ Configuração: %s", arq.descricao())
+	}
+
+	opts := &opcoesValidacao{
+		xsdPath:       *xsdPath,
+		xsdOnly:       *xsdOnly,
+		skipSefaz:     *skipSefaz,
+		offline:       *offline,
+		versaoSaida:   *versaoSaida,
+		mascararSaida: logOpts.mascararSaida(),
+		regras:        arq.configRegras(splitList(*disableRules)),
+	}
+	if !flagInformada(flags, "schema") {
+		opts.xsdPath = arq.schema(schemaPadrao)
+	}
+	if opts.consultaSefaz() {
+		if opts.cfg, err = arq.carregarConfig(); err != nil {
+			logErro("❌ %v", err)
+			return saidaConectividade
+		}
+		logInfo("Ambiente ativo: %s (UF %s)", opts.cfg.Env, opts.cfg.UF)
+	}
+	opts.logNivel()
+
+	historico, err := abrirHistorico(*storeDSN, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if historico != nil {
+		defer historico.Close()
+	}
+	opts.historico, opts.origem = historico, "sqs"
+
+	arquivamento, err := arquivamentoOpts.criar(flags, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	arquivamento.manterRetencao(context.Background())
+	opts.arquivamento = arquivamento
+
+	dups, fecharDedup, err := dedupOpts.criar(historico, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	defer fecharDedup()
+	opts.dedup = dups
+
+	alertas, err := criarAlertador(*alertasDestinos, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	opts.alertas = alertas
+
+	xsd, err := validation.NewXSDValidator(opts.xsdPath)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	defer xsd.Close()
+	opts.xsd = xsd
+
+	var opcoesAWS []func(*awsconfig.LoadOptions) error
+	if *regiao != "" {
+		opcoesAWS = append(opcoesAWS, awsconfig.WithRegion(*regiao))
+	}
+	cfgAWS, err := awsconfig.LoadDefaultConfig(context.Background(), opcoesAWS...)
+	if err != nil {
+		logErro("❌ Falha ao carregar a configuração AWS: %v", err)
+		return saidaConectividade
+	}
+
+	p := &pipelineS3{
+		fila:    *filaURL,
+		prefixo: *prefixo,
+		bucket:  *bucketResultados,
+		opts:    opts,
+		s3: s3.NewFromConfig(cfgAWS, func(o *s3.Options) {
+			if *endpoint != "" {
+				o.BaseEndpoint = aws.String(*endpoint)
+				o.UsePathStyle = true
+			}
+		}),
+		sqs: sqs.NewFromConfig(cfgAWS, func(o *sqs.Options) {
+			if *endpoint != "" {
+				o.BaseEndpoint = aws.String(*endpoint)
+			}
+		}),
+	}
+
+	// O sinal interrompe a espera por mensagens; as recebidas têm até
+	// -shutdown-timeout para terminar de ser processadas
+	sinal, pararSinais := contextoSinais()
+	defer pararSinais()
+	trabalho, cancelarTrabalho := contextoTrabalho(sinal, *shutdownTimeout)
+	defer cancelarTrabalho()
+	opts.ctx = trabalho
+	// Parado antes de pararSinais (defer): saídas por erro não aparecem como encerramento
+	pararAviso := context.AfterFunc(sinal, func() {
+		logInfo("🛑 Encerrando sqs (até %s para concluir as mensagens em andamento)", *shutdownTimeout)
+	})
+	defer pararAviso()
+
+	logInfo("🪣 Consumindo eventos de %s ➜ resultados em %s (Ctrl+C para sair)", *filaURL, *prefixo)
+
+	for sinal.Err() == nil {
+		out, err := p.sqs.ReceiveMessage(sinal, &sqs.ReceiveMessageInput{
+			QueueUrl:            filaURL,
+			MaxNumberOfMessages: int32(min(max(*workers, 1), 10)),
+			WaitTimeSeconds:     int32(espera.Seconds()),
+		})
+		if sinal.Err() != nil {
+			break
+		}
+		if err != nil {
+			logErro("❌ Falha ao receber mensagens de %s: %v", *filaURL, err)
+			return saidaConectividade
+		}
+
+		var wg sync.WaitGroup
+		for _, m := range out.Messages {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.processar(m)
+			}()
+		}
+		wg.Wait()
+	}
+
+	logInfo("📦 sqs encerrado (%d XML(s) validados, %d falha(s))", p.validados, p.falhas)
+	return saidaOK
+}
+
+// pipelineS3 baixa, valida e grava os resultados dos XMLs avisados pela fila
+type pipelineS3 struct {
+	s3  *s3.Client
+	sqs *sqs.Client
+
+	// fila é a URL da fila SQS
+	fila string
+
+	// prefixo e bucket definem onde os resultados são gravados (bucket vazio = o do XML)
+	prefixo string
+	bucket  string
+
+	opts *opcoesValidacao
+
+	// contadores para o log de encerramento
+	mu        sync.Mutex
+	validados int
+	falhas    int
+}
+
+// eventoS3 é a parte usada da notificação de evento do S3
+type eventoS3 struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+
+	// Event é "s3:TestEvent" na mensagem enviada ao configurar a notificação
+	Event string `json:"Event"`
+}
+
+// processar trata uma mensagem da fila e a apaga se todos os objetos foram processados
+func (p *pipelineS3) processar(m sqstypes.Message) {
+	// Depois do prazo de encerramento as chamadas AWS são canceladas e a mensagem fica na fila
+	ctx := p.opts.contexto()
+	id := aws.ToString(m.MessageId)
+
+	evento, err := lerEventoS3(aws.ToString(m.Body))
+	if err != nil {
+		// Mantida na fila: depois de maxReceiveCount recebimentos, a redrive policy a move para a DLQ
+		logAviso("⚠️ Mensagem %s ignorada: %v", id, err)
+		return
+	}
+
+	ok := true
+	for _, r := range evento.Records {
+		if !strings.HasPrefix(r.EventName, "ObjectCreated:") {
+			continue
+		}
+		chave, err := url.QueryUnescape(r.S3.Object.Key)
+		if err != nil {
+			logAviso("⚠️ Chave de objeto inválida '%s': %v", r.S3.Object.Key, err)
+			continue
+		}
+		// Sem isso, uma notificação no bucket inteiro validaria os próprios resultados
+		if strings.HasPrefix(chave, p.prefixo) || !isXML(chave) {
+			logDebug("Objeto s3://%s/%s ignorado", r.S3.Bucket.Name, chave)
+			continue
+		}
+
+		err = p.validarObjeto(ctx, r.S3.Bucket.Name, chave)
+		if err != nil && conferirPrazo(ctx) != nil {
+			// Download, validação ou gravação cortados pelo prazo: nada foi gravado
+			logAviso("⚠️ s3://%s/%s: %v; a mensagem %s volta para a fila", r.S3.Bucket.Name, chave, errPrazoEncerramento, id)
+			return
+		}
+		if err != nil {
+			logErro("❌ s3://%s/%s: %v", r.S3.Bucket.Name, chave, err)
+			p.contar(&p.falhas)
+			ok = false
+			continue
+		}
+		p.contar(&p.validados)
+	}
+
+	if !ok {
+		// A mensagem volta a ficar visível após o visibility timeout e é processada de novo
+		return
+	}
+	if _, err := p.sqs.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: &p.fila, ReceiptHandle: m.ReceiptHandle}); err != nil {
+		logAviso("⚠️ Falha ao apagar a mensagem %s (será processada de novo): %v", id, err)
+	}
+}
+
+// validarObjeto baixa o XML, valida e grava o resultado em <prefixo><chave>.json
+func (p *pipelineS3) validarObjeto(ctx context.Context, bucket, chave string) error {
+	obj, err := p.s3.GetObject(ctx, &s3.GetObjectInput{Bucket: &bucket, Key: &chave})
+	var semChave *s3types.NoSuchKey
+	if errors.As(err, &semChave) {
+		// Apagado antes da validação: não há o que refazer
+		logAviso("⚠️ s3://%s/%s não existe mais", bucket, chave)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao baixar: %w", err)
+	}
+	xmlData, err := io.ReadAll(obj.Body)
+	obj.Body.Close()
+	if err != nil {
+		return fmt.Errorf("erro ao baixar: %w", err)
+	}
+
+	origem := "s3://" + bucket + "/" + chave
+	logInfo("📄 %s", origem)
+	result := validarXML(xmlData, p.opts)
+	result.Arquivo = origem
+	if err := conferirPrazo(ctx); err != nil {
+		return err
+	}
+	p.opts.gravar(result)
+
+	corpo, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro ao serializar resultado: %w", err)
+	}
+
+	destino := bucket
+	if p.bucket != "" {
+		destino = p.bucket
+	}
+	chaveResultado := p.prefixo + chave + ".json"
+	if _, err := p.s3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &destino,
+		Key:         &chaveResultado,
+		Body:        bytes.NewReader(corpo),
+		ContentType: aws.String("application/json"),
+	}); err != nil {
+		return fmt.Errorf("erro ao gravar resultado: %w", err)
+	}
+
+	logInfo("   ➜ s3://%s/%s (código %d)", destino, chaveResultado, result.codigoSaida())
+	return nil
+}
+
+// contar incrementa um contador do log de encerramento
+func (p *pipelineS3) contar(contador *int) {
+	p.mu.Lock()
+	*contador++
+	p.mu.Unlock()
+}
+
+// lerEventoS3 interpreta o corpo da mensagem: evento do S3 direto ou dentro de uma notificação SNS
+func lerEventoS3(corpo string) (eventoS3, error) {
+	var sns struct {
+		Type    string `json:"Type"`
+		Message string `json:"Message"`
+	}
+	if err := json.Unmarshal([]byte(corpo), &sns); err == nil && sns.Type == "Notification" {
+		corpo = sns.Message
+	}
+
+	var evento eventoS3
+	if err := json.Unmarshal([]byte(corpo), &evento); err != nil {
+		return evento, fmt.Errorf("corpo não é um evento do S3: %w", err)
+	}
+	if evento.Event == "s3:TestEvent" {
+		return evento, nil
+	}
+	if len(evento.Records) == 0 {
+		return evento, errors.New("evento do S3 sem Records")
+	}
+	return evento, nil
+}
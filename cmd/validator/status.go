@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// resultadoStatus é a saída JSON do subcomando "status" (um item por UF)
+type resultadoStatus struct {
+	UF          string               `json:"uf"`
+	Autorizador string               `json:"autorizador"`
+	URL         string               `json:"url"`
+	Disponivel  bool                 `json:"disponivel"`
+	Status      *sefaz.StatusServico `json:"status,omitempty"`
+	Erro        string               `json:"erro,omitempty"`
+}
+
+// runStatus executa o subcomando "status": consulta o NfeStatusServico4 de uma ou de todas as UFs
+//
+// Sem argumentos consulta a UF da configuração; "todas" consulta as 27 UFs em
+// paralelo. Útil antes de disparar um lote grande com consulta à SEFAZ.
+func runStatus(args []string) int {
+	flags := flag.NewFlagSet("status", flag.ExitOnError)
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s status [opções] [UF...|todas]\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Opções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator status            # UF da configuração")
+		fmt.Fprintln(os.Stderr, "  ./validator status SP MG")
+		fmt.Fprintln(os.Stderr, "  ./validator status todas")
+	}
+	posicionais := parseIntercalado(flags, args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	cfg, err := arq.carregarConfig()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	ufConfig := nfe.SiglaUF(cfg.UF)
+
+	ufs, err := ufsStatus(posicionais, ufConfig)
+	if err != nil {
+		logErro("❌ %v", err)
+		flags.Usage()
+		return saidaErro
+	}
+
+	logInfo("📡 Modo: Status do serviço SEFAZ")
+	logInfo("Ambiente: %s | UF(s): %s", cfg.Env, strings.Join(ufs, ", "))
+
+	client, err := sefaz.NewClient(*cfg)
+	if err != nil {
+		logErro("❌ Falha ao configurar cliente SEFAZ: %v", err)
+		return saidaConectividade
+	}
+
+	results := make([]resultadoStatus, len(ufs))
+	var wg sync.WaitGroup
+	for i, uf := range ufs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = consultarStatus(client, uf)
+		}()
+	}
+	wg.Wait()
+
+	codigo := saidaOK
+	disponiveis := 0
+	for _, r := range results {
+		switch {
+		case r.Erro != "":
+			logErro("❌ %s (%s): %s", r.UF, r.Autorizador, r.Erro)
+			codigo = max(codigo, saidaConectividade)
+		case r.Disponivel:
+			disponiveis++
+			logInfo("✅ %s (%s): %s - %s | tempo médio %.0fs | %dms",
+				r.UF, r.Autorizador, r.Status.Codigo, r.Status.Mensagem, r.Status.TempoMedio, r.Status.LatenciaMillis)
+		default:
+			logAviso("⚠️ %s (%s): %s - %s", r.UF, r.Autorizador, r.Status.Codigo, r.Status.Mensagem)
+			codigo = max(codigo, saidaRejeitada)
+		}
+	}
+	logInfo("📊 %d de %d UF(s) com serviço em operação", disponiveis, len(results))
+
+	printJSON(results)
+	return codigo
+}
+
+// ufsStatus resolve os argumentos em siglas de UF (vazio = UF da configuração)
+func ufsStatus(args []string, ufConfig string) ([]string, error) {
+	if len(args) == 0 {
+		if ufConfig == "" {
+			return nil, fmt.Errorf("informe a UF (ou configure NFE_UF_IBGE / uf no validator.yaml)")
+		}
+		return []string{ufConfig}, nil
+	}
+
+	var ufs []string
+	for _, arg := range args {
+		if strings.EqualFold(arg, "todas") || strings.EqualFold(arg, "all") {
+			return sefaz.UFs(), nil
+		}
+
+		uf := strings.ToUpper(arg)
+		if sigla := nfe.SiglaUF(arg); sigla != "" {
+			uf = sigla
+		}
+		if sefaz.Autorizador(uf) == "" {
+			return nil, fmt.Errorf("UF '%s' desconhecida", arg)
+		}
+		ufs = append(ufs, uf)
+	}
+	return ufs, nil
+}
+
+// consultarStatus consulta o NfeStatusServico4 que atende a UF
+//
+// A URL segue Client.URLServico: endpoints.status / SEFAZ_STATUS_URL vale
+// apenas para a UF da configuração; depois vêm as substituições da seção
+// webservices e a tabela embutida.
+func consultarStatus(client *sefaz.Client, uf string) resultadoStatus {
+	r := resultadoStatus{UF: uf, Autorizador: sefaz.Autorizador(uf)}
+
+	url, err := client.URLServico(sefaz.ServicoStatus, uf)
+	if err != nil {
+		r.Erro = err.Error()
+		return r
+	}
+	r.URL = url
+
+	logDetalhe("➡️ Consultando %s em %s", uf, url)
+	status, err := client.ConsultaStatusServico(nfe.CodigoUF(uf), url)
+	if err != nil {
+		r.Erro = err.Error()
+		return r
+	}
+
+	r.Status = &status
+	r.Disponivel = status.EmOperacao
+	return r
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// nomeServicoPadrao é o service.name dos spans quando OTEL_SERVICE_NAME não está definido
+const nomeServicoPadrao = "go-nfe-validator"
+
+// tracer cria os spans do pipeline de validação (no-op sem iniciarTracing)
+var tracer = otel.Tracer("github.com/fabyo/go-nfe-validator/cmd/validator")
+
+// encerrarTracing envia os spans pendentes; sair chama antes de encerrar o processo
+var encerrarTracing = func() {}
+
+// iniciarTracing liga a exportação OTLP dos spans quando o coletor está configurado no ambiente
+//
+// Segue as variáveis padrão do OpenTelemetry, sem flags próprias:
+//
+//	OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector:4318   # liga o tracing
+//	OTEL_EXPORTER_OTLP_PROTOCOL=grpc                         # padrão: http/protobuf
+//	OTEL_SERVICE_NAME=nfe-validator                          # padrão: go-nfe-validator
+//	OTEL_TRACES_SAMPLER=parentbased_traceidratio OTEL_TRACES_SAMPLER_ARG=0.1
+//
+// Sem OTEL_EXPORTER_OTLP_ENDPOINT (ou OTEL_EXPORTER_OTLP_TRACES_ENDPOINT), ou
+// com OTEL_SDK_DISABLED=true, os spans não são gravados.
+func iniciarTracing() {
+	if os.Getenv("OTEL_SDK_DISABLED") == "true" ||
+		(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "") {
+		return
+	}
+
+	ctx := context.Background()
+	exportador, err := novoExportadorOTLP(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Tracing desligado: %v\n", err)
+		return
+	}
+
+	// O service.name padrão vem antes: OTEL_SERVICE_NAME e OTEL_RESOURCE_ATTRIBUTES o sobrescrevem
+	recurso, err := resource.Merge(
+		resource.NewSchemaless(attribute.String("service.name", nomeServicoPadrao)),
+		resource.Environment(),
+	)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Atributos OTEL_RESOURCE_ATTRIBUTES ignorados: %v\n", err)
+	}
+
+	provedor := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exportador),
+		sdktrace.WithResource(recurso),
+	)
+	otel.SetTracerProvider(provedor)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	encerrarTracing = func() {
+		ctx, cancelar := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelar()
+		if err := provedor.Shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ Falha ao enviar os spans pendentes: %v\n", err)
+		}
+	}
+}
+
+// novoExportadorOTLP cria o exportador no protocolo de OTEL_EXPORTER_OTLP_(TRACES_)PROTOCOL
+func novoExportadorOTLP(ctx context.Context) (sdktrace.SpanExporter, error) {
+	protocolo := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	preencher(&protocolo, os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"))
+
+	switch strings.ToLower(protocolo) {
+	case "grpc":
+		return otlptracegrpc.New(ctx)
+	case "", "http/protobuf":
+		return otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("protocolo OTLP '%s' não suportado (use grpc ou http/protobuf)", protocolo)
+	}
+}
+
+// sair envia os spans pendentes e encerra o processo com o código informado
+func sair(codigo int) {
+	encerrarTracing()
+	os.Exit(codigo)
+}
+
+// anotarValidacao acrescenta ao span da validação os atributos do resultado
+func anotarValidacao(span trace.Span, r resultado) {
+	span.SetAttributes(
+		attribute.String("nfe.resultado", r.resultadoMetrica()),
+		attribute.Int("nfe.codigo_saida", r.codigoSaida()),
+	)
+	if r.ChaveAcesso != "" {
+		span.SetAttributes(attribute.String("nfe.chave", r.ChaveAcesso))
+	}
+	if r.DadosXML != nil {
+		span.SetAttributes(attribute.String("nfe.modelo", r.DadosXML.Modelo))
+	}
+	if r.consultouSefaz() {
+		span.SetAttributes(attribute.String("nfe.sefaz.cstat", r.Sefaz.Codigo))
+	}
+	if r.Erro != "" {
+		span.SetStatus(codes.Error, r.Erro)
+	}
+}
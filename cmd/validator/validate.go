@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// schemaPadrao é o XSD usado pelo modo lote quando -schema não é informado
+const schemaPadrao = "schemas/v4/procNFe_v4.00.xsd"
+
+// runValidate executa o subcomando "validate": valida vários XMLs de uma vez
+//
+// Aceita arquivos, pacotes .zip/.tar.gz, diretórios (varridos recursivamente)
+// e globs, inclusive com "**" para descer em subdiretórios.
+// Retorna o código de saída do processo.
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	xsdPath := flags.String("schema", schemaPadrao, "Arquivo XSD usado na validação")
+	xsdOnly := flags.Bool("xsd", false, "Validar apenas contra XSD (sem consulta SEFAZ)")
+	skipSefaz := flags.Bool("skip-sefaz", false, "Pular consulta SEFAZ (valida XSD + parse dados)")
+	offline := flags.Bool("offline", false, "Validação local completa (XSD + parse + assinatura + regras); SEFAZ marcada como pulada")
+	disableRules := flags.String("disable-rules", "", "IDs de regras de negócio a desabilitar, separados por vírgula (ex: ncm,cfop)")
+	workers := flags.Int("workers", 1, "Quantidade de arquivos validados em paralelo")
+	formato := flags.String("format", formatoJSON, "Formato da saída: "+strings.Join(formatosSaida, ", "))
+	saidaPath := flags.String("o", "", "Gravar os resultados (e o resumo) neste arquivo em vez do stdout, de forma atômica")
+	soFalhas := flags.Bool("only-failures", false, "Exibir apenas as notas reprovadas (qualquer fase ou achado de erro)")
+	soNaoAutorizadas := flags.Bool("only-unauthorized", false, "Exibir apenas as notas que a SEFAZ informou como não autorizadas")
+	status := flags.String("status", "", "Exibir apenas as notas com estes cStat da SEFAZ, separados por vírgula (ex: 101,110)")
+
+	versaoSaida := registrarFlagVersaoSaida(flags)
+	storeDSN := registrarFlagStore(flags)
+	arquivamentoOpts := registrarFlagsArquivamento(flags)
+	dedupOpts := registrarFlagsDedup(flags)
+	alertasDestinos := registrarFlagAlertas(flags)
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s validate [opções] <arquivo|pacote|diretório|glob>...\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Opções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -skip-sefaz ./notas")
+		fmt.Fprintln(os.Stderr, "  ./validator validate './notas/**/*.xml'")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -workers 8 -skip-sefaz ./notas")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -format table -skip-sefaz ./notas")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -workers 4 -skip-sefaz backup-2025-07.zip")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -skip-sefaz -o resultados.json ./notas")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -format html -o relatorio.html ./notas")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -format pdf -o fechamento-2025-07.pdf backup-2025-07.zip")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -format xlsx -o notas.xlsx ./notas")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -only-failures -format table ./notas")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -status 101,110 ./notas")
+		fmt.Fprintln(os.Stderr, "  ./validator validate -store sqlite:validacoes.db ./notas    # grava o histórico das validações")
+	}
+	flags.Parse(args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+	if err := validarVersaoSaida(*versaoSaida); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return saidaErro
+	}
+
+	filtro, err := novoFiltro(*soFalhas, *soNaoAutorizadas, *status)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+
+	// Com -o, grava em um temporário que só substitui o destino ao final do lote
+	var destino io.Writer = os.Stdout
+	var arquivoSaida *arquivoAtomico
+	if *saidaPath != "" {
+		arquivo, err := criarArquivoAtomico(*saidaPath)
+		if err != nil {
+			logErro("❌ %v", err)
+			return saidaErro
+		}
+		defer arquivo.Descartar()
+		arquivoSaida, destino = arquivo, arquivo
+	}
+
+	saida, err := novaSaida(*formato, destino, arquivoSaida != nil, *versaoSaida)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+
+	arquivos, err := expandirEntradas(flags.Args())
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	if len(arquivos) == 0 {
+		logErro("❌ Nenhum arquivo XML encontrado")
+		return saidaErro
+	}
+	logInfo("📂 %d arquivo(s)/pacote(s) para validar", len(arquivos))
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if arq.caminho != "" {
+		logInfo("⚙️ Configuração: %s", arq.descricao())
+	}
+
+	opts := &opcoesValidacao{
+		xsdPath:       *xsdPath,
+		xsdOnly:       *xsdOnly,
+		skipSefaz:     *skipSefaz,
+		offline:       *offline,
+		versaoSaida:   *versaoSaida,
+		mascararSaida: logOpts.mascararSaida(),
+		regras:        arq.configRegras(splitList(*disableRules)),
+	}
+	if !flagInformada(flags, "schema") {
+		opts.xsdPath = arq.schema(schemaPadrao)
+	}
+	if opts.consultaSefaz() {
+		if opts.cfg, err = arq.carregarConfig(); err != nil {
+			logErro("❌ %v", err)
+			return saidaConectividade
+		}
+		logInfo("Ambiente ativo: %s (UF %s)", opts.cfg.Env, opts.cfg.UF)
+	}
+	opts.logNivel()
+
+	historico, err := abrirHistorico(*storeDSN, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if historico != nil {
+		defer historico.Close()
+	}
+	opts.historico, opts.origem = historico, "validate"
+
+	arquivamento, err := arquivamentoOpts.criar(flags, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	arquivamento.manterRetencao(context.Background())
+	opts.arquivamento = arquivamento
+
+	dups, fecharDedup, err := dedupOpts.criar(historico, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	defer fecharDedup()
+	opts.dedup = dups
+
+	alertas, err := criarAlertador(*alertasDestinos, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	opts.alertas = alertas
+
+	// XSD carregado uma única vez e compartilhado entre os workers
+	xsd, err := validation.NewXSDValidator(opts.xsdPath)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	defer xsd.Close()
+	opts.xsd = xsd
+
+	barra := novaBarraProgresso(contarItens(arquivos))
+	inicio := time.Now()
+
+	var errSaida error
+	exibidos := 0
+	results := validarLote(itensDoLote(arquivos), opts, *workers, func(r resultado) {
+		barra.avancar()
+		if !filtro.aceita(r) {
+			return
+		}
+		exibidos++
+		if err := saida.Escrever(r); err != nil && errSaida == nil {
+			errSaida = err
+		}
+	})
+	barra.concluir()
+
+	resumo := novoResumo(results, time.Since(inicio))
+	if filtro.ativo() {
+		resumo.Omitidos = len(results) - exibidos
+		logInfo("🔎 Filtro (%s): %d de %d resultado(s) exibidos", filtro, exibidos, len(results))
+	}
+	if err := saida.Fechar(resumo); err != nil && errSaida == nil {
+		errSaida = err
+	}
+	if errSaida == nil && arquivoSaida != nil {
+		errSaida = arquivoSaida.Confirmar()
+	}
+	if errSaida != nil {
+		logErro("❌ Falha ao escrever resultados: %v", errSaida)
+		return saidaErro
+	}
+	if arquivoSaida != nil {
+		logInfo("💾 Resultados gravados em %s", *saidaPath)
+	}
+
+	resumo.registrar()
+
+	return codigoSaidaLote(results)
+}
+
+// validarLote valida os itens com N workers, devolvendo os resultados na ordem de entrada
+//
+// emitir (opcional) recebe cada resultado assim que ele e todos os anteriores
+// estão prontos, permitindo saídas em streaming sem perder a ordem.
+func validarLote(itens iter.Seq[itemLote], opts *opcoesValidacao, workers int, emitir func(resultado)) []resultado {
+	if workers < 1 {
+		workers = 1
+	}
+
+	type tarefa struct {
+		indice int
+		item   itemLote
+	}
+	type concluido struct {
+		indice int
+		result resultado
+	}
+
+	tarefas := make(chan tarefa)
+	concluidos := make(chan concluido)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tarefas {
+				logDetalhe("📄 %s", t.item.nome)
+				result := validarItem(t.item, opts)
+				result.Arquivo = t.item.nome
+				opts.gravar(result)
+				concluidos <- concluido{indice: t.indice, result: result}
+			}
+		}()
+	}
+
+	// Os itens são produzidos sob demanda (pacotes são descompactados aos poucos)
+	go func() {
+		i := 0
+		for item := range itens {
+			tarefas <- tarefa{indice: i, item: item}
+			i++
+		}
+		close(tarefas)
+		wg.Wait()
+		close(concluidos)
+	}()
+
+	// Emite em ordem: guarda os resultados fora de ordem até o anterior chegar
+	var results []resultado
+	pendentes := make(map[int]resultado)
+	for c := range concluidos {
+		pendentes[c.indice] = c.result
+		for {
+			result, ok := pendentes[len(results)]
+			if !ok {
+				break
+			}
+			delete(pendentes, len(results))
+			results = append(results, result)
+			if emitir != nil {
+				emitir(result)
+			}
+		}
+	}
+
+	return results
+}
+
+// expandirEntradas converte arquivos, diretórios e globs na lista de XMLs a validar
+//
+// Diretórios são varridos recursivamente (apenas *.xml e pacotes .zip/.tar.gz/.tgz). Globs aceitam "**"
+// para casar qualquer quantidade de subdiretórios. O resultado não tem
+// duplicatas e mantém a ordem das entradas (arquivos de cada entrada em ordem alfabética).
+func expandirEntradas(entradas []string) ([]string, error) {
+	var arquivos []string
+	vistos := make(map[string]bool)
+
+	adicionar := func(paths []string) {
+		sort.Strings(paths)
+		for _, p := range paths {
+			if !vistos[p] {
+				vistos[p] = true
+				arquivos = append(arquivos, p)
+			}
+		}
+	}
+
+	for _, entrada := range entradas {
+		// Glob
+		if strings.ContainsAny(entrada, "*?[") {
+			paths, err := expandirGlob(entrada)
+			if err != nil {
+				return nil, fmt.Errorf("glob inválido '%s': %w", entrada, err)
+			}
+			adicionar(paths)
+			continue
+		}
+
+		info, err := os.Stat(entrada)
+		if err != nil {
+			return nil, fmt.Errorf("entrada inválida '%s': %w", entrada, err)
+		}
+
+		// Diretório
+		if info.IsDir() {
+			paths, err := xmlsDoDiretorio(entrada)
+			if err != nil {
+				return nil, err
+			}
+			adicionar(paths)
+			continue
+		}
+
+		// Arquivo
+		adicionar([]string{entrada})
+	}
+
+	return arquivos, nil
+}
+
+// xmlsDoDiretorio lista recursivamente os arquivos .xml e pacotes de um diretório
+func xmlsDoDiretorio(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && (isXML(path) || ehPacote(path)) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("falha ao varrer diretório '%s': %w", dir, err)
+	}
+	return paths, nil
+}
+
+// expandirGlob expande um glob, com suporte a "**" (zero ou mais diretórios)
+func expandirGlob(pattern string) ([]string, error) {
+	idx := strings.Index(pattern, "**")
+	if idx < 0 {
+		return filepath.Glob(pattern)
+	}
+
+	// Valida a sintaxe do padrão antes de varrer o disco
+	if _, err := filepath.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return nil, err
+	}
+
+	raiz := filepath.Clean(pattern[:idx])
+	if pattern[:idx] == "" {
+		raiz = "."
+	}
+	resto := strings.TrimLeft(pattern[idx+2:], `/\`)
+	if resto == "" {
+		resto = "*"
+	}
+
+	var paths []string
+	err := filepath.WalkDir(raiz, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(raiz, path)
+		if err != nil {
+			return nil
+		}
+
+		// "**" casa qualquer prefixo de diretórios: testa cada sufixo do caminho relativo
+		partes := strings.Split(filepath.ToSlash(rel), "/")
+		for i := range partes {
+			if ok, _ := filepath.Match(filepath.FromSlash(resto), filepath.Join(partes[i:]...)); ok {
+				paths = append(paths, path)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
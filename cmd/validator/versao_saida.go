@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// Versões do contrato JSON aceitas por -output-version (campo schema_version)
+const (
+	// versaoSaidaV1 é o formato original: campos da ValidationResponse no topo
+	versaoSaidaV1 = "v1"
+
+	// versaoSaidaV2 agrupa o andamento por fase e sempre traz todas as chaves
+	versaoSaidaV2 = "v2"
+)
+
+// versoesSaida lista as versões válidas (usado na ajuda e na validação da flag)
+var versoesSaida = []string{versaoSaidaV1, versaoSaidaV2}
+
+// Situação de cada fase no contrato v2
+const (
+	faseOK     = "ok"
+	faseFalha  = "falha"
+	fasePulada = "pulada"
+)
+
+// registrarFlagVersaoSaida registra -output-version no FlagSet do subcomando
+func registrarFlagVersaoSaida(flags *flag.FlagSet) *string {
+	return flags.String("output-version", versaoSaidaV1, "Versão do contrato JSON da saída: "+strings.Join(versoesSaida, ", "))
+}
+
+// validarVersaoSaida confere o valor de -output-version
+func validarVersaoSaida(versao string) error {
+	for _, v := range versoesSaida {
+		if versao == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("versão de saída inválida '%s' (use %s)", versao, strings.Join(versoesSaida, ", "))
+}
+
+// resultadoV2 é o contrato v2: chaves estáveis (sempre presentes) e situação por fase
+//
+// Campos novos entram sem mudar os existentes; uma mudança incompatível
+// exige um novo schema_version.
+type resultadoV2 struct {
+	SchemaVersion string                  `json:"schema_version"`
+	Arquivo       string                  `json:"arquivo,omitempty"`
+	Tipo          string                  `json:"tipo"`
+	ChaveAcesso   string                  `json:"chave_acesso"`
+	Aprovado      bool                    `json:"aprovado"`
+	CodigoSaida   int                     `json:"codigo_saida"`
+	Fases         fasesV2                 `json:"fases"`
+	Sefaz         validation.SefazStatus  `json:"sefaz"`
+	DadosXML      *validation.DadosXMLNFe `json:"dados_xml"`
+	Achados       []nfe.Achado            `json:"achados"`
+	Erro          string                  `json:"erro"`
+	CodigoErro    nfe.CodigoErro          `json:"codigo_erro"`
+	Duplicata     *duplicata              `json:"duplicata"`
+}
+
+// fasesV2 indica se cada fase passou, falhou ou não foi executada
+type fasesV2 struct {
+	XSD        string `json:"xsd"`
+	Parse      string `json:"parse"`
+	Assinatura string `json:"assinatura"`
+	Regras     string `json:"regras"`
+	Sefaz      string `json:"sefaz"`
+}
+
+// MarshalJSON escreve o resultado no contrato escolhido em -output-version
+func (r resultado) MarshalJSON() ([]byte, error) {
+	if r.versao == versaoSaidaV2 {
+		return json.Marshal(r.v2())
+	}
+
+	// Sem o método MarshalJSON, para não entrar em recursão
+	type resultadoV1 resultado
+	return json.Marshal(struct {
+		SchemaVersion string `json:"schema_version"`
+		resultadoV1
+	}{versaoSaidaV1, resultadoV1(r.mascarado())})
+}
+
+// mascarado devolve uma cópia com os dados pessoais mascarados (-redact all); sem a flag, o próprio resultado
+//
+// O histórico, o arquivamento e os alertas continuam recebendo os dados
+// completos: o mascaramento vale apenas para o que o CLI publica.
+func (r resultado) mascarado() resultado {
+	if !r.mascarar {
+		return r
+	}
+
+	if d := r.DadosXML; d != nil {
+		dados := *d
+		dados.EmitCNPJ = nfe.MascararDocumento(d.EmitCNPJ)
+		dados.EmitRazao = nfe.MascararNome(d.EmitRazao)
+		dados.DestDoc = nfe.MascararDocumento(d.DestDoc)
+		dados.DestNome = nfe.MascararNome(d.DestNome)
+		r.DadosXML = &dados
+	}
+	if r.Achados != nil {
+		achados := make([]nfe.Achado, len(r.Achados))
+		for i, a := range r.Achados {
+			a.Mensagem = nfe.MascararTexto(a.Mensagem)
+			achados[i] = a
+		}
+		r.Achados = achados
+	}
+	r.Sefaz.Mensagem = nfe.MascararTexto(r.Sefaz.Mensagem)
+	r.Erro = nfe.MascararTexto(r.Erro)
+	r.mascarar = false
+	return r
+}
+
+// v2 converte o resultado para o contrato v2 (mascarado com -redact all)
+func (r resultado) v2() resultadoV2 {
+	r = r.mascarado()
+	out := resultadoV2{
+		SchemaVersion: versaoSaidaV2,
+		Arquivo:       r.Arquivo,
+		Tipo:          r.Tipo,
+		ChaveAcesso:   r.ChaveAcesso,
+		Aprovado:      r.aprovado(),
+		CodigoSaida:   r.codigoSaida(),
+		Sefaz:         r.Sefaz,
+		DadosXML:      r.DadosXML,
+		Achados:       r.Achados,
+		Erro:          r.Erro,
+		CodigoErro:    r.CodigoErro,
+		Duplicata:     r.Duplicata,
+		Fases: fasesV2{
+			XSD:        fasePulada,
+			Parse:      fasePulada,
+			Assinatura: fasePulada,
+			Regras:     fasePulada,
+			Sefaz:      fasePulada,
+		},
+	}
+	if out.Achados == nil {
+		out.Achados = []nfe.Achado{}
+	}
+	if out.Sefaz.Consulta == "" {
+		out.Sefaz.Consulta = validation.ConsultaPulada
+	}
+
+	switch {
+	case r.ValidoXSD:
+		out.Fases.XSD = faseOK
+	case r.saida == saidaXSDInvalido:
+		out.Fases.XSD = faseFalha
+	}
+
+	switch {
+	case r.DadosXML != nil:
+		out.Fases.Parse = faseOK
+		out.Fases.Regras = situacaoAchados(r.Achados, func(a nfe.Achado) bool { return a.Regra != nfe.RegraAssinatura })
+	case r.saida == saidaParse:
+		out.Fases.Parse = faseFalha
+	}
+
+	if r.assinaturaConferida {
+		out.Fases.Assinatura = situacaoAchados(r.Achados, func(a nfe.Achado) bool { return a.Regra == nfe.RegraAssinatura })
+	}
+
+	switch r.Sefaz.Consulta {
+	case validation.ConsultaRealizada:
+		out.Fases.Sefaz = faseOK
+		if !r.Sefaz.Autorizado {
+			out.Fases.Sefaz = faseFalha
+		}
+	case validation.ConsultaFalhou:
+		out.Fases.Sefaz = faseFalha
+	}
+
+	return out
+}
+
+// situacaoAchados é "falha" se algum achado selecionado tem severidade de erro, senão "ok"
+func situacaoAchados(achados []nfe.Achado, selecionar func(nfe.Achado) bool) string {
+	for _, a := range achados {
+		if selecionar(a) && a.Severidade == nfe.SeveridadeErro {
+			return faseFalha
+		}
+	}
+	return faseOK
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// Subdiretórios de destino do modo watch
+const (
+	dirOK   = "ok"
+	dirErro = "erro"
+)
+
+// runWatch executa o subcomando "watch": valida os XMLs que chegam em uma pasta
+//
+// Cada arquivo .xml criado na pasta é validado e movido para ok/ ou erro/,
+// junto com o resultado em JSON (<arquivo>.json). É o padrão clássico de
+// integração com ERPs legados via "hot folder".
+func runWatch(args []string) int {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	xsdPath := flags.String("schema", schemaPadrao, "Arquivo XSD usado na validação")
+	xsdOnly := flags.Bool("xsd", false, "Validar apenas contra XSD (sem consulta SEFAZ)")
+	skipSefaz := flags.Bool("skip-sefaz", false, "Pular consulta SEFAZ (valida XSD + parse dados)")
+	offline := flags.Bool("offline", false, "Validação local completa (XSD + parse + assinatura + regras); SEFAZ marcada como pulada")
+	disableRules := flags.String("disable-rules", "", "IDs de regras de negócio a desabilitar, separados por vírgula (ex: ncm,cfop)")
+	settle := flags.Duration("settle", 500*time.Millisecond, "Tempo sem escrita antes de validar o arquivo (evita ler arquivo pela metade)")
+	shutdownTimeout := registrarFlagEncerramento(flags)
+
+	versaoSaida := registrarFlagVersaoSaida(flags)
+	webhookOpts := registrarFlagsWebhook(flags)
+	storeDSN := registrarFlagStore(flags)
+	arquivamentoOpts := registrarFlagsArquivamento(flags)
+	dedupOpts := registrarFlagsDedup(flags)
+	alertasDestinos := registrarFlagAlertas(flags)
+	configOpts := registrarFlagConfig(flags)
+	logOpts := registrarFlagsLog(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Uso: %s watch [opções] <diretório>\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Valida cada XML colocado no diretório e move para ok/ ou erro/.")
+		fmt.Fprintln(os.Stderr, "\nOpções:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr, "\nExemplos:")
+		fmt.Fprintln(os.Stderr, "  ./validator watch -skip-sefaz /srv/erp/saida")
+		fmt.Fprintln(os.Stderr, "  ./validator watch -webhook https://erp.exemplo.com.br/nfe/validacoes /srv/erp/saida")
+		fmt.Fprintln(os.Stderr, "  ./validator watch -archive s3://nfe-xml/aprovados -archive-retention 5 /srv/erp/saida")
+	}
+	flags.Parse(args)
+
+	if err := logOpts.aplicar(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+	if err := validarVersaoSaida(*versaoSaida); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return saidaErro
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return saidaErro
+	}
+	dir := flags.Arg(0)
+
+	for _, sub := range []string{dirOK, dirErro} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			logErro("❌ Falha ao criar diretório %s: %v", sub, err)
+			return saidaErro
+		}
+	}
+
+	arq, err := configOpts.carregar()
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if arq.caminho != "" {
+		logInfo("⚙️ Configuração: %s", arq.descricao())
+	}
+
+	opts := &opcoesValidacao{
+		xsdPath:       *xsdPath,
+		xsdOnly:       *xsdOnly,
+		skipSefaz:     *skipSefaz,
+		offline:       *offline,
+		versaoSaida:   *versaoSaida,
+		mascararSaida: logOpts.mascararSaida(),
+		regras:        arq.configRegras(splitList(*disableRules)),
+	}
+	if !flagInformada(flags, "schema") {
+		opts.xsdPath = arq.schema(schemaPadrao)
+	}
+	if opts.consultaSefaz() {
+		if opts.cfg, err = arq.carregarConfig(); err != nil {
+			logErro("❌ %v", err)
+			return saidaConectividade
+		}
+		logInfo("Ambiente ativo: %s (UF %s)", opts.cfg.Env, opts.cfg.UF)
+	}
+	opts.logNivel()
+
+	sinal, pararSinais := contextoSinais()
+	defer pararSinais()
+	trabalho, cancelarTrabalho := contextoTrabalho(sinal, *shutdownTimeout)
+	defer cancelarTrabalho()
+	opts.ctx = trabalho
+
+	wh, err := webhookOpts.criar(flags, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	if wh != nil {
+		logInfo("🔔 Webhook: %s", wh.url)
+	}
+	defer wh.fechar()
+
+	historico, err := abrirHistorico(*storeDSN, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	if historico != nil {
+		defer historico.Close()
+	}
+	opts.historico, opts.origem = historico, "watch"
+
+	arquivamento, err := arquivamentoOpts.criar(flags, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	arquivamento.manterRetencao(context.Background())
+	opts.arquivamento = arquivamento
+
+	dups, fecharDedup, err := dedupOpts.criar(historico, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	defer fecharDedup()
+	opts.dedup = dups
+
+	alertas, err := criarAlertador(*alertasDestinos, arq)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaErro
+	}
+	opts.alertas = alertas
+
+	xsd, err := validation.NewXSDValidator(opts.xsdPath)
+	if err != nil {
+		logErro("❌ %v", err)
+		return saidaConectividade
+	}
+	defer xsd.Close()
+	opts.xsd = xsd
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logErro("❌ Falha ao iniciar watcher: %v", err)
+		return saidaErro
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(dir); err != nil {
+		logErro("❌ Falha ao observar %s: %v", dir, err)
+		return saidaErro
+	}
+
+	w := &hotFolder{dir: dir, opts: opts, webhook: wh, settle: *settle, pendentes: make(map[string]*time.Timer)}
+
+	// Arquivos que já estavam na pasta antes do watch iniciar
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		logErro("❌ Falha ao ler %s: %v", dir, err)
+		return saidaErro
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && isXML(entry.Name()) {
+			w.agendar(filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	logInfo("👀 Observando %s (Ctrl+C para sair)", dir)
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return 0
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+				if isXML(event.Name) {
+					w.agendar(event.Name)
+				}
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return 0
+			}
+			logAviso("⚠️ Erro do watcher: %v", err)
+
+		case <-sinal.Done():
+			logInfo("🛑 Encerrando watch (até %s para concluir os arquivos em validação)", *shutdownTimeout)
+			prazo, cancelar := context.WithTimeout(context.Background(), *shutdownTimeout)
+			defer cancelar()
+
+			aguardarAte(prazo, "os arquivos em validação", w.parar)
+			wh.drenar(prazo)
+			return 0
+		}
+	}
+}
+
+// hotFolder valida e move os arquivos que chegam na pasta observada
+type hotFolder struct {
+	dir     string
+	opts    *opcoesValidacao
+	webhook *webhook
+	settle  time.Duration
+
+	mu        sync.Mutex
+	pendentes map[string]*time.Timer
+	wg        sync.WaitGroup
+}
+
+// agendar (re)inicia o timer do arquivo: só valida depois de "settle" sem novas escritas
+func (h *hotFolder) agendar(path string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if t, ok := h.pendentes[path]; ok {
+		t.Reset(h.settle)
+		return
+	}
+
+	h.wg.Add(1)
+	h.pendentes[path] = time.AfterFunc(h.settle, func() {
+		defer h.wg.Done()
+
+		h.mu.Lock()
+		delete(h.pendentes, path)
+		h.mu.Unlock()
+
+		h.processar(path)
+	})
+}
+
+// parar cancela os arquivos ainda não iniciados e aguarda os que estão em validação
+func (h *hotFolder) parar() {
+	h.mu.Lock()
+	for path, t := range h.pendentes {
+		if t.Stop() {
+			h.wg.Done()
+			delete(h.pendentes, path)
+		}
+	}
+	h.mu.Unlock()
+
+	h.wg.Wait()
+}
+
+// processar valida o arquivo, move para ok/ ou erro/ e grava o resultado ao lado
+func (h *hotFolder) processar(path string) {
+	if _, err := os.Stat(path); err != nil {
+		// Arquivo removido/renomeado antes da validação
+		return
+	}
+
+	logInfo("📄 %s", path)
+	result := validarArquivo(path, h.opts)
+	result.Arquivo = filepath.Base(path)
+	if err := conferirPrazo(h.opts.contexto()); err != nil {
+		// Não move: o arquivo é validado de novo na próxima execução do watch
+		logAviso("⚠️ %s: %v; o arquivo continua na pasta", path, err)
+		return
+	}
+	h.opts.gravar(result)
+
+	destino := dirErro
+	if result.aprovado() {
+		destino = dirOK
+	}
+
+	novoPath, err := moverSemSobrescrever(path, filepath.Join(h.dir, destino))
+	if err != nil {
+		logErro("❌ Falha ao mover %s para %s/: %v", path, destino, err)
+		return
+	}
+
+	jsonOutput, err := json.MarshalIndent(result, "", "  ")
+	if err == nil {
+		err = os.WriteFile(novoPath+".json", jsonOutput, 0o644)
+	}
+	if err != nil {
+		logAviso("⚠️ Falha ao gravar resultado de %s: %v", novoPath, err)
+	}
+
+	// Uma linha JSON por arquivo no stdout (fácil de acompanhar com tail/jq)
+	if linha, err := json.Marshal(result); err == nil {
+		fmt.Println(string(linha))
+	}
+	h.webhook.notificar(result)
+
+	logInfo("   ➜ %s", novoPath)
+}
+
+// moverSemSobrescrever move o arquivo para o diretório, renomeando se já existir um homônimo
+func moverSemSobrescrever(path, dir string) (string, error) {
+	nome := filepath.Base(path)
+	destino := filepath.Join(dir, nome)
+
+	if _, err := os.Stat(destino); err == nil {
+		ext := filepath.Ext(nome)
+		base := strings.TrimSuffix(nome, ext)
+		destino = filepath.Join(dir, fmt.Sprintf("%s_%s%s", base, time.Now().Format("20060102150405.000000000"), ext))
+	}
+
+	if err := os.Rename(path, destino); err != nil {
+		return "", err
+	}
+	return destino, nil
+}
+
+// isXML indica se o nome do arquivo tem extensão .xml
+func isXML(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".xml")
+}
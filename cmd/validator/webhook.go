@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Cabeçalhos de cada entrega do webhook
+const (
+	// cabecalhoAssinatura traz "sha256=<hex>": HMAC-SHA256 do corpo com o segredo
+	cabecalhoAssinatura = "X-Validator-Signature-256"
+
+	// cabecalhoEntrega identifica a entrega; é o mesmo em todas as tentativas (idempotência)
+	cabecalhoEntrega = "X-Validator-Delivery"
+
+	// cabecalhoEvento é o tipo do evento (por enquanto, sempre "validacao")
+	cabecalhoEvento = "X-Validator-Event"
+)
+
+// eventoValidacao é o evento enviado a cada XML validado
+const eventoValidacao = "validacao"
+
+// envSegredoWebhook é a variável de ambiente com o segredo do HMAC (evita o segredo no "ps")
+const envSegredoWebhook = "NFE_WEBHOOK_SECRET"
+
+// entregasSimultaneas limita os POSTs em andamento; os demais aguardam a vez
+const entregasSimultaneas = 4
+
+// esperaWebhook é o intervalo antes da segunda tentativa; dobra a cada nova falha
+var esperaWebhook = time.Second
+
+// flagsWebhook são as flags -webhook* dos modos serve e watch
+type flagsWebhook struct {
+	url        *string
+	segredo    *string
+	tentativas *int
+	timeout    *time.Duration
+}
+
+// registrarFlagsWebhook adiciona as flags do webhook ao FlagSet
+func registrarFlagsWebhook(flags *flag.FlagSet) *flagsWebhook {
+	return &flagsWebhook{
+		url:        flags.String("webhook", "", "URL que recebe um POST com o resultado de cada validação"),
+		segredo:    flags.String("webhook-secret", "", "Segredo do HMAC-SHA256 do webhook (padrão: $"+envSegredoWebhook+")"),
+		tentativas: flags.Int("webhook-retries", 3, "Tentativas de entrega do webhook (falha de rede, HTTP 429 ou 5xx)"),
+		timeout:    flags.Duration("webhook-timeout", 10*time.Second, "Tempo máximo de cada tentativa de entrega do webhook"),
+	}
+}
+
+// criar monta o webhook a partir das flags, da variável de ambiente e do arquivo de configuração
+//
+// Retorna nil (webhook desligado) quando nenhuma URL foi configurada.
+func (f *flagsWebhook) criar(flags *flag.FlagSet, arq *arquivoConfig) (*webhook, error) {
+	w := &webhook{
+		url:        *f.url,
+		segredo:    *f.segredo,
+		tentativas: *f.tentativas,
+		vagas:      make(chan struct{}, entregasSimultaneas),
+	}
+	timeout := *f.timeout
+
+	preencher(&w.url, arq.Webhook.URL)
+	preencher(&w.segredo, arq.getenv(envSegredoWebhook))
+	preencher(&w.segredo, arq.Webhook.Segredo)
+	if !flagInformada(flags, "webhook-retries") && arq.Webhook.Tentativas > 0 {
+		w.tentativas = arq.Webhook.Tentativas
+	}
+	if !flagInformada(flags, "webhook-timeout") && arq.Webhook.Timeout != "" {
+		// Já conferido em arquivoConfig.validar
+		timeout, _ = time.ParseDuration(arq.Webhook.Timeout)
+	}
+
+	if w.url == "" {
+		return nil, nil
+	}
+	if u, err := url.Parse(w.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("URL do webhook inválida '%s' (use http:// ou https://)", w.url)
+	}
+	if w.tentativas < 1 {
+		return nil, fmt.Errorf("-webhook-retries deve ser ao menos 1 (recebido %d)", w.tentativas)
+	}
+
+	w.client = &http.Client{Timeout: timeout}
+	w.ctx, w.cancelar = context.WithCancel(context.Background())
+	return w, nil
+}
+
+// webhook entrega os resultados das validações via HTTP POST, em segundo plano
+//
+// O corpo é o mesmo JSON do stdout (respeita -output-version). Com segredo,
+// o receptor confere a origem recalculando o HMAC:
+//
+//	mac := hmac.New(sha256.New, []byte(segredo))
+//	mac.Write(corpo)
+//	ok := hmac.Equal([]byte(r.Header.Get("X-Validator-Signature-256")),
+//		[]byte("sha256="+hex.EncodeToString(mac.Sum(nil))))
+type webhook struct {
+	url        string
+	segredo    string
+	tentativas int
+	client     *http.Client
+
+	vagas chan struct{}
+	wg    sync.WaitGroup
+
+	// ctx cancela as entregas pendentes quando o prazo de encerramento vence (ver drenar)
+	ctx      context.Context
+	cancelar context.CancelFunc
+}
+
+// notificar agenda a entrega do resultado; não faz nada com o webhook desligado (nil)
+func (w *webhook) notificar(r resultado) {
+	if w == nil {
+		return
+	}
+
+	corpo, err := json.Marshal(r)
+	if err != nil {
+		logAviso("⚠️ Webhook: falha ao serializar %s: %v", valorOuTraco(r.Arquivo), err)
+		return
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.vagas <- struct{}{}
+		defer func() { <-w.vagas }()
+
+		if err := w.entregar(corpo); err != nil {
+			logAviso("⚠️ Webhook de %s não entregue: %v", valorOuTraco(r.Arquivo), err)
+		}
+	}()
+}
+
+// fechar aguarda as entregas em andamento (inclusive as novas tentativas)
+func (w *webhook) fechar() {
+	if w == nil {
+		return
+	}
+	w.wg.Wait()
+}
+
+// drenar aguarda as entregas pendentes até o fim do prazo de ctx e cancela as que sobrarem
+func (w *webhook) drenar(ctx context.Context) {
+	if w == nil {
+		return
+	}
+	if !aguardarAte(ctx, "as entregas do webhook", w.wg.Wait) {
+		w.cancelar()
+		w.wg.Wait()
+	}
+}
+
+// entregar envia o corpo, repetindo com espera exponencial enquanto a falha for temporária
+func (w *webhook) entregar(corpo []byte) error {
+	id := idEntrega()
+	espera := esperaWebhook
+
+	var err error
+	for tentativa := 1; tentativa <= w.tentativas; tentativa++ {
+		var repetir bool
+		if repetir, err = w.enviar(corpo, id); err == nil {
+			logDetalhe("🔔 Webhook %s entregue (tentativa %d)", id, tentativa)
+			return nil
+		}
+		if !repetir {
+			break
+		}
+		if tentativa < w.tentativas {
+			logDebug("Webhook %s: tentativa %d falhou (%v); nova tentativa em %s", id, tentativa, err, espera)
+			select {
+			case <-time.After(espera):
+			case <-w.ctx.Done():
+				return fmt.Errorf("entrega cancelada no encerramento: %w", err)
+			}
+			espera *= 2
+		}
+	}
+	return err
+}
+
+// enviar faz um POST; repetir indica se a falha é temporária (rede, 429 ou 5xx)
+func (w *webhook) enviar(corpo []byte, id string) (repetir bool, err error) {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, w.url, bytes.NewReader(corpo))
+	if err != nil {
+		return false, fmt.Errorf("erro ao montar requisição: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(cabecalhoEvento, eventoValidacao)
+	req.Header.Set(cabecalhoEntrega, id)
+	if w.segredo != "" {
+		req.Header.Set(cabecalhoAssinatura, assinarWebhook(w.segredo, corpo))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("erro ao enviar: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, errors.New("HTTP " + resp.Status)
+	default:
+		return false, errors.New("HTTP " + resp.Status)
+	}
+}
+
+// assinarWebhook calcula o valor do cabeçalho de assinatura: "sha256=" + HMAC-SHA256 em hex
+func assinarWebhook(segredo string, corpo []byte) string {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	mac.Write(corpo)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// idEntrega gera um identificador aleatório para a entrega
+func idEntrega() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
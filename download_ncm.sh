@@ -0,0 +1,44 @@
+#!/bin/bash
+
+# --- Configurações ---
+NCM_URL="https://portalunico.siscomex.gov.br/classif/api/publico/nomenclatura/download/json"
+TARGET_FILE="${1:-pkg/nfe/ncm.txt}" # go generate passa o caminho relativo ao pacote
+TEMP_FILE="$(mktemp)"
+NOVA_TABELA="$(mktemp "$(dirname "$TARGET_FILE")/.ncm.XXXXXX")" # mesmo diretório: o mv é um rename
+trap 'rm -f "$TEMP_FILE" "$NOVA_TABELA"' EXIT
+
+# --- 1. Baixa a tabela NCM vigente (Siscomex) ---
+echo "Baixando tabela NCM do Portal Único Siscomex..."
+curl -L --fail -s -o "$TEMP_FILE" "$NCM_URL"
+
+if [ $? -ne 0 ]; then
+    echo "❌ FALHA: Download da tabela NCM falhou. Verifique a URL ou a conexão."
+    exit 1
+fi
+
+# --- 2. Gera a tabela compacta (apenas códigos de 8 dígitos) ---
+# Formato: CODIGO ou CODIGO;AAAA-MM-DD (fim de vigência, quando revogado)
+# Vai para um temporário: o $TARGET_FILE só é substituído depois da conferência
+echo "Gerando $TARGET_FILE..."
+{
+    echo "# Tabela NCM compacta - gerada por download_ncm.sh em $(date +%Y-%m-%d)"
+    echo "# Formato: CODIGO[;FIM_VIGENCIA AAAA-MM-DD]"
+    jq -r '.Nomenclaturas[]
+        | (.Codigo | gsub("\\."; "")) as $cod
+        | select($cod | length == 8)
+        | if .Data_Fim == "31/12/9999" then $cod
+          else $cod + ";" + (.Data_Fim | split("/") | .[2] + "-" + .[1] + "-" + .[0])
+          end' "$TEMP_FILE" | sort -u
+} > "$NOVA_TABELA"
+
+# --- 3. Confere e instala ---
+# Uma tabela vazia faria VerificarNCM voltar a conferir só o capítulo
+if [ "$(grep -vc '^#' "$NOVA_TABELA")" -eq 0 ]; then
+    echo "❌ FALHA: nenhum código NCM de 8 dígitos no download; $TARGET_FILE mantido."
+    exit 1
+fi
+
+chmod 644 "$NOVA_TABELA"
+mv "$NOVA_TABELA" "$TARGET_FILE"
+
+echo "✅ Sucesso! $(grep -vc '^#' "$TARGET_FILE") códigos NCM gravados em $TARGET_FILE."
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfetest"
+)
+
+// Gera NF-e falsas e válidas no XSD (ver nfetest.Gerador), para teste de carga ou para examples/testdata
+//
+//	go run examples/gerar-notas/main.go -n 1000 -dir /tmp/carga
+//	go run examples/gerar-notas/main.go -n 4 -semente 1 -emissao 2025-07-10
+func main() {
+	n := flag.Int("n", 10, "quantidade de notas")
+	dir := flag.String("dir", "examples/testdata", "diretório de saída")
+	modelo := flag.String("modelo", "", "55, 65 ou vazio para alternar entre os dois")
+	uf := flag.String("uf", "35", "código IBGE da UF do emitente")
+	itens := flag.Int("itens", 0, "itens por nota (0 = de 1 a 5, sorteado)")
+	semente := flag.Uint64("semente", uint64(time.Now().UnixNano()), "semente (a mesma semente e -emissao geram as mesmas notas)")
+	emissao := flag.String("emissao", "", "data de emissão AAAA-MM-DD (vazio = agora)")
+	flag.Parse()
+
+	var dhEmi time.Time
+	if *emissao != "" {
+		d, err := time.Parse("2006-01-02", *emissao)
+		if err != nil {
+			log.Fatalf("❌ -emissao inválida: %v", err)
+		}
+		dhEmi = d.Add(10 * time.Hour)
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	g := nfetest.NewGerador(*semente)
+	for i := range *n {
+		m := *modelo
+		if m == "" {
+			m = []string{"55", "65"}[i%2]
+		}
+		nota := g.Gerar(nfetest.ConfigGerador{Modelo: m, UF: *uf, Itens: *itens, Emissao: dhEmi})
+
+		arquivo := filepath.Join(*dir, nota.Chave+"-procNFe.xml")
+		if err := os.WriteFile(arquivo, nota.XML, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("✅ %s (modelo %s, %d itens, R$ %s)\n", arquivo, m, len(nota.Dados.Itens), nota.Dados.ValorTotal)
+	}
+}
@@ -0,0 +1,131 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Cabeçalhos do webhook genérico (os mesmos do webhook de validações do CLI)
+const (
+	cabecalhoAssinatura = "X-Validator-Signature-256" // "sha256=<hex>": HMAC-SHA256 do corpo
+	cabecalhoEntrega    = "X-Validator-Delivery"
+	cabecalhoEvento     = "X-Validator-Event"
+)
+
+// eventoAlerta é o valor de X-Validator-Event nos alertas
+const eventoAlerta = "alerta"
+
+// timeoutHTTP limita cada POST quando o contexto não tem prazo
+const timeoutHTTP = 15 * time.Second
+
+// Webhook envia o Alerta em JSON por HTTP POST
+//
+// Com segredo, cada entrega leva X-Validator-Signature-256, conferida pelo
+// receptor como no webhook de validações (HMAC-SHA256 do corpo).
+type Webhook struct {
+	url     string
+	segredo string
+	client  *http.Client
+}
+
+// NewWebhook cria o destino que faz POST do alerta na URL (http:// ou https://)
+func NewWebhook(endereco, segredo string) (*Webhook, error) {
+	if err := conferirURL(endereco); err != nil {
+		return nil, err
+	}
+	return &Webhook{url: endereco, segredo: segredo, client: &http.Client{Timeout: timeoutHTTP}}, nil
+}
+
+// Enviar faz o POST do alerta
+func (w *Webhook) Enviar(ctx context.Context, a Alerta) error {
+	corpo, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar alerta: %w", err)
+	}
+
+	cabecalhos := map[string]string{
+		cabecalhoEvento:  eventoAlerta,
+		cabecalhoEntrega: idEntrega(),
+	}
+	if w.segredo != "" {
+		mac := hmac.New(sha256.New, []byte(w.segredo))
+		mac.Write(corpo)
+		cabecalhos[cabecalhoAssinatura] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	return postarJSON(ctx, w.client, w.url, corpo, cabecalhos)
+}
+
+// Slack envia o alerta a um incoming webhook do Slack (ou compatível: Mattermost, Rocket.Chat)
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlack cria o destino do incoming webhook (https://hooks.slack.com/services/...)
+func NewSlack(endereco string) (*Slack, error) {
+	if err := conferirURL(endereco); err != nil {
+		return nil, err
+	}
+	return &Slack{url: endereco, client: &http.Client{Timeout: timeoutHTTP}}, nil
+}
+
+// Enviar publica o alerta como mensagem de texto
+func (s *Slack) Enviar(ctx context.Context, a Alerta) error {
+	corpo, err := json.Marshal(map[string]string{
+		"text": ":rotating_light: *" + a.Assunto() + "*\n```" + a.Texto() + "```",
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao serializar alerta: %w", err)
+	}
+	return postarJSON(ctx, s.client, s.url, corpo, nil)
+}
+
+// postarJSON faz o POST e trata como erro qualquer resposta fora de 2xx
+func postarJSON(ctx context.Context, client *http.Client, endereco string, corpo []byte, cabecalhos map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endereco, bytes.NewReader(corpo))
+	if err != nil {
+		return fmt.Errorf("erro ao montar requisição: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range cabecalhos {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("erro ao enviar alerta: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("erro ao enviar alerta: HTTP %s", resp.Status)
+	}
+	return nil
+}
+
+// conferirURL aceita apenas URLs http:// e https:// com host
+func conferirURL(endereco string) error {
+	u, err := url.Parse(endereco)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("URL de alerta inválida '%s' (use http:// ou https://)", OcultarSenha(endereco))
+	}
+	return nil
+}
+
+// idEntrega gera um identificador aleatório para a entrega
+func idEntrega() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,94 @@
+// Package archive guarda os XMLs aprovados pelo prazo legal de guarda
+//
+// Emitente e destinatário precisam manter os XMLs por 5 anos, contados a
+// partir do primeiro dia do ano seguinte ao da emissão. Os XMLs ficam
+// organizados por emitente e mês de emissão (tirados da chave de acesso):
+//
+//	<CNPJ do emitente>/<AAAA>/<MM>/<chave>.xml
+//
+// Cada XML é identificado pelo SHA-256 do conteúdo: gravar de novo o mesmo
+// XML não faz nada, e um XML diferente com a mesma chave é guardado ao lado
+// (<chave>-<hash>.xml), nunca por cima.
+//
+// Exemplo:
+//
+//	a, err := archive.NewDir("/srv/nfe/xml")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	g, err := a.Guardar(ctx, chave, xmlData)
+//	// g.Local = /srv/nfe/xml/32409620000175/2025/07/3525...4648.xml
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// RetencaoMinima é o prazo legal de guarda dos XMLs, em anos
+const RetencaoMinima = 5
+
+// Guardado descreve o XML arquivado por Guardar
+type Guardado struct {
+	Local  string // Caminho do arquivo ou s3://bucket/objeto
+	SHA256 string // Hash do conteúdo, em hex
+	Novo   bool   // false = o mesmo conteúdo já estava arquivado
+}
+
+// Archive guarda os XMLs e expurga os que passaram do prazo de retenção
+//
+// As implementações devem aceitar chamadas concorrentes.
+type Archive interface {
+	// Guardar arquiva o XML da chave; o mesmo conteúdo não é gravado duas vezes
+	Guardar(ctx context.Context, chave string, xml []byte) (Guardado, error)
+
+	// Expurgar remove os XMLs emitidos antes do ano informado e devolve quantos foram removidos
+	Expurgar(ctx context.Context, antesDoAno int) (int, error)
+}
+
+// Caminho é o caminho relativo do XML no arquivo: <emitente>/<AAAA>/<MM>/<chave>.xml
+func Caminho(chave string) (string, error) {
+	c, err := nfe.DecomporChave(chave)
+	if err != nil {
+		return "", fmt.Errorf("chave '%s' inválida para arquivar: %w", chave, err)
+	}
+	ano, mes, _ := strings.Cut(c.Periodo, "-")
+	return path.Join(c.Emitente, ano, mes, c.Chave+".xml"), nil
+}
+
+// AnoLimite é o primeiro ano de emissão que ainda precisa ser guardado com retencao anos de guarda
+//
+// Um XML emitido em 2020 conta o prazo a partir de 01/01/2021: com 5 anos,
+// pode ser expurgado a partir de 01/01/2026 (AnoLimite = 2021 em 2026).
+func AnoLimite(retencao int, agora time.Time) int {
+	return agora.Year() - retencao
+}
+
+// hashConteudo é o SHA-256 do XML, em hex
+func hashConteudo(xml []byte) string {
+	soma := sha256.Sum256(xml)
+	return hex.EncodeToString(soma[:])
+}
+
+// comHash é o nome alternativo do XML quando a chave já foi arquivada com outro conteúdo
+func comHash(caminho, hash string) string {
+	return strings.TrimSuffix(caminho, ".xml") + "-" + hash[:12] + ".xml"
+}
+
+// anoDoCaminho extrai o AAAA de um caminho relativo <emitente>/<AAAA>/...; ok = false fora do layout
+func anoDoCaminho(rel string) (ano int, ok bool) {
+	partes := strings.Split(rel, "/")
+	if len(partes) < 2 || len(partes[1]) != 4 {
+		return 0, false
+	}
+	ano, err := strconv.Atoi(partes[1])
+	return ano, err == nil
+}
@@ -0,0 +1,138 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Dir arquiva os XMLs em um diretório local (ou montado em rede)
+//
+// Ao lado de cada XML fica um <arquivo>.sha256 no formato do sha256sum, para
+// conferir a integridade com "sha256sum -c".
+type Dir struct {
+	raiz string
+}
+
+// NewDir usa raiz como diretório do arquivo, criando-o se preciso
+func NewDir(raiz string) (*Dir, error) {
+	if err := os.MkdirAll(raiz, 0o755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório de arquivo '%s': %w", raiz, err)
+	}
+	return &Dir{raiz: raiz}, nil
+}
+
+// Guardar grava o XML em <raiz>/<emitente>/<AAAA>/<MM>/<chave>.xml
+func (d *Dir) Guardar(_ context.Context, chave string, xml []byte) (Guardado, error) {
+	rel, err := Caminho(chave)
+	if err != nil {
+		return Guardado{}, err
+	}
+	g := Guardado{SHA256: hashConteudo(xml)}
+
+	destino := filepath.Join(d.raiz, filepath.FromSlash(rel))
+	if existente, err := os.ReadFile(destino); err == nil {
+		if bytes.Equal(existente, xml) {
+			g.Local = destino
+			return g, nil
+		}
+		destino = comHash(destino, g.SHA256)
+		if _, err := os.Stat(destino); err == nil {
+			g.Local = destino
+			return g, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destino), 0o755); err != nil {
+		return Guardado{}, fmt.Errorf("erro ao criar diretório de arquivo: %w", err)
+	}
+	if err := gravarAtomico(destino, xml); err != nil {
+		return Guardado{}, err
+	}
+	soma := g.SHA256 + "  " + filepath.Base(destino) + "\n"
+	if err := gravarAtomico(destino+".sha256", []byte(soma)); err != nil {
+		return Guardado{}, err
+	}
+
+	g.Local, g.Novo = destino, true
+	return g, nil
+}
+
+// Expurgar remove os diretórios <emitente>/<AAAA> dos anos anteriores a antesDoAno
+func (d *Dir) Expurgar(ctx context.Context, antesDoAno int) (int, error) {
+	emitentes, err := os.ReadDir(d.raiz)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao ler diretório de arquivo: %w", err)
+	}
+
+	removidos := 0
+	for _, emitente := range emitentes {
+		if !emitente.IsDir() {
+			continue
+		}
+		anos, err := os.ReadDir(filepath.Join(d.raiz, emitente.Name()))
+		if err != nil {
+			return removidos, fmt.Errorf("erro ao ler diretório de arquivo: %w", err)
+		}
+		for _, ano := range anos {
+			if err := ctx.Err(); err != nil {
+				return removidos, err
+			}
+			n, ok := anoDoCaminho(emitente.Name() + "/" + ano.Name())
+			if !ano.IsDir() || !ok || n >= antesDoAno {
+				continue
+			}
+
+			dir := filepath.Join(d.raiz, emitente.Name(), ano.Name())
+			removidos += contarXMLs(dir)
+			if err := os.RemoveAll(dir); err != nil {
+				return removidos, fmt.Errorf("erro ao expurgar '%s': %w", dir, err)
+			}
+		}
+	}
+	return removidos, nil
+}
+
+// contarXMLs conta os .xml dentro de dir (subdiretórios inclusive)
+func contarXMLs(dir string) int {
+	n := 0
+	filepath.WalkDir(dir, func(p string, e fs.DirEntry, err error) error {
+		if err == nil && !e.IsDir() && strings.HasSuffix(p, ".xml") {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+// gravarAtomico grava em um temporário no mesmo diretório e renomeia: nunca deixa um XML pela metade
+func gravarAtomico(destino string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(destino), ".arquivando-*")
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo temporário: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao gravar '%s': %w", destino, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao gravar '%s': %w", destino, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("erro ao gravar '%s': %w", destino, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("erro ao gravar '%s': %w", destino, err)
+	}
+	if err := os.Rename(tmp.Name(), destino); err != nil {
+		return fmt.Errorf("erro ao gravar '%s': %w", destino, err)
+	}
+	return nil
+}
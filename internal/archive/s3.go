@@ -0,0 +1,140 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// metadadoHash é o metadado (x-amz-meta-sha256) com o hash do XML arquivado
+const metadadoHash = "sha256"
+
+// S3 arquiva os XMLs em um bucket S3 (ou compatível: MinIO, LocalStack)
+//
+// O hash vai no metadado x-amz-meta-sha256 e como checksum SHA-256 do
+// upload, conferido pelo S3 na gravação. Para impedir a remoção antes do
+// prazo, combine com Object Lock (modo compliance) no bucket.
+type S3 struct {
+	cliente *s3.Client
+	bucket  string
+	prefixo string
+}
+
+// NewS3 arquiva em s3://bucket/prefixo/<emitente>/<AAAA>/<MM>/<chave>.xml
+func NewS3(cliente *s3.Client, bucket, prefixo string) *S3 {
+	return &S3{cliente: cliente, bucket: bucket, prefixo: strings.Trim(prefixo, "/")}
+}
+
+// Guardar envia o XML ao bucket, a menos que o mesmo conteúdo já esteja lá
+func (a *S3) Guardar(ctx context.Context, chave string, xml []byte) (Guardado, error) {
+	rel, err := Caminho(chave)
+	if err != nil {
+		return Guardado{}, err
+	}
+	g := Guardado{SHA256: hashConteudo(xml)}
+
+	objeto := path.Join(a.prefixo, rel)
+	for tentativa := 0; tentativa < 2; tentativa++ {
+		hash, existe, err := a.hashArquivado(ctx, objeto)
+		if err != nil {
+			return Guardado{}, err
+		}
+		if !existe {
+			break
+		}
+		if hash == g.SHA256 {
+			g.Local = a.url(objeto)
+			return g, nil
+		}
+		// Outro conteúdo com a mesma chave: guarda ao lado
+		objeto = comHash(objeto, g.SHA256)
+	}
+
+	soma, _ := hex.DecodeString(g.SHA256)
+	_, err = a.cliente.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:         aws.String(a.bucket),
+		Key:            aws.String(objeto),
+		Body:           bytes.NewReader(xml),
+		ContentType:    aws.String("application/xml"),
+		ChecksumSHA256: aws.String(base64.StdEncoding.EncodeToString(soma)),
+		Metadata:       map[string]string{metadadoHash: g.SHA256},
+	})
+	if err != nil {
+		return Guardado{}, fmt.Errorf("erro ao arquivar em %s: %w", a.url(objeto), err)
+	}
+
+	g.Local, g.Novo = a.url(objeto), true
+	return g, nil
+}
+
+// Expurgar remove os objetos <prefixo>/<emitente>/<AAAA>/... dos anos anteriores a antesDoAno
+func (a *S3) Expurgar(ctx context.Context, antesDoAno int) (int, error) {
+	prefixo := a.prefixo
+	if prefixo != "" {
+		prefixo += "/"
+	}
+
+	removidos := 0
+	paginas := s3.NewListObjectsV2Paginator(a.cliente, &s3.ListObjectsV2Input{
+		Bucket: aws.String(a.bucket),
+		Prefix: aws.String(prefixo),
+	})
+	for paginas.HasMorePages() {
+		pagina, err := paginas.NextPage(ctx)
+		if err != nil {
+			return removidos, fmt.Errorf("erro ao listar s3://%s/%s: %w", a.bucket, prefixo, err)
+		}
+		for _, obj := range pagina.Contents {
+			objeto := aws.ToString(obj.Key)
+			ano, ok := anoDoCaminho(strings.TrimPrefix(objeto, prefixo))
+			if !ok || ano >= antesDoAno {
+				continue
+			}
+			if _, err := a.cliente.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(a.bucket), Key: obj.Key}); err != nil {
+				return removidos, fmt.Errorf("erro ao expurgar %s: %w", a.url(objeto), err)
+			}
+			if strings.HasSuffix(objeto, ".xml") {
+				removidos++
+			}
+		}
+	}
+	return removidos, nil
+}
+
+// hashArquivado lê o hash do objeto já arquivado; existe = false se o objeto não existe
+func (a *S3) hashArquivado(ctx context.Context, objeto string) (hash string, existe bool, err error) {
+	out, err := a.cliente.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(a.bucket), Key: aws.String(objeto)})
+	if err != nil {
+		if naoEncontrado(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("erro ao consultar %s: %w", a.url(objeto), err)
+	}
+	return out.Metadata[metadadoHash], true, nil
+}
+
+// url identifica o objeto nas mensagens e em Guardado.Local
+func (a *S3) url(objeto string) string {
+	return "s3://" + a.bucket + "/" + objeto
+}
+
+// naoEncontrado indica o 404 do HeadObject (que vem sem corpo, às vezes sem o tipo NotFound)
+func naoEncontrado(err error) bool {
+	var nf *s3types.NotFound
+	if errors.As(err, &nf) {
+		return true
+	}
+	var resp *awshttp.ResponseError
+	return errors.As(err, &resp) && resp.HTTPStatusCode() == http.StatusNotFound
+}
@@ -1,9 +1,15 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -18,16 +24,94 @@ type Config struct {
 	UF           string
 	ConsultaURL  string
 	DistURL      string
+	StatusURL    string // Opcional: substitui a URL do NfeStatusServico4 da UF configurada
+	EventoURL    string // Opcional: substitui a URL do NFeRecepcaoEvento4 do Ambiente Nacional
+
+	// SchemasRequisicao é a pasta com os XSD das requisições (consSitNFe,
+	// envEvento, distDFeInt...), conferidas antes do envio; vazia = sem conferência
+	SchemasRequisicao string
+
+	// Webservices substitui URLs da tabela embutida (sefaz.URLWebservice),
+	// com chaves "<UF ou autorizador>/<serviço>/<ambiente>" (ex: "SP/consulta/producao")
+	Webservices map[string]string
+
+	// opcoes de LoadCom, para as mensagens de Validar citarem as variáveis lidas
+	opcoes Opcoes
+}
+
+// PrefixoPadrao é o prefixo das variáveis NFE_* (ver Opcoes.Prefixo)
+const PrefixoPadrao = "NFE_"
+
+// Opcoes ajustam a leitura do ambiente em LoadCom
+type Opcoes struct {
+	// Prefixo substitui o NFE_ das variáveis (ex: "ACME_" lê ACME_ENV e
+	// ACME_CERT_DIR); vazio = PrefixoPadrao. As SEFAZ_*_URL não têm prefixo.
+	Prefixo string
+	// SemDotenv não lê nenhum arquivo .env: só o ambiente do processo (modo 12-factor)
+	SemDotenv bool
+	// Ambiente é o NFE_ENV quando a variável não está definida (ex: o ambiente
+	// do validator.yaml); vazio = "production". Não é gravado no ambiente do processo.
+	Ambiente string
+}
+
+// Variavel devolve o nome da variável NFE_* com o prefixo das opções (ex: NFE_CERT_DIR → ACME_CERT_DIR)
+func (o Opcoes) Variavel(nome string) string {
+	if resto, ok := strings.CutPrefix(nome, PrefixoPadrao); ok && o.Prefixo != "" {
+		return o.Prefixo + resto
+	}
+	return nome
 }
 
 // Load carregar a configuração com base na variável NFE_ENV ou padroniza para 'production'.
+//
+// O arquivo .env.<NFE_ENV> é carregado no ambiente do processo; para montar a
+// configuração sem alterar o ambiente, use FromValues ou FromFile. Os valores
+// não são conferidos aqui: use Validar (e ValidarCertificado antes de conectar).
 func Load() *Config {
+	return LoadCom(Opcoes{})
+}
+
+// LoadCom é o Load com as Opcoes (prefixo das variáveis e modo sem .env)
+//
+// Com Opcoes.SemDotenv, nenhum arquivo é lido nem gravado no ambiente: a
+// configuração vem apenas das variáveis do processo, como em containers e CI.
+func LoadCom(o Opcoes) *Config {
 	// Pega NFE_ENV do ambiente global para decidir qual arquivo carregar
-	env := os.Getenv("NFE_ENV")
+	env := os.Getenv(o.Variavel("NFE_ENV"))
+	if env == "" {
+		env = o.Ambiente
+	}
 	if env == "" {
 		env = "production"
 	}
-	
+
+	if !o.SemDotenv {
+		carregarDotenv(env)
+	}
+
+	valores := ValoresAmbiente(o)
+	valores["NFE_ENV"] = env
+	return FromValuesCom(valores, o)
+}
+
+// ValoresAmbiente lê as variáveis de Load do ambiente do processo, sem nenhum .env
+//
+// As chaves são os nomes padrão (NFE_CERT_DIR...), mesmo com Opcoes.Prefixo;
+// sem NFE_ENV definido, vale Opcoes.Ambiente. O resultado vai para FromValues
+// ou FromValuesCom, completado antes com outros valores (ex: um arquivo YAML).
+func ValoresAmbiente(o Opcoes) map[string]string {
+	valores := make(map[string]string, len(variaveis))
+	for _, v := range variaveis {
+		valores[v] = os.Getenv(o.Variavel(v))
+	}
+	if valores["NFE_ENV"] == "" {
+		valores["NFE_ENV"] = o.Ambiente
+	}
+	return valores
+}
+
+// carregarDotenv carrega o .env.<env> no ambiente do processo, se existir
+func carregarDotenv(env string) {
 	// Cria o nome do arquivo (ex: .env.production)
 	envFile := fmt.Sprintf(".env.%s", env)
 	
@@ -42,15 +126,183 @@ func Load() *Config {
             log.Printf("Aviso: Arquivo de ambiente '%s' não encontrado. Usando variáveis de ambiente do sistema.", envFile)
         }
 	}
+}
+
+// variaveis são as variáveis de ambiente lidas por Load (e as chaves aceitas por FromValues)
+var variaveis = []string{
+	"NFE_ENV",
+	"NFE_CERT_DIR",
+	"NFE_CERT_KEY_FILE",
+	"NFE_CERT_PUB_FILE",
+	"NFE_CNPJ",
+	"NFE_UF_IBGE",
+	"SEFAZ_CONSULTA_URL",
+	"SEFAZ_DIST_URL",
+	"SEFAZ_STATUS_URL",
+	"SEFAZ_EVENTO_URL",
+	"NFE_SCHEMAS_REQUISICAO",
+}
+
+// FromValues monta a configuração a partir dos valores informados, com os nomes das variáveis de ambiente como chaves
+//
+// Não lê arquivos nem o ambiente do processo: chaves ausentes ficam vazias,
+// salvo NFE_ENV, que assume "production". Assim, clientes com configurações
+// diferentes convivem no mesmo processo.
+//
+//	cfg := config.FromValues(map[string]string{"NFE_ENV": "homologacao", "NFE_CERT_DIR": "cert", "NFE_UF_IBGE": "35"})
+func FromValues(valores map[string]string) *Config {
+	env := valores["NFE_ENV"]
+	if env == "" {
+		env = "production"
+	}
 
 	return &Config{
-		Env:          env,
-		CertDir:      os.Getenv("NFE_CERT_DIR"),
-		CertKeyFile:  os.Getenv("NFE_CERT_KEY_FILE"),
-		CertPubFile:  os.Getenv("NFE_CERT_PUB_FILE"),
-		CNPJ:         os.Getenv("NFE_CNPJ"),
-		UF:           os.Getenv("NFE_UF_IBGE"),
-		ConsultaURL:  os.Getenv("SEFAZ_CONSULTA_URL"),
-		DistURL:      os.Getenv("SEFAZ_DIST_URL"),
-	}
-}
\ No newline at end of file
+		Env:         env,
+		CertDir:     valores["NFE_CERT_DIR"],
+		CertKeyFile: valores["NFE_CERT_KEY_FILE"],
+		CertPubFile: valores["NFE_CERT_PUB_FILE"],
+		CNPJ:        valores["NFE_CNPJ"],
+		UF:          valores["NFE_UF_IBGE"],
+		ConsultaURL: valores["SEFAZ_CONSULTA_URL"],
+		DistURL:     valores["SEFAZ_DIST_URL"],
+		StatusURL:   valores["SEFAZ_STATUS_URL"],
+		EventoURL:   valores["SEFAZ_EVENTO_URL"],
+
+		SchemasRequisicao: valores["NFE_SCHEMAS_REQUISICAO"],
+	}
+}
+
+// FromValuesCom é o FromValues com as Opcoes: as mensagens de Validar citam as variáveis com o prefixo
+func FromValuesCom(valores map[string]string, o Opcoes) *Config {
+	cfg := FromValues(valores)
+	cfg.opcoes = o
+	return cfg
+}
+
+// FromFile monta a configuração a partir de um arquivo no formato .env (ex: ".env.homologacao")
+//
+// Diferente de Load, as variáveis do arquivo não vão para o ambiente do
+// processo e as do ambiente não completam o arquivo (ver FromValues).
+func FromFile(path string) (*Config, error) {
+	valores, err := godotenv.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de configuração %s: %w", path, err)
+	}
+	return FromValues(valores), nil
+}
+
+// ambientes são os valores aceitos em NFE_ENV, indicando se são de produção
+var ambientes = map[string]bool{
+	"production": true, "producao": true, "produção": true,
+	"homologation": false, "homologacao": false, "homologação": false,
+}
+
+// Producao indica se o ambiente configurado é o de produção (tpAmb 1)
+//
+// Os demais valores de NFE_ENV (ex: "homologacao") são tratados como homologação (tpAmb 2).
+func (c *Config) Producao() bool {
+	return ambientes[strings.ToLower(c.Env)]
+}
+
+// ufsIBGE são os códigos IBGE das UFs aceitos em NFE_UF_IBGE
+var ufsIBGE = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true,
+	"21": true, "22": true, "23": true, "24": true, "25": true, "26": true, "27": true, "28": true, "29": true,
+	"31": true, "32": true, "33": true, "35": true,
+	"41": true, "42": true, "43": true,
+	"50": true, "51": true, "52": true, "53": true,
+}
+
+// cnpjValido aceita o CNPJ numérico e o alfanumérico (12 posições alfanuméricas + 2 dígitos verificadores)
+var cnpjValido = regexp.MustCompile(`^[0-9A-Z]{12}[0-9]{2}$`)
+
+// Validar confere o formato dos valores preenchidos: ambiente, UF, CNPJ e URLs dos web services
+//
+// Campos vazios não são erro: cada serviço exige o que usa (o certificado ao
+// conectar, ver ValidarCertificado; o CNPJ na distribuição e nos eventos).
+// Todos os problemas são devolvidos juntos.
+func (c *Config) Validar() error {
+	var erros []error
+
+	if _, ok := ambientes[strings.ToLower(c.Env)]; c.Env != "" && !ok {
+		erros = append(erros, fmt.Errorf("%s '%s' desconhecido: use production (ou producao) ou homologation (ou homologacao)", c.opcoes.Variavel("NFE_ENV"), c.Env))
+	}
+
+	if c.UF != "" && !ufsIBGE[c.UF] {
+		erros = append(erros, fmt.Errorf("%s '%s' inválido: use o código IBGE da UF (ex: 35 para SP)", c.opcoes.Variavel("NFE_UF_IBGE"), c.UF))
+	}
+	if c.CNPJ != "" && !cnpjValido.MatchString(c.CNPJ) {
+		erros = append(erros, fmt.Errorf("%s '%s' inválido: use os 14 caracteres, sem pontuação", c.opcoes.Variavel("NFE_CNPJ"), c.CNPJ))
+	}
+
+	if c.SchemasRequisicao != "" {
+		if info, err := os.Stat(c.SchemasRequisicao); err != nil || !info.IsDir() {
+			erros = append(erros, fmt.Errorf("%s '%s' não é uma pasta acessível", c.opcoes.Variavel("NFE_SCHEMAS_REQUISICAO"), c.SchemasRequisicao))
+		}
+	}
+
+	urls := []struct{ variavel, valor string }{
+		{"SEFAZ_CONSULTA_URL", c.ConsultaURL},
+		{"SEFAZ_DIST_URL", c.DistURL},
+		{"SEFAZ_STATUS_URL", c.StatusURL},
+		{"SEFAZ_EVENTO_URL", c.EventoURL},
+	}
+	for _, u := range urls {
+		if u.valor == "" {
+			continue
+		}
+		if !urlValida(u.valor) {
+			erros = append(erros, fmt.Errorf("%s '%s' inválida: use uma URL http(s) completa", u.variavel, u.valor))
+		}
+	}
+	for _, chave := range slices.Sorted(maps.Keys(c.Webservices)) {
+		if !urlValida(c.Webservices[chave]) {
+			erros = append(erros, fmt.Errorf("webservice %s '%s' inválido: use uma URL http(s) completa", chave, c.Webservices[chave]))
+		}
+	}
+
+	return errors.Join(erros...)
+}
+
+// urlValida indica se o valor é uma URL http(s) com host
+func urlValida(valor string) bool {
+	parsed, err := url.Parse(valor)
+	return err == nil && (parsed.Scheme == "https" || parsed.Scheme == "http") && parsed.Host != ""
+}
+
+// ValidarCertificado confere o par PEM do certificado do cliente: os três campos preenchidos e os arquivos existentes
+//
+// Não abre os arquivos: senha, formato e validade são conferidos ao conectar.
+// Todos os problemas são devolvidos juntos.
+func (c *Config) ValidarCertificado() error {
+	var erros []error
+
+	pastaOK := false
+	if c.CertDir == "" {
+		erros = append(erros, fmt.Errorf("%s não configurado: informe a pasta do certificado e das CAs do ICP-Brasil", c.opcoes.Variavel("NFE_CERT_DIR")))
+	} else if info, err := os.Stat(c.CertDir); err != nil || !info.IsDir() {
+		erros = append(erros, fmt.Errorf("%s '%s' não é uma pasta acessível", c.opcoes.Variavel("NFE_CERT_DIR"), c.CertDir))
+	} else {
+		pastaOK = true
+	}
+
+	arquivos := []struct{ variavel, valor, descricao string }{
+		{c.opcoes.Variavel("NFE_CERT_KEY_FILE"), c.CertKeyFile, "da chave privada (ex: key.pem)"},
+		{c.opcoes.Variavel("NFE_CERT_PUB_FILE"), c.CertPubFile, "do certificado público (ex: cert.pem)"},
+	}
+	for _, a := range arquivos {
+		if a.valor == "" {
+			erros = append(erros, fmt.Errorf("%s não configurado: informe o arquivo %s dentro de %s", a.variavel, a.descricao, c.opcoes.Variavel("NFE_CERT_DIR")))
+			continue
+		}
+		if !pastaOK {
+			continue // Os arquivos ficam na pasta: o erro dela basta
+		}
+		caminho := filepath.Join(c.CertDir, a.valor)
+		if info, err := os.Stat(caminho); err != nil || info.IsDir() {
+			erros = append(erros, fmt.Errorf("%s: arquivo '%s' não encontrado", a.variavel, caminho))
+		}
+	}
+
+	return errors.Join(erros...)
+}
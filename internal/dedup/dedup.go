@@ -0,0 +1,43 @@
+// Package dedup detecta chaves de acesso já validadas
+//
+// Em pipelines de ingestão a mesma nota costuma chegar mais de uma vez
+// (reentrega da fila, reenvio do ERP, o mesmo XML em dois pacotes). O
+// Cache guarda a primeira validação de cada chave por um prazo (TTL);
+// dentro dele, uma nova validação da chave é uma duplicata.
+//
+// Implementações: Memoria (um processo), Redis (várias instâncias) e
+// Store (o histórico do internal/store, sem outro serviço).
+//
+// Exemplo:
+//
+//	c := dedup.NewMemoria(24 * time.Hour)
+//	if anterior, ok, _ := c.Buscar(ctx, chave); ok {
+//	    log.Printf("%s já validada em %s", chave, anterior.ValidadoEm)
+//	}
+package dedup
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// Anterior é o que fica guardado da primeira validação da chave
+type Anterior struct {
+	ValidadoEm  time.Time              `json:"validado_em"`
+	Origem      string                 `json:"origem,omitempty"`
+	CodigoSaida int                    `json:"codigo_saida"`
+	Sefaz       validation.SefazStatus `json:"sefaz"`
+}
+
+// Cache guarda a primeira validação de cada chave até o TTL vencer
+//
+// As implementações devem aceitar chamadas concorrentes.
+type Cache interface {
+	// Buscar devolve a validação anterior da chave; ok = false se não houve (ou o TTL venceu)
+	Buscar(ctx context.Context, chave string) (a Anterior, ok bool, err error)
+
+	// Guardar registra a validação da chave, contando o TTL a partir de agora
+	Guardar(ctx context.Context, chave string, a Anterior) error
+}
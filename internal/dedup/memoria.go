@@ -0,0 +1,61 @@
+package dedup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limpezaMemoria é a quantidade de gravações entre as varreduras das entradas vencidas
+const limpezaMemoria = 1024
+
+// Memoria é o Cache dentro do processo: perdido ao reiniciar e não compartilhado entre instâncias
+type Memoria struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entradas  map[string]entradaMemoria
+	gravacoes int
+}
+
+// entradaMemoria é uma chave guardada na Memoria
+type entradaMemoria struct {
+	anterior Anterior
+	expira   time.Time
+}
+
+// NewMemoria cria o cache em memória com o TTL informado
+func NewMemoria(ttl time.Duration) *Memoria {
+	return &Memoria{ttl: ttl, entradas: make(map[string]entradaMemoria)}
+}
+
+// Buscar devolve a validação anterior da chave, se ainda dentro do TTL
+func (m *Memoria) Buscar(_ context.Context, chave string) (Anterior, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entradas[chave]
+	if !ok || time.Now().After(e.expira) {
+		return Anterior{}, false, nil
+	}
+	return e.anterior, true, nil
+}
+
+// Guardar registra a validação da chave
+func (m *Memoria) Guardar(_ context.Context, chave string, a Anterior) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	agora := time.Now()
+	m.entradas[chave] = entradaMemoria{anterior: a, expira: agora.Add(m.ttl)}
+
+	m.gravacoes++
+	if m.gravacoes%limpezaMemoria == 0 {
+		for c, e := range m.entradas {
+			if agora.After(e.expira) {
+				delete(m.entradas, c)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package dedup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// prefixoRedis é o prefixo das chaves gravadas no Redis
+const prefixoRedis = "nfe-validator:validada:"
+
+// Redis é o Cache compartilhado entre instâncias (ex: vários consumidores da mesma fila)
+//
+// Cada chave vira uma string JSON com expiração (SET ... EX), então o
+// próprio Redis descarta as entradas vencidas.
+type Redis struct {
+	cliente *redis.Client
+	ttl     time.Duration
+}
+
+// NewRedis conecta ao Redis da URL (redis://[:senha@]host:6379/0 ou rediss:// com TLS)
+func NewRedis(url string, ttl time.Duration) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("URL do Redis inválida: %w", err)
+	}
+
+	cliente := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := cliente.Ping(ctx).Err(); err != nil {
+		cliente.Close()
+		return nil, fmt.Errorf("erro ao conectar ao Redis %s: %w", opts.Addr, err)
+	}
+	return &Redis{cliente: cliente, ttl: ttl}, nil
+}
+
+// Buscar devolve a validação anterior da chave, se ainda dentro do TTL
+func (r *Redis) Buscar(ctx context.Context, chave string) (Anterior, bool, error) {
+	data, err := r.cliente.Get(ctx, prefixoRedis+chave).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Anterior{}, false, nil
+	}
+	if err != nil {
+		return Anterior{}, false, fmt.Errorf("erro ao consultar o Redis: %w", err)
+	}
+
+	var a Anterior
+	if err := json.Unmarshal(data, &a); err != nil {
+		return Anterior{}, false, fmt.Errorf("entrada inválida no Redis para %s: %w", chave, err)
+	}
+	return a, true, nil
+}
+
+// Guardar registra a validação da chave
+func (r *Redis) Guardar(ctx context.Context, chave string, a Anterior) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar validação de %s: %w", chave, err)
+	}
+	if err := r.cliente.Set(ctx, prefixoRedis+chave, data, r.ttl).Err(); err != nil {
+		return fmt.Errorf("erro ao gravar no Redis: %w", err)
+	}
+	return nil
+}
+
+// Close fecha a conexão com o Redis
+func (r *Redis) Close() error {
+	return r.cliente.Close()
+}
@@ -0,0 +1,50 @@
+package dedup
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/internal/store"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// Store usa o histórico de validações como Cache
+//
+// Não grava nada: cada validação já entra no histórico, e Buscar procura a
+// mais recente da chave dentro do TTL.
+type Store struct {
+	historico store.ResultStore
+	ttl       time.Duration
+}
+
+// NewStore usa o histórico como cache de duplicatas
+func NewStore(historico store.ResultStore, ttl time.Duration) *Store {
+	return &Store{historico: historico, ttl: ttl}
+}
+
+// Buscar devolve a validação mais recente da chave gravada no histórico dentro do TTL
+func (s *Store) Buscar(ctx context.Context, chave string) (Anterior, bool, error) {
+	regs, err := s.historico.Buscar(ctx, store.Filtro{Chave: chave, Desde: time.Now().Add(-s.ttl), Limite: 1})
+	if err != nil || len(regs) == 0 {
+		return Anterior{}, false, err
+	}
+
+	r := regs[0]
+	return Anterior{
+		ValidadoEm:  r.ValidadoEm,
+		Origem:      r.Origem,
+		CodigoSaida: r.CodigoSaida,
+		Sefaz: validation.SefazStatus{
+			Autorizado: r.Autorizado,
+			Codigo:     r.CStat,
+			Mensagem:   r.Mensagem,
+			Consulta:   r.Consulta,
+			Protocolo:  r.Protocolo,
+		},
+	}, true, nil
+}
+
+// Guardar não faz nada: a validação é gravada no histórico pelo próprio fluxo
+func (s *Store) Guardar(context.Context, string, Anterior) error {
+	return nil
+}
@@ -0,0 +1,66 @@
+package sefaz
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Algoritmos da assinatura XML-DSig exigida pela SEFAZ (RSA-SHA1 com C14N inclusiva)
+const (
+	algC14N      = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	algRSASHA1   = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	algEnveloped = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algSHA1      = "http://www.w3.org/2000/09/xmldsig#sha1"
+	nsXMLDSig    = "http://www.w3.org/2000/09/xmldsig#"
+)
+
+// assinarXML gera o elemento <Signature> do elemento identificado por id
+//
+// elementoCanonico deve ser o elemento assinado já na forma canônica (C14N),
+// incluindo o xmlns herdado do elemento pai. Como os XMLs de envio são
+// montados pelo próprio cliente (sem espaços, atributos em ordem e texto
+// escapado com escaparTexto), basta montá-los nesse formato.
+func (c *Client) assinarXML(elementoCanonico, id string) (string, error) {
+	signer, ok := c.cert.PrivateKey.(crypto.Signer)
+	if !ok || len(c.cert.Certificate) == 0 {
+		return "", categorizar(ErrCertificado, fmt.Errorf("certificado do cliente não permite assinatura"))
+	}
+
+	digest := sha1.Sum([]byte(elementoCanonico))
+
+	signedInfo := `<SignedInfo xmlns="` + nsXMLDSig + `">` +
+		`<CanonicalizationMethod Algorithm="` + algC14N + `"></CanonicalizationMethod>` +
+		`<SignatureMethod Algorithm="` + algRSASHA1 + `"></SignatureMethod>` +
+		`<Reference URI="#` + id + `"><Transforms>` +
+		`<Transform Algorithm="` + algEnveloped + `"></Transform>` +
+		`<Transform Algorithm="` + algC14N + `"></Transform>` +
+		`</Transforms><DigestMethod Algorithm="` + algSHA1 + `"></DigestMethod>` +
+		`<DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</DigestValue>` +
+		`</Reference></SignedInfo>`
+
+	hash := sha1.Sum([]byte(signedInfo))
+	assinatura, err := signer.Sign(rand.Reader, hash[:], crypto.SHA1)
+	if err != nil {
+		return "", fmt.Errorf("erro ao assinar XML: %w", err)
+	}
+
+	// Dentro de <Signature> o SignedInfo herda o namespace: a declaração repetida sai
+	signedInfo = strings.Replace(signedInfo, ` xmlns="`+nsXMLDSig+`"`, "", 1)
+
+	return `<Signature xmlns="` + nsXMLDSig + `">` + signedInfo +
+		`<SignatureValue>` + base64.StdEncoding.EncodeToString(assinatura) + `</SignatureValue>` +
+		`<KeyInfo><X509Data><X509Certificate>` + base64.StdEncoding.EncodeToString(c.cert.Certificate[0]) +
+		`</X509Certificate></X509Data></KeyInfo></Signature>`, nil
+}
+
+// escaparTexto escapa o conteúdo de texto como na forma canônica (C14N)
+//
+// Diferente de xml.EscapeText, aspas não são escapadas: o texto precisa
+// sair idêntico ao que o validador da SEFAZ canonicaliza.
+func escaparTexto(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;").Replace(s)
+}
@@ -0,0 +1,133 @@
+package sefaz
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// TpEventoAtorInteressado é o evento "Ator interessado na NF-e" (NT 2020.007)
+const TpEventoAtorInteressado = "110150"
+
+// Autores do evento Ator interessado (tpAutor)
+const (
+	AutorEmitente      = "1"
+	AutorDestinatario  = "2"
+	AutorTransportador = "3"
+)
+
+// Autorizações do transportador informado (tpAutorizacao)
+const (
+	autorizacaoSomenteTransportador = "0"
+	autorizacaoComSubcontratados    = "1"
+)
+
+// condicaoUsoAtorInteressado é o xCondUso exigido pelo schema quando tpAutorizacao é 1
+const condicaoUsoAtorInteressado = "O emitente ou destinatário da NF-e, declara que permite o transportador declarado no campo CNPJ/CPF deste evento a autorizar os transportadores subcontratados ou redespachados a terem acesso ao download da NF-e"
+
+// maxSeqAtorInteressado é o limite de atores interessados (nSeqEvento) por NF-e
+const maxSeqAtorInteressado = 20
+
+// Documento do ator: CNPJ (numérico ou alfanumérico) ou CPF
+var (
+	cnpjAtor = regexp.MustCompile(`^[0-9A-Z]{12}[0-9]{2}$`)
+	cpfAtor  = regexp.MustCompile(`^[0-9]{11}$`)
+)
+
+// AtorInteressado descreve o transportador que passa a ter acesso ao XML da NF-e
+type AtorInteressado struct {
+	// COrgaoAutor é o código IBGE da UF do autor do evento (quem envia)
+	COrgaoAutor string
+
+	// TpAutor é AutorEmitente ou AutorDestinatario (o transportador só informa redespachos)
+	TpAutor string
+
+	// Documento é o CNPJ (14 posições) ou o CPF (11 dígitos) do transportador autorizado, com ou sem pontuação
+	Documento string
+
+	// Subcontratados permite ao transportador autorizar os subcontratados e redespachos
+	Subcontratados bool
+
+	// NSeqEvento diferencia os atores da mesma nota (1 a 20; padrão 1)
+	NSeqEvento int
+
+	// VerAplic é a versão do aplicativo do autor (padrão "go-nfe-validator")
+	VerAplic string
+}
+
+// NovoEventoAtorInteressado monta o evento 110150, que autoriza um transportador a baixar o XML da NF-e
+//
+// Registrado no Ambiente Nacional, libera o XML da nota para o CNPJ/CPF do
+// transportador na distribuição DF-e (NFeDistribuicaoDFe). Cada transportador
+// da mesma nota usa um NSeqEvento diferente.
+//
+// Exemplo:
+//
+//	ev, err := sefaz.NovoEventoAtorInteressado(chave, sefaz.AtorInteressado{
+//	    COrgaoAutor: "35",
+//	    TpAutor:     sefaz.AutorEmitente,
+//	    Documento:   "12345678000199",
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	ret, err := client.EnviarEvento(ev)
+func NovoEventoAtorInteressado(chave string, ator AtorInteressado) (Evento, error) {
+	if len(ator.COrgaoAutor) != 2 {
+		return Evento{}, fmt.Errorf("cOrgaoAutor '%s' inválido: informe o código IBGE da UF do autor", ator.COrgaoAutor)
+	}
+	if ator.TpAutor != AutorEmitente && ator.TpAutor != AutorDestinatario && ator.TpAutor != AutorTransportador {
+		return Evento{}, fmt.Errorf("tpAutor '%s' inválido (use %s = emitente, %s = destinatário ou %s = transportador)", ator.TpAutor, AutorEmitente, AutorDestinatario, AutorTransportador)
+	}
+	if ator.NSeqEvento == 0 {
+		ator.NSeqEvento = 1
+	}
+	if ator.NSeqEvento < 1 || ator.NSeqEvento > maxSeqAtorInteressado {
+		return Evento{}, fmt.Errorf("nSeqEvento %d inválido: use de 1 a %d", ator.NSeqEvento, maxSeqAtorInteressado)
+	}
+	if ator.VerAplic == "" {
+		ator.VerAplic = verAplicPadrao
+	}
+
+	// Aceita o documento formatado (12.345.678/0001-99)
+	ator.Documento = strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) || unicode.IsLetter(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, ator.Documento)
+
+	var autXML string
+	switch {
+	case cnpjAtor.MatchString(ator.Documento):
+		autXML = "<CNPJ>" + ator.Documento + "</CNPJ>"
+	case cpfAtor.MatchString(ator.Documento):
+		autXML = "<CPF>" + ator.Documento + "</CPF>"
+	default:
+		return Evento{}, fmt.Errorf("documento do transportador '%s' inválido: informe o CNPJ (14 posições) ou o CPF (11 dígitos)", ator.Documento)
+	}
+
+	tpAutorizacao := autorizacaoSomenteTransportador
+	if ator.Subcontratados {
+		tpAutorizacao = autorizacaoComSubcontratados
+	}
+
+	det := "<descEvento>Ator interessado na NF-e</descEvento>" +
+		"<cOrgaoAutor>" + ator.COrgaoAutor + "</cOrgaoAutor>" +
+		"<tpAutor>" + ator.TpAutor + "</tpAutor>" +
+		"<verAplic>" + escaparTexto(ator.VerAplic) + "</verAplic>" +
+		"<autXML>" + autXML + "</autXML>" +
+		"<tpAutorizacao>" + tpAutorizacao + "</tpAutorizacao>"
+	if ator.Subcontratados {
+		det += "<xCondUso>" + condicaoUsoAtorInteressado + "</xCondUso>"
+	}
+
+	return Evento{
+		COrgao:     COrgaoAmbienteNacional,
+		Chave:      chave,
+		TpEvento:   TpEventoAtorInteressado,
+		NSeqEvento: ator.NSeqEvento,
+		DetEvento:  det,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package sefaz
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// tlsBase é o tls.Config sem o certificado do cliente, montado para uma pasta de CAs
+type tlsBase struct {
+	assinatura string // Arquivos de CA da pasta quando o pool foi montado (ver assinaturaCAs)
+	config     *tls.Config
+}
+
+// cacheTLS guarda o tlsBase de cada pasta de CAs, compartilhado por todos os clientes do processo
+//
+// Um Gerenciador com muitos tenants cria um Client por empresa, quase sempre
+// com a mesma pasta do ICP-Brasil: o pool (sistema + CAs da pasta) é montado
+// uma vez e só é refeito quando os .crt/.pem da pasta mudam. O lock cobre a
+// montagem, então clientes criados em paralelo não leem a pasta duas vezes.
+var cacheTLS = struct {
+	sync.Mutex
+	porPasta map[string]*tlsBase
+}{porPasta: make(map[string]*tlsBase)}
+
+// arquivoCA indica se o arquivo da pasta de certificados é uma CA a carregar (.crt e .pem, exceto key.pem)
+func arquivoCA(entry os.DirEntry) bool {
+	name := entry.Name()
+	if entry.IsDir() || strings.Contains(name, "key.pem") {
+		return false
+	}
+	return strings.HasSuffix(name, ".crt") || strings.HasSuffix(name, ".pem")
+}
+
+// assinaturaCAs resume os arquivos de CA de dir (nome, tamanho e data): muda quando uma CA é incluída, trocada ou removida
+func assinaturaCAs(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("falha ao ler o diretório %s: %w", dir, err)
+	}
+
+	var b strings.Builder
+	for _, entry := range entries {
+		if !arquivoCA(entry) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removido durante a leitura: loadCertsFromDir também o ignora
+		}
+		fmt.Fprintf(&b, "%s|%d|%d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+// configTLSBase devolve o tls.Config base (RootCAs, renegociação e versão) para as CAs de dir, do cache quando a pasta não mudou
+//
+// dir vazio usa só as CAs do sistema. O resultado é compartilhado: o cliente
+// usa um Clone com o seu certificado, nunca o altera.
+func configTLSBase(dir string) (*tls.Config, error) {
+	chave := dir
+	if dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			chave = abs
+		}
+	}
+
+	cacheTLS.Lock()
+	defer cacheTLS.Unlock()
+
+	assinatura := ""
+	if dir != "" {
+		var err error
+		if assinatura, err = assinaturaCAs(dir); err != nil {
+			return nil, err
+		}
+	}
+	if base := cacheTLS.porPasta[chave]; base != nil && base.assinatura == assinatura {
+		return base.config, nil
+	}
+
+	// Pool de Confiança (RootCAs)
+	caCertPool, err := x509.SystemCertPool()
+	if err != nil || caCertPool == nil {
+		log.Println("⚠️ Aviso: SystemCertPool falhou ou retornou nil. Usando pool vazio.")
+		caCertPool = x509.NewCertPool()
+	}
+
+	// CAs do ICP-Brasil (Resolve o erro de confiança no servidor)
+	if dir != "" {
+		if err := loadCertsFromDir(caCertPool, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	// ⚡ CORREÇÃO CRÍTICA: Habilitar renegociação TLS (exigido pela SEFAZ SP e Nacional)
+	config := &tls.Config{
+		RootCAs:       caCertPool,
+		Renegotiation: tls.RenegotiateFreelyAsClient,
+		MinVersion:    tls.VersionTLS12,
+		MaxVersion:    tls.VersionTLS12,
+	}
+	cacheTLS.porPasta[chave] = &tlsBase{assinatura: assinatura, config: config}
+	return config, nil
+}
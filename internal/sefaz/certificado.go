@@ -0,0 +1,35 @@
+package sefaz
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// CarregarPFX lê o certificado A1 (.pfx ou .p12) com a chave privada e a cadeia de CAs
+//
+// A cadeia vai junto no tls.Certificate e é enviada no handshake mTLS, como
+// fazem os emissores de NF-e com o arquivo exportado pela certificadora.
+func CarregarPFX(caminho, senha string) (tls.Certificate, error) {
+	dados, err := os.ReadFile(caminho)
+	if err != nil {
+		return tls.Certificate{}, categorizar(ErrCertificado, fmt.Errorf("falha ao ler o certificado PFX: %w", err))
+	}
+
+	chave, folha, cadeia, err := pkcs12.DecodeChain(dados, senha)
+	if err != nil {
+		return tls.Certificate{}, categorizar(ErrCertificado, fmt.Errorf("falha ao abrir o certificado PFX %s (senha incorreta?): %w", caminho, err))
+	}
+
+	cert := tls.Certificate{
+		Certificate: [][]byte{folha.Raw},
+		PrivateKey:  chave,
+		Leaf:        folha,
+	}
+	for _, ca := range cadeia {
+		cert.Certificate = append(cert.Certificate, ca.Raw)
+	}
+	return cert, nil
+}
@@ -1,17 +1,21 @@
 package sefaz
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
-	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/fabyo/go-nfe-validator/internal/config"
 	"github.com/fabyo/go-nfe-validator/internal/validation"
@@ -25,8 +29,34 @@ var xMotivoRegex = regexp.MustCompile(`<xMotivo>(.*?)</xMotivo>`)
 type Client struct {
 	http *http.Client
 	cfg  *config.Config
+	cert tls.Certificate // Certificado do cliente, usado também para assinar eventos
+
+	observador  Observador   // Recebe cada chamada aos web services (ver Observar)
+	logger      *slog.Logger // Recebe a resposta bruta das consultas (ver Opcoes.LogResposta)
+	logResposta int          // Máximo de bytes da resposta registrados (0 = não registra)
+
+	schemas map[string]ValidadorXML // XSD das requisições, pela raiz ou pelo tpEvento (ver conferirPayload)
 }
 
+// Opcoes ajustam o cliente criado por NewClientCom (valor zero = comportamento de NewClient)
+type Opcoes struct {
+	// Certificado do cliente já carregado (ex: CarregarPFX); substitui o par PEM de cfg.CertDir
+	Certificado *tls.Certificate
+	// Timeout de cada requisição aos web services (padrão 15s)
+	Timeout time.Duration
+	// Logger recebe a resposta bruta das consultas em nível Debug (com LogResposta)
+	Logger *slog.Logger
+	// LogResposta é o máximo de bytes da resposta bruta registrados no Logger (0 = não registra)
+	//
+	// A resposta traz dados pessoais (CPF/CNPJ, nomes): registre apenas para diagnóstico.
+	LogResposta int
+	// Transporte envolve o transporte HTTP com mTLS (ex: gravação das chamadas); recebe o transporte padrão
+	Transporte func(http.RoundTripper) http.RoundTripper
+}
+
+// timeoutPadrao é o Timeout das requisições quando Opcoes.Timeout não é informado
+const timeoutPadrao = 15 * time.Second
+
 // --- Funções Auxiliares (CA Loading) ---
 
 // loadCertsFromDir: Carrega todos os certificados .crt e .pem de um diretório e os adiciona ao pool.
@@ -37,24 +67,20 @@ func loadCertsFromDir(pool *x509.CertPool, dir string) error {
 	}
 
 	for _, entry := range entries {
+		// Carregar apenas .crt e .pem (exceto key.pem)
+		if !arquivoCA(entry) {
+			continue
+		}
+
 		name := entry.Name()
-		
-		// Pular arquivos que não são certificados CA
-		if entry.IsDir() || strings.Contains(name, "key.pem") {
+		path := filepath.Join(dir, name)
+		certBytes, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("⚠️ Aviso: Falha ao ler arquivo %s: %v", path, err)
 			continue
 		}
-		
-		// Carregar apenas .crt e .pem (exceto key.pem)
-		if strings.HasSuffix(name, ".crt") || strings.HasSuffix(name, ".pem") {
-			path := filepath.Join(dir, name)
-			certBytes, err := os.ReadFile(path)
-			if err != nil {
-				log.Printf("⚠️ Aviso: Falha ao ler arquivo %s: %v", path, err)
-				continue
-			}
-			if ok := pool.AppendCertsFromPEM(certBytes); !ok {
-				log.Printf("⚠️ Aviso: Falha ao adicionar CA do arquivo %s (formato inválido).", name)
-			}
+		if ok := pool.AppendCertsFromPEM(certBytes); !ok {
+			log.Printf("⚠️ Aviso: Falha ao adicionar CA do arquivo %s (formato inválido).", name)
 		}
 	}
 	return nil
@@ -62,41 +88,54 @@ func loadCertsFromDir(pool *x509.CertPool, dir string) error {
 
 // --- CONSTRUTOR ---
 // NewClient: Configura o cliente HTTP com o certificado mTLS necessário
-func NewClient(cfg *config.Config) (*Client, error) {
-	// Caminhos completos dos arquivos do certificado de cliente
-	keyPath := filepath.Join(cfg.CertDir, cfg.CertKeyFile)
-	certPath := filepath.Join(cfg.CertDir, cfg.CertPubFile)
+//
+// O cliente guarda uma cópia de cfg: alterações posteriores na configuração
+// do chamador não o afetam.
+func NewClient(cfg config.Config) (*Client, error) {
+	return NewClientCom(cfg, Opcoes{})
+}
 
-	// 1. Carregar Chaves e Certificado do Cliente
-	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
-	if err != nil {
-		return nil, fmt.Errorf("falha ao carregar chaves PEM (%s/%s): %w", cfg.CertDir, cfg.CertPubFile, err)
+// NewClientCom é o NewClient com as Opcoes (certificado PFX, timeout, logger)
+//
+// Com Opcoes.Certificado, o par PEM de cfg.CertDir não é lido e a pasta,
+// se informada, serve apenas para as CAs do ICP-Brasil. A configuração é
+// conferida antes (ver validarConfig): todos os problemas voltam juntos,
+// com ErrConfig na cadeia.
+func NewClientCom(cfg config.Config, o Opcoes) (*Client, error) {
+	// 0. Conferir a configuração antes de abrir arquivos e conexões
+	if err := validarConfig(cfg, o); err != nil {
+		return nil, err
 	}
 
-	// 2. Configurar Pool de Confiança (RootCAs)
-	caCertPool, err := x509.SystemCertPool()
-	if err != nil || caCertPool == nil {
-		log.Println("⚠️ Aviso: SystemCertPool falhou ou retornou nil. Usando pool vazio.")
-		caCertPool = x509.NewCertPool()
-	}
+	// 1. Carregar Chaves e Certificado do Cliente
+	var cert tls.Certificate
+	if o.Certificado != nil {
+		cert = *o.Certificado
+	} else {
+		// Caminhos completos dos arquivos do certificado de cliente
+		keyPath := filepath.Join(cfg.CertDir, cfg.CertKeyFile)
+		certPath := filepath.Join(cfg.CertDir, cfg.CertPubFile)
 
-	// 3. Carregar CAs do ICP-Brasil (Resolve o erro de confiança no servidor)
-	if err := loadCertsFromDir(caCertPool, cfg.CertDir); err != nil {
-		return nil, fmt.Errorf("erro ao carregar CAs da pasta %s: %w", cfg.CertDir, err)
+		var err error
+		cert, err = tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, categorizar(ErrCertificado, fmt.Errorf("falha ao carregar chaves PEM (%s/%s): %w", cfg.CertDir, cfg.CertPubFile, err))
+		}
 	}
 
-	// 4. Configurações mTLS e Protocolo
-	// ⚡ CORREÇÃO CRÍTICA: Habilitar renegociação TLS (exigido pela SEFAZ SP e Nacional)
-	tlsConfig := &tls.Config{
-		Certificates:  []tls.Certificate{cert},
-		RootCAs:       caCertPool,
-		Renegotiation: tls.RenegotiateFreelyAsClient, // ← MUDANÇA AQUI!
-		MinVersion:    tls.VersionTLS12,
-		MaxVersion:    tls.VersionTLS12,
+	// 2. Pool de Confiança (sistema + CAs do ICP-Brasil), compartilhado entre os clientes da mesma pasta
+	//    (Com Opcoes.Certificado e sem pasta, só as CAs do sistema)
+	base, err := configTLSBase(cfg.CertDir)
+	if err != nil {
+		return nil, categorizar(ErrCertificado, fmt.Errorf("erro ao carregar CAs da pasta %s: %w", cfg.CertDir, err))
 	}
 
+	// 3. Configurações mTLS: a base com o certificado deste cliente
+	tlsConfig := base.Clone()
+	tlsConfig.Certificates = []tls.Certificate{cert}
+
 	httpClient := &http.Client{
-		Timeout: 15 * time.Second,
+		Timeout: timeoutPadrao,
 		Transport: &http.Transport{
 			TLSClientConfig: tlsConfig,
 			Proxy:           http.ProxyFromEnvironment,
@@ -105,39 +144,97 @@ func NewClient(cfg *config.Config) (*Client, error) {
 		},
 	}
 
-	return &Client{http: httpClient, cfg: cfg}, nil
+	if o.Timeout > 0 {
+		httpClient.Timeout = o.Timeout
+	}
+	if o.Transporte != nil {
+		httpClient.Transport = o.Transporte(httpClient.Transport)
+	}
+
+	c := &Client{http: httpClient, cfg: &cfg, cert: cert, logger: o.Logger, logResposta: o.LogResposta}
+
+	// 4. XSD das requisições (opcional): conferidas antes de cada envio
+	if cfg.SchemasRequisicao != "" {
+		if c.schemas, err = carregarSchemasRequisicao(cfg.SchemasRequisicao); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// validarConfig junta os problemas de cfg.Validar e, sem Opcoes.Certificado, de cfg.ValidarCertificado
+//
+// Com problemas no certificado, o erro também leva ErrCertificado.
+func validarConfig(cfg config.Config, o Opcoes) error {
+	var errCert error
+	if o.Certificado == nil {
+		errCert = cfg.ValidarCertificado()
+	}
+	err := errors.Join(cfg.Validar(), errCert)
+	if err == nil {
+		return nil
+	}
+
+	err = categorizar(ErrConfig, fmt.Errorf("configuração inválida: %w", err))
+	if errCert != nil {
+		err = categorizar(ErrCertificado, err)
+	}
+	return err
 }
 
 // --- MÉTODO DE NEGÓCIO ---
 // ConsultaSituacaoNFe: Consulta a situação da NF-e no SEFAZ (Webservice NfeConsultaNFe4)
+//
+// A URL vem de SEFAZ_CONSULTA_URL ou, se vazia, do autorizador da UF da chave
+// no modelo da chave: NF-e (55) ou NFC-e (65), que em várias UFs têm web
+// services distintos (ver URLServico e ServicoConsultaModelo).
 func (c *Client) ConsultaSituacaoNFe(chaveAcesso string) (validation.SefazStatus, error) {
-	
-	soapAction := "http://www.portalfiscal.inf.br/nfe/wsdl/NfeConsultaNFe4/nfeConsultaNF"
-	sefazUrl := c.cfg.ConsultaURL 
+	return c.ConsultaSituacaoNFeContext(context.Background(), chaveAcesso)
+}
 
-	// O XML de Consulta de Situação (sem quebras de linha - SEFAZ SP é sensível!)
-	soapEnv := fmt.Sprintf(`<soap12:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:soap12="http://www.w3.org/2003/05/soap-envelope"><soap12:Body><nfeDadosMsg xmlns="http://www.portalfiscal.inf.br/nfe/wsdl/NFeConsultaProtocolo4"><consSitNFe xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00"><tpAmb>1</tpAmb><xServ>CONSULTAR</xServ><chNFe>%s</chNFe></consSitNFe></nfeDadosMsg></soap12:Body></soap12:Envelope>`, chaveAcesso)
+// ConsultaSituacaoNFeContext: ConsultaSituacaoNFe com o contexto da requisição (cancelamento e tracing)
+func (c *Client) ConsultaSituacaoNFeContext(ctx context.Context, chaveAcesso string) (validation.SefazStatus, error) {
+	return c.ConsultaSituacaoNFeAmbiente(ctx, chaveAcesso, c.cfg.Producao())
+}
+
+// ConsultaSituacaoNFeAmbiente: ConsultaSituacaoNFeContext no ambiente informado, e não no da configuração
+//
+// O tpAmb e a URL seguem producao; SEFAZ_CONSULTA_URL só vale no ambiente da
+// configuração (no outro, valem Config.Webservices e a tabela embutida). O
+// mesmo certificado atende os dois ambientes.
+func (c *Client) ConsultaSituacaoNFeAmbiente(ctx context.Context, chaveAcesso string, producao bool) (validation.SefazStatus, error) {
+	soapAction := "http://www.portalfiscal.inf.br/nfe/wsdl/NfeConsultaNFe4/nfeConsultaNF"
 
-	req, err := http.NewRequest("POST", sefazUrl, strings.NewReader(soapEnv))
+	// SEFAZ_CONSULTA_URL ou o autorizador da UF da chave (cUF nos 2 primeiros dígitos) no modelo da chave
+	cUF := chaveAcesso[:min(2, len(chaveAcesso))]
+	servico := ServicoConsultaModelo(chaveAcesso)
+	sefazUrl, err := c.urlServicoAmbiente(servico, cUF, producao)
 	if err != nil {
-		return validation.SefazStatus{Codigo: "999"}, fmt.Errorf("erro ao criar requisição: %w", err)
+		return validation.SefazStatus{Codigo: "999"}, err
 	}
 
-	req.Header.Set("Content-Type", `application/soap+xml; charset=utf-8; action="`+soapAction+`"`)
+	tpAmb := "2"
+	if producao {
+		tpAmb = "1"
+	}
 
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return validation.SefazStatus{Codigo: "999"}, fmt.Errorf("erro na conexão mTLS/webservice: %w", err)
+	// O XML de Consulta de Situação (sem quebras de linha - SEFAZ SP é sensível!)
+	consSitNFe := fmt.Sprintf(`<consSitNFe xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00"><tpAmb>%s</tpAmb><xServ>CONSULTAR</xServ><chNFe>%s</chNFe></consSitNFe>`, tpAmb, chaveAcesso)
+	if err := c.conferirPayload(servico, "consSitNFe", "", consSitNFe); err != nil {
+		return validation.SefazStatus{Codigo: "999"}, err
 	}
-	defer resp.Body.Close()
+	soapEnv := `<soap12:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:soap12="http://www.w3.org/2003/05/soap-envelope"><soap12:Body><nfeDadosMsg xmlns="http://www.portalfiscal.inf.br/nfe/wsdl/NFeConsultaProtocolo4">` +
+		consSitNFe + `</nfeDadosMsg></soap12:Body></soap12:Envelope>`
 
-	body, err := io.ReadAll(resp.Body)
+	body, _, err := c.postar(ctx, servico, sefazUrl, soapAction, soapEnv)
 	if err != nil {
-		return validation.SefazStatus{Codigo: "999"}, fmt.Errorf("erro ao ler resposta: %w", err)
+		return validation.SefazStatus{Codigo: "999"}, err
 	}
 
-	// DEBUG: Ver a resposta completa da SEFAZ
-	log.Printf("📄 Resposta SEFAZ:\n%s", string(body))
+	// DEBUG: Ver a resposta da SEFAZ (opcional, cortada em LogResposta bytes)
+	if c.logger != nil && c.logResposta > 0 {
+		c.logger.DebugContext(ctx, "📄 Resposta SEFAZ", "servico", servico, "chave", chaveAcesso, "corpo", truncar(string(body), c.logResposta))
+	}
 
 	// Analisa a resposta XML...
 	bodyStr := string(body)
@@ -162,19 +259,98 @@ func (c *Client) ConsultaSituacaoNFe(chaveAcesso string) (validation.SefazStatus
 	}
 
 	status := validation.SefazStatus{
-		Codigo:   cStat,
-		Mensagem: xMotivo,
+		Codigo:    cStat,
+		Mensagem:  xMotivo,
+		Consulta:  validation.ConsultaRealizada,
+		Protocolo: bodyStr,
 	}
 
-	// Status 100 (Autorizada) ou 110 (Em processamento, mas autorizado)
-	if cStat == "100" || cStat == "110" {
-		status.Autorizado = true
-	} else if cStat == "101" {
-		// 101: Cancelamento de NF-e Homologado
-		status.Autorizado = false
-	} else {
-		status.Autorizado = false
+	// Status 100 (Autorizado o uso) ou 150 (Autorizado o uso, fora de prazo);
+	// 110 é Uso Denegado e 101 o cancelamento homologado: nenhum dos dois autoriza
+	status.Autorizado = cStat == "100" || cStat == "150"
+
+	return status, nil
+}
+
+// truncar corta s em até limite bytes (sem partir um caractere UTF-8) e indica o tamanho original
+func truncar(s string, limite int) string {
+	if len(s) <= limite {
+		return s
+	}
+	corte := limite
+	for corte > 0 && !utf8.RuneStart(s[corte]) {
+		corte--
+	}
+	return fmt.Sprintf("%s… (%d de %d bytes)", s[:corte], corte, len(s))
+}
+
+// StatusServico é o retorno do web service NfeStatusServico4 (retConsStatServ)
+type StatusServico struct {
+	Codigo         string  `json:"codigo"`   // cStat: 107 = em operação, 108/109 = paralisado
+	Mensagem       string  `json:"mensagem"` // xMotivo
+	TempoMedio     float64 `json:"tempo_medio_segundos"`
+	Observacao     string  `json:"observacao,omitempty"` // xObs
+	DataRecebido   string  `json:"dh_recebimento,omitempty"`
+	EmOperacao     bool    `json:"em_operacao"`
+	LatenciaMillis int64   `json:"latencia_ms"` // Tempo total da requisição, medido pelo cliente
+}
+
+// cStatServicoEmOperacao é o cStat do NfeStatusServico4 para "Serviço em Operação"
+const cStatServicoEmOperacao = "107"
+
+// ConsultaStatusServico: Consulta a disponibilidade do autorizador da UF (Webservice NfeStatusServico4)
+//
+// cUF é o código IBGE da UF consultada e url o endpoint do autorizador que a
+// atende (ver URLServico). O tpAmb segue o ambiente da configuração.
+func (c *Client) ConsultaStatusServico(cUF, url string) (StatusServico, error) {
+	soapAction := "http://www.portalfiscal.inf.br/nfe/wsdl/NFeStatusServico4/nfeStatusServicoNF"
+
+	tpAmb := "2"
+	if c.cfg.Producao() {
+		tpAmb = "1"
+	}
+
+	consStatServ := fmt.Sprintf(`<consStatServ xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00"><tpAmb>%s</tpAmb><cUF>%s</cUF><xServ>STATUS</xServ></consStatServ>`, tpAmb, cUF)
+	if err := c.conferirPayload(ServicoStatus, "consStatServ", "", consStatServ); err != nil {
+		return StatusServico{}, err
 	}
+	soapEnv := `<soap12:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:soap12="http://www.w3.org/2003/05/soap-envelope"><soap12:Body><nfeDadosMsg xmlns="http://www.portalfiscal.inf.br/nfe/wsdl/NFeStatusServico4">` +
+		consStatServ + `</nfeDadosMsg></soap12:Body></soap12:Envelope>`
+
+	inicio := time.Now()
+	body, httpStatus, err := c.postar(context.Background(), ServicoStatus, url, soapAction, soapEnv)
+	latencia := time.Since(inicio).Milliseconds()
+	if err != nil {
+		return StatusServico{}, err
+	}
+
+	bodyStr := string(body)
+	status := StatusServico{
+		Codigo:         valorTag(bodyStr, "cStat"),
+		Mensagem:       valorTag(bodyStr, "xMotivo"),
+		Observacao:     valorTag(bodyStr, "xObs"),
+		DataRecebido:   valorTag(bodyStr, "dhRecbto"),
+		LatenciaMillis: latencia,
+	}
+	if status.Codigo == "" {
+		return status, categorizar(ErrResposta, fmt.Errorf("resposta da SEFAZ não parseada (HTTP %d)", httpStatus))
+	}
+	status.TempoMedio, _ = strconv.ParseFloat(valorTag(bodyStr, "tMed"), 64)
+	status.EmOperacao = status.Codigo == cStatServicoEmOperacao
 
 	return status, nil
-}
\ No newline at end of file
+}
+
+// valorTag extrai o conteúdo da primeira ocorrência de <tag>...</tag> (vazio se ausente)
+func valorTag(xml, tag string) string {
+	inicio := strings.Index(xml, "<"+tag+">")
+	if inicio < 0 {
+		return ""
+	}
+	inicio += len(tag) + 2
+	fim := strings.Index(xml[inicio:], "</"+tag+">")
+	if fim < 0 {
+		return ""
+	}
+	return strings.TrimSpace(xml[inicio : inicio+fim])
+}
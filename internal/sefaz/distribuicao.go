@@ -0,0 +1,139 @@
+package sefaz
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Códigos de retorno do NFeDistribuicaoDFe
+const (
+	CStatNenhumDocumento     = "137" // Nenhum documento localizado (aguardar 1 hora antes de consultar de novo)
+	CStatDocumentoLocalizado = "138" // Documento(s) localizado(s)
+	CStatConsumoIndevido     = "656" // Consumo indevido (consultas repetidas sem respeitar o ultNSU)
+)
+
+// DocumentoDFe é um documento devolvido pela distribuição (docZip já descompactado)
+type DocumentoDFe struct {
+	NSU    string // Número sequencial único do documento
+	Schema string // Schema do documento (ex: "procNFe_v4.00.xsd", "resNFe_v1.01.xsd")
+	XML    []byte
+}
+
+// Tipo retorna o nome do schema sem versão (ex: "procNFe", "resNFe", "procEventoNFe")
+func (d DocumentoDFe) Tipo() string {
+	tipo, _, _ := strings.Cut(d.Schema, "_")
+	return strings.TrimSuffix(tipo, ".xsd")
+}
+
+// RetornoDistribuicao é o retorno de uma consulta ao NFeDistribuicaoDFe (retDistDFeInt)
+type RetornoDistribuicao struct {
+	Codigo     string // cStat (137, 138, 656...)
+	Mensagem   string // xMotivo
+	UltNSU     string // Último NSU deste lote: usar na próxima consulta
+	MaxNSU     string // Maior NSU disponível para o interessado
+	Documentos []DocumentoDFe
+}
+
+// retDistDFeInt é o XML de retorno do NFeDistribuicaoDFe
+type retDistDFeInt struct {
+	CStat   string `xml:"cStat"`
+	XMotivo string `xml:"xMotivo"`
+	UltNSU  string `xml:"ultNSU"`
+	MaxNSU  string `xml:"maxNSU"`
+	DocZip  []struct {
+		NSU    string `xml:"NSU,attr"`
+		Schema string `xml:"schema,attr"`
+		Valor  string `xml:",chardata"`
+	} `xml:"loteDistDFeInt>docZip"`
+}
+
+// DistribuicaoDFe: Busca os documentos destinados ao CNPJ configurado a partir do ultNSU (Webservice NFeDistribuicaoDFe)
+//
+// Cada chamada devolve no máximo 50 documentos; repita com o UltNSU retornado
+// até alcançar o MaxNSU. A URL vem de SEFAZ_DIST_URL ou, se vazia, do
+// Ambiente Nacional (ver URLServico).
+func (c *Client) DistribuicaoDFe(ultNSU string) (RetornoDistribuicao, error) {
+	soapAction := "http://www.portalfiscal.inf.br/nfe/wsdl/NFeDistribuicaoDFe/nfeDistDFeInteresse"
+
+	sefazUrl, err := c.URLServico(ServicoDistribuicao, "")
+	if err != nil {
+		return RetornoDistribuicao{}, err
+	}
+
+	tpAmb := "2"
+	if c.cfg.Producao() {
+		tpAmb = "1"
+	}
+
+	distDFeInt := fmt.Sprintf(`<distDFeInt xmlns="http://www.portalfiscal.inf.br/nfe" versao="1.01"><tpAmb>%s</tpAmb><cUFAutor>%s</cUFAutor><CNPJ>%s</CNPJ><distNSU><ultNSU>%s</ultNSU></distNSU></distDFeInt>`,
+		tpAmb, c.cfg.UF, c.cfg.CNPJ, ultNSU)
+	if err := c.conferirPayload(ServicoDistribuicao, "distDFeInt", "", distDFeInt); err != nil {
+		return RetornoDistribuicao{}, err
+	}
+	soapEnv := `<soap12:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:soap12="http://www.w3.org/2003/05/soap-envelope"><soap12:Body><nfeDistDFeInteresse xmlns="http://www.portalfiscal.inf.br/nfe/wsdl/NFeDistribuicaoDFe"><nfeDadosMsg>` +
+		distDFeInt + `</nfeDadosMsg></nfeDistDFeInteresse></soap12:Body></soap12:Envelope>`
+
+	body, httpStatus, err := c.postar(context.Background(), ServicoDistribuicao, sefazUrl, soapAction, soapEnv)
+	if err != nil {
+		return RetornoDistribuicao{}, err
+	}
+
+	ret, err := parseRetDistDFeInt(body)
+	if err != nil {
+		return RetornoDistribuicao{}, categorizar(ErrResposta, fmt.Errorf("resposta da SEFAZ não parseada (HTTP %d): %w", httpStatus, err))
+	}
+
+	retorno := RetornoDistribuicao{
+		Codigo:   ret.CStat,
+		Mensagem: ret.XMotivo,
+		UltNSU:   ret.UltNSU,
+		MaxNSU:   ret.MaxNSU,
+	}
+	for _, doc := range ret.DocZip {
+		conteudo, err := descompactarDocZip(doc.Valor)
+		if err != nil {
+			return retorno, fmt.Errorf("erro ao descompactar documento NSU %s: %w", doc.NSU, err)
+		}
+		retorno.Documentos = append(retorno.Documentos, DocumentoDFe{NSU: doc.NSU, Schema: doc.Schema, XML: conteudo})
+	}
+
+	return retorno, nil
+}
+
+// parseRetDistDFeInt localiza o retDistDFeInt dentro do envelope SOAP
+func parseRetDistDFeInt(body []byte) (*retDistDFeInt, error) {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("retDistDFeInt não encontrado: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "retDistDFeInt" {
+			var ret retDistDFeInt
+			if err := dec.DecodeElement(&ret, &se); err != nil {
+				return nil, err
+			}
+			return &ret, nil
+		}
+	}
+}
+
+// descompactarDocZip decodifica o conteúdo do docZip (base64 de um XML em gzip)
+func descompactarDocZip(valor string) ([]byte, error) {
+	compactado, err := base64.StdEncoding.DecodeString(strings.TrimSpace(valor))
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compactado))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
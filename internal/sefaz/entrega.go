@@ -0,0 +1,185 @@
+package sefaz
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Eventos do Comprovante de Entrega da NF-e (NT 2020.001)
+const (
+	TpEventoComprovanteEntrega             = "110130"
+	TpEventoCancelamentoComprovanteEntrega = "110131"
+)
+
+// Limites do recebedor (nDoc e xNome) no schema do Comprovante de Entrega
+const (
+	minDocRecebedor  = 2
+	maxDocRecebedor  = 20
+	minNomeRecebedor = 2
+	maxNomeRecebedor = 60
+)
+
+// nProtEvento tem 15 dígitos
+var protocoloEvento = regexp.MustCompile(`^[0-9]{15}$`)
+
+// Coordenadas é a posição do GPS no momento da entrega
+type Coordenadas struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// ComprovanteEntrega são os dados da entrega informados pelo emitente no evento 110130
+type ComprovanteEntrega struct {
+	// COrgaoAutor é o código IBGE da UF do emitente
+	COrgaoAutor string
+
+	// DataEntrega é o momento da entrega (dhEntrega)
+	DataEntrega time.Time
+
+	// Documento e Nome identificam quem recebeu a mercadoria (nDoc e xNome)
+	Documento string
+	Nome      string
+
+	// GPS é a posição da entrega (opcional)
+	GPS *Coordenadas
+
+	// Imagem é o comprovante capturado (assinatura, foto...), que entra no
+	// hashComprovante; Hash substitui a imagem quando ela já foi resumida por
+	// HashComprovante (ex: no aplicativo do entregador)
+	Imagem []byte
+	Hash   string
+
+	// DataHash é o momento em que o hash foi gerado (padrão: agora)
+	DataHash time.Time
+
+	// NSeqEvento é o sequencial do evento (padrão 1)
+	NSeqEvento int
+
+	// VerAplic é a versão do aplicativo do autor (padrão "go-nfe-validator")
+	VerAplic string
+}
+
+// HashComprovante calcula o hashComprovante: SHA-1 em Base64 da chave de acesso seguida do Base64 da imagem
+func HashComprovante(chave string, imagem []byte) string {
+	h := sha1.Sum([]byte(chave + base64.StdEncoding.EncodeToString(imagem)))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// NovoEventoComprovanteEntrega monta o evento 110130 (Comprovante de Entrega da NF-e)
+//
+// Enviado pelo emitente ao Ambiente Nacional, registra quem recebeu a
+// mercadoria, quando e onde, com o hash da imagem do comprovante. Um evento
+// registrado só é substituído depois do cancelamento (ver
+// NovoEventoCancelamentoComprovanteEntrega).
+//
+// Exemplo:
+//
+//	ev, err := sefaz.NovoEventoComprovanteEntrega(chave, sefaz.ComprovanteEntrega{
+//	    COrgaoAutor: "35",
+//	    DataEntrega: entregue,
+//	    Documento:   "12345678900",
+//	    Nome:        "Maria da Silva",
+//	    Imagem:      assinatura,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	ret, err := client.EnviarEvento(ev)
+func NovoEventoComprovanteEntrega(chave string, c ComprovanteEntrega) (Evento, error) {
+	if len(c.COrgaoAutor) != 2 {
+		return Evento{}, fmt.Errorf("cOrgaoAutor '%s' inválido: informe o código IBGE da UF do emitente", c.COrgaoAutor)
+	}
+	if c.DataEntrega.IsZero() {
+		return Evento{}, fmt.Errorf("data da entrega obrigatória")
+	}
+	if c.DataEntrega.After(time.Now()) {
+		return Evento{}, fmt.Errorf("data da entrega %s no futuro", c.DataEntrega.Format(time.RFC3339))
+	}
+
+	documento := strings.Join(strings.Fields(c.Documento), " ")
+	if n := utf8.RuneCountInString(documento); n < minDocRecebedor || n > maxDocRecebedor {
+		return Evento{}, fmt.Errorf("documento do recebedor obrigatório, com %d a %d caracteres (informado: %d)", minDocRecebedor, maxDocRecebedor, n)
+	}
+	nome := strings.Join(strings.Fields(c.Nome), " ")
+	if n := utf8.RuneCountInString(nome); n < minNomeRecebedor || n > maxNomeRecebedor {
+		return Evento{}, fmt.Errorf("nome do recebedor obrigatório, com %d a %d caracteres (informado: %d)", minNomeRecebedor, maxNomeRecebedor, n)
+	}
+
+	if g := c.GPS; g != nil && (g.Latitude < -90 || g.Latitude > 90 || g.Longitude < -180 || g.Longitude > 180) {
+		return Evento{}, fmt.Errorf("coordenadas da entrega inválidas: %g, %g", g.Latitude, g.Longitude)
+	}
+
+	hash := c.Hash
+	switch {
+	case len(c.Imagem) > 0 && hash != "":
+		return Evento{}, fmt.Errorf("informe a imagem do comprovante ou o hash, não os dois")
+	case len(c.Imagem) > 0:
+		hash = HashComprovante(chave, c.Imagem)
+	case hash == "":
+		return Evento{}, fmt.Errorf("imagem do comprovante (ou o hash) obrigatória")
+	}
+	if b, err := base64.StdEncoding.DecodeString(hash); err != nil || len(b) != sha1.Size {
+		return Evento{}, fmt.Errorf("hash do comprovante '%s' inválido: deve ser o SHA-1 em Base64 (28 caracteres)", hash)
+	}
+
+	dataHash := c.DataHash
+	if dataHash.IsZero() {
+		dataHash = time.Now()
+	}
+	if c.VerAplic == "" {
+		c.VerAplic = verAplicPadrao
+	}
+
+	det := "<descEvento>Comprovante de Entrega da NF-e</descEvento>" +
+		"<cOrgaoAutor>" + c.COrgaoAutor + "</cOrgaoAutor>" +
+		"<tpAutor>" + AutorEmitente + "</tpAutor>" +
+		"<verAplic>" + escaparTexto(c.VerAplic) + "</verAplic>" +
+		"<dhEntrega>" + formatarDataEvento(c.DataEntrega) + "</dhEntrega>" +
+		"<nDoc>" + escaparTexto(documento) + "</nDoc>" +
+		"<xNome>" + escaparTexto(nome) + "</xNome>"
+	if c.GPS != nil {
+		det += fmt.Sprintf("<latGPS>%.6f</latGPS><longGPS>%.6f</longGPS>", c.GPS.Latitude, c.GPS.Longitude)
+	}
+	det += "<hashComprovante>" + hash + "</hashComprovante>" +
+		"<dhHashComprovante>" + formatarDataEvento(dataHash) + "</dhHashComprovante>"
+
+	return Evento{
+		COrgao:     COrgaoAmbienteNacional,
+		Chave:      chave,
+		TpEvento:   TpEventoComprovanteEntrega,
+		NSeqEvento: c.NSeqEvento,
+		DetEvento:  det,
+	}, nil
+}
+
+// NovoEventoCancelamentoComprovanteEntrega monta o evento 110131, que cancela o Comprovante de Entrega registrado
+//
+// protocolo é o nProt devolvido no registro do evento 110130 e cOrgaoAutor, o
+// código IBGE da UF do emitente.
+func NovoEventoCancelamentoComprovanteEntrega(chave, cOrgaoAutor, protocolo string) (Evento, error) {
+	if len(cOrgaoAutor) != 2 {
+		return Evento{}, fmt.Errorf("cOrgaoAutor '%s' inválido: informe o código IBGE da UF do emitente", cOrgaoAutor)
+	}
+	if !protocoloEvento.MatchString(protocolo) {
+		return Evento{}, fmt.Errorf("protocolo do comprovante de entrega '%s' inválido: deve ter 15 dígitos", protocolo)
+	}
+
+	det := "<descEvento>Cancelamento Comprovante de Entrega da NF-e</descEvento>" +
+		"<cOrgaoAutor>" + cOrgaoAutor + "</cOrgaoAutor>" +
+		"<tpAutor>" + AutorEmitente + "</tpAutor>" +
+		"<verAplic>" + verAplicPadrao + "</verAplic>" +
+		"<nProtEvento>" + protocolo + "</nProtEvento>"
+
+	return Evento{
+		COrgao:     COrgaoAmbienteNacional,
+		Chave:      chave,
+		TpEvento:   TpEventoCancelamentoComprovanteEntrega,
+		NSeqEvento: 1,
+		DetEvento:  det,
+	}, nil
+}
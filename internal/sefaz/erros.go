@@ -0,0 +1,36 @@
+package sefaz
+
+import "errors"
+
+// Categorias das falhas do Client, para classificar com errors.Is sem depender das mensagens
+var (
+	// ErrCertificado: certificado do cliente ou CAs ausentes, ilegíveis ou sem chave para assinar
+	ErrCertificado = errors.New("certificado do cliente")
+
+	// ErrResposta: a SEFAZ (ou um proxy no caminho) respondeu sem o retorno esperado
+	ErrResposta = errors.New("resposta da SEFAZ inválida")
+
+	// ErrUF: a UF não tem autorizador conhecido
+	ErrUF = errors.New("UF sem autorizador")
+
+	// ErrPayload: a requisição montada pelo cliente não passou no XSD e não foi enviada (ver ErroPayload)
+	ErrPayload = errors.New("requisição fora do schema")
+
+	// ErrConfig: a configuração tem valores inválidos ou falta o que o cliente exige (ver NewClientCom)
+	ErrConfig = errors.New("configuração inválida")
+)
+
+// erroCategoria acrescenta uma categoria (Err*) à cadeia de um erro, sem mudar a mensagem
+type erroCategoria struct {
+	error
+	categoria error
+}
+
+func (e erroCategoria) Unwrap() []error {
+	return []error{e.error, e.categoria}
+}
+
+// categorizar marca err com a categoria (ver erroCategoria)
+func categorizar(categoria, err error) error {
+	return erroCategoria{err, categoria}
+}
@@ -0,0 +1,193 @@
+package sefaz
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// COrgaoAmbienteNacional é o cOrgao dos eventos registrados no Ambiente Nacional (ex: manifestação)
+const COrgaoAmbienteNacional = "91"
+
+// verAplicPadrao é o verAplic dos eventos que não informam a versão do aplicativo do autor
+const verAplicPadrao = "go-nfe-validator"
+
+// fusoBrasilia é usado no dhEvento (a SEFAZ rejeita datas sem o fuso)
+var fusoBrasilia = time.FixedZone("BRT", -3*60*60)
+
+// formatarDataEvento formata as datas do evento (dhEvento, dhEntrega...) no horário de Brasília, com o fuso
+func formatarDataEvento(t time.Time) string {
+	return t.In(fusoBrasilia).Format("2006-01-02T15:04:05-07:00")
+}
+
+// Evento é um evento da NF-e a ser assinado e enviado ao NFeRecepcaoEvento4
+type Evento struct {
+	COrgao     string // Órgão de recepção: código IBGE da UF ou 91 (Ambiente Nacional)
+	Chave      string // Chave de acesso da NF-e
+	TpEvento   string // Código do evento (ex: 210210 = Ciência da Operação)
+	NSeqEvento int    // Sequencial do evento para a mesma nota e tipo (começa em 1)
+	VerEvento  string // Versão do evento (padrão "1.00")
+
+	// DetEvento é o conteúdo do <detEvento> já escapado (ex: "<descEvento>...</descEvento>")
+	DetEvento string
+}
+
+// id retorna o Id do infEvento: "ID" + tpEvento + chave + nSeqEvento (2 dígitos)
+func (e Evento) id() string {
+	return fmt.Sprintf("ID%s%s%02d", e.TpEvento, e.Chave, e.NSeqEvento)
+}
+
+// RetornoEvento é o resultado do registro de um evento (retEnvEvento/retEvento)
+type RetornoEvento struct {
+	CodigoLote   string `json:"codigo_lote"` // cStat do lote (128 = lote processado)
+	MensagemLote string `json:"mensagem_lote"`
+	Codigo       string `json:"codigo"` // cStat do evento (135/136 = registrado)
+	Mensagem     string `json:"mensagem"`
+	Protocolo    string `json:"protocolo,omitempty"`
+	DataRegistro string `json:"dh_registro,omitempty"`
+	Registrado   bool   `json:"registrado"`
+}
+
+// retEnvEvento é o XML de retorno do NFeRecepcaoEvento4
+type retEnvEvento struct {
+	CStat     string `xml:"cStat"`
+	XMotivo   string `xml:"xMotivo"`
+	RetEvento []struct {
+		InfEvento struct {
+			CStat       string `xml:"cStat"`
+			XMotivo     string `xml:"xMotivo"`
+			NProt       string `xml:"nProt"`
+			DhRegEvento string `xml:"dhRegEvento"`
+		} `xml:"infEvento"`
+	} `xml:"retEvento"`
+}
+
+// ValidadorXML confere um XML contra um schema (ex: *validation.XSDValidator)
+type ValidadorXML interface {
+	Validate(xml []byte) error
+}
+
+// ValidarEvento registra o schema do tpEvento: EnviarEvento confere o envEvento assinado antes de enviá-lo (nil remove)
+//
+// Os schemas de cada evento vêm nos pacotes de liberação do Portal Nacional
+// da NF-e (o envEvento específico do evento) e têm precedência sobre o
+// envEvento genérico de Config.SchemasRequisicao. Um evento fora do schema
+// não é enviado (ver ErroPayload).
+func (c *Client) ValidarEvento(tpEvento string, v ValidadorXML) {
+	if v == nil {
+		delete(c.schemas, tpEvento)
+		return
+	}
+	if c.schemas == nil {
+		c.schemas = make(map[string]ValidadorXML)
+	}
+	c.schemas[tpEvento] = v
+}
+
+// AssinarEvento monta o <evento> (infEvento + Signature) assinado com o certificado do cliente
+//
+// O CNPJ do autor é o da configuração e o tpAmb, o do ambiente configurado.
+// O XML devolvido é o enviado dentro do envEvento por EnviarEvento; serve
+// também para guardar o evento ou enviá-lo por outro sistema.
+func (c *Client) AssinarEvento(ev Evento) (string, error) {
+	if ev.VerEvento == "" {
+		ev.VerEvento = "1.00"
+	}
+	if ev.NSeqEvento == 0 {
+		ev.NSeqEvento = 1
+	}
+
+	tpAmb := "2"
+	if c.cfg.Producao() {
+		tpAmb = "1"
+	}
+
+	infEvento := fmt.Sprintf(`<infEvento Id="%s"><cOrgao>%s</cOrgao><tpAmb>%s</tpAmb><CNPJ>%s</CNPJ><chNFe>%s</chNFe><dhEvento>%s</dhEvento><tpEvento>%s</tpEvento><nSeqEvento>%d</nSeqEvento><verEvento>%s</verEvento><detEvento versao="%s">%s</detEvento></infEvento>`,
+		ev.id(), ev.COrgao, tpAmb, c.cfg.CNPJ, ev.Chave, formatarDataEvento(time.Now()),
+		ev.TpEvento, ev.NSeqEvento, ev.VerEvento, ev.VerEvento, ev.DetEvento)
+
+	// Forma canônica: o infEvento herda o xmlns do envEvento
+	canonico := strings.Replace(infEvento, "<infEvento ", `<infEvento xmlns="http://www.portalfiscal.inf.br/nfe" `, 1)
+	assinatura, err := c.assinarXML(canonico, ev.id())
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`<evento versao="%s">%s%s</evento>`, ev.VerEvento, infEvento, assinatura), nil
+}
+
+// EnviarEvento: Assina o evento com o certificado do cliente e envia ao NFeRecepcaoEvento4
+//
+// O CNPJ do autor é o da configuração. Os eventos com COrgao 91 (como a
+// manifestação do destinatário) vão a SEFAZ_EVENTO_URL ou, se vazia, ao
+// Ambiente Nacional; os demais, ao autorizador da UF do COrgao (ver
+// URLServico).
+func (c *Client) EnviarEvento(ev Evento) (RetornoEvento, error) {
+	soapAction := "http://www.portalfiscal.inf.br/nfe/wsdl/NFeRecepcaoEvento4/nferecepcaoEvento"
+
+	evento, err := c.AssinarEvento(ev)
+	if err != nil {
+		return RetornoEvento{}, err
+	}
+
+	envEvento := fmt.Sprintf(`<envEvento xmlns="http://www.portalfiscal.inf.br/nfe" versao="1.00"><idLote>%d</idLote>%s</envEvento>`,
+		time.Now().UnixNano()%1e15, evento)
+
+	soapEnv := `<soap12:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:soap12="http://www.w3.org/2003/05/soap-envelope"><soap12:Body><nfeDadosMsg xmlns="http://www.portalfiscal.inf.br/nfe/wsdl/NFeRecepcaoEvento4">` +
+		envEvento + `</nfeDadosMsg></soap12:Body></soap12:Envelope>`
+
+	// cOrgao 91 vai ao Ambiente Nacional; os demais, ao autorizador da UF (ex: pedido de prorrogação)
+	servico, uf := ServicoEvento, ""
+	if ev.COrgao != COrgaoAmbienteNacional {
+		servico, uf = ServicoEventoUF, ev.COrgao
+	}
+	sefazUrl, err := c.URLServico(servico, uf)
+	if err != nil {
+		return RetornoEvento{}, err
+	}
+	if err := c.conferirPayload(servico, "envEvento", ev.TpEvento, envEvento); err != nil {
+		return RetornoEvento{}, err
+	}
+
+	body, httpStatus, err := c.postar(context.Background(), servico, sefazUrl, soapAction, soapEnv)
+	if err != nil {
+		return RetornoEvento{}, err
+	}
+
+	ret, err := parseRetEnvEvento(body)
+	if err != nil {
+		return RetornoEvento{}, categorizar(ErrResposta, fmt.Errorf("resposta da SEFAZ não parseada (HTTP %d): %w", httpStatus, err))
+	}
+
+	retorno := RetornoEvento{CodigoLote: ret.CStat, MensagemLote: ret.XMotivo}
+	if len(ret.RetEvento) > 0 {
+		inf := ret.RetEvento[0].InfEvento
+		retorno.Codigo, retorno.Mensagem = inf.CStat, inf.XMotivo
+		retorno.Protocolo, retorno.DataRegistro = inf.NProt, inf.DhRegEvento
+	}
+	// 135 = registrado e vinculado à NF-e; 136 = registrado, mas sem vínculo
+	retorno.Registrado = retorno.Codigo == "135" || retorno.Codigo == "136"
+
+	return retorno, nil
+}
+
+// parseRetEnvEvento localiza o retEnvEvento dentro do envelope SOAP
+func parseRetEnvEvento(body []byte) (*retEnvEvento, error) {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("retEnvEvento não encontrado: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "retEnvEvento" {
+			var ret retEnvEvento
+			if err := dec.DecodeElement(&ret, &se); err != nil {
+				return nil, err
+			}
+			return &ret, nil
+		}
+	}
+}
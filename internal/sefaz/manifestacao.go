@@ -0,0 +1,68 @@
+package sefaz
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// TipoManifestacao é um evento de manifestação do destinatário
+type TipoManifestacao struct {
+	TpEvento  string
+	Descricao string // descEvento exigido pelo schema (sem acentos)
+	ExigeJust bool   // Operação não Realizada exige justificativa
+}
+
+// TiposManifestacao mapeia o nome usado na linha de comando para o evento
+var TiposManifestacao = map[string]TipoManifestacao{
+	"confirmacao":     {"210200", "Confirmacao da Operacao", false},
+	"ciencia":         {"210210", "Ciencia da Operacao", false},
+	"desconhecimento": {"210220", "Desconhecimento da Operacao", false},
+	"nao-realizada":   {"210240", "Operacao nao Realizada", true},
+}
+
+// Limites do xJust no schema do evento de manifestação
+const (
+	minJustificativa = 15
+	maxJustificativa = 255
+)
+
+// NovoEventoManifestacao monta o evento de manifestação do destinatário para a chave
+//
+// tipo é uma das chaves de TiposManifestacao. A justificativa só é enviada em
+// "nao-realizada", onde é obrigatória (15 a 255 caracteres).
+//
+// Exemplo:
+//
+//	ev, err := sefaz.NovoEventoManifestacao(chave, "ciencia", "")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	ret, err := client.EnviarEvento(ev)
+func NovoEventoManifestacao(chave, tipo, justificativa string) (Evento, error) {
+	t, ok := TiposManifestacao[tipo]
+	if !ok {
+		return Evento{}, fmt.Errorf("tipo de manifestação '%s' inválido (use ciencia, confirmacao, desconhecimento ou nao-realizada)", tipo)
+	}
+
+	det := "<descEvento>" + t.Descricao + "</descEvento>"
+
+	// O schema não aceita quebras de linha nem espaços nas pontas
+	justificativa = strings.Join(strings.Fields(justificativa), " ")
+	if t.ExigeJust {
+		if n := utf8.RuneCountInString(justificativa); n < minJustificativa || n > maxJustificativa {
+			return Evento{}, fmt.Errorf("justificativa obrigatória para '%s', com %d a %d caracteres (informada: %d)", tipo, minJustificativa, maxJustificativa, n)
+		}
+		det += "<xJust>" + escaparTexto(justificativa) + "</xJust>"
+	} else if justificativa != "" {
+		return Evento{}, fmt.Errorf("justificativa só é aceita na manifestação 'nao-realizada'")
+	}
+
+	return Evento{
+		COrgao:     COrgaoAmbienteNacional,
+		Chave:      chave,
+		TpEvento:   t.TpEvento,
+		NSeqEvento: 1,
+		DetEvento:  det,
+	}, nil
+}
@@ -0,0 +1,120 @@
+package sefaz
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// nomeTracer identifica os spans criados por este pacote
+const nomeTracer = "github.com/fabyo/go-nfe-validator/internal/sefaz"
+
+// Serviços informados em Chamada.Servico
+const (
+	ServicoConsulta       = "consulta"        // NfeConsultaProtocolo4
+	ServicoConsultaNFCe   = "consulta-nfce"   // NfeConsultaProtocolo4 da NFC-e (modelo 65)
+	ServicoStatus         = "status"          // NfeStatusServico4
+	ServicoEvento         = "evento"          // NFeRecepcaoEvento4
+	ServicoDistribuicao   = "distribuicao"    // NFeDistribuicaoDFe
+	ServicoRetAutorizacao = "ret-autorizacao" // NFeRetAutorizacao4 (consulta do lote pelo recibo)
+	ServicoEventoUF       = "evento-uf"       // NFeRecepcaoEvento4 do autorizador da UF (eventos com cOrgao da UF)
+)
+
+// Chamada descreve uma requisição concluída a um web service da SEFAZ
+type Chamada struct {
+	Servico  string        // Um dos Servico* (ex: ServicoConsulta)
+	Endpoint string        // URL do web service
+	Duracao  time.Duration // Do envio até a leitura completa da resposta
+	Codigo   string        // Primeiro cStat da resposta (vazio se não houve resposta)
+	Erro     error         // Falha de rede ou de leitura; rejeições vêm em Codigo
+}
+
+// Observador recebe cada Chamada (ex: para métricas); é chamado na goroutine da requisição
+type Observador func(Chamada)
+
+// Observar registra o observador das chamadas deste cliente (nil desliga)
+func (c *Client) Observar(o Observador) {
+	c.observador = o
+}
+
+// Certificado retorna o certificado do cliente (mTLS), ex: para acompanhar a validade
+func (c *Client) Certificado() (*x509.Certificate, error) {
+	if c.cert.Leaf != nil {
+		return c.cert.Leaf, nil
+	}
+	if len(c.cert.Certificate) == 0 {
+		return nil, categorizar(ErrCertificado, fmt.Errorf("certificado do cliente não carregado"))
+	}
+	return x509.ParseCertificate(c.cert.Certificate[0])
+}
+
+// postar envia o envelope SOAP ao web service e devolve o corpo e o HTTP status da resposta
+//
+// Cada chamada gera um span "sefaz.<servico>" (filho do span em ctx) com o
+// endpoint, o HTTP status e o cStat, e é repassada ao Observador.
+func (c *Client) postar(ctx context.Context, servico, url, soapAction, soapEnv string) ([]byte, int, error) {
+	ctx, span := otel.Tracer(nomeTracer).Start(ctx, "sefaz."+servico,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("nfe.sefaz.servico", servico),
+			attribute.String("url.full", url),
+		))
+	defer span.End()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(soapEnv))
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao criar requisição: %w", err)
+	}
+	req.Header.Set("Content-Type", `application/soap+xml; charset=utf-8; action="`+soapAction+`"`)
+	span.SetAttributes(attribute.String("server.address", req.URL.Hostname()))
+
+	inicio := time.Now()
+	body, status, err := c.enviar(req)
+	cStat := valorTag(string(body), "cStat")
+
+	if status != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+	}
+	if cStat != "" {
+		span.SetAttributes(attribute.String("nfe.sefaz.cstat", cStat))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	if c.observador != nil {
+		c.observador(Chamada{
+			Servico:  servico,
+			Endpoint: url,
+			Duracao:  time.Since(inicio),
+			Codigo:   cStat,
+			Erro:     err,
+		})
+	}
+	return body, status, err
+}
+
+// enviar executa a requisição e lê a resposta inteira
+func (c *Client) enviar(req *http.Request) ([]byte, int, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro na conexão mTLS/webservice: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("erro ao ler resposta: %w", err)
+	}
+	return body, resp.StatusCode, nil
+}
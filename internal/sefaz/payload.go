@@ -0,0 +1,88 @@
+package sefaz
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// schemasRequisicao são os XSD de cada requisição (pelo elemento raiz), procurados em Config.SchemasRequisicao
+//
+// Os nomes são os dos pacotes de liberação do Portal Nacional da NF-e: o
+// PL da NF-e 4.00 traz consSitNFe, consStatServ e consReciNFe; envEvento e
+// distDFeInt vêm nos pacotes de eventos e da distribuição DF-e.
+var schemasRequisicao = []struct{ raiz, arquivo string }{
+	{"consSitNFe", "consSitNFe_v4.00.xsd"},
+	{"consStatServ", "consStatServ_v4.00.xsd"},
+	{"consReciNFe", "consReciNFe_v4.00.xsd"},
+	{"envEvento", "envEvento_v1.00.xsd"},
+	{"distDFeInt", "distDFeInt_v1.01.xsd"},
+}
+
+// ErroPayload é a requisição montada pelo cliente que não passou no XSD: não é enviada à SEFAZ
+//
+// Traz ErrPayload na cadeia (errors.Is) e, em Err, a falha da validação com
+// a linha do XML.
+type ErroPayload struct {
+	Servico  string // Um dos Servico* (ex: ServicoConsulta)
+	Raiz     string // Elemento raiz da requisição (ex: "consSitNFe")
+	TpEvento string // Só em envEvento: o tpEvento conferido
+	Err      error
+}
+
+func (e *ErroPayload) Error() string {
+	raiz := e.Raiz
+	if e.TpEvento != "" {
+		raiz += " " + e.TpEvento
+	}
+	return fmt.Sprintf("requisição %s (%s) fora do schema, não enviada: %v", raiz, e.Servico, e.Err)
+}
+
+func (e *ErroPayload) Unwrap() []error {
+	return []error{e.Err, ErrPayload}
+}
+
+// carregarSchemasRequisicao carrega os XSD de schemasRequisicao presentes em dir
+//
+// Os ausentes são ignorados (a requisição segue sem conferência); uma pasta
+// sem nenhum deles é erro de configuração, assim como um XSD inválido.
+func carregarSchemasRequisicao(dir string) (map[string]ValidadorXML, error) {
+	schemas := make(map[string]ValidadorXML)
+	var esperados []string
+	for _, s := range schemasRequisicao {
+		esperados = append(esperados, s.arquivo)
+		caminho := filepath.Join(dir, s.arquivo)
+		if _, err := os.Stat(caminho); err != nil {
+			continue
+		}
+		v, err := validation.NewXSDValidator(caminho)
+		if err != nil {
+			return nil, err
+		}
+		schemas[s.raiz] = v
+	}
+	if len(schemas) == 0 {
+		return nil, categorizar(ErrConfig, fmt.Errorf("nenhum XSD de requisição em '%s' (esperados: %s)", dir, strings.Join(esperados, ", ")))
+	}
+	return schemas, nil
+}
+
+// conferirPayload valida a requisição contra o XSD da raiz (ou, em envEvento, o registrado para o tpEvento)
+//
+// Sem XSD para a requisição, não confere nada.
+func (c *Client) conferirPayload(servico, raiz, tpEvento, payload string) error {
+	v := c.schemas[tpEvento]
+	if tpEvento == "" || v == nil {
+		v = c.schemas[raiz]
+	}
+	if v == nil {
+		return nil
+	}
+	if err := v.Validate([]byte(payload)); err != nil {
+		return &ErroPayload{Servico: servico, Raiz: raiz, TpEvento: tpEvento, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package sefaz
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// Eventos do pedido de prorrogação do prazo de retorno (ICMS suspenso em remessas para industrialização)
+const (
+	TpEventoPedidoProrrogacao1       = "111500" // Pedido de prorrogação do 1º prazo
+	TpEventoPedidoProrrogacao2       = "111501" // Pedido de prorrogação do 2º prazo
+	TpEventoCancelamentoProrrogacao1 = "111502" // Cancelamento do pedido do 1º prazo
+	TpEventoCancelamentoProrrogacao2 = "111503" // Cancelamento do pedido do 2º prazo
+
+	// Respostas do Fisco, registradas pela SEFAZ e recebidas na consulta ou na distribuição DF-e
+	TpEventoRespostaProrrogacao1             = "411500"
+	TpEventoRespostaProrrogacao2             = "411501"
+	TpEventoRespostaCancelamentoProrrogacao1 = "411502"
+	TpEventoRespostaCancelamentoProrrogacao2 = "411503"
+)
+
+// Limites do itemPedido no schema do pedido de prorrogação
+const (
+	maxItensProrrogacao = 990
+	maxQtdeProrrogacao  = 1e11 // qtdeItem: até 11 dígitos inteiros e 4 decimais
+)
+
+// ItemProrrogacao é um item da NF-e de remessa (nItem do det) e a quantidade com prazo a prorrogar
+type ItemProrrogacao struct {
+	NumItem    int
+	Quantidade float64
+}
+
+// tpEventoPrazo escolhe o tpEvento do 1º ou do 2º prazo
+func tpEventoPrazo(prazo int, primeiro, segundo string) (string, error) {
+	switch prazo {
+	case 1:
+		return primeiro, nil
+	case 2:
+		return segundo, nil
+	}
+	return "", fmt.Errorf("prazo %d inválido: use 1 (primeiro prazo) ou 2 (segundo prazo)", prazo)
+}
+
+// NovoEventoPedidoProrrogacao monta o evento 111500 (1º prazo) ou 111501 (2º prazo) do pedido de prorrogação
+//
+// Enviado pelo emitente da NF-e de remessa para industrialização ao
+// autorizador da UF da chave, pede mais prazo para o retorno dos itens com ICMS
+// suspenso. protocolo é o nProt da autorização da NF-e; nSeq diferencia os
+// pedidos da mesma nota e prazo (padrão 1). A resposta do Fisco chega depois
+// como evento 411500/411501 (ver LerRespostaProrrogacao).
+//
+// Exemplo:
+//
+//	ev, err := sefaz.NovoEventoPedidoProrrogacao(chave, "135250000012345", 1, 1, []sefaz.ItemProrrogacao{
+//	    {NumItem: 1, Quantidade: 150},
+//	    {NumItem: 3, Quantidade: 12.5},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	ret, err := client.EnviarEvento(ev)
+func NovoEventoPedidoProrrogacao(chave, protocolo string, prazo, nSeq int, itens []ItemProrrogacao) (Evento, error) {
+	tpEvento, err := tpEventoPrazo(prazo, TpEventoPedidoProrrogacao1, TpEventoPedidoProrrogacao2)
+	if err != nil {
+		return Evento{}, err
+	}
+	if len(chave) != 44 {
+		return Evento{}, fmt.Errorf("chave de acesso '%s' inválida: deve ter 44 dígitos", chave)
+	}
+	if !protocoloEvento.MatchString(protocolo) {
+		return Evento{}, fmt.Errorf("protocolo de autorização '%s' inválido: deve ter 15 dígitos", protocolo)
+	}
+	if len(itens) == 0 || len(itens) > maxItensProrrogacao {
+		return Evento{}, fmt.Errorf("informe de 1 a %d itens no pedido de prorrogação (informados: %d)", maxItensProrrogacao, len(itens))
+	}
+
+	det := "<descEvento>Pedido de Prorrogacao</descEvento><nProt>" + protocolo + "</nProt>"
+	vistos := make(map[int]bool, len(itens))
+	for _, item := range itens {
+		if item.NumItem < 1 || item.NumItem > maxItensProrrogacao {
+			return Evento{}, fmt.Errorf("item %d inválido: o número do item vai de 1 a %d", item.NumItem, maxItensProrrogacao)
+		}
+		if vistos[item.NumItem] {
+			return Evento{}, fmt.Errorf("item %d repetido no pedido de prorrogação", item.NumItem)
+		}
+		vistos[item.NumItem] = true
+		if item.Quantidade <= 0 || item.Quantidade >= maxQtdeProrrogacao {
+			return Evento{}, fmt.Errorf("quantidade %g do item %d inválida: deve ser maior que zero, com até 11 dígitos inteiros", item.Quantidade, item.NumItem)
+		}
+		det += fmt.Sprintf(`<itemPedido numItem="%d"><qtdeItem>%s</qtdeItem></itemPedido>`,
+			item.NumItem, strconv.FormatFloat(item.Quantidade, 'f', 4, 64))
+	}
+
+	return Evento{
+		COrgao:     chave[0:2],
+		Chave:      chave,
+		TpEvento:   tpEvento,
+		NSeqEvento: nSeq,
+		DetEvento:  det,
+	}, nil
+}
+
+// NovoEventoCancelamentoProrrogacao monta o evento 111502 (1º prazo) ou 111503 (2º prazo), que cancela um pedido de prorrogação
+//
+// protocolo é o nProt do registro do pedido e nSeqPedido, o nSeqEvento com
+// que ele foi enviado (o cancelamento usa o mesmo sequencial).
+func NovoEventoCancelamentoProrrogacao(chave, protocolo string, prazo, nSeqPedido int) (Evento, error) {
+	tpPedido, err := tpEventoPrazo(prazo, TpEventoPedidoProrrogacao1, TpEventoPedidoProrrogacao2)
+	if err != nil {
+		return Evento{}, err
+	}
+	tpEvento, _ := tpEventoPrazo(prazo, TpEventoCancelamentoProrrogacao1, TpEventoCancelamentoProrrogacao2)
+	if len(chave) != 44 {
+		return Evento{}, fmt.Errorf("chave de acesso '%s' inválida: deve ter 44 dígitos", chave)
+	}
+	if !protocoloEvento.MatchString(protocolo) {
+		return Evento{}, fmt.Errorf("protocolo do pedido de prorrogação '%s' inválido: deve ter 15 dígitos", protocolo)
+	}
+	if nSeqPedido == 0 {
+		nSeqPedido = 1
+	}
+	if nSeqPedido < 1 || nSeqPedido > 99 {
+		return Evento{}, fmt.Errorf("nSeqEvento do pedido %d inválido: use de 1 a 99", nSeqPedido)
+	}
+
+	pedido := Evento{TpEvento: tpPedido, Chave: chave, NSeqEvento: nSeqPedido}
+	det := "<descEvento>Cancelamento de Pedido de Prorrogacao</descEvento>" +
+		"<idPedidoCancelado>" + pedido.id() + "</idPedidoCancelado>" +
+		"<nProt>" + protocolo + "</nProt>"
+
+	return Evento{
+		COrgao:     chave[0:2],
+		Chave:      chave,
+		TpEvento:   tpEvento,
+		NSeqEvento: nSeqPedido,
+		DetEvento:  det,
+	}, nil
+}
+
+// RespostaProrrogacao é a resposta do Fisco a um pedido de prorrogação (411500/411501) ou ao seu cancelamento (411502/411503)
+type RespostaProrrogacao struct {
+	TpEvento  string `json:"tp_evento"`
+	Chave     string `json:"chave"`
+	Protocolo string `json:"protocolo,omitempty"` // nProt do registro da resposta
+
+	// Pedido é o Id do evento respondido (idPedido ou idPedidoCancelado)
+	Pedido string `json:"pedido"`
+
+	// Status é o statPrazo da resposta ao pedido ou o statCancPedido da resposta ao cancelamento
+	Status string `json:"status"`
+
+	// Itens traz a decisão por item (só na resposta ao pedido)
+	Itens []ItemRespostaProrrogacao `json:"itens,omitempty"`
+
+	// Justificativa e JustificativaOutra vêm na resposta ao cancelamento
+	Justificativa      string `json:"justificativa,omitempty"`
+	JustificativaOutra string `json:"justificativa_outra,omitempty"`
+}
+
+// ItemRespostaProrrogacao é a decisão do Fisco sobre um item do pedido
+type ItemRespostaProrrogacao struct {
+	NumItem            int    `json:"num_item"`
+	Quantidade         string `json:"quantidade"`
+	Justificativa      string `json:"justificativa,omitempty"`       // justStatus
+	JustificativaOutra string `json:"justificativa_outra,omitempty"` // justStaOutra
+}
+
+// infEventoResposta é o infEvento das respostas do Fisco ao pedido de prorrogação
+type infEventoResposta struct {
+	ChNFe     string `xml:"chNFe"`
+	TpEvento  string `xml:"tpEvento"`
+	DetEvento struct {
+		IDPedido          string `xml:"idPedido"`
+		IDPedidoCancelado string `xml:"idPedidoCancelado"`
+		RespPedido        struct {
+			StatPrazo  string `xml:"statPrazo"`
+			ItemPedido []struct {
+				NumItem      int    `xml:"numItem,attr"`
+				QtdeItem     string `xml:"qtdeItem"`
+				JustStatus   string `xml:"justStatus"`
+				JustStaOutra string `xml:"justStaOutra"`
+			} `xml:"itemPedido"`
+		} `xml:"respPedido"`
+		RespCancPedido struct {
+			StatCancPedido string `xml:"statCancPedido"`
+			JustStatus     string `xml:"justStatus"`
+			JustStaOutra   string `xml:"justStaOutra"`
+		} `xml:"respCancPedido"`
+	} `xml:"detEvento"`
+}
+
+// LerRespostaProrrogacao interpreta a resposta do Fisco (evento 411500 a 411503) de um procEventoNFe ou evento
+//
+// Outros eventos são erro: use para os documentos da distribuição DF-e (ou da
+// consulta) cujo tpEvento seja um dos TpEventoResposta*.
+func LerRespostaProrrogacao(xmlData []byte) (*RespostaProrrogacao, error) {
+	var inf *infEventoResposta
+	nProt := ""
+	dec := xml.NewDecoder(bytes.NewReader(xmlData))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch {
+		case se.Name.Local == "infEvento" && inf == nil:
+			inf = &infEventoResposta{}
+			if err := dec.DecodeElement(inf, &se); err != nil {
+				return nil, fmt.Errorf("infEvento da resposta ilegível: %w", err)
+			}
+		case se.Name.Local == "nProt" && inf != nil:
+			// nProt do retEvento: o protocolo de registro da resposta
+			if err := dec.DecodeElement(&nProt, &se); err != nil {
+				return nil, fmt.Errorf("nProt da resposta ilegível: %w", err)
+			}
+		}
+	}
+	if inf == nil {
+		return nil, fmt.Errorf("infEvento não encontrado no XML")
+	}
+
+	r := &RespostaProrrogacao{TpEvento: inf.TpEvento, Chave: inf.ChNFe, Protocolo: nProt}
+	det := inf.DetEvento
+	switch inf.TpEvento {
+	case TpEventoRespostaProrrogacao1, TpEventoRespostaProrrogacao2:
+		r.Pedido, r.Status = det.IDPedido, det.RespPedido.StatPrazo
+		for _, item := range det.RespPedido.ItemPedido {
+			r.Itens = append(r.Itens, ItemRespostaProrrogacao{
+				NumItem:            item.NumItem,
+				Quantidade:         item.QtdeItem,
+				Justificativa:      item.JustStatus,
+				JustificativaOutra: item.JustStaOutra,
+			})
+		}
+	case TpEventoRespostaCancelamentoProrrogacao1, TpEventoRespostaCancelamentoProrrogacao2:
+		r.Pedido, r.Status = det.IDPedidoCancelado, det.RespCancPedido.StatCancPedido
+		r.Justificativa, r.JustificativaOutra = det.RespCancPedido.JustStatus, det.RespCancPedido.JustStaOutra
+	default:
+		return nil, fmt.Errorf("evento %s não é resposta a pedido de prorrogação (use 411500 a 411503)", inf.TpEvento)
+	}
+	return r, nil
+}
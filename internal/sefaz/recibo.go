@@ -0,0 +1,143 @@
+package sefaz
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Códigos de retorno do lote no NFeRetAutorizacao4
+const (
+	CStatLoteProcessado      = "104" // Lote processado: os protocolos das notas vêm em protNFe
+	CStatLoteEmProcessamento = "105" // Lote em processamento: consultar de novo depois do tMed
+	CStatLoteNaoLocalizado   = "106" // Lote não localizado (recibo inexistente ou de outro ambiente)
+)
+
+// ProtocoloNota é o protocolo de uma nota do lote (protNFe/infProt)
+type ProtocoloNota struct {
+	Chave           string `json:"chave_acesso"`
+	Codigo          string `json:"codigo"` // cStat da nota (100 = autorizado o uso)
+	Mensagem        string `json:"mensagem"`
+	Protocolo       string `json:"protocolo,omitempty"`
+	DataRecebimento string `json:"dh_recebimento,omitempty"`
+	DigVal          string `json:"dig_val,omitempty"`
+	Autorizado      bool   `json:"autorizado"`
+
+	// XML é o protNFe completo, para montar o nfeProc com a NFe enviada
+	XML string `json:"-"`
+}
+
+// RetornoRecibo é o resultado de um lote consultado pelo recibo (retConsReciNFe)
+type RetornoRecibo struct {
+	Recibo          string          `json:"recibo"`
+	Codigo          string          `json:"codigo"` // cStat do lote (104, 105, 106...)
+	Mensagem        string          `json:"mensagem"`
+	DataRecebimento string          `json:"dh_recebimento,omitempty"`
+	Protocolos      []ProtocoloNota `json:"protocolos,omitempty"`
+}
+
+// retConsReciNFe é o XML de retorno do NFeRetAutorizacao4
+type retConsReciNFe struct {
+	NRec     string `xml:"nRec"`
+	CStat    string `xml:"cStat"`
+	XMotivo  string `xml:"xMotivo"`
+	DhRecbto string `xml:"dhRecbto"`
+	ProtNFe  []struct {
+		Versao   string `xml:"versao,attr"`
+		Conteudo string `xml:",innerxml"`
+		InfProt  struct {
+			ChNFe    string `xml:"chNFe"`
+			DhRecbto string `xml:"dhRecbto"`
+			NProt    string `xml:"nProt"`
+			DigVal   string `xml:"digVal"`
+			CStat    string `xml:"cStat"`
+			XMotivo  string `xml:"xMotivo"`
+		} `xml:"infProt"`
+	} `xml:"protNFe"`
+}
+
+// ConsultaRecibo: Consulta o resultado do processamento de um lote pelo número do recibo (Webservice NFeRetAutorizacao4)
+//
+// Recupera os protocolos quando o envio ao NFeAutorizacao4 (modo assíncrono)
+// devolveu o recibo, mas a consulta do resultado não chegou a ser feita (ex:
+// timeout). uf é a sigla ou o código IBGE da UF do emissor (vazio = a da
+// configuração); o serviço é o do autorizador da NF-e (modelo 55).
+func (c *Client) ConsultaRecibo(ctx context.Context, recibo, uf string, producao bool) (RetornoRecibo, error) {
+	soapAction := "http://www.portalfiscal.inf.br/nfe/wsdl/NFeRetAutorizacao4/nfeRetAutorizacaoLote"
+
+	if uf == "" {
+		uf = c.cfg.UF
+	}
+	sefazUrl, err := c.urlServicoAmbiente(ServicoRetAutorizacao, uf, producao)
+	if err != nil {
+		return RetornoRecibo{}, err
+	}
+
+	tpAmb := "2"
+	if producao {
+		tpAmb = "1"
+	}
+
+	consReciNFe := fmt.Sprintf(`<consReciNFe xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00"><tpAmb>%s</tpAmb><nRec>%s</nRec></consReciNFe>`, tpAmb, recibo)
+	if err := c.conferirPayload(ServicoRetAutorizacao, "consReciNFe", "", consReciNFe); err != nil {
+		return RetornoRecibo{}, err
+	}
+	soapEnv := `<soap12:Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:soap12="http://www.w3.org/2003/05/soap-envelope"><soap12:Body><nfeDadosMsg xmlns="http://www.portalfiscal.inf.br/nfe/wsdl/NFeRetAutorizacao4">` +
+		consReciNFe + `</nfeDadosMsg></soap12:Body></soap12:Envelope>`
+
+	body, httpStatus, err := c.postar(ctx, ServicoRetAutorizacao, sefazUrl, soapAction, soapEnv)
+	if err != nil {
+		return RetornoRecibo{}, err
+	}
+
+	ret, err := parseRetConsReciNFe(body)
+	if err != nil {
+		return RetornoRecibo{}, categorizar(ErrResposta, fmt.Errorf("resposta da SEFAZ não parseada (HTTP %d): %w", httpStatus, err))
+	}
+
+	retorno := RetornoRecibo{
+		Recibo:          ret.NRec,
+		Codigo:          ret.CStat,
+		Mensagem:        ret.XMotivo,
+		DataRecebimento: ret.DhRecbto,
+	}
+	if retorno.Recibo == "" {
+		retorno.Recibo = recibo
+	}
+	for _, p := range ret.ProtNFe {
+		inf := p.InfProt
+		retorno.Protocolos = append(retorno.Protocolos, ProtocoloNota{
+			Chave:           inf.ChNFe,
+			Codigo:          inf.CStat,
+			Mensagem:        inf.XMotivo,
+			Protocolo:       inf.NProt,
+			DataRecebimento: inf.DhRecbto,
+			DigVal:          inf.DigVal,
+			// 100 = autorizado o uso; 150 = autorizado fora de prazo
+			Autorizado: inf.CStat == "100" || inf.CStat == "150",
+			XML:        fmt.Sprintf(`<protNFe xmlns="http://www.portalfiscal.inf.br/nfe" versao="%s">%s</protNFe>`, p.Versao, strings.TrimSpace(p.Conteudo)),
+		})
+	}
+
+	return retorno, nil
+}
+
+// parseRetConsReciNFe localiza o retConsReciNFe dentro do envelope SOAP
+func parseRetConsReciNFe(body []byte) (*retConsReciNFe, error) {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("retConsReciNFe não encontrado: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "retConsReciNFe" {
+			var ret retConsReciNFe
+			if err := dec.DecodeElement(&ret, &se); err != nil {
+				return nil, err
+			}
+			return &ret, nil
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package sefaz
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Ambientes das chaves de Config.Webservices
+const (
+	AmbienteProducao    = "producao"
+	AmbienteHomologacao = "homologacao"
+)
+
+// AutorizadorNacional é o Ambiente Nacional, que atende a distribuição e a recepção de eventos de todas as UFs
+const AutorizadorNacional = "AN"
+
+// urlsServico são as URLs de um web service nos dois ambientes
+type urlsServico struct {
+	Producao    string
+	Homologacao string
+}
+
+// webservices é a tabela autorizador × serviço → URL de produção e de homologação
+//
+// Fonte: relação de web services do Portal Nacional da NF-e (versão 4.00).
+// Uma URL desatualizada pode ser substituída sem nova versão em
+// Config.Webservices (webservices no validator.yaml).
+var webservices = map[string]map[string]urlsServico{
+	"AM": {
+		ServicoStatus:         {"https://nfe.sefaz.am.gov.br/services2/services/NfeStatusServico4", "https://homnfe.sefaz.am.gov.br/services2/services/NfeStatusServico4"},
+		ServicoConsulta:       {"https://nfe.sefaz.am.gov.br/services2/services/NfeConsulta4", "https://homnfe.sefaz.am.gov.br/services2/services/NfeConsulta4"},
+		ServicoConsultaNFCe:   {"https://nfce.sefaz.am.gov.br/nfce-services/services/NfeConsulta4", "https://homnfce.sefaz.am.gov.br/nfce-services/services/NfeConsulta4"},
+		ServicoRetAutorizacao: {"https://nfe.sefaz.am.gov.br/services2/services/NfeRetAutorizacao4", "https://homnfe.sefaz.am.gov.br/services2/services/NfeRetAutorizacao4"},
+		ServicoEventoUF:       {"https://nfe.sefaz.am.gov.br/services2/services/RecepcaoEvento4", "https://homnfe.sefaz.am.gov.br/services2/services/RecepcaoEvento4"},
+	},
+	"BA": {
+		ServicoStatus:         {"https://nfe.sefaz.ba.gov.br/webservices/NFeStatusServico4/NFeStatusServico4.asmx", "https://hnfe.sefaz.ba.gov.br/webservices/NFeStatusServico4/NFeStatusServico4.asmx"},
+		ServicoConsulta:       {"https://nfe.sefaz.ba.gov.br/webservices/NFeConsultaProtocolo4/NFeConsultaProtocolo4.asmx", "https://hnfe.sefaz.ba.gov.br/webservices/NFeConsultaProtocolo4/NFeConsultaProtocolo4.asmx"},
+		ServicoRetAutorizacao: {"https://nfe.sefaz.ba.gov.br/webservices/NFeRetAutorizacao4/NFeRetAutorizacao4.asmx", "https://hnfe.sefaz.ba.gov.br/webservices/NFeRetAutorizacao4/NFeRetAutorizacao4.asmx"},
+		ServicoEventoUF:       {"https://nfe.sefaz.ba.gov.br/webservices/NFeRecepcaoEvento4/NFeRecepcaoEvento4.asmx", "https://hnfe.sefaz.ba.gov.br/webservices/NFeRecepcaoEvento4/NFeRecepcaoEvento4.asmx"},
+	},
+	"CE": {
+		ServicoStatus:         {"https://nfe.sefaz.ce.gov.br/nfe4/services/NFeStatusServico4", "https://nfeh.sefaz.ce.gov.br/nfe4/services/NFeStatusServico4"},
+		ServicoConsulta:       {"https://nfe.sefaz.ce.gov.br/nfe4/services/NFeConsultaProtocolo4", "https://nfeh.sefaz.ce.gov.br/nfe4/services/NFeConsultaProtocolo4"},
+		ServicoConsultaNFCe:   {"https://nfce.sefaz.ce.gov.br/nfce4/services/NFeConsultaProtocolo4", "https://nfceh.sefaz.ce.gov.br/nfce4/services/NFeConsultaProtocolo4"},
+		ServicoRetAutorizacao: {"https://nfe.sefaz.ce.gov.br/nfe4/services/NFeRetAutorizacao4", "https://nfeh.sefaz.ce.gov.br/nfe4/services/NFeRetAutorizacao4"},
+		ServicoEventoUF:       {"https://nfe.sefaz.ce.gov.br/nfe4/services/NFeRecepcaoEvento4", "https://nfeh.sefaz.ce.gov.br/nfe4/services/NFeRecepcaoEvento4"},
+	},
+	"GO": {
+		ServicoStatus:         {"https://nfe.sefaz.go.gov.br/nfe/services/NFeStatusServico4", "https://homolog.sefaz.go.gov.br/nfe/services/NFeStatusServico4"},
+		ServicoConsulta:       {"https://nfe.sefaz.go.gov.br/nfe/services/NFeConsultaProtocolo4", "https://homolog.sefaz.go.gov.br/nfe/services/NFeConsultaProtocolo4"},
+		ServicoConsultaNFCe:   {"https://nfe.sefaz.go.gov.br/nfe/services/NFeConsultaProtocolo4", "https://homolog.sefaz.go.gov.br/nfe/services/NFeConsultaProtocolo4"},
+		ServicoRetAutorizacao: {"https://nfe.sefaz.go.gov.br/nfe/services/NFeRetAutorizacao4", "https://homolog.sefaz.go.gov.br/nfe/services/NFeRetAutorizacao4"},
+		ServicoEventoUF:       {"https://nfe.sefaz.go.gov.br/nfe/services/NFeRecepcaoEvento4", "https://homolog.sefaz.go.gov.br/nfe/services/NFeRecepcaoEvento4"},
+	},
+	"MG": {
+		ServicoStatus:         {"https://nfe.fazenda.mg.gov.br/nfe2/services/NFeStatusServico4", "https://hnfe.fazenda.mg.gov.br/nfe2/services/NFeStatusServico4"},
+		ServicoConsulta:       {"https://nfe.fazenda.mg.gov.br/nfe2/services/NFeConsultaProtocolo4", "https://hnfe.fazenda.mg.gov.br/nfe2/services/NFeConsultaProtocolo4"},
+		ServicoConsultaNFCe:   {"https://nfce.fazenda.mg.gov.br/nfce/services/NFeConsultaProtocolo4", "https://hnfce.fazenda.mg.gov.br/nfce/services/NFeConsultaProtocolo4"},
+		ServicoRetAutorizacao: {"https://nfe.fazenda.mg.gov.br/nfe2/services/NFeRetAutorizacao4", "https://hnfe.fazenda.mg.gov.br/nfe2/services/NFeRetAutorizacao4"},
+		ServicoEventoUF:       {"https://nfe.fazenda.mg.gov.br/nfe2/services/NFeRecepcaoEvento4", "https://hnfe.fazenda.mg.gov.br/nfe2/services/NFeRecepcaoEvento4"},
+	},
+	"MS": {
+		ServicoStatus:         {"https://nfe.sefaz.ms.gov.br/ws/NFeStatusServico4", "https://hom.nfe.sefaz.ms.gov.br/ws/NFeStatusServico4"},
+		ServicoConsulta:       {"https://nfe.sefaz.ms.gov.br/ws/NFeConsultaProtocolo4", "https://hom.nfe.sefaz.ms.gov.br/ws/NFeConsultaProtocolo4"},
+		ServicoConsultaNFCe:   {"https://nfce.sefaz.ms.gov.br/ws/NFeConsultaProtocolo4", "https://hom.nfce.sefaz.ms.gov.br/ws/NFeConsultaProtocolo4"},
+		ServicoRetAutorizacao: {"https://nfe.sefaz.ms.gov.br/ws/NFeRetAutorizacao4", "https://hom.nfe.sefaz.ms.gov.br/ws/NFeRetAutorizacao4"},
+		ServicoEventoUF:       {"https://nfe.sefaz.ms.gov.br/ws/NFeRecepcaoEvento4", "https://hom.nfe.sefaz.ms.gov.br/ws/NFeRecepcaoEvento4"},
+	},
+	"MT": {
+		ServicoStatus:         {"https://nfe.sefaz.mt.gov.br/nfews/v2/services/NfeStatusServico4", "https://homologacao.sefaz.mt.gov.br/nfews/v2/services/NfeStatusServico4"},
+		ServicoConsulta:       {"https://nfe.sefaz.mt.gov.br/nfews/v2/services/NfeConsulta4", "https://homologacao.sefaz.mt.gov.br/nfews/v2/services/NfeConsulta4"},
+		ServicoConsultaNFCe:   {"https://nfce.sefaz.mt.gov.br/nfcews/services/NfeConsulta4", "https://homologacao.sefaz.mt.gov.br/nfcews/services/NfeConsulta4"},
+		ServicoRetAutorizacao: {"https://nfe.sefaz.mt.gov.br/nfews/v2/services/NfeRetAutorizacao4", "https://homologacao.sefaz.mt.gov.br/nfews/v2/services/NfeRetAutorizacao4"},
+		ServicoEventoUF:       {"https://nfe.sefaz.mt.gov.br/nfews/v2/services/RecepcaoEvento4", "https://homologacao.sefaz.mt.gov.br/nfews/v2/services/RecepcaoEvento4"},
+	},
+	"PE": {
+		ServicoStatus:         {"https://nfe.sefaz.pe.gov.br/nfe-service/services/NFeStatusServico4", "https://nfehomolog.sefaz.pe.gov.br/nfe-service/services/NFeStatusServico4"},
+		ServicoConsulta:       {"https://nfe.sefaz.pe.gov.br/nfe-service/services/NFeConsultaProtocolo4", "https://nfehomolog.sefaz.pe.gov.br/nfe-service/services/NFeConsultaProtocolo4"},
+		ServicoRetAutorizacao: {"https://nfe.sefaz.pe.gov.br/nfe-service/services/NFeRetAutorizacao4", "https://nfehomolog.sefaz.pe.gov.br/nfe-service/services/NFeRetAutorizacao4"},
+		ServicoEventoUF:       {"https://nfe.sefaz.pe.gov.br/nfe-service/services/NFeRecepcaoEvento4", "https://nfehomolog.sefaz.pe.gov.br/nfe-service/services/NFeRecepcaoEvento4"},
+	},
+	"PR": {
+		ServicoStatus:         {"https://nfe.sefa.pr.gov.br/nfe/NFeStatusServico4", "https://homologacao.nfe.sefa.pr.gov.br/nfe/NFeStatusServico4"},
+		ServicoConsulta:       {"https://nfe.sefa.pr.gov.br/nfe/NFeConsultaProtocolo4", "https://homologacao.nfe.sefa.pr.gov.br/nfe/NFeConsultaProtocolo4"},
+		ServicoConsultaNFCe:   {"https://nfce.sefa.pr.gov.br/nfce/NFeConsultaProtocolo4", "https://homologacao.nfce.sefa.pr.gov.br/nfce/NFeConsultaProtocolo4"},
+		ServicoRetAutorizacao: {"https://nfe.sefa.pr.gov.br/nfe/NFeRetAutorizacao4", "https://homologacao.nfe.sefa.pr.gov.br/nfe/NFeRetAutorizacao4"},
+		ServicoEventoUF:       {"https://nfe.sefa.pr.gov.br/nfe/NFeRecepcaoEvento4", "https://homologacao.nfe.sefa.pr.gov.br/nfe/NFeRecepcaoEvento4"},
+	},
+	"RS": {
+		ServicoStatus:         {"https://nfe.sefazrs.rs.gov.br/ws/NfeStatusServico/NfeStatusServico4.asmx", "https://nfe-homologacao.sefazrs.rs.gov.br/ws/NfeStatusServico/NfeStatusServico4.asmx"},
+		ServicoConsulta:       {"https://nfe.sefazrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx", "https://nfe-homologacao.sefazrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx"},
+		ServicoConsultaNFCe:   {"https://nfce.sefazrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx", "https://nfce-homologacao.sefazrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx"},
+		ServicoRetAutorizacao: {"https://nfe.sefazrs.rs.gov.br/ws/NfeRetAutorizacao/NFeRetAutorizacao4.asmx", "https://nfe-homologacao.sefazrs.rs.gov.br/ws/NfeRetAutorizacao/NFeRetAutorizacao4.asmx"},
+		ServicoEventoUF:       {"https://nfe.sefazrs.rs.gov.br/ws/recepcaoevento/recepcaoevento4.asmx", "https://nfe-homologacao.sefazrs.rs.gov.br/ws/recepcaoevento/recepcaoevento4.asmx"},
+	},
+	"SP": {
+		ServicoStatus:         {"https://nfe.fazenda.sp.gov.br/ws/nfestatusservico4.asmx", "https://homologacao.nfe.fazenda.sp.gov.br/ws/nfestatusservico4.asmx"},
+		ServicoConsulta:       {"https://nfe.fazenda.sp.gov.br/ws/nfeconsultaprotocolo4.asmx", "https://homologacao.nfe.fazenda.sp.gov.br/ws/nfeconsultaprotocolo4.asmx"},
+		ServicoConsultaNFCe:   {"https://nfce.fazenda.sp.gov.br/ws/NFeConsultaProtocolo4.asmx", "https://homologacao.nfce.fazenda.sp.gov.br/ws/NFeConsultaProtocolo4.asmx"},
+		ServicoRetAutorizacao: {"https://nfe.fazenda.sp.gov.br/ws/nferetautorizacao4.asmx", "https://homologacao.nfe.fazenda.sp.gov.br/ws/nferetautorizacao4.asmx"},
+		ServicoEventoUF:       {"https://nfe.fazenda.sp.gov.br/ws/nferecepcaoevento4.asmx", "https://homologacao.nfe.fazenda.sp.gov.br/ws/nferecepcaoevento4.asmx"},
+	},
+
+	// SEFAZ Virtual do Ambiente Nacional
+	"SVAN": {
+		ServicoStatus:         {"https://www.sefazvirtual.fazenda.gov.br/NFeStatusServico4/NFeStatusServico4.asmx", "https://hom.sefazvirtual.fazenda.gov.br/NFeStatusServico4/NFeStatusServico4.asmx"},
+		ServicoConsulta:       {"https://www.sefazvirtual.fazenda.gov.br/NFeConsultaProtocolo4/NFeConsultaProtocolo4.asmx", "https://hom.sefazvirtual.fazenda.gov.br/NFeConsultaProtocolo4/NFeConsultaProtocolo4.asmx"},
+		ServicoRetAutorizacao: {"https://www.sefazvirtual.fazenda.gov.br/NFeRetAutorizacao4/NFeRetAutorizacao4.asmx", "https://hom.sefazvirtual.fazenda.gov.br/NFeRetAutorizacao4/NFeRetAutorizacao4.asmx"},
+		ServicoEventoUF:       {"https://www.sefazvirtual.fazenda.gov.br/NFeRecepcaoEvento4/NFeRecepcaoEvento4.asmx", "https://hom.sefazvirtual.fazenda.gov.br/NFeRecepcaoEvento4/NFeRecepcaoEvento4.asmx"},
+	},
+
+	// SEFAZ Virtual do Rio Grande do Sul
+	"SVRS": {
+		ServicoStatus:         {"https://nfe.svrs.rs.gov.br/ws/NfeStatusServico/NfeStatusServico4.asmx", "https://nfe-homologacao.svrs.rs.gov.br/ws/NfeStatusServico/NfeStatusServico4.asmx"},
+		ServicoConsulta:       {"https://nfe.svrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx", "https://nfe-homologacao.svrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx"},
+		ServicoConsultaNFCe:   {"https://nfce.svrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx", "https://nfce-homologacao.svrs.rs.gov.br/ws/NfeConsulta/NfeConsulta4.asmx"},
+		ServicoRetAutorizacao: {"https://nfe.svrs.rs.gov.br/ws/NfeRetAutorizacao/NFeRetAutorizacao4.asmx", "https://nfe-homologacao.svrs.rs.gov.br/ws/NfeRetAutorizacao/NFeRetAutorizacao4.asmx"},
+		ServicoEventoUF:       {"https://nfe.svrs.rs.gov.br/ws/recepcaoevento/recepcaoevento4.asmx", "https://nfe-homologacao.svrs.rs.gov.br/ws/recepcaoevento/recepcaoevento4.asmx"},
+	},
+
+	// Ambiente Nacional: distribuição DF-e e eventos com cOrgao 91 (manifestação do destinatário)
+	AutorizadorNacional: {
+		ServicoDistribuicao: {"https://www1.nfe.fazenda.gov.br/NFeDistribuicaoDFe/NFeDistribuicaoDFe.asmx", "https://hom1.nfe.fazenda.gov.br/NFeDistribuicaoDFe/NFeDistribuicaoDFe.asmx"},
+		ServicoEvento:       {"https://www.nfe.fazenda.gov.br/NFeRecepcaoEvento4/NFeRecepcaoEvento4.asmx", "https://hom1.nfe.fazenda.gov.br/NFeRecepcaoEvento4/NFeRecepcaoEvento4.asmx"},
+	},
+}
+
+// ufAutorizador é o código IBGE de uma UF e os autorizadores que a atendem na NF-e e na NFC-e
+type ufAutorizador struct {
+	codigo      string
+	autorizador string
+	nfce        string
+}
+
+// autorizadorUF indica o código IBGE e os autorizadores (NF-e e NFC-e) de cada UF (sigla)
+var autorizadorUF = map[string]ufAutorizador{
+	"AC": {"12", "SVRS", "SVRS"}, "AL": {"27", "SVRS", "SVRS"}, "AM": {"13", "AM", "AM"}, "AP": {"16", "SVRS", "SVRS"},
+	"BA": {"29", "BA", "SVRS"}, "CE": {"23", "CE", "CE"}, "DF": {"53", "SVRS", "SVRS"}, "ES": {"32", "SVRS", "SVRS"},
+	"GO": {"52", "GO", "GO"}, "MA": {"21", "SVAN", "SVRS"}, "MG": {"31", "MG", "MG"}, "MS": {"50", "MS", "MS"},
+	"MT": {"51", "MT", "MT"}, "PA": {"15", "SVRS", "SVRS"}, "PB": {"25", "SVRS", "SVRS"}, "PE": {"26", "PE", "SVRS"},
+	"PI": {"22", "SVRS", "SVRS"}, "PR": {"41", "PR", "PR"}, "RJ": {"33", "SVRS", "SVRS"}, "RN": {"24", "SVRS", "SVRS"},
+	"RO": {"11", "SVRS", "SVRS"}, "RR": {"14", "SVRS", "SVRS"}, "RS": {"43", "RS", "RS"}, "SC": {"42", "SVRS", "SVRS"},
+	"SE": {"28", "SVRS", "SVRS"}, "SP": {"35", "SP", "SP"}, "TO": {"17", "SVRS", "SVRS"},
+}
+
+// ModeloNFCe é o modelo (posições 21-22 da chave) da NFC-e; as demais chaves são consultadas como NF-e (55)
+const ModeloNFCe = "65"
+
+// ServicoConsultaModelo retorna o serviço de consulta da chave: ServicoConsultaNFCe se o modelo for 65, senão ServicoConsulta
+func ServicoConsultaModelo(chave string) string {
+	if len(chave) >= 22 && chave[20:22] == ModeloNFCe {
+		return ServicoConsultaNFCe
+	}
+	return ServicoConsulta
+}
+
+// autorizadorServico retorna o autorizador da UF (sigla) para o serviço: os da NFC-e têm autorizador próprio
+func autorizadorServico(sigla, servico string) string {
+	if servico == ServicoConsultaNFCe {
+		return autorizadorUF[sigla].nfce
+	}
+	return autorizadorUF[sigla].autorizador
+}
+
+// servicosNacionais são atendidos pelo Ambiente Nacional qualquer que seja a UF
+var servicosNacionais = map[string]bool{ServicoDistribuicao: true, ServicoEvento: true}
+
+// UFs retorna as siglas de todas as UFs com autorizador conhecido, em ordem alfabética
+func UFs() []string {
+	ufs := make([]string, 0, len(autorizadorUF))
+	for uf := range autorizadorUF {
+		ufs = append(ufs, uf)
+	}
+	sort.Strings(ufs)
+	return ufs
+}
+
+// siglaUF normaliza a UF para a sigla: aceita a sigla ("sp") ou o código IBGE ("35"); vazio se desconhecida
+func siglaUF(uf string) string {
+	uf = strings.ToUpper(strings.TrimSpace(uf))
+	if _, ok := autorizadorUF[uf]; ok {
+		return uf
+	}
+	for sigla, a := range autorizadorUF {
+		if a.codigo == uf {
+			return sigla
+		}
+	}
+	return ""
+}
+
+// Autorizador retorna o nome do autorizador que atende a UF (ex: "SP", "SVRS"); aceita a sigla ou o código IBGE
+func Autorizador(uf string) string {
+	return autorizadorUF[siglaUF(uf)].autorizador
+}
+
+// URLWebservice retorna a URL do serviço (um dos Servico*) que atende a UF no ambiente, pela tabela embutida
+//
+// A UF é a sigla ou o código IBGE; nos serviços do Ambiente Nacional
+// (distribuição e eventos) ela é ignorada. Para aplicar as substituições da
+// configuração, use Client.URLServico.
+func URLWebservice(servico, uf string, producao bool) (string, error) {
+	return urlTabela(servico, uf, producao, nil)
+}
+
+// URLServico retorna a URL do serviço para a UF no ambiente da configuração, com as substituições configuradas
+//
+// Ordem: a URL específica (SEFAZ_CONSULTA_URL para as consultas de NF-e e
+// de NFC-e, SEFAZ_DIST_URL, SEFAZ_EVENTO_URL; SEFAZ_STATUS_URL apenas para a
+// UF da configuração),
+// Config.Webservices da UF, Config.Webservices do autorizador e, por fim, a
+// tabela embutida.
+func (c *Client) URLServico(servico, uf string) (string, error) {
+	return c.urlServicoAmbiente(servico, uf, c.cfg.Producao())
+}
+
+// urlServicoAmbiente é o URLServico no ambiente informado: as URLs específicas só valem no ambiente da configuração
+func (c *Client) urlServicoAmbiente(servico, uf string, producao bool) (string, error) {
+	if producao != c.cfg.Producao() {
+		return urlTabela(servico, uf, producao, c.cfg.Webservices)
+	}
+
+	var especifica string
+	switch servico {
+	case ServicoConsulta, ServicoConsultaNFCe:
+		especifica = c.cfg.ConsultaURL
+	case ServicoDistribuicao:
+		especifica = c.cfg.DistURL
+	case ServicoEvento:
+		especifica = c.cfg.EventoURL
+	case ServicoStatus:
+		if sigla := siglaUF(uf); sigla != "" && sigla == siglaUF(c.cfg.UF) {
+			especifica = c.cfg.StatusURL
+		}
+	}
+	if especifica != "" {
+		return especifica, nil
+	}
+	return urlTabela(servico, uf, producao, c.cfg.Webservices)
+}
+
+// urlTabela procura a URL nas substituições (da UF e do autorizador) e na tabela embutida
+func urlTabela(servico, uf string, producao bool, substituicoes map[string]string) (string, error) {
+	ambiente := AmbienteHomologacao
+	if producao {
+		ambiente = AmbienteProducao
+	}
+
+	locais := []string{AutorizadorNacional}
+	if !servicosNacionais[servico] {
+		sigla := siglaUF(uf)
+		if sigla == "" {
+			return "", categorizar(ErrUF, fmt.Errorf("UF '%s' sem autorizador conhecido", uf))
+		}
+		locais = []string{sigla, autorizadorServico(sigla, servico)}
+	}
+
+	for _, local := range locais {
+		if url := substituicoes[local+"/"+servico+"/"+ambiente]; url != "" {
+			return url, nil
+		}
+	}
+	urls, ok := webservices[locais[len(locais)-1]][servico]
+	if !ok {
+		return "", categorizar(ErrUF, fmt.Errorf("serviço '%s' sem URL conhecida para '%s'", servico, locais[0]))
+	}
+	if producao {
+		return urls.Producao, nil
+	}
+	return urls.Homologacao, nil
+}
+
+// ChaveWebservice confere e normaliza uma substituição de Config.Webservices: "<UF ou autorizador>/<serviço>/<ambiente>"
+//
+// O local é a sigla ou o código IBGE de uma UF, um autorizador (SVRS, SVAN...)
+// ou AN; o serviço, um dos Servico*; o ambiente, producao ou homologacao.
+// Exemplo: ChaveWebservice("35", "consulta", "producao") = "SP/consulta/producao".
+func ChaveWebservice(local, servico, ambiente string) (string, error) {
+	servico, ambiente = strings.ToLower(servico), strings.ToLower(ambiente)
+	switch servico {
+	case ServicoConsulta, ServicoConsultaNFCe, ServicoStatus, ServicoRetAutorizacao, ServicoEventoUF, ServicoDistribuicao, ServicoEvento:
+	default:
+		return "", fmt.Errorf("serviço '%s' desconhecido (use %s, %s, %s, %s, %s, %s ou %s)", servico, ServicoConsulta, ServicoConsultaNFCe, ServicoStatus, ServicoRetAutorizacao, ServicoEventoUF, ServicoDistribuicao, ServicoEvento)
+	}
+	if ambiente != AmbienteProducao && ambiente != AmbienteHomologacao {
+		return "", fmt.Errorf("ambiente '%s' desconhecido (use %s ou %s)", ambiente, AmbienteProducao, AmbienteHomologacao)
+	}
+
+	nome := strings.ToUpper(strings.TrimSpace(local))
+	if sigla := siglaUF(nome); sigla != "" {
+		nome = sigla
+	} else if _, ok := webservices[nome]; !ok {
+		return "", fmt.Errorf("'%s' não é uma UF nem um autorizador conhecido", local)
+	}
+	if servicosNacionais[servico] != (nome == AutorizadorNacional) {
+		return "", fmt.Errorf("o serviço %s é atendido por %s, não por %s", servico, atendidoPor(servico), nome)
+	}
+	return nome + "/" + servico + "/" + ambiente, nil
+}
+
+// atendidoPor descreve quem atende o serviço, para as mensagens de ChaveWebservice
+func atendidoPor(servico string) string {
+	if servicosNacionais[servico] {
+		return AutorizadorNacional
+	}
+	return "UF ou autorizador"
+}
+
+// URLStatusServico retorna a URL do NfeStatusServico4 que atende a UF no ambiente informado
+func URLStatusServico(uf string, producao bool) (string, error) {
+	return URLWebservice(ServicoStatus, uf, producao)
+}
+
+// URLDistribuicaoDFe retorna a URL do NFeDistribuicaoDFe do Ambiente Nacional
+func URLDistribuicaoDFe(producao bool) string {
+	url, _ := URLWebservice(ServicoDistribuicao, "", producao)
+	return url
+}
+
+// URLRecepcaoEvento retorna a URL do NFeRecepcaoEvento4 do Ambiente Nacional
+func URLRecepcaoEvento(producao bool) string {
+	url, _ := URLWebservice(ServicoEvento, "", producao)
+	return url
+}
@@ -0,0 +1,224 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// colunas são as colunas de validacoes na ordem usada em Salvar e Buscar (sem o id)
+var colunas = []string{
+	"chave", "arquivo", "origem", "modelo", "emitente_cnpj",
+	"aprovado", "codigo_saida", "valido_xsd", "erro", "achados",
+	"cstat", "mensagem", "consulta", "autorizado", "protocolo",
+	"validado_em", "reconsultado_em",
+}
+
+// bancoSQL é a implementação do ResultStore comum a SQLite e Postgres
+//
+// Os comandos são escritos com "?" e convertidos para "$1, $2..." quando o
+// banco pede parâmetros numerados (Postgres).
+type bancoSQL struct {
+	db          *sql.DB
+	numerados   bool
+	insercao    string
+	selecao     string
+	atualizacao string
+}
+
+// novoBancoSQL cria as tabelas (ddl) e prepara os comandos
+func novoBancoSQL(db *sql.DB, ddl []string, numerados bool) (*bancoSQL, error) {
+	ctx := context.Background()
+	for _, cmd := range ddl {
+		if _, err := db.ExecContext(ctx, cmd); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("erro ao criar tabela de resultados: %w", err)
+		}
+	}
+
+	b := &bancoSQL{db: db, numerados: numerados}
+	marcadores := strings.TrimSuffix(strings.Repeat("?, ", len(colunas)), ", ")
+	b.insercao = b.parametros("INSERT INTO validacoes (" + strings.Join(colunas, ", ") + ") VALUES (" + marcadores + ") RETURNING id")
+	b.selecao = "SELECT id, " + strings.Join(colunas, ", ") + " FROM validacoes"
+	b.atualizacao = b.parametros("UPDATE validacoes SET aprovado = ?, codigo_saida = ?, erro = ?, " +
+		"cstat = ?, mensagem = ?, consulta = ?, autorizado = ?, protocolo = ?, reconsultado_em = ? WHERE id = ?")
+	return b, nil
+}
+
+// Salvar grava o registro (ValidadoEm vazio = agora) e devolve o ID atribuído
+func (b *bancoSQL) Salvar(ctx context.Context, r Registro) (int64, error) {
+	if r.ValidadoEm.IsZero() {
+		r.ValidadoEm = time.Now()
+	}
+
+	if r.Achados == nil {
+		r.Achados = []nfe.Achado{}
+	}
+	achados, err := json.Marshal(r.Achados)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao serializar achados: %w", err)
+	}
+
+	var id int64
+	err = b.db.QueryRowContext(ctx, b.insercao,
+		r.Chave, r.Arquivo, r.Origem, r.Modelo, r.EmitenteCNPJ,
+		r.Aprovado, r.CodigoSaida, r.ValidoXSD, r.Erro, string(achados),
+		r.CStat, r.Mensagem, r.Consulta, r.Autorizado, r.Protocolo,
+		r.ValidadoEm.UTC(), instanteOuNulo(r.ReconsultadoEm),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao gravar resultado de %s: %w", identificacao(r), err)
+	}
+	return id, nil
+}
+
+// Buscar devolve os registros do filtro, do mais recente para o mais antigo
+func (b *bancoSQL) Buscar(ctx context.Context, f Filtro) ([]Registro, error) {
+	var (
+		condicoes []string
+		args      []any
+	)
+	adicionar := func(condicao string, valor any) {
+		condicoes = append(condicoes, condicao)
+		args = append(args, valor)
+	}
+	if f.Chave != "" {
+		adicionar("chave = ?", f.Chave)
+	}
+	if f.CStat != "" {
+		adicionar("cstat = ?", f.CStat)
+	}
+	if f.Origem != "" {
+		adicionar("origem = ?", f.Origem)
+	}
+	if f.Consulta != "" {
+		adicionar("consulta = ?", f.Consulta)
+	}
+	if !f.Desde.IsZero() {
+		adicionar("validado_em >= ?", f.Desde.UTC())
+	}
+	if !f.Ate.IsZero() {
+		adicionar("validado_em < ?", f.Ate.UTC())
+	}
+
+	if f.UltimoPorChave {
+		condicoes = append(condicoes, "NOT EXISTS (SELECT 1 FROM validacoes v WHERE v.chave = validacoes.chave AND "+
+			"(v.validado_em > validacoes.validado_em OR (v.validado_em = validacoes.validado_em AND v.id > validacoes.id)))")
+	}
+
+	limite := f.Limite
+	if limite <= 0 {
+		limite = limitePadrao
+	}
+
+	consulta := b.selecao
+	if len(condicoes) > 0 {
+		consulta += " WHERE " + strings.Join(condicoes, " AND ")
+	}
+	consulta += " ORDER BY validado_em DESC, id DESC LIMIT " + strconv.Itoa(limite)
+
+	rows, err := b.db.QueryContext(ctx, b.parametros(consulta), args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar resultados: %w", err)
+	}
+	defer rows.Close()
+
+	var regs []Registro
+	for rows.Next() {
+		var (
+			r            Registro
+			achados      string
+			reconsultado sql.NullTime
+		)
+		err := rows.Scan(&r.ID,
+			&r.Chave, &r.Arquivo, &r.Origem, &r.Modelo, &r.EmitenteCNPJ,
+			&r.Aprovado, &r.CodigoSaida, &r.ValidoXSD, &r.Erro, &achados,
+			&r.CStat, &r.Mensagem, &r.Consulta, &r.Autorizado, &r.Protocolo,
+			&r.ValidadoEm, &reconsultado,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler resultado: %w", err)
+		}
+		if err := json.Unmarshal([]byte(achados), &r.Achados); err != nil {
+			return nil, fmt.Errorf("achados inválidos no resultado %d: %w", r.ID, err)
+		}
+		r.ValidadoEm = r.ValidadoEm.Local()
+		if reconsultado.Valid {
+			r.ReconsultadoEm = reconsultado.Time.Local()
+		}
+		regs = append(regs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao consultar resultados: %w", err)
+	}
+	return regs, nil
+}
+
+// Atualizar regrava a situação SEFAZ do registro r.ID (ReconsultadoEm vazio = agora)
+func (b *bancoSQL) Atualizar(ctx context.Context, r Registro) error {
+	if r.ReconsultadoEm.IsZero() {
+		r.ReconsultadoEm = time.Now()
+	}
+
+	res, err := b.db.ExecContext(ctx, b.atualizacao,
+		r.Aprovado, r.CodigoSaida, r.Erro,
+		r.CStat, r.Mensagem, r.Consulta, r.Autorizado, r.Protocolo, r.ReconsultadoEm.UTC(),
+		r.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar resultado de %s: %w", identificacao(r), err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("resultado %d não encontrado", r.ID)
+	}
+	return nil
+}
+
+// Close fecha a conexão com o banco
+func (b *bancoSQL) Close() error {
+	return b.db.Close()
+}
+
+// parametros converte os "?" do comando para "$1, $2..." nos bancos que pedem parâmetros numerados
+func (b *bancoSQL) parametros(consulta string) string {
+	if !b.numerados {
+		return consulta
+	}
+
+	var out strings.Builder
+	n := 0
+	for _, c := range consulta {
+		if c == '?' {
+			n++
+			out.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		out.WriteRune(c)
+	}
+	return out.String()
+}
+
+// instanteOuNulo grava o instante em UTC ou NULL quando ele é zero
+func instanteOuNulo(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// identificacao identifica o registro nas mensagens de erro (chave ou, sem ela, o arquivo)
+func identificacao(r Registro) string {
+	if r.Chave != "" {
+		return r.Chave
+	}
+	if r.Arquivo != "" {
+		return r.Arquivo
+	}
+	return "-"
+}
@@ -0,0 +1,78 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ddlSQLite cria a tabela e os índices do histórico no SQLite
+var ddlSQLite = []string{
+	`CREATE TABLE IF NOT EXISTS validacoes (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		chave         TEXT NOT NULL,
+		arquivo       TEXT NOT NULL,
+		origem        TEXT NOT NULL,
+		modelo        TEXT NOT NULL,
+		emitente_cnpj TEXT NOT NULL,
+		aprovado      BOOLEAN NOT NULL,
+		codigo_saida  INTEGER NOT NULL,
+		valido_xsd    BOOLEAN NOT NULL,
+		erro          TEXT NOT NULL,
+		achados       TEXT NOT NULL,
+		cstat         TEXT NOT NULL,
+		mensagem      TEXT NOT NULL,
+		consulta      TEXT NOT NULL,
+		autorizado    BOOLEAN NOT NULL,
+		protocolo     TEXT NOT NULL,
+		validado_em   TIMESTAMP NOT NULL,
+		reconsultado_em TIMESTAMP
+	)`,
+	`CREATE INDEX IF NOT EXISTS validacoes_chave ON validacoes (chave, validado_em)`,
+	`CREATE INDEX IF NOT EXISTS validacoes_validado_em ON validacoes (validado_em)`,
+}
+
+// SQLite é o ResultStore em um arquivo SQLite (um único processo gravando)
+type SQLite struct {
+	*bancoSQL
+}
+
+// NewSQLite abre (ou cria) o banco SQLite em caminho
+//
+// O banco usa WAL, para as consultas não bloquearem as gravações, e uma
+// única conexão: os workers gravam um de cada vez.
+func NewSQLite(caminho string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", "file:"+caminho+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir SQLite '%s': %w", caminho, err)
+	}
+	db.SetMaxOpenConns(1)
+
+	b, err := novoBancoSQL(db, ddlSQLite, false)
+	if err != nil {
+		return nil, fmt.Errorf("SQLite '%s': %w", caminho, err)
+	}
+	if err := migrarSQLite(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("SQLite '%s': %w", caminho, err)
+	}
+	return &SQLite{b}, nil
+}
+
+// migrarSQLite acrescenta às tabelas de versões anteriores as colunas criadas depois
+//
+// O SQLite não tem ADD COLUMN IF NOT EXISTS: a coluna é procurada antes.
+func migrarSQLite(db *sql.DB) error {
+	var existe int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('validacoes') WHERE name = 'reconsultado_em'`).Scan(&existe)
+	if err != nil {
+		return fmt.Errorf("erro ao conferir colunas: %w", err)
+	}
+	if existe == 0 {
+		if _, err := db.Exec(`ALTER TABLE validacoes ADD COLUMN reconsultado_em TIMESTAMP`); err != nil {
+			return fmt.Errorf("erro ao criar coluna reconsultado_em: %w", err)
+		}
+	}
+	return nil
+}
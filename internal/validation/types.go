@@ -1,66 +1,27 @@
 package validation
 
-import (
-	"encoding/xml"
-)
-
-// ======================================================================
-// Structs de NFe (Simplificados)
-// ======================================================================
-
-// Caso o XML seja um procNFe (mais comum: arquivo final retornado pela SEFAZ)
-type ProcNFe struct {
-	XMLName xml.Name   `xml:"nfeProc"`
-	NFe     NFeEnvelope `xml:"NFe"`
-}
-
-// NFe "pura" (root <NFe>...</NFe>)
-type NFeEnvelope struct {
-	XMLName xml.Name `xml:"NFe"`
-	InfNFe  InfNFe   `xml:"infNFe"`
-}
-
-type InfNFe struct {
-	ID   string `xml:"Id,attr"` // Id="NFe<chave>"
-	Ide  Ide    `xml:"ide"`
-	Emit Emit   `xml:"emit"`
-	Dest Dest   `xml:"dest"`
-	Total Total `xml:"total"`
-}
-
-type Ide struct {
-	Modelo string `xml:"mod"`
-	Serie  string `xml:"serie"`
-	NumNf  string `xml:"nNF"`
-}
-
-type Emit struct {
-	CNPJ  string `xml:"CNPJ"`
-	XNome string `xml:"xNome"`
-}
-
-type Dest struct {
-	CNPJ  string `xml:"CNPJ"`
-	CPF   string `xml:"CPF"`
-	XNome string `xml:"xNome"`
-}
-
-type Total struct {
-	ICMSTot ICMSTot `xml:"ICMSTot"`
-}
-
-type ICMSTot struct {
-	VNF string `xml:"vNF"`
-}
+// As structs do XML da NF-e (procNFe, NFe, infNFe...) ficam apenas em pkg/nfe
+// (nfe.ParseNFe); aqui ficam só os modelos da resposta JSON do CLI.
 
 // ======================================================================
 // Structs da Resposta JSON (Modelo de Dados)
 // ======================================================================
 
+// Situação da fase SEFAZ (SefazStatus.Consulta)
+const (
+	ConsultaRealizada = "realizada" // a SEFAZ respondeu (Codigo é o cStat)
+	ConsultaPulada    = "pulada"    // fase não executada (-offline, -skip-sefaz, -xsd)
+	ConsultaFalhou    = "falhou"    // erro de configuração ou conectividade
+)
+
 type SefazStatus struct {
 	Autorizado bool   `json:"autorizado"`
 	Codigo     string `json:"codigo"`
 	Mensagem   string `json:"mensagem"`
+	Consulta   string `json:"consulta,omitempty"`
+
+	// Protocolo é a resposta bruta da SEFAZ (retConsSitNFe), guardada no histórico; fora do JSON
+	Protocolo string `json:"-"`
 }
 
 type DadosXMLNFe struct {
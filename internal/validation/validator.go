@@ -1,43 +1,75 @@
 package validation
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	xsdvalidate "github.com/terminalstatic/go-xsd-validate"
 )
 
-func ValidateWithXSD(xmlBytes []byte, schemaPath string) error {
-	// opcional: checar se o XSD existe, pra erro ficar mais claro
+// ErrSchema marca as falhas ao carregar o XSD (arquivo ausente ou inválido), para separá-las com errors.Is do XML fora do schema
+var ErrSchema = errors.New("XSD indisponível")
+
+// erroSchema acrescenta ErrSchema à cadeia de um erro de carregamento, sem mudar a mensagem
+type erroSchema struct{ error }
+
+func (e erroSchema) Unwrap() []error {
+	return []error{e.error, ErrSchema}
+}
+
+// XSDValidator mantém um XSD pré-carregado para validar muitos XMLs
+//
+// O schema é compilado uma única vez e pode ser usado por várias goroutines
+// ao mesmo tempo (libxml2 cria um contexto de validação por chamada).
+// Chame Close() ao final para liberar a memória do schema. A libxml2 nunca é
+// finalizada (Cleanup): outros XSDValidator podem estar em uso.
+type XSDValidator struct {
+	schemaPath string
+	handler    *xsdvalidate.XsdHandler
+}
+
+// NewXSDValidator: Inicializa a libxml2 e carrega o XSD em memória
+func NewXSDValidator(schemaPath string) (*XSDValidator, error) {
 	if _, err := os.Stat(schemaPath); err != nil {
-		return fmt.Errorf("arquivo XSD não encontrado em '%s': %w", schemaPath, err)
+		return nil, erroSchema{fmt.Errorf("arquivo XSD não encontrado em '%s': %w", schemaPath, err)}
 	}
 
-	// Inicializa libxml2 wrapper
-	xsdvalidate.Init()
-	defer xsdvalidate.Cleanup()
+	// Erro aqui significa apenas que a libxml2 já foi inicializada
+	_ = xsdvalidate.Init()
 
-	// Carrega o XSD (como no exemplo da doc)
-	xsdHandler, err := xsdvalidate.NewXsdHandlerUrl(schemaPath, xsdvalidate.ParsErrDefault)
+	handler, err := xsdvalidate.NewXsdHandlerUrl(schemaPath, xsdvalidate.ParsErrDefault)
 	if err != nil {
-		return fmt.Errorf("erro ao carregar XSD '%s': %w", schemaPath, err)
+		return nil, erroSchema{fmt.Errorf("erro ao carregar XSD '%s': %w", schemaPath, err)}
 	}
-	defer xsdHandler.Free()
 
-	// Option 2 do exemplo: validar direto da memória
-	err = xsdHandler.ValidateMem(xmlBytes, xsdvalidate.ValidErrDefault)
-	if err != nil {
-		switch e := err.(type) {
-		case xsdvalidate.ValidationError:
-			if len(e.Errors) > 0 {
-				first := e.Errors[0]
-				return fmt.Errorf("falha na validação XSD (linha %d): %s", first.Line, first.Message)
-			}
-			return fmt.Errorf("falha na validação XSD: %v", e)
-		default:
-			return fmt.Errorf("erro de validação XSD: %w", err)
-		}
+	return &XSDValidator{schemaPath: schemaPath, handler: handler}, nil
+}
+
+// Validate: Valida o XML contra o XSD pré-carregado
+func (v *XSDValidator) Validate(xmlBytes []byte) error {
+	return formatXSDError(v.handler.ValidateMem(xmlBytes, xsdvalidate.ValidErrDefault))
+}
+
+// Close: Libera o schema carregado
+func (v *XSDValidator) Close() {
+	v.handler.Free()
+}
+
+// formatXSDError: Converte o erro da libxml2 em mensagem com a primeira linha inválida
+func formatXSDError(err error) error {
+	if err == nil {
+		return nil
 	}
 
-	return nil
+	switch e := err.(type) {
+	case xsdvalidate.ValidationError:
+		if len(e.Errors) > 0 {
+			first := e.Errors[0]
+			return fmt.Errorf("falha na validação XSD (linha %d): %s", first.Line, first.Message)
+		}
+		return fmt.Errorf("falha na validação XSD: %v", e)
+	default:
+		return fmt.Errorf("erro de validação XSD: %w", err)
+	}
 }
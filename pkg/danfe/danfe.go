@@ -0,0 +1,731 @@
+// Package danfe gera o DANFE (Documento Auxiliar da NF-e) em PDF
+//
+// O DANFE é a representação impressa da NF-e modelo 55 que acompanha a
+// mercadoria. Só pode ser impresso para notas com uso autorizado, por isso
+// a geração parte do procNFe (nota + protocolo devolvido pela SEFAZ).
+//
+// Além do DANFE completo (A4), WithLayout gera o DANFE Simplificado (A5) e a
+// etiqueta de 100 × 150 mm usada na expedição.
+//
+// Exemplo:
+//
+//	xmlData, _ := os.ReadFile("nota-procNFe.xml")
+//	f, _ := os.Create("nota.pdf")
+//	defer f.Close()
+//	if err := danfe.GerarXML(f, xmlData); err != nil {
+//	    log.Fatal(err)
+//	}
+package danfe
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boombuler/barcode/code128"
+	"github.com/go-pdf/fpdf"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// ErrNaoAutorizada indica que o procNFe não tem protocolo de uso autorizado (cStat 100/150)
+var ErrNaoAutorizada = errors.New("nota sem autorização de uso: o DANFE só pode ser impresso para NF-e autorizada")
+
+// Dimensões da página A4 retrato (mm)
+const (
+	larguraPagina = 210.0
+	alturaPagina  = 297.0
+	margem        = 5.0
+	larguraUtil   = larguraPagina - 2*margem
+
+	alturaCampo           = 7.0  // altura padrão de um quadro rótulo + valor
+	alturaDadosAdicionais = 32.0 // quadro de dados adicionais no pé da primeira folha
+	alturaLinhaItem       = 3.2  // altura de cada linha de texto da tabela de itens
+	tamanhoRotulo         = 5.5
+	tamanhoValor          = 8.0
+	tamanhoItem           = 6.0
+)
+
+// Modalidades de frete (modFrete) exibidas no quadro do transportador
+var modalidadesFrete = map[string]string{
+	"0": "0-Por conta do Emit",
+	"1": "1-Por conta do Dest",
+	"2": "2-Por conta de Terceiros",
+	"3": "3-Próprio por conta do Rem",
+	"4": "4-Próprio por conta do Dest",
+	"9": "9-Sem Transporte",
+}
+
+// GerarXML faz o parse do procNFe e grava o DANFE em PDF no writer (ver Gerar)
+//
+// Exemplo:
+//
+//	xmlData, _ := os.ReadFile("nota-procNFe.xml")
+//	var buf bytes.Buffer
+//	if err := danfe.GerarXML(&buf, xmlData); err != nil {
+//	    log.Fatal(err)
+//	}
+func GerarXML(w io.Writer, xmlData []byte, opcoes ...Opcao) error {
+	proc, err := nfe.ParseProcNFe(xmlData)
+	if err != nil {
+		return err
+	}
+	return Gerar(w, proc, opcoes...)
+}
+
+// Gerar grava o DANFE em PDF do procNFe informado (A4 retrato, salvo outro WithLayout)
+//
+// Retorna ErrNaoAutorizada se o protocolo não for de uso autorizado e um erro
+// se a nota não for modelo 55 (a NFC-e usa o DANFE NFC-e, em outro layout)
+// ou se o layout for desconhecido. Notas de homologação (tpAmb 2) saem com a
+// marca "SEM VALOR FISCAL".
+func Gerar(w io.Writer, proc *nfe.ProcNFe, opcoes ...Opcao) error {
+	o := opcoesDanfe{layout: LayoutCompleto}
+	for _, opcao := range opcoes {
+		opcao(&o)
+	}
+	if err := o.layout.validar(); err != nil {
+		return err
+	}
+
+	if !proc.Autorizada() {
+		if proc.ProtNFe != nil {
+			p := proc.ProtNFe.InfProt
+			return fmt.Errorf("%w (cStat %s - %s)", ErrNaoAutorizada, p.CStat, p.XMotivo)
+		}
+		return ErrNaoAutorizada
+	}
+	if modelo := proc.NFe.InfNFe.Ide.Modelo; modelo != nfe.ModeloNFe {
+		return fmt.Errorf("modelo %s não suportado: o DANFE é impresso apenas para NF-e modelo 55", modelo)
+	}
+
+	d := novoDocumento(proc, o.layout)
+	switch o.layout {
+	case LayoutSimplificado:
+		d.desenharSimplificado()
+	case LayoutEtiqueta:
+		d.desenharEtiqueta()
+	default:
+		d.desenhar()
+	}
+
+	if err := d.pdf.Output(w); err != nil {
+		return fmt.Errorf("erro ao gerar PDF do DANFE: %w", err)
+	}
+	return nil
+}
+
+// documento guarda o estado da geração de um DANFE
+type documento struct {
+	pdf   *fpdf.Fpdf
+	tr    func(string) string // converte UTF-8 para a codificação das fontes padrão do PDF
+	proc  *nfe.ProcNFe
+	inf   *nfe.InfNFe
+	chave string
+	y     float64
+
+	// largura e altura são as dimensões da folha do layout (mm)
+	largura, altura float64
+
+	// inicioTabela é o Y da primeira linha de itens na folha atual
+	inicioTabela float64
+}
+
+// tamanhosFolha são as dimensões da folha (mm) dos layouts que não usam A4
+var tamanhosFolha = map[Layout]fpdf.SizeType{
+	LayoutSimplificado: {Wd: 148, Ht: 210}, // A5
+	LayoutEtiqueta:     {Wd: 100, Ht: 150},
+}
+
+func novoDocumento(proc *nfe.ProcNFe, layout Layout) *documento {
+	folha := &fpdf.InitType{OrientationStr: "P", UnitStr: "mm", SizeStr: "A4"}
+	tamanho, ok := tamanhosFolha[layout]
+	if ok {
+		folha.SizeStr, folha.Size = "", tamanho
+	} else {
+		tamanho = fpdf.SizeType{Wd: larguraPagina, Ht: alturaPagina}
+	}
+	pdf := fpdf.NewCustom(folha)
+	pdf.SetMargins(margem, margem, margem)
+	pdf.SetAutoPageBreak(false, margem)
+	pdf.AliasNbPages("{nb}")
+	pdf.SetCellMargin(0.8)
+	pdf.SetLineWidth(0.2)
+
+	d := &documento{
+		pdf:   pdf,
+		tr:    pdf.UnicodeTranslatorFromDescriptor(""),
+		proc:  proc,
+		inf:   &proc.NFe.InfNFe,
+		chave: nfe.ExtractChaveFromID(proc.NFe.InfNFe.ID),
+
+		largura: tamanho.Wd,
+		altura:  tamanho.Ht,
+	}
+	pdf.SetTitle("DANFE "+d.chave, true)
+	pdf.SetCreator("go-nfe-validator", true)
+	return d
+}
+
+// desenhar monta todas as folhas do DANFE
+func (d *documento) desenhar() {
+	d.novaFolha()
+	d.canhoto()
+	d.cabecalho()
+	d.destinatario()
+	d.fatura()
+	d.impostos()
+	d.transportador()
+	d.itens()
+	d.dadosAdicionais()
+}
+
+// novaFolha adiciona uma página (com a marca de homologação quando for o caso)
+func (d *documento) novaFolha() {
+	d.pdf.AddPage()
+	d.y = margem
+
+	if d.inf.Ide.TpAmb != "2" {
+		return
+	}
+	d.pdf.SetFont("Helvetica", "B", 40*d.largura/larguraPagina)
+	d.pdf.SetTextColor(210, 210, 210)
+	d.pdf.TransformBegin()
+	d.pdf.TransformRotate(45, d.largura/2, d.altura/2)
+	texto := d.tr("SEM VALOR FISCAL")
+	d.pdf.Text(d.largura/2-d.pdf.GetStringWidth(texto)/2, d.altura/2, texto)
+	d.pdf.TransformEnd()
+	d.pdf.SetTextColor(0, 0, 0)
+}
+
+// canhoto desenha o recibo destacável do topo da primeira folha
+func (d *documento) canhoto() {
+	ide := d.inf.Ide
+	x, y := margem, d.y
+
+	recibo := fmt.Sprintf("RECEBEMOS DE %s OS PRODUTOS E/OU SERVIÇOS CONSTANTES DA NOTA FISCAL ELETRÔNICA INDICADA AO LADO. "+
+		"EMISSÃO: %s VALOR TOTAL: R$ %s DESTINATÁRIO: %s",
+		d.inf.Emit.XNome, data(ide.DhEmi), moeda(d.inf.Total.ICMSTot.VNF), d.inf.Dest.XNome)
+	d.pdf.Rect(x, y, 160, 9, "D")
+	d.pdf.SetXY(x, y+0.5)
+	d.pdf.SetFont("Helvetica", "", tamanhoRotulo)
+	d.pdf.MultiCell(160, 2.6, d.tr(recibo), "", "L", false)
+
+	d.campo(x, y+9, 40, 9, "DATA DE RECEBIMENTO", "", "L")
+	d.campo(x+40, y+9, 120, 9, "IDENTIFICAÇÃO E ASSINATURA DO RECEBEDOR", "", "L")
+
+	d.pdf.Rect(x+160, y, 40, 18, "D")
+	d.texto(x+160, y+2, 40, 5, "NF-e", "B", 11, "C")
+	d.texto(x+160, y+8, 40, 4, "Nº "+numeroNota(ide.NumNf), "B", tamanhoValor, "C")
+	d.texto(x+160, y+12, 40, 4, "SÉRIE "+serie(ide.Serie), "B", tamanhoValor, "C")
+
+	// Linha pontilhada de corte
+	d.pdf.SetDashPattern([]float64{1, 1}, 0)
+	d.pdf.Line(margem, y+20, margem+larguraUtil, y+20)
+	d.pdf.SetDashPattern([]float64{}, 0)
+
+	d.y = y + 22
+}
+
+// cabecalho desenha emitente, quadro DANFE, código de barras da chave e
+// os dados de natureza da operação, protocolo e inscrições do emitente
+func (d *documento) cabecalho() {
+	ide, emit := d.inf.Ide, d.inf.Emit
+	x, y := margem, d.y
+	const altura = 34.0
+
+	// Identificação do emitente
+	d.pdf.Rect(x, y, 80, altura, "D")
+	d.texto(x, y+1, 80, 3, "IDENTIFICAÇÃO DO EMITENTE", "", tamanhoRotulo, "L")
+	d.pdf.SetXY(x, y+6)
+	d.pdf.SetFont("Helvetica", "B", 10)
+	d.pdf.MultiCell(80, 4.5, d.tr(emit.XNome), "", "C", false)
+	d.pdf.SetX(x)
+	d.pdf.SetFont("Helvetica", "", 7)
+	for _, linha := range enderecoEmitente(emit.EnderEmit) {
+		d.pdf.SetX(x)
+		d.pdf.CellFormat(80, 3.3, d.tr(linha), "", 2, "C", false, 0, "")
+	}
+
+	// Quadro DANFE
+	d.pdf.Rect(x+80, y, 35, altura, "D")
+	d.texto(x+80, y+1.5, 35, 5, "DANFE", "B", 12, "C")
+	d.pdf.SetXY(x+80, y+7)
+	d.pdf.SetFont("Helvetica", "", 6.5)
+	d.pdf.MultiCell(35, 2.6, d.tr("Documento Auxiliar da Nota Fiscal Eletrônica"), "", "C", false)
+	d.texto(x+82, y+15, 22, 3, "0 - ENTRADA", "", 6.5, "L")
+	d.texto(x+82, y+18, 22, 3, "1 - SAÍDA", "", 6.5, "L")
+	d.pdf.Rect(x+106, y+15, 6, 6, "D")
+	d.texto(x+106, y+15.5, 6, 5, ide.TpNF, "B", 10, "C")
+	d.texto(x+80, y+22, 35, 3.5, "Nº "+numeroNota(ide.NumNf), "B", tamanhoValor, "C")
+	d.texto(x+80, y+25.5, 35, 3.5, "SÉRIE "+serie(ide.Serie), "B", tamanhoValor, "C")
+	d.pdf.SetXY(x+80, y+29)
+	d.pdf.SetFont("Helvetica", "B", tamanhoValor)
+	d.pdf.CellFormat(35, 3.5, d.tr("FOLHA "+strconv.Itoa(d.pdf.PageNo())+"/{nb}"), "", 0, "C", false, 0, "")
+
+	// Código de barras e chave de acesso
+	d.pdf.Rect(x+115, y, 85, 14, "D")
+	d.codigoBarras(x+118, y+2, 79, 10)
+	d.campo(x+115, y+14, 85, alturaCampo, "CHAVE DE ACESSO", formatarChave(d.chave), "C")
+	d.pdf.Rect(x+115, y+14+alturaCampo, 85, altura-14-alturaCampo, "D")
+	d.pdf.SetXY(x+115, y+14+alturaCampo+2)
+	d.pdf.SetFont("Helvetica", "", 7)
+	d.pdf.MultiCell(85, 3, d.tr("Consulta de autenticidade no portal nacional da NF-e www.nfe.fazenda.gov.br/portal ou no site da Sefaz Autorizadora"), "", "C", false)
+
+	y += altura
+	prot := d.proc.ProtNFe.InfProt
+	d.campo(x, y, 115, alturaCampo, "NATUREZA DA OPERAÇÃO", ide.NatOp, "L")
+	d.campo(x+115, y, 85, alturaCampo, "PROTOCOLO DE AUTORIZAÇÃO DE USO", prot.NProt+" - "+dataHora(prot.DhRecbto), "C")
+
+	y += alturaCampo
+	d.campo(x, y, 67, alturaCampo, "INSCRIÇÃO ESTADUAL", emit.IE, "L")
+	d.campo(x+67, y, 67, alturaCampo, "INSCRIÇÃO ESTADUAL DO SUBST. TRIB.", emit.IEST, "L")
+	d.campo(x+134, y, 66, alturaCampo, "CNPJ / CPF", documentoFiscal(emit.CNPJ, emit.CPF), "L")
+
+	d.y = y + alturaCampo
+}
+
+// destinatario desenha o quadro do destinatário/remetente
+func (d *documento) destinatario() {
+	ide, dest := d.inf.Ide, d.inf.Dest
+	end := dest.EnderDest
+	x := margem
+	y := d.secao("DESTINATÁRIO / REMETENTE")
+
+	d.campo(x, y, 120, alturaCampo, "NOME / RAZÃO SOCIAL", dest.XNome, "L")
+	d.campo(x+120, y, 45, alturaCampo, "CNPJ / CPF", documentoFiscal(dest.CNPJ, dest.CPF), "L")
+	d.campo(x+165, y, 35, alturaCampo, "DATA DA EMISSÃO", data(ide.DhEmi), "C")
+
+	y += alturaCampo
+	d.campo(x, y, 95, alturaCampo, "ENDEREÇO", juntar(", ", end.XLgr, end.Nro, end.XCpl), "L")
+	d.campo(x+95, y, 45, alturaCampo, "BAIRRO / DISTRITO", end.XBairro, "L")
+	d.campo(x+140, y, 25, alturaCampo, "CEP", cep(end.CEP), "C")
+	d.campo(x+165, y, 35, alturaCampo, "DATA DA SAÍDA/ENTRADA", data(ide.DhSaiEnt), "C")
+
+	y += alturaCampo
+	d.campo(x, y, 75, alturaCampo, "MUNICÍPIO", end.XMun, "L")
+	d.campo(x+75, y, 10, alturaCampo, "UF", end.UF, "C")
+	d.campo(x+85, y, 35, alturaCampo, "FONE / FAX", end.Fone, "L")
+	d.campo(x+120, y, 45, alturaCampo, "INSCRIÇÃO ESTADUAL", dest.IE, "L")
+	d.campo(x+165, y, 35, alturaCampo, "HORA DA SAÍDA/ENTRADA", hora(ide.DhSaiEnt), "C")
+
+	d.y = y + alturaCampo
+}
+
+// fatura desenha as duplicatas (quando a nota tem cobrança)
+func (d *documento) fatura() {
+	if d.inf.Cobr == nil || len(d.inf.Cobr.Dup) == 0 {
+		return
+	}
+	y := d.secao("FATURA / DUPLICATAS")
+
+	const porLinha = 6
+	const largura = larguraUtil / porLinha
+	for i, dup := range d.inf.Cobr.Dup {
+		if i > 0 && i%porLinha == 0 {
+			y += alturaCampo
+		}
+		x := margem + float64(i%porLinha)*largura
+		d.pdf.Rect(x, y, largura, alturaCampo, "D")
+		d.texto(x, y+0.5, largura, 3, "Nº "+dup.NDup+"  Venc. "+data(dup.DVenc), "", tamanhoRotulo, "L")
+		d.texto(x, y+3.5, largura, 3, "R$ "+moeda(dup.VDup), "B", 7, "R")
+	}
+
+	d.y = y + alturaCampo
+}
+
+// impostos desenha o quadro de cálculo do imposto
+func (d *documento) impostos() {
+	tot := d.inf.Total.ICMSTot
+	y := d.secao("CÁLCULO DO IMPOSTO")
+
+	linhas := [][][2]string{
+		{
+			{"BASE DE CÁLC. DO ICMS", tot.VBC},
+			{"VALOR DO ICMS", tot.VICMS},
+			{"BASE DE CÁLC. ICMS S.T.", tot.VBCST},
+			{"VALOR DO ICMS SUBST.", tot.VST},
+			{"V. APROX. DOS TRIBUTOS", tot.VTotTrib},
+			{"V. TOTAL DOS PRODUTOS", tot.VProd},
+		},
+		{
+			{"VALOR DO FRETE", tot.VFrete},
+			{"VALOR DO SEGURO", tot.VSeg},
+			{"DESCONTO", tot.VDesc},
+			{"OUTRAS DESPESAS", tot.VOutro},
+			{"VALOR TOTAL DO IPI", tot.VIPI},
+			{"V. TOTAL DA NOTA", tot.VNF},
+		},
+	}
+
+	for _, linha := range linhas {
+		largura := larguraUtil / float64(len(linha))
+		for i, c := range linha {
+			d.campo(margem+float64(i)*largura, y, largura, alturaCampo, c[0], moeda(c[1]), "R")
+		}
+		y += alturaCampo
+	}
+
+	d.y = y
+}
+
+// transportador desenha o quadro de transportador/volumes
+func (d *documento) transportador() {
+	transp := d.inf.Transp
+	x := margem
+	y := d.secao("TRANSPORTADOR / VOLUMES TRANSPORTADOS")
+
+	var t nfe.Transporta
+	if transp.Transporta != nil {
+		t = *transp.Transporta
+	}
+	frete := modalidadesFrete[transp.ModFrete]
+	if frete == "" {
+		frete = transp.ModFrete
+	}
+
+	d.campo(x, y, 75, alturaCampo, "NOME / RAZÃO SOCIAL", t.XNome, "L")
+	d.campo(x+75, y, 40, alturaCampo, "FRETE POR CONTA", frete, "L")
+	d.campo(x+115, y, 35, alturaCampo, "CNPJ / CPF", documentoFiscal(t.CNPJ, t.CPF), "L")
+	d.campo(x+150, y, 40, alturaCampo, "MUNICÍPIO", t.XMun, "L")
+	d.campo(x+190, y, 10, alturaCampo, "UF", t.UF, "C")
+
+	d.y = y + alturaCampo
+}
+
+// colunaItem descreve uma coluna da tabela de produtos
+type colunaItem struct {
+	titulo  string
+	largura float64
+	alinh   string
+}
+
+// colunasItens são as colunas da tabela de produtos (somam larguraUtil)
+var colunasItens = []colunaItem{
+	{"CÓDIGO", 17, "L"},
+	{"DESCRIÇÃO DO PRODUTO / SERVIÇO", 47, "L"},
+	{"NCM/SH", 13, "C"},
+	{"CST", 8, "C"},
+	{"CFOP", 9, "C"},
+	{"UN", 9, "C"},
+	{"QUANT.", 14, "R"},
+	{"V. UNIT.", 16, "R"},
+	{"V. TOTAL", 16, "R"},
+	{"BC ICMS", 14, "R"},
+	{"V. ICMS", 12, "R"},
+	{"V. IPI", 11, "R"},
+	{"ALÍQ. ICMS", 7, "R"},
+	{"ALÍQ. IPI", 7, "R"},
+}
+
+// itens desenha a tabela de produtos, abrindo novas folhas quando necessário
+//
+// A primeira folha reserva o pé para os dados adicionais; as demais repetem
+// o cabeçalho do emitente antes de continuar a tabela.
+func (d *documento) itens() {
+	limite := alturaPagina - margem - alturaDadosAdicionais
+	d.cabecalhoItens(colunasItens)
+
+	for _, det := range d.inf.Det {
+		descricao, altura := d.medirItem(det, colunasItens)
+		if d.y+altura > limite {
+			d.fecharTabela(colunasItens, limite)
+			d.novaFolha()
+			d.cabecalho()
+			d.cabecalhoItens(colunasItens)
+			limite = alturaPagina - margem
+		}
+		d.linhaItem(colunasItens, valoresItem(det), descricao, altura)
+	}
+
+	d.fecharTabela(colunasItens, limite)
+}
+
+// medirItem quebra a descrição do item (segunda coluna) em linhas e retorna a altura da linha da tabela
+func (d *documento) medirItem(det nfe.Det, colunas []colunaItem) ([]string, float64) {
+	d.pdf.SetFont("Helvetica", "", tamanhoItem)
+	descricao := d.pdf.SplitText(d.tr(det.Prod.XProd), colunas[1].largura)
+	return descricao, float64(max(len(descricao), 1))*alturaLinhaItem + 0.8
+}
+
+// linhaItem escreve os valores de um item nas colunas, com a descrição já quebrada por medirItem
+func (d *documento) linhaItem(colunas []colunaItem, valores, descricao []string, altura float64) {
+	x := margem
+	for i, col := range colunas {
+		if i == 1 {
+			for l, linha := range descricao {
+				d.pdf.SetXY(x, d.y+0.4+float64(l)*alturaLinhaItem)
+				d.pdf.CellFormat(col.largura, alturaLinhaItem, linha, "", 0, col.alinh, false, 0, "")
+			}
+		} else {
+			d.pdf.SetXY(x, d.y+0.4)
+			d.pdf.CellFormat(col.largura, alturaLinhaItem, d.tr(valores[i]), "", 0, col.alinh, false, 0, "")
+		}
+		x += col.largura
+	}
+	d.y += altura
+}
+
+// cabecalhoItens desenha o título da seção e a linha de títulos das colunas
+func (d *documento) cabecalhoItens(colunas []colunaItem) {
+	y := d.secao("DADOS DOS PRODUTOS / SERVIÇOS")
+	d.pdf.SetFont("Helvetica", "B", tamanhoRotulo)
+	x := margem
+	for _, col := range colunas {
+		d.pdf.Rect(x, y, col.largura, 5, "D")
+		d.pdf.SetXY(x, y)
+		d.pdf.CellFormat(col.largura, 5, d.tr(col.titulo), "", 0, "C", false, 0, "")
+		x += col.largura
+	}
+	d.y = y + 5
+	d.inicioTabela = d.y
+}
+
+// fecharTabela desenha as bordas verticais das colunas até o limite da folha
+func (d *documento) fecharTabela(colunas []colunaItem, limite float64) {
+	x := margem
+	for _, col := range colunas {
+		d.pdf.Rect(x, d.inicioTabela, col.largura, limite-d.inicioTabela, "D")
+		x += col.largura
+	}
+}
+
+// valoresItem formata as colunas de um item na ordem de colunasItens
+func valoresItem(det nfe.Det) []string {
+	var icms, ipi nfe.GrupoTributo
+	if len(det.Imposto.ICMS.Grupos) > 0 {
+		icms = det.Imposto.ICMS.Grupos[0]
+	}
+	for _, g := range det.Imposto.IPI.Grupos {
+		if g.XMLName.Local == "IPITrib" {
+			ipi = g
+		}
+	}
+
+	cst := icms.CST
+	if cst == "" {
+		cst = icms.CSOSN
+	}
+
+	p := det.Prod
+	return []string{
+		p.CProd, p.XProd, p.NCM, icms.Orig + cst, p.CFOP, p.UCom,
+		decimal(p.QCom, 4), decimal(p.VUnCom, 2), moeda(p.VProd),
+		moeda(icms.VBC), moeda(icms.VICMS), moeda(ipi.VIPI),
+		decimal(icms.PICMS, 2), decimal(ipi.PIPI, 2),
+	}
+}
+
+// dadosAdicionais desenha o quadro de informações complementares no pé da primeira folha
+func (d *documento) dadosAdicionais() {
+	d.pdf.SetPage(1)
+	y := alturaPagina - margem - alturaDadosAdicionais
+	d.texto(margem, y, larguraUtil, 4, "DADOS ADICIONAIS", "B", 6.5, "L")
+	y += 4
+	altura := alturaDadosAdicionais - 4
+
+	d.campo(margem, y, 140, altura, "INFORMAÇÕES COMPLEMENTARES", "", "L")
+	d.pdf.SetXY(margem, y+3.5)
+	d.pdf.SetFont("Helvetica", "", tamanhoItem)
+	d.pdf.MultiCell(140, 2.8, d.tr(d.informacoesComplementares()), "", "L", false)
+	d.campo(margem+140, y, 60, altura, "RESERVADO AO FISCO", "", "L")
+
+	// O fpdf só grava até a página corrente: volta para a última folha
+	d.pdf.SetPage(d.pdf.PageCount())
+}
+
+// informacoesComplementares junta o aviso de homologação e as informações adicionais da nota
+func (d *documento) informacoesComplementares() string {
+	var info []string
+	if adic := d.inf.InfAdic; adic != nil {
+		info = append(info, adic.InfAdFisco, adic.InfCpl)
+	}
+	if d.inf.Ide.TpAmb == "2" {
+		info = append([]string{"NF-E EMITIDA EM AMBIENTE DE HOMOLOGAÇÃO - SEM VALOR FISCAL"}, info...)
+	}
+	return juntar(" ", info...)
+}
+
+// secao escreve o título de uma seção e retorna o Y onde seus quadros começam
+func (d *documento) secao(titulo string) float64 {
+	d.texto(margem, d.y+0.5, d.largura-2*margem, 3.5, titulo, "B", 6.5, "L")
+	return d.y + 4
+}
+
+// campo desenha um quadro com rótulo pequeno no topo e o valor abaixo
+func (d *documento) campo(x, y, w, h float64, rotulo, valor, alinh string) {
+	d.pdf.Rect(x, y, w, h, "D")
+	d.texto(x, y+0.3, w, 2.5, rotulo, "", tamanhoRotulo, "L")
+	if valor != "" {
+		d.texto(x, y+3, w, 3.5, valor, "", tamanhoValor, alinh)
+	}
+}
+
+// texto escreve uma linha de texto em uma célula sem borda
+func (d *documento) texto(x, y, w, h float64, s, estilo string, tamanho float64, alinh string) {
+	d.pdf.SetXY(x, y)
+	d.pdf.SetFont("Helvetica", estilo, tamanho)
+	d.pdf.CellFormat(w, h, d.tr(s), "", 0, alinh, false, 0, "")
+}
+
+// codigoBarras desenha a chave de acesso em Code 128C
+func (d *documento) codigoBarras(x, y, w, h float64) {
+	bc, err := code128.Encode(d.chave)
+	if err != nil {
+		return
+	}
+	modulos := bc.Bounds().Dx()
+	largura := w / float64(modulos)
+
+	d.pdf.SetFillColor(0, 0, 0)
+	for i := 0; i < modulos; i++ {
+		if r, _, _, _ := bc.At(i, 0).RGBA(); r == 0 {
+			d.pdf.Rect(x+float64(i)*largura, y, largura, h, "F")
+		}
+	}
+}
+
+// enderecoEmitente monta as linhas de endereço do quadro do emitente
+func enderecoEmitente(e nfe.Endereco) []string {
+	linhas := []string{
+		juntar(", ", e.XLgr, e.Nro, e.XCpl),
+		juntar(" - ", e.XBairro, cep(e.CEP)),
+		juntar(" - ", e.XMun, e.UF),
+	}
+	if e.Fone != "" {
+		linhas = append(linhas, "Fone: "+e.Fone)
+	}
+	return linhas
+}
+
+// juntar une as partes não vazias com o separador
+func juntar(sep string, partes ...string) string {
+	var preenchidas []string
+	for _, p := range partes {
+		if p = strings.TrimSpace(p); p != "" {
+			preenchidas = append(preenchidas, p)
+		}
+	}
+	return strings.Join(preenchidas, sep)
+}
+
+// formatarChave separa a chave em grupos de 4 dígitos para leitura
+func formatarChave(chave string) string {
+	var grupos []string
+	for i := 0; i < len(chave); i += 4 {
+		grupos = append(grupos, chave[i:min(i+4, len(chave))])
+	}
+	return strings.Join(grupos, " ")
+}
+
+// numeroNota formata o nNF com 9 dígitos em grupos de 3 ("3747" -> "000.003.747")
+func numeroNota(n string) string {
+	if len(n) > 9 {
+		return n
+	}
+	n = strings.Repeat("0", 9-len(n)) + n
+	return n[0:3] + "." + n[3:6] + "." + n[6:9]
+}
+
+// serie formata a série com 3 dígitos ("1" -> "001")
+func serie(s string) string {
+	if len(s) >= 3 {
+		return s
+	}
+	return strings.Repeat("0", 3-len(s)) + s
+}
+
+// documentoFiscal formata CNPJ ou CPF (o que estiver preenchido)
+func documentoFiscal(cnpj, cpf string) string {
+	if len(cnpj) == 14 {
+		return cnpj[0:2] + "." + cnpj[2:5] + "." + cnpj[5:8] + "/" + cnpj[8:12] + "-" + cnpj[12:14]
+	}
+	if len(cpf) == 11 {
+		return cpf[0:3] + "." + cpf[3:6] + "." + cpf[6:9] + "-" + cpf[9:11]
+	}
+	return juntar("", cnpj, cpf)
+}
+
+// cep formata o CEP ("01001000" -> "01001-000")
+func cep(c string) string {
+	if len(c) != 8 {
+		return c
+	}
+	return c[0:5] + "-" + c[5:8]
+}
+
+// data formata data ou data/hora da NF-e como dd/mm/aaaa (vazio se ausente)
+func data(s string) string {
+	if t, ok := parseData(s); ok {
+		return t.Format("02/01/2006")
+	}
+	return s
+}
+
+// hora formata a hora de uma data/hora da NF-e como hh:mm:ss
+func hora(s string) string {
+	if t, ok := parseData(s); ok && strings.Contains(s, "T") {
+		return t.Format("15:04:05")
+	}
+	return ""
+}
+
+// dataHora formata a data/hora do protocolo como dd/mm/aaaa hh:mm:ss
+func dataHora(s string) string {
+	if t, ok := parseData(s); ok {
+		return t.Format("02/01/2006 15:04:05")
+	}
+	return s
+}
+
+// parseData aceita data/hora com fuso (dhEmi, dhRecbto) e datas simples (dVenc)
+func parseData(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// moeda formata um valor do XML com 2 casas no padrão brasileiro ("1234.5" -> "1.234,50")
+func moeda(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "0,00"
+	}
+	return decimal(s, 2)
+}
+
+// decimal formata um número do XML com a quantidade de casas informada e separadores brasileiros
+//
+// Valores vazios continuam vazios; valores que não são números são devolvidos como estão.
+func decimal(s string, casas int) string {
+	s = strings.TrimSpace(s)
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return s
+	}
+
+	texto := strconv.FormatFloat(v, 'f', casas, 64)
+	inteiro, fracao, _ := strings.Cut(texto, ".")
+	sinal := ""
+	if strings.HasPrefix(inteiro, "-") {
+		sinal, inteiro = "-", inteiro[1:]
+	}
+
+	var b strings.Builder
+	for i, c := range inteiro {
+		if i > 0 && (len(inteiro)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	if fracao != "" {
+		return sinal + b.String() + "," + fracao
+	}
+	return sinal + b.String()
+}
@@ -0,0 +1,61 @@
+package danfe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layout é o formato do DANFE gerado (ver WithLayout)
+type Layout string
+
+const (
+	// LayoutCompleto: A4 retrato, com canhoto, impostos, transportador e todos os itens (padrão)
+	LayoutCompleto Layout = "completo"
+	// LayoutSimplificado: DANFE Simplificado em A5 retrato, com destinatário, itens e valor total
+	LayoutSimplificado Layout = "simplificado"
+	// LayoutEtiqueta: DANFE Simplificado - Etiqueta de 100 × 150 mm, para as impressoras térmicas da expedição
+	LayoutEtiqueta Layout = "etiqueta"
+)
+
+// Layouts são os layouts disponíveis, na ordem em que aparecem nas mensagens
+var Layouts = []Layout{LayoutCompleto, LayoutSimplificado, LayoutEtiqueta}
+
+// ParseLayout converte o nome do layout (ex: de uma flag) em Layout; vazio é LayoutCompleto
+func ParseLayout(nome string) (Layout, error) {
+	l := Layout(strings.ToLower(strings.TrimSpace(nome)))
+	if l == "" {
+		return LayoutCompleto, nil
+	}
+	if err := l.validar(); err != nil {
+		return "", err
+	}
+	return l, nil
+}
+
+// validar confere se o layout é um dos Layouts
+func (l Layout) validar() error {
+	switch l {
+	case LayoutCompleto, LayoutSimplificado, LayoutEtiqueta:
+		return nil
+	}
+	return fmt.Errorf("layout '%s' desconhecido (use %s, %s ou %s)", string(l), LayoutCompleto, LayoutSimplificado, LayoutEtiqueta)
+}
+
+// Opcao ajusta a geração do DANFE em Gerar e GerarXML
+//
+// Exemplo:
+//
+//	err := danfe.Gerar(w, proc, danfe.WithLayout(danfe.LayoutEtiqueta))
+type Opcao func(*opcoesDanfe)
+
+// opcoesDanfe reúne o que as Opcao configuram
+type opcoesDanfe struct {
+	layout Layout
+}
+
+// WithLayout escolhe o layout do DANFE (padrão LayoutCompleto)
+func WithLayout(l Layout) Opcao {
+	return func(o *opcoesDanfe) {
+		o.layout = l
+	}
+}
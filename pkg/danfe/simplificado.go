@@ -0,0 +1,217 @@
+package danfe
+
+import (
+	"fmt"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// Títulos dos layouts simplificados
+const (
+	tituloSimplificado = "DANFE SIMPLIFICADO"
+	tituloEtiqueta     = "DANFE SIMPLIFICADO - ETIQUETA"
+)
+
+// alturaInformacoes é a altura do quadro de informações complementares do DANFE Simplificado
+const alturaInformacoes = 18.0
+
+// colunasSimplificado são as colunas da tabela de produtos do DANFE Simplificado (somam a largura útil do A5)
+var colunasSimplificado = []colunaItem{
+	{"CÓDIGO", 20, "L"},
+	{"DESCRIÇÃO DO PRODUTO / SERVIÇO", 60, "L"},
+	{"QUANT.", 16, "R"},
+	{"UN", 8, "C"},
+	{"V. UNIT.", 16, "R"},
+	{"V. TOTAL", 18, "R"},
+}
+
+// desenharSimplificado monta o DANFE Simplificado: identificação, destinatário, itens e totais
+func (d *documento) desenharSimplificado() {
+	d.novaFolha()
+	d.cabecalhoSimplificado(tituloSimplificado)
+	d.destinatarioSimplificado()
+	d.itensSimplificado()
+	d.totaisSimplificado()
+}
+
+// desenharEtiqueta monta a etiqueta de uma folha: identificação, destinatário e transporte, sem itens
+func (d *documento) desenharEtiqueta() {
+	d.novaFolha()
+	d.cabecalhoSimplificado(tituloEtiqueta)
+	d.destinatarioEtiqueta()
+	d.transporteEtiqueta()
+}
+
+// cabecalhoSimplificado desenha emitente, identificação da nota, código de barras,
+// chave de acesso e protocolo na largura da folha
+func (d *documento) cabecalhoSimplificado(titulo string) {
+	ide, emit := d.inf.Ide, d.inf.Emit
+	end := emit.EnderEmit
+	x, y, u := margem, d.y, d.largura-2*margem
+
+	d.pdf.SetXY(x, y)
+	d.pdf.SetFont("Helvetica", "B", 9)
+	d.pdf.MultiCell(u, 4, d.tr(emit.XNome), "", "C", false)
+	d.pdf.SetFont("Helvetica", "", 6.5)
+	for _, linha := range []string{
+		juntar("   ", "CNPJ/CPF: "+documentoFiscal(emit.CNPJ, emit.CPF), "IE: "+emit.IE),
+		juntar(" - ", juntar(", ", end.XLgr, end.Nro), end.XMun, end.UF),
+	} {
+		d.pdf.SetX(x)
+		d.pdf.CellFormat(u, 3, d.tr(linha), "", 2, "C", false, 0, "")
+	}
+	y = d.pdf.GetY() + 1
+
+	d.pdf.Rect(x, y, u, 11, "D")
+	d.texto(x, y+0.5, u, 4.5, titulo, "B", 9, "C")
+	identificacao := fmt.Sprintf("%s   Nº %s   SÉRIE %s   FOLHA %d/{nb}",
+		tipoOperacao(ide.TpNF), numeroNota(ide.NumNf), serie(ide.Serie), d.pdf.PageNo())
+	d.texto(x, y+5.5, u, 4.5, identificacao, "B", 7, "C")
+	y += 11
+
+	d.pdf.Rect(x, y, u, 14, "D")
+	d.codigoBarras(x+3, y+2, u-6, 10)
+	y += 14
+	d.campo(x, y, u, alturaCampo, "CHAVE DE ACESSO", formatarChave(d.chave), "C")
+	y += alturaCampo
+
+	prot := d.proc.ProtNFe.InfProt
+	d.campo(x, y, u, alturaCampo, "PROTOCOLO DE AUTORIZAÇÃO DE USO", prot.NProt+" - "+dataHora(prot.DhRecbto), "C")
+	y += alturaCampo
+	d.campo(x, y, u*0.65, alturaCampo, "NATUREZA DA OPERAÇÃO", ide.NatOp, "L")
+	d.campo(x+u*0.65, y, u*0.35, alturaCampo, "DATA DA EMISSÃO", data(ide.DhEmi), "C")
+
+	d.y = y + alturaCampo
+}
+
+// destinatarioSimplificado desenha o quadro do destinatário em duas linhas
+func (d *documento) destinatarioSimplificado() {
+	dest := d.inf.Dest
+	end := dest.EnderDest
+	x, u := margem, d.largura-2*margem
+	y := d.secao("DESTINATÁRIO / REMETENTE")
+
+	d.campo(x, y, u*0.65, alturaCampo, "NOME / RAZÃO SOCIAL", dest.XNome, "L")
+	d.campo(x+u*0.65, y, u*0.35, alturaCampo, "CNPJ / CPF", documentoFiscal(dest.CNPJ, dest.CPF), "L")
+	y += alturaCampo
+	d.campo(x, y, u*0.65, alturaCampo, "ENDEREÇO", juntar(", ", end.XLgr, end.Nro, end.XCpl, end.XBairro), "L")
+	d.campo(x+u*0.65, y, u*0.35, alturaCampo, "MUNICÍPIO / UF", juntar(" - ", end.XMun, end.UF), "L")
+
+	d.y = y + alturaCampo
+}
+
+// itensSimplificado desenha a tabela de produtos do DANFE Simplificado, que termina na última linha
+//
+// As folhas seguintes repetem o cabeçalho simplificado antes de continuar a tabela.
+func (d *documento) itensSimplificado() {
+	limite := d.altura - margem
+	d.cabecalhoItens(colunasSimplificado)
+
+	for _, det := range d.inf.Det {
+		descricao, altura := d.medirItem(det, colunasSimplificado)
+		if d.y+altura > limite {
+			d.fecharTabela(colunasSimplificado, limite)
+			d.novaFolha()
+			d.cabecalhoSimplificado(tituloSimplificado)
+			d.cabecalhoItens(colunasSimplificado)
+		}
+		d.linhaItem(colunasSimplificado, valoresItemSimplificado(det), descricao, altura)
+	}
+
+	d.fecharTabela(colunasSimplificado, d.y)
+}
+
+// valoresItemSimplificado formata as colunas de um item na ordem de colunasSimplificado
+func valoresItemSimplificado(det nfe.Det) []string {
+	p := det.Prod
+	return []string{p.CProd, p.XProd, decimal(p.QCom, 4), p.UCom, decimal(p.VUnCom, 2), moeda(p.VProd)}
+}
+
+// totaisSimplificado desenha os totais e as informações complementares, em nova folha se não couberem
+func (d *documento) totaisSimplificado() {
+	if d.y+4+alturaCampo+alturaInformacoes > d.altura-margem {
+		d.novaFolha()
+		d.cabecalhoSimplificado(tituloSimplificado)
+	}
+
+	tot := d.inf.Total.ICMSTot
+	u := d.largura - 2*margem
+	y := d.secao("TOTAIS")
+
+	campos := [][2]string{
+		{"V. TOTAL DOS PRODUTOS", tot.VProd},
+		{"VALOR DO FRETE", tot.VFrete},
+		{"DESCONTO", tot.VDesc},
+		{"V. TOTAL DA NOTA", tot.VNF},
+	}
+	largura := u / float64(len(campos))
+	for i, c := range campos {
+		d.campo(margem+float64(i)*largura, y, largura, alturaCampo, c[0], moeda(c[1]), "R")
+	}
+	y += alturaCampo
+
+	d.campo(margem, y, u, alturaInformacoes, "INFORMAÇÕES COMPLEMENTARES", "", "L")
+	d.pdf.SetXY(margem, y+3.5)
+	d.pdf.SetFont("Helvetica", "", tamanhoItem)
+	d.pdf.MultiCell(u, 2.8, d.tr(d.informacoesComplementares()), "", "L", false)
+
+	d.y = y + alturaInformacoes
+}
+
+// destinatarioEtiqueta desenha o destinatário com o endereço completo, um campo por linha
+func (d *documento) destinatarioEtiqueta() {
+	dest := d.inf.Dest
+	end := dest.EnderDest
+	x, u := margem, d.largura-2*margem
+	y := d.secao("DESTINATÁRIO")
+
+	d.campo(x, y, u, alturaCampo, "NOME / RAZÃO SOCIAL", dest.XNome, "L")
+	y += alturaCampo
+	d.campo(x, y, u*0.55, alturaCampo, "CNPJ / CPF", documentoFiscal(dest.CNPJ, dest.CPF), "L")
+	d.campo(x+u*0.55, y, u*0.45, alturaCampo, "INSCRIÇÃO ESTADUAL", dest.IE, "L")
+	y += alturaCampo
+	d.campo(x, y, u, alturaCampo, "ENDEREÇO", juntar(", ", end.XLgr, end.Nro, end.XCpl), "L")
+	y += alturaCampo
+	d.campo(x, y, u*0.65, alturaCampo, "BAIRRO / DISTRITO", end.XBairro, "L")
+	d.campo(x+u*0.65, y, u*0.35, alturaCampo, "CEP", cep(end.CEP), "C")
+	y += alturaCampo
+	d.campo(x, y, u*0.8, alturaCampo, "MUNICÍPIO", end.XMun, "L")
+	d.campo(x+u*0.8, y, u*0.2, alturaCampo, "UF", end.UF, "C")
+
+	d.y = y + alturaCampo
+}
+
+// transporteEtiqueta desenha transportador, modalidade do frete e valor total da nota
+func (d *documento) transporteEtiqueta() {
+	transp := d.inf.Transp
+	x, u := margem, d.largura-2*margem
+	y := d.secao("TRANSPORTE")
+
+	var t nfe.Transporta
+	if transp.Transporta != nil {
+		t = *transp.Transporta
+	}
+	frete := modalidadesFrete[transp.ModFrete]
+	if frete == "" {
+		frete = transp.ModFrete
+	}
+
+	d.campo(x, y, u*0.55, alturaCampo, "TRANSPORTADOR", t.XNome, "L")
+	d.campo(x+u*0.55, y, u*0.45, alturaCampo, "FRETE POR CONTA", frete, "L")
+	y += alturaCampo
+	d.campo(x, y, u*0.55, alturaCampo, "QUANTIDADE DE ITENS", fmt.Sprint(len(d.inf.Det)), "R")
+	d.campo(x+u*0.55, y, u*0.45, alturaCampo, "V. TOTAL DA NOTA", moeda(d.inf.Total.ICMSTot.VNF), "R")
+
+	d.y = y + alturaCampo
+}
+
+// tipoOperacao descreve o tpNF ("0 - ENTRADA", "1 - SAÍDA")
+func tipoOperacao(tpNF string) string {
+	switch tpNF {
+	case "0":
+		return "0 - ENTRADA"
+	case "1":
+		return "1 - SAÍDA"
+	}
+	return tpNF
+}
@@ -0,0 +1,70 @@
+package nfe
+
+import (
+	"context"
+	"fmt"
+)
+
+// Ambiente é o ambiente da SEFAZ consultado: produção (tpAmb 1) ou homologação (tpAmb 2)
+//
+// O zero (AmbientePadrao) é o ambiente de Config.Env. Informado por chamada
+// (ValidarChave, Options.Ambiente ou ComAmbiente), permite consultar os dois
+// ambientes com o mesmo Client e o mesmo certificado.
+type Ambiente string
+
+const (
+	// AmbientePadrao usa o ambiente de Config.Env
+	AmbientePadrao Ambiente = ""
+	// Producao: ambiente de produção (tpAmb 1)
+	Producao Ambiente = "producao"
+	// Homologacao: ambiente de homologação (tpAmb 2)
+	Homologacao Ambiente = "homologacao"
+)
+
+// chaveAmbiente guarda o Ambiente no contexto (ver ComAmbiente)
+type chaveAmbiente struct{}
+
+// ComAmbiente devolve um contexto em que as consultas à SEFAZ usam o ambiente informado
+//
+// Vale para os métodos *Context do Client; AmbientePadrao devolve ctx sem
+// alteração.
+//
+// Exemplo:
+//
+//	ctx := nfe.ComAmbiente(r.Context(), nfe.Homologacao)
+//	result, err := client.ValidarXMLBytesContext(ctx, xmlData, xsd)
+func ComAmbiente(ctx context.Context, a Ambiente) context.Context {
+	if a == AmbientePadrao {
+		return ctx
+	}
+	return context.WithValue(ctx, chaveAmbiente{}, a)
+}
+
+// AmbienteDe devolve o ambiente pedido no contexto (AmbientePadrao se nenhum)
+//
+// Durante a consulta, o Client já resolveu o padrão: um Consulter próprio
+// recebe sempre Producao ou Homologacao.
+func AmbienteDe(ctx context.Context) Ambiente {
+	a, _ := ctx.Value(chaveAmbiente{}).(Ambiente)
+	return a
+}
+
+// validar confere se o ambiente é um dos conhecidos
+func (a Ambiente) validar() error {
+	switch a {
+	case AmbientePadrao, Producao, Homologacao:
+		return nil
+	}
+	return fmt.Errorf("ambiente '%s' desconhecido (use nfe.Producao ou nfe.Homologacao)", string(a))
+}
+
+// ambiente resolve o ambiente da consulta: o do contexto ou o de Config.Env
+func (c *Client) ambiente(ctx context.Context) Ambiente {
+	if a := AmbienteDe(ctx); a != AmbientePadrao {
+		return a
+	}
+	if c.cfg.Producao() {
+		return Producao
+	}
+	return Homologacao
+}
@@ -0,0 +1,177 @@
+package nfe
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// RegraAssinatura é o ID da conferência de assinatura (habilitável como as demais regras)
+//
+// Não faz parte de RegrasPadrao porque precisa do XML original, não da
+// nota parseada: use VerificarAssinatura.
+const RegraAssinatura = "assinatura"
+
+// Rejeições da SEFAZ correspondentes às falhas de assinatura
+const (
+	// RejeicaoCertificadoInvalido: Certificado Assinatura inválido (cStat 290)
+	RejeicaoCertificadoInvalido = "290"
+
+	// RejeicaoAssinaturaDifere: Assinatura difere do calculado (cStat 297)
+	RejeicaoAssinaturaDifere = "297"
+
+	// RejeicaoAssinaturaPadrao: Assinatura difere do padrão do Sistema (cStat 298)
+	RejeicaoAssinaturaPadrao = "298"
+)
+
+// Algoritmos aceitos na assinatura da NF-e (RSA-SHA1 com C14N inclusiva)
+const (
+	algC14N      = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	algRSASHA1   = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	algSHA1      = "http://www.w3.org/2000/09/xmldsig#sha1"
+	algEnveloped = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+)
+
+// assinaturaXML é o <Signature> (XML-DSig) da NF-e
+type assinaturaXML struct {
+	SignedInfo struct {
+		CanonicalizationMethod struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"CanonicalizationMethod"`
+		SignatureMethod struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"SignatureMethod"`
+		Reference struct {
+			URI        string `xml:"URI,attr"`
+			Transforms []struct {
+				Algorithm string `xml:"Algorithm,attr"`
+			} `xml:"Transforms>Transform"`
+			DigestMethod struct {
+				Algorithm string `xml:"Algorithm,attr"`
+			} `xml:"DigestMethod"`
+			DigestValue string `xml:"DigestValue"`
+		} `xml:"Reference"`
+	} `xml:"SignedInfo"`
+	SignatureValue  string `xml:"SignatureValue"`
+	X509Certificate string `xml:"KeyInfo>X509Data>X509Certificate"`
+}
+
+// VerificarAssinatura confere a assinatura digital (XML-DSig) do infNFe
+//
+// Aceita NFe ou nfeProc. Confere os algoritmos exigidos pela SEFAZ, a
+// referência ao Id do infNFe, o DigestValue (SHA-1 do infNFe canonizado) e
+// o SignatureValue com a chave pública do certificado do KeyInfo. A cadeia
+// ICP-Brasil e a validade do certificado não são conferidas (sem rede).
+//
+// Exemplo:
+//
+//	for _, inc := range nfe.VerificarAssinatura(xmlData) {
+//	    fmt.Printf("Assinatura: %s (cStat %s)\n", inc.Mensagem, inc.Codigo)
+//	}
+func VerificarAssinatura(xmlData []byte) []Inconsistencia {
+	falha := func(codigo, formato string, args ...any) []Inconsistencia {
+		return []Inconsistencia{{Codigo: codigo, Grupo: "Signature", Mensagem: fmt.Sprintf(formato, args...)}}
+	}
+
+	ass, err := localizarAssinatura(xmlData)
+	if err != nil {
+		return falha(RejeicaoAssinaturaPadrao, "%v", err)
+	}
+	si := ass.SignedInfo
+
+	if si.CanonicalizationMethod.Algorithm != algC14N || si.SignatureMethod.Algorithm != algRSASHA1 || si.Reference.DigestMethod.Algorithm != algSHA1 {
+		return falha(RejeicaoAssinaturaPadrao, "algoritmos da assinatura diferentes de RSA-SHA1/C14N")
+	}
+	for _, t := range si.Reference.Transforms {
+		if t.Algorithm != algEnveloped && t.Algorithm != algC14N {
+			return falha(RejeicaoAssinaturaPadrao, "transformação '%s' não suportada", t.Algorithm)
+		}
+	}
+
+	id := strings.TrimPrefix(si.Reference.URI, "#")
+	if !strings.HasPrefix(si.Reference.URI, "#") || !strings.HasPrefix(id, "NFe") {
+		return falha(RejeicaoAssinaturaPadrao, "referência '%s' não aponta para o infNFe", si.Reference.URI)
+	}
+
+	infNFe, err := canonizar(xmlData, func(t xml.StartElement) bool {
+		return t.Name.Local == "infNFe" && atributo(t, "Id") == id
+	})
+	if err != nil {
+		return falha(RejeicaoAssinaturaPadrao, "infNFe com Id '%s' referenciado pela assinatura: %v", id, err)
+	}
+
+	digest := sha1.Sum(infNFe)
+	if base64.StdEncoding.EncodeToString(digest[:]) != semEspacos(si.Reference.DigestValue) {
+		return falha(RejeicaoAssinaturaDifere, "DigestValue não confere: infNFe alterado depois de assinado")
+	}
+
+	certDER, err := base64.StdEncoding.DecodeString(semEspacos(ass.X509Certificate))
+	if err != nil {
+		return falha(RejeicaoCertificadoInvalido, "X509Certificate não está em base64: %v", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return falha(RejeicaoCertificadoInvalido, "X509Certificate inválido: %v", err)
+	}
+	chave, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return falha(RejeicaoCertificadoInvalido, "certificado sem chave RSA")
+	}
+
+	signedInfo, err := canonizar(xmlData, func(t xml.StartElement) bool {
+		return t.Name.Local == "SignedInfo"
+	})
+	if err != nil {
+		return falha(RejeicaoAssinaturaPadrao, "SignedInfo: %v", err)
+	}
+	valor, err := base64.StdEncoding.DecodeString(semEspacos(ass.SignatureValue))
+	if err != nil {
+		return falha(RejeicaoAssinaturaPadrao, "SignatureValue não está em base64: %v", err)
+	}
+
+	hash := sha1.Sum(signedInfo)
+	if err := rsa.VerifyPKCS1v15(chave, crypto.SHA1, hash[:], valor); err != nil {
+		return falha(RejeicaoAssinaturaDifere, "SignatureValue não confere com o certificado de %s", cert.Subject.CommonName)
+	}
+
+	return nil
+}
+
+// localizarAssinatura decodifica o primeiro <Signature> do XML (o da NFe; o do protNFe vem depois)
+func localizarAssinatura(xmlData []byte) (*assinaturaXML, error) {
+	dec := xml.NewDecoder(bytes.NewReader(xmlData))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("nota sem assinatura digital (Signature)")
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Space == nsXMLDSig && se.Name.Local == "Signature" {
+			var ass assinaturaXML
+			if err := dec.DecodeElement(&ass, &se); err != nil {
+				return nil, fmt.Errorf("Signature malformado: %w", err)
+			}
+			return &ass, nil
+		}
+	}
+}
+
+// atributo retorna o valor do atributo sem prefixo (vazio se ausente)
+func atributo(t xml.StartElement, nome string) string {
+	for _, a := range t.Attr {
+		if a.Name.Space == "" && a.Name.Local == nome {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// semEspacos remove quebras de linha e espaços (base64 costuma vir quebrado em linhas)
+func semEspacos(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
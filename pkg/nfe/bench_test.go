@@ -0,0 +1,226 @@
+package nfe_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+	"github.com/fabyo/go-nfe-validator/pkg/nfetest"
+)
+
+// Benchmarks do XSD, do parse e do pipeline (go test -run '^$' -bench . -benchmem ./pkg/nfe).
+// A linha de base fica em bench-base.txt e é conferida por ./bench.sh; as
+// alocações também são protegidas por TestAlocacoes, que roda com go test.
+
+// xsdBench é o schema usado nos benchmarks (relativo a pkg/nfe)
+const xsdBench = "../../schemas/v4/procNFe_v4.00.xsd"
+
+// notasBench gera n notas modelo 55 com 3 itens, sempre as mesmas
+func notasBench(n int) [][]byte {
+	g := nfetest.NewGerador(1)
+	emissao := time.Date(2025, 7, 10, 10, 0, 0, 0, time.UTC)
+	notas := make([][]byte, n)
+	for i := range notas {
+		notas[i] = g.Gerar(nfetest.ConfigGerador{Itens: 3, Emissao: emissao}).XML
+	}
+	return notas
+}
+
+// clienteBench cria um Client com a SEFAZ em memória (nfetest.FakeConsulter)
+func clienteBench(b testing.TB) *nfe.Client {
+	client, err := nfetest.NewFakeConsulter().Cliente()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return client
+}
+
+func BenchmarkValidarApenasXSD(b *testing.B) {
+	nota := notasBench(1)[0]
+	if err := nfe.ValidarApenasXSD(nota, xsdBench); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(nota)))
+	b.ReportAllocs()
+	for b.Loop() {
+		nfe.ValidarApenasXSD(nota, xsdBench)
+	}
+}
+
+// BenchmarkCarregarXSD mede a compilação do XSD que o CacheXSD evita
+func BenchmarkCarregarXSD(b *testing.B) {
+	cache := nfe.NewCacheXSD()
+	defer cache.Close()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := cache.Carregar(xsdBench); err != nil {
+			b.Fatal(err)
+		}
+		cache.Descartar(xsdBench)
+	}
+}
+
+func BenchmarkParseNFe(b *testing.B) {
+	nota := notasBench(1)[0]
+	b.SetBytes(int64(len(nota)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := nfe.ParseNFe(nota); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseNFeReader(b *testing.B) {
+	nota := notasBench(1)[0]
+	b.SetBytes(int64(len(nota)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := nfe.ParseNFeReader(bytes.NewReader(nota)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParsearXML(b *testing.B) {
+	nota := notasBench(1)[0]
+	b.SetBytes(int64(len(nota)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := nfe.ParsearXML(nota); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkValidar mede uma nota no pipeline completo de Client.Validar, por nível
+func BenchmarkValidar(b *testing.B) {
+	nota := notasBench(1)[0]
+	client := clienteBench(b)
+	for _, nivel := range []nfe.Nivel{nfe.NivelXSD, nfe.NivelParse, nfe.NivelCompleto} {
+		b.Run(nivel.String(), func(b *testing.B) {
+			o := nfe.Options{Nivel: nivel, XSD: xsdBench, Assinatura: nivel == nfe.NivelParse}
+			b.SetBytes(int64(len(nota)))
+			b.ReportAllocs()
+			for b.Loop() {
+				result, err := client.Validar(nota, o)
+				if err != nil || result.Erro != nil {
+					b.Fatal(err, result.Err())
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkValidarLote mede um lote de 64 arquivos em Client.ValidarLote (uma operação = o lote)
+func BenchmarkValidarLote(b *testing.B) {
+	dir := b.TempDir()
+	var arquivos []string
+	for i, nota := range notasBench(64) {
+		arquivo := filepath.Join(dir, strconv.Itoa(i)+".xml")
+		if err := os.WriteFile(arquivo, nota, 0o644); err != nil {
+			b.Fatal(err)
+		}
+		arquivos = append(arquivos, arquivo)
+	}
+	client := clienteBench(b)
+	o := nfe.Options{XSD: xsdBench}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, resumo, err := client.ValidarLote(arquivos, o); err != nil || resumo.Aprovadas != len(arquivos) {
+			b.Fatal(err, resumo)
+		}
+	}
+	b.ReportMetric(float64(b.N*len(arquivos))/b.Elapsed().Seconds(), "notas/s")
+}
+
+// BenchmarkValidarStream mede 64 notas em Client.ValidarStream (uma operação = as 64 notas)
+func BenchmarkValidarStream(b *testing.B) {
+	notas := notasBench(64)
+	client := clienteBench(b)
+	o := nfe.Options{XSD: xsdBench}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		entrada := make(chan []byte)
+		go func() {
+			defer close(entrada)
+			for _, nota := range notas {
+				entrada <- nota
+			}
+		}()
+		n := 0
+		for result := range client.ValidarStream(context.Background(), entrada, o) {
+			if result.Erro != nil {
+				b.Fatal(result.Err())
+			}
+			n++
+		}
+		if n != len(notas) {
+			b.Fatalf("%d resultados para %d notas", n, len(notas))
+		}
+	}
+	b.ReportMetric(float64(b.N*len(notas))/b.Elapsed().Seconds(), "notas/s")
+}
+
+// BenchmarkLeitorNotas mede a leitura de um arquivo com 64 notas pelo LeitorNotas
+func BenchmarkLeitorNotas(b *testing.B) {
+	var arquivo bytes.Buffer
+	arquivo.WriteString("<exportacao>")
+	for _, nota := range notasBench(64) {
+		arquivo.Write(nota[bytes.Index(nota, []byte("<nfeProc")):])
+	}
+	arquivo.WriteString("</exportacao>")
+
+	b.SetBytes(int64(arquivo.Len()))
+	b.ReportAllocs()
+	for b.Loop() {
+		leitor := nfe.NewLeitorNotas(bytes.NewReader(arquivo.Bytes()))
+		for {
+			lida, err := leitor.Proxima()
+			if err != nil {
+				break
+			}
+			if lida.Err != nil {
+				b.Fatal(lida.Err)
+			}
+		}
+	}
+}
+
+// TestAlocacoes protege as alocações por nota do XSD, do parse e do pipeline: falha acima da linha de base + 10%
+//
+// As alocações são estáveis entre máquinas (com a mesma versão do Go); o
+// tempo não, e fica com ./bench.sh. Ao reduzir as alocações, baixe a linha
+// de base junto, para a folga não esconder a próxima regressão.
+func TestAlocacoes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("go test -short")
+	}
+	nota := notasBench(1)[0]
+	client := clienteBench(t)
+
+	casos := []struct {
+		nome  string
+		base  float64 // Alocações por nota medidas (ver bench-base.txt)
+		rodar func()
+	}{
+		{"ValidarApenasXSD", 18, func() { nfe.ValidarApenasXSD(nota, xsdBench) }},
+		{"ParseNFe", 2355, func() { nfe.ParseNFe(nota) }},
+		{"ParseNFeReader", 2335, func() { nfe.ParseNFeReader(bytes.NewReader(nota)) }},
+		{"Validar/parse", 7645, func() { client.Validar(nota, nfe.Options{Nivel: nfe.NivelParse, XSD: xsdBench, Assinatura: true}) }},
+		{"Validar/completo", 2435, func() { client.Validar(nota, nfe.Options{XSD: xsdBench}) }},
+	}
+	for _, c := range casos {
+		c.rodar() // Carrega o XSD no cache
+		if n := testing.AllocsPerRun(20, c.rodar); n > c.base*1.1 {
+			t.Errorf("%s: %.0f alocações por nota, linha de base %.0f (+10%%)", c.nome, n, c.base)
+		}
+	}
+}
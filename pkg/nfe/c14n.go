@@ -0,0 +1,207 @@
+package nfe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Namespaces usados na forma canônica
+const (
+	nsXML     = "http://www.w3.org/XML/1998/namespace"
+	nsXMLDSig = "http://www.w3.org/2000/09/xmldsig#"
+)
+
+// errElementoNaoEncontrado indica que nenhum elemento do XML atendeu ao filtro de canonizar
+var errElementoNaoEncontrado = errors.New("elemento não encontrado")
+
+// canonizar retorna a forma canônica (C14N inclusiva, sem comentários) do
+// primeiro elemento para o qual alvo retorna true
+//
+// Aplica também a transformação enveloped-signature: um <Signature> do
+// XML-DSig dentro do elemento é omitido. É o suficiente para conferir a
+// assinatura da NF-e e de eventos (namespaces simples, sem DTD).
+func canonizar(xmlData []byte, alvo func(xml.StartElement) bool) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(xmlData))
+
+	var (
+		escopo      []map[string]string // namespaces declarados em cada nível do documento
+		renderizado []map[string]string // namespaces em vigor na saída, por nível do elemento
+		saida       bytes.Buffer
+		dentro      int // profundidade dentro do elemento alvo (0 = fora)
+		pular       int // profundidade dentro de um Signature envelopado
+	)
+
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			return nil, errElementoNaoEncontrado
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			escopo = append(escopo, declaracoesNS(t))
+
+			switch {
+			case pular > 0:
+				pular++
+				continue
+			case dentro == 0:
+				if !alvo(t) {
+					continue
+				}
+				renderizado = []map[string]string{{}}
+			case resolverNS(escopo, t.Name.Space) == nsXMLDSig && t.Name.Local == "Signature":
+				pular = 1
+				continue
+			}
+
+			dentro++
+			rend := escreverInicio(&saida, t, escopo, renderizado[len(renderizado)-1])
+			renderizado = append(renderizado, rend)
+
+		case xml.EndElement:
+			escopo = escopo[:len(escopo)-1]
+			switch {
+			case pular > 0:
+				pular--
+				continue
+			case dentro == 0:
+				continue
+			}
+
+			saida.WriteString("</" + nomeQualificado(t.Name) + ">")
+			renderizado = renderizado[:len(renderizado)-1]
+			dentro--
+			if dentro == 0 {
+				return saida.Bytes(), nil
+			}
+
+		case xml.CharData:
+			if dentro > 0 && pular == 0 {
+				saida.WriteString(strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;").Replace(string(t)))
+			}
+
+		case xml.ProcInst:
+			if dentro > 0 && pular == 0 {
+				saida.WriteString("<?" + t.Target)
+				if len(t.Inst) > 0 {
+					saida.WriteString(" " + string(t.Inst))
+				}
+				saida.WriteString("?>")
+			}
+		}
+	}
+}
+
+// escreverInicio escreve a tag de abertura canônica e retorna os namespaces em vigor na saída
+//
+// Só são escritas as declarações de namespace que mudam em relação ao
+// elemento pai já escrito (no elemento alvo, todas as que estão em escopo).
+func escreverInicio(saida *bytes.Buffer, t xml.StartElement, escopo []map[string]string, pai map[string]string) map[string]string {
+	emVigor := map[string]string{}
+	for _, nivel := range escopo {
+		for prefixo, uri := range nivel {
+			emVigor[prefixo] = uri
+		}
+	}
+
+	rend := make(map[string]string, len(emVigor))
+	for prefixo, uri := range pai {
+		rend[prefixo] = uri
+	}
+
+	var prefixos []string
+	for prefixo, uri := range emVigor {
+		if anterior, ok := pai[prefixo]; (ok && anterior == uri) || (!ok && prefixo == "" && uri == "") {
+			continue
+		}
+		prefixos = append(prefixos, prefixo)
+		rend[prefixo] = uri
+	}
+	sort.Strings(prefixos)
+
+	var attrs []xml.Attr
+	for _, a := range t.Attr {
+		if a.Name.Space != "xmlns" && !(a.Name.Space == "" && a.Name.Local == "xmlns") {
+			attrs = append(attrs, a)
+		}
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		nsI, nsJ := resolverNSAtributo(escopo, attrs[i].Name.Space), resolverNSAtributo(escopo, attrs[j].Name.Space)
+		if nsI != nsJ {
+			return nsI < nsJ
+		}
+		return attrs[i].Name.Local < attrs[j].Name.Local
+	})
+
+	saida.WriteString("<" + nomeQualificado(t.Name))
+	for _, prefixo := range prefixos {
+		if prefixo == "" {
+			saida.WriteString(` xmlns="` + escaparAtributo(emVigor[prefixo]) + `"`)
+		} else {
+			saida.WriteString(` xmlns:` + prefixo + `="` + escaparAtributo(emVigor[prefixo]) + `"`)
+		}
+	}
+	for _, a := range attrs {
+		saida.WriteString(" " + nomeQualificado(a.Name) + `="` + escaparAtributo(a.Value) + `"`)
+	}
+	saida.WriteString(">")
+
+	return rend
+}
+
+// declaracoesNS retorna os namespaces declarados no elemento (prefixo → URI; "" = padrão)
+func declaracoesNS(t xml.StartElement) map[string]string {
+	decl := map[string]string{}
+	for _, a := range t.Attr {
+		switch {
+		case a.Name.Space == "xmlns":
+			decl[a.Name.Local] = a.Value
+		case a.Name.Space == "" && a.Name.Local == "xmlns":
+			decl[""] = a.Value
+		}
+	}
+	return decl
+}
+
+// resolverNS retorna a URI do prefixo no escopo atual ("" = namespace padrão)
+func resolverNS(escopo []map[string]string, prefixo string) string {
+	if prefixo == "xml" {
+		return nsXML
+	}
+	for i := len(escopo) - 1; i >= 0; i-- {
+		if uri, ok := escopo[i][prefixo]; ok {
+			return uri
+		}
+	}
+	return ""
+}
+
+// resolverNSAtributo é como resolverNS, mas atributo sem prefixo não herda o namespace padrão
+func resolverNSAtributo(escopo []map[string]string, prefixo string) string {
+	if prefixo == "" {
+		return ""
+	}
+	return resolverNS(escopo, prefixo)
+}
+
+// nomeQualificado monta "prefixo:nome" (ou só "nome") como no XML original
+func nomeQualificado(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// escaparAtributo escapa o valor de um atributo como na forma canônica
+func escaparAtributo(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;").Replace(s)
+}
@@ -0,0 +1,311 @@
+package nfe
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// CacheXSD guarda os XSD compilados por caminho, para não recarregar o schema a cada validação
+//
+// Compilar o XSD da NF-e na libxml2 custa bem mais que validar uma nota: o
+// schema é carregado no primeiro uso e reaproveitado enquanto os arquivos (o
+// XSD e os que ele inclui ou importa) não mudam. Os arquivos são conferidos
+// no máximo uma vez por intervalo (IntervaloConferenciaXSD no
+// CacheXSDPadrao); uma mudança na data de modificação ou no tamanho de
+// qualquer deles descarta o compilado e o uso seguinte recarrega. Um schema
+// descartado só é liberado quando as validações em curso com ele terminam.
+// É seguro para uso concorrente: a conferência e a compilação de um caminho
+// não bloqueiam as validações dos demais.
+//
+// ValidarApenasXSD, Options.XSD, ValidarLote e Client.ValidarLote usam o
+// CacheXSDPadrao.
+type CacheXSD struct {
+	intervalo time.Duration
+
+	// mu protege schemas, travas e o uso de cada schemaCache; não fica travado durante o acesso ao disco
+	mu      sync.Mutex
+	schemas map[string]*schemaCache
+
+	// travas serializam a conferência e a compilação de cada caminho (uma única compilação por vez)
+	travas map[string]*sync.Mutex
+}
+
+// IntervaloConferenciaXSD é o intervalo mínimo entre duas conferências dos arquivos de um XSD em cache (NewCacheXSD)
+const IntervaloConferenciaXSD = time.Second
+
+// CacheXSDPadrao é o cache do processo, usado pelas funções que recebem o caminho do XSD
+//
+// Chame Close ao encerrar (ou para liberar a memória dos schemas) e
+// Descartar depois de trocar os XSD de um caminho sem mudar a data dos
+// arquivos.
+var CacheXSDPadrao = NewCacheXSD()
+
+// schemaCache é um XSD compilado no cache
+type schemaCache struct {
+	xsd        *validation.XSDValidator
+	arquivos   map[string]estadoArquivo // O XSD e os incluídos/importados
+	conferido  time.Time                // Última conferência dos arquivos
+	usos       int                      // Validações em curso
+	descartado bool                     // Fora do cache: liberado quando usos chegar a zero
+}
+
+// estadoArquivo identifica a versão de um arquivo do schema
+type estadoArquivo struct {
+	modificado time.Time
+	tamanho    int64
+}
+
+// NewCacheXSD cria um CacheXSD vazio (para um cache separado do CacheXSDPadrao)
+func NewCacheXSD() *CacheXSD {
+	return NewCacheXSDCom(IntervaloConferenciaXSD)
+}
+
+// NewCacheXSDCom é o NewCacheXSD com o intervalo mínimo entre as conferências dos arquivos (0 = a cada uso)
+func NewCacheXSDCom(intervalo time.Duration) *CacheXSD {
+	return &CacheXSD{intervalo: intervalo, schemas: make(map[string]*schemaCache), travas: make(map[string]*sync.Mutex)}
+}
+
+// Carregar compila o XSD (se ainda não estiver no cache) e devolve o SchemaXSD para Options.Schema
+//
+// O SchemaXSD devolvido continua seguindo as mudanças nos arquivos. Útil na
+// inicialização, para falhar cedo com um caminho errado.
+//
+// Exemplo:
+//
+//	schema, err := nfe.CacheXSDPadrao.Carregar("schemas/v4/procNFe_v4.00.xsd")
+//	if err != nil {
+//	    log.Fatal(err) // CodigoXSDIndisponivel
+//	}
+//	defer nfe.CacheXSDPadrao.Close()
+//	result, err := client.Validar(xmlData, nfe.Options{Schema: schema})
+func (c *CacheXSD) Carregar(xsdPath string) (SchemaXSD, error) {
+	s, err := c.obter(xsdPath)
+	if err != nil {
+		return nil, err
+	}
+	c.liberar(s)
+	return schemaEmCache{cache: c, caminho: xsdPath}, nil
+}
+
+// Validar valida o XML com o XSD do caminho, carregando-o se necessário
+func (c *CacheXSD) Validar(xmlData []byte, xsdPath string) error {
+	s, err := c.obter(xsdPath)
+	if err != nil {
+		return err
+	}
+	defer c.liberar(s)
+	return s.xsd.Validate(xmlData)
+}
+
+// Descartar remove o XSD do caminho do cache; o próximo uso recarrega
+func (c *CacheXSD) Descartar(xsdPath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.schemas[chaveXSD(xsdPath)]; ok {
+		c.descartar(chaveXSD(xsdPath), s)
+	}
+}
+
+// Close libera todos os XSD do cache (os em uso, ao fim das validações em curso)
+//
+// O cache continua utilizável: o próximo uso de cada caminho recarrega.
+func (c *CacheXSD) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for chave, s := range c.schemas {
+		c.descartar(chave, s)
+	}
+}
+
+// Caminhos lista os XSD carregados no cache, em ordem (caminhos absolutos)
+func (c *CacheXSD) Caminhos() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	caminhos := make([]string, 0, len(c.schemas))
+	for chave := range c.schemas {
+		caminhos = append(caminhos, chave)
+	}
+	slices.Sort(caminhos)
+	return caminhos
+}
+
+// obter devolve o schema em cache, recarregando se os arquivos mudaram, e marca o uso
+//
+// Conferido há menos de c.intervalo, o schema é usado direto. Senão, a
+// conferência (e a compilação, se preciso) fica sob a trava do caminho, fora
+// de c.mu: chamadas simultâneas do mesmo caminho compilam o XSD uma única
+// vez e as dos demais caminhos seguem.
+func (c *CacheXSD) obter(xsdPath string) (*schemaCache, error) {
+	chave := chaveXSD(xsdPath)
+	if s := c.usarConferido(chave); s != nil {
+		return s, nil
+	}
+
+	trava := c.trava(chave)
+	trava.Lock()
+	defer trava.Unlock()
+
+	// Outra chamada pode ter conferido ou recompilado enquanto esta esperava
+	if s := c.usarConferido(chave); s != nil {
+		return s, nil
+	}
+
+	c.mu.Lock()
+	s := c.schemas[chave]
+	c.mu.Unlock()
+	if s != nil {
+		alterado := s.alterado()
+
+		c.mu.Lock()
+		if c.schemas[chave] == s { // Descartar e Close podem tê-lo tirado do cache
+			if !alterado {
+				s.conferido = time.Now()
+				s.usos++
+				c.mu.Unlock()
+				return s, nil
+			}
+			c.descartar(chave, s)
+		}
+		c.mu.Unlock()
+	}
+
+	xsd, err := validation.NewXSDValidator(xsdPath)
+	if err != nil {
+		return nil, err
+	}
+	s = &schemaCache{xsd: xsd, arquivos: arquivosXSD(chave), conferido: time.Now(), usos: 1}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if antigo, ok := c.schemas[chave]; ok {
+		c.descartar(chave, antigo)
+	}
+	c.schemas[chave] = s
+	return s, nil
+}
+
+// usarConferido marca o uso do schema em cache se ele foi conferido há menos de c.intervalo (nil se não)
+func (c *CacheXSD) usarConferido(chave string) *schemaCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.schemas[chave]
+	if !ok || c.intervalo <= 0 || time.Since(s.conferido) >= c.intervalo {
+		return nil
+	}
+	s.usos++
+	return s
+}
+
+// trava devolve a trava de conferência e compilação do caminho
+func (c *CacheXSD) trava(chave string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.travas[chave]
+	if !ok {
+		t = &sync.Mutex{}
+		c.travas[chave] = t
+	}
+	return t
+}
+
+// liberar encerra um uso do schema e o libera se ele já saiu do cache
+func (c *CacheXSD) liberar(s *schemaCache) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s.usos--; s.usos == 0 && s.descartado {
+		s.xsd.Close()
+	}
+}
+
+// descartar tira o schema do cache (com c.mu travado); libera agora se não está em uso
+func (c *CacheXSD) descartar(chave string, s *schemaCache) {
+	delete(c.schemas, chave)
+	s.descartado = true
+	if s.usos == 0 {
+		s.xsd.Close()
+	}
+}
+
+// alterado indica se algum arquivo do schema mudou (ou sumiu) desde o carregamento
+func (s *schemaCache) alterado() bool {
+	for caminho, antes := range s.arquivos {
+		if estado, ok := estadoDe(caminho); !ok || estado != antes {
+			return true
+		}
+	}
+	return false
+}
+
+// chaveXSD normaliza o caminho ("schemas/x.xsd" e "./schemas/x.xsd" são o mesmo schema)
+func chaveXSD(xsdPath string) string {
+	if abs, err := filepath.Abs(xsdPath); err == nil {
+		return abs
+	}
+	return filepath.Clean(xsdPath)
+}
+
+// referenciaXSD encontra o schemaLocation de xs:include, xs:import e xs:redefine
+var referenciaXSD = regexp.MustCompile(`<(?:[\w-]+:)?(?:include|import|redefine)\b[^>]*\bschemaLocation\s*=\s*["']([^"']+)["']`)
+
+// esquemaURL reconhece um schemaLocation remoto (http://, file://...)
+var esquemaURL = regexp.MustCompile(`^\w+://`)
+
+// arquivosXSD devolve o estado do XSD e de todos os arquivos locais que ele inclui ou importa, recursivamente
+func arquivosXSD(xsdPath string) map[string]estadoArquivo {
+	arquivos := make(map[string]estadoArquivo)
+	pendentes := []string{xsdPath}
+	for len(pendentes) > 0 {
+		caminho := pendentes[len(pendentes)-1]
+		pendentes = pendentes[:len(pendentes)-1]
+		if _, visto := arquivos[caminho]; visto {
+			continue
+		}
+		estado, ok := estadoDe(caminho)
+		if !ok {
+			continue
+		}
+		arquivos[caminho] = estado
+
+		conteudo, err := os.ReadFile(caminho)
+		if err != nil {
+			continue
+		}
+		for _, m := range referenciaXSD.FindAllSubmatch(conteudo, -1) {
+			ref := string(m[1])
+			if esquemaURL.MatchString(ref) {
+				continue // Schema remoto: não há arquivo a acompanhar
+			}
+			if !filepath.IsAbs(ref) {
+				ref = filepath.Join(filepath.Dir(caminho), ref)
+			}
+			pendentes = append(pendentes, filepath.Clean(ref))
+		}
+	}
+	return arquivos
+}
+
+// estadoDe lê a data de modificação e o tamanho do arquivo (ok = false se não existe)
+func estadoDe(caminho string) (estadoArquivo, bool) {
+	info, err := os.Stat(caminho)
+	if err != nil {
+		return estadoArquivo{}, false
+	}
+	return estadoArquivo{modificado: info.ModTime(), tamanho: info.Size()}, true
+}
+
+// schemaEmCache é o SchemaXSD devolvido por CacheXSD.Carregar
+type schemaEmCache struct {
+	cache   *CacheXSD
+	caminho string
+}
+
+// Validate valida com o XSD do cache (recarregado se os arquivos mudaram)
+func (s schemaEmCache) Validate(xmlData []byte) error {
+	return s.cache.Validar(xmlData, s.caminho)
+}
@@ -0,0 +1,135 @@
+package nfe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Códigos de rejeição SEFAZ relacionados ao CFOP
+const (
+	// RejeicaoCFOPEntradaEmSaida: CFOP de entrada para NF-e de saída (cStat 518)
+	RejeicaoCFOPEntradaEmSaida = "518"
+
+	// RejeicaoCFOPSaidaEmEntrada: CFOP de saída para NF-e de entrada (cStat 519)
+	RejeicaoCFOPSaidaEmEntrada = "519"
+)
+
+// Destinos da operação (ide/idDest)
+const (
+	IdDestInterna       = "1"
+	IdDestInterestadual = "2"
+	IdDestExterior      = "3"
+)
+
+// Tipos de operação (ide/tpNF)
+const (
+	TpNFEntrada = "0"
+	TpNFSaida   = "1"
+)
+
+// VerificarCFOP confere o CFOP de cada item contra o tipo e o destino da operação
+//
+// O primeiro dígito do CFOP indica direção e destino:
+//   - 1, 2, 3: entrada (interna, interestadual, exterior)
+//   - 5, 6, 7: saída (interna, interestadual, exterior)
+//
+// Verifica:
+//   - CFOP de entrada em nota de saída (rejeição 518) e vice-versa (rejeição 519)
+//   - CFOP incompatível com ide/idDest (ex: 5xxx em operação interestadual)
+//
+// Exemplo:
+//
+//	nota, _ := nfe.ParseNFe(xmlData)
+//	for _, inc := range nfe.VerificarCFOP(nota) {
+//	    fmt.Printf("Item %d: %s\n", inc.Item, inc.Mensagem)
+//	}
+func VerificarCFOP(nfe *NFeEnvelope) []Inconsistencia {
+	var inconsistencias []Inconsistencia
+	ide := nfe.InfNFe.Ide
+
+	for i, det := range nfe.InfNFe.Det {
+		item := numeroItem(det, i)
+		cfop := strings.TrimSpace(det.Prod.CFOP)
+
+		if len(cfop) != 4 || OnlyDigits(cfop) != cfop {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Item:     item,
+				Grupo:    "CFOP",
+				Mensagem: fmt.Sprintf("CFOP '%s' deve ter 4 dígitos", cfop),
+			})
+			continue
+		}
+
+		entrada, destino, ok := classificarCFOP(cfop)
+		if !ok {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Item:     item,
+				Grupo:    "CFOP",
+				Mensagem: fmt.Sprintf("CFOP '%s' inválido: primeiro dígito deve ser 1, 2, 3, 5, 6 ou 7", cfop),
+			})
+			continue
+		}
+
+		// 1) Direção (tpNF)
+		switch {
+		case ide.TpNF == TpNFSaida && entrada:
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Codigo:   RejeicaoCFOPEntradaEmSaida,
+				Item:     item,
+				Grupo:    "CFOP",
+				Mensagem: fmt.Sprintf("CFOP de entrada (%s) para NF-e de saída", cfop),
+			})
+		case ide.TpNF == TpNFEntrada && !entrada:
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Codigo:   RejeicaoCFOPSaidaEmEntrada,
+				Item:     item,
+				Grupo:    "CFOP",
+				Mensagem: fmt.Sprintf("CFOP de saída (%s) para NF-e de entrada", cfop),
+			})
+		}
+
+		// 2) Destino (idDest)
+		if ide.IdDest != "" && ide.IdDest != destino {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Item:  item,
+				Grupo: "CFOP",
+				Mensagem: fmt.Sprintf("CFOP %s é de operação %s, mas idDest=%s indica operação %s",
+					cfop, descricaoDestino(destino), ide.IdDest, descricaoDestino(ide.IdDest)),
+			})
+		}
+	}
+
+	return inconsistencias
+}
+
+// classificarCFOP retorna a direção (entrada ou saída) e o idDest esperado para o CFOP
+func classificarCFOP(cfop string) (entrada bool, idDest string, ok bool) {
+	switch cfop[0] {
+	case '1':
+		return true, IdDestInterna, true
+	case '2':
+		return true, IdDestInterestadual, true
+	case '3':
+		return true, IdDestExterior, true
+	case '5':
+		return false, IdDestInterna, true
+	case '6':
+		return false, IdDestInterestadual, true
+	case '7':
+		return false, IdDestExterior, true
+	}
+	return false, "", false
+}
+
+// descricaoDestino descreve o idDest para mensagens
+func descricaoDestino(idDest string) string {
+	switch idDest {
+	case IdDestInterna:
+		return "interna"
+	case IdDestInterestadual:
+		return "interestadual"
+	case IdDestExterior:
+		return "com o exterior"
+	}
+	return "desconhecida"
+}
@@ -0,0 +1,116 @@
+package nfe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Tipos de emissão (tpEmis, 35ª posição da chave)
+var TiposEmissao = map[string]string{
+	"1": "Normal",
+	"2": "Contingência FS-IA",
+	"3": "Contingência SCAN",
+	"4": "Contingência EPEC",
+	"5": "Contingência FS-DA",
+	"6": "Contingência SVC-AN",
+	"7": "Contingência SVC-RS",
+	"9": "Contingência off-line NFC-e",
+}
+
+// ChaveDecomposta são os campos que compõem a chave de acesso
+//
+// Layout (44 dígitos): cUF(2) AAMM(4) CNPJ/CPF(14) mod(2) serie(3) nNF(9) tpEmis(1) cNF(8) cDV(1)
+type ChaveDecomposta struct {
+	Chave string `json:"chave"`
+
+	// CUF é o código IBGE da UF do emitente; UF é a sigla correspondente
+	CUF string `json:"cuf"`
+	UF  string `json:"uf"`
+
+	// AnoMes é o AAMM da emissão; Periodo é o mesmo valor no formato AAAA-MM
+	AnoMes  string `json:"aamm"`
+	Periodo string `json:"periodo"`
+
+	// Emitente é o CNPJ (ou CPF completado com zeros à esquerda) do emitente
+	Emitente string `json:"emitente"`
+
+	Modelo string `json:"modelo"`
+	Serie  string `json:"serie"`
+	Numero string `json:"numero"`
+
+	// TipoEmissao é o tpEmis; DescricaoTipoEmissao é o nome do tipo (ver TiposEmissao)
+	TipoEmissao          string `json:"tp_emis"`
+	DescricaoTipoEmissao string `json:"tp_emis_descricao,omitempty"`
+
+	CodigoNumerico    string `json:"cnf"`
+	DigitoVerificador string `json:"cdv"`
+}
+
+// DecomporChave valida a chave de acesso (formato e dígito verificador) e separa seus campos
+//
+// Série e número são devolvidos sem os zeros à esquerda.
+//
+// Exemplo:
+//
+//	c, err := nfe.DecomporChave("35250732409620000175550010000037471011544648")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(c.UF, c.Periodo, c.Emitente, c.Modelo, c.Serie, c.Numero)
+//	// SP 2025-07 32409620000175 55 1 3747
+func DecomporChave(chave string) (*ChaveDecomposta, error) {
+	chave = strings.TrimSpace(chave)
+	if err := ValidarChaveAcesso(chave); err != nil {
+		return nil, err
+	}
+
+	c := &ChaveDecomposta{
+		Chave:             chave,
+		CUF:               chave[0:2],
+		UF:                SiglaUF(chave[0:2]),
+		AnoMes:            chave[2:6],
+		Periodo:           "20" + chave[2:4] + "-" + chave[4:6],
+		Emitente:          chave[6:20],
+		Modelo:            chave[20:22],
+		Serie:             semZerosAEsquerda(chave[22:25]),
+		Numero:            semZerosAEsquerda(chave[25:34]),
+		TipoEmissao:       chave[34:35],
+		CodigoNumerico:    chave[35:43],
+		DigitoVerificador: chave[43:44],
+	}
+	c.DescricaoTipoEmissao = TiposEmissao[c.TipoEmissao]
+
+	if c.UF == "" {
+		return c, comCodigo(CodigoChaveCampo, fmt.Errorf("código de UF '%s' inexistente", c.CUF))
+	}
+	if mes, _ := strconv.Atoi(chave[4:6]); mes < 1 || mes > 12 {
+		return c, comCodigo(CodigoChaveCampo, fmt.Errorf("mês '%s' inválido no AAMM da chave", chave[4:6]))
+	}
+
+	return c, nil
+}
+
+// verificarChaveConsulta confere localmente a chave antes de consultá-la na SEFAZ
+//
+// Além do que DecomporChave verifica (formato, dígito verificador, UF e mês),
+// exige o modelo 55 (NF-e) ou 65 (NFC-e): a SEFAZ recusaria qualquer outra.
+func verificarChaveConsulta(chave string) error {
+	c, err := DecomporChave(chave)
+	if err != nil {
+		return err
+	}
+	if c.Modelo != ModeloNFe && c.Modelo != ModeloNFCe {
+		return comCodigo(CodigoChaveCampo, fmt.Errorf("modelo '%s' não é NF-e (55) nem NFC-e (65)", c.Modelo))
+	}
+	return nil
+}
+
+// semZerosAEsquerda remove os zeros à esquerda de um campo numérico ("001" -> "1")
+func semZerosAEsquerda(s string) string {
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		return "0"
+	}
+	return s
+}
@@ -1,8 +1,13 @@
 package nfe
 
 import (
+	"context"
+	"crypto/x509"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/fabyo/go-nfe-validator/internal/config"
 	"github.com/fabyo/go-nfe-validator/internal/sefaz"
@@ -11,8 +16,17 @@ import (
 
 // Client é o cliente principal para validação de NF-e
 type Client struct {
-	sefaz *sefaz.Client
-	cfg   *config.Config
+	sefaz      *sefaz.Client // nil com WithConsulter
+	consulter  Consulter
+	cfg        *config.Config
+	regras     ConfigRegras
+	observador Observador
+	cache      Cache        // Consultas à SEFAZ reaproveitadas (WithCache; opcional)
+	logger     *slog.Logger // WithLogger (opcional)
+	hooks      []Hook       // Client.Use (opcional)
+
+	validadores []Validador // WithValidadores (opcional)
+	resposta    *string     // WithRespostaSefaz: diretório das respostas (opcional)
 }
 
 // Config representa as configurações do cliente
@@ -27,12 +41,22 @@ type Config struct {
 	CNPJ string
 	// Código UF IBGE (ex: "35" para SP)
 	UF string
-	// URL de consulta da SEFAZ (opcional, usa padrão se vazio)
+	// URL de consulta da SEFAZ (opcional: se vazia, usa o autorizador da UF de cada chave)
 	ConsultaURL string
-	// URL de distribuição (opcional)
+	// URL de distribuição (opcional: se vazia, usa a do Ambiente Nacional)
 	DistURL string
+	// Substituições da tabela de web services embutida, com chaves
+	// "<UF ou autorizador>/<serviço>/<ambiente>" (ex: "SVRS/consulta/producao"; opcional)
+	Webservices map[string]string
 	// Ambiente: "production" ou "homologation"
 	Env string
+	// Pasta com os XSD das requisições à SEFAZ (consSitNFe_v4.00.xsd, envEvento_v1.00.xsd...):
+	// cada requisição é conferida antes do envio e, fora do schema, não sai (opcional)
+	SchemasRequisicao string
+	// Configuração das regras de negócio (todas habilitadas por padrão)
+	Regras ConfigRegras
+	// Observador recebe as validações e as chamadas à SEFAZ (ex: nfemetrics.Coletor; opcional)
+	Observador Observador
 }
 
 // NewClient cria um novo cliente de validação NF-e
@@ -46,7 +70,15 @@ type Config struct {
 //	    UF:          "35",
 //	    Env:         "production",
 //	})
-func NewClient(cfg Config) (*Client, error) {
+//
+// As opções (WithCertPFX, WithTimeout, WithEndpoint, WithLogger, WithCache, WithValidadores...)
+// completam ou substituem os campos de Config:
+//
+//	client, err := nfe.NewClient(nfe.Config{UF: "35"},
+//	    nfe.WithCertPFX("cert/empresa.pfx", senha),
+//	    nfe.WithTimeout(30*time.Second),
+//	)
+func NewClient(cfg Config, opcoes ...Opcao) (*Client, error) {
 	// Configuração interna
 	internalCfg := &config.Config{
 		CertDir:     cfg.CertDir,
@@ -57,6 +89,9 @@ func NewClient(cfg Config) (*Client, error) {
 		ConsultaURL: cfg.ConsultaURL,
 		DistURL:     cfg.DistURL,
 		Env:         cfg.Env,
+		Webservices: cfg.Webservices,
+
+		SchemasRequisicao: cfg.SchemasRequisicao,
 	}
 
 	// Se não especificou ambiente, usa production
@@ -64,43 +99,92 @@ func NewClient(cfg Config) (*Client, error) {
 		internalCfg.Env = "production"
 	}
 
-	// Criar cliente SEFAZ
-	sefazClient, err := sefaz.NewClient(internalCfg)
-	if err != nil {
-		return nil, fmt.Errorf("falha ao criar cliente SEFAZ: %w", err)
+	c := &Client{cfg: internalCfg, regras: cfg.Regras, observador: cfg.Observador}
+	if err := c.conectar(opcoes); err != nil {
+		return nil, err
 	}
-
-	return &Client{
-		sefaz: sefazClient,
-		cfg:   internalCfg,
-	}, nil
+	return c, nil
 }
 
 // NewClientFromEnv cria um cliente usando variáveis de ambiente
 // Lê de .env.production ou .env.homologation automaticamente
 //
-// Variáveis necessárias:
+// Variáveis necessárias (sem WithCertPFX):
 //   - NFE_CERT_DIR
 //   - NFE_CERT_KEY_FILE
 //   - NFE_CERT_PUB_FILE
-//   - NFE_UF_IBGE
-//   - SEFAZ_CONSULTA_URL
+//
+// Opcionais: NFE_ENV, NFE_UF_IBGE, NFE_CNPJ e as URLs SEFAZ_*_URL (sem elas,
+// vale a tabela de web services embutida). Valores inválidos ou arquivos
+// ausentes são devolvidos juntos, num único erro (CodigoConfig ou
+// CodigoCertificado).
 //
 // Exemplo:
 //
 //	client, err := nfe.NewClientFromEnv()
-func NewClientFromEnv() (*Client, error) {
-	cfg := config.Load()
+func NewClientFromEnv(opcoes ...Opcao) (*Client, error) {
+	c := &Client{cfg: config.Load()}
+	if err := c.conectar(opcoes); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
 
-	sefazClient, err := sefaz.NewClient(cfg)
+// NewClientFromFile cria um cliente com as variáveis de um arquivo .env (as mesmas de NewClientFromEnv)
+//
+// Diferente de NewClientFromEnv, o arquivo não é carregado no ambiente do
+// processo e as variáveis de ambiente não são consultadas: clientes com
+// arquivos diferentes (ex: duas empresas, ou produção e homologação)
+// convivem no mesmo processo.
+//
+// Exemplo:
+//
+//	producao, err := nfe.NewClientFromFile(".env.production")
+//	homologacao, err := nfe.NewClientFromFile(".env.homologacao")
+func NewClientFromFile(path string, opcoes ...Opcao) (*Client, error) {
+	cfg, err := config.FromFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("falha ao criar cliente SEFAZ: %w", err)
+		return nil, err
+	}
+	c := &Client{cfg: cfg}
+	if err := c.conectar(opcoes); err != nil {
+		return nil, err
 	}
+	return c, nil
+}
 
-	return &Client{
-		sefaz: sefazClient,
-		cfg:   cfg,
-	}, nil
+// conectar aplica as opções e cria o cliente SEFAZ interno
+func (c *Client) conectar(opcoes []Opcao) error {
+	var o opcoesClient
+	for _, opcao := range opcoes {
+		opcao(&o)
+	}
+	c.cache, c.logger, c.validadores, c.resposta = o.cache, o.logger, o.validadores, o.resposta
+	if o.consulter != nil {
+		c.consulter = o.consulter
+		return nil
+	}
+	if o.endpoint != "" {
+		c.cfg.ConsultaURL = o.endpoint
+	}
+
+	so := sefaz.Opcoes{Timeout: o.timeout, Logger: o.logger, LogResposta: o.logResposta, Transporte: o.transporte}
+	if o.pfxCaminho != "" {
+		cert, err := sefaz.CarregarPFX(o.pfxCaminho, o.pfxSenha)
+		if err != nil {
+			return err
+		}
+		so.Certificado = &cert
+	}
+
+	sefazClient, err := sefaz.NewClientCom(*c.cfg, so)
+	if err != nil {
+		return fmt.Errorf("falha ao criar cliente SEFAZ: %w", err)
+	}
+	observarSefaz(sefazClient, c.observador)
+
+	c.sefaz, c.consulter = sefazClient, consulterSefaz{cliente: sefazClient}
+	return nil
 }
 
 // ValidarXML valida um XML de NF-e completamente (XSD + Parse + SEFAZ)
@@ -119,55 +203,17 @@ func NewClientFromEnv() (*Client, error) {
 //	}
 //	fmt.Printf("Autorizada: %v\n", result.Autorizado)
 func (c *Client) ValidarXML(xmlPath, xsdPath string) (*ValidationResult, error) {
-	// 1. Validar XSD
-	xmlData, err := os.ReadFile(xmlPath)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao ler arquivo XML: %w", err)
-	}
-
-	if err := ValidateWithXSD(xmlData, xsdPath); err != nil {
-		return &ValidationResult{
-			ValidoXSD: false,
-			Erro:      fmt.Errorf("falha na validação XSD: %w", err),
-		}, nil
-	}
-
-	// 2. Parse do XML
-	nfe, err := validation.ParseNFe(xmlData)
-	if err != nil {
-		return &ValidationResult{
-			ValidoXSD: true,
-			Erro:      fmt.Errorf("falha ao parsear XML: %w", err),
-		}, nil
-	}
-
-	// Extrair chave
-	chave := validation.ExtractChaveFromID(nfe.InfNFe.ID)
-	if chave == "" {
-		chave = nfe.InfNFe.ID
-	}
+	return c.ValidarXMLContext(context.Background(), xmlPath, xsdPath)
+}
 
-	// 3. Consultar SEFAZ
-	status, err := c.sefaz.ConsultaSituacaoNFe(chave)
+// ValidarXMLContext é o ValidarXML com contexto (ver ValidarXMLBytesContext)
+func (c *Client) ValidarXMLContext(ctx context.Context, xmlPath, xsdPath string) (*ValidationResult, error) {
+	xmlData, err := os.ReadFile(xmlPath)
 	if err != nil {
-		return &ValidationResult{
-			ValidoXSD:   true,
-			ChaveAcesso: chave,
-			DadosNFe:    convertInternalNFeData(nfe),
-			Erro:        fmt.Errorf("falha na consulta SEFAZ: %w", err),
-		}, nil
+		return nil, comCodigo(CodigoXMLIlegivel, fmt.Errorf("erro ao ler arquivo XML: %w", err))
 	}
 
-	return &ValidationResult{
-		ValidoXSD:   true,
-		ChaveAcesso: chave,
-		Autorizado:  status.Autorizado,
-		Status: StatusSefaz{
-			Codigo:   status.Codigo,
-			Mensagem: status.Mensagem,
-		},
-		DadosNFe: convertInternalNFeData(nfe),
-	}, nil
+	return c.ValidarXMLBytesContext(ctx, xmlData, xsdPath)
 }
 
 // ValidarXMLBytes valida um XML de NF-e a partir de bytes na memória
@@ -179,58 +225,41 @@ func (c *Client) ValidarXML(xmlPath, xsdPath string) (*ValidationResult, error)
 //	xmlData := []byte("<nfeProc>...</nfeProc>")
 //	result, err := client.ValidarXMLBytes(xmlData, "schemas/v4/procNFe_v4.00.xsd")
 func (c *Client) ValidarXMLBytes(xmlData []byte, xsdPath string) (*ValidationResult, error) {
-	// 1. Validar XSD
-	if err := ValidateWithXSD(xmlData, xsdPath); err != nil {
-		return &ValidationResult{
-			ValidoXSD: false,
-			Erro:      fmt.Errorf("falha na validação XSD: %w", err),
-		}, nil
-	}
-
-	// 2. Parse do XML
-	nfe, err := validation.ParseNFe(xmlData)
-	if err != nil {
-		return &ValidationResult{
-			ValidoXSD: true,
-			Erro:      fmt.Errorf("falha ao parsear XML: %w", err),
-		}, nil
-	}
-
-	// Extrair chave
-	chave := validation.ExtractChaveFromID(nfe.InfNFe.ID)
-	if chave == "" {
-		chave = nfe.InfNFe.ID
-	}
-
-	// 3. Consultar SEFAZ
-	status, err := c.sefaz.ConsultaSituacaoNFe(chave)
-	if err != nil {
-		return &ValidationResult{
-			ValidoXSD:   true,
-			ChaveAcesso: chave,
-			DadosNFe:    convertInternalNFeData(nfe),
-			Erro:        fmt.Errorf("falha na consulta SEFAZ: %w", err),
-		}, nil
-	}
+	return c.ValidarXMLBytesContext(context.Background(), xmlData, xsdPath)
+}
 
-	return &ValidationResult{
-		ValidoXSD:   true,
-		ChaveAcesso: chave,
-		Autorizado:  status.Autorizado,
-		Status: StatusSefaz{
-			Codigo:   status.Codigo,
-			Mensagem: status.Mensagem,
-		},
-		DadosNFe: convertInternalNFeData(nfe),
-	}, nil
+// ValidarXMLBytesContext é o ValidarXMLBytes com contexto: o prazo e o
+// cancelamento de ctx valem para a validação XSD e para a consulta à SEFAZ
+//
+// Se ctx terminar antes do resultado, retorna um erro que embrulha ctx.Err()
+// (context.Canceled ou context.DeadlineExceeded) e nenhum resultado.
+//
+// Exemplo (handler HTTP):
+//
+//	func validar(w http.ResponseWriter, r *http.Request) {
+//	    ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+//	    defer cancel()
+//
+//	    result, err := client.ValidarXMLBytesContext(ctx, xmlData, "schemas/v4/procNFe_v4.00.xsd")
+//	    if errors.Is(err, context.DeadlineExceeded) {
+//	        http.Error(w, "tempo esgotado", http.StatusGatewayTimeout)
+//	        return
+//	    }
+//	    ...
+//	}
+func (c *Client) ValidarXMLBytesContext(ctx context.Context, xmlData []byte, xsdPath string) (*ValidationResult, error) {
+	return c.ValidarContext(ctx, xmlData, Options{XSD: xsdPath})
 }
 
 // ValidarChave consulta a situação de uma NF-e apenas pela chave de acesso
 //
 // Não valida XSD nem faz parse do XML. Apenas consulta o status na SEFAZ.
+// Antes, confere a chave localmente: com dígito verificador, UF ou modelo
+// inválidos, retorna erro (CodigoChaveDV ou CodigoChaveCampo) sem consultar.
 //
 // Parâmetros:
-//   - chave: chave de acesso de 44 dígitos
+//   - chave: chave de acesso de 44 dígitos (espaços e pontuação são ignorados)
+//   - ambiente: opcional; consulta nesse ambiente em vez do de Config.Env
 //
 // Exemplo:
 //
@@ -238,46 +267,145 @@ func (c *Client) ValidarXMLBytes(xmlData []byte, xsdPath string) (*ValidationRes
 //	if result.Autorizado {
 //	    fmt.Println("NF-e está autorizada!")
 //	}
-func (c *Client) ValidarChave(chave string) (*ValidationResult, error) {
+//
+//	// A mesma chave em homologação, com o mesmo cliente
+//	result, err = client.ValidarChave(chave, nfe.Homologacao)
+func (c *Client) ValidarChave(chave string, ambiente ...Ambiente) (*ValidationResult, error) {
+	return c.ValidarChaveContext(context.Background(), chave, ambiente...)
+}
+
+// ValidarChaveContext é o ValidarChave com contexto: o prazo e o cancelamento de ctx valem para a consulta à SEFAZ
+//
+// Se ctx terminar antes da resposta, retorna um erro que embrulha ctx.Err().
+// O ambiente informado tem precedência sobre o de ComAmbiente.
+func (c *Client) ValidarChaveContext(ctx context.Context, chave string, ambiente ...Ambiente) (*ValidationResult, error) {
 	// Validar formato
 	chaveClean := validation.OnlyDigits(chave)
 	if len(chaveClean) != 44 {
-		return nil, fmt.Errorf("chave de acesso inválida: deve ter 44 dígitos")
+		return nil, comCodigo(CodigoChaveFormato, fmt.Errorf("chave de acesso inválida: deve ter 44 dígitos"))
+	}
+	// Chave com DV, UF ou modelo errados nem chega à SEFAZ
+	if err := verificarChaveConsulta(chaveClean); err != nil {
+		return nil, fmt.Errorf("chave de acesso inválida: %w", err)
+	}
+	if len(ambiente) > 0 {
+		if err := ambiente[0].validar(); err != nil {
+			return nil, err
+		}
+		ctx = ComAmbiente(ctx, ambiente[0])
+	}
+
+	// A consulta, o Cache e o resultado usam os 44 dígitos, sem a formatação de chave
+	result := &ValidationResult{ChaveAcesso: chaveClean} // ValidoXSD: N/A neste modo
+
+	ctx, fase, ok := c.iniciarFase(ctx, FaseSefaz, result)
+	if !ok {
+		return result, nil
+	}
+	status, err := c.consultarSefaz(ctx, chaveClean)
+	if err != nil {
+		if ctx.Err() != nil {
+			fase.span.End()
+			return nil, interrompida(ctx)
+		}
+		result.Erro = novoErro(ResultadoConectividade, FaseSefaz, "falha na consulta SEFAZ", err)
+		fase.encerrar(result, result.Erro)
+		return result, nil
+	}
+
+	result.Autorizado, result.Status = status.Autorizado, status.Status
+	c.guardarResposta(result, status)
+	fase.encerrarSefaz(result)
+	return result, nil
+}
+
+// consultarSefaz consulta a situação da chave no Consulter, passando pelo Cache (WithCache) quando houver
+//
+// O Consulter recebe no contexto o ambiente já resolvido (ver AmbienteDe).
+// Fora do ambiente de Config.Env, a consulta fica no Cache com a chave
+// seguida de "@" e o ambiente, para não se misturar com a do padrão.
+func (c *Client) consultarSefaz(ctx context.Context, chave string) (ConsultaSefaz, error) {
+	ambiente := c.ambiente(ctx)
+	ctx = ComAmbiente(ctx, ambiente)
+
+	chaveCache := chave
+	if padrao := c.ambiente(context.Background()); ambiente != padrao {
+		chaveCache = chave + "@" + string(ambiente)
+	}
+
+	if c.cache != nil {
+		guardada, ok, err := c.cache.Buscar(ctx, chaveCache)
+		if err != nil {
+			c.avisarCache("busca", chave, err)
+		} else if ok {
+			return guardada, nil
+		}
 	}
 
-	status, err := c.sefaz.ConsultaSituacaoNFe(chave)
+	consulta, err := c.consulter.ConsultarSituacao(ctx, chave)
 	if err != nil {
-		return &ValidationResult{
-			ChaveAcesso: chave,
-			Erro:        fmt.Errorf("falha na consulta SEFAZ: %w", err),
-		}, nil
+		return ConsultaSefaz{}, err
+	}
+	if consulta.ConsultadoEm.IsZero() {
+		consulta.ConsultadoEm = time.Now()
+	}
+
+	if c.cache != nil && SituacaoDefinitiva(consulta.Status.Codigo) {
+		if err := c.cache.Guardar(ctx, chaveCache, consulta); err != nil {
+			c.avisarCache("gravação", chave, err)
+		}
+	}
+	return consulta, nil
+}
+
+// avisarCache registra no logger (WithLogger) a falha do Cache, que não interrompe a validação
+func (c *Client) avisarCache(operacao, chave string, err error) {
+	if c.logger != nil {
+		c.logger.Warn("falha no cache de consultas SEFAZ", "operacao", operacao, "chave", chave, "erro", err)
+	}
+}
+
+// guardarResposta anexa a resposta bruta da SEFAZ ao resultado ou a grava no diretório (WithRespostaSefaz)
+func (c *Client) guardarResposta(result *ValidationResult, consulta ConsultaSefaz) {
+	if c.resposta == nil || consulta.Resposta == "" {
+		return
+	}
+	dir := *c.resposta
+	if dir == "" {
+		result.RespostaSefaz = consulta.Resposta
+		return
 	}
 
-	return &ValidationResult{
-		ChaveAcesso: chave,
-		ValidoXSD:   false, // N/A neste modo
-		Autorizado:  status.Autorizado,
-		Status: StatusSefaz{
-			Codigo:   status.Codigo,
-			Mensagem: status.Mensagem,
-		},
-	}, nil
+	caminho := filepath.Join(dir, validation.OnlyDigits(result.ChaveAcesso)+"-"+consulta.ConsultadoEm.Format("20060102150405")+".xml")
+	err := os.MkdirAll(dir, 0o755)
+	if err == nil {
+		err = os.WriteFile(caminho, []byte(consulta.Resposta), 0o644)
+	}
+	if err != nil {
+		if c.logger != nil {
+			c.logger.Warn("falha ao gravar a resposta da SEFAZ", "chave", result.ChaveAcesso, "erro", err)
+		}
+		return
+	}
+	result.RespostaSefazArquivo = caminho
 }
 
-// convertInternalNFeData converte a struct interna validation.NFeEnvelope para DadosNFe público
-func convertInternalNFeData(nfe *validation.NFeEnvelope) *DadosNFe {
-	return &DadosNFe{
-		Modelo: nfe.InfNFe.Ide.Modelo,
-		Serie:  nfe.InfNFe.Ide.Serie,
-		Numero: nfe.InfNFe.Ide.NumNf,
-		Emitente: Empresa{
-			Documento: nfe.InfNFe.Emit.CNPJ,
-			Nome:      nfe.InfNFe.Emit.XNome,
-		},
-		Destinatario: Empresa{
-			Documento: validation.ChooseFirstNonEmpty(nfe.InfNFe.Dest.CNPJ, nfe.InfNFe.Dest.CPF),
-			Nome:      nfe.InfNFe.Dest.XNome,
-		},
-		ValorTotal: nfe.InfNFe.Total.ICMSTot.VNF,
+// Certificado retorna o certificado digital (mTLS) do cliente
+//
+// Útil para acompanhar a validade, por exemplo com nfemetrics.Coletor:
+//
+//	cert, err := client.Certificado()
+//	if err == nil {
+//	    coletor.ObservarCertificado(cert)
+//	}
+func (c *Client) Certificado() (*x509.Certificate, error) {
+	if c.sefaz == nil {
+		return nil, fmt.Errorf("cliente sem certificado (consulta à SEFAZ via WithConsulter)")
 	}
-}
\ No newline at end of file
+	return c.sefaz.Certificado()
+}
+
+// interrompida é o erro devolvido quando ctx termina antes do resultado
+func interrompida(ctx context.Context) error {
+	return comCodigo(CodigoInterrompida, fmt.Errorf("validação interrompida: %w", ctx.Err()))
+}
@@ -0,0 +1,135 @@
+package nfe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrChaveDivergente indica que o código de barras lido do DANFE não é a chave de acesso esperada
+var ErrChaveDivergente = errors.New("código de barras do DANFE não confere com a chave da nota")
+
+// LerCodigoBarras extrai a chave de acesso do texto lido no código de barras do DANFE (Code 128C)
+//
+// Descarta o identificador de simbologia AIM que alguns leitores enviam antes
+// do código (ex: "]C0") e os espaços, tabulações e quebras de linha que eles
+// acrescentam; o restante deve ser a chave de 44 dígitos com DV válido. Um
+// erro de DV costuma ser leitura incompleta ou código danificado: peça nova
+// leitura ou digite a chave.
+//
+// Exemplo:
+//
+//	chave, err := nfe.LerCodigoBarras(leitura) // texto enviado pelo leitor (teclado)
+//	if err != nil {
+//	    fmt.Println("leia de novo:", err)
+//	}
+func LerCodigoBarras(lido string) (string, error) {
+	s := strings.TrimSpace(lido)
+	if len(s) >= 3 && s[0] == ']' {
+		s = s[3:]
+	}
+	s = strings.Join(strings.Fields(s), "")
+
+	if err := ValidarChaveAcesso(s); err != nil {
+		return "", fmt.Errorf("código de barras do DANFE inválido: %w", err)
+	}
+	return s, nil
+}
+
+// ConferirCodigoBarras confere o código de barras lido do DANFE com a chave de acesso esperada
+//
+// A chave esperada pode vir com o prefixo "NFe" do Id ou com espaços. Retorna
+// o erro de LerCodigoBarras para uma leitura inválida e ErrChaveDivergente
+// (com as duas chaves na mensagem) quando o DANFE é de outra nota.
+func ConferirCodigoBarras(lido, chave string) error {
+	lida, err := LerCodigoBarras(lido)
+	if err != nil {
+		return err
+	}
+
+	esperada := ExtractChaveFromID(chave)
+	if esperada == "" {
+		esperada = OnlyDigits(chave)
+	}
+	if lida != esperada {
+		return fmt.Errorf("%w: lida %s, esperada %s", ErrChaveDivergente, lida, esperada)
+	}
+	return nil
+}
+
+// ConferirCodigoBarrasXML confere o código de barras lido do DANFE com a chave do XML recebido
+//
+// Para o recebimento de mercadorias: o DANFE que chega com a carga é lido no
+// leitor e conferido com o XML enviado pelo fornecedor. Aceita a NF-e, o
+// procNFe e os XMLs só com o protocolo (protNFe, retConsSitNFe), que trazem a
+// chave em chNFe; no procNFe, a chave do protocolo tem de ser a da nota.
+//
+// Exemplo:
+//
+//	xmlData, _ := os.ReadFile("recebidos/" + arquivo)
+//	err := nfe.ConferirCodigoBarrasXML(leitura, xmlData)
+//	if errors.Is(err, nfe.ErrChaveDivergente) {
+//	    fmt.Println("DANFE não corresponde ao XML:", err)
+//	}
+func ConferirCodigoBarrasXML(lido string, xmlData []byte) error {
+	chave, err := chaveDoXML(xmlData)
+	if err != nil {
+		return err
+	}
+	return ConferirCodigoBarras(lido, chave)
+}
+
+// chaveDoXML devolve a chave do infNFe/@Id ou, sem ele, do primeiro chNFe (protocolo)
+func chaveDoXML(xmlData []byte) (string, error) {
+	if err := conferirXML(xmlData); err != nil {
+		return "", err
+	}
+
+	var idNota, chNFe string
+	dec := xml.NewDecoder(bytes.NewReader(xmlData))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", comCodigo(CodigoParse, fmt.Errorf("falha ao ler XML: %w", err))
+		}
+
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch el.Name.Local {
+		case "infNFe":
+			for _, a := range el.Attr {
+				if a.Name.Local == "Id" && idNota == "" {
+					if idNota, err = ExtrairChaveDoID(a.Value); err != nil {
+						return "", err
+					}
+				}
+			}
+		case "chNFe":
+			var v string
+			if err := dec.DecodeElement(&v, &el); err != nil {
+				return "", comCodigo(CodigoParse, fmt.Errorf("falha ao ler chNFe: %w", err))
+			}
+			if chNFe == "" {
+				chNFe = strings.TrimSpace(v)
+			}
+		}
+	}
+
+	switch {
+	case idNota != "" && chNFe != "" && idNota != chNFe:
+		return "", comCodigo(CodigoParse, fmt.Errorf("chave do protocolo (%s) difere da chave da nota (%s)", chNFe, idNota))
+	case idNota != "":
+		return idNota, nil
+	case chNFe != "":
+		return chNFe, nil
+	}
+	return "", comCodigo(CodigoParse, fmt.Errorf("chave de acesso não encontrada no XML (infNFe/@Id ou chNFe)"))
+}
@@ -0,0 +1,214 @@
+package nfe
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// CodigoErro identifica a causa de uma falha com um código estável (ex: "NFE-XSD-001")
+//
+// As mensagens são em português e trazem detalhes variáveis (linha do XSD,
+// endereço da SEFAZ); os códigos não: dashboards e scripts de suporte podem
+// agrupar as falhas por eles. Novos códigos podem surgir, mas um código
+// existente não muda de significado.
+type CodigoErro string
+
+const (
+	// CodigoXMLIlegivel: o XML não pôde ser lido (arquivo ausente, sem permissão ou maior que TamanhoMaxXML)
+	CodigoXMLIlegivel CodigoErro = "NFE-XML-001"
+
+	// CodigoXSDInvalido: o XML não segue o schema (inclui XML malformado)
+	CodigoXSDInvalido CodigoErro = "NFE-XSD-001"
+
+	// CodigoXSDIndisponivel: o XSD não foi encontrado ou não pôde ser carregado (configuração)
+	CodigoXSDIndisponivel CodigoErro = "NFE-XSD-002"
+
+	// CodigoParse: XML válido no XSD, mas que não pôde ser interpretado como NF-e ou procNFe
+	CodigoParse CodigoErro = "NFE-PARSE-001"
+
+	// CodigoChaveFormato: a chave de acesso não tem 44 dígitos numéricos
+	CodigoChaveFormato CodigoErro = "NFE-CHAVE-FORMATO"
+
+	// CodigoChaveDV: o dígito verificador da chave de acesso não confere
+	CodigoChaveDV CodigoErro = "NFE-CHAVE-DV"
+
+	// CodigoChaveCampo: a chave de acesso tem UF inexistente ou mês inválido
+	CodigoChaveCampo CodigoErro = "NFE-CHAVE-CAMPO"
+
+	// CodigoRegras: há achados de erro nas regras de negócio (ver ValidationResult.Achados)
+	CodigoRegras CodigoErro = "NFE-REGRAS"
+
+	// CodigoCertificado: certificado do cliente ou CAs ausentes, ilegíveis ou com senha incorreta
+	CodigoCertificado CodigoErro = "NFE-CERT"
+
+	// CodigoSefazTLS: o handshake mTLS falhou (certificado recusado, CA desconhecida, certificado vencido)
+	CodigoSefazTLS CodigoErro = "NFE-SEFAZ-TLS"
+
+	// CodigoSefazTimeout: a SEFAZ não respondeu dentro do prazo (WithTimeout ou o prazo do contexto)
+	CodigoSefazTimeout CodigoErro = "NFE-SEFAZ-TIMEOUT"
+
+	// CodigoSefazConexao: falha de rede até a SEFAZ (DNS, conexão recusada ou interrompida)
+	CodigoSefazConexao CodigoErro = "NFE-SEFAZ-CONEXAO"
+
+	// CodigoSefazResposta: a SEFAZ (ou um proxy) respondeu sem o retorno esperado
+	CodigoSefazResposta CodigoErro = "NFE-SEFAZ-RESPOSTA"
+
+	// CodigoSefazRejeitada: a SEFAZ respondeu, mas a nota não está autorizada (o cStat está em Status.Codigo)
+	CodigoSefazRejeitada CodigoErro = "NFE-SEFAZ-REJEITADA"
+
+	// CodigoSefazPayload: a requisição montada para a SEFAZ não passou no XSD (Config.SchemasRequisicao) e não foi enviada
+	CodigoSefazPayload CodigoErro = "NFE-SEFAZ-PAYLOAD"
+
+	// CodigoSefazUF: a UF não tem autorizador conhecido
+	CodigoSefazUF CodigoErro = "NFE-SEFAZ-UF"
+
+	// CodigoConfig: a configuração do cliente tem valores inválidos (ambiente, UF, CNPJ, URLs)
+	CodigoConfig CodigoErro = "NFE-CONFIG"
+
+	// CodigoVetada: um Hook (Client.Use) vetou uma fase
+	CodigoVetada CodigoErro = "NFE-HOOK-VETO"
+
+	// CodigoInterrompida: o contexto terminou antes do resultado
+	CodigoInterrompida CodigoErro = "NFE-INTERROMPIDA"
+
+	// CodigoDesconhecido: falha sem classificação (ex: erro de um Consulter próprio)
+	CodigoDesconhecido CodigoErro = "NFE-ERRO"
+)
+
+// CodigoDe classifica err (procurando em toda a cadeia) em um CodigoErro
+//
+// Devolve "" para err nil e CodigoDesconhecido quando nenhuma causa é
+// reconhecida.
+//
+// Exemplo:
+//
+//	if err := nfe.ValidarChaveAcesso(chave); err != nil {
+//	    metricaFalhas.WithLabelValues(string(nfe.CodigoDe(err))).Inc() // NFE-CHAVE-DV...
+//	}
+func CodigoDe(err error) CodigoErro {
+	if err == nil {
+		return ""
+	}
+
+	var ve *ValidationError
+	if errors.As(err, &ve) && ve.CodigoErro != "" {
+		return ve.CodigoErro
+	}
+	var codificado *erroCodificado
+	if errors.As(err, &codificado) {
+		return codificado.codigo
+	}
+
+	switch {
+	case errors.Is(err, sefaz.ErrPayload):
+		return CodigoSefazPayload
+	case errors.Is(err, validation.ErrSchema):
+		return CodigoXSDIndisponivel
+	case errors.Is(err, sefaz.ErrCertificado):
+		return CodigoCertificado
+	case errors.Is(err, sefaz.ErrResposta):
+		return CodigoSefazResposta
+	case errors.Is(err, sefaz.ErrUF):
+		return CodigoSefazUF
+	case errors.Is(err, sefaz.ErrConfig):
+		return CodigoConfig
+	case errors.Is(err, context.Canceled):
+		return CodigoInterrompida
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodigoSefazTimeout
+	case falhaTLS(err):
+		return CodigoSefazTLS
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return CodigoSefazTimeout
+		}
+		return CodigoSefazConexao
+	}
+	return CodigoDesconhecido
+}
+
+// falhaTLS indica se err veio do handshake mTLS: verificação do certificado do servidor ou alerta enviado por ele
+func falhaTLS(err error) bool {
+	var (
+		verificacao *tls.CertificateVerificationError
+		cabecalho   tls.RecordHeaderError
+		autoridade  x509.UnknownAuthorityError
+		invalido    x509.CertificateInvalidError
+		host        x509.HostnameError
+		op          *net.OpError
+	)
+	switch {
+	case errors.As(err, &verificacao), errors.As(err, &cabecalho),
+		errors.As(err, &autoridade), errors.As(err, &invalido), errors.As(err, &host):
+		return true
+	case errors.As(err, &op):
+		return op.Op == "remote error" // Alerta TLS da SEFAZ (ex: bad certificate)
+	}
+	return false
+}
+
+// erroCodificado carrega o CodigoErro de uma falha deste pacote, sem mudar a mensagem
+type erroCodificado struct {
+	codigo CodigoErro
+	err    error
+}
+
+func (e *erroCodificado) Error() string {
+	return e.err.Error()
+}
+
+func (e *erroCodificado) Unwrap() error {
+	return e.err
+}
+
+// comCodigo marca err com o código (ver CodigoDe)
+func comCodigo(codigo CodigoErro, err error) error {
+	return &erroCodificado{codigo: codigo, err: err}
+}
+
+// codigoFase é o CodigoErro do ValidationError criado por novoErro
+//
+// A fase define o código, salvo quando a causa é mais específica: XSD que não
+// carregou, validação interrompida, chave do Id inválida no parse ou as
+// falhas da consulta à SEFAZ.
+func codigoFase(code string, causa error) CodigoErro {
+	switch code {
+	case ResultadoVetada:
+		return CodigoVetada
+	case ResultadoParse:
+		if c := CodigoDe(causa); c == CodigoChaveFormato || c == CodigoChaveDV {
+			return c
+		}
+		return CodigoParse
+	case ResultadoXSDInvalido:
+		if c := CodigoDe(causa); c == CodigoXSDIndisponivel || c == CodigoInterrompida {
+			return c
+		}
+		return CodigoXSDInvalido
+	}
+	return CodigoDe(causa)
+}
+
+// CodigoErro devolve o código da falha do resultado: o de Erro, a rejeição da SEFAZ ou os achados de erro
+//
+// Devolve "" quando todas as fases executadas passaram.
+func (r *ValidationResult) CodigoErro() CodigoErro {
+	switch {
+	case r.Erro != nil:
+		return r.Erro.CodigoErro
+	case r.Status.Codigo != "" && !r.Autorizado:
+		return CodigoSefazRejeitada
+	case TemErros(r.Achados):
+		return CodigoRegras
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package nfe
+
+import (
+	"context"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// Consulter consulta a situação da NF-e na SEFAZ (NfeConsultaProtocolo4) pela chave de acesso
+//
+// O Client usa o cliente SEFAZ com o certificado da Config; WithConsulter
+// injeta outra implementação, sem certificado nem rede. Um erro é tratado
+// como falha de conectividade (ResultadoConectividade).
+//
+// Exemplo (teste unitário):
+//
+//	fake := nfe.ConsulterFunc(func(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+//	    return nfe.ConsultaSefaz{Autorizado: true, Status: nfe.StatusSefaz{Codigo: "100"}}, nil
+//	})
+//	client, err := nfe.NewClient(nfe.Config{}, nfe.WithConsulter(fake))
+type Consulter interface {
+	ConsultarSituacao(ctx context.Context, chave string) (ConsultaSefaz, error)
+}
+
+// ConsulterFunc adapta uma função comum ao Consulter
+type ConsulterFunc func(ctx context.Context, chave string) (ConsultaSefaz, error)
+
+// ConsultarSituacao chama f(ctx, chave)
+func (f ConsulterFunc) ConsultarSituacao(ctx context.Context, chave string) (ConsultaSefaz, error) {
+	return f(ctx, chave)
+}
+
+// ConsultaSefaz é a situação da nota na SEFAZ, devolvida pelo Consulter e guardada no Cache
+type ConsultaSefaz struct {
+	Autorizado   bool
+	Status       StatusSefaz
+	ConsultadoEm time.Time // Preenchido pelo Client quando o Consulter não informa
+	Resposta     string    // XML bruto da resposta (retConsSitNFe); opcional, ver WithRespostaSefaz
+}
+
+// consulterSefaz é o Consulter padrão: o cliente SEFAZ interno, com o certificado do Client
+type consulterSefaz struct {
+	cliente *sefaz.Client
+}
+
+// ConsultarSituacao consulta a chave no web service da UF da chave, no ambiente do contexto (ver AmbienteDe)
+func (s consulterSefaz) ConsultarSituacao(ctx context.Context, chave string) (ConsultaSefaz, error) {
+	var (
+		status validation.SefazStatus
+		err    error
+	)
+	if a := AmbienteDe(ctx); a != AmbientePadrao {
+		status, err = s.cliente.ConsultaSituacaoNFeAmbiente(ctx, chave, a == Producao)
+	} else {
+		status, err = s.cliente.ConsultaSituacaoNFeContext(ctx, chave)
+	}
+	if err != nil {
+		return ConsultaSefaz{}, err
+	}
+	return ConsultaSefaz{
+		Autorizado: status.Autorizado,
+		Status: StatusSefaz{
+			Codigo:   status.Codigo,
+			Mensagem: status.Mensagem,
+		},
+		ConsultadoEm: time.Now(),
+		Resposta:     status.Protocolo,
+	}, nil
+}
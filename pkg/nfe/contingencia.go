@@ -0,0 +1,198 @@
+package nfe
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Tipos de emissão (ide/tpEmis) usados pelas regras de contingência
+const (
+	TpEmisNormal           = "1"
+	TpEmisContingenciaNFCe = "9" // NFC-e em contingência off-line
+)
+
+// PrazoContingenciaNFCePadrao é o prazo para transmitir uma NFC-e emitida em contingência off-line
+//
+// Use ConfigRegras.PrazoContingenciaNFCe para ajustar.
+const PrazoContingenciaNFCePadrao = 24 * time.Hour
+
+// Tamanho aceito para a justificativa da entrada em contingência (xJust)
+const (
+	minXJust = 15
+	maxXJust = 256
+)
+
+// Códigos de rejeição SEFAZ relacionados à contingência
+const (
+	// RejeicaoJustificativaNormal: justificativa de contingência informada em emissão normal (cStat 556)
+	RejeicaoJustificativaNormal = "556"
+
+	// RejeicaoSemJustificativa: justificativa de entrada em contingência não informada (cStat 557)
+	RejeicaoSemJustificativa = "557"
+)
+
+// parametrosQRCodeOffline é a quantidade de parâmetros do QR Code versão 2 em contingência off-line:
+// chave|2|tpAmb|dia da emissão|vNF|digVal|cIdToken|hash
+const parametrosQRCodeOffline = 8
+
+// VerificarContingenciaNFCe confere os campos da NFC-e emitida em contingência off-line (tpEmis 9)
+//
+// Verifica:
+//   - dhCont informada e não posterior à dhEmi
+//   - xJust informada, com 15 a 256 caracteres (rejeição 557)
+//   - QR Code na variante off-line: na versão 2, chave, tpAmb, dia da emissão,
+//     vNF e digVal (DigestValue da assinatura em hexadecimal) iguais aos da nota
+//   - transmissão dentro do prazo: dhRecbto do protocolo (ou agora, se a nota
+//     ainda não tem protocolo) até prazo depois da dhEmi
+//
+// Em emissão normal (tpEmis 1) confere apenas que dhCont e xJust não foram
+// informadas (rejeição 556).
+//
+// Exemplo:
+//
+//	nota, _ := nfe.ParseNFe(xmlData)
+//	for _, inc := range nfe.VerificarContingenciaNFCe(nota, time.Now(), nfe.PrazoContingenciaNFCePadrao) {
+//	    fmt.Printf("[%s] %s: %s\n", inc.Codigo, inc.Grupo, inc.Mensagem)
+//	}
+func VerificarContingenciaNFCe(nfe *NFeEnvelope, agora time.Time, prazo time.Duration) []Inconsistencia {
+	var inconsistencias []Inconsistencia
+	ide := nfe.InfNFe.Ide
+	dhCont, xJust := strings.TrimSpace(ide.DhCont), strings.TrimSpace(ide.XJust)
+
+	if ide.TpEmis == TpEmisNormal {
+		if dhCont != "" || xJust != "" {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Codigo:   RejeicaoJustificativaNormal,
+				Grupo:    "xJust",
+				Mensagem: "dhCont/xJust de contingência informados em NFC-e de emissão normal (tpEmis = 1)",
+			})
+		}
+		return inconsistencias
+	}
+	if ide.TpEmis != TpEmisContingenciaNFCe {
+		return nil
+	}
+
+	dhEmi, errEmi := parseDataHora(ide.DhEmi)
+
+	// 1) Entrada em contingência
+	if dhCont == "" {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Grupo:    "dhCont",
+			Mensagem: "NFC-e em contingência off-line (tpEmis = 9) sem data/hora de entrada em contingência (dhCont)",
+		})
+	} else if inicio, err := parseDataHora(dhCont); err != nil {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Grupo:    "dhCont",
+			Mensagem: fmt.Sprintf("dhCont inválida: %v", err),
+		})
+	} else if errEmi == nil && inicio.After(dhEmi) {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Grupo:    "dhCont",
+			Mensagem: fmt.Sprintf("entrada em contingência (%s) posterior à emissão (%s)", ide.DhCont, ide.DhEmi),
+		})
+	}
+
+	if xJust == "" {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Codigo:   RejeicaoSemJustificativa,
+			Grupo:    "xJust",
+			Mensagem: "NFC-e em contingência off-line (tpEmis = 9) sem justificativa (xJust)",
+		})
+	} else if n := utf8.RuneCountInString(xJust); n < minXJust || n > maxXJust {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Grupo:    "xJust",
+			Mensagem: fmt.Sprintf("justificativa da contingência com %d caracteres (esperado de %d a %d)", n, minXJust, maxXJust),
+		})
+	}
+
+	// 2) QR Code off-line (a ausência do QR Code é da regra modelo-nfce)
+	if nfe.InfNFeSupl != nil && strings.TrimSpace(nfe.InfNFeSupl.QrCode) != "" {
+		inconsistencias = append(inconsistencias, verificarQRCodeOffline(nfe, dhEmi, errEmi == nil)...)
+	}
+
+	// 3) Prazo de transmissão
+	if errEmi == nil && prazo > 0 {
+		if p := nfe.Protocolo; p != nil {
+			if recebimento, err := parseDataHora(p.DhRecbto); err == nil && recebimento.Sub(dhEmi) > prazo {
+				inconsistencias = append(inconsistencias, Inconsistencia{
+					Grupo: "dhRecbto",
+					Mensagem: fmt.Sprintf("NFC-e em contingência transmitida %s após a emissão, além do prazo de %s",
+						duracaoHoras(recebimento.Sub(dhEmi)), duracaoHoras(prazo)),
+				})
+			}
+		} else if agora.Sub(dhEmi) > prazo {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Grupo: "dhEmi",
+				Mensagem: fmt.Sprintf("NFC-e em contingência sem protocolo de autorização emitida há %s: prazo de transmissão de %s vencido",
+					duracaoHoras(agora.Sub(dhEmi)), duracaoHoras(prazo)),
+			})
+		}
+	}
+
+	return inconsistencias
+}
+
+// verificarQRCodeOffline confere os parâmetros do QR Code contra a nota
+//
+// Só a versão 2 tem os campos da contingência conferidos; nas demais apenas
+// chave e tpAmb, que ocupam as mesmas posições.
+func verificarQRCodeOffline(nfe *NFeEnvelope, dhEmi time.Time, temDhEmi bool) []Inconsistencia {
+	falha := func(formato string, args ...any) []Inconsistencia {
+		return []Inconsistencia{{Grupo: "qrCode", Mensagem: fmt.Sprintf(formato, args...)}}
+	}
+
+	p := parametrosQRCode(nfe.InfNFeSupl.QrCode)
+	if len(p) < 3 {
+		return falha("QR Code sem os parâmetros da consulta (p=chave|versão|tpAmb|...)")
+	}
+
+	var inconsistencias []Inconsistencia
+	adicionar := func(formato string, args ...any) {
+		inconsistencias = append(inconsistencias, falha(formato, args...)...)
+	}
+
+	if chave := ExtractChaveFromID(nfe.InfNFe.ID); chave != "" && p[0] != chave {
+		adicionar("chave do QR Code (%s) difere da chave da nota (%s)", p[0], chave)
+	}
+	if tpAmb := nfe.InfNFe.Ide.TpAmb; tpAmb != "" && p[2] != tpAmb {
+		adicionar("tpAmb do QR Code (%s) difere do tpAmb da nota (%s)", p[2], tpAmb)
+	}
+	if p[1] != "2" {
+		return inconsistencias
+	}
+
+	if len(p) != parametrosQRCodeOffline {
+		adicionar("QR Code na variante on-line (%d parâmetros) em NFC-e de contingência off-line: esperados %d (chave|2|tpAmb|dia|vNF|digVal|cIdToken|hash)",
+			len(p), parametrosQRCodeOffline)
+		return inconsistencias
+	}
+
+	if temDhEmi && p[3] != dhEmi.Format("02") {
+		adicionar("dia da emissão do QR Code (%s) difere do dia da dhEmi (%s)", p[3], dhEmi.Format("02"))
+	}
+	vQR, okQR := parseValor(p[4])
+	vNF, okNF := parseValor(nfe.InfNFe.Total.ICMSTot.VNF)
+	if okNF && (!okQR || math.Abs(vQR-vNF) > ToleranciaCalculoTributo+1e-9) {
+		adicionar("vNF do QR Code (%s) difere do valor da nota (%s)", p[4], nfe.InfNFe.Total.ICMSTot.VNF)
+	}
+	if digest := strings.TrimSpace(nfe.DigestValue); digest != "" && !strings.EqualFold(p[5], hex.EncodeToString([]byte(digest))) {
+		adicionar("digVal do QR Code difere do DigestValue da assinatura em hexadecimal")
+	}
+
+	return inconsistencias
+}
+
+// duracaoHoras formata a duração em horas e minutos (ex: "26h30min", "24h")
+func duracaoHoras(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h, m := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	if m == 0 {
+		return fmt.Sprintf("%dh", h)
+	}
+	return fmt.Sprintf("%dh%02dmin", h, m)
+}
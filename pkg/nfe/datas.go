@@ -0,0 +1,106 @@
+package nfe
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ToleranciaDhEmiFutura é quanto a dhEmi pode estar à frente do horário de
+// recebimento antes da SEFAZ rejeitar a nota (diferença de relógio aceita)
+const ToleranciaDhEmiFutura = 5 * time.Minute
+
+// Códigos de rejeição SEFAZ relacionados às datas da nota
+const (
+	// RejeicaoChaveDifereCampos: Campo Id não corresponde à concatenação dos campos (cStat 502)
+	RejeicaoChaveDifereCampos = "502"
+
+	// RejeicaoDhSaiEntMenorDhEmi: Data de Saída menor que a Data de Emissão (cStat 506)
+	RejeicaoDhSaiEntMenorDhEmi = "506"
+
+	// RejeicaoDhEmiFutura: Data-Hora de Emissão posterior ao horário de recebimento (cStat 703)
+	RejeicaoDhEmiFutura = "703"
+)
+
+// VerificarDatas confere as datas de emissão e saída/entrada da nota
+//
+// Verifica:
+//   - dhEmi não está no futuro além de ToleranciaDhEmiFutura (rejeição 703)
+//   - dhSaiEnt, quando informada, não é anterior à dhEmi (rejeição 506)
+//   - o AAMM da chave de acesso corresponde ao ano/mês da dhEmi (rejeição 502;
+//     com a chave do Id inválida, 236 ou 502 no lugar da conferência)
+//
+// Parâmetros:
+//   - nfe: nota já parseada
+//   - agora: horário de referência (normalmente time.Now())
+//
+// Exemplo:
+//
+//	nota, _ := nfe.ParseNFe(xmlData)
+//	for _, inc := range nfe.VerificarDatas(nota, time.Now()) {
+//	    fmt.Printf("[%s] %s\n", inc.Codigo, inc.Mensagem)
+//	}
+func VerificarDatas(nfe *NFeEnvelope, agora time.Time) []Inconsistencia {
+	var inconsistencias []Inconsistencia
+	ide := nfe.InfNFe.Ide
+
+	dhEmi, err := parseDataHora(ide.DhEmi)
+	if err != nil {
+		return []Inconsistencia{{
+			Grupo:    "dhEmi",
+			Mensagem: fmt.Sprintf("dhEmi inválida: %v", err),
+		}}
+	}
+
+	// 1) dhEmi no futuro
+	if dhEmi.After(agora.Add(ToleranciaDhEmiFutura)) {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Codigo:   RejeicaoDhEmiFutura,
+			Grupo:    "dhEmi",
+			Mensagem: fmt.Sprintf("Data-Hora de Emissão (%s) posterior ao horário de recebimento", ide.DhEmi),
+		})
+	}
+
+	// 2) dhSaiEnt anterior à emissão
+	if strings.TrimSpace(ide.DhSaiEnt) != "" {
+		dhSaiEnt, err := parseDataHora(ide.DhSaiEnt)
+		if err != nil {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Grupo:    "dhSaiEnt",
+				Mensagem: fmt.Sprintf("dhSaiEnt inválida: %v", err),
+			})
+		} else if dhSaiEnt.Before(dhEmi) {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Codigo:   RejeicaoDhSaiEntMenorDhEmi,
+				Grupo:    "dhSaiEnt",
+				Mensagem: fmt.Sprintf("Data de Saída/Entrada (%s) menor que a Data de Emissão (%s)", ide.DhSaiEnt, ide.DhEmi),
+			})
+		}
+	}
+
+	// 3) AAMM da chave x dhEmi (no fuso informado na própria nota)
+	if chave, err := ExtrairChaveDoID(nfe.InfNFe.ID); err != nil {
+		inconsistencias = append(inconsistencias, inconsistenciaChaveID(nfe.InfNFe.ID, err))
+	} else {
+		aammChave := chave[2:6]
+		aammEmi := dhEmi.Format("0601")
+		if aammChave != aammEmi {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Codigo:   RejeicaoChaveDifereCampos,
+				Grupo:    "chNFe",
+				Mensagem: fmt.Sprintf("AAMM da chave de acesso (%s) difere do ano/mês da dhEmi (%s)", aammChave, aammEmi),
+			})
+		}
+	}
+
+	return inconsistencias
+}
+
+// parseDataHora interpreta datas no formato UTC da NF-e (AAAA-MM-DDThh:mm:ssTZD)
+func parseDataHora(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("data não informada")
+	}
+	return time.Parse(time.RFC3339, s)
+}
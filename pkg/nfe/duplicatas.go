@@ -0,0 +1,93 @@
+package nfe
+
+import (
+	"fmt"
+	"math"
+)
+
+// Meios de pagamento (detPag/tPag) que representam o próprio parcelamento,
+// e por isso não abatem o valor a ser coberto pelas duplicatas
+var tPagParcelamento = map[string]bool{
+	"14": true, // Duplicata Mercantil
+	"15": true, // Boleto Bancário
+	"90": true, // Sem pagamento
+}
+
+// VerificarDuplicatas confere a soma das duplicatas (cobr/dup/vDup) contra o valor da nota
+//
+// A soma das duplicatas deve ser igual ao vNF menos os pagamentos à vista
+// já recebidos na emissão (detPag com indPag = 0 em meios como dinheiro, PIX
+// ou cartão). Quando a fatura informa vLiq, a soma também deve fechar com ele.
+//
+// Notas sem duplicatas não são conferidas.
+//
+// Exemplo:
+//
+//	nota, _ := nfe.ParseNFe(xmlData)
+//	for _, inc := range nfe.VerificarDuplicatas(nota) {
+//	    fmt.Println(inc.Mensagem)
+//	}
+func VerificarDuplicatas(nfe *NFeEnvelope) []Inconsistencia {
+	cobr := nfe.InfNFe.Cobr
+	if cobr == nil || len(cobr.Dup) == 0 {
+		return nil
+	}
+
+	var inconsistencias []Inconsistencia
+
+	soma := 0.0
+	for _, dup := range cobr.Dup {
+		if v, ok := parseValor(dup.VDup); ok {
+			soma += v
+		}
+	}
+	soma = arredondar(soma)
+
+	// 1) Soma das duplicatas x vNF (menos pagamentos à vista)
+	if vNF, ok := parseValor(nfe.InfNFe.Total.ICMSTot.VNF); ok {
+		vista := pagamentosAVista(nfe.InfNFe.Pag)
+		esperado := arredondar(vNF - vista)
+
+		if math.Abs(soma-esperado) > ToleranciaCalculoTributo+1e-9 {
+			msg := fmt.Sprintf("soma das duplicatas (%.2f) difere do vNF (%.2f)", soma, vNF)
+			if vista > 0 {
+				msg = fmt.Sprintf("soma das duplicatas (%.2f) difere do vNF menos pagamentos à vista (%.2f - %.2f = %.2f)",
+					soma, vNF, vista, esperado)
+			}
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Grupo:    "cobr/dup",
+				Mensagem: msg,
+			})
+		}
+	}
+
+	// 2) Soma das duplicatas x valor líquido da fatura
+	if cobr.Fat != nil {
+		if vLiq, ok := parseValor(cobr.Fat.VLiq); ok && math.Abs(soma-vLiq) > ToleranciaCalculoTributo+1e-9 {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Grupo:    "cobr/fat",
+				Mensagem: fmt.Sprintf("soma das duplicatas (%.2f) difere do valor líquido da fatura (%.2f)", soma, vLiq),
+			})
+		}
+	}
+
+	return inconsistencias
+}
+
+// pagamentosAVista soma os pagamentos à vista que não são o próprio parcelamento
+func pagamentosAVista(pag *Pag) float64 {
+	if pag == nil {
+		return 0
+	}
+
+	total := 0.0
+	for _, det := range pag.DetPag {
+		if det.IndPag != "0" || tPagParcelamento[det.TPag] {
+			continue
+		}
+		if v, ok := parseValor(det.VPag); ok {
+			total += v
+		}
+	}
+	return arredondar(total)
+}
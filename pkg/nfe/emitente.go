@@ -0,0 +1,72 @@
+package nfe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RejeicaoUFEmitenteDiverge: Sigla da UF do Emitente diverge da UF autorizadora (cStat 247)
+const RejeicaoUFEmitenteDiverge = "247"
+
+// RejeicaoChaveDV: Chave de Acesso com dígito verificador inválido (cStat 236)
+const RejeicaoChaveDV = "236"
+
+// inconsistenciaChaveID descreve o Id sem chave válida (erro de ExtrairChaveDoID)
+//
+// As regras que conferem a chave com os campos da nota a reportam no lugar
+// da conferência: uma chave forjada ou digitada errado não passa em silêncio.
+func inconsistenciaChaveID(id string, err error) Inconsistencia {
+	if CodigoDe(err) == CodigoChaveDV {
+		return Inconsistencia{Codigo: RejeicaoChaveDV, Grupo: "chNFe", Mensagem: fmt.Sprintf("chave do Id inválida: DV (Id %q)", id)}
+	}
+	return Inconsistencia{Codigo: RejeicaoChaveDifereCampos, Grupo: "chNFe", Mensagem: fmt.Sprintf("chave do Id inválida: formato (Id %q)", id)}
+}
+
+// VerificarUFEmitente confere a UF codificada na chave de acesso com a UF do emitente
+//
+// Os dois primeiros dígitos da chave são o código IBGE da UF autorizadora, que
+// deve ser a mesma UF do endereço do emitente (enderEmit/UF) e do ide/cUF.
+// Divergência indica emitente mal configurado no ERP ou chave forjada. Um
+// Id sem chave válida (dígitos ou DV) é reportado no lugar da conferência.
+//
+// Exemplo:
+//
+//	nota, _ := nfe.ParseNFe(xmlData)
+//	if incs := nfe.VerificarUFEmitente(nota); len(incs) > 0 {
+//	    fmt.Println("Chave suspeita:", incs[0].Mensagem)
+//	}
+func VerificarUFEmitente(nfe *NFeEnvelope) []Inconsistencia {
+	chave, err := ExtrairChaveDoID(nfe.InfNFe.ID)
+	if err != nil {
+		return []Inconsistencia{inconsistenciaChaveID(nfe.InfNFe.ID, err)}
+	}
+
+	var inconsistencias []Inconsistencia
+	cUFChave := chave[:2]
+
+	siglaChave := SiglaUF(cUFChave)
+	if siglaChave == "" {
+		return []Inconsistencia{{
+			Grupo:    "chNFe",
+			Mensagem: fmt.Sprintf("código de UF da chave de acesso (%s) inválido", cUFChave),
+		}}
+	}
+
+	if ufEmit := strings.ToUpper(strings.TrimSpace(nfe.InfNFe.Emit.EnderEmit.UF)); ufEmit != "" && ufEmit != siglaChave {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Codigo:   RejeicaoUFEmitenteDiverge,
+			Grupo:    "enderEmit/UF",
+			Mensagem: fmt.Sprintf("UF da chave de acesso (%s - %s) difere da UF do emitente (%s)", cUFChave, siglaChave, ufEmit),
+		})
+	}
+
+	if cUF := strings.TrimSpace(nfe.InfNFe.Ide.CUF); cUF != "" && cUF != cUFChave {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Codigo:   RejeicaoChaveDifereCampos,
+			Grupo:    "cUF",
+			Mensagem: fmt.Sprintf("UF da chave de acesso (%s) difere do ide/cUF (%s)", cUFChave, cUF),
+		})
+	}
+
+	return inconsistencias
+}
@@ -0,0 +1,393 @@
+package nfe
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Nomes em inglês da API, para equipes de fora do Brasil que integram com
+// fornecedores brasileiros. Cada função apenas delega à original em
+// português (indicada no comentário), que continua sendo a referência: os
+// tipos, os erros e as mensagens são os mesmos.
+
+// Type names in English (aliases: values are interchangeable with the Portuguese types)
+type (
+	// Option configures NewClient (alias of Opcao)
+	Option = Opcao
+	// AccessKey holds the fields of a 44-digit access key (alias of ChaveDecomposta)
+	AccessKey = ChaveDecomposta
+	// InvoiceData holds the main data of the invoice (alias of DadosNFe)
+	InvoiceData = DadosNFe
+	// Company is the issuer or recipient of the invoice (alias of Empresa)
+	Company = Empresa
+	// RulesConfig enables and tunes the business rules (alias of ConfigRegras)
+	RulesConfig = ConfigRegras
+	// Finding is a business rule violation (alias of Achado)
+	Finding = Achado
+	// MemoryCache is the in-process Cache (alias of CacheMemoria)
+	MemoryCache = CacheMemoria
+	// XSDCache keeps compiled XSD schemas by path (alias of CacheXSD)
+	XSDCache = CacheXSD
+	// InvoiceReader walks a file with many invoices one at a time (alias of LeitorNotas)
+	InvoiceReader = LeitorNotas
+	// Level selects the phases run by Client.Validate (alias of Nivel)
+	Level = Nivel
+	// Environment is the SEFAZ environment of a call: Producao or Homologacao (alias of Ambiente)
+	Environment = Ambiente
+	// Validator is a custom check run in the rules phase (alias of Validador)
+	Validator = Validador
+	// BatchResult is the validation of one file of Client.ValidateBatch (alias of LoteResult)
+	BatchResult = LoteResult
+	// BatchSummary totals Client.ValidateBatch by outcome (alias of ResumoLote)
+	BatchSummary = ResumoLote
+	// ErrorCode is the stable code of a failure, e.g. "NFE-XSD-001" (alias of CodigoErro)
+	ErrorCode = CodigoErro
+	// Manager holds one Client per tenant and routes the validations to it (alias of Gerenciador)
+	Manager = Gerenciador
+	// ClientLoader creates a tenant's Client on first use (alias of CarregadorCliente)
+	ClientLoader = CarregadorCliente
+	// Stage is a phase of the pipeline composed in Options.Etapas (alias of Etapa)
+	Stage = Etapa
+	// ValidationState is what the stages of one validation share (alias of EstadoValidacao)
+	ValidationState = EstadoValidacao
+	// ReceiptQRCode is the content of the QR code printed on an NFC-e receipt (alias of QRCodeNFCe)
+	ReceiptQRCode = QRCodeNFCe
+	// BatchReceiptResult is the processing result of a batch queried by its receipt number (alias of RetornoRecibo)
+	BatchReceiptResult = RetornoRecibo
+	// InvoiceProtocol is the SEFAZ protocol of one invoice of the batch (alias of ProtocoloNota)
+	InvoiceProtocol = ProtocoloNota
+)
+
+// ValidateXSD validates the XML against the XSD schema only, without calling SEFAZ (ValidarApenasXSD)
+func ValidateXSD(xmlData []byte, xsdPath string) error {
+	return ValidarApenasXSD(xmlData, xsdPath)
+}
+
+// ValidateXSDReader validates the XML read from r against the XSD schema (ValidarXSDReader)
+func ValidateXSDReader(r io.Reader, xsdPath string) error {
+	return ValidarXSDReader(r, xsdPath)
+}
+
+// ValidateXMLFile reads the file and validates it against the XSD schema (ValidarXMLFile)
+func ValidateXMLFile(xmlPath, xsdPath string) error {
+	return ValidarXMLFile(xmlPath, xsdPath)
+}
+
+// ValidateBatch validates several files against the same XSD schema (ValidarLote)
+func ValidateBatch(xmlPaths []string, xsdPath string) map[string]error {
+	return ValidarLote(xmlPaths, xsdPath)
+}
+
+// ParseXML extracts the main invoice data from an NFe or procNFe XML (ParsearXML)
+func ParseXML(xmlData []byte) (*InvoiceData, error) {
+	return ParsearXML(xmlData)
+}
+
+// ParseXMLFile reads the file and extracts the main invoice data (ParsearXMLFile)
+func ParseXMLFile(xmlPath string) (*InvoiceData, error) {
+	return ParsearXMLFile(xmlPath)
+}
+
+// ParseXMLReader extracts the main invoice data while reading the XML from r (ParsearXMLReader)
+func ParseXMLReader(r io.Reader) (*InvoiceData, error) {
+	return ParsearXMLReader(r)
+}
+
+// ParseAccessKey validates the access key (format and check digit) and splits its fields (DecomporChave)
+func ParseAccessKey(key string) (*AccessKey, error) {
+	return DecomporChave(key)
+}
+
+// ValidateAccessKey checks the access key length, digits and check digit (ValidarChaveAcesso)
+func ValidateAccessKey(key string) error {
+	return ValidarChaveAcesso(key)
+}
+
+// ParseQRCode reads the access key from the content of an NFC-e receipt QR code (LerQRCode)
+func ParseQRCode(content string) (*ReceiptQRCode, error) {
+	return LerQRCode(content)
+}
+
+// ErrBarcodeMismatch means the DANFE barcode is not the expected access key (ErrChaveDivergente; same value)
+var ErrBarcodeMismatch = ErrChaveDivergente
+
+// ParseBarcode reads the access key from a scanned DANFE barcode (LerCodigoBarras)
+func ParseBarcode(scanned string) (string, error) {
+	return LerCodigoBarras(scanned)
+}
+
+// CheckBarcode checks that a scanned DANFE barcode is the given access key (ConferirCodigoBarras)
+func CheckBarcode(scanned, key string) error {
+	return ConferirCodigoBarras(scanned, key)
+}
+
+// CheckBarcodeXML checks that a scanned DANFE barcode is the access key of the XML (ConferirCodigoBarrasXML)
+func CheckBarcodeXML(scanned string, xmlData []byte) error {
+	return ConferirCodigoBarrasXML(scanned, xmlData)
+}
+
+// ExtractAccessKey returns the 44-digit access key of the XML (ExtrairChave)
+func ExtractAccessKey(xmlData []byte) (string, error) {
+	return ExtrairChave(xmlData)
+}
+
+// ExtractAccessKeyFromID returns the access key of the infNFe Id, checking digits and check digit (ExtrairChaveDoID)
+func ExtractAccessKeyFromID(id string) (string, error) {
+	return ExtrairChaveDoID(id)
+}
+
+// StateAbbreviation returns the state abbreviation ("SP") of an IBGE code ("35") (SiglaUF)
+func StateAbbreviation(ibgeCode string) string {
+	return SiglaUF(ibgeCode)
+}
+
+// StateCode returns the IBGE code ("35") of a state abbreviation ("SP") (CodigoUF)
+func StateCode(abbreviation string) string {
+	return CodigoUF(abbreviation)
+}
+
+// ErrorCodeOf classifies err (or any error in its chain) into an ErrorCode (CodigoDe)
+func ErrorCodeOf(err error) ErrorCode {
+	return CodigoDe(err)
+}
+
+// EvaluateRules runs the enabled business rules on the invoice (AvaliarRegras)
+func EvaluateRules(nfe *NFeEnvelope, cfg RulesConfig) []Finding {
+	return AvaliarRegras(nfe, cfg)
+}
+
+// HasErrors reports whether any finding has error severity (TemErros)
+func HasErrors(findings []Finding) bool {
+	return TemErros(findings)
+}
+
+// NewMemoryCache creates the in-process Cache; each SEFAZ response is kept for ttl (NewCacheMemoria)
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	return NewCacheMemoria(ttl)
+}
+
+// DefaultXSDCache is the process-wide XSD cache (CacheXSDPadrao; same value)
+var DefaultXSDCache = CacheXSDPadrao
+
+// NewXSDCache creates an empty XSD cache, separate from the default one (NewCacheXSD)
+func NewXSDCache() *XSDCache {
+	return NewCacheXSD()
+}
+
+// NewXSDCacheWithInterval is NewXSDCache with the minimum interval between file checks; 0 checks on every use (NewCacheXSDCom)
+func NewXSDCacheWithInterval(interval time.Duration) *XSDCache {
+	return NewCacheXSDCom(interval)
+}
+
+// Load compiles the XSD if it is not cached yet and returns it as a SchemaXSD (Carregar)
+func (c *CacheXSD) Load(xsdPath string) (SchemaXSD, error) {
+	return c.Carregar(xsdPath)
+}
+
+// Validate validates the XML against the cached XSD at xsdPath (Validar)
+func (c *CacheXSD) Validate(xmlData []byte, xsdPath string) error {
+	return c.Validar(xmlData, xsdPath)
+}
+
+// Evict drops the XSD at xsdPath from the cache; the next use reloads it (Descartar)
+func (c *CacheXSD) Evict(xsdPath string) {
+	c.Descartar(xsdPath)
+}
+
+// Paths lists the XSD paths loaded in the cache (Caminhos)
+func (c *CacheXSD) Paths() []string {
+	return c.Caminhos()
+}
+
+// Validate runs the validation pipeline up to the level chosen in o (Validar)
+func (c *Client) Validate(xmlData []byte, o Options) (*ValidationResult, error) {
+	return c.Validar(xmlData, o)
+}
+
+// ValidateContext is Validate with a context (ValidarContext)
+func (c *Client) ValidateContext(ctx context.Context, xmlData []byte, o Options) (*ValidationResult, error) {
+	return c.ValidarContext(ctx, xmlData, o)
+}
+
+// WithValidators adds custom checks to the rules phase of Client.Validate (WithValidadores)
+func WithValidators(v ...Validator) Option {
+	return WithValidadores(v...)
+}
+
+// WithTransport wraps the HTTP transport used for SEFAZ calls (WithTransporte)
+func WithTransport(wrap func(http.RoundTripper) http.RoundTripper) Option {
+	return WithTransporte(wrap)
+}
+
+// ValidateXML fully validates an XML file: XSD, parsing, business rules and SEFAZ (ValidarXML)
+func (c *Client) ValidateXML(xmlPath, xsdPath string) (*ValidationResult, error) {
+	return c.ValidarXML(xmlPath, xsdPath)
+}
+
+// ValidateXMLContext is ValidateXML with a context (ValidarXMLContext)
+func (c *Client) ValidateXMLContext(ctx context.Context, xmlPath, xsdPath string) (*ValidationResult, error) {
+	return c.ValidarXMLContext(ctx, xmlPath, xsdPath)
+}
+
+// ValidateXMLBytes fully validates an XML already in memory (ValidarXMLBytes)
+func (c *Client) ValidateXMLBytes(xmlData []byte, xsdPath string) (*ValidationResult, error) {
+	return c.ValidarXMLBytes(xmlData, xsdPath)
+}
+
+// ValidateXMLBytesContext is ValidateXMLBytes with a context (ValidarXMLBytesContext)
+func (c *Client) ValidateXMLBytesContext(ctx context.Context, xmlData []byte, xsdPath string) (*ValidationResult, error) {
+	return c.ValidarXMLBytesContext(ctx, xmlData, xsdPath)
+}
+
+// ValidateBatch validates the files concurrently, returning results in input order and a summary (Client.ValidarLote)
+func (c *Client) ValidateBatch(xmlPaths []string, o Options) ([]BatchResult, BatchSummary, error) {
+	return c.ValidarLote(xmlPaths, o)
+}
+
+// ValidateBatchContext is ValidateBatch with a context (Client.ValidarLoteContext)
+func (c *Client) ValidateBatchContext(ctx context.Context, xmlPaths []string, o Options) ([]BatchResult, BatchSummary, error) {
+	return c.ValidarLoteContext(ctx, xmlPaths, o)
+}
+
+// NewInvoiceReader creates the InvoiceReader that reads the document from r (NewLeitorNotas)
+func NewInvoiceReader(r io.Reader) *InvoiceReader {
+	return NewLeitorNotas(r)
+}
+
+// Next returns the next invoice in the file, or io.EOF when there are no more (Proxima)
+func (l *LeitorNotas) Next() (*NotaLida, error) {
+	return l.Proxima()
+}
+
+// ValidateStream validates a continuous flow of XML in parallel stages, delivering results in input order (Client.ValidarStream)
+func (c *Client) ValidateStream(ctx context.Context, input <-chan []byte, o Options) <-chan ValidationResult {
+	return c.ValidarStream(ctx, input, o)
+}
+
+// ValidateKey checks the invoice status at SEFAZ by access key only, optionally in another environment (ValidarChave)
+func (c *Client) ValidateKey(key string, env ...Environment) (*ValidationResult, error) {
+	return c.ValidarChave(key, env...)
+}
+
+// ValidateKeyContext is ValidateKey with a context (ValidarChaveContext)
+func (c *Client) ValidateKeyContext(ctx context.Context, key string, env ...Environment) (*ValidationResult, error) {
+	return c.ValidarChaveContext(ctx, key, env...)
+}
+
+// QueryBatchReceipt returns the processing result of a batch by its receipt number (ConsultarRecibo)
+func (c *Client) QueryBatchReceipt(ctx context.Context, receipt string, env ...Environment) (*BatchReceiptResult, error) {
+	return c.ConsultarRecibo(ctx, receipt, env...)
+}
+
+// ContextWithEnvironment returns a context whose SEFAZ queries use the environment (ComAmbiente)
+func ContextWithEnvironment(ctx context.Context, env Environment) context.Context {
+	return ComAmbiente(ctx, env)
+}
+
+// Certificate returns the client's mTLS digital certificate (Certificado)
+func (c *Client) Certificate() (*x509.Certificate, error) {
+	return c.Certificado()
+}
+
+// NewManager creates the Manager; load (optional) creates on demand the client of an unregistered tenant (NewGerenciador)
+func NewManager(load ClientLoader) *Manager {
+	return NewGerenciador(load)
+}
+
+// Register creates the tenant's client, replacing the previous one (Gerenciador.Registrar)
+func (g *Gerenciador) Register(tenant string, cfg Config, opts ...Option) error {
+	return g.Registrar(tenant, cfg, opts...)
+}
+
+// Add registers an existing client for the tenant, replacing the previous one (Gerenciador.Adicionar)
+func (g *Gerenciador) Add(tenant string, c *Client) {
+	g.Adicionar(tenant, c)
+}
+
+// Remove drops the tenant's client (Gerenciador.Remover)
+func (g *Gerenciador) Remove(tenant string) {
+	g.Remover(tenant)
+}
+
+// Certificates returns the digital certificate of each registered or loaded tenant (Gerenciador.Certificados)
+func (g *Gerenciador) Certificates() map[string]*x509.Certificate {
+	return g.Certificados()
+}
+
+// Client returns the tenant's client, loading it if needed (Gerenciador.Cliente)
+func (g *Gerenciador) Client(tenant string) (*Client, error) {
+	return g.Cliente(tenant)
+}
+
+// Validate runs Client.Validate with the tenant's client (Gerenciador.Validar)
+func (g *Gerenciador) Validate(tenant string, xmlData []byte, o Options) (*ValidationResult, error) {
+	return g.Validar(tenant, xmlData, o)
+}
+
+// ValidateContext is Validate with a context (Gerenciador.ValidarContext)
+func (g *Gerenciador) ValidateContext(ctx context.Context, tenant string, xmlData []byte, o Options) (*ValidationResult, error) {
+	return g.ValidarContext(ctx, tenant, xmlData, o)
+}
+
+// ValidateXML runs Client.ValidateXML with the tenant's client (Gerenciador.ValidarXML)
+func (g *Gerenciador) ValidateXML(tenant, xmlPath, xsdPath string) (*ValidationResult, error) {
+	return g.ValidarXML(tenant, xmlPath, xsdPath)
+}
+
+// ValidateXMLContext is ValidateXML with a context (Gerenciador.ValidarXMLContext)
+func (g *Gerenciador) ValidateXMLContext(ctx context.Context, tenant, xmlPath, xsdPath string) (*ValidationResult, error) {
+	return g.ValidarXMLContext(ctx, tenant, xmlPath, xsdPath)
+}
+
+// ValidateKey runs Client.ValidateKey with the tenant's client (Gerenciador.ValidarChave)
+func (g *Gerenciador) ValidateKey(tenant, key string) (*ValidationResult, error) {
+	return g.ValidarChave(tenant, key)
+}
+
+// ValidateKeyContext is ValidateKey with a context (Gerenciador.ValidarChaveContext)
+func (g *Gerenciador) ValidateKeyContext(ctx context.Context, tenant, key string) (*ValidationResult, error) {
+	return g.ValidarChaveContext(ctx, tenant, key)
+}
+
+// StageXSD validates the XML against an already loaded schema (EtapaXSD)
+func StageXSD(schema SchemaXSD) Stage {
+	return EtapaXSD(schema)
+}
+
+// StageXSDFile validates the XML against the schema at xsdPath (EtapaXSDArquivo)
+func StageXSDFile(xsdPath string) Stage {
+	return EtapaXSDArquivo(xsdPath)
+}
+
+// StageParse parses the XML as an NF-e or procNFe (EtapaParse)
+func StageParse() Stage {
+	return EtapaParse()
+}
+
+// StageRules runs the business rules and the client's validators; nil rules = the client's (EtapaRegras)
+func StageRules(rules *RulesConfig) Stage {
+	return EtapaRegras(rules)
+}
+
+// StageSignature checks the digital signature (EtapaAssinatura)
+func StageSignature() Stage {
+	return EtapaAssinatura()
+}
+
+// StageSefaz checks the invoice status at SEFAZ by access key (EtapaSefaz)
+func StageSefaz() Stage {
+	return EtapaSefaz()
+}
+
+// NewStage creates a custom stage named phase (NovaEtapa)
+func NewStage(phase string, run func(ctx context.Context, v *ValidationState) error) Stage {
+	return NovaEtapa(phase, run)
+}
+
+// Stages returns the stages Validate runs for o, to reorder or extend (Client.Etapas)
+func (c *Client) Stages(o Options) []Stage {
+	return c.Etapas(o)
+}
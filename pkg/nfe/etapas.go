@@ -0,0 +1,266 @@
+package nfe
+
+import (
+	"context"
+	"fmt"
+)
+
+// Etapa é uma fase do pipeline de Client.Validar, para compor em Options.Etapas
+//
+// As etapas prontas são EtapaXSD (ou EtapaXSDArquivo), EtapaParse,
+// EtapaRegras, EtapaAssinatura e EtapaSefaz; NovaEtapa cria uma etapa
+// própria. Cada etapa executada fica em ValidationResult.Fases, com span e
+// hooks (Client.Use) como as fases padrão.
+type Etapa struct {
+	fase       string
+	code       string // Resultado* do ValidationError quando a etapa falha
+	contexto   string // Início da mensagem do ValidationError
+	exigeParse bool   // Precisa da nota parseada (EstadoValidacao.Nota)
+	checaCtx   bool   // Não começa com o contexto encerrado
+
+	executar func(ctx context.Context, v *EstadoValidacao) (situacao string, err error)
+}
+
+// Fase devolve o nome da etapa (um dos Fase* ou o nome dado a NovaEtapa)
+func (e Etapa) Fase() string {
+	return e.fase
+}
+
+// EstadoValidacao é o que as etapas de uma validação compartilham
+type EstadoValidacao struct {
+	// XML é o documento em validação
+	XML []byte
+
+	// Nota é o XML interpretado pela EtapaParse (nil antes dela)
+	Nota *NFeEnvelope
+
+	// Resultado é o resultado em construção; as etapas próprias podem acrescentar achados
+	Resultado *ValidationResult
+
+	client    *Client
+	consultou bool // A SEFAZ respondeu (EtapaSefaz)
+}
+
+// EtapaXSD valida o XML com um XSD já carregado (ver Options.Schema)
+func EtapaXSD(schema SchemaXSD) Etapa {
+	return etapaXSD(Options{Schema: schema})
+}
+
+// EtapaXSDArquivo valida o XML com o XSD do caminho informado, compilado uma vez no CacheXSDPadrao (ver Options.XSD)
+func EtapaXSDArquivo(xsd string) Etapa {
+	return etapaXSD(Options{XSD: xsd})
+}
+
+// etapaXSD valida com o Schema ou o XSD de o; o sucesso marca ValidoXSD
+func etapaXSD(o Options) Etapa {
+	return Etapa{
+		fase:     FaseXSD,
+		code:     ResultadoXSDInvalido,
+		contexto: "falha na validação XSD",
+		executar: func(ctx context.Context, v *EstadoValidacao) (string, error) {
+			if err := validarSchema(ctx, v.XML, o); err != nil {
+				return "", err
+			}
+			v.Resultado.ValidoXSD = true
+			return SituacaoOK, nil
+		},
+	}
+}
+
+// EtapaParse interpreta o XML como NF-e (ou procNFe): preenche ChaveAcesso e DadosNFe
+//
+// EtapaRegras e EtapaSefaz precisam dela antes. Um Id sem chave válida
+// (ExtrairChaveDoID: dígitos ou DV) é falha da fase, com CodigoChaveFormato
+// ou CodigoChaveDV.
+func EtapaParse() Etapa {
+	return Etapa{
+		fase:     FaseParse,
+		code:     ResultadoParse,
+		contexto: "falha ao parsear XML",
+		executar: func(ctx context.Context, v *EstadoValidacao) (string, error) {
+			nota, err := ParseNFe(v.XML)
+			if err != nil {
+				return "", err
+			}
+			// Sem chave válida no Id, as regras e a SEFAZ não têm o que conferir
+			chave, err := ExtrairChaveDoID(nota.InfNFe.ID)
+			if err != nil {
+				return "", err
+			}
+			v.Nota = nota
+
+			r := v.Resultado
+			r.ChaveAcesso = chave
+			r.DadosNFe = convertNFeData(nota)
+			return SituacaoOK, nil
+		},
+	}
+}
+
+// EtapaRegras aplica as regras de negócio e os Validador do cliente (WithValidadores)
+//
+// regras substitui Config.Regras nesta etapa (nil = as do Client). Os
+// achados vão para ValidationResult.Achados e não interrompem a validação;
+// a fase fica como falha se algum for de erro.
+func EtapaRegras(regras *ConfigRegras) Etapa {
+	return Etapa{
+		fase:       FaseRegras,
+		exigeParse: true,
+		executar: func(ctx context.Context, v *EstadoValidacao) (string, error) {
+			cfg := v.regras(regras)
+			achados := AvaliarRegras(v.Nota, cfg)
+			achados = append(achados, avaliarValidadores(ctx, v.client.validadores, v.Resultado.DadosNFe, cfg)...)
+			return v.acrescentar(achados), nil
+		},
+	}
+}
+
+// EtapaAssinatura confere a assinatura digital (RegraAssinatura); divergências viram achados
+//
+// A severidade é a de RegraAssinatura nas regras do Client (erro, salvo
+// sobrescrita). Diferente de Options.Assinatura, a etapa roda mesmo com
+// RegraAssinatura desabilitada.
+func EtapaAssinatura() Etapa {
+	return etapaAssinatura(nil)
+}
+
+// etapaAssinatura é a EtapaAssinatura com a severidade de regras (nil = as do Client)
+func etapaAssinatura(regras *ConfigRegras) Etapa {
+	return Etapa{
+		fase: FaseAssinatura,
+		executar: func(ctx context.Context, v *EstadoValidacao) (string, error) {
+			severidade := severidadeAssinatura(v.regras(regras))
+			var achados []Achado
+			for _, inc := range VerificarAssinatura(v.XML) {
+				achados = append(achados, Achado{Regra: RegraAssinatura, Severidade: severidade, Inconsistencia: inc})
+			}
+			return v.acrescentar(achados), nil
+		},
+	}
+}
+
+// EtapaSefaz consulta a situação da nota pela chave de acesso, com o Consulter e o Cache do cliente
+//
+// A fase fica como falha se a nota não está autorizada; uma falha na
+// consulta interrompe a validação (ResultadoConectividade).
+func EtapaSefaz() Etapa {
+	return Etapa{
+		fase:       FaseSefaz,
+		code:       ResultadoConectividade,
+		contexto:   "falha na consulta SEFAZ",
+		exigeParse: true,
+		checaCtx:   true,
+		executar: func(ctx context.Context, v *EstadoValidacao) (string, error) {
+			status, err := v.client.consultarSefaz(ctx, v.Resultado.ChaveAcesso)
+			if err != nil {
+				return "", err
+			}
+
+			r := v.Resultado
+			r.Autorizado, r.Status = status.Autorizado, status.Status
+			v.client.guardarResposta(r, status)
+			v.consultou = true
+			if !status.Autorizado {
+				return SituacaoFalha, nil
+			}
+			return SituacaoOK, nil
+		},
+	}
+}
+
+// NovaEtapa cria uma etapa própria com o nome de fase informado (ex: "duplicidade")
+//
+// Um erro de executar interrompe a validação com um ValidationError de Code
+// ResultadoErro. Achados acrescentados a v.Resultado.Achados não
+// interrompem; a fase fica como falha se algum for de erro. executar só é
+// chamada com o contexto ainda ativo.
+//
+// Exemplo:
+//
+//	duplicidade := nfe.NovaEtapa("duplicidade", func(ctx context.Context, v *nfe.EstadoValidacao) error {
+//	    existe, err := banco.ChaveExiste(ctx, v.Resultado.ChaveAcesso)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    if existe {
+//	        return errors.New("nota já importada")
+//	    }
+//	    return nil
+//	})
+func NovaEtapa(fase string, executar func(ctx context.Context, v *EstadoValidacao) error) Etapa {
+	return Etapa{
+		fase:     fase,
+		code:     ResultadoErro,
+		contexto: "falha na etapa " + fase,
+		checaCtx: true,
+		executar: func(ctx context.Context, v *EstadoValidacao) (string, error) {
+			antes := len(v.Resultado.Achados)
+			if err := executar(ctx, v); err != nil {
+				return "", err
+			}
+			if antes <= len(v.Resultado.Achados) && TemErros(v.Resultado.Achados[antes:]) {
+				return SituacaoFalha, nil
+			}
+			return SituacaoOK, nil
+		},
+	}
+}
+
+// Etapas devolve as etapas que Validar executa com o (sem Options.Etapas), para reordenar ou completar
+//
+// Exemplo (uma etapa própria antes da consulta à SEFAZ):
+//
+//	etapas := client.Etapas(nfe.Options{XSD: xsd})
+//	etapas = slices.Insert(etapas, len(etapas)-1, duplicidade)
+//	result, err := client.Validar(xmlData, nfe.Options{Etapas: etapas})
+func (c *Client) Etapas(o Options) []Etapa {
+	etapas := []Etapa{etapaXSD(o)}
+	if o.Nivel == NivelXSD {
+		return etapas
+	}
+
+	regras := c.regras
+	if o.Regras != nil {
+		regras = *o.Regras
+	}
+	etapas = append(etapas, EtapaParse(), EtapaRegras(&regras))
+	if o.Assinatura && regras.Habilitada(RegraAssinatura) {
+		etapas = append(etapas, etapaAssinatura(&regras))
+	}
+	if o.Nivel == NivelParse {
+		return etapas
+	}
+	return append(etapas, EtapaSefaz())
+}
+
+// conferirEtapas recusa etapas vazias e as que precisam da nota sem EtapaParse antes
+func conferirEtapas(etapas []Etapa) error {
+	parse := false
+	for i, e := range etapas {
+		switch {
+		case e.executar == nil:
+			return fmt.Errorf("etapa %d vazia: use as funções Etapa* ou NovaEtapa", i+1)
+		case e.exigeParse && !parse:
+			return fmt.Errorf("etapa %s exige a etapa %s antes", e.fase, FaseParse)
+		}
+		parse = parse || e.fase == FaseParse
+	}
+	return nil
+}
+
+// regras devolve as regras da etapa (nil = as do Client)
+func (v *EstadoValidacao) regras(regras *ConfigRegras) ConfigRegras {
+	if regras != nil {
+		return *regras
+	}
+	return v.client.regras
+}
+
+// acrescentar junta os achados da etapa ao resultado e devolve a situação da fase
+func (v *EstadoValidacao) acrescentar(achados []Achado) string {
+	v.Resultado.Achados = append(v.Resultado.Achados, achados...)
+	if TemErros(achados) {
+		return SituacaoFalha
+	}
+	return SituacaoOK
+}
@@ -1,11 +1,22 @@
 package nfe_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+	"github.com/fabyo/go-nfe-validator/pkg/nfetest"
 )
 
 // Exemplo básico: validar apenas XSD (desenvolvimento)
@@ -102,6 +113,25 @@ func ExampleClient_ValidarChave() {
 	}
 }
 
+// Exemplo: chave copiada com espaços ou pontuação é consultada só com os 44 dígitos
+func ExampleClient_ValidarChave_formatada() {
+	fake := nfetest.NewFakeConsulter()
+	client, err := fake.Cliente()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := client.ValidarChave("3525 0732 4096 2000 0175 5500 1000 0037 4710 1154 4648")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result.ChaveAcesso, result.Autorizado)
+	fmt.Println(fake.Consultas())
+	// Output:
+	// 35250732409620000175550010000037471011544648 true
+	// [35250732409620000175550010000037471011544648]
+}
+
 // Exemplo: validar XML em bytes (útil para APIs)
 func ExampleClient_ValidarXMLBytes() {
 	client, err := nfe.NewClient(nfe.Config{
@@ -199,4 +229,988 @@ func Example_fluxoCompleto() {
 	} else {
 		fmt.Printf("❌ NF-e não autorizada: %s\n", result.Status.Mensagem)
 	}
-}
\ No newline at end of file
+}
+
+// Exemplo: executar as regras de negócio sobre a nota parseada
+//
+// A nota gerada pelo nfetest passa em todas as regras; cada alteração abaixo
+// faz uma delas apontar o problema.
+func ExampleAvaliarRegras() {
+	gerada := nfetest.NewGerador(1).Gerar(nfetest.ConfigGerador{
+		Itens:   1,
+		Emissao: time.Date(2025, 7, 10, 10, 0, 0, 0, time.UTC),
+	})
+	nota, err := nfe.ParseNFe(gerada.XML)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ide := &nota.InfNFe.Ide
+	prod := &nota.InfNFe.Det[0].Prod
+	icms := &nota.InfNFe.Det[0].Imposto.ICMS.Grupos[0]
+
+	icms.VICMS = "1.00"                        // tributos: vICMS não fecha com vBC × pICMS
+	ide.DhSaiEnt = "2025-07-09T10:00:00-03:00" // datas: saída antes da emissão (506)
+	prod.NCM = "99999999"                      // ncm: código inexistente (778)
+	prod.CFOP = "1102"                         // cfop: entrada em nota de saída
+
+	for _, a := range nfe.AvaliarRegras(nota, nfe.ConfigRegras{}) {
+		fmt.Printf("[%s] %s item %d: %s\n", a.Severidade, a.Regra, a.Item, a.Mensagem)
+	}
+	// Output:
+	// [erro] calculo-tributos item 1: vICMS informado 1.00 difere do calculado 1.35 (vBC 7.50 × 18.0000%)
+	// [erro] datas-emissao item 0: Data de Saída/Entrada (2025-07-09T10:00:00-03:00) menor que a Data de Emissão (2025-07-10T07:00:00-03:00)
+	// [erro] ncm item 1: NCM '99999999' inexistente na tabela NCM
+	// [erro] cfop item 1: CFOP de entrada (1102) para NF-e de saída
+}
+
+// Exemplo: validar e decompor uma chave de acesso
+func ExampleDecomporChave() {
+	c, err := nfe.DecomporChave("35250732409620000175550010000037471011544648")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(c.UF, c.Periodo, c.Emitente, c.Modelo, c.Serie, c.Numero, c.DescricaoTipoEmissao)
+	// Output: SP 2025-07 32409620000175 55 1 3747 Normal
+}
+
+// Exemplo: ler o protocolo de autorização de um procNFe
+func ExampleParseProcNFe() {
+	xmlData, err := os.ReadFile("testdata/nota.xml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	proc, err := nfe.ParseProcNFe(xmlData)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !proc.Autorizada() {
+		fmt.Printf("❌ Sem autorização de uso: %s\n", proc.ProtNFe.InfProt.XMotivo)
+		return
+	}
+	fmt.Printf("✅ Protocolo %s em %s\n", proc.ProtNFe.InfProt.NProt, proc.ProtNFe.InfProt.DhRecbto)
+}
+
+// Exemplo: conferir a assinatura digital sem consultar a SEFAZ
+func ExampleVerificarAssinatura() {
+	xmlData, err := os.ReadFile("testdata/nota.xml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	incs := nfe.VerificarAssinatura(xmlData)
+	if len(incs) == 0 {
+		fmt.Println("✅ Assinatura confere")
+		return
+	}
+	for _, inc := range incs {
+		fmt.Printf("❌ cStat %s: %s\n", inc.Codigo, inc.Mensagem)
+	}
+}
+
+// Exemplo: validar com prazo (em um handler HTTP, derive o contexto de r.Context())
+func ExampleClient_ValidarXMLBytesContext() {
+	client, err := nfe.NewClientFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	xmlData, err := os.ReadFile("testdata/nota.xml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	result, err := client.ValidarXMLBytesContext(ctx, xmlData, "schemas/v4/procNFe_v4.00.xsd")
+	if errors.Is(err, context.DeadlineExceeded) {
+		fmt.Println("⏱️ Prazo esgotado antes da resposta da SEFAZ")
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Autorizado: %v\n", result.Autorizado)
+}
+
+// Exemplo: cliente com certificado A1 (.pfx), prazo por requisição e cache das consultas
+func ExampleNewClient_opcoes() {
+	client, err := nfe.NewClient(nfe.Config{UF: "35"},
+		nfe.WithCertPFX("cert/empresa.pfx", os.Getenv("NFE_CERT_SENHA")),
+		nfe.WithTimeout(30*time.Second),
+		nfe.WithCache(nfe.NewCacheMemoria(10*time.Minute)),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// A segunda consulta da mesma chave vem do cache, sem nova chamada à SEFAZ
+	for i := 0; i < 2; i++ {
+		result, err := client.ValidarChave("35250732409620000175550010000037471011544648")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Autorizado: %v\n", result.Autorizado)
+	}
+}
+
+// Exemplo: testar código que usa o cliente sem certificado nem rede (Consulter fake)
+func ExampleWithConsulter() {
+	fake := nfe.ConsulterFunc(func(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+		return nfe.ConsultaSefaz{
+			Autorizado: true,
+			Status:     nfe.StatusSefaz{Codigo: "100", Mensagem: "Autorizado o uso da NF-e"},
+		}, nil
+	})
+
+	client, err := nfe.NewClient(nfe.Config{}, nfe.WithConsulter(fake))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := client.ValidarChave("35250732409620000175550010000037471011544648")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Autorizado: %v (%s)\n", result.Autorizado, result.Status.Codigo)
+	// Output: Autorizado: true (100)
+}
+
+// Exemplo: consultar a mesma chave em produção e em homologação com um único cliente
+func ExampleAmbiente() {
+	// O Consulter recebe o ambiente de cada consulta no contexto
+	sefaz := nfe.ConsulterFunc(func(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+		if nfe.AmbienteDe(ctx) == nfe.Homologacao {
+			return nfe.ConsultaSefaz{Status: nfe.StatusSefaz{Codigo: "217", Mensagem: "NF-e não consta na base de dados da SEFAZ"}}, nil
+		}
+		return nfe.ConsultaSefaz{Autorizado: true, Status: nfe.StatusSefaz{Codigo: "100", Mensagem: "Autorizado o uso da NF-e"}}, nil
+	})
+
+	client, err := nfe.NewClient(nfe.Config{Env: "production"}, nfe.WithConsulter(sefaz))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	chave := "35250732409620000175550010000037471011544648"
+	for _, ambiente := range []nfe.Ambiente{nfe.AmbientePadrao, nfe.Homologacao} {
+		result, err := client.ValidarChave(chave, ambiente)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%q: %s\n", ambiente, result.Status.Codigo)
+	}
+
+	_, err = client.ValidarChave(chave, "teste")
+	fmt.Println(err)
+	// Output:
+	// "": 100
+	// "homologacao": 217
+	// ambiente 'teste' desconhecido (use nfe.Producao ou nfe.Homologacao)
+}
+
+// Exemplo: o erro da validação vai para o JSON com código, fase e mensagem
+func ExampleValidationError() {
+	offline := nfe.ConsulterFunc(func(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+		return nfe.ConsultaSefaz{}, errors.New("conexão recusada")
+	})
+	client, err := nfe.NewClient(nfe.Config{}, nfe.WithConsulter(offline))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := client.ValidarChave("35250732409620000175550010000037471011544648")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var ve *nfe.ValidationError
+	if errors.As(result.Err(), &ve) && ve.Fase == nfe.FaseSefaz {
+		fmt.Println("SEFAZ indisponível, tentar de novo mais tarde")
+	}
+
+	saida, _ := json.Marshal(result.Erro)
+	fmt.Println(string(saida))
+	// Output:
+	// SEFAZ indisponível, tentar de novo mais tarde
+	// {"codigo":"conectividade","codigo_erro":"NFE-ERRO","fase":"sefaz","mensagem":"falha na consulta SEFAZ: conexão recusada"}
+}
+
+// Exemplo: parse direto de um io.Reader (corpo HTTP, entrada de um .zip)
+func ExampleParsearXMLReader() {
+	corpo := strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<nfeProc xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00">
+  <NFe>
+    <infNFe Id="NFe35250732409620000175550010000037471011544648" versao="4.00">
+      <ide><mod>55</mod><serie>1</serie><nNF>3747</nNF></ide>
+      <emit><CNPJ>32409620000175</CNPJ><xNome>EMPRESA EXEMPLO LTDA</xNome></emit>
+      <total><ICMSTot><vNF>110.00</vNF></ICMSTot></total>
+    </infNFe>
+  </NFe>
+</nfeProc>`)
+
+	dados, err := nfe.ParsearXMLReader(corpo)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("NF-e %s/%s de %s: R$ %s\n", dados.Serie, dados.Numero, dados.Emitente.Nome, dados.ValorTotal)
+	// Output: NF-e 1/3747 de EMPRESA EXEMPLO LTDA: R$ 110.00
+}
+
+// Example: the same API with English names
+func ExampleParseAccessKey() {
+	key, err := nfe.ParseAccessKey("35250732409620000175550010000037471011544648")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(key.UF, key.Periodo, key.Emitente, key.Modelo, nfe.StateCode(key.UF))
+	// Output: SP 2025-07 32409620000175 55 35
+}
+
+// Exemplo: validação offline (XSD + parse + regras + assinatura), o mesmo nível do CLI com -offline
+func ExampleClient_Validar() {
+	client, err := nfe.NewClientFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	xmlData, err := os.ReadFile("testdata/nota.xml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := client.Validar(xmlData, nfe.Options{
+		Nivel:      nfe.NivelParse,
+		XSD:        "schemas/v4/procNFe_v4.00.xsd",
+		Assinatura: true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if result.Erro != nil {
+		fmt.Printf("❌ Fase %s: %s\n", result.Erro.Fase, result.Erro.Mensagem)
+		return
+	}
+
+	for _, a := range result.Achados {
+		fmt.Printf("[%s] %s\n", a.Regra, a.Inconsistencia.Mensagem)
+	}
+	fmt.Printf("Reprovada: %v\n", nfe.TemErros(result.Achados))
+}
+
+// Exemplo: onde a validação gastou tempo e qual fase falhou, sem ler a mensagem de erro
+func ExampleValidationResult_Fase() {
+	recusada := nfe.ConsulterFunc(func(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+		return nfe.ConsultaSefaz{Status: nfe.StatusSefaz{Codigo: "217", Mensagem: "NF-e não consta na base de dados da SEFAZ"}}, nil
+	})
+
+	client, err := nfe.NewClient(nfe.Config{}, nfe.WithConsulter(recusada))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := client.ValidarChave("35250732409620000175550010000037471011544648")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, f := range result.Fases {
+		fmt.Printf("%s: %s\n", f.Fase, f.Situacao) // f.Duracao: tempo gasto na fase
+	}
+	if _, ok := result.Fase(nfe.FaseXSD); !ok {
+		fmt.Println("xsd: não executada")
+	}
+	// Output:
+	// sefaz: falha
+	// xsd: não executada
+}
+
+// Exemplo: hooks em volta das fases (registrar o andamento e vetar a consulta de emitentes bloqueados)
+func ExampleClient_Use() {
+	autorizada := nfe.ConsulterFunc(func(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+		return nfe.ConsultaSefaz{Autorizado: true, Status: nfe.StatusSefaz{Codigo: "100"}}, nil
+	})
+	client, err := nfe.NewClient(nfe.Config{}, nfe.WithConsulter(autorizada))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	bloqueados := map[string]bool{"32409620000175": true}
+	client.Use(
+		nfe.Hook{
+			Depois: func(ctx context.Context, f nfe.ExecucaoFase, r *nfe.ValidationResult) {
+				fmt.Printf("fase %s: %s\n", f.Fase, f.Situacao)
+			},
+		},
+		nfe.Hook{
+			Antes: func(ctx context.Context, fase string, r *nfe.ValidationResult) error {
+				k, err := nfe.DecomporChave(r.ChaveAcesso)
+				if fase == nfe.FaseSefaz && err == nil && bloqueados[k.Emitente] {
+					return fmt.Errorf("emitente %s bloqueado", k.Emitente)
+				}
+				return nil
+			},
+		},
+	)
+
+	result, err := client.ValidarChave("35250732409620000175550010000037471011544648")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: %s\n", result.Erro.Code, result.Erro.Mensagem)
+	// Output:
+	// fase sefaz: falha
+	// vetada: fase vetada: emitente 32409620000175 bloqueado
+}
+
+// cfopsPermitidos é um Validador próprio: apenas os CFOPs da lista são aceitos
+type cfopsPermitidos map[string]bool
+
+func (cfopsPermitidos) Name() string { return "cfop-permitido" }
+
+func (p cfopsPermitidos) Validate(ctx context.Context, dados *nfe.DadosNFe) []nfe.Achado {
+	var achados []nfe.Achado
+	for _, item := range dados.Itens {
+		if !p[item.CFOP] {
+			achados = append(achados, nfe.Achado{Inconsistencia: nfe.Inconsistencia{
+				Item:     item.Item,
+				Grupo:    "CFOP",
+				Mensagem: "CFOP " + item.CFOP + " fora da lista da empresa",
+			}})
+		}
+	}
+	return achados
+}
+
+// Exemplo: verificações próprias da empresa na fase de regras (lista de CFOPs permitidos)
+func ExampleWithValidadores() {
+	client, err := nfe.NewClientFromEnv(
+		nfe.WithValidadores(cfopsPermitidos{"5102": true, "6102": true}),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	xmlData, err := os.ReadFile("testdata/nota.xml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := client.Validar(xmlData, nfe.Options{Nivel: nfe.NivelParse, XSD: "schemas/v4/procNFe_v4.00.xsd"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, a := range result.Achados {
+		fmt.Printf("[%s] item %d: %s\n", a.Regra, a.Item, a.Mensagem)
+	}
+}
+
+// Exemplo: lote validado em paralelo, com o resultado de cada arquivo na ordem de entrada e o resumo
+func ExampleClient_ValidarLote() {
+	client, err := nfe.NewClientFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	arquivos, err := filepath.Glob("notas/*.xml")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	resultados, resumo, err := client.ValidarLote(arquivos, nfe.Options{
+		XSD:     "schemas/v4/procNFe_v4.00.xsd",
+		Workers: 8,
+	})
+	if err != nil {
+		log.Fatal(err) // XSD não carregou
+	}
+
+	for _, r := range resultados {
+		fmt.Printf("%s: %s em %s\n", r.Arquivo, r.Classificacao, r.Duracao)
+	}
+	fmt.Printf("%d de %d aprovadas em %s\n", resumo.Aprovadas, resumo.Total, resumo.Duracao)
+}
+
+// Exemplo: guardar a resposta exata da SEFAZ para auditoria
+func ExampleWithRespostaSefaz() {
+	sefaz := nfe.ConsulterFunc(func(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+		return nfe.ConsultaSefaz{
+			Autorizado: true,
+			Status:     nfe.StatusSefaz{Codigo: "100", Mensagem: "Autorizado o uso da NF-e"},
+			Resposta:   "<retConsSitNFe><cStat>100</cStat><xMotivo>Autorizado o uso da NF-e</xMotivo></retConsSitNFe>",
+		}, nil
+	})
+
+	// Com um diretório (ex: "auditoria/sefaz"), a resposta é gravada e o caminho vai em RespostaSefazArquivo
+	client, err := nfe.NewClient(nfe.Config{}, nfe.WithConsulter(sefaz), nfe.WithRespostaSefaz(""))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := client.ValidarChave("35250732409620000175550010000037471011544648")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result.RespostaSefaz)
+	// Output: <retConsSitNFe><cStat>100</cStat><xMotivo>Autorizado o uso da NF-e</xMotivo></retConsSitNFe>
+}
+
+// Exemplo: mascarar dados pessoais (LGPD) antes de registrar em log
+func ExampleMascararTexto() {
+	dest := "<dest><CPF>12345678909</CPF><xNome>Maria da Silva</xNome><enderDest><xLgr>Rua das Flores</xLgr></enderDest></dest>"
+
+	fmt.Println(nfe.MascararTexto(dest))
+	fmt.Println(nfe.MascararTexto("emitente 12.345.678/0001-95 sem autorização"))
+	fmt.Println(nfe.MascararNome("Comercial Exemplo Ltda"))
+	// Output:
+	// <dest><CPF>123******09</CPF><xNome>M**** d* S****</xNome><enderDest><xLgr>***</xLgr></enderDest></dest>
+	// emitente 123*********95 sem autorização
+	// C******** E****** L***
+}
+
+// Exemplo: agrupar falhas pelo código estável, sem interpretar as mensagens
+func ExampleCodigoDe() {
+	err := nfe.ValidarChaveAcesso("35250732409620000175550010000037471011544649")
+	fmt.Println(nfe.CodigoDe(err))
+
+	cancelada := nfe.ConsulterFunc(func(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+		return nfe.ConsultaSefaz{Status: nfe.StatusSefaz{Codigo: "101", Mensagem: "Cancelamento de NF-e homologado"}}, nil
+	})
+	client, err := nfe.NewClient(nfe.Config{}, nfe.WithConsulter(cancelada))
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := client.ValidarChave("35250732409620000175550010000037471011544648")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result.CodigoErro(), result.Status.Codigo)
+	// Output:
+	// NFE-CHAVE-DV
+	// NFE-SEFAZ-REJEITADA 101
+}
+
+// Exemplo: chave com dígito verificador ou modelo errados é recusada sem consultar a SEFAZ
+func ExampleClient_ValidarChave_chaveInvalida() {
+	fake := nfetest.NewFakeConsulter()
+	client, err := fake.Cliente()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, chave := range []string{
+		"35250732409620000175550010000037471011544649", // DV errado
+		"35250732409620000175570010000037471011544645", // modelo 57 (CT-e)
+	} {
+		_, err := client.ValidarChave(chave)
+		fmt.Println(nfe.CodigoDe(err), err)
+	}
+	fmt.Println(len(fake.Consultas()), "consultas")
+	// Output:
+	// NFE-CHAVE-DV chave de acesso inválida: dígito verificador inválido
+	// NFE-CHAVE-CAMPO chave de acesso inválida: modelo '57' não é NF-e (55) nem NFC-e (65)
+	// 0 consultas
+}
+
+// Exemplo: guardar o resultado em JSON e recarregá-lo depois (ex: de um banco ou fila)
+func ExampleValidationResult_UnmarshalJSON() {
+	cancelada := nfe.ConsulterFunc(func(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+		return nfe.ConsultaSefaz{Status: nfe.StatusSefaz{Codigo: "101", Mensagem: "Cancelamento de NF-e homologado"}}, nil
+	})
+	client, err := nfe.NewClient(nfe.Config{}, nfe.WithConsulter(cancelada))
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := client.ValidarChave("35250732409620000175550010000037471011544648")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	guardado, err := json.Marshal(result)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var recarregado nfe.ValidationResult
+	if err := json.Unmarshal(guardado, &recarregado); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(reflect.DeepEqual(result, &recarregado))
+	fmt.Println(recarregado.CodigoErro(), recarregado.Status.Codigo, len(recarregado.Fases))
+
+	var antigo nfe.ValidationResult
+	_ = json.Unmarshal([]byte(`{"valido_xsd":false,"autorizado":false,"status":{"codigo":"","mensagem":""},"erro":{"code":"xsd_invalido","fase":"xsd","mensagem":"falha na validação XSD"}}`), &antigo)
+	fmt.Println(antigo.Err(), nfe.CodigoDe(antigo.Err()))
+	// Output:
+	// true
+	// NFE-SEFAZ-REJEITADA 101 1
+	// falha na validação XSD NFE-XSD-001
+}
+
+// Exemplo: uma plataforma que valida para várias empresas, cada uma com a própria configuração
+func ExampleGerenciador() {
+	situacao := func(cStat string) nfe.Consulter {
+		return nfe.ConsulterFunc(func(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+			return nfe.ConsultaSefaz{Autorizado: cStat == "100", Status: nfe.StatusSefaz{Codigo: cStat}}, nil
+		})
+	}
+
+	// Em produção, cada empresa tem o próprio certificado: nfe.Config{CertDir: ..., UF: ...} ou WithCertPFX
+	g := nfe.NewGerenciador(nil)
+	if err := g.Registrar("12345678000195", nfe.Config{UF: "35"}, nfe.WithConsulter(situacao("100"))); err != nil {
+		log.Fatal(err)
+	}
+	if err := g.Registrar("98765432000198", nfe.Config{UF: "41", Env: "homologacao"}, nfe.WithConsulter(situacao("217"))); err != nil {
+		log.Fatal(err)
+	}
+
+	chave := "35250732409620000175550010000037471011544648"
+	for _, tenant := range g.Tenants() {
+		result, err := g.ValidarChave(tenant, chave)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(tenant, result.Status.Codigo)
+	}
+
+	_, err := g.ValidarChave("11111111000111", chave)
+	fmt.Println(errors.Is(err, nfe.ErrTenantDesconhecido))
+	// Output:
+	// 12345678000195 100
+	// 98765432000198 217
+	// true
+}
+
+// Exemplo: pipeline montado etapa a etapa, sem XSD e com uma verificação própria antes da SEFAZ
+func ExampleEtapa() {
+	autorizada := nfe.ConsulterFunc(func(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+		return nfe.ConsultaSefaz{Autorizado: true, Status: nfe.StatusSefaz{Codigo: "100"}}, nil
+	})
+	client, err := nfe.NewClient(nfe.Config{}, nfe.WithConsulter(autorizada))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	serieReservada := nfe.NovaEtapa("serie", func(ctx context.Context, v *nfe.EstadoValidacao) error {
+		if v.Resultado.DadosNFe.Serie == "1" {
+			v.Resultado.Achados = append(v.Resultado.Achados, nfe.Achado{
+				Regra:          "serie-reservada",
+				Severidade:     nfe.SeveridadeAviso,
+				Inconsistencia: nfe.Inconsistencia{Mensagem: "série 1 reservada para o ERP antigo"},
+			})
+		}
+		return nil
+	})
+
+	xmlData := []byte(`<nfeProc xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00">
+  <NFe>
+    <infNFe Id="NFe35250732409620000175550010000037471011544648" versao="4.00">
+      <ide><mod>55</mod><serie>1</serie><nNF>3747</nNF></ide>
+    </infNFe>
+  </NFe>
+</nfeProc>`)
+	result, err := client.Validar(xmlData, nfe.Options{Etapas: []nfe.Etapa{
+		nfe.EtapaParse(),
+		serieReservada,
+		nfe.EtapaSefaz(),
+	}})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, f := range result.Fases {
+		fmt.Printf("%s: %s\n", f.Fase, f.Situacao)
+	}
+	for _, a := range result.Achados {
+		fmt.Printf("[%s] %s\n", a.Regra, a.Mensagem)
+	}
+
+	_, err = client.Validar(xmlData, nfe.Options{Etapas: []nfe.Etapa{nfe.EtapaSefaz()}})
+	fmt.Println(err)
+	// Output:
+	// parse: ok
+	// serie: ok
+	// sefaz: ok
+	// [serie-reservada] série 1 reservada para o ERP antigo
+	// etapa sefaz exige a etapa parse antes
+}
+
+// Exemplo: teste de integração contra a SEFAZ falsa de nfetest (TLS local, sem certificado real)
+func Example_nfetest() {
+	sefaz := nfetest.New()
+	defer sefaz.Close()
+
+	cancelada := "35250732409620000175550010000037471011544648"
+	lenta := "35250732409620000175550010000037481011544645"
+	sefaz.Responder(cancelada, nfetest.Resposta{CStat: "101"})
+	sefaz.Responder(lenta, nfetest.Resposta{CStat: "100", Atraso: time.Second})
+
+	client, err := sefaz.Cliente(nfe.WithTimeout(100 * time.Millisecond))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, chave := range []string{"35250732409620000175550010000037491011544642", cancelada, lenta} {
+		result, err := client.ValidarChave(chave)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%t [%s] %q\n", result.Autorizado, result.CodigoErro(), result.Status.Mensagem)
+	}
+	fmt.Println(len(sefaz.Consultas()), "consultas")
+	// Output:
+	// true [] "Autorizado o uso da NF-e"
+	// false [NFE-SEFAZ-REJEITADA] "Cancelamento de NF-e homologado"
+	// false [NFE-SEFAZ-TIMEOUT] ""
+	// 3 consultas
+}
+
+// Exemplo: situações e erros programados por chave no nfetest.FakeConsulter, sem HTTP
+func Example_fakeConsulter() {
+	fake := nfetest.NewFakeConsulter()
+	cancelada := "35250732409620000175550010000037471011544648"
+	foraDoAr := "35250732409620000175550010000037481011544645"
+	fake.Responder(cancelada, nfetest.Resposta{CStat: "101"})
+	fake.Falhar(foraDoAr, context.DeadlineExceeded)
+
+	client, err := fake.Cliente(nfe.WithCache(nfe.NewCacheMemoria(time.Minute)))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, chave := range []string{"35250732409620000175550010000037491011544642", cancelada, foraDoAr, cancelada} {
+		result, err := client.ValidarChave(chave)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%t [%s] %q\n", result.Autorizado, result.CodigoErro(), result.Status.Mensagem)
+	}
+	fmt.Println(len(fake.Consultas()), "consultas") // a segunda consulta da cancelada veio do Cache
+	// Output:
+	// true [] "Autorizado o uso da NF-e"
+	// false [NFE-SEFAZ-REJEITADA] "Cancelamento de NF-e homologado"
+	// false [NFE-SEFAZ-TIMEOUT] ""
+	// false [NFE-SEFAZ-REJEITADA] "Cancelamento de NF-e homologado"
+	// 3 consultas
+}
+
+// Exemplo: 110 (Uso Denegado) no nfetest.FakeConsulter não conta como autorizada
+func Example_fakeConsulter_denegada() {
+	fake := nfetest.NewFakeConsulter()
+	chave := "35250732409620000175550010000037471011544648"
+	fake.Responder(chave, nfetest.Resposta{CStat: "110"})
+
+	client, err := fake.Cliente()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	result, err := client.ValidarChave(chave)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result.Autorizado, result.Status.IsDenegado(), result.Status.Mensagem)
+	// Output:
+	// false true Uso Denegado
+}
+
+// Exemplo: nota falsa e válida do nfetest.Gerador, validada de ponta a ponta contra a SEFAZ falsa
+func Example_gerador() {
+	sefaz := nfetest.New()
+	defer sefaz.Close()
+
+	client, err := sefaz.Cliente()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := nfetest.NewGerador(42)
+	nota := g.Gerar(nfetest.ConfigGerador{Itens: 3, Emissao: time.Date(2025, 7, 10, 10, 0, 0, 0, time.UTC)})
+
+	etapas := []nfe.Etapa{nfe.EtapaParse(), nfe.EtapaRegras(nil), nfe.EtapaSefaz()}
+	result, err := client.Validar(nota.XML, nfe.Options{Etapas: etapas})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(result.ChaveAcesso == nota.Chave, nfe.ValidarChaveAcesso(nota.Chave) == nil)
+	fmt.Println(len(result.DadosNFe.Itens), "itens, R$", result.DadosNFe.ValorTotal, "-", len(result.Achados), "achados")
+	fmt.Println(result.Autorizado, sefaz.Consultas()[0] == nota.Chave)
+	// Output:
+	// true true
+	// 3 itens, R$ 16435.32 - 0 achados
+	// true true
+}
+
+// Exemplo: consultas gravadas em um cassete e reproduzidas depois, sem rede nem certificado
+func Example_cassete() {
+	dir, err := os.MkdirTemp("", "cassete")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	arquivo := filepath.Join(dir, "consulta.json")
+	chave := "35250732409620000175550010000037471011544648"
+
+	// Gravação (aqui contra a SEFAZ falsa; nos testes reais, contra a homologação)
+	sefaz := nfetest.New()
+	sefaz.Responder(chave, nfetest.Resposta{CStat: "101"})
+	gravacao, err := nfetest.NewCassete(arquivo, nfetest.ModoAutomatico)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err := gravacao.Cliente(sefaz.Config())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := client.ValidarChave(chave); err != nil {
+		log.Fatal(err)
+	}
+	cfg := sefaz.Config()
+	sefaz.Close()
+
+	// Reprodução: o servidor já foi encerrado
+	reproducao, err := nfetest.NewCassete(arquivo, nfetest.ModoAutomatico)
+	if err != nil {
+		log.Fatal(err)
+	}
+	client, err = reproducao.Cliente(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := client.ValidarChave(chave)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(reproducao.Modo() == nfetest.ModoReproduzir, len(reproducao.Interacoes()))
+	fmt.Printf("%t %q\n", result.Autorizado, result.Status.Mensagem)
+
+	result, err = client.ValidarChave("35250732409620000175550010000037481011544645")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(errors.Is(result.Err(), nfetest.ErrSemGravacao))
+	// Output:
+	// true 1
+	// false "Cancelamento de NF-e homologado"
+	// true
+}
+
+// Exemplo: XSD compilado uma vez e recarregado quando o arquivo muda
+func ExampleCacheXSD() {
+	dir, err := os.MkdirTemp("", "cachexsd")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	xsd := filepath.Join(dir, "serie.xsd")
+	escrever := func(tipo string) {
+		schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="serie" type="xs:` + tipo + `"/></xs:schema>`
+		if err := os.WriteFile(xsd, []byte(schema), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Confere os arquivos a cada uso; NewCacheXSD confere no máximo uma vez por IntervaloConferenciaXSD
+	cache := nfe.NewCacheXSDCom(0)
+	defer cache.Close()
+	escrever("int")
+	schema, err := cache.Carregar(xsd)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(schema.Validate([]byte("<serie>1</serie>")) == nil, schema.Validate([]byte("<serie>A1</serie>")) == nil)
+
+	// O XSD mudou: a próxima validação recarrega
+	escrever("string")
+	fmt.Println(schema.Validate([]byte("<serie>A1</serie>")) == nil, len(cache.Caminhos()))
+
+	_, err = cache.Carregar(filepath.Join(dir, "outro.xsd"))
+	fmt.Println(nfe.CodigoDe(err))
+	// Output:
+	// true false
+	// true 1
+	// NFE-XSD-002
+}
+
+// Exemplo: fluxo contínuo de notas validado em estágios (XSD, parse e regras, SEFAZ), com os resultados na ordem de entrada
+func ExampleClient_ValidarStream() {
+	fake := nfetest.NewFakeConsulter()
+	client, err := fake.Cliente()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := nfetest.NewGerador(7)
+	emissao := time.Date(2025, 7, 10, 10, 0, 0, 0, time.UTC)
+	cancelada := g.Gerar(nfetest.ConfigGerador{Emissao: emissao})
+	fake.Responder(cancelada.Chave, nfetest.Resposta{CStat: "101"})
+
+	entrada := make(chan []byte)
+	go func() {
+		defer close(entrada)
+		entrada <- g.Gerar(nfetest.ConfigGerador{Emissao: emissao}).XML
+		entrada <- []byte("<NFe>truncada")
+		entrada <- cancelada.XML
+		entrada <- g.Gerar(nfetest.ConfigGerador{Modelo: "65", Emissao: emissao}).XML
+	}()
+
+	o := nfe.Options{XSD: "../../schemas/v4/procNFe_v4.00.xsd", Workers: 2}
+	for result := range client.ValidarStream(context.Background(), entrada, o) {
+		fmt.Printf("%t %t [%s]\n", result.ValidoXSD, result.Autorizado, result.CodigoErro())
+	}
+	// Output:
+	// true true []
+	// false false [NFE-XSD-001]
+	// true false [NFE-SEFAZ-REJEITADA]
+	// true true []
+}
+
+// Exemplo: arquivo com muitas notas percorrido uma nota por vez, sem carregá-lo inteiro
+func ExampleLeitorNotas() {
+	g := nfetest.NewGerador(5)
+	emissao := time.Date(2025, 7, 10, 10, 0, 0, 0, time.UTC)
+	var arquivo strings.Builder
+	arquivo.WriteString(`<?xml version="1.0" encoding="UTF-8"?><exportacao>`)
+	for _, modelo := range []string{"55", "65"} {
+		nota := g.Gerar(nfetest.ConfigGerador{Modelo: modelo, Emissao: emissao}).XML
+		arquivo.Write(nota[bytes.Index(nota, []byte("<nfeProc")):])
+	}
+	arquivo.WriteString(`<NFe><infNFe/></NFe></exportacao>`)
+
+	leitor := nfe.NewLeitorNotas(strings.NewReader(arquivo.String()))
+	for {
+		lida, err := leitor.Proxima()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if lida.Err != nil {
+			fmt.Printf("%d: %v\n", lida.Indice, lida.Err)
+			continue
+		}
+		fmt.Printf("%d: modelo %s, %t\n", lida.Indice, lida.Nota.InfNFe.Ide.Modelo, nfe.ValidarApenasXSD(lida.XML, "../../schemas/v4/procNFe_v4.00.xsd") == nil)
+	}
+	// Output:
+	// 1: modelo 55, true
+	// 2: modelo 65, true
+	// 3: infNFe.Id não encontrado no XML
+}
+
+// Exemplo: NFC-e em contingência off-line com justificativa curta, QR Code on-line e fora do prazo
+func ExampleVerificarContingenciaNFCe() {
+	chave := "35250743602655000142650040006577639174269970"
+	nota := &nfe.NFeEnvelope{
+		InfNFe: nfe.InfNFe{
+			ID: "NFe" + chave,
+			Ide: nfe.Ide{
+				Modelo: "65", TpEmis: "9", TpAmb: "2",
+				DhEmi:  "2025-07-10T10:00:00-03:00",
+				DhCont: "2025-07-10T09:30:00-03:00",
+				XJust:  "sem internet",
+			},
+			Total: nfe.Total{ICMSTot: nfe.ICMSTot{VNF: "59.90"}},
+		},
+		InfNFeSupl: &nfe.InfNFeSupl{
+			QrCode: "https://www.homologacao.nfce.fazenda.sp.gov.br/qrcode?p=" + chave + "|2|2|1|3A1B5C",
+		},
+		DigestValue: "pX3mQ8x=",
+	}
+	agora := time.Date(2025, 7, 11, 16, 0, 0, 0, time.FixedZone("BRT", -3*3600))
+
+	for _, inc := range nfe.VerificarContingenciaNFCe(nota, agora, nfe.PrazoContingenciaNFCePadrao) {
+		fmt.Printf("%s: %s\n", inc.Grupo, inc.Mensagem)
+	}
+
+	// Justificativa completa, QR Code off-line (dia, vNF e DigestValue em hexadecimal) e protocolo dentro do prazo
+	nota.InfNFe.Ide.XJust = "falha na conexão com a internet"
+	nota.InfNFeSupl.QrCode = "https://www.homologacao.nfce.fazenda.sp.gov.br/qrcode?p=" + chave + "|2|2|10|59.90|" +
+		hex.EncodeToString([]byte(nota.DigestValue)) + "|1|3A1B5C"
+	nota.Protocolo = &nfe.InfProt{DhRecbto: "2025-07-10T18:00:00-03:00"}
+	fmt.Println(len(nfe.VerificarContingenciaNFCe(nota, agora, nfe.PrazoContingenciaNFCePadrao)), "inconsistências")
+	// Output:
+	// xJust: justificativa da contingência com 12 caracteres (esperado de 15 a 256)
+	// qrCode: QR Code na variante on-line (5 parâmetros) em NFC-e de contingência off-line: esperados 8 (chave|2|tpAmb|dia|vNF|digVal|cIdToken|hash)
+	// dhEmi: NFC-e em contingência sem protocolo de autorização emitida há 30h: prazo de transmissão de 24h vencido
+	// 0 inconsistências
+}
+
+func ExampleLerQRCode() {
+	qr, err := nfe.LerQRCode("https://www.homologacao.nfce.fazenda.sp.gov.br/qrcode?p=35250743602655000142650040006577639174269978|2|2|1|3A1B5C")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(qr.Chave, qr.Versao, qr.Ambiente())
+
+	// Versão 1, com a chave em chNFe
+	qr, _ = nfe.LerQRCode("http://www.fazenda.pr.gov.br/nfce/qrcode?chNFe=35250743602655000142650040006577639174269978&nVersao=100&tpAmb=1")
+	fmt.Println(qr.Versao, qr.Ambiente())
+
+	_, err = nfe.LerQRCode("https://www.nfce.fazenda.sp.gov.br/qrcode?p=35250743602655000142650040006577639174269979|2|1|1|3A1B5C")
+	fmt.Println(nfe.CodigoDe(err))
+	// Output:
+	// 35250743602655000142650040006577639174269978 2 homologacao
+	// 1 producao
+	// NFE-CHAVE-DV
+}
+
+func ExampleConferirCodigoBarrasXML() {
+	xmlData := []byte(`<nfeProc xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00">
+  <NFe><infNFe Id="NFe35250732409620000175550010000037471011544648" versao="4.00"></infNFe></NFe>
+  <protNFe versao="4.00"><infProt><chNFe>35250732409620000175550010000037471011544648</chNFe><cStat>100</cStat></infProt></protNFe>
+</nfeProc>`)
+
+	// Leitor com identificador de simbologia e ENTER no fim
+	fmt.Println(nfe.ConferirCodigoBarrasXML("]C035250732409620000175550010000037471011544648\r\n", xmlData))
+
+	err := nfe.ConferirCodigoBarrasXML("35250743602655000142650040006577639174269978", xmlData)
+	fmt.Println(errors.Is(err, nfe.ErrChaveDivergente))
+
+	// Leitura incompleta: o DV não confere
+	err = nfe.ConferirCodigoBarrasXML("35250732409620000175550010000037471011544640", xmlData)
+	fmt.Println(nfe.CodigoDe(err))
+	// Output:
+	// <nil>
+	// true
+	// NFE-CHAVE-DV
+}
+
+func ExampleExtrairChaveDoID() {
+	chave, err := nfe.ExtrairChaveDoID("NFe35250732409620000175550010000037471011544648")
+	fmt.Println(chave, err)
+
+	_, err = nfe.ExtrairChaveDoID("NFe35250732409620000175550010000037471011544640")
+	fmt.Println(nfe.CodigoDe(err), err)
+
+	_, err = nfe.ExtrairChaveDoID("NFe3525073240962000017555001000003747101154464X")
+	fmt.Println(nfe.CodigoDe(err))
+
+	// ExtractChaveFromID devolve "" nos mesmos casos
+	fmt.Printf("%q\n", nfe.ExtractChaveFromID("NFe35250732409620000175550010000037471011544640"))
+	// Output:
+	// 35250732409620000175550010000037471011544648 <nil>
+	// NFE-CHAVE-DV Id "NFe35250732409620000175550010000037471011544640": dígito verificador inválido
+	// NFE-CHAVE-FORMATO
+	// ""
+}
+
+// Exemplo: Id com DV errado vira achado das regras que conferem a chave, em vez de pular a conferência
+func ExampleVerificarUFEmitente() {
+	var nota nfe.NFeEnvelope
+	nota.InfNFe.ID = "NFe35250732409620000175550010000037471011544640"
+	nota.InfNFe.Emit.EnderEmit.UF = "SP"
+
+	for _, inc := range nfe.VerificarUFEmitente(&nota) {
+		fmt.Printf("[%s] %s\n", inc.Codigo, inc.Mensagem)
+	}
+	// Output:
+	// [236] chave do Id inválida: DV (Id "NFe35250732409620000175550010000037471011544640")
+}
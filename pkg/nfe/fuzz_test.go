@@ -0,0 +1,143 @@
+package nfe_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+	"github.com/fabyo/go-nfe-validator/pkg/nfetest"
+)
+
+// Alvos de fuzzing do parser e da chave de acesso (go test -fuzz=FuzzParseNFe ./pkg/nfe).
+// Sem -fuzz, rodam apenas o corpus abaixo, como testes comuns.
+
+// corpusXML são notas válidas (do nfetest.Gerador) e documentos malformados ou hostis
+func corpusXML() [][]byte {
+	g := nfetest.NewGerador(1)
+	emissao := time.Date(2025, 7, 10, 10, 0, 0, 0, time.UTC)
+	nota55 := g.Gerar(nfetest.ConfigGerador{Itens: 2, Emissao: emissao}).XML
+	nota65 := g.Gerar(nfetest.ConfigGerador{Modelo: "65", Itens: 1, Emissao: emissao}).XML
+
+	return [][]byte{
+		nota55,
+		nota65,
+		nota55[:len(nota55)/2], // truncada
+		bytes.Replace(nota55, []byte("<nfeProc"), []byte("<outro"), 1),
+		[]byte(`<?xml version="1.0"?><!DOCTYPE lolz [<!ENTITY lol "lol"><!ENTITY lol2 "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;">]><NFe><infNFe Id="&lol2;"/></NFe>`),
+		[]byte(`<!DOCTYPE NFe SYSTEM "file:///etc/passwd"><NFe><infNFe Id="NFe1"/></NFe>`),
+		[]byte(`<NFe><infNFe Id="NFe35250732409620000175550010000037471011544648"><det nItem="abc"/><det/><total/></infNFe></NFe>`),
+		[]byte(strings.Repeat("<a>", 20000)),
+		{},
+	}
+}
+
+func FuzzParseNFe(f *testing.F) {
+	for _, xml := range corpusXML() {
+		f.Add(xml)
+	}
+	f.Fuzz(func(t *testing.T, xml []byte) {
+		nota, err := nfe.ParseNFe(xml)
+		if err != nil {
+			if nota != nil {
+				t.Fatal("ParseNFe devolveu nota e erro")
+			}
+			return
+		}
+		if nota.InfNFe.ID == "" {
+			t.Fatal("ParseNFe aceitou nota sem infNFe.Id")
+		}
+		// A nota aceita passa pelas regras e pela conversão sem pânico
+		nfe.AvaliarRegras(nota, nfe.ConfigRegras{})
+		if _, err := nfe.ParsearXML(xml); err != nil {
+			t.Fatalf("ParsearXML recusou o que ParseNFe aceitou: %v", err)
+		}
+	})
+}
+
+func FuzzParseNFeReader(f *testing.F) {
+	for _, xml := range corpusXML() {
+		f.Add(xml)
+	}
+	f.Fuzz(func(t *testing.T, xml []byte) {
+		nota, err := nfe.ParseNFeReader(bytes.NewReader(xml))
+		if err == nil && nota.InfNFe.ID == "" {
+			t.Fatal("ParseNFeReader aceitou nota sem infNFe.Id")
+		}
+	})
+}
+
+func FuzzLeitorNotas(f *testing.F) {
+	for _, xml := range corpusXML() {
+		f.Add(xml)
+	}
+	nota := corpusXML()[0]
+	f.Add(append(append([]byte(`<lote xmlns="http://www.portalfiscal.inf.br/nfe">`), bytes.Repeat(nota[bytes.Index(nota, []byte("<nfeProc")):], 2)...), "</lote>"...))
+	f.Fuzz(func(t *testing.T, xml []byte) {
+		leitor := nfe.NewLeitorNotas(bytes.NewReader(xml))
+		for i := 1; ; i++ {
+			lida, err := leitor.Proxima()
+			if err != nil {
+				if _, errDepois := leitor.Proxima(); errDepois != err {
+					t.Fatalf("erro definitivo mudou: %v, depois %v", err, errDepois)
+				}
+				return
+			}
+			if lida.Indice != i || lida.Posicao < 0 || lida.Posicao >= int64(len(xml)) {
+				t.Fatalf("nota %d com Indice %d e Posicao %d", i, lida.Indice, lida.Posicao)
+			}
+			if lida.Err == nil && (lida.Nota == nil || lida.Nota.InfNFe.ID == "") {
+				t.Fatal("nota sem erro e sem infNFe.Id")
+			}
+		}
+	})
+}
+
+func FuzzExtractChaveFromID(f *testing.F) {
+	f.Add("NFe35250732409620000175550010000037471011544648")
+	f.Add("35250732409620000175550010000037471011544648")
+	f.Add(" NFe3525073240962000017555001000003747101154464 ")
+	f.Add("NFeçççççççççççççççççççççç")
+	f.Add("NFe3525073240962000017555001000003747101154464X")
+	f.Add("NFe35250732409620000175550010000037471011544640")
+	f.Fuzz(func(t *testing.T, id string) {
+		chave := nfe.ExtractChaveFromID(id)
+		if chave != "" && len(chave) != 44 {
+			t.Fatalf("chave com %d bytes: %q", len(chave), chave)
+		}
+		if chave != "" {
+			if err := nfe.ValidarChaveAcesso(chave); err != nil {
+				t.Fatalf("chave inválida extraída de %q: %v", id, err)
+			}
+		}
+		if c, err := nfe.ExtrairChaveDoID(id); c != chave || (err == nil) != (chave != "") || (err != nil && nfe.CodigoDe(err) == nfe.CodigoDesconhecido) {
+			t.Fatalf("ExtrairChaveDoID(%q) = %q, %v; ExtractChaveFromID = %q", id, c, err, chave)
+		}
+	})
+}
+
+func FuzzValidarChaveAcesso(f *testing.F) {
+	f.Add("35250732409620000175550010000037471011544648")
+	f.Add("35250732409620000175550010000037471011544640")
+	f.Add("3525073240962000017555001000003747101154464")
+	f.Add("3525073240962000017555001000003747101154464ç")
+	f.Add("99991332409620000175550010000037471011544640")
+	f.Fuzz(func(t *testing.T, chave string) {
+		errValidar := nfe.ValidarChaveAcesso(chave)
+		c, errDecompor := nfe.DecomporChave(chave)
+		if errValidar != nil {
+			if errDecompor == nil || nfe.CodigoDe(errValidar) == nfe.CodigoDesconhecido {
+				t.Fatalf("chave recusada sem código ou aceita por DecomporChave: %v", errValidar)
+			}
+			return
+		}
+		// Uma chave de formato e DV corretos só é recusada por UF ou mês inválidos
+		if errDecompor != nil && nfe.CodigoDe(errDecompor) != nfe.CodigoChaveCampo {
+			t.Fatalf("DecomporChave recusou uma chave válida: %v", errDecompor)
+		}
+		if c.Chave != strings.TrimSpace(chave) || c.DigitoVerificador != c.Chave[43:] {
+			t.Fatalf("chave decomposta diverge: %+v", c)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package nfe
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ErrTenantDesconhecido indica um tenant sem cliente registrado no Gerenciador (nem carregável)
+var ErrTenantDesconhecido = errors.New("tenant não registrado")
+
+// CarregadorCliente cria o cliente de um tenant na primeira vez em que ele é usado (ver NewGerenciador)
+type CarregadorCliente func(tenant string) (*Client, error)
+
+// Gerenciador guarda um Client por tenant (empresa, CNPJ ou ID próprio) e encaminha as validações a ele
+//
+// Cada cliente tem o próprio certificado, UF, ambiente e opções. É seguro
+// para uso concorrente: um serviço que valida para centenas de empresas
+// mantém um único Gerenciador.
+//
+// Exemplo:
+//
+//	g := nfe.NewGerenciador(nil)
+//	g.Registrar("12345678000195", nfe.Config{CertDir: "certs/empresa-a", CertKeyFile: "key.pem", CertPubFile: "cert.pem", UF: "35"})
+//	g.Registrar("98765432000198", nfe.Config{UF: "41", Env: "homologacao"}, nfe.WithCertPFX("certs/empresa-b.pfx", senha))
+//
+//	result, err := g.ValidarChave("12345678000195", chave)
+type Gerenciador struct {
+	carregar CarregadorCliente
+
+	mu       sync.RWMutex
+	clientes map[string]*Client
+}
+
+// NewGerenciador cria o Gerenciador; carregar (opcional) cria sob demanda o cliente de um tenant não registrado
+//
+// Com carregar, os certificados de cada empresa só são lidos no primeiro uso
+// e o cliente criado fica guardado; um erro do carregador não é guardado (a
+// próxima chamada tenta de novo). Sem carregar, apenas os tenants de
+// Registrar e Adicionar são aceitos.
+//
+// Exemplo (um arquivo .env por empresa):
+//
+//	g := nfe.NewGerenciador(func(tenant string) (*nfe.Client, error) {
+//	    return nfe.NewClientFromFile(filepath.Join("tenants", tenant+".env"))
+//	})
+func NewGerenciador(carregar CarregadorCliente) *Gerenciador {
+	return &Gerenciador{carregar: carregar, clientes: make(map[string]*Client)}
+}
+
+// Registrar cria o cliente do tenant (ver NewClient), substituindo o anterior, se houver
+func (g *Gerenciador) Registrar(tenant string, cfg Config, opcoes ...Opcao) error {
+	c, err := NewClient(cfg, opcoes...)
+	if err != nil {
+		return fmt.Errorf("tenant %s: %w", tenant, err)
+	}
+	g.Adicionar(tenant, c)
+	return nil
+}
+
+// Adicionar registra um cliente já criado (ex: NewClientFromFile) para o tenant, substituindo o anterior
+func (g *Gerenciador) Adicionar(tenant string, c *Client) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.clientes[tenant] = c
+}
+
+// Remover descarta o cliente do tenant (ex: empresa que deixou a plataforma ou trocou de certificado)
+//
+// Com um CarregadorCliente, o próximo uso do tenant cria o cliente de novo.
+func (g *Gerenciador) Remover(tenant string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.clientes, tenant)
+}
+
+// Tenants lista os tenants com cliente registrado ou já carregado, em ordem
+func (g *Gerenciador) Tenants() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	tenants := make([]string, 0, len(g.clientes))
+	for t := range g.clientes {
+		tenants = append(tenants, t)
+	}
+	slices.Sort(tenants)
+	return tenants
+}
+
+// Certificados devolve o certificado digital de cada tenant com cliente registrado ou já carregado
+//
+// Não carrega tenants: os criados sob demanda entram a partir do primeiro
+// uso. Clientes sem certificado (WithConsulter) ficam de fora. Usado por
+// nfemetrics.Coletor.ObservarGerenciador para a validade por tenant.
+func (g *Gerenciador) Certificados() map[string]*x509.Certificate {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	certificados := make(map[string]*x509.Certificate, len(g.clientes))
+	for t, c := range g.clientes {
+		if cert, err := c.Certificado(); err == nil && cert != nil {
+			certificados[t] = cert
+		}
+	}
+	return certificados
+}
+
+// Cliente devolve o cliente do tenant, criando-o com o CarregadorCliente se necessário
+//
+// Sem cliente nem carregador, o erro é ErrTenantDesconhecido (errors.Is).
+func (g *Gerenciador) Cliente(tenant string) (*Client, error) {
+	g.mu.RLock()
+	c, ok := g.clientes[tenant]
+	g.mu.RUnlock()
+	if ok {
+		return c, nil
+	}
+	if g.carregar == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTenantDesconhecido, tenant)
+	}
+
+	// O carregamento fica sob o lock: chamadas simultâneas do mesmo tenant criam um único cliente
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c, ok := g.clientes[tenant]; ok {
+		return c, nil
+	}
+	c, err := g.carregar(tenant)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %s: %w", tenant, err)
+	}
+	if c == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTenantDesconhecido, tenant)
+	}
+	g.clientes[tenant] = c
+	return c, nil
+}
+
+// Validar executa Client.Validar com o cliente do tenant
+func (g *Gerenciador) Validar(tenant string, xmlData []byte, o Options) (*ValidationResult, error) {
+	return g.ValidarContext(context.Background(), tenant, xmlData, o)
+}
+
+// ValidarContext é o Validar com contexto
+func (g *Gerenciador) ValidarContext(ctx context.Context, tenant string, xmlData []byte, o Options) (*ValidationResult, error) {
+	c, err := g.Cliente(tenant)
+	if err != nil {
+		return nil, err
+	}
+	return c.ValidarContext(ctx, xmlData, o)
+}
+
+// ValidarXML executa Client.ValidarXML com o cliente do tenant
+func (g *Gerenciador) ValidarXML(tenant, xmlPath, xsdPath string) (*ValidationResult, error) {
+	return g.ValidarXMLContext(context.Background(), tenant, xmlPath, xsdPath)
+}
+
+// ValidarXMLContext é o ValidarXML com contexto
+func (g *Gerenciador) ValidarXMLContext(ctx context.Context, tenant, xmlPath, xsdPath string) (*ValidationResult, error) {
+	c, err := g.Cliente(tenant)
+	if err != nil {
+		return nil, err
+	}
+	return c.ValidarXMLContext(ctx, xmlPath, xsdPath)
+}
+
+// ValidarChave executa Client.ValidarChave com o cliente do tenant
+func (g *Gerenciador) ValidarChave(tenant, chave string) (*ValidationResult, error) {
+	return g.ValidarChaveContext(context.Background(), tenant, chave)
+}
+
+// ValidarChaveContext é o ValidarChave com contexto
+func (g *Gerenciador) ValidarChaveContext(ctx context.Context, tenant, chave string) (*ValidationResult, error) {
+	c, err := g.Cliente(tenant)
+	if err != nil {
+		return nil, err
+	}
+	return c.ValidarChaveContext(ctx, chave)
+}
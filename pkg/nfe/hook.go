@@ -0,0 +1,41 @@
+package nfe
+
+import "context"
+
+// Hook envolve as fases de Client.Validar e de ValidarChave (registrado com Client.Use)
+//
+// Antes e Depois são opcionais. Antes roda antes de cada fase, na ordem de
+// registro, e pode vetá-la: um erro interrompe a validação com um
+// ValidationError de Code ResultadoVetada. Depois roda ao fim de cada fase
+// registrada em ValidationResult.Fases (inclusive a vetada), na ordem
+// inversa, e pode enriquecer o resultado (ex: acrescentar achados).
+//
+// fase é um dos Fase* (ex: FaseXSD) ou o nome de uma NovaEtapa; ctx carrega
+// o span da fase.
+//
+// Exemplo:
+//
+//	client.Use(nfe.Hook{
+//	    Antes: func(ctx context.Context, fase string, r *nfe.ValidationResult) error {
+//	        if fase == nfe.FaseSefaz && !cota.Allow() {
+//	            return errors.New("cota de consultas esgotada")
+//	        }
+//	        return nil
+//	    },
+//	    Depois: func(ctx context.Context, f nfe.ExecucaoFase, r *nfe.ValidationResult) {
+//	        duracaoFase.WithLabelValues(f.Fase, f.Situacao).Observe(f.Duracao.Seconds())
+//	    },
+//	})
+type Hook struct {
+	Antes  func(ctx context.Context, fase string, r *ValidationResult) error
+	Depois func(ctx context.Context, f ExecucaoFase, r *ValidationResult)
+}
+
+// Use registra hooks em volta das fases de validação
+//
+// Registre os hooks ao configurar o cliente, antes de validar: Use não pode
+// ser chamado junto com validações em andamento. Os hooks, por sua vez,
+// podem ser chamados por várias goroutines ao mesmo tempo.
+func (c *Client) Use(hooks ...Hook) {
+	c.hooks = append(c.hooks, hooks...)
+}
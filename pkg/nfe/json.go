@@ -0,0 +1,127 @@
+package nfe
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Formato JSON do ValidationResult, para guardar os resultados e recarregá-los
+// depois (json.Unmarshal devolve um resultado equivalente ao serializado).
+// Campos vazios são omitidos, salvo valido_xsd e autorizado; "status" só
+// aparece quando houve consulta à SEFAZ. A causa original de Erro não é
+// serializada: depois do Unmarshal, Err() e CodigoDe continuam funcionando,
+// mas errors.Unwrap devolve nil.
+
+// resultadoJSON é o ValidationResult como vai no JSON
+type resultadoJSON struct {
+	ChaveAcesso          string           `json:"chave_acesso,omitempty"`
+	ValidoXSD            bool             `json:"valido_xsd"`
+	Autorizado           bool             `json:"autorizado"`
+	Status               *StatusSefaz     `json:"status,omitempty"`
+	RespostaSefaz        string           `json:"resposta_sefaz,omitempty"`
+	RespostaSefazArquivo string           `json:"resposta_sefaz_arquivo,omitempty"`
+	DadosNFe             *DadosNFe        `json:"dados_nfe,omitempty"`
+	Achados              []Achado         `json:"achados,omitempty"`
+	Erro                 *ValidationError `json:"erro,omitempty"`
+	Fases                []faseJSON       `json:"fases,omitempty"`
+}
+
+// faseJSON é a ExecucaoFase como vai no JSON (duração em nanossegundos)
+type faseJSON struct {
+	Fase     string `json:"fase"`
+	Situacao string `json:"situacao"`
+	Duracao  int64  `json:"duracao_ns"`
+}
+
+// statusJSON é o StatusSefaz como vai no JSON
+type statusJSON struct {
+	Codigo   string `json:"codigo,omitempty"`
+	Mensagem string `json:"mensagem,omitempty"`
+}
+
+// MarshalJSON serializa o resultado no formato descrito acima
+func (r ValidationResult) MarshalJSON() ([]byte, error) {
+	out := resultadoJSON{
+		ChaveAcesso:          r.ChaveAcesso,
+		ValidoXSD:            r.ValidoXSD,
+		Autorizado:           r.Autorizado,
+		RespostaSefaz:        r.RespostaSefaz,
+		RespostaSefazArquivo: r.RespostaSefazArquivo,
+		DadosNFe:             r.DadosNFe,
+		Achados:              r.Achados,
+		Erro:                 r.Erro,
+	}
+	if r.Status != (StatusSefaz{}) {
+		out.Status = &r.Status
+	}
+	for _, f := range r.Fases {
+		out.Fases = append(out.Fases, faseJSON{Fase: f.Fase, Situacao: f.Situacao, Duracao: int64(f.Duracao)})
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON recarrega um resultado serializado por MarshalJSON
+//
+// Um Erro sem codigo_erro (gravado por versões anteriores) recebe o código
+// deduzido de Code, ou CodigoDesconhecido.
+func (r *ValidationResult) UnmarshalJSON(data []byte) error {
+	var in resultadoJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	*r = ValidationResult{
+		ChaveAcesso:          in.ChaveAcesso,
+		ValidoXSD:            in.ValidoXSD,
+		Autorizado:           in.Autorizado,
+		RespostaSefaz:        in.RespostaSefaz,
+		RespostaSefazArquivo: in.RespostaSefazArquivo,
+		DadosNFe:             in.DadosNFe,
+		Achados:              in.Achados,
+		Erro:                 in.Erro,
+	}
+	if in.Status != nil {
+		r.Status = *in.Status
+	}
+	if r.Erro != nil && r.Erro.CodigoErro == "" {
+		if r.Erro.CodigoErro = codigoFase(r.Erro.Code, nil); r.Erro.CodigoErro == "" {
+			r.Erro.CodigoErro = CodigoDesconhecido
+		}
+	}
+	for _, f := range in.Fases {
+		r.Fases = append(r.Fases, ExecucaoFase{Fase: f.Fase, Situacao: f.Situacao, Duracao: time.Duration(f.Duracao)})
+	}
+	return nil
+}
+
+// UnmarshalJSON recarrega um erro serializado; aceita também "code", o nome de Code em versões anteriores
+func (e *ValidationError) UnmarshalJSON(data []byte) error {
+	type erroJSON ValidationError // sem os métodos, para não recursar
+	var in struct {
+		erroJSON
+		CodeAntigo string `json:"code"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*e = ValidationError(in.erroJSON)
+	if e.Code == "" {
+		e.Code = in.CodeAntigo
+	}
+	return nil
+}
+
+// MarshalJSON serializa o status omitindo os campos vazios
+func (s StatusSefaz) MarshalJSON() ([]byte, error) {
+	return json.Marshal(statusJSON(s))
+}
+
+// UnmarshalJSON recarrega um status serializado por MarshalJSON (campos ausentes ficam vazios)
+func (s *StatusSefaz) UnmarshalJSON(data []byte) error {
+	var in statusJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*s = StatusSefaz(in)
+	return nil
+}
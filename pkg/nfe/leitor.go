@@ -0,0 +1,209 @@
+package nfe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// LeitorNotas percorre um arquivo com muitas NF-e (exportação, lote enviNFe, backup de procNFe) uma nota por vez
+//
+// O documento é lido como fluxo de tokens (xml.Decoder): cada elemento nfeProc
+// ou NFe, em qualquer nível, vira uma NotaLida, e só a nota atual fica na
+// memória — um arquivo de centenas de megabytes é percorrido com a memória
+// de uma nota. Cada nota é limitada a TamanhoMaxXML; o arquivo, não. Como em
+// ParseNFeReader, um DOCTYPE é recusado.
+//
+// Exemplo (alimentando o ValidarStream):
+//
+//	arq, _ := os.Open("exportacao-2025-07.xml")
+//	defer arq.Close()
+//	leitor := nfe.NewLeitorNotas(arq)
+//
+//	entrada := make(chan []byte)
+//	go func() {
+//	    defer close(entrada)
+//	    for {
+//	        nota, err := leitor.Proxima()
+//	        if err != nil {
+//	            return // io.EOF ao fim; outro erro: arquivo malformado
+//	        }
+//	        if nota.Err != nil {
+//	            log.Printf("nota %d: %v", nota.Indice, nota.Err)
+//	            continue
+//	        }
+//	        entrada <- nota.XML
+//	    }
+//	}()
+//	for result := range client.ValidarStream(ctx, entrada, nfe.Options{XSD: xsd}) {
+//	    ...
+//	}
+type LeitorNotas struct {
+	dec    *xml.Decoder
+	leitor *gravador
+	lidas  int
+	err    error // Erro definitivo (sintaxe, DOCTYPE, io.EOF): devolvido de novo a cada chamada
+}
+
+// NotaLida é uma nota encontrada por LeitorNotas.Proxima
+type NotaLida struct {
+	// XML é o elemento nfeProc ou NFe como está no arquivo (com o xmlns herdado do documento, se declarado fora dele)
+	XML []byte
+
+	// Nota é o XML interpretado, como por ParseNFe (nil quando Err != nil)
+	Nota *NFeEnvelope
+
+	// Err é a falha do parse desta nota; as seguintes continuam sendo lidas
+	Err error
+
+	// Indice é a posição da nota no arquivo, a partir de 1
+	Indice int
+
+	// Posicao é o deslocamento, em bytes, do início da nota no arquivo
+	Posicao int64
+}
+
+// NewLeitorNotas cria o LeitorNotas que lê o documento de r
+func NewLeitorNotas(r io.Reader) *LeitorNotas {
+	g := &gravador{r: bufio.NewReader(r)}
+	return &LeitorNotas{dec: xml.NewDecoder(g), leitor: g}
+}
+
+// Proxima devolve a próxima nota do arquivo, ou io.EOF quando não há mais notas
+//
+// Uma nota sem infNFe.Id ou maior que TamanhoMaxXML vem com NotaLida.Err, e
+// a leitura continua na seguinte. err só é preenchido quando o documento não
+// pode mais ser percorrido (XML malformado, DOCTYPE, trecho fora das notas
+// maior que TamanhoMaxXML) e, a partir daí, é devolvido em toda chamada.
+func (l *LeitorNotas) Proxima() (*NotaLida, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	for {
+		inicio := l.dec.InputOffset()
+		if !l.leitor.descartarAte(inicio) {
+			return nil, l.falhar(errXMLGrande)
+		}
+
+		tok, err := l.dec.Token()
+		if err == io.EOF {
+			return nil, l.falhar(io.EOF)
+		}
+		if err != nil {
+			return nil, l.falhar(fmt.Errorf("falha ao ler o arquivo de notas (byte %d): %w", inicio, err))
+		}
+		if _, ok := tok.(xml.Directive); ok {
+			return nil, l.falhar(errDoctype)
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok || !elementoNota(el) {
+			continue
+		}
+
+		// O XML da nota é decodificado uma única vez, enquanto o gravador guarda os bytes
+		nfe, err := decodificarNota(l.dec, el)
+		if err != nil {
+			return nil, l.falhar(fmt.Errorf("nota no byte %d: %w", inicio, err))
+		}
+		l.lidas++
+		nota := &NotaLida{Indice: l.lidas, Posicao: inicio}
+		trecho, ok := l.leitor.trecho(inicio, l.dec.InputOffset())
+		switch {
+		case !ok:
+			nota.Err = errXMLGrande
+		case nfe.InfNFe.ID == "":
+			nota.XML, nota.Err = comNamespace(trecho, el), fmt.Errorf("infNFe.Id não encontrado no XML")
+		default:
+			nota.XML, nota.Nota = comNamespace(trecho, el), nfe
+		}
+		return nota, nil
+	}
+}
+
+// falhar guarda o erro definitivo, devolvido nas chamadas seguintes
+func (l *LeitorNotas) falhar(err error) error {
+	l.err = err
+	return err
+}
+
+// comNamespace declara no elemento o namespace padrão herdado do documento (ex: NFe dentro de um enviNFe)
+//
+// Sem ele, a nota extraída não passaria no XSD. Elementos com prefixo ficam como estão.
+func comNamespace(trecho []byte, el xml.StartElement) []byte {
+	if el.Name.Space == "" || !bytes.HasPrefix(trecho, []byte("<"+el.Name.Local)) {
+		return trecho
+	}
+	for _, a := range el.Attr {
+		if a.Name.Space == "" && a.Name.Local == "xmlns" {
+			return trecho
+		}
+	}
+	n := len(el.Name.Local) + 1
+	xmlns := fmt.Sprintf(` xmlns="%s"`, el.Name.Space)
+	return append(trecho[:n:n], append([]byte(xmlns), trecho[n:]...)...)
+}
+
+// gravador entrega o documento ao xml.Decoder byte a byte e guarda os bytes desde o início do token atual
+//
+// Como io.ByteReader, o Decoder não faz buffer próprio: os deslocamentos de
+// InputOffset correspondem aos bytes guardados em buf.
+type gravador struct {
+	r       *bufio.Reader
+	lidos   int64  // Bytes entregues ao Decoder
+	base    int64  // Deslocamento de buf[0]
+	buf     []byte // Limitado a TamanhoMaxXML
+	excedeu bool   // Bytes deixaram de ser guardados desde o último descarte
+}
+
+func (g *gravador) ReadByte() (byte, error) {
+	b, err := g.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	g.lidos++
+	if len(g.buf) < TamanhoMaxXML {
+		g.buf = append(g.buf, b)
+	} else {
+		g.excedeu = true
+	}
+	return b, nil
+}
+
+func (g *gravador) Read(p []byte) (int, error) {
+	for i := range p {
+		b, err := g.ReadByte()
+		if err != nil {
+			if i > 0 {
+				err = nil
+			}
+			return i, err
+		}
+		p[i] = b
+	}
+	return len(p), nil
+}
+
+// descartarAte descarta os bytes antes de offset; false quando bytes a partir de offset já se perderam (trecho grande demais)
+func (g *gravador) descartarAte(offset int64) bool {
+	if g.excedeu {
+		g.excedeu = false
+		g.buf, g.base = g.buf[:0], g.lidos
+		return offset == g.lidos
+	}
+	if offset < g.base {
+		return false
+	}
+	n := copy(g.buf, g.buf[offset-g.base:])
+	g.buf, g.base = g.buf[:n], offset
+	return true
+}
+
+// trecho copia os bytes de inicio a fim; false quando a nota passou de TamanhoMaxXML
+func (g *gravador) trecho(inicio, fim int64) ([]byte, bool) {
+	if g.excedeu || inicio < g.base || fim-g.base > int64(len(g.buf)) {
+		return nil, false
+	}
+	return bytes.Clone(g.buf[inicio-g.base : fim-g.base]), true
+}
@@ -0,0 +1,93 @@
+package nfe
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Mascaramento de dados pessoais (LGPD) para logs e relatórios. Os
+// resultados da validação trazem os dados completos; estas funções servem
+// a quem precisa exibi-los ou registrá-los onde a política não permite.
+
+// mascaraEndereco substitui os campos de endereço e contato, que não têm parte útil a manter
+const mascaraEndereco = "***"
+
+// tagsPessoais são os campos do XML da NF-e (e das respostas da SEFAZ) com dados pessoais
+//
+// grupo 1: abertura da tag, com prefixo de namespace e atributos; grupo 2: nome; grupo 3: conteúdo.
+var tagsPessoais = regexp.MustCompile(`(<(?:[\w-]+:)?(CNPJ|CPF|idEstrangeiro|xNome|xFant|xLgr|nro|xCpl|xBairro|CEP|fone|email)(?:\s[^>]*)?>)([^<]*)`)
+
+// documentosSoltos são CPF e CNPJ fora de tags: formatados ou apenas os 11/14 dígitos
+var documentosSoltos = regexp.MustCompile(`\b(?:\d{3}\.\d{3}\.\d{3}-\d{2}|\d{2}\.\d{3}\.\d{3}/\d{4}-\d{2}|\d{14}|\d{11})\b`)
+
+// MascararDocumento mascara um CPF ou CNPJ mantendo os 3 primeiros e os 2 últimos dígitos
+//
+// A pontuação é descartada; um documento com menos de 6 dígitos é mascarado
+// por inteiro.
+//
+// Exemplo:
+//
+//	nfe.MascararDocumento("12.345.678/0001-95") // 123*********95
+//	nfe.MascararDocumento("123.456.789-09")     // 123******09
+func MascararDocumento(doc string) string {
+	digitos := OnlyDigits(doc)
+	if len(digitos) < 6 {
+		return strings.Repeat("*", len(digitos))
+	}
+	return digitos[:3] + strings.Repeat("*", len(digitos)-5) + digitos[len(digitos)-2:]
+}
+
+// MascararNome mascara um nome ou razão social mantendo a inicial de cada palavra
+//
+// Exemplo:
+//
+//	nfe.MascararNome("Maria da Silva") // M**** d* S****
+func MascararNome(nome string) string {
+	var b strings.Builder
+	inicio := true
+	for _, r := range nome {
+		switch {
+		case unicode.IsSpace(r):
+			inicio = true
+			b.WriteRune(r)
+		case inicio:
+			inicio = false
+			b.WriteRune(r)
+		default:
+			b.WriteByte('*')
+		}
+	}
+	return b.String()
+}
+
+// MascararTexto mascara os dados pessoais de um texto livre: uma mensagem de log, um XML da NF-e ou uma resposta da SEFAZ
+//
+// Nas tags do XML, CPF/CNPJ passam por MascararDocumento, nomes (xNome,
+// xFant) por MascararNome e endereço, contato e documento estrangeiro
+// (xLgr, nro, xCpl, xBairro, CEP, fone, email, idEstrangeiro) são trocados
+// por "***". Fora das tags, são mascarados os CPF e CNPJ formatados e os
+// números de 11 ou 14 dígitos; a chave de acesso (44 dígitos) é mantida.
+// Nomes soltos no texto não são reconhecidos.
+//
+// Exemplo:
+//
+//	nfe.MascararTexto("<dest><CPF>12345678909</CPF><xNome>Maria da Silva</xNome></dest>")
+//	// <dest><CPF>123******09</CPF><xNome>M**** d* S****</xNome></dest>
+func MascararTexto(s string) string {
+	s = tagsPessoais.ReplaceAllStringFunc(s, func(m string) string {
+		partes := tagsPessoais.FindStringSubmatch(m)
+		abertura, tag, conteudo := partes[1], partes[2], partes[3]
+		if conteudo == "" {
+			return m
+		}
+		switch tag {
+		case "CNPJ", "CPF":
+			return abertura + MascararDocumento(conteudo)
+		case "xNome", "xFant":
+			return abertura + MascararNome(conteudo)
+		}
+		return abertura + mascaraEndereco
+	})
+	return documentosSoltos.ReplaceAllStringFunc(s, MascararDocumento)
+}
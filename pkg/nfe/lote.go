@@ -0,0 +1,170 @@
+package nfe
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// LoteResult é a validação de um arquivo do lote (ver Client.ValidarLote)
+type LoteResult struct {
+	// Arquivo é o caminho informado
+	Arquivo string `json:"arquivo"`
+
+	// Classificacao é um dos Resultado* (ResultadoErro: arquivo ilegível ou lote interrompido)
+	Classificacao string `json:"classificacao"`
+
+	// Resultado é a validação completa do arquivo (nil com ResultadoErro)
+	Resultado *ValidationResult `json:"resultado,omitempty"`
+
+	// Erro descreve a falha com ResultadoErro; as falhas das fases ficam em Resultado.Erro
+	Erro string `json:"erro,omitempty"`
+
+	// CodigoErro é o código da falha do arquivo (ver ValidationResult.CodigoErro); vazio se aprovado
+	CodigoErro CodigoErro `json:"codigo_erro,omitempty"`
+
+	// Duracao é o tempo de leitura e validação do arquivo (no JSON, em nanossegundos)
+	Duracao time.Duration `json:"duracao_ns"`
+}
+
+// ResumoLote totaliza um lote por classificação (ver Resultado*)
+type ResumoLote struct {
+	Total         int `json:"total"`
+	Aprovadas     int `json:"aprovadas"`
+	Reprovadas    int `json:"reprovadas"`
+	XSDInvalido   int `json:"xsd_invalido"`
+	Parse         int `json:"parse"`
+	Rejeitadas    int `json:"rejeitadas"`
+	Conectividade int `json:"conectividade"`
+	Vetadas       int `json:"vetadas"`
+	Erros         int `json:"erros"`
+
+	// ComAchados conta os arquivos com algum achado das regras (de qualquer severidade)
+	ComAchados int `json:"com_achados"`
+
+	// Duracao é o tempo total do lote, do início ao último arquivo (no JSON, em nanossegundos)
+	Duracao time.Duration `json:"duracao_ns"`
+}
+
+// ValidarLote valida os arquivos em paralelo com Client.Validar, nas fases escolhidas em o
+//
+// Os resultados vêm na ordem de xmlPaths. O XSD de o.XSD vem do CacheXSDPadrao
+// (compilado uma única vez) e é compartilhado entre os workers (o.Workers; zero =
+// runtime.NumCPU()). err só é preenchido quando o XSD não pode ser carregado.
+//
+// Exemplo:
+//
+//	resultados, resumo, err := client.ValidarLote(arquivos, nfe.Options{
+//	    XSD:     "schemas/v4/procNFe_v4.00.xsd",
+//	    Workers: 8,
+//	})
+//	for _, r := range resultados {
+//	    fmt.Printf("%s: %s (%s)\n", r.Arquivo, r.Classificacao, r.Duracao)
+//	}
+//	fmt.Printf("%d de %d aprovadas\n", resumo.Aprovadas, resumo.Total)
+func (c *Client) ValidarLote(xmlPaths []string, o Options) ([]LoteResult, ResumoLote, error) {
+	return c.ValidarLoteContext(context.Background(), xmlPaths, o)
+}
+
+// ValidarLoteContext é o ValidarLote com contexto
+//
+// Quando ctx termina, os arquivos que faltavam ficam com ResultadoErro e o
+// erro do contexto.
+func (c *Client) ValidarLoteContext(ctx context.Context, xmlPaths []string, o Options) ([]LoteResult, ResumoLote, error) {
+	inicio := time.Now()
+
+	if o.Schema == nil {
+		xsd, err := CacheXSDPadrao.Carregar(o.XSD)
+		if err != nil {
+			return nil, ResumoLote{}, fmt.Errorf("falha ao carregar XSD: %w", err)
+		}
+		o.Schema = xsd
+	}
+
+	workers := o.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, len(xmlPaths))
+
+	resultados := make([]LoteResult, len(xmlPaths))
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				resultados[i] = c.validarArquivoLote(ctx, xmlPaths[i], o)
+			}
+		}()
+	}
+	for i := range xmlPaths {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return resultados, resumirLote(resultados, time.Since(inicio)), nil
+}
+
+// validarArquivoLote lê e valida um arquivo do lote
+func (c *Client) validarArquivoLote(ctx context.Context, xmlPath string, o Options) LoteResult {
+	inicio := time.Now()
+	r := LoteResult{Arquivo: xmlPath, Classificacao: ResultadoErro}
+
+	if err := ctx.Err(); err != nil {
+		r.Erro, r.CodigoErro = interrompida(ctx).Error(), CodigoInterrompida
+		return r
+	}
+
+	xmlData, err := os.ReadFile(xmlPath)
+	if err != nil {
+		r.Erro, r.CodigoErro = fmt.Sprintf("erro ao ler arquivo XML: %v", err), CodigoXMLIlegivel
+		r.Duracao = time.Since(inicio)
+		return r
+	}
+
+	result, classificacao, err := c.validar(ctx, xmlData, o)
+	r.Duracao = time.Since(inicio)
+	if err != nil {
+		r.Erro, r.CodigoErro = err.Error(), CodigoDe(err)
+		return r
+	}
+	r.Resultado, r.Classificacao, r.CodigoErro = result, classificacao, result.CodigoErro()
+	return r
+}
+
+// resumirLote totaliza os resultados por classificação
+func resumirLote(resultados []LoteResult, duracao time.Duration) ResumoLote {
+	resumo := ResumoLote{Total: len(resultados), Duracao: duracao}
+	for _, r := range resultados {
+		if r.Resultado != nil && len(r.Resultado.Achados) > 0 {
+			resumo.ComAchados++
+		}
+
+		switch r.Classificacao {
+		case ResultadoAprovada:
+			resumo.Aprovadas++
+		case ResultadoReprovada:
+			resumo.Reprovadas++
+		case ResultadoXSDInvalido:
+			resumo.XSDInvalido++
+		case ResultadoParse:
+			resumo.Parse++
+		case ResultadoRejeitada:
+			resumo.Rejeitadas++
+		case ResultadoConectividade:
+			resumo.Conectividade++
+		case ResultadoVetada:
+			resumo.Vetadas++
+		default:
+			resumo.Erros++
+		}
+	}
+	return resumo
+}
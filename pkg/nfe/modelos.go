@@ -0,0 +1,111 @@
+package nfe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Modelos de documento fiscal (ide/mod)
+const (
+	ModeloNFe  = "55"
+	ModeloNFCe = "65"
+)
+
+// LimiteValorNFCePadrao é o valor máximo padrão de uma NFC-e (R$ 200.000,00)
+//
+// Algumas UFs adotam limites menores; use ConfigRegras.LimiteValorNFCe para ajustar.
+const LimiteValorNFCePadrao = 200000.00
+
+// VerificarModeloNFe confere os campos e restrições específicos da NF-e (modelo 55)
+//
+// Verifica:
+//   - destinatário informado (obrigatório na NF-e)
+//   - ausência de infNFeSupl (QR Code é exclusivo da NFC-e)
+func VerificarModeloNFe(nfe *NFeEnvelope) []Inconsistencia {
+	var inconsistencias []Inconsistencia
+
+	if !destinatarioInformado(nfe.InfNFe.Dest) {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Grupo:    "dest",
+			Mensagem: "NF-e (modelo 55) sem destinatário informado",
+		})
+	}
+
+	if nfe.InfNFeSupl != nil {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Grupo:    "infNFeSupl",
+			Mensagem: "infNFeSupl (QR Code) informado em NF-e modelo 55",
+		})
+	}
+
+	return inconsistencias
+}
+
+// VerificarModeloNFCe confere os campos e restrições específicos da NFC-e (modelo 65)
+//
+// Verifica:
+//   - grupo de pagamento (pag) informado
+//   - infNFeSupl com qrCode e urlChave informados
+//   - operação de saída (tpNF = 1) e interna (idDest = 1)
+//   - vNF não excede o limite de valor da NFC-e
+//
+// O destinatário é opcional na NFC-e e não é conferido.
+func VerificarModeloNFCe(nfe *NFeEnvelope, limiteValor float64) []Inconsistencia {
+	var inconsistencias []Inconsistencia
+	inf := nfe.InfNFe
+
+	if inf.Pag == nil || len(inf.Pag.DetPag) == 0 {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Grupo:    "pag",
+			Mensagem: "NFC-e sem grupo de pagamento (pag/detPag)",
+		})
+	}
+
+	if nfe.InfNFeSupl == nil {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Grupo:    "infNFeSupl",
+			Mensagem: "NFC-e sem informações suplementares (infNFeSupl)",
+		})
+	} else {
+		if strings.TrimSpace(nfe.InfNFeSupl.QrCode) == "" {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Grupo:    "qrCode",
+				Mensagem: "NFC-e sem QR Code (infNFeSupl/qrCode)",
+			})
+		}
+		if strings.TrimSpace(nfe.InfNFeSupl.URLChave) == "" {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Grupo:    "urlChave",
+				Mensagem: "NFC-e sem URL de consulta (infNFeSupl/urlChave)",
+			})
+		}
+	}
+
+	if inf.Ide.TpNF != "" && inf.Ide.TpNF != TpNFSaida {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Grupo:    "tpNF",
+			Mensagem: "NFC-e deve ser de saída (tpNF = 1)",
+		})
+	}
+
+	if inf.Ide.IdDest != "" && inf.Ide.IdDest != IdDestInterna {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Grupo:    "idDest",
+			Mensagem: fmt.Sprintf("NFC-e deve ser operação interna (idDest = 1), informado %s", inf.Ide.IdDest),
+		})
+	}
+
+	if vNF, ok := parseValor(inf.Total.ICMSTot.VNF); ok && limiteValor > 0 && vNF > limiteValor {
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Grupo:    "vNF",
+			Mensagem: fmt.Sprintf("valor da NFC-e (%.2f) excede o limite de %.2f", vNF, limiteValor),
+		})
+	}
+
+	return inconsistencias
+}
+
+// destinatarioInformado indica se o grupo dest foi preenchido
+func destinatarioInformado(dest Dest) bool {
+	return ChooseFirstNonEmpty(dest.CNPJ, dest.CPF, dest.IdEstrangeiro, dest.XNome) != ""
+}
@@ -0,0 +1,167 @@
+package nfe
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RejeicaoNCMInexistente: Informado NCM inexistente (cStat 778)
+const RejeicaoNCMInexistente = "778"
+
+// ncmTabela é a tabela NCM compacta embutida no binário
+//
+// Gerada pelo script download_ncm.sh a partir do Portal Único Siscomex
+// (go generate ./pkg/nfe); sem ela, NCMExiste cai na conferência do capítulo.
+//
+//go:generate bash ../../download_ncm.sh ncm.txt
+//go:embed ncm.txt
+var ncmTabela string
+
+var (
+	ncmOnce   sync.Once
+	ncmCodigo map[string]time.Time // código -> fim de vigência (zero se vigente)
+)
+
+// carregarNCM faz o parse da tabela embutida (apenas na primeira chamada)
+func carregarNCM() map[string]time.Time {
+	ncmOnce.Do(func() {
+		ncmCodigo = make(map[string]time.Time)
+
+		scanner := bufio.NewScanner(strings.NewReader(ncmTabela))
+		for scanner.Scan() {
+			linha := strings.TrimSpace(scanner.Text())
+			if linha == "" || strings.HasPrefix(linha, "#") {
+				continue
+			}
+
+			codigo, fim, _ := strings.Cut(linha, ";")
+			var fimVigencia time.Time
+			if fim != "" {
+				if t, err := time.Parse("2006-01-02", fim); err == nil {
+					fimVigencia = t
+				}
+			}
+			ncmCodigo[codigo] = fimVigencia
+		}
+	})
+	return ncmCodigo
+}
+
+// NCMExiste indica se o código NCM (8 dígitos) é aceito
+//
+// O ncm.txt do repositório traz só o cabeçalho: sem gerar a tabela (ver
+// download_ncm.sh), confere apenas se o capítulo existe, e um código
+// inexistente de capítulo válido passa. Com a tabela gerada, confere o código
+// e retorna também a data de fim de vigência (zero quando o NCM está vigente).
+func NCMExiste(ncm string) (existe bool, fimVigencia time.Time) {
+	if len(ncm) != 8 || OnlyDigits(ncm) != ncm {
+		return false, time.Time{}
+	}
+
+	tabela := carregarNCM()
+	if len(tabela) == 0 {
+		return capituloNCMExiste(ncm[:2]), time.Time{}
+	}
+
+	fim, ok := tabela[ncm]
+	return ok, fim
+}
+
+// capituloNCMExiste confere o capítulo (2 primeiros dígitos) da NCM/SH
+//
+// Os capítulos vão de 01 a 97, sendo o 77 reservado para uso futuro.
+func capituloNCMExiste(capitulo string) bool {
+	return capitulo >= "01" && capitulo <= "97" && capitulo != "77"
+}
+
+// VerificarNCM confere o NCM de cada item da nota
+//
+// Verifica se o NCM tem 8 dígitos e se o capítulo existe (rejeição 778).
+// A existência do código e a vigência na data de emissão só são conferidas
+// com a tabela NCM gerada por download_ncm.sh (ver NCMExiste).
+// O NCM "00" é aceito, pois é o valor usado para itens de serviço.
+//
+// Exemplo:
+//
+//	nota, _ := nfe.ParseNFe(xmlData)
+//	for _, inc := range nfe.VerificarNCM(nota) {
+//	    fmt.Printf("Item %d: %s\n", inc.Item, inc.Mensagem)
+//	}
+func VerificarNCM(nfe *NFeEnvelope) []Inconsistencia {
+	var inconsistencias []Inconsistencia
+
+	for i, det := range nfe.InfNFe.Det {
+		ncm := strings.TrimSpace(det.Prod.NCM)
+		if ncm == "00" {
+			continue
+		}
+
+		item := numeroItem(det, i)
+		if len(ncm) != 8 || OnlyDigits(ncm) != ncm {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Codigo:   RejeicaoNCMInexistente,
+				Item:     item,
+				Grupo:    "NCM",
+				Mensagem: fmt.Sprintf("NCM '%s' deve ter 8 dígitos", ncm),
+			})
+			continue
+		}
+
+		existe, fim := NCMExiste(ncm)
+		if !existe {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Codigo:   RejeicaoNCMInexistente,
+				Item:     item,
+				Grupo:    "NCM",
+				Mensagem: fmt.Sprintf("NCM '%s' inexistente na tabela NCM", ncm),
+			})
+			continue
+		}
+
+		// NCM já revogado na data de emissão
+		if dhEmi, err := parseDataHora(nfe.InfNFe.Ide.DhEmi); err == nil && !fim.IsZero() && dhEmi.After(fim) {
+			inconsistencias = append(inconsistencias, Inconsistencia{
+				Codigo:   RejeicaoNCMInexistente,
+				Item:     item,
+				Grupo:    "NCM",
+				Mensagem: fmt.Sprintf("NCM '%s' revogado em %s, antes da emissão", ncm, fim.Format("02/01/2006")),
+			})
+		}
+	}
+
+	return inconsistencias
+}
+
+// VerificarNCMRevogados aponta itens com NCM revogado após a data informada
+//
+// Útil como aviso: o NCM ainda consta na tabela, mas teve a vigência encerrada
+// depois de 'desde' (ex: mudanças recentes da TIPI que o ERP ainda não aplicou).
+// Depende da tabela completa gerada por download_ncm.sh.
+//
+// Exemplo:
+//
+//	seisMeses := time.Now().AddDate(0, -6, 0)
+//	avisos := nfe.VerificarNCMRevogados(nota, seisMeses)
+func VerificarNCMRevogados(nfe *NFeEnvelope, desde time.Time) []Inconsistencia {
+	var inconsistencias []Inconsistencia
+
+	for i, det := range nfe.InfNFe.Det {
+		ncm := strings.TrimSpace(det.Prod.NCM)
+		existe, fim := NCMExiste(ncm)
+		if !existe || fim.IsZero() || !fim.After(desde) {
+			continue
+		}
+
+		inconsistencias = append(inconsistencias, Inconsistencia{
+			Item:     numeroItem(det, i),
+			Grupo:    "NCM",
+			Mensagem: fmt.Sprintf("NCM '%s' revogado em %s", ncm, fim.Format("02/01/2006")),
+		})
+	}
+
+	return inconsistencias
+}
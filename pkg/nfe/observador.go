@@ -0,0 +1,96 @@
+package nfe
+
+import (
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+)
+
+// Resultados de uma validação, informados em Validacao.Resultado
+const (
+	// ResultadoAprovada: todas as fases executadas passaram
+	ResultadoAprovada = "aprovada"
+
+	// ResultadoReprovada: as fases passaram, mas há achados de erro nas regras de negócio
+	ResultadoReprovada = "reprovada"
+
+	// ResultadoXSDInvalido: XML não passou na validação XSD
+	ResultadoXSDInvalido = "xsd_invalido"
+
+	// ResultadoParse: XML válido no XSD, mas não pôde ser interpretado como NF-e
+	ResultadoParse = "parse"
+
+	// ResultadoRejeitada: SEFAZ respondeu, mas a nota não está autorizada
+	ResultadoRejeitada = "rejeitada"
+
+	// ResultadoConectividade: falha de configuração ou de comunicação com a SEFAZ
+	ResultadoConectividade = "conectividade"
+
+	// ResultadoVetada: um Hook (Client.Use) vetou uma fase
+	ResultadoVetada = "vetada"
+
+	// ResultadoErro: XML ilegível, falha de uma etapa própria (NovaEtapa) ou outra falha fora das fases de validação
+	ResultadoErro = "erro"
+)
+
+// Serviços da SEFAZ informados em ChamadaSefaz.Servico
+const (
+	ServicoConsulta       = sefaz.ServicoConsulta       // NfeConsultaProtocolo4
+	ServicoConsultaNFCe   = sefaz.ServicoConsultaNFCe   // NfeConsultaProtocolo4 da NFC-e (modelo 65)
+	ServicoStatus         = sefaz.ServicoStatus         // NfeStatusServico4
+	ServicoEvento         = sefaz.ServicoEvento         // NFeRecepcaoEvento4
+	ServicoDistribuicao   = sefaz.ServicoDistribuicao   // NFeDistribuicaoDFe
+	ServicoRetAutorizacao = sefaz.ServicoRetAutorizacao // NFeRetAutorizacao4 (consulta do lote pelo recibo)
+	ServicoEventoUF       = sefaz.ServicoEventoUF       // NFeRecepcaoEvento4 do autorizador da UF
+)
+
+// Validacao resume uma validação concluída
+type Validacao struct {
+	// Resultado é um dos Resultado* (ex: ResultadoAprovada)
+	Resultado string
+
+	// Duracao é o tempo total da validação, da leitura do XML à resposta da SEFAZ
+	Duracao time.Duration
+
+	// ErroXSD é a falha da validação XSD (apenas com ResultadoXSDInvalido)
+	ErroXSD error
+}
+
+// ChamadaSefaz descreve uma requisição concluída a um web service da SEFAZ
+type ChamadaSefaz struct {
+	Servico  string        // Um dos Servico* (ex: ServicoConsulta)
+	Endpoint string        // URL do web service
+	Duracao  time.Duration // Do envio até a leitura completa da resposta
+	Codigo   string        // Primeiro cStat da resposta (vazio se não houve resposta)
+	Erro     error         // Falha de rede ou de leitura; rejeições vêm em Codigo
+}
+
+// Observador recebe as validações e as chamadas à SEFAZ de um Client (ex: nfemetrics.Coletor)
+//
+// Os métodos podem ser chamados por várias goroutines ao mesmo tempo.
+//
+// Exemplo:
+//
+//	coletor := nfemetrics.New()
+//	prometheus.MustRegister(coletor)
+//
+//	client, err := nfe.NewClient(nfe.Config{
+//	    CertDir:     "cert",
+//	    CertKeyFile: "key.pem",
+//	    CertPubFile: "cert.pem",
+//	    Observador:  coletor,
+//	})
+type Observador interface {
+	ObservarValidacao(v Validacao)
+	ObservarSefaz(c ChamadaSefaz)
+}
+
+// observarSefaz repassa as chamadas do cliente SEFAZ interno ao Observador
+func observarSefaz(cliente *sefaz.Client, o Observador) {
+	if o == nil {
+		return
+	}
+	cliente.Observar(func(c sefaz.Chamada) {
+		o.ObservarSefaz(ChamadaSefaz(c))
+	})
+}
@@ -0,0 +1,226 @@
+package nfe
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Opcao ajusta o cliente criado por NewClient ou NewClientFromEnv
+//
+// As opções são aplicadas na ordem e valem sobre os campos equivalentes de
+// Config (ex: WithEndpoint substitui Config.ConsultaURL).
+//
+// Exemplo:
+//
+//	client, err := nfe.NewClient(nfe.Config{UF: "35"},
+//	    nfe.WithCertPFX("cert/empresa.pfx", os.Getenv("NFE_CERT_SENHA")),
+//	    nfe.WithTimeout(30*time.Second),
+//	    nfe.WithCache(nfe.NewCacheMemoria(10*time.Minute)),
+//	)
+type Opcao func(*opcoesClient)
+
+// opcoesClient reúne o que as Opcao configuram
+type opcoesClient struct {
+	pfxCaminho  string
+	pfxSenha    string
+	timeout     time.Duration
+	endpoint    string
+	logger      *slog.Logger
+	cache       Cache
+	consulter   Consulter
+	validadores []Validador
+	logResposta int
+	resposta    *string // WithRespostaSefaz: diretório das respostas ("" = no próprio resultado)
+	transporte  func(http.RoundTripper) http.RoundTripper
+}
+
+// WithCertPFX usa o certificado A1 do arquivo .pfx/.p12 (chave privada e cadeia) no mTLS
+//
+// Substitui CertDir/CertKeyFile/CertPubFile de Config; se CertDir for
+// informado, a pasta continua fornecendo as CAs do ICP-Brasil.
+func WithCertPFX(caminho, senha string) Opcao {
+	return func(o *opcoesClient) {
+		o.pfxCaminho, o.pfxSenha = caminho, senha
+	}
+}
+
+// WithTimeout limita cada requisição à SEFAZ (padrão 15s; zero ou negativo mantém o padrão)
+//
+// Para limitar uma validação inteira, use os métodos *Context com um prazo.
+func WithTimeout(d time.Duration) Opcao {
+	return func(o *opcoesClient) {
+		o.timeout = d
+	}
+}
+
+// WithEndpoint define a URL do web service de consulta (NfeConsultaProtocolo4)
+//
+// Útil para homologação, SVC (contingência) ou um proxy interno.
+func WithEndpoint(url string) Opcao {
+	return func(o *opcoesClient) {
+		o.endpoint = url
+	}
+}
+
+// WithTransporte envolve o transporte HTTP das chamadas à SEFAZ (ex: nfetest.Cassete, um proxy próprio, métricas)
+//
+// envolver recebe o transporte padrão, já com o certificado (mTLS), e
+// devolve o que o cliente vai usar; o Timeout de WithTimeout continua
+// valendo. Ignorada com WithConsulter.
+func WithTransporte(envolver func(http.RoundTripper) http.RoundTripper) Opcao {
+	return func(o *opcoesClient) {
+		o.transporte = envolver
+	}
+}
+
+// WithLogger recebe os registros do cliente: as falhas do Cache (Warn) e, com WithLogRespostaSefaz, a resposta bruta da SEFAZ (Debug)
+//
+// Sem logger, o cliente não registra nada.
+func WithLogger(l *slog.Logger) Opcao {
+	return func(o *opcoesClient) {
+		o.logger = l
+	}
+}
+
+// LimiteLogRespostaPadrao é o corte da resposta registrada por WithLogRespostaSefaz com limite zero
+const LimiteLogRespostaPadrao = 2 << 10
+
+// WithLogRespostaSefaz registra no logger (WithLogger), em nível Debug, a resposta bruta de cada consulta
+//
+// A resposta é cortada nos primeiros limite bytes (zero ou negativo =
+// LimiteLogRespostaPadrao). Ela traz dados pessoais (CPF/CNPJ, nomes):
+// ligue apenas para diagnóstico. Para guardar a resposta, use WithRespostaSefaz.
+func WithLogRespostaSefaz(limite int) Opcao {
+	return func(o *opcoesClient) {
+		if limite <= 0 {
+			limite = LimiteLogRespostaPadrao
+		}
+		o.logResposta = limite
+	}
+}
+
+// WithCache reaproveita as consultas à SEFAZ por chave de acesso
+//
+// Apenas situações definitivas são guardadas (ver SituacaoDefinitiva): uma
+// nota em processamento ou não encontrada é consultada de novo.
+func WithCache(c Cache) Opcao {
+	return func(o *opcoesClient) {
+		o.cache = c
+	}
+}
+
+// WithConsulter troca a consulta à SEFAZ por outra implementação (ex: um fake nos testes unitários)
+//
+// Com um Consulter próprio, nenhum certificado é carregado: as opções e os
+// campos de Config que só servem ao cliente SEFAZ (certificado, timeout,
+// endpoint) são ignorados e Client.Certificado devolve erro.
+func WithConsulter(c Consulter) Opcao {
+	return func(o *opcoesClient) {
+		o.consulter = c
+	}
+}
+
+// WithValidadores acrescenta verificações próprias à fase de regras de Client.Validar (ver Validador)
+//
+// Os validadores rodam depois das regras padrão, na ordem informada; a opção
+// pode ser repetida.
+func WithValidadores(v ...Validador) Opcao {
+	return func(o *opcoesClient) {
+		o.validadores = append(o.validadores, v...)
+	}
+}
+
+// WithRespostaSefaz guarda a resposta bruta da SEFAZ (retConsSitNFe) de cada consulta, para auditoria
+//
+// Com dir vazio, o XML vai em ValidationResult.RespostaSefaz. Com um
+// diretório, a resposta é gravada em dir/<chave>-<AAAAMMDDhhmmss>.xml (o
+// horário da consulta) e o caminho vai em ValidationResult.RespostaSefazArquivo;
+// uma falha na gravação não interrompe a validação: é registrada no logger
+// (WithLogger) e o caminho fica vazio.
+//
+// Uma consulta vinda do Cache traz a resposta original. Um Consulter próprio
+// (WithConsulter) só tem resposta se preencher ConsultaSefaz.Resposta.
+func WithRespostaSefaz(dir string) Opcao {
+	return func(o *opcoesClient) {
+		o.resposta = &dir
+	}
+}
+
+// Cache guarda as consultas de situação na SEFAZ por chave de acesso (ver WithCache)
+//
+// As implementações devem aceitar chamadas concorrentes e decidem por quanto
+// tempo cada consulta vale. Um erro do Cache não interrompe a validação: a
+// SEFAZ é consultada normalmente.
+type Cache interface {
+	// Buscar devolve a consulta guardada da chave; ok = encontrada e ainda válida
+	Buscar(ctx context.Context, chave string) (c ConsultaSefaz, ok bool, err error)
+	// Guardar registra a consulta da chave
+	Guardar(ctx context.Context, chave string, c ConsultaSefaz) error
+}
+
+// SituacaoDefinitiva indica se o cStat da consulta não muda sem nova ação do emitente
+//
+//	100, 150            autorizada (fora do prazo)
+//	101, 151, 155       cancelamento homologado
+//	110, 205, 301-303   uso denegado
+//
+// Uma nota autorizada ainda pode ser cancelada: o TTL do Cache define por
+// quanto tempo essa possibilidade é aceita.
+func SituacaoDefinitiva(cStat string) bool {
+	switch cStat {
+	case "100", "150", "101", "151", "155", "110", "205", "301", "302", "303":
+		return true
+	}
+	return false
+}
+
+// CacheMemoria é o Cache em memória do processo, com validade fixa por consulta
+type CacheMemoria struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	itens   map[string]ConsultaSefaz
+	limpeza time.Time // Última remoção dos itens vencidos
+}
+
+// NewCacheMemoria cria o Cache em memória; cada consulta vale por ttl
+func NewCacheMemoria(ttl time.Duration) *CacheMemoria {
+	return &CacheMemoria{ttl: ttl, itens: make(map[string]ConsultaSefaz), limpeza: time.Now()}
+}
+
+// Buscar devolve a consulta da chave se ainda estiver dentro do ttl
+func (m *CacheMemoria) Buscar(_ context.Context, chave string) (ConsultaSefaz, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.itens[chave]
+	if !ok {
+		return ConsultaSefaz{}, false, nil
+	}
+	if time.Since(c.ConsultadoEm) >= m.ttl {
+		delete(m.itens, chave)
+		return ConsultaSefaz{}, false, nil
+	}
+	return c, true, nil
+}
+
+// Guardar registra a consulta e, a cada ttl, remove as vencidas
+func (m *CacheMemoria) Guardar(_ context.Context, chave string, c ConsultaSefaz) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	agora := time.Now()
+	if agora.Sub(m.limpeza) >= m.ttl {
+		for k, item := range m.itens {
+			if agora.Sub(item.ConsultadoEm) >= m.ttl {
+				delete(m.itens, k)
+			}
+		}
+		m.limpeza = agora
+	}
+	m.itens[chave] = c
+	return nil
+}
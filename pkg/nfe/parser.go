@@ -1,8 +1,11 @@
 package nfe
 
 import (
+	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
@@ -34,7 +37,7 @@ import (
 func ParsearXML(xmlData []byte) (*DadosNFe, error) {
 	nfe, err := ParseNFe(xmlData)
 	if err != nil {
-		return nil, fmt.Errorf("falha ao parsear XML: %w", err)
+		return nil, comCodigo(codigoParse(err), fmt.Errorf("falha ao parsear XML: %w", err))
 	}
 
 	return convertNFeData(nfe), nil
@@ -53,22 +56,47 @@ func ParsearXML(xmlData []byte) (*DadosNFe, error) {
 func ParsearXMLFile(xmlPath string) (*DadosNFe, error) {
 	xmlData, err := os.ReadFile(xmlPath)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao ler arquivo XML: %w", err)
+		return nil, comCodigo(CodigoXMLIlegivel, fmt.Errorf("erro ao ler arquivo XML: %w", err))
 	}
 
 	return ParsearXML(xmlData)
 }
 
+// ParsearXMLReader é o ParsearXML lendo o XML de r, sem carregá-lo inteiro na memória
+//
+// Exemplo (corpo de uma requisição HTTP):
+//
+//	dados, err := nfe.ParsearXMLReader(r.Body)
+//	if err != nil {
+//	    http.Error(w, err.Error(), http.StatusBadRequest)
+//	    return
+//	}
+func ParsearXMLReader(r io.Reader) (*DadosNFe, error) {
+	nfe, err := ParseNFeReader(r)
+	if err != nil {
+		return nil, comCodigo(codigoParse(err), fmt.Errorf("falha ao parsear XML: %w", err))
+	}
+
+	return convertNFeData(nfe), nil
+}
+
 // ParseNFe faz o parse do XML bruto para a estrutura NFeEnvelope
 //
 // Tenta primeiro como procNFe (formato mais comum), depois como NFe puro.
 //
 // Esta é uma função de nível mais baixo. Use ParsearXML() para casos comuns.
+//
+// XML maior que TamanhoMaxXML ou com DOCTYPE (a NF-e não usa DTD) é
+// recusado antes do parse.
 func ParseNFe(xmlData []byte) (*NFeEnvelope, error) {
+	if err := conferirXML(xmlData); err != nil {
+		return nil, err
+	}
+
 	// 1) Tentar parsear como procNFe (XML completo com protocolo)
 	var proc ProcNFe
 	if err := xml.Unmarshal(xmlData, &proc); err == nil && proc.NFe.InfNFe.ID != "" {
-		return &proc.NFe, nil
+		return proc.nota(), nil
 	}
 
 	// 2) Tentar parsear como NFe direto (sem protocolo)
@@ -85,6 +113,183 @@ func ParseNFe(xmlData []byte) (*NFeEnvelope, error) {
 	return &nfe, nil
 }
 
+// ParseNFeReader é o ParseNFe lendo o XML de r
+//
+// O XML é decodificado à medida que é lido: o elemento raiz decide entre
+// procNFe (nfeProc) e NFe puro, e o restante do documento depois da nota
+// não é lido. Como em ParseNFe, a leitura para em TamanhoMaxXML e um
+// DOCTYPE é recusado.
+func ParseNFeReader(r io.Reader) (*NFeEnvelope, error) {
+	limite := &leitorLimitado{r: r, restante: TamanhoMaxXML + 1}
+	dec := xml.NewDecoder(limite)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, limite.erro(fmt.Errorf("falha ao parsear XML: não é um formato NFe válido: %w", err))
+		}
+		if _, ok := tok.(xml.Directive); ok {
+			return nil, errDoctype
+		}
+		raiz, ok := tok.(xml.StartElement)
+		if !ok {
+			continue // declaração <?xml?>, comentários e espaços antes da raiz
+		}
+
+		if !elementoNota(raiz) {
+			return nil, fmt.Errorf("falha ao parsear XML: elemento raiz <%s> não é nfeProc nem NFe", raiz.Name.Local)
+		}
+		nfe, err := decodificarNota(dec, raiz)
+		if err != nil {
+			return nil, limite.erro(err)
+		}
+		if nfe.InfNFe.ID == "" {
+			return nil, fmt.Errorf("infNFe.Id não encontrado no XML")
+		}
+		return nfe, nil
+	}
+}
+
+// elementoNota indica se o elemento é uma nota: nfeProc ou NFe
+func elementoNota(el xml.StartElement) bool {
+	return el.Name.Local == "nfeProc" || el.Name.Local == "NFe"
+}
+
+// decodificarNota decodifica o elemento nfeProc ou NFe que começa em el (sem conferir o infNFe.Id)
+func decodificarNota(dec *xml.Decoder, el xml.StartElement) (*NFeEnvelope, error) {
+	if el.Name.Local == "nfeProc" {
+		var proc ProcNFe
+		if err := dec.DecodeElement(&proc, &el); err != nil {
+			return nil, fmt.Errorf("falha ao parsear XML: não é um procNFe válido: %w", err)
+		}
+		return proc.nota(), nil
+	}
+	var nfe NFeEnvelope
+	if err := dec.DecodeElement(&nfe, &el); err != nil {
+		return nil, fmt.Errorf("falha ao parsear XML: não é um formato NFe válido: %w", err)
+	}
+	return &nfe, nil
+}
+
+// ParseProcNFe faz o parse de um procNFe (nota + protocolo de autorização)
+//
+// Ao contrário de ParseNFe, exige o XML completo devolvido pela SEFAZ e
+// mantém o protocolo (protNFe), necessário por exemplo para imprimir o DANFE.
+//
+// Exemplo:
+//
+//	xmlData, _ := os.ReadFile("nota-procNFe.xml")
+//	proc, err := nfe.ParseProcNFe(xmlData)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(proc.ProtNFe.InfProt.NProt) // 135250001234567
+func ParseProcNFe(xmlData []byte) (*ProcNFe, error) {
+	if err := conferirXML(xmlData); err != nil {
+		return nil, err
+	}
+
+	var proc ProcNFe
+	if err := xml.Unmarshal(xmlData, &proc); err != nil {
+		return nil, fmt.Errorf("falha ao parsear XML: não é um procNFe válido: %w", err)
+	}
+
+	if proc.NFe.InfNFe.ID == "" {
+		return nil, fmt.Errorf("infNFe.Id não encontrado no XML")
+	}
+	if proc.ProtNFe == nil {
+		return nil, fmt.Errorf("protNFe não encontrado: o XML não contém o protocolo de autorização")
+	}
+
+	return &proc, nil
+}
+
+// errDoctype recusa o DOCTYPE: a NF-e não usa DTD, e as entidades de um DTD são o vetor de ataques como o billion laughs
+var errDoctype = errors.New("falha ao parsear XML: DOCTYPE não é permitido na NF-e")
+
+// errXMLGrande indica um XML maior que TamanhoMaxXML
+var errXMLGrande = comCodigo(CodigoXMLIlegivel, fmt.Errorf("XML maior que %d MB", TamanhoMaxXML>>20))
+
+// conferirXML recusa o XML maior que TamanhoMaxXML ou com DOCTYPE antes do elemento raiz
+//
+// Só o prólogo é lido; os erros de sintaxe ficam para o parse.
+func conferirXML(xmlData []byte) error {
+	if len(xmlData) > TamanhoMaxXML {
+		return errXMLGrande
+	}
+	dec := xml.NewDecoder(bytes.NewReader(xmlData))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		switch tok.(type) {
+		case xml.Directive:
+			return errDoctype
+		case xml.StartElement:
+			return nil
+		}
+	}
+}
+
+// leitorLimitado falha com errXMLGrande ao chegar ao restante-ésimo byte (use TamanhoMaxXML+1)
+type leitorLimitado struct {
+	r        io.Reader
+	restante int64
+	excedeu  bool
+}
+
+func (l *leitorLimitado) Read(p []byte) (int, error) {
+	if l.restante <= 0 {
+		l.excedeu = true
+		return 0, errXMLGrande
+	}
+	if int64(len(p)) > l.restante {
+		p = p[:l.restante]
+	}
+	n, err := l.r.Read(p)
+	if l.restante -= int64(n); l.restante <= 0 {
+		l.excedeu = true
+		return 0, errXMLGrande
+	}
+	return n, err
+}
+
+// erro troca err por errXMLGrande quando a leitura parou no limite
+func (l *leitorLimitado) erro(err error) error {
+	if l.excedeu {
+		return errXMLGrande
+	}
+	return err
+}
+
+// codigoParse é o CodigoErro da falha de ParseNFe: CodigoXMLIlegivel para o XML grande demais, senão CodigoParse
+func codigoParse(err error) CodigoErro {
+	if errors.Is(err, errXMLGrande) {
+		return CodigoXMLIlegivel
+	}
+	return CodigoParse
+}
+
+// nota devolve a NFe do procNFe com o Protocolo preenchido
+func (p *ProcNFe) nota() *NFeEnvelope {
+	if p.ProtNFe != nil {
+		p.NFe.Protocolo = &p.ProtNFe.InfProt
+	}
+	return &p.NFe
+}
+
+// Autorizada indica se o protocolo é de uso autorizado (cStat 100 ou 150)
+func (p *ProcNFe) Autorizada() bool {
+	if p.ProtNFe == nil {
+		return false
+	}
+	switch p.ProtNFe.InfProt.CStat {
+	case "100", "150":
+		return true
+	}
+	return false
+}
+
 // ExtrairChave extrai a chave de acesso de 44 dígitos do XML
 //
 // Aceita tanto o ID completo (ex: "NFe35250732409620000175550010000037471011544648")
@@ -111,22 +316,46 @@ func ExtrairChave(xmlData []byte) (string, error) {
 
 // ExtrairChaveFromID extrai os 44 dígitos da chave do atributo Id
 //
-// Remove o prefixo "NFe" se presente.
+// Remove o prefixo "NFe" se presente. Retorna "" se o restante não for uma
+// chave válida (44 dígitos com DV correto); use ExtrairChaveDoID para saber o
+// motivo.
 //
 // Exemplo:
 //
 //	chave := nfe.ExtractChaveFromID("NFe35250732409620000175550010000037471011544648")
 //	fmt.Println(chave) // 35250732409620000175550010000037471011544648
 func ExtractChaveFromID(id string) string {
-	id = strings.TrimSpace(id)
-	if strings.HasPrefix(id, "NFe") && len(id) == 47 {
-		return id[3:] // Remove "NFe" e retorna os 44 dígitos
+	chave, err := ExtrairChaveDoID(id)
+	if err != nil {
+		return ""
 	}
-	// Se já tem 44 dígitos, retorna como está
-	if len(id) == 44 {
-		return id
+	return chave
+}
+
+// ExtrairChaveDoID extrai a chave do atributo Id e confere os dígitos e o DV
+//
+// Aceita o Id completo ("NFe" + 44 dígitos) ou só os 44 dígitos. O erro traz
+// o código da falha (CodigoChaveFormato ou CodigoChaveDV), para diagnóstico
+// de Ids gerados com defeito pelo emissor.
+//
+// Exemplo:
+//
+//	chave, err := nfe.ExtrairChaveDoID(nota.InfNFe.ID)
+//	if err != nil {
+//	    fmt.Println(nfe.CodigoDe(err), err) // NFE-CHAVE-DV Id "NFe3525...": dígito verificador inválido
+//	}
+func ExtrairChaveDoID(id string) (string, error) {
+	chave := strings.TrimSpace(id)
+	if strings.HasPrefix(chave, "NFe") && len(chave) == 47 {
+		chave = chave[3:] // Remove "NFe" e fica com os 44 dígitos
 	}
-	return ""
+	if len(chave) != 44 {
+		return "", comCodigo(CodigoChaveFormato, fmt.Errorf("Id %q não é \"NFe\" seguido dos 44 dígitos da chave de acesso", id))
+	}
+	if err := ValidarChaveAcesso(chave); err != nil {
+		return "", fmt.Errorf("Id %q: %w", id, err)
+	}
+	return chave, nil
 }
 
 // OnlyDigits remove todos os caracteres que não são dígitos
@@ -184,19 +413,19 @@ func ValidarChaveAcesso(chave string) error {
 
 	// Verificar tamanho
 	if len(chave) != 44 {
-		return fmt.Errorf("chave deve ter exatamente 44 dígitos (tem %d)", len(chave))
+		return comCodigo(CodigoChaveFormato, fmt.Errorf("chave deve ter exatamente 44 dígitos (tem %d)", len(chave)))
 	}
 
 	// Verificar se são apenas números
 	for _, c := range chave {
 		if c < '0' || c > '9' {
-			return fmt.Errorf("chave deve conter apenas números")
+			return comCodigo(CodigoChaveFormato, fmt.Errorf("chave deve conter apenas números"))
 		}
 	}
 
 	// Validar dígito verificador (último dígito)
 	if !validarDigitoVerificador(chave) {
-		return fmt.Errorf("dígito verificador inválido")
+		return comCodigo(CodigoChaveDV, fmt.Errorf("dígito verificador inválido"))
 	}
 
 	return nil
@@ -252,5 +481,25 @@ func convertNFeData(nfe *NFeEnvelope) *DadosNFe {
 			Nome:      nfe.InfNFe.Dest.XNome,
 		},
 		ValorTotal: nfe.InfNFe.Total.ICMSTot.VNF,
+		Itens:      convertItens(nfe.InfNFe.Det),
+	}
+}
+
+// convertItens converte os det da nota em ItemNFe
+func convertItens(det []Det) []ItemNFe {
+	if len(det) == 0 {
+		return nil
+	}
+	itens := make([]ItemNFe, 0, len(det))
+	for i, d := range det {
+		itens = append(itens, ItemNFe{
+			Item:      numeroItem(d, i),
+			Codigo:    d.Prod.CProd,
+			Descricao: d.Prod.XProd,
+			NCM:       d.Prod.NCM,
+			CFOP:      d.Prod.CFOP,
+			Valor:     d.Prod.VProd,
+		})
 	}
+	return itens
 }
\ No newline at end of file
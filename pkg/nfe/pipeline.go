@@ -0,0 +1,291 @@
+package nfe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// nomeTracer identifica os spans das fases de Validar (nfe.fase.xsd, nfe.fase.sefaz...)
+const nomeTracer = "github.com/fabyo/go-nfe-validator/pkg/nfe"
+
+// Nivel escolhe as fases executadas por Client.Validar
+type Nivel int
+
+const (
+	// NivelCompleto executa XSD, parse, regras de negócio e a consulta à SEFAZ (padrão)
+	NivelCompleto Nivel = iota
+
+	// NivelXSD executa apenas a validação XSD (CLI: -xsd)
+	NivelXSD
+
+	// NivelParse executa XSD, parse e regras, sem consultar a SEFAZ (CLI: -skip-sefaz; com Assinatura, -offline)
+	NivelParse
+)
+
+// String devolve o nome do nível: "completo", "xsd" ou "parse"
+func (n Nivel) String() string {
+	switch n {
+	case NivelXSD:
+		return "xsd"
+	case NivelParse:
+		return "parse"
+	default:
+		return "completo"
+	}
+}
+
+// SchemaXSD valida o XML contra um XSD já carregado (ver Options.Schema)
+type SchemaXSD interface {
+	Validate(xmlData []byte) error
+}
+
+// Options escolhe as fases e a configuração de uma chamada a Client.Validar
+type Options struct {
+	// Nivel define até onde a validação vai (zero = NivelCompleto)
+	Nivel Nivel
+
+	// XSD é o caminho do schema (ex: "schemas/v4/procNFe_v4.00.xsd"), compilado uma vez no CacheXSDPadrao
+	XSD string
+
+	// Schema é um XSD já carregado, reaproveitado entre validações; tem precedência sobre XSD
+	Schema SchemaXSD
+
+	// Regras substitui as regras de negócio de Config.Regras nesta chamada (nil = as do Client)
+	Regras *ConfigRegras
+
+	// Assinatura confere a assinatura digital (RegraAssinatura); divergências viram achados
+	//
+	// Sem consulta à SEFAZ (NivelParse), é o que garante que o XML não foi alterado.
+	Assinatura bool
+
+	// Ambiente da consulta à SEFAZ nesta chamada (zero = o de Config.Env; ver Ambiente)
+	Ambiente Ambiente
+
+	// Workers é o número de arquivos validados ao mesmo tempo por Client.ValidarLote e de goroutines por estágio de Client.ValidarStream (zero = runtime.NumCPU())
+	Workers int
+
+	// Etapas monta o pipeline etapa a etapa, na ordem informada (ver Etapa e Client.Etapas)
+	//
+	// Quando informado, substitui Nivel, XSD, Schema, Regras e Assinatura.
+	// EtapaRegras e EtapaSefaz exigem EtapaParse antes.
+	Etapas []Etapa
+}
+
+// Validar executa o pipeline de validação sobre o XML em memória, nas fases escolhidas em o
+//
+// É o mesmo pipeline de ValidarXMLBytes e do CLI: XSD → parse → regras de
+// negócio (+ assinatura) → SEFAZ. A primeira falha interrompe o fluxo e fica
+// em ValidationResult.Erro; achados das regras não interrompem. Sem a fase
+// SEFAZ, Status fica vazio e Autorizado false. Os hooks de Client.Use rodam
+// em volta de cada fase. Para reordenar, pular ou inserir fases, monte o
+// pipeline em Options.Etapas.
+//
+// Exemplo:
+//
+//	result, err := client.Validar(xmlData, nfe.Options{
+//	    Nivel:      nfe.NivelParse,
+//	    XSD:        "schemas/v4/procNFe_v4.00.xsd",
+//	    Assinatura: true,
+//	})
+func (c *Client) Validar(xmlData []byte, o Options) (*ValidationResult, error) {
+	return c.ValidarContext(context.Background(), xmlData, o)
+}
+
+// ValidarContext é o Validar com contexto (ver ValidarXMLBytesContext)
+func (c *Client) ValidarContext(ctx context.Context, xmlData []byte, o Options) (*ValidationResult, error) {
+	result, _, err := c.validar(ctx, xmlData, o)
+	return result, err
+}
+
+// validar executa as fases, informa o Observador e devolve também a classificação (ver Resultado*)
+func (c *Client) validar(ctx context.Context, xmlData []byte, o Options) (*ValidationResult, string, error) {
+	inicio := time.Now()
+	result, resultado, err := c.executarFases(ctx, xmlData, o)
+	if err != nil {
+		return nil, "", err
+	}
+	c.observar(result, resultado, time.Since(inicio))
+	return result, resultado, nil
+}
+
+// observar informa o Observador (se houver) sobre uma validação concluída
+func (c *Client) observar(result *ValidationResult, resultado string, duracao time.Duration) {
+	if c.observador == nil {
+		return
+	}
+	v := Validacao{Resultado: resultado, Duracao: duracao}
+	if resultado == ResultadoXSDInvalido {
+		v.ErroXSD = result.Err()
+	}
+	c.observador.ObservarValidacao(v)
+}
+
+// executarFases executa as etapas (Options.Etapas ou as do Nivel) e classifica o resultado (ver Resultado*)
+//
+// Cada etapa é um span filho de ctx e fica registrada em result.Fases. err só
+// é preenchido quando ctx termina antes do resultado ou as etapas de
+// Options.Etapas não formam um pipeline válido.
+func (c *Client) executarFases(ctx context.Context, xmlData []byte, o Options) (*ValidationResult, string, error) {
+	etapas, err := c.etapasDe(o)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := o.Ambiente.validar(); err != nil {
+		return nil, "", err
+	}
+	ctx = ComAmbiente(ctx, o.Ambiente)
+
+	v := c.novoEstado(xmlData)
+	resultado, err := c.executarEtapas(ctx, v, etapas)
+	if err != nil {
+		return nil, "", err
+	}
+	if resultado == "" {
+		resultado = v.classificar()
+	}
+	return v.Resultado, resultado, nil
+}
+
+// etapasDe devolve as etapas da validação: as de Options.Etapas (conferidas) ou as do Nivel
+func (c *Client) etapasDe(o Options) ([]Etapa, error) {
+	if len(o.Etapas) == 0 {
+		return c.Etapas(o), nil
+	}
+	if err := conferirEtapas(o.Etapas); err != nil {
+		return nil, err
+	}
+	return o.Etapas, nil
+}
+
+// novoEstado prepara o EstadoValidacao de um XML
+func (c *Client) novoEstado(xmlData []byte) *EstadoValidacao {
+	return &EstadoValidacao{XML: xmlData, Resultado: &ValidationResult{}, client: c}
+}
+
+// executarEtapas executa as etapas em sequência sobre v
+//
+// resultado só é preenchido quando a validação termina antes da última etapa
+// (falha ou veto): é a classificação final. err só é preenchido quando ctx
+// termina antes do resultado.
+func (c *Client) executarEtapas(ctx context.Context, v *EstadoValidacao, etapas []Etapa) (resultado string, err error) {
+	result := v.Resultado
+	for _, e := range etapas {
+		if e.checaCtx && ctx.Err() != nil {
+			return "", interrompida(ctx)
+		}
+		ctxFase, fase, ok := c.iniciarFase(ctx, e.fase, result)
+		if !ok {
+			return ResultadoVetada, nil
+		}
+		situacao, err := e.executar(ctxFase, v)
+		if err != nil {
+			if ctx.Err() != nil {
+				fase.span.End()
+				return "", interrompida(ctx)
+			}
+			result.Erro = novoErro(e.code, e.fase, e.contexto, err)
+			fase.encerrar(result, result.Erro)
+			return e.code, nil
+		}
+		fase.registrar(result, situacao)
+	}
+	return "", nil
+}
+
+// classificar devolve a classificação de uma validação que executou todas as etapas
+func (v *EstadoValidacao) classificar() string {
+	switch {
+	case v.consultou && !v.Resultado.Autorizado:
+		return ResultadoRejeitada
+	case TemErros(v.Resultado.Achados):
+		return ResultadoReprovada
+	}
+	return ResultadoAprovada
+}
+
+// validarSchema valida com o Schema pré-carregado, se houver, ou carrega o XSD de o.XSD
+func validarSchema(ctx context.Context, xmlData []byte, o Options) error {
+	if o.Schema == nil {
+		return ValidateWithXSDContext(ctx, xmlData, o.XSD)
+	}
+	if err := ctx.Err(); err != nil {
+		return comCodigo(CodigoInterrompida, fmt.Errorf("validação XSD interrompida: %w", err))
+	}
+	return o.Schema.Validate(xmlData)
+}
+
+// severidadeAssinatura é a severidade dos achados de assinatura (erro, salvo sobrescrita em Severidades)
+func severidadeAssinatura(regras ConfigRegras) Severidade {
+	if s, ok := regras.Severidades[RegraAssinatura]; ok {
+		return s
+	}
+	return SeveridadeErro
+}
+
+// faseEmCurso é uma fase iniciada por iniciarFase: o span, os hooks e o início da medição
+type faseEmCurso struct {
+	nome   string
+	inicio time.Time
+	ctx    context.Context
+	span   trace.Span
+	hooks  []Hook
+}
+
+// iniciarFase abre o span nfe.fase.<fase>, roda os Hook.Antes e começa a medir a duração
+//
+// ok = false quando um hook vetou a fase: r.Erro já está preenchido e a fase
+// registrada como falha.
+func (c *Client) iniciarFase(ctx context.Context, fase string, r *ValidationResult) (_ context.Context, _ *faseEmCurso, ok bool) {
+	ctx, span := otel.Tracer(nomeTracer).Start(ctx, "nfe.fase."+fase)
+	f := &faseEmCurso{nome: fase, inicio: time.Now(), ctx: ctx, span: span, hooks: c.hooks}
+
+	for _, h := range c.hooks {
+		if h.Antes == nil {
+			continue
+		}
+		if err := h.Antes(ctx, fase, r); err != nil {
+			r.Erro = novoErro(ResultadoVetada, fase, "fase vetada", err)
+			f.encerrar(r, r.Erro)
+			return ctx, f, false
+		}
+	}
+	f.inicio = time.Now() // A duração não inclui os hooks
+	return ctx, f, true
+}
+
+// encerrar registra a fase em r.Fases (falha = erro que interrompeu a validação, se houver) e fecha o span
+func (f *faseEmCurso) encerrar(r *ValidationResult, falha *ValidationError) {
+	situacao := SituacaoOK
+	if falha != nil {
+		situacao = SituacaoFalha
+		f.span.SetStatus(codes.Error, falha.Mensagem)
+	}
+	f.registrar(r, situacao)
+}
+
+// encerrarSefaz registra a consulta respondida: falha se a nota não está autorizada
+func (f *faseEmCurso) encerrarSefaz(r *ValidationResult) {
+	situacao := SituacaoOK
+	if !r.Autorizado {
+		situacao = SituacaoFalha
+	}
+	f.registrar(r, situacao)
+}
+
+// registrar acrescenta a execução da fase a r.Fases, roda os Hook.Depois e fecha o span
+func (f *faseEmCurso) registrar(r *ValidationResult, situacao string) {
+	execucao := ExecucaoFase{Fase: f.nome, Situacao: situacao, Duracao: time.Since(f.inicio)}
+	r.Fases = append(r.Fases, execucao)
+
+	for i := len(f.hooks) - 1; i >= 0; i-- {
+		if h := f.hooks[i]; h.Depois != nil {
+			h.Depois(f.ctx, execucao, r)
+		}
+	}
+	f.span.End()
+}
@@ -0,0 +1,105 @@
+package nfe
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// QRCodeNFCe são os dados do QR Code impresso no DANFE NFC-e (cupom)
+//
+// O conteúdo é a URL de consulta da SEFAZ com os parâmetros da nota:
+// "https://...?p=chave|versão|tpAmb|..." a partir da versão 2 e
+// "https://...?chNFe=chave&nVersao=100&tpAmb=..." na versão 1.
+type QRCodeNFCe struct {
+	// Conteudo é o texto lido do QR Code
+	Conteudo string `json:"conteudo"`
+
+	// URL é o endereço de consulta, sem os parâmetros
+	URL string `json:"url,omitempty"`
+
+	// Chave é a chave de acesso de 44 dígitos
+	Chave string `json:"chave"`
+
+	// Versao é a versão do QR Code ("1", "2", "3"...)
+	Versao string `json:"versao,omitempty"`
+
+	// TpAmb é o ambiente informado no QR Code (1 = produção, 2 = homologação)
+	TpAmb string `json:"tp_amb,omitempty"`
+}
+
+// LerQRCode interpreta o conteúdo do QR Code da NFC-e e valida a chave de acesso
+//
+// Aceita as URLs das versões 1, 2 e 3 e também só os 44 dígitos da chave
+// (com ou sem espaços). Retorna erro se não encontrar a chave ou se ela for
+// inválida (CodigoChaveFormato ou CodigoChaveDV).
+//
+// Exemplo:
+//
+//	qr, err := nfe.LerQRCode(conteudo) // texto lido pelo leitor de QR Code do app
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	result, err := client.ValidarChave(qr.Chave, qr.Ambiente())
+func LerQRCode(conteudo string) (*QRCodeNFCe, error) {
+	conteudo = strings.TrimSpace(conteudo)
+	qr := &QRCodeNFCe{Conteudo: conteudo}
+
+	if endereco, consulta, ok := strings.Cut(conteudo, "?"); ok {
+		qr.URL = endereco
+		if p := parametrosQRCode(conteudo); len(p) > 0 {
+			qr.Chave = p[0]
+			if len(p) > 1 {
+				qr.Versao = p[1]
+			}
+			if len(p) > 2 {
+				qr.TpAmb = p[2]
+			}
+		} else if valores, err := url.ParseQuery(consulta); err == nil && valores.Get("chNFe") != "" {
+			qr.Chave = valores.Get("chNFe")
+			qr.Versao = "1"
+			qr.TpAmb = valores.Get("tpAmb")
+		}
+	} else {
+		qr.Chave = strings.Join(strings.Fields(conteudo), "")
+	}
+
+	if qr.Chave == "" {
+		return nil, comCodigo(CodigoChaveFormato, fmt.Errorf("QR Code sem chave de acesso (parâmetro p ou chNFe): %q", conteudo))
+	}
+	if err := ValidarChaveAcesso(qr.Chave); err != nil {
+		return nil, fmt.Errorf("chave de acesso do QR Code inválida: %w", err)
+	}
+	return qr, nil
+}
+
+// Ambiente é o Ambiente do tpAmb do QR Code (AmbientePadrao se não informado)
+func (q *QRCodeNFCe) Ambiente() Ambiente {
+	switch q.TpAmb {
+	case "1":
+		return Producao
+	case "2":
+		return Homologacao
+	}
+	return AmbientePadrao
+}
+
+// parametrosQRCode separa o parâmetro p do QR Code (chave|versão|tpAmb|...); nil se não houver
+func parametrosQRCode(qrCode string) []string {
+	qrCode = strings.TrimSpace(qrCode)
+	_, consulta, ok := strings.Cut(qrCode, "?")
+	if !ok {
+		return nil
+	}
+	for _, parametro := range strings.Split(consulta, "&") {
+		valor, ok := strings.CutPrefix(parametro, "p=")
+		if !ok || valor == "" {
+			continue
+		}
+		if v, err := url.QueryUnescape(valor); err == nil {
+			valor = v
+		}
+		return strings.Split(valor, "|")
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package nfe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fabyo/go-nfe-validator/internal/sefaz"
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+)
+
+// Situações do lote consultado pelo recibo (RetornoRecibo.Status.Codigo)
+const (
+	LoteProcessado      = sefaz.CStatLoteProcessado      // 104: os protocolos das notas estão em Protocolos
+	LoteEmProcessamento = sefaz.CStatLoteEmProcessamento // 105: consultar de novo em alguns segundos
+	LoteNaoLocalizado   = sefaz.CStatLoteNaoLocalizado   // 106: recibo inexistente ou de outro ambiente
+)
+
+// tamanhoRecibo é a quantidade de dígitos do número do recibo (nRec)
+const tamanhoRecibo = 15
+
+// RetornoRecibo é o resultado do processamento de um lote, consultado pelo número do recibo
+type RetornoRecibo struct {
+	// Recibo é o número do recibo (nRec) devolvido pelo NFeAutorizacao4
+	Recibo string `json:"recibo"`
+
+	// Status é o cStat/xMotivo do lote (ver LoteProcessado, LoteEmProcessamento e LoteNaoLocalizado)
+	Status StatusSefaz `json:"status"`
+
+	// DataRecebimento é a dhRecbto do lote
+	DataRecebimento string `json:"dh_recebimento,omitempty"`
+
+	// Protocolos traz um protocolo por nota do lote, na ordem devolvida pela SEFAZ (só com LoteProcessado)
+	Protocolos []ProtocoloNota `json:"protocolos,omitempty"`
+}
+
+// ProtocoloNota é o protocolo de uma nota do lote (protNFe)
+type ProtocoloNota struct {
+	ChaveAcesso     string      `json:"chave_acesso"`
+	Autorizado      bool        `json:"autorizado"` // cStat 100 ou 150
+	Status          StatusSefaz `json:"status"`
+	Protocolo       string      `json:"protocolo,omitempty"` // nProt
+	DataRecebimento string      `json:"dh_recebimento,omitempty"`
+
+	// XML é o protNFe completo, para montar o nfeProc com a NFe enviada
+	XML string `json:"-"`
+}
+
+// Processado indica se o lote já foi processado (cStat 104) e os protocolos estão disponíveis
+func (r *RetornoRecibo) Processado() bool {
+	return r.Status.Codigo == LoteProcessado
+}
+
+// EmProcessamento indica se a SEFAZ ainda processa o lote (cStat 105)
+func (r *RetornoRecibo) EmProcessamento() bool {
+	return r.Status.Codigo == LoteEmProcessamento
+}
+
+// ConsultarRecibo consulta o resultado do processamento de um lote pelo número do recibo (NFeRetAutorizacao4)
+//
+// Para o emissor que enviou o lote ao NFeAutorizacao4 em modo assíncrono e
+// perdeu a resposta (ex: timeout depois de receber o recibo): os
+// protocolos de cada nota são recuperados sem reenviar o lote. Consulta o
+// autorizador da NF-e (modelo 55) da UF de Config.UF, no ambiente de
+// Config.Env ou no informado.
+//
+// Exemplo:
+//
+//	ret, err := client.ConsultarRecibo(ctx, "351000012345678")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if ret.EmProcessamento() {
+//	    // consultar de novo em alguns segundos
+//	}
+//	for _, p := range ret.Protocolos {
+//	    fmt.Println(p.ChaveAcesso, p.Status.Codigo, p.Protocolo)
+//	}
+func (c *Client) ConsultarRecibo(ctx context.Context, recibo string, ambiente ...Ambiente) (*RetornoRecibo, error) {
+	nRec := validation.OnlyDigits(recibo)
+	if len(nRec) != tamanhoRecibo {
+		return nil, fmt.Errorf("recibo inválido: deve ter %d dígitos (tem %d)", tamanhoRecibo, len(nRec))
+	}
+	if c.sefaz == nil {
+		return nil, fmt.Errorf("cliente sem certificado (consulta à SEFAZ via WithConsulter)")
+	}
+	if len(ambiente) > 0 {
+		if err := ambiente[0].validar(); err != nil {
+			return nil, err
+		}
+		ctx = ComAmbiente(ctx, ambiente[0])
+	}
+
+	ret, err := c.sefaz.ConsultaRecibo(ctx, nRec, "", c.ambiente(ctx) == Producao)
+	if err != nil {
+		return nil, fmt.Errorf("falha na consulta do recibo %s: %w", nRec, err)
+	}
+
+	retorno := &RetornoRecibo{
+		Recibo:          ret.Recibo,
+		Status:          StatusSefaz{Codigo: ret.Codigo, Mensagem: ret.Mensagem},
+		DataRecebimento: ret.DataRecebimento,
+	}
+	for _, p := range ret.Protocolos {
+		retorno.Protocolos = append(retorno.Protocolos, ProtocoloNota{
+			ChaveAcesso:     p.Chave,
+			Autorizado:      p.Autorizado,
+			Status:          StatusSefaz{Codigo: p.Codigo, Mensagem: p.Mensagem},
+			Protocolo:       p.Protocolo,
+			DataRecebimento: p.DataRecebimento,
+			XML:             p.XML,
+		})
+	}
+	return retorno, nil
+}
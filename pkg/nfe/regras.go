@@ -0,0 +1,274 @@
+package nfe
+
+import (
+	"time"
+)
+
+// ======================================================================
+// MOTOR DE REGRAS DE NEGÓCIO
+// ======================================================================
+
+// Severidade indica a gravidade de um achado de regra de negócio
+type Severidade string
+
+const (
+	// SeveridadeErro indica problema que leva (ou levaria) à rejeição da nota
+	SeveridadeErro Severidade = "erro"
+
+	// SeveridadeAviso indica problema que merece atenção, mas não rejeita a nota
+	SeveridadeAviso Severidade = "aviso"
+
+	// SeveridadeInfo indica observação informativa
+	SeveridadeInfo Severidade = "info"
+)
+
+// Regra é uma regra de negócio aplicada sobre a nota já parseada
+type Regra struct {
+	// ID identifica a regra (usado para habilitar/desabilitar via ConfigRegras)
+	ID string
+
+	// Descricao explica o que a regra confere
+	Descricao string
+
+	// Severidade padrão dos achados da regra
+	Severidade Severidade
+
+	// Modelos restringe a regra a modelos específicos (ex: "55", "65"); vazio = todos
+	Modelos []string
+
+	// Verificar executa a regra e retorna as inconsistências encontradas
+	Verificar func(nfe *NFeEnvelope, cfg ConfigRegras) []Inconsistencia
+}
+
+// Achado é uma inconsistência encontrada por uma regra, com sua severidade
+type Achado struct {
+	// Regra é o ID da regra que gerou o achado
+	Regra string `json:"regra"`
+
+	// Severidade do achado (erro, aviso ou info)
+	Severidade Severidade `json:"severidade"`
+
+	Inconsistencia
+}
+
+// ConfigRegras configura a execução do motor de regras
+type ConfigRegras struct {
+	// Desabilitadas lista os IDs de regras que não devem ser executadas
+	Desabilitadas []string
+
+	// Severidades sobrescreve a severidade padrão por ID de regra
+	Severidades map[string]Severidade
+
+	// Agora é o horário de referência das regras temporais (zero = time.Now())
+	Agora time.Time
+
+	// NCMRevogadosDesde habilita o aviso de NCM revogado após esta data (zero = desligado)
+	NCMRevogadosDesde time.Time
+
+	// LimiteValorNFCe é o valor máximo aceito para NFC-e (zero = LimiteValorNFCePadrao)
+	LimiteValorNFCe float64
+
+	// PrazoContingenciaNFCe é o prazo para transmitir a NFC-e emitida off-line (zero = PrazoContingenciaNFCePadrao)
+	PrazoContingenciaNFCe time.Duration
+}
+
+// IDs das regras padrão
+const (
+	RegraCalculoTributos = "calculo-tributos"
+	RegraDatasEmissao    = "datas-emissao"
+	RegraNCM             = "ncm"
+	RegraNCMRevogado     = "ncm-revogado"
+	RegraCFOP            = "cfop"
+	RegraModeloNFe       = "modelo-nfe"
+	RegraModeloNFCe      = "modelo-nfce"
+	RegraUFEmitente      = "chave-uf-emitente"
+	RegraDuplicatas      = "duplicatas-vnf"
+	RegraContingencia    = "contingencia-nfce"
+)
+
+// RegrasPadrao são as regras executadas por AvaliarRegras
+var RegrasPadrao = []Regra{
+	{
+		ID:         RegraCalculoTributos,
+		Descricao:  "vICMS/vIPI/vPIS/vCOFINS ≈ vBC × alíquota em cada item",
+		Severidade: SeveridadeErro,
+		Verificar: func(nfe *NFeEnvelope, _ ConfigRegras) []Inconsistencia {
+			return VerificarTributos(nfe)
+		},
+	},
+	{
+		ID:         RegraDatasEmissao,
+		Descricao:  "dhEmi não futura, dhSaiEnt >= dhEmi e AAMM da chave igual à dhEmi",
+		Severidade: SeveridadeErro,
+		Verificar: func(nfe *NFeEnvelope, cfg ConfigRegras) []Inconsistencia {
+			return VerificarDatas(nfe, cfg.agora())
+		},
+	},
+	{
+		ID:         RegraNCM,
+		Descricao:  "NCM de cada item com 8 dígitos e capítulo existente (código e vigência com a tabela NCM gerada)",
+		Severidade: SeveridadeErro,
+		Verificar: func(nfe *NFeEnvelope, _ ConfigRegras) []Inconsistencia {
+			return VerificarNCM(nfe)
+		},
+	},
+	{
+		ID:         RegraNCMRevogado,
+		Descricao:  "NCM revogado após ConfigRegras.NCMRevogadosDesde",
+		Severidade: SeveridadeAviso,
+		Verificar: func(nfe *NFeEnvelope, cfg ConfigRegras) []Inconsistencia {
+			if cfg.NCMRevogadosDesde.IsZero() {
+				return nil
+			}
+			return VerificarNCMRevogados(nfe, cfg.NCMRevogadosDesde)
+		},
+	},
+	{
+		ID:         RegraCFOP,
+		Descricao:  "CFOP compatível com tpNF e idDest",
+		Severidade: SeveridadeErro,
+		Verificar: func(nfe *NFeEnvelope, _ ConfigRegras) []Inconsistencia {
+			return VerificarCFOP(nfe)
+		},
+	},
+	{
+		ID:         RegraUFEmitente,
+		Descricao:  "UF da chave de acesso igual à UF do emitente (enderEmit/UF e ide/cUF)",
+		Severidade: SeveridadeErro,
+		Verificar: func(nfe *NFeEnvelope, _ ConfigRegras) []Inconsistencia {
+			return VerificarUFEmitente(nfe)
+		},
+	},
+	{
+		ID:         RegraDuplicatas,
+		Descricao:  "Soma das duplicatas igual ao vNF menos pagamentos à vista",
+		Severidade: SeveridadeErro,
+		Verificar: func(nfe *NFeEnvelope, _ ConfigRegras) []Inconsistencia {
+			return VerificarDuplicatas(nfe)
+		},
+	},
+	{
+		ID:         RegraModeloNFe,
+		Descricao:  "NF-e: destinatário obrigatório e sem infNFeSupl",
+		Severidade: SeveridadeErro,
+		Modelos:    []string{ModeloNFe},
+		Verificar: func(nfe *NFeEnvelope, _ ConfigRegras) []Inconsistencia {
+			return VerificarModeloNFe(nfe)
+		},
+	},
+	{
+		ID:         RegraModeloNFCe,
+		Descricao:  "NFC-e: pag e infNFeSupl obrigatórios, operação interna de saída e limite de valor",
+		Severidade: SeveridadeErro,
+		Modelos:    []string{ModeloNFCe},
+		Verificar: func(nfe *NFeEnvelope, cfg ConfigRegras) []Inconsistencia {
+			return VerificarModeloNFCe(nfe, cfg.limiteValorNFCe())
+		},
+	},
+	{
+		ID:         RegraContingencia,
+		Descricao:  "NFC-e off-line (tpEmis 9): dhCont, xJust, QR Code off-line e prazo de transmissão",
+		Severidade: SeveridadeErro,
+		Modelos:    []string{ModeloNFCe},
+		Verificar: func(nfe *NFeEnvelope, cfg ConfigRegras) []Inconsistencia {
+			return VerificarContingenciaNFCe(nfe, cfg.agora(), cfg.prazoContingenciaNFCe())
+		},
+	},
+}
+
+// AvaliarRegras executa as regras padrão habilitadas e retorna os achados
+//
+// O conjunto de regras é escolhido automaticamente pelo modelo da nota (ide/mod):
+// regras com Modelos preenchido só rodam para os modelos listados.
+// Os achados são retornados na ordem das regras em RegrasPadrao.
+//
+// Exemplo:
+//
+//	nota, _ := nfe.ParseNFe(xmlData)
+//	achados := nfe.AvaliarRegras(nota, nfe.ConfigRegras{
+//	    Desabilitadas: []string{nfe.RegraNCM},
+//	})
+//	for _, a := range achados {
+//	    fmt.Printf("[%s] %s: %s\n", a.Severidade, a.Regra, a.Mensagem)
+//	}
+func AvaliarRegras(nfe *NFeEnvelope, cfg ConfigRegras) []Achado {
+	var achados []Achado
+
+	for _, regra := range RegrasPadrao {
+		if !cfg.Habilitada(regra.ID) || !regra.aplicaAoModelo(nfe.InfNFe.Ide.Modelo) {
+			continue
+		}
+
+		severidade := regra.Severidade
+		if s, ok := cfg.Severidades[regra.ID]; ok {
+			severidade = s
+		}
+
+		for _, inc := range regra.Verificar(nfe, cfg) {
+			achados = append(achados, Achado{
+				Regra:          regra.ID,
+				Severidade:     severidade,
+				Inconsistencia: inc,
+			})
+		}
+	}
+
+	return achados
+}
+
+// Habilitada indica se a regra com o ID informado deve ser executada
+func (c ConfigRegras) Habilitada(id string) bool {
+	for _, d := range c.Desabilitadas {
+		if d == id {
+			return false
+		}
+	}
+	return true
+}
+
+// aplicaAoModelo indica se a regra vale para o modelo informado
+func (r Regra) aplicaAoModelo(modelo string) bool {
+	if len(r.Modelos) == 0 {
+		return true
+	}
+	for _, m := range r.Modelos {
+		if m == modelo {
+			return true
+		}
+	}
+	return false
+}
+
+// limiteValorNFCe retorna o limite configurado ou LimiteValorNFCePadrao
+func (c ConfigRegras) limiteValorNFCe() float64 {
+	if c.LimiteValorNFCe <= 0 {
+		return LimiteValorNFCePadrao
+	}
+	return c.LimiteValorNFCe
+}
+
+// prazoContingenciaNFCe retorna o prazo configurado ou PrazoContingenciaNFCePadrao
+func (c ConfigRegras) prazoContingenciaNFCe() time.Duration {
+	if c.PrazoContingenciaNFCe <= 0 {
+		return PrazoContingenciaNFCePadrao
+	}
+	return c.PrazoContingenciaNFCe
+}
+
+// agora retorna o horário de referência configurado ou time.Now()
+func (c ConfigRegras) agora() time.Time {
+	if c.Agora.IsZero() {
+		return time.Now()
+	}
+	return c.Agora
+}
+
+// TemErros retorna true se algum achado tem severidade de erro
+func TemErros(achados []Achado) bool {
+	for _, a := range achados {
+		if a.Severidade == SeveridadeErro {
+			return true
+		}
+	}
+	return false
+}
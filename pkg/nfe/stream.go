@@ -0,0 +1,202 @@
+package nfe
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ValidarStream valida um fluxo contínuo de XML em estágios paralelos e devolve os resultados na ordem de entrada
+//
+// As etapas de o (as do Nivel ou Options.Etapas) são divididas em estágios —
+// o que vem antes do parse (XSD), do parse à consulta (parse, regras,
+// assinatura) e da consulta à SEFAZ em diante —, cada um com o.Workers
+// goroutines (zero = runtime.NumCPU()): enquanto uma nota espera a SEFAZ, as
+// seguintes já passam pelo XSD. O número de notas em andamento é limitado;
+// quando a saída não é consumida, ValidarStream para de ler a entrada
+// (backpressure).
+//
+// Cada XML produz um ValidationResult, como o de Validar. A saída fecha
+// depois do último resultado, quando a entrada fecha, ou quando ctx termina:
+// as notas em andamento são descartadas. Consuma a saída até ela fechar (ou
+// encerre ctx). Falhas fora das fases (ctx encerrado durante a validação,
+// Options.Etapas inválidas) ficam em ValidationResult.Erro, com Code
+// ResultadoErro.
+//
+// Exemplo:
+//
+//	entrada := make(chan []byte)
+//	go func() {
+//	    defer close(entrada)
+//	    for msg := range fila {
+//	        entrada <- msg.Body
+//	    }
+//	}()
+//	for result := range client.ValidarStream(ctx, entrada, nfe.Options{XSD: xsd, Workers: 8}) {
+//	    publicar(result) // na ordem em que os XML chegaram
+//	}
+func (c *Client) ValidarStream(ctx context.Context, entrada <-chan []byte, o Options) <-chan ValidationResult {
+	workers := o.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	etapas, errEtapas := c.etapasDe(o)
+	estagios := dividirEtapas(etapas)
+	if errEtapas == nil {
+		errEtapas = o.Ambiente.validar()
+	}
+	ctx = ComAmbiente(ctx, o.Ambiente)
+
+	// Notas em andamento: todas as goroutines ocupadas e uma na fila de cada estágio
+	ordem := make(chan *itemStream, len(estagios)*workers*2)
+	saida := make(chan ValidationResult)
+
+	// Leitura da entrada: cada XML entra na fila de ordem e no primeiro estágio
+	primeiro := make(chan *itemStream)
+	go func() {
+		defer close(ordem)
+		defer close(primeiro)
+		for {
+			var xmlData []byte
+			select {
+			case x, ok := <-entrada:
+				if !ok {
+					return
+				}
+				xmlData = x
+			case <-ctx.Done():
+				return
+			}
+
+			it := &itemStream{v: c.novoEstado(xmlData), inicio: time.Now(), feito: make(chan struct{}), err: errEtapas}
+			select {
+			case ordem <- it:
+			case <-ctx.Done():
+				return
+			}
+			if it.err != nil || !enviarItem(ctx, primeiro, it) {
+				it.concluir()
+			}
+		}
+	}()
+
+	var fila <-chan *itemStream = primeiro
+	for _, estagio := range estagios {
+		fila = c.iniciarEstagio(ctx, fila, estagio, workers)
+	}
+	go func() {
+		// Ao fim do último estágio, as notas que chegam já terminaram todas as etapas
+		for it := range fila {
+			it.concluir()
+		}
+	}()
+
+	// Entrega na ordem de entrada
+	go func() {
+		defer close(saida)
+		for it := range ordem {
+			<-it.feito
+			if ctx.Err() != nil {
+				continue // Descarta o que estava em andamento, mas deixa os estágios terminarem
+			}
+			select {
+			case saida <- it.resultadoFinal(c):
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return saida
+}
+
+// itemStream é um XML em trânsito pelos estágios de ValidarStream
+type itemStream struct {
+	v         *EstadoValidacao
+	inicio    time.Time
+	resultado string // Classificação, quando a validação terminou antes da última etapa
+	err       error  // Falha fora das fases: etapas inválidas ou ctx encerrado
+	feito     chan struct{}
+}
+
+// terminou indica se a nota não deve passar pelos estágios seguintes
+func (it *itemStream) terminou() bool {
+	return it.resultado != "" || it.err != nil
+}
+
+// concluir libera a nota para a entrega
+func (it *itemStream) concluir() {
+	close(it.feito)
+}
+
+// resultadoFinal monta o ValidationResult entregue e informa o Observador
+func (it *itemStream) resultadoFinal(c *Client) ValidationResult {
+	result := it.v.Resultado
+	if it.err != nil {
+		result.Erro = &ValidationError{Code: ResultadoErro, CodigoErro: CodigoDe(it.err), Mensagem: it.err.Error(), causa: it.err}
+		return *result
+	}
+
+	resultado := it.resultado
+	if resultado == "" {
+		resultado = it.v.classificar()
+	}
+	c.observar(result, resultado, time.Since(it.inicio))
+	return *result
+}
+
+// iniciarEstagio executa as etapas sobre as notas de entrada com workers goroutines e devolve a fila do estágio seguinte
+//
+// As notas que já terminaram (falha, veto ou erro) passam adiante sem executar nada.
+func (c *Client) iniciarEstagio(ctx context.Context, entrada <-chan *itemStream, etapas []Etapa, workers int) <-chan *itemStream {
+	proxima := make(chan *itemStream, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for it := range entrada {
+				if !it.terminou() {
+					it.resultado, it.err = c.executarEtapas(ctx, it.v, etapas)
+				}
+				if !enviarItem(ctx, proxima, it) {
+					if it.err == nil {
+						it.err = interrompida(ctx)
+					}
+					it.concluir()
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(proxima)
+	}()
+	return proxima
+}
+
+// enviarItem envia a nota ao próximo estágio; false quando ctx termina antes
+func enviarItem(ctx context.Context, fila chan<- *itemStream, it *itemStream) bool {
+	select {
+	case fila <- it:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// dividirEtapas separa as etapas em estágios de ValidarStream: antes de EtapaParse, até EtapaSefaz e dela em diante
+func dividirEtapas(etapas []Etapa) [][]Etapa {
+	var estagios [][]Etapa
+	inicio := 0
+	for i, e := range etapas {
+		if i > inicio && (e.fase == FaseParse || e.fase == FaseSefaz) {
+			estagios = append(estagios, etapas[inicio:i])
+			inicio = i
+		}
+	}
+	if inicio < len(etapas) {
+		estagios = append(estagios, etapas[inicio:])
+	}
+	return estagios
+}
@@ -0,0 +1,112 @@
+package nfe
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ToleranciaCalculoTributo é a diferença máxima aceita (em R$) entre o valor
+// do tributo informado e o calculado a partir da base e da alíquota
+const ToleranciaCalculoTributo = 0.01
+
+// VerificarTributos confere o cálculo dos tributos de cada item da nota
+//
+// Para cada item verifica se:
+//   - vICMS ≈ vBC × pICMS
+//   - vIPI ≈ vBC × pIPI
+//   - vPIS ≈ vBC × pPIS
+//   - vCOFINS ≈ vBC × pCOFINS
+//
+// dentro da tolerância de arredondamento (ToleranciaCalculoTributo).
+// Grupos sem base, alíquota ou valor informados (ex: ICMS40, IPINT, PISQtde) são ignorados.
+//
+// Retorna uma Inconsistencia por item/grupo que não fecha (lista vazia se tudo OK).
+//
+// Exemplo:
+//
+//	nota, _ := nfe.ParseNFe(xmlData)
+//	for _, inc := range nfe.VerificarTributos(nota) {
+//	    fmt.Printf("Item %d (%s): %s\n", inc.Item, inc.Grupo, inc.Mensagem)
+//	}
+func VerificarTributos(nfe *NFeEnvelope) []Inconsistencia {
+	var inconsistencias []Inconsistencia
+
+	for i, det := range nfe.InfNFe.Det {
+		item := numeroItem(det, i)
+
+		for _, g := range det.Imposto.ICMS.Grupos {
+			if inc, ok := conferirCalculo(item, g.XMLName.Local, "vICMS", g.VBC, g.PICMS, g.VICMS); !ok {
+				inconsistencias = append(inconsistencias, inc)
+			}
+		}
+		for _, g := range det.Imposto.IPI.Grupos {
+			if inc, ok := conferirCalculo(item, g.XMLName.Local, "vIPI", g.VBC, g.PIPI, g.VIPI); !ok {
+				inconsistencias = append(inconsistencias, inc)
+			}
+		}
+		for _, g := range det.Imposto.PIS.Grupos {
+			if inc, ok := conferirCalculo(item, g.XMLName.Local, "vPIS", g.VBC, g.PPIS, g.VPIS); !ok {
+				inconsistencias = append(inconsistencias, inc)
+			}
+		}
+		for _, g := range det.Imposto.COFINS.Grupos {
+			if inc, ok := conferirCalculo(item, g.XMLName.Local, "vCOFINS", g.VBC, g.PCOFINS, g.VCOFINS); !ok {
+				inconsistencias = append(inconsistencias, inc)
+			}
+		}
+	}
+
+	return inconsistencias
+}
+
+// conferirCalculo compara valor com base × alíquota / 100
+//
+// Retorna ok=true quando o cálculo fecha ou quando algum dos campos não foi informado.
+func conferirCalculo(item int, grupo, campo, base, aliquota, valor string) (Inconsistencia, bool) {
+	vBC, okBase := parseValor(base)
+	pAliq, okAliq := parseValor(aliquota)
+	vTrib, okValor := parseValor(valor)
+	if !okBase || !okAliq || !okValor {
+		return Inconsistencia{}, true
+	}
+
+	esperado := arredondar(vBC * pAliq / 100)
+	if math.Abs(vTrib-esperado) <= ToleranciaCalculoTributo+1e-9 {
+		return Inconsistencia{}, true
+	}
+
+	return Inconsistencia{
+		Item:  item,
+		Grupo: grupo,
+		Mensagem: fmt.Sprintf("%s informado %.2f difere do calculado %.2f (vBC %.2f × %.4f%%)",
+			campo, vTrib, esperado, vBC, pAliq),
+	}, false
+}
+
+// numeroItem retorna o nItem do item ou a posição (1-based) se o atributo estiver ausente
+func numeroItem(det Det, indice int) int {
+	if n, err := strconv.Atoi(strings.TrimSpace(det.NItem)); err == nil {
+		return n
+	}
+	return indice + 1
+}
+
+// parseValor converte um valor decimal do XML (ex: "199.90") para float64
+func parseValor(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// arredondar arredonda para 2 casas decimais
+func arredondar(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -1,6 +1,9 @@
 package nfe
 
-import "encoding/xml"
+import (
+	"encoding/xml"
+	"time"
+)
 
 // ======================================================================
 // TIPOS DE RESULTADO DA VALIDAÇÃO
@@ -21,11 +24,118 @@ type ValidationResult struct {
 	// Status contém o código e mensagem retornados pela SEFAZ
 	Status StatusSefaz `json:"status"`
 
+	// RespostaSefaz é o XML bruto da resposta da SEFAZ (com WithRespostaSefaz(""))
+	RespostaSefaz string `json:"resposta_sefaz,omitempty"`
+
+	// RespostaSefazArquivo é onde a resposta da SEFAZ foi gravada (com WithRespostaSefaz(dir))
+	RespostaSefazArquivo string `json:"resposta_sefaz_arquivo,omitempty"`
+
 	// DadosNFe contém os dados extraídos do XML (quando disponível)
 	DadosNFe *DadosNFe `json:"dados_nfe,omitempty"`
 
-	// Erro contém qualquer erro ocorrido durante a validação
-	Erro error `json:"erro,omitempty"`
+	// Achados contém as inconsistências das regras de negócio (separadas dos erros de XSD)
+	Achados []Achado `json:"achados,omitempty"`
+
+	// Erro descreve a falha que interrompeu a validação (nil quando todas as fases executaram)
+	// Use Err() para tratá-lo como error
+	Erro *ValidationError `json:"erro,omitempty"`
+
+	// Fases registra a situação e a duração de cada fase executada, na ordem
+	// Fases puladas (pelo Nivel ou por uma falha anterior) não aparecem
+	Fases []ExecucaoFase `json:"fases,omitempty"`
+}
+
+// Err devolve Erro como error (nil sem erro, evitando a interface com ponteiro nil)
+//
+// Exemplo:
+//
+//	if err := result.Err(); err != nil {
+//	    var ve *nfe.ValidationError
+//	    errors.As(err, &ve) // ve.Fase == nfe.FaseSefaz...
+//	}
+func (r *ValidationResult) Err() error {
+	if r == nil || r.Erro == nil {
+		return nil
+	}
+	return r.Erro
+}
+
+// Fases da validação informadas em ValidationError.Fase (os mesmos nomes de "fases" no JSON do CLI)
+const (
+	FaseXSD        = "xsd"
+	FaseParse      = "parse"
+	FaseRegras     = "regras"
+	FaseAssinatura = "assinatura"
+	FaseSefaz      = "sefaz"
+)
+
+// Situação de uma fase em ExecucaoFase.Situacao
+const (
+	// SituacaoOK: a fase executou e passou
+	SituacaoOK = "ok"
+
+	// SituacaoFalha: a fase interrompeu a validação (ver ValidationResult.Erro),
+	// encontrou achados de erro (regras, assinatura) ou a SEFAZ não autorizou a nota
+	SituacaoFalha = "falha"
+)
+
+// ExecucaoFase é o andamento de uma fase da validação (ver ValidationResult.Fases)
+type ExecucaoFase struct {
+	// Fase é um dos Fase* (ex: FaseXSD) ou o nome de uma NovaEtapa
+	Fase string `json:"fase"`
+
+	// Situacao é SituacaoOK ou SituacaoFalha
+	Situacao string `json:"situacao"`
+
+	// Duracao é o tempo gasto na fase (no JSON, em nanossegundos)
+	Duracao time.Duration `json:"duracao_ns"`
+}
+
+// Fase devolve a execução da fase informada; ok = false se ela não foi executada
+//
+// Exemplo:
+//
+//	if f, ok := result.Fase(nfe.FaseSefaz); ok {
+//	    metricaSefaz.Observe(f.Duracao.Seconds())
+//	}
+func (r *ValidationResult) Fase(fase string) (ExecucaoFase, bool) {
+	for _, f := range r.Fases {
+		if f.Fase == fase {
+			return f, true
+		}
+	}
+	return ExecucaoFase{}, false
+}
+
+// ValidationError é a falha de uma fase da validação, serializável em JSON
+//
+// Code é o Resultado* da validação (ex: ResultadoXSDInvalido) e CodigoErro
+// a causa (ex: CodigoSefazTLS), ambos estáveis para tratamento automático;
+// Mensagem é o texto do erro original. A causa original continua acessível
+// por errors.Unwrap, mas não sobrevive ao JSON. No JSON, Code vai em
+// "codigo", como nos demais payloads da API.
+type ValidationError struct {
+	Code       string     `json:"codigo"`
+	CodigoErro CodigoErro `json:"codigo_erro"`
+	Fase       string     `json:"fase"`
+	Mensagem   string     `json:"mensagem"`
+
+	causa error
+}
+
+// novoErro cria o ValidationError da fase: Mensagem = "<contexto>: <causa>"
+func novoErro(code, fase, contexto string, causa error) *ValidationError {
+	return &ValidationError{Code: code, CodigoErro: codigoFase(code, causa), Fase: fase, Mensagem: contexto + ": " + causa.Error(), causa: causa}
+}
+
+// Error devolve a Mensagem
+func (e *ValidationError) Error() string {
+	return e.Mensagem
+}
+
+// Unwrap devolve o erro original, sem o contexto da fase (nil depois de um json.Unmarshal)
+func (e *ValidationError) Unwrap() error {
+	return e.causa
 }
 
 // StatusSefaz representa o status retornado pela SEFAZ
@@ -61,6 +171,28 @@ type DadosNFe struct {
 
 	// ValorTotal é o valor total da nota fiscal
 	ValorTotal string `json:"valor_total"`
+
+	// Itens são os produtos da nota (det), na ordem do XML
+	Itens []ItemNFe `json:"itens,omitempty"`
+}
+
+// ItemNFe representa um produto da nota
+type ItemNFe struct {
+	// Item é o número do item (nItem)
+	Item int `json:"item"`
+
+	// Codigo é o código do produto no emitente (cProd)
+	Codigo string `json:"codigo"`
+
+	// Descricao é a descrição do produto (xProd)
+	Descricao string `json:"descricao"`
+
+	// NCM e CFOP do item
+	NCM  string `json:"ncm"`
+	CFOP string `json:"cfop"`
+
+	// Valor é o valor total bruto do item (vProd)
+	Valor string `json:"valor"`
 }
 
 // Empresa representa os dados de uma empresa (emitente ou destinatário)
@@ -72,6 +204,24 @@ type Empresa struct {
 	Nome string `json:"nome"`
 }
 
+// Inconsistencia representa um problema de regra de negócio encontrado na nota
+//
+// Diferente dos erros de XSD, a nota é estruturalmente válida mas contém
+// dados que não fecham (ex: imposto calculado errado, CFOP incompatível).
+type Inconsistencia struct {
+	// Codigo é o cStat da rejeição SEFAZ correspondente, quando existir
+	Codigo string `json:"codigo,omitempty"`
+
+	// Item é o número do item (nItem) afetado, 0 quando a regra é da nota inteira
+	Item int `json:"item,omitempty"`
+
+	// Grupo identifica o grupo/campo afetado (ex: "ICMS00", "IPITrib", "dhEmi")
+	Grupo string `json:"grupo,omitempty"`
+
+	// Mensagem descreve o problema encontrado
+	Mensagem string `json:"mensagem"`
+}
+
 // ======================================================================
 // STRUCTS DO XML DA NF-E (PARA PARSE)
 // ======================================================================
@@ -81,41 +231,155 @@ type Empresa struct {
 type ProcNFe struct {
 	XMLName xml.Name    `xml:"nfeProc"`
 	NFe     NFeEnvelope `xml:"NFe"`
+	ProtNFe *ProtNFe    `xml:"protNFe"`
+}
+
+// ProtNFe é o protocolo de autorização anexado pela SEFAZ
+type ProtNFe struct {
+	InfProt InfProt `xml:"infProt"`
+}
+
+// InfProt contém os dados do protocolo de autorização
+type InfProt struct {
+	TpAmb    string `xml:"tpAmb"`    // 1 = produção, 2 = homologação
+	ChNFe    string `xml:"chNFe"`    // Chave de acesso autorizada
+	DhRecbto string `xml:"dhRecbto"` // Data/hora do processamento na SEFAZ
+	NProt    string `xml:"nProt"`    // Número do protocolo
+	CStat    string `xml:"cStat"`    // 100 = autorizado o uso
+	XMotivo  string `xml:"xMotivo"`
 }
 
 // NFeEnvelope é o envelope principal da NF-e
 type NFeEnvelope struct {
-	XMLName xml.Name `xml:"NFe"`
-	InfNFe  InfNFe   `xml:"infNFe"`
+	XMLName    xml.Name    `xml:"NFe"`
+	InfNFe     InfNFe      `xml:"infNFe"`
+	InfNFeSupl *InfNFeSupl `xml:"infNFeSupl"` // Apenas NFC-e (QR Code)
+
+	// DigestValue é o da assinatura (Signature/SignedInfo/Reference); vazio em nota não assinada
+	DigestValue string `xml:"Signature>SignedInfo>Reference>DigestValue"`
+
+	// Protocolo é o protNFe do nfeProc, preenchido por ParseNFe; nil em NFe sem protocolo
+	Protocolo *InfProt `xml:"-"`
 }
 
 // InfNFe contém as informações principais da nota
 type InfNFe struct {
-	ID    string `xml:"Id,attr"` // Ex: "NFe35250732409620000175550010000037471011544648"
-	Ide   Ide    `xml:"ide"`
-	Emit  Emit   `xml:"emit"`
-	Dest  Dest   `xml:"dest"`
-	Total Total  `xml:"total"`
+	ID      string   `xml:"Id,attr"` // Ex: "NFe35250732409620000175550010000037471011544648"
+	Ide     Ide      `xml:"ide"`
+	Emit    Emit     `xml:"emit"`
+	Dest    Dest     `xml:"dest"`
+	Det     []Det    `xml:"det"`
+	Total   Total    `xml:"total"`
+	Transp  Transp   `xml:"transp"`
+	Cobr    *Cobr    `xml:"cobr"`
+	Pag     *Pag     `xml:"pag"`
+	InfAdic *InfAdic `xml:"infAdic"`
 }
 
 // Ide contém dados de identificação da nota
 type Ide struct {
-	Modelo string `xml:"mod"`   // 55 = NF-e, 65 = NFC-e
-	Serie  string `xml:"serie"` // Série da nota
-	NumNf  string `xml:"nNF"`   // Número da nota
+	CUF      string `xml:"cUF"`      // Código IBGE da UF do emitente
+	Modelo   string `xml:"mod"`      // 55 = NF-e, 65 = NFC-e
+	Serie    string `xml:"serie"`    // Série da nota
+	NumNf    string `xml:"nNF"`      // Número da nota
+	DhEmi    string `xml:"dhEmi"`    // Data/hora de emissão (ex: "2025-07-10T10:00:00-03:00")
+	DhSaiEnt string `xml:"dhSaiEnt"` // Data/hora de saída ou entrada (opcional)
+	TpNF     string `xml:"tpNF"`     // 0 = entrada, 1 = saída
+	IdDest   string `xml:"idDest"`   // 1 = interna, 2 = interestadual, 3 = exterior
+	NatOp    string `xml:"natOp"`    // Natureza da operação (ex: "VENDA")
+	TpEmis   string `xml:"tpEmis"`   // Tipo de emissão (1 = normal, demais = contingência)
+	TpAmb    string `xml:"tpAmb"`    // 1 = produção, 2 = homologação
+	DhCont   string `xml:"dhCont"`   // Data/hora de entrada em contingência (apenas tpEmis diferente de 1)
+	XJust    string `xml:"xJust"`    // Justificativa da entrada em contingência
 }
 
 // Emit representa o emitente da nota
 type Emit struct {
-	CNPJ  string `xml:"CNPJ"`
-	XNome string `xml:"xNome"`
+	CNPJ      string   `xml:"CNPJ"`
+	CPF       string   `xml:"CPF"` // Produtor rural pessoa física
+	XNome     string   `xml:"xNome"`
+	XFant     string   `xml:"xFant"`
+	IE        string   `xml:"IE"`
+	IEST      string   `xml:"IEST"` // IE do substituto tributário
+	EnderEmit Endereco `xml:"enderEmit"`
 }
 
 // Dest representa o destinatário da nota
 type Dest struct {
-	CNPJ  string `xml:"CNPJ"` // Pode estar vazio se for CPF
-	CPF   string `xml:"CPF"`  // Pode estar vazio se for CNPJ
-	XNome string `xml:"xNome"`
+	CNPJ          string   `xml:"CNPJ"`          // Pode estar vazio se for CPF
+	CPF           string   `xml:"CPF"`           // Pode estar vazio se for CNPJ
+	IdEstrangeiro string   `xml:"idEstrangeiro"` // Destinatário estrangeiro
+	XNome         string   `xml:"xNome"`
+	IE            string   `xml:"IE"`
+	Email         string   `xml:"email"`
+	EnderDest     Endereco `xml:"enderDest"`
+}
+
+// Endereco representa o endereço do emitente ou destinatário
+type Endereco struct {
+	XLgr    string `xml:"xLgr"`
+	Nro     string `xml:"nro"`
+	XCpl    string `xml:"xCpl"`
+	XBairro string `xml:"xBairro"`
+	CMun    string `xml:"cMun"` // Código IBGE do município
+	XMun    string `xml:"xMun"`
+	UF      string `xml:"UF"` // Sigla da UF (ex: "SP"), "EX" para exterior
+	CEP     string `xml:"CEP"`
+	Fone    string `xml:"fone"`
+}
+
+// Det representa um item (produto/serviço) da nota
+type Det struct {
+	NItem   string  `xml:"nItem,attr"` // Número do item (1, 2, 3...)
+	Prod    Prod    `xml:"prod"`
+	Imposto Imposto `xml:"imposto"`
+}
+
+// Prod contém os dados do produto de um item
+type Prod struct {
+	CProd  string `xml:"cProd"`
+	XProd  string `xml:"xProd"`
+	NCM    string `xml:"NCM"`
+	CFOP   string `xml:"CFOP"`
+	UCom   string `xml:"uCom"`   // Unidade comercial
+	QCom   string `xml:"qCom"`   // Quantidade comercial
+	VUnCom string `xml:"vUnCom"` // Valor unitário de comercialização
+	VProd  string `xml:"vProd"`
+}
+
+// Imposto agrupa os tributos de um item
+//
+// Cada tributo possui vários grupos possíveis (ICMS00, ICMS20, PISAliq, IPITrib...),
+// por isso os grupos são lidos de forma genérica em GrupoTributo.
+type Imposto struct {
+	ICMS   GruposTributo `xml:"ICMS"`
+	IPI    GruposTributo `xml:"IPI"`
+	PIS    GruposTributo `xml:"PIS"`
+	COFINS GruposTributo `xml:"COFINS"`
+}
+
+// GruposTributo contém os grupos filhos de um tributo (ex: <ICMS><ICMS00>...</ICMS00></ICMS>)
+type GruposTributo struct {
+	Grupos []GrupoTributo `xml:",any"`
+}
+
+// GrupoTributo representa um grupo de tributação (ex: ICMS00, IPITrib, PISAliq)
+//
+// Apenas os campos usados no cálculo são mapeados. Campos ausentes ficam vazios.
+type GrupoTributo struct {
+	XMLName xml.Name
+	Orig    string `xml:"orig"` // Origem da mercadoria (ICMS)
+	CST     string `xml:"CST"`
+	CSOSN   string `xml:"CSOSN"`
+	VBC     string `xml:"vBC"`
+	PICMS   string `xml:"pICMS"`
+	VICMS   string `xml:"vICMS"`
+	PIPI    string `xml:"pIPI"`
+	VIPI    string `xml:"vIPI"`
+	PPIS    string `xml:"pPIS"`
+	VPIS    string `xml:"vPIS"`
+	PCOFINS string `xml:"pCOFINS"`
+	VCOFINS string `xml:"vCOFINS"`
 }
 
 // Total contém os totais da nota
@@ -123,9 +387,84 @@ type Total struct {
 	ICMSTot ICMSTot `xml:"ICMSTot"`
 }
 
+// Cobr contém os dados de cobrança (fatura e duplicatas)
+type Cobr struct {
+	Fat *Fat  `xml:"fat"`
+	Dup []Dup `xml:"dup"`
+}
+
+// Fat representa a fatura da nota
+type Fat struct {
+	NFat  string `xml:"nFat"`
+	VOrig string `xml:"vOrig"` // Valor original da fatura
+	VDesc string `xml:"vDesc"` // Valor do desconto
+	VLiq  string `xml:"vLiq"`  // Valor líquido da fatura
+}
+
+// Dup representa uma duplicata (parcela) da fatura
+type Dup struct {
+	NDup  string `xml:"nDup"`
+	DVenc string `xml:"dVenc"`
+	VDup  string `xml:"vDup"`
+}
+
+// Pag contém as formas de pagamento da nota
+type Pag struct {
+	DetPag []DetPag `xml:"detPag"`
+	VTroco string   `xml:"vTroco"`
+}
+
+// DetPag representa uma forma de pagamento
+type DetPag struct {
+	IndPag string `xml:"indPag"` // 0 = à vista, 1 = a prazo
+	TPag   string `xml:"tPag"`   // Meio de pagamento (01 = dinheiro, 15 = boleto...)
+	VPag   string `xml:"vPag"`
+}
+
+// InfNFeSupl contém as informações suplementares da NFC-e
+type InfNFeSupl struct {
+	QrCode   string `xml:"qrCode"`
+	URLChave string `xml:"urlChave"`
+}
+
 // ICMSTot contém o total de ICMS e valor total da NF
 type ICMSTot struct {
-	VNF string `xml:"vNF"` // Valor total da nota
+	VBC      string `xml:"vBC"`
+	VICMS    string `xml:"vICMS"`
+	VBCST    string `xml:"vBCST"`
+	VST      string `xml:"vST"`
+	VProd    string `xml:"vProd"`
+	VFrete   string `xml:"vFrete"`
+	VSeg     string `xml:"vSeg"`
+	VDesc    string `xml:"vDesc"`
+	VIPI     string `xml:"vIPI"`
+	VPIS     string `xml:"vPIS"`
+	VCOFINS  string `xml:"vCOFINS"`
+	VOutro   string `xml:"vOutro"`
+	VNF      string `xml:"vNF"` // Valor total da nota
+	VTotTrib string `xml:"vTotTrib"`
+}
+
+// Transp contém os dados do transporte
+type Transp struct {
+	ModFrete   string      `xml:"modFrete"` // 0 = emitente, 1 = destinatário, 2 = terceiros, 9 = sem frete...
+	Transporta *Transporta `xml:"transporta"`
+}
+
+// Transporta identifica a transportadora
+type Transporta struct {
+	CNPJ  string `xml:"CNPJ"`
+	CPF   string `xml:"CPF"`
+	XNome string `xml:"xNome"`
+	IE    string `xml:"IE"`
+	XMun  string `xml:"xMun"`
+	UF    string `xml:"UF"`
+}
+
+// InfAdic contém as informações adicionais da nota
+type InfAdic struct {
+	InfAdFisco string `xml:"infAdFisco"` // Interesse do fisco
+	InfCpl     string `xml:"infCpl"`     // Interesse do contribuinte
 }
 
 // ======================================================================
@@ -192,4 +531,4 @@ func (s StatusSefaz) IsRejeitado() bool {
 // (ambos são status válidos - cancelada ainda consta na base)
 func (s StatusSefaz) IsValido() bool {
 	return s.IsAutorizado() || s.IsCancelado()
-}
\ No newline at end of file
+}
@@ -0,0 +1,31 @@
+package nfe
+
+// UFs mapeia o código IBGE da UF para a sigla
+//
+// Usado para decompor o cUF da chave de acesso e compará-lo com os endereços da nota.
+var UFs = map[string]string{
+	"11": "RO", "12": "AC", "13": "AM", "14": "RR", "15": "PA", "16": "AP", "17": "TO",
+	"21": "MA", "22": "PI", "23": "CE", "24": "RN", "25": "PB", "26": "PE", "27": "AL", "28": "SE", "29": "BA",
+	"31": "MG", "32": "ES", "33": "RJ", "35": "SP",
+	"41": "PR", "42": "SC", "43": "RS",
+	"50": "MS", "51": "MT", "52": "GO", "53": "DF",
+}
+
+// SiglaUF retorna a sigla da UF para o código IBGE (ex: "35" -> "SP")
+//
+// Retorna string vazia se o código não for de uma UF válida.
+func SiglaUF(codigoIBGE string) string {
+	return UFs[codigoIBGE]
+}
+
+// CodigoUF retorna o código IBGE da UF para a sigla (ex: "SP" -> "35")
+//
+// Retorna string vazia se a sigla não for de uma UF válida.
+func CodigoUF(sigla string) string {
+	for codigo, s := range UFs {
+		if s == sigla {
+			return codigo
+		}
+	}
+	return ""
+}
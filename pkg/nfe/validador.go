@@ -0,0 +1,61 @@
+package nfe
+
+import "context"
+
+// Validador é uma verificação própria da empresa, executada na fase de regras (ver WithValidadores)
+//
+// Os achados aparecem em ValidationResult.Achados junto aos das regras
+// padrão. Name funciona como o ID de uma regra: é a Regra dos achados que
+// não informam uma, e ConfigRegras.Desabilitadas e Severidades valem para
+// ele. Achados sem Severidade são erros.
+//
+// Validate pode ser chamado por várias goroutines ao mesmo tempo; ctx é o
+// da validação (com o span da fase de regras).
+//
+// Exemplo:
+//
+//	type cfopsPermitidos map[string]bool
+//
+//	func (cfopsPermitidos) Name() string { return "cfop-permitido" }
+//
+//	func (p cfopsPermitidos) Validate(ctx context.Context, dados *nfe.DadosNFe) []nfe.Achado {
+//	    var achados []nfe.Achado
+//	    for _, item := range dados.Itens {
+//	        if !p[item.CFOP] {
+//	            achados = append(achados, nfe.Achado{Inconsistencia: nfe.Inconsistencia{
+//	                Item: item.Item, Grupo: "CFOP", Mensagem: "CFOP " + item.CFOP + " não permitido",
+//	            }})
+//	        }
+//	    }
+//	    return achados
+//	}
+type Validador interface {
+	Name() string
+	Validate(ctx context.Context, dados *DadosNFe) []Achado
+}
+
+// avaliarValidadores executa os Validador habilitados em regras e completa a Regra e a Severidade dos achados
+func avaliarValidadores(ctx context.Context, validadores []Validador, dados *DadosNFe, regras ConfigRegras) []Achado {
+	var achados []Achado
+	for _, v := range validadores {
+		nome := v.Name()
+		if !regras.Habilitada(nome) {
+			continue
+		}
+
+		sobrescrita, temSobrescrita := regras.Severidades[nome]
+		for _, a := range v.Validate(ctx, dados) {
+			if a.Regra == "" {
+				a.Regra = nome
+			}
+			switch {
+			case temSobrescrita:
+				a.Severidade = sobrescrita
+			case a.Severidade == "":
+				a.Severidade = SeveridadeErro
+			}
+			achados = append(achados, a)
+		}
+	}
+	return achados
+}
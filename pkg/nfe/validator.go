@@ -1,10 +1,10 @@
 package nfe
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
-
-	xsdvalidate "github.com/terminalstatic/go-xsd-validate"
 )
 
 // ValidarApenasXSD valida um XML de NF-e apenas contra o schema XSD
@@ -31,40 +31,90 @@ func ValidarApenasXSD(xmlData []byte, xsdPath string) error {
 	return ValidateWithXSD(xmlData, xsdPath)
 }
 
+// ValidarApenasXSDContext é o ValidarApenasXSD com contexto
+//
+// A libxml2 não interrompe uma validação já iniciada: quando ctx termina
+// antes, a função retorna na hora um erro que embrulha ctx.Err() e a
+// validação conclui em segundo plano, sem efeito no resultado.
+//
+// Exemplo:
+//
+//	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+//	defer cancel()
+//	if err := nfe.ValidarApenasXSDContext(ctx, xmlData, "schemas/v4/procNFe_v4.00.xsd"); err != nil {
+//	    log.Println("XML inválido ou prazo esgotado:", err)
+//	}
+func ValidarApenasXSDContext(ctx context.Context, xmlData []byte, xsdPath string) error {
+	return ValidateWithXSDContext(ctx, xmlData, xsdPath)
+}
+
+// ValidateWithXSDContext é um alias para ValidarApenasXSDContext
+func ValidateWithXSDContext(ctx context.Context, xmlData []byte, schemaPath string) error {
+	if err := ctx.Err(); err != nil {
+		return comCodigo(CodigoInterrompida, fmt.Errorf("validação XSD interrompida: %w", err))
+	}
+
+	feito := make(chan error, 1)
+	go func() {
+		feito <- ValidateWithXSD(xmlData, schemaPath)
+	}()
+
+	select {
+	case err := <-feito:
+		return err
+	case <-ctx.Done():
+		return comCodigo(CodigoInterrompida, fmt.Errorf("validação XSD interrompida: %w", ctx.Err()))
+	}
+}
+
 // ValidateWithXSD é um alias para ValidarApenasXSD (mantido por compatibilidade)
+//
+// O XSD compilado fica no CacheXSDPadrao: só a primeira validação de cada
+// schema (ou a seguinte a uma mudança nos arquivos) paga o carregamento.
 func ValidateWithXSD(xmlData []byte, schemaPath string) error {
-	// Verificar se o XSD existe
-	if _, err := os.Stat(schemaPath); err != nil {
-		return fmt.Errorf("arquivo XSD não encontrado em '%s': %w", schemaPath, err)
-	}
+	return CacheXSDPadrao.Validar(xmlData, schemaPath)
+}
 
-	// Inicializa libxml2 wrapper
-	xsdvalidate.Init()
-	defer xsdvalidate.Cleanup()
+// TamanhoMaxXML limita o XML lido de um io.Reader (ValidarXSDReader, ParseNFeReader) e o aceito por ParseNFe: uma NF-e não chega perto disso
+const TamanhoMaxXML = 50 << 20 // 50 MB
 
-	// Carrega o XSD
-	xsdHandler, err := xsdvalidate.NewXsdHandlerUrl(schemaPath, xsdvalidate.ParsErrDefault)
+// ValidarXSDReader valida contra o XSD o XML lido de r (corpo de requisição HTTP, entrada de um .zip...)
+//
+// A libxml2 valida o documento inteiro em memória: o XML é lido aqui, até
+// TamanhoMaxXML, e quem chama não precisa montar o []byte.
+//
+// Exemplo:
+//
+//	func validar(w http.ResponseWriter, r *http.Request) {
+//	    if err := nfe.ValidarXSDReader(r.Body, "schemas/v4/procNFe_v4.00.xsd"); err != nil {
+//	        http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+//	        return
+//	    }
+//	    ...
+//	}
+func ValidarXSDReader(r io.Reader, xsdPath string) error {
+	return ValidarXSDReaderContext(context.Background(), r, xsdPath)
+}
+
+// ValidarXSDReaderContext é o ValidarXSDReader com contexto (ver ValidarApenasXSDContext)
+func ValidarXSDReaderContext(ctx context.Context, r io.Reader, xsdPath string) error {
+	xmlData, err := lerXML(r)
 	if err != nil {
-		return fmt.Errorf("erro ao carregar XSD '%s': %w", schemaPath, err)
+		return err
 	}
-	defer xsdHandler.Free()
+	return ValidateWithXSDContext(ctx, xmlData, xsdPath)
+}
 
-	// Valida o XML contra o XSD
-	err = xsdHandler.ValidateMem(xmlData, xsdvalidate.ValidErrDefault)
+// lerXML lê r até TamanhoMaxXML, falhando se a entrada for maior
+func lerXML(r io.Reader) ([]byte, error) {
+	xmlData, err := io.ReadAll(io.LimitReader(r, TamanhoMaxXML+1))
 	if err != nil {
-		switch e := err.(type) {
-		case xsdvalidate.ValidationError:
-			if len(e.Errors) > 0 {
-				first := e.Errors[0]
-				return fmt.Errorf("falha na validação XSD (linha %d): %s", first.Line, first.Message)
-			}
-			return fmt.Errorf("falha na validação XSD: %v", e)
-		default:
-			return fmt.Errorf("erro de validação XSD: %w", err)
-		}
+		return nil, comCodigo(CodigoXMLIlegivel, fmt.Errorf("erro ao ler XML: %w", err))
 	}
-
-	return nil
+	if len(xmlData) > TamanhoMaxXML {
+		return nil, comCodigo(CodigoXMLIlegivel, fmt.Errorf("XML maior que %d MB", TamanhoMaxXML>>20))
+	}
+	return xmlData, nil
 }
 
 // ValidarXMLFile valida um arquivo XML diretamente
@@ -78,16 +128,24 @@ func ValidateWithXSD(xmlData []byte, schemaPath string) error {
 //	    log.Fatal(err)
 //	}
 func ValidarXMLFile(xmlPath, xsdPath string) error {
+	return ValidarXMLFileContext(context.Background(), xmlPath, xsdPath)
+}
+
+// ValidarXMLFileContext é o ValidarXMLFile com contexto (ver ValidarApenasXSDContext)
+func ValidarXMLFileContext(ctx context.Context, xmlPath, xsdPath string) error {
 	xmlData, err := os.ReadFile(xmlPath)
 	if err != nil {
-		return fmt.Errorf("erro ao ler arquivo XML: %w", err)
+		return comCodigo(CodigoXMLIlegivel, fmt.Errorf("erro ao ler arquivo XML: %w", err))
 	}
 
-	return ValidateWithXSD(xmlData, xsdPath)
+	return ValidateWithXSDContext(ctx, xmlData, xsdPath)
 }
 
 // ValidarLote valida múltiplos XMLs contra o mesmo schema
 //
+// Valida apenas o XSD, um arquivo por vez; para validar em paralelo, com o
+// resultado completo de cada arquivo, use Client.ValidarLote.
+//
 // Útil para validar em batch. Retorna um map com os resultados:
 // - chave: caminho do arquivo
 // - valor: erro (nil se válido)
@@ -105,10 +163,18 @@ func ValidarXMLFile(xmlPath, xsdPath string) error {
 //	    }
 //	}
 func ValidarLote(xmlPaths []string, xsdPath string) map[string]error {
+	return ValidarLoteContext(context.Background(), xmlPaths, xsdPath)
+}
+
+// ValidarLoteContext é o ValidarLote com contexto
+//
+// Quando ctx termina, os arquivos que faltavam não são validados e ficam no
+// mapa com o erro do contexto.
+func ValidarLoteContext(ctx context.Context, xmlPaths []string, xsdPath string) map[string]error {
 	resultados := make(map[string]error)
 
 	for _, xmlPath := range xmlPaths {
-		err := ValidarXMLFile(xmlPath, xsdPath)
+		err := ValidarXMLFileContext(ctx, xmlPath, xsdPath)
 		resultados[xmlPath] = err
 	}
 
@@ -0,0 +1,309 @@
+// Package nfelambda oferece handlers prontos para rodar o validador no AWS Lambda
+//
+// O Handler valida XMLs recebidos pelo API Gateway (REST ou HTTP API) ou
+// gravados em um bucket S3. O schema XSD e a tabela NCM são carregados em
+// New, durante a inicialização da função (cold start), e reaproveitados por
+// todas as invocações seguintes do mesmo ambiente de execução.
+//
+// Exemplo (API Gateway):
+//
+//	func main() {
+//	    h, err := nfelambda.New(nfelambda.Config{
+//	        XSDPath: "schemas/v4/procNFe_v4.00.xsd",
+//	    })
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    lambda.Start(h.APIGateway)
+//	}
+//
+// Exemplo (eventos S3, resultados gravados em resultados/<chave>.json):
+//
+//	awsCfg, _ := config.LoadDefaultConfig(context.Background())
+//	h, err := nfelambda.New(nfelambda.Config{
+//	    XSDPath: "schemas/v4/procNFe_v4.00.xsd",
+//	    S3:      s3.NewFromConfig(awsCfg),
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	lambda.Start(h.S3)
+package nfelambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/fabyo/go-nfe-validator/internal/validation"
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// PrefixoResultadosPadrao é o prefixo dos resultados gravados no S3 quando Config.PrefixoResultados está vazio
+const PrefixoResultadosPadrao = "resultados/"
+
+// ClienteS3 é o subconjunto do *s3.Client usado pelo handler de eventos S3
+type ClienteS3 interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// Config configura o Handler
+type Config struct {
+	// XSDPath é o schema usado na validação (ex: "schemas/v4/procNFe_v4.00.xsd")
+	XSDPath string
+
+	// Regras configura o motor de regras de negócio (todas habilitadas por padrão)
+	Regras nfe.ConfigRegras
+
+	// ConferirAssinatura confere a assinatura digital (XMLDSig) do XML
+	// A regra "assinatura" de Regras pode desligar a conferência ou mudar a severidade.
+	ConferirAssinatura bool
+
+	// Cliente consulta a situação da nota na SEFAZ (nil = sem consulta)
+	Cliente *nfe.Client
+
+	// S3 lê os XMLs e grava os resultados no handler S3 (obrigatório apenas para ele)
+	S3 ClienteS3
+
+	// PrefixoResultados é onde os resultados são gravados: <prefixo><chave do XML>.json
+	// Objetos com este prefixo são ignorados, para a função não disparar a si mesma.
+	PrefixoResultados string
+}
+
+// Resultado é o JSON devolvido pelo API Gateway e gravado no S3
+type Resultado struct {
+	// ChaveAcesso é a chave de 44 dígitos da NF-e
+	ChaveAcesso string `json:"chave_acesso,omitempty"`
+
+	// ValidoXSD indica se o XML passou na validação XSD
+	ValidoXSD bool `json:"valido_xsd"`
+
+	// Aprovado indica que a nota passou em todas as fases executadas
+	Aprovado bool `json:"aprovado"`
+
+	// Autorizado indica se a NF-e está autorizada pela SEFAZ (false sem consulta)
+	Autorizado bool `json:"autorizado"`
+
+	// Status é o retorno da SEFAZ (nil sem consulta)
+	Status *nfe.StatusSefaz `json:"status,omitempty"`
+
+	// DadosNFe contém os dados extraídos do XML
+	DadosNFe *nfe.DadosNFe `json:"dados_nfe,omitempty"`
+
+	// Achados contém as inconsistências das regras de negócio e da assinatura
+	Achados []nfe.Achado `json:"achados,omitempty"`
+
+	// Erro descreve a falha que interrompeu a validação
+	Erro string `json:"erro,omitempty"`
+}
+
+// Handler valida XMLs de NF-e com o schema pré-carregado
+//
+// É seguro para invocações concorrentes e deve ser criado uma única vez,
+// fora da função handler, para aproveitar o ambiente entre invocações.
+type Handler struct {
+	cfg Config
+	xsd *validation.XSDValidator
+}
+
+// New carrega o XSD e a tabela NCM e devolve o Handler pronto para o lambda.Start
+func New(cfg Config) (*Handler, error) {
+	if cfg.XSDPath == "" {
+		return nil, errors.New("nfelambda: XSDPath é obrigatório")
+	}
+	if cfg.PrefixoResultados == "" {
+		cfg.PrefixoResultados = PrefixoResultadosPadrao
+	}
+
+	xsd, err := validation.NewXSDValidator(cfg.XSDPath)
+	if err != nil {
+		return nil, fmt.Errorf("nfelambda: %w", err)
+	}
+
+	// A primeira consulta faz o parse da tabela NCM embutida; melhor pagar isso no cold start
+	nfe.NCMExiste("00000000")
+
+	return &Handler{cfg: cfg, xsd: xsd}, nil
+}
+
+// Close libera o schema pré-carregado
+func (h *Handler) Close() {
+	h.xsd.Close()
+}
+
+// Validar executa as fases de validação sobre o XML
+//
+// Na ordem: XSD, extração dos dados, assinatura (se habilitada), regras de
+// negócio e consulta SEFAZ (se houver Cliente). A primeira falha encerra a
+// validação e fica em Resultado.Erro.
+func (h *Handler) Validar(xmlData []byte) *Resultado {
+	r := &Resultado{}
+
+	if err := h.xsd.Validate(xmlData); err != nil {
+		r.Erro = err.Error()
+		return r
+	}
+	r.ValidoXSD = true
+
+	nota, err := nfe.ParseNFe(xmlData)
+	if err != nil {
+		r.Erro = fmt.Sprintf("falha ao parsear XML: %v", err)
+		return r
+	}
+	r.ChaveAcesso = nfe.ExtractChaveFromID(nota.InfNFe.ID)
+	if dados, err := nfe.ParsearXML(xmlData); err == nil {
+		r.DadosNFe = dados
+	}
+
+	if h.cfg.ConferirAssinatura && h.cfg.Regras.Habilitada(nfe.RegraAssinatura) {
+		severidade := nfe.SeveridadeErro
+		if s, ok := h.cfg.Regras.Severidades[nfe.RegraAssinatura]; ok {
+			severidade = s
+		}
+		for _, inc := range nfe.VerificarAssinatura(xmlData) {
+			r.Achados = append(r.Achados, nfe.Achado{Regra: nfe.RegraAssinatura, Severidade: severidade, Inconsistencia: inc})
+		}
+	}
+	r.Achados = append(r.Achados, nfe.AvaliarRegras(nota, h.cfg.Regras)...)
+
+	if h.cfg.Cliente != nil {
+		consulta, err := h.cfg.Cliente.ValidarChave(r.ChaveAcesso)
+		if err != nil {
+			r.Erro = err.Error()
+			return r
+		}
+		if consulta.Erro != nil {
+			r.Erro = consulta.Erro.Error()
+			return r
+		}
+		r.Autorizado = consulta.Autorizado
+		r.Status = &consulta.Status
+	}
+
+	r.Aprovado = !nfe.TemErros(r.Achados) && (h.cfg.Cliente == nil || r.Autorizado)
+	return r
+}
+
+// APIGateway valida o XML enviado no corpo de uma requisição do API Gateway (REST API, payload v1)
+//
+// Responde 200 com o Resultado em JSON (aprovado ou não) e 400 quando o
+// corpo está vazio ou não pode ser decodificado.
+func (h *Handler) APIGateway(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	status, corpo := h.responder(req.Body, req.IsBase64Encoded)
+	return events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       corpo,
+	}, nil
+}
+
+// APIGatewayV2 é o APIGateway para HTTP APIs e Function URLs (payload v2)
+func (h *Handler) APIGatewayV2(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	status, corpo := h.responder(req.Body, req.IsBase64Encoded)
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       corpo,
+	}, nil
+}
+
+// responder decodifica o corpo, valida e serializa a resposta
+func (h *Handler) responder(body string, base64Codificado bool) (int, string) {
+	xmlData := []byte(body)
+	if base64Codificado {
+		var err error
+		if xmlData, err = base64.StdEncoding.DecodeString(body); err != nil {
+			return erroJSON(http.StatusBadRequest, fmt.Sprintf("corpo base64 inválido: %v", err))
+		}
+	}
+	if len(bytes.TrimSpace(xmlData)) == 0 {
+		return erroJSON(http.StatusBadRequest, "corpo vazio: envie o XML da NF-e")
+	}
+
+	corpo, err := json.Marshal(h.Validar(xmlData))
+	if err != nil {
+		return erroJSON(http.StatusInternalServerError, fmt.Sprintf("erro ao serializar resultado: %v", err))
+	}
+	return http.StatusOK, string(corpo)
+}
+
+// erroJSON monta uma resposta {"erro": "..."}
+func erroJSON(status int, mensagem string) (int, string) {
+	corpo, _ := json.Marshal(Resultado{Erro: mensagem})
+	return status, string(corpo)
+}
+
+// S3 valida os XMLs criados no bucket e grava cada resultado em <PrefixoResultados><chave>.json
+//
+// Objetos sem extensão .xml, já dentro do prefixo de resultados ou apagados
+// antes da validação são ignorados. Retorna erro se algum objeto não pôde
+// ser lido ou gravado, para o Lambda repetir a invocação (ou enviar o evento
+// à DLQ da função).
+func (h *Handler) S3(ctx context.Context, evento events.S3Event) error {
+	if h.cfg.S3 == nil {
+		return errors.New("nfelambda: Config.S3 é obrigatório para eventos S3")
+	}
+
+	var erros []error
+	for _, r := range evento.Records {
+		bucket, chave := r.S3.Bucket.Name, r.S3.Object.URLDecodedKey
+		if chave == "" {
+			chave = r.S3.Object.Key
+		}
+		if strings.HasPrefix(chave, h.cfg.PrefixoResultados) || !strings.EqualFold(path.Ext(chave), ".xml") {
+			continue
+		}
+		if err := h.validarObjeto(ctx, bucket, chave); err != nil {
+			erros = append(erros, fmt.Errorf("s3://%s/%s: %w", bucket, chave, err))
+		}
+	}
+	return errors.Join(erros...)
+}
+
+// validarObjeto baixa o XML, valida e grava o resultado ao lado, no prefixo de resultados
+func (h *Handler) validarObjeto(ctx context.Context, bucket, chave string) error {
+	obj, err := h.cfg.S3.GetObject(ctx, &s3.GetObjectInput{Bucket: &bucket, Key: &chave})
+	var semChave *s3types.NoSuchKey
+	if errors.As(err, &semChave) {
+		// Apagado antes da validação: repetir a invocação não adianta
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao ler objeto: %w", err)
+	}
+	xmlData, err := io.ReadAll(obj.Body)
+	obj.Body.Close()
+	if err != nil {
+		return fmt.Errorf("erro ao ler objeto: %w", err)
+	}
+
+	corpo, err := json.MarshalIndent(h.Validar(xmlData), "", "  ")
+	if err != nil {
+		return fmt.Errorf("erro ao serializar resultado: %w", err)
+	}
+
+	chaveResultado := h.cfg.PrefixoResultados + chave + ".json"
+	_, err = h.cfg.S3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &bucket,
+		Key:         &chaveResultado,
+		Body:        bytes.NewReader(corpo),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao gravar resultado: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+// Package nfemetrics expõe as métricas do validador no formato Prometheus
+//
+// O Coletor implementa prometheus.Collector e nfe.Observador: basta
+// registrá-lo e passá-lo ao nfe.Client para medir as validações e as
+// chamadas à SEFAZ.
+//
+// Exemplo:
+//
+//	coletor := nfemetrics.New()
+//	prometheus.MustRegister(coletor)
+//
+//	client, err := nfe.NewClient(nfe.Config{
+//	    CertDir:     "cert",
+//	    CertKeyFile: "key.pem",
+//	    CertPubFile: "cert.pem",
+//	    Observador:  coletor,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if cert, err := client.Certificado(); err == nil {
+//	    coletor.ObservarCertificado(cert)
+//	}
+//
+//	http.Handle("/metrics", promhttp.Handler())
+//
+// Métricas:
+//
+//	nfe_validacoes_total{resultado}              validações por resultado (aprovada, xsd_invalido, rejeitada...)
+//	nfe_validacao_duracao_segundos               histograma do tempo total de cada validação
+//	nfe_xsd_falhas_total{elemento}               falhas XSD pelo elemento apontado pela libxml2
+//	nfe_sefaz_duracao_segundos{servico,endpoint} histograma da latência dos web services da SEFAZ
+//	nfe_sefaz_erros_total{servico,endpoint}      chamadas sem resposta (rede, TLS, timeout)
+//	nfe_sefaz_cstat_total{servico,cstat}         distribuição dos cStat retornados
+//	nfe_certificado_dias_expiracao{titular}      dias até o vencimento do certificado digital
+//	nfe_certificate_expiry_days{tenant}          dias até o vencimento do certificado de cada tenant
+//
+// Com vários tenants (nfe.Gerenciador), ObservarGerenciador publica a
+// validade do certificado de cada um, lida a cada coleta:
+//
+//	g := nfe.NewGerenciador(carregar)
+//	coletor.ObservarGerenciador(g)
+package nfemetrics
+
+import (
+	"crypto/x509"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// elementoXSD extrai o elemento da mensagem da libxml2 (ex: "Element '{ns}natOp': ...")
+var elementoXSD = regexp.MustCompile(`Element '(?:\{[^}]*\})?([^']+)'`)
+
+// elementoDesconhecido é o rótulo das falhas XSD sem elemento identificável
+const elementoDesconhecido = "desconhecido"
+
+// Coletor agrega as métricas de validação e da SEFAZ
+//
+// Os métodos Observar* aceitam um Coletor nil (métricas desligadas).
+type Coletor struct {
+	validacoes   *prometheus.CounterVec
+	duracao      prometheus.Histogram
+	falhasXSD    *prometheus.CounterVec
+	sefazDuracao *prometheus.HistogramVec
+	sefazErros   *prometheus.CounterVec
+	cstat        *prometheus.CounterVec
+
+	// vencimentos guarda o NotAfter de cada certificado; os dias são calculados na coleta
+	diasCertificado *prometheus.Desc
+	mu              sync.Mutex
+	vencimentos     map[string]time.Time
+
+	// diasTenant é a mesma validade por tenant: a de ObservarCertificadoTenant e a dos gerenciadores, lida na coleta
+	diasTenant    *prometheus.Desc
+	tenants       map[string]time.Time
+	gerenciadores []*nfe.Gerenciador
+}
+
+// New cria o Coletor; registre-o com prometheus.MustRegister ou em um Registry próprio
+func New() *Coletor {
+	return &Coletor{
+		validacoes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "nfe_validacoes_total",
+			Help: "Validações de XML concluídas, por resultado.",
+		}, []string{"resultado"}),
+		duracao: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "nfe_validacao_duracao_segundos",
+			Help:    "Tempo total de cada validação (XSD, parse, regras e SEFAZ).",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 15},
+		}),
+		falhasXSD: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "nfe_xsd_falhas_total",
+			Help: "XMLs reprovados no XSD, pelo elemento apontado na primeira falha.",
+		}, []string{"elemento"}),
+		sefazDuracao: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "nfe_sefaz_duracao_segundos",
+			Help:    "Latência das chamadas aos web services da SEFAZ.",
+			Buckets: []float64{.1, .25, .5, 1, 2, 3, 5, 10, 15},
+		}, []string{"servico", "endpoint"}),
+		sefazErros: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "nfe_sefaz_erros_total",
+			Help: "Chamadas à SEFAZ sem resposta (rede, TLS ou timeout).",
+		}, []string{"servico", "endpoint"}),
+		cstat: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "nfe_sefaz_cstat_total",
+			Help: "Respostas da SEFAZ por cStat.",
+		}, []string{"servico", "cstat"}),
+		diasCertificado: prometheus.NewDesc(
+			"nfe_certificado_dias_expiracao",
+			"Dias até o vencimento do certificado digital (negativo se vencido).",
+			[]string{"titular"}, nil,
+		),
+		vencimentos: make(map[string]time.Time),
+		diasTenant: prometheus.NewDesc(
+			"nfe_certificate_expiry_days",
+			"Dias até o vencimento do certificado digital de cada tenant (negativo se vencido).",
+			[]string{"tenant"}, nil,
+		),
+		tenants: make(map[string]time.Time),
+	}
+}
+
+// Describe implementa prometheus.Collector
+func (c *Coletor) Describe(ch chan<- *prometheus.Desc) {
+	c.validacoes.Describe(ch)
+	c.duracao.Describe(ch)
+	c.falhasXSD.Describe(ch)
+	c.sefazDuracao.Describe(ch)
+	c.sefazErros.Describe(ch)
+	c.cstat.Describe(ch)
+	ch <- c.diasCertificado
+	ch <- c.diasTenant
+}
+
+// Collect implementa prometheus.Collector
+func (c *Coletor) Collect(ch chan<- prometheus.Metric) {
+	c.validacoes.Collect(ch)
+	c.duracao.Collect(ch)
+	c.falhasXSD.Collect(ch)
+	c.sefazDuracao.Collect(ch)
+	c.sefazErros.Collect(ch)
+	c.cstat.Collect(ch)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for titular, vencimento := range c.vencimentos {
+		dias := time.Until(vencimento).Hours() / 24
+		ch <- prometheus.MustNewConstMetric(c.diasCertificado, prometheus.GaugeValue, dias, titular)
+	}
+
+	// Um tenant do gerenciador substitui o mesmo tenant de ObservarCertificadoTenant (série única)
+	tenants := make(map[string]time.Time, len(c.tenants))
+	for tenant, vencimento := range c.tenants {
+		tenants[tenant] = vencimento
+	}
+	for _, g := range c.gerenciadores {
+		for tenant, cert := range g.Certificados() {
+			tenants[tenant] = cert.NotAfter
+		}
+	}
+	for tenant, vencimento := range tenants {
+		dias := time.Until(vencimento).Hours() / 24
+		ch <- prometheus.MustNewConstMetric(c.diasTenant, prometheus.GaugeValue, dias, tenant)
+	}
+}
+
+// ObservarValidacao implementa nfe.Observador
+func (c *Coletor) ObservarValidacao(v nfe.Validacao) {
+	if c == nil {
+		return
+	}
+	c.validacoes.WithLabelValues(v.Resultado).Inc()
+	c.duracao.Observe(v.Duracao.Seconds())
+
+	if v.Resultado == nfe.ResultadoXSDInvalido {
+		c.falhasXSD.WithLabelValues(ElementoXSD(v.ErroXSD)).Inc()
+	}
+}
+
+// ObservarSefaz implementa nfe.Observador
+func (c *Coletor) ObservarSefaz(ch nfe.ChamadaSefaz) {
+	if c == nil {
+		return
+	}
+	c.sefazDuracao.WithLabelValues(ch.Servico, ch.Endpoint).Observe(ch.Duracao.Seconds())
+	if ch.Erro != nil {
+		c.sefazErros.WithLabelValues(ch.Servico, ch.Endpoint).Inc()
+	} else if ch.Codigo != "" {
+		c.cstat.WithLabelValues(ch.Servico, ch.Codigo).Inc()
+	}
+}
+
+// ObservarCertificado passa a acompanhar a validade do certificado (rótulo: Common Name do titular)
+func (c *Coletor) ObservarCertificado(cert *x509.Certificate) {
+	if c == nil || cert == nil {
+		return
+	}
+	c.mu.Lock()
+	c.vencimentos[cert.Subject.CommonName] = cert.NotAfter
+	c.mu.Unlock()
+}
+
+// ObservarCertificadoTenant passa a acompanhar a validade do certificado do tenant (nfe_certificate_expiry_days)
+func (c *Coletor) ObservarCertificadoTenant(tenant string, cert *x509.Certificate) {
+	if c == nil || cert == nil {
+		return
+	}
+	c.mu.Lock()
+	c.tenants[tenant] = cert.NotAfter
+	c.mu.Unlock()
+}
+
+// ObservarGerenciador publica a validade do certificado de cada tenant do Gerenciador (ver Gerenciador.Certificados)
+//
+// Os tenants são lidos a cada coleta: os registrados ou carregados depois
+// entram sozinhos, e os removidos saem.
+func (c *Coletor) ObservarGerenciador(g *nfe.Gerenciador) {
+	if c == nil || g == nil {
+		return
+	}
+	c.mu.Lock()
+	c.gerenciadores = append(c.gerenciadores, g)
+	c.mu.Unlock()
+}
+
+// ElementoXSD devolve o elemento apontado na falha XSD (ex: "natOp"), ou "desconhecido"
+func ElementoXSD(err error) string {
+	if err == nil {
+		return elementoDesconhecido
+	}
+	if m := elementoXSD.FindStringSubmatch(err.Error()); m != nil {
+		return m[1]
+	}
+	return elementoDesconhecido
+}
@@ -0,0 +1,1031 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: nfe/validator/v1/validator.proto
+
+package nfepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Opcoes escolhe as fases executadas; vazio = padrão configurado no servidor.
+type Opcoes struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Apenas XSD (equivalente a -xsd).
+	XsdOnly bool `protobuf:"varint,1,opt,name=xsd_only,json=xsdOnly,proto3" json:"xsd_only,omitempty"`
+	// XSD + parse + regras, sem SEFAZ (equivalente a -skip-sefaz).
+	SkipSefaz bool `protobuf:"varint,2,opt,name=skip_sefaz,json=skipSefaz,proto3" json:"skip_sefaz,omitempty"`
+	// XSD + parse + assinatura + regras, sem SEFAZ (equivalente a -offline).
+	Offline bool `protobuf:"varint,3,opt,name=offline,proto3" json:"offline,omitempty"`
+	// IDs de regras de negócio a desabilitar, além das do servidor.
+	RegrasDesabilitadas []string `protobuf:"bytes,4,rep,name=regras_desabilitadas,json=regrasDesabilitadas,proto3" json:"regras_desabilitadas,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Opcoes) Reset() {
+	*x = Opcoes{}
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Opcoes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Opcoes) ProtoMessage() {}
+
+func (x *Opcoes) ProtoReflect() protoreflect.Message {
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Opcoes.ProtoReflect.Descriptor instead.
+func (*Opcoes) Descriptor() ([]byte, []int) {
+	return file_nfe_validator_v1_validator_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Opcoes) GetXsdOnly() bool {
+	if x != nil {
+		return x.XsdOnly
+	}
+	return false
+}
+
+func (x *Opcoes) GetSkipSefaz() bool {
+	if x != nil {
+		return x.SkipSefaz
+	}
+	return false
+}
+
+func (x *Opcoes) GetOffline() bool {
+	if x != nil {
+		return x.Offline
+	}
+	return false
+}
+
+func (x *Opcoes) GetRegrasDesabilitadas() []string {
+	if x != nil {
+		return x.RegrasDesabilitadas
+	}
+	return nil
+}
+
+type ValidateXMLRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Identificação devolvida no resultado (ex: nome do arquivo).
+	Nome string `protobuf:"bytes,1,opt,name=nome,proto3" json:"nome,omitempty"`
+	// Conteúdo do XML (NFe ou nfeProc).
+	Xml           []byte  `protobuf:"bytes,2,opt,name=xml,proto3" json:"xml,omitempty"`
+	Opcoes        *Opcoes `protobuf:"bytes,3,opt,name=opcoes,proto3" json:"opcoes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateXMLRequest) Reset() {
+	*x = ValidateXMLRequest{}
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateXMLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateXMLRequest) ProtoMessage() {}
+
+func (x *ValidateXMLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateXMLRequest.ProtoReflect.Descriptor instead.
+func (*ValidateXMLRequest) Descriptor() ([]byte, []int) {
+	return file_nfe_validator_v1_validator_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ValidateXMLRequest) GetNome() string {
+	if x != nil {
+		return x.Nome
+	}
+	return ""
+}
+
+func (x *ValidateXMLRequest) GetXml() []byte {
+	if x != nil {
+		return x.Xml
+	}
+	return nil
+}
+
+func (x *ValidateXMLRequest) GetOpcoes() *Opcoes {
+	if x != nil {
+		return x.Opcoes
+	}
+	return nil
+}
+
+type ValidateChaveRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Chave de acesso (44 dígitos; pontuação é ignorada).
+	Chave string `protobuf:"bytes,1,opt,name=chave,proto3" json:"chave,omitempty"`
+	// Consultar a situação da nota na SEFAZ.
+	ConsultarSefaz bool `protobuf:"varint,2,opt,name=consultar_sefaz,json=consultarSefaz,proto3" json:"consultar_sefaz,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ValidateChaveRequest) Reset() {
+	*x = ValidateChaveRequest{}
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateChaveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateChaveRequest) ProtoMessage() {}
+
+func (x *ValidateChaveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateChaveRequest.ProtoReflect.Descriptor instead.
+func (*ValidateChaveRequest) Descriptor() ([]byte, []int) {
+	return file_nfe_validator_v1_validator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ValidateChaveRequest) GetChave() string {
+	if x != nil {
+		return x.Chave
+	}
+	return ""
+}
+
+func (x *ValidateChaveRequest) GetConsultarSefaz() bool {
+	if x != nil {
+		return x.ConsultarSefaz
+	}
+	return false
+}
+
+// Fases indica "ok", "falha" ou "pulada" para cada fase.
+type Fases struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Xsd           string                 `protobuf:"bytes,1,opt,name=xsd,proto3" json:"xsd,omitempty"`
+	Parse         string                 `protobuf:"bytes,2,opt,name=parse,proto3" json:"parse,omitempty"`
+	Assinatura    string                 `protobuf:"bytes,3,opt,name=assinatura,proto3" json:"assinatura,omitempty"`
+	Regras        string                 `protobuf:"bytes,4,opt,name=regras,proto3" json:"regras,omitempty"`
+	Sefaz         string                 `protobuf:"bytes,5,opt,name=sefaz,proto3" json:"sefaz,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Fases) Reset() {
+	*x = Fases{}
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fases) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fases) ProtoMessage() {}
+
+func (x *Fases) ProtoReflect() protoreflect.Message {
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fases.ProtoReflect.Descriptor instead.
+func (*Fases) Descriptor() ([]byte, []int) {
+	return file_nfe_validator_v1_validator_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Fases) GetXsd() string {
+	if x != nil {
+		return x.Xsd
+	}
+	return ""
+}
+
+func (x *Fases) GetParse() string {
+	if x != nil {
+		return x.Parse
+	}
+	return ""
+}
+
+func (x *Fases) GetAssinatura() string {
+	if x != nil {
+		return x.Assinatura
+	}
+	return ""
+}
+
+func (x *Fases) GetRegras() string {
+	if x != nil {
+		return x.Regras
+	}
+	return ""
+}
+
+func (x *Fases) GetSefaz() string {
+	if x != nil {
+		return x.Sefaz
+	}
+	return ""
+}
+
+type SefazStatus struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Autorizado bool                   `protobuf:"varint,1,opt,name=autorizado,proto3" json:"autorizado,omitempty"`
+	// cStat ("N/A" quando a consulta não foi feita).
+	Codigo   string `protobuf:"bytes,2,opt,name=codigo,proto3" json:"codigo,omitempty"`
+	Mensagem string `protobuf:"bytes,3,opt,name=mensagem,proto3" json:"mensagem,omitempty"`
+	// "realizada", "pulada" ou "falhou".
+	Consulta      string `protobuf:"bytes,4,opt,name=consulta,proto3" json:"consulta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SefazStatus) Reset() {
+	*x = SefazStatus{}
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SefazStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SefazStatus) ProtoMessage() {}
+
+func (x *SefazStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SefazStatus.ProtoReflect.Descriptor instead.
+func (*SefazStatus) Descriptor() ([]byte, []int) {
+	return file_nfe_validator_v1_validator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SefazStatus) GetAutorizado() bool {
+	if x != nil {
+		return x.Autorizado
+	}
+	return false
+}
+
+func (x *SefazStatus) GetCodigo() string {
+	if x != nil {
+		return x.Codigo
+	}
+	return ""
+}
+
+func (x *SefazStatus) GetMensagem() string {
+	if x != nil {
+		return x.Mensagem
+	}
+	return ""
+}
+
+func (x *SefazStatus) GetConsulta() string {
+	if x != nil {
+		return x.Consulta
+	}
+	return ""
+}
+
+type DadosXML struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Modelo           string                 `protobuf:"bytes,1,opt,name=modelo,proto3" json:"modelo,omitempty"`
+	Serie            string                 `protobuf:"bytes,2,opt,name=serie,proto3" json:"serie,omitempty"`
+	Numero           string                 `protobuf:"bytes,3,opt,name=numero,proto3" json:"numero,omitempty"`
+	EmitenteCnpj     string                 `protobuf:"bytes,4,opt,name=emitente_cnpj,json=emitenteCnpj,proto3" json:"emitente_cnpj,omitempty"`
+	EmitenteRazao    string                 `protobuf:"bytes,5,opt,name=emitente_razao,json=emitenteRazao,proto3" json:"emitente_razao,omitempty"`
+	DestinatarioDoc  string                 `protobuf:"bytes,6,opt,name=destinatario_doc,json=destinatarioDoc,proto3" json:"destinatario_doc,omitempty"`
+	DestinatarioNome string                 `protobuf:"bytes,7,opt,name=destinatario_nome,json=destinatarioNome,proto3" json:"destinatario_nome,omitempty"`
+	ValorTotalNota   string                 `protobuf:"bytes,8,opt,name=valor_total_nota,json=valorTotalNota,proto3" json:"valor_total_nota,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *DadosXML) Reset() {
+	*x = DadosXML{}
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DadosXML) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DadosXML) ProtoMessage() {}
+
+func (x *DadosXML) ProtoReflect() protoreflect.Message {
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DadosXML.ProtoReflect.Descriptor instead.
+func (*DadosXML) Descriptor() ([]byte, []int) {
+	return file_nfe_validator_v1_validator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DadosXML) GetModelo() string {
+	if x != nil {
+		return x.Modelo
+	}
+	return ""
+}
+
+func (x *DadosXML) GetSerie() string {
+	if x != nil {
+		return x.Serie
+	}
+	return ""
+}
+
+func (x *DadosXML) GetNumero() string {
+	if x != nil {
+		return x.Numero
+	}
+	return ""
+}
+
+func (x *DadosXML) GetEmitenteCnpj() string {
+	if x != nil {
+		return x.EmitenteCnpj
+	}
+	return ""
+}
+
+func (x *DadosXML) GetEmitenteRazao() string {
+	if x != nil {
+		return x.EmitenteRazao
+	}
+	return ""
+}
+
+func (x *DadosXML) GetDestinatarioDoc() string {
+	if x != nil {
+		return x.DestinatarioDoc
+	}
+	return ""
+}
+
+func (x *DadosXML) GetDestinatarioNome() string {
+	if x != nil {
+		return x.DestinatarioNome
+	}
+	return ""
+}
+
+func (x *DadosXML) GetValorTotalNota() string {
+	if x != nil {
+		return x.ValorTotalNota
+	}
+	return ""
+}
+
+type Achado struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID da regra (ex: "ncm", "assinatura").
+	Regra string `protobuf:"bytes,1,opt,name=regra,proto3" json:"regra,omitempty"`
+	// "erro", "aviso" ou "info".
+	Severidade string `protobuf:"bytes,2,opt,name=severidade,proto3" json:"severidade,omitempty"`
+	// cStat da rejeição correspondente, quando existir.
+	Codigo string `protobuf:"bytes,3,opt,name=codigo,proto3" json:"codigo,omitempty"`
+	// Item afetado (0 = nota inteira).
+	Item          int32  `protobuf:"varint,4,opt,name=item,proto3" json:"item,omitempty"`
+	Grupo         string `protobuf:"bytes,5,opt,name=grupo,proto3" json:"grupo,omitempty"`
+	Mensagem      string `protobuf:"bytes,6,opt,name=mensagem,proto3" json:"mensagem,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Achado) Reset() {
+	*x = Achado{}
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Achado) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Achado) ProtoMessage() {}
+
+func (x *Achado) ProtoReflect() protoreflect.Message {
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Achado.ProtoReflect.Descriptor instead.
+func (*Achado) Descriptor() ([]byte, []int) {
+	return file_nfe_validator_v1_validator_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Achado) GetRegra() string {
+	if x != nil {
+		return x.Regra
+	}
+	return ""
+}
+
+func (x *Achado) GetSeveridade() string {
+	if x != nil {
+		return x.Severidade
+	}
+	return ""
+}
+
+func (x *Achado) GetCodigo() string {
+	if x != nil {
+		return x.Codigo
+	}
+	return ""
+}
+
+func (x *Achado) GetItem() int32 {
+	if x != nil {
+		return x.Item
+	}
+	return 0
+}
+
+func (x *Achado) GetGrupo() string {
+	if x != nil {
+		return x.Grupo
+	}
+	return ""
+}
+
+func (x *Achado) GetMensagem() string {
+	if x != nil {
+		return x.Mensagem
+	}
+	return ""
+}
+
+type ValidationResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SchemaVersion string                 `protobuf:"bytes,1,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	Nome          string                 `protobuf:"bytes,2,opt,name=nome,proto3" json:"nome,omitempty"`
+	Tipo          string                 `protobuf:"bytes,3,opt,name=tipo,proto3" json:"tipo,omitempty"`
+	ChaveAcesso   string                 `protobuf:"bytes,4,opt,name=chave_acesso,json=chaveAcesso,proto3" json:"chave_acesso,omitempty"`
+	Aprovado      bool                   `protobuf:"varint,5,opt,name=aprovado,proto3" json:"aprovado,omitempty"`
+	// Código de saída equivalente ao do CLI (0 = ok, 2 = XSD, 3 = parse, 4 = rejeitada, 5 = conectividade).
+	CodigoSaida int32        `protobuf:"varint,6,opt,name=codigo_saida,json=codigoSaida,proto3" json:"codigo_saida,omitempty"`
+	Fases       *Fases       `protobuf:"bytes,7,opt,name=fases,proto3" json:"fases,omitempty"`
+	Sefaz       *SefazStatus `protobuf:"bytes,8,opt,name=sefaz,proto3" json:"sefaz,omitempty"`
+	// Ausente quando o parse não foi executado ou falhou.
+	DadosXml      *DadosXML `protobuf:"bytes,9,opt,name=dados_xml,json=dadosXml,proto3" json:"dados_xml,omitempty"`
+	Achados       []*Achado `protobuf:"bytes,10,rep,name=achados,proto3" json:"achados,omitempty"`
+	Erro          string    `protobuf:"bytes,11,opt,name=erro,proto3" json:"erro,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidationResult) Reset() {
+	*x = ValidationResult{}
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidationResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidationResult) ProtoMessage() {}
+
+func (x *ValidationResult) ProtoReflect() protoreflect.Message {
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidationResult.ProtoReflect.Descriptor instead.
+func (*ValidationResult) Descriptor() ([]byte, []int) {
+	return file_nfe_validator_v1_validator_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ValidationResult) GetSchemaVersion() string {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return ""
+}
+
+func (x *ValidationResult) GetNome() string {
+	if x != nil {
+		return x.Nome
+	}
+	return ""
+}
+
+func (x *ValidationResult) GetTipo() string {
+	if x != nil {
+		return x.Tipo
+	}
+	return ""
+}
+
+func (x *ValidationResult) GetChaveAcesso() string {
+	if x != nil {
+		return x.ChaveAcesso
+	}
+	return ""
+}
+
+func (x *ValidationResult) GetAprovado() bool {
+	if x != nil {
+		return x.Aprovado
+	}
+	return false
+}
+
+func (x *ValidationResult) GetCodigoSaida() int32 {
+	if x != nil {
+		return x.CodigoSaida
+	}
+	return 0
+}
+
+func (x *ValidationResult) GetFases() *Fases {
+	if x != nil {
+		return x.Fases
+	}
+	return nil
+}
+
+func (x *ValidationResult) GetSefaz() *SefazStatus {
+	if x != nil {
+		return x.Sefaz
+	}
+	return nil
+}
+
+func (x *ValidationResult) GetDadosXml() *DadosXML {
+	if x != nil {
+		return x.DadosXml
+	}
+	return nil
+}
+
+func (x *ValidationResult) GetAchados() []*Achado {
+	if x != nil {
+		return x.Achados
+	}
+	return nil
+}
+
+func (x *ValidationResult) GetErro() string {
+	if x != nil {
+		return x.Erro
+	}
+	return ""
+}
+
+type ChaveComponentes struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	CUf                  string                 `protobuf:"bytes,1,opt,name=c_uf,json=cUf,proto3" json:"c_uf,omitempty"`
+	Uf                   string                 `protobuf:"bytes,2,opt,name=uf,proto3" json:"uf,omitempty"`
+	Periodo              string                 `protobuf:"bytes,3,opt,name=periodo,proto3" json:"periodo,omitempty"`
+	Emitente             string                 `protobuf:"bytes,4,opt,name=emitente,proto3" json:"emitente,omitempty"`
+	Modelo               string                 `protobuf:"bytes,5,opt,name=modelo,proto3" json:"modelo,omitempty"`
+	Serie                string                 `protobuf:"bytes,6,opt,name=serie,proto3" json:"serie,omitempty"`
+	Numero               string                 `protobuf:"bytes,7,opt,name=numero,proto3" json:"numero,omitempty"`
+	TipoEmissao          string                 `protobuf:"bytes,8,opt,name=tipo_emissao,json=tipoEmissao,proto3" json:"tipo_emissao,omitempty"`
+	DescricaoTipoEmissao string                 `protobuf:"bytes,9,opt,name=descricao_tipo_emissao,json=descricaoTipoEmissao,proto3" json:"descricao_tipo_emissao,omitempty"`
+	CodigoNumerico       string                 `protobuf:"bytes,10,opt,name=codigo_numerico,json=codigoNumerico,proto3" json:"codigo_numerico,omitempty"`
+	Dv                   string                 `protobuf:"bytes,11,opt,name=dv,proto3" json:"dv,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *ChaveComponentes) Reset() {
+	*x = ChaveComponentes{}
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChaveComponentes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChaveComponentes) ProtoMessage() {}
+
+func (x *ChaveComponentes) ProtoReflect() protoreflect.Message {
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChaveComponentes.ProtoReflect.Descriptor instead.
+func (*ChaveComponentes) Descriptor() ([]byte, []int) {
+	return file_nfe_validator_v1_validator_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ChaveComponentes) GetCUf() string {
+	if x != nil {
+		return x.CUf
+	}
+	return ""
+}
+
+func (x *ChaveComponentes) GetUf() string {
+	if x != nil {
+		return x.Uf
+	}
+	return ""
+}
+
+func (x *ChaveComponentes) GetPeriodo() string {
+	if x != nil {
+		return x.Periodo
+	}
+	return ""
+}
+
+func (x *ChaveComponentes) GetEmitente() string {
+	if x != nil {
+		return x.Emitente
+	}
+	return ""
+}
+
+func (x *ChaveComponentes) GetModelo() string {
+	if x != nil {
+		return x.Modelo
+	}
+	return ""
+}
+
+func (x *ChaveComponentes) GetSerie() string {
+	if x != nil {
+		return x.Serie
+	}
+	return ""
+}
+
+func (x *ChaveComponentes) GetNumero() string {
+	if x != nil {
+		return x.Numero
+	}
+	return ""
+}
+
+func (x *ChaveComponentes) GetTipoEmissao() string {
+	if x != nil {
+		return x.TipoEmissao
+	}
+	return ""
+}
+
+func (x *ChaveComponentes) GetDescricaoTipoEmissao() string {
+	if x != nil {
+		return x.DescricaoTipoEmissao
+	}
+	return ""
+}
+
+func (x *ChaveComponentes) GetCodigoNumerico() string {
+	if x != nil {
+		return x.CodigoNumerico
+	}
+	return ""
+}
+
+func (x *ChaveComponentes) GetDv() string {
+	if x != nil {
+		return x.Dv
+	}
+	return ""
+}
+
+type ChaveResult struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ChaveAcesso string                 `protobuf:"bytes,1,opt,name=chave_acesso,json=chaveAcesso,proto3" json:"chave_acesso,omitempty"`
+	Valida      bool                   `protobuf:"varint,2,opt,name=valida,proto3" json:"valida,omitempty"`
+	Componentes *ChaveComponentes      `protobuf:"bytes,3,opt,name=componentes,proto3" json:"componentes,omitempty"`
+	// Presente quando consultar_sefaz = true e a consulta foi feita.
+	Sefaz         *SefazStatus `protobuf:"bytes,4,opt,name=sefaz,proto3" json:"sefaz,omitempty"`
+	Erro          string       `protobuf:"bytes,5,opt,name=erro,proto3" json:"erro,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChaveResult) Reset() {
+	*x = ChaveResult{}
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChaveResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChaveResult) ProtoMessage() {}
+
+func (x *ChaveResult) ProtoReflect() protoreflect.Message {
+	mi := &file_nfe_validator_v1_validator_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChaveResult.ProtoReflect.Descriptor instead.
+func (*ChaveResult) Descriptor() ([]byte, []int) {
+	return file_nfe_validator_v1_validator_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ChaveResult) GetChaveAcesso() string {
+	if x != nil {
+		return x.ChaveAcesso
+	}
+	return ""
+}
+
+func (x *ChaveResult) GetValida() bool {
+	if x != nil {
+		return x.Valida
+	}
+	return false
+}
+
+func (x *ChaveResult) GetComponentes() *ChaveComponentes {
+	if x != nil {
+		return x.Componentes
+	}
+	return nil
+}
+
+func (x *ChaveResult) GetSefaz() *SefazStatus {
+	if x != nil {
+		return x.Sefaz
+	}
+	return nil
+}
+
+func (x *ChaveResult) GetErro() string {
+	if x != nil {
+		return x.Erro
+	}
+	return ""
+}
+
+var File_nfe_validator_v1_validator_proto protoreflect.FileDescriptor
+
+const file_nfe_validator_v1_validator_proto_rawDesc = "" +
+	"\n" +
+	" nfe/validator/v1/validator.proto\x12\x10nfe.validator.v1\"\x8f\x01\n" +
+	"\x06Opcoes\x12\x19\n" +
+	"\bxsd_only\x18\x01 \x01(\bR\axsdOnly\x12\x1d\n" +
+	"\n" +
+	"skip_sefaz\x18\x02 \x01(\bR\tskipSefaz\x12\x18\n" +
+	"\aoffline\x18\x03 \x01(\bR\aoffline\x121\n" +
+	"\x14regras_desabilitadas\x18\x04 \x03(\tR\x13regrasDesabilitadas\"l\n" +
+	"\x12ValidateXMLRequest\x12\x12\n" +
+	"\x04nome\x18\x01 \x01(\tR\x04nome\x12\x10\n" +
+	"\x03xml\x18\x02 \x01(\fR\x03xml\x120\n" +
+	"\x06opcoes\x18\x03 \x01(\v2\x18.nfe.validator.v1.OpcoesR\x06opcoes\"U\n" +
+	"\x14ValidateChaveRequest\x12\x14\n" +
+	"\x05chave\x18\x01 \x01(\tR\x05chave\x12'\n" +
+	"\x0fconsultar_sefaz\x18\x02 \x01(\bR\x0econsultarSefaz\"}\n" +
+	"\x05Fases\x12\x10\n" +
+	"\x03xsd\x18\x01 \x01(\tR\x03xsd\x12\x14\n" +
+	"\x05parse\x18\x02 \x01(\tR\x05parse\x12\x1e\n" +
+	"\n" +
+	"assinatura\x18\x03 \x01(\tR\n" +
+	"assinatura\x12\x16\n" +
+	"\x06regras\x18\x04 \x01(\tR\x06regras\x12\x14\n" +
+	"\x05sefaz\x18\x05 \x01(\tR\x05sefaz\"}\n" +
+	"\vSefazStatus\x12\x1e\n" +
+	"\n" +
+	"autorizado\x18\x01 \x01(\bR\n" +
+	"autorizado\x12\x16\n" +
+	"\x06codigo\x18\x02 \x01(\tR\x06codigo\x12\x1a\n" +
+	"\bmensagem\x18\x03 \x01(\tR\bmensagem\x12\x1a\n" +
+	"\bconsulta\x18\x04 \x01(\tR\bconsulta\"\x9e\x02\n" +
+	"\bDadosXML\x12\x16\n" +
+	"\x06modelo\x18\x01 \x01(\tR\x06modelo\x12\x14\n" +
+	"\x05serie\x18\x02 \x01(\tR\x05serie\x12\x16\n" +
+	"\x06numero\x18\x03 \x01(\tR\x06numero\x12#\n" +
+	"\remitente_cnpj\x18\x04 \x01(\tR\femitenteCnpj\x12%\n" +
+	"\x0eemitente_razao\x18\x05 \x01(\tR\remitenteRazao\x12)\n" +
+	"\x10destinatario_doc\x18\x06 \x01(\tR\x0fdestinatarioDoc\x12+\n" +
+	"\x11destinatario_nome\x18\a \x01(\tR\x10destinatarioNome\x12(\n" +
+	"\x10valor_total_nota\x18\b \x01(\tR\x0evalorTotalNota\"\x9c\x01\n" +
+	"\x06Achado\x12\x14\n" +
+	"\x05regra\x18\x01 \x01(\tR\x05regra\x12\x1e\n" +
+	"\n" +
+	"severidade\x18\x02 \x01(\tR\n" +
+	"severidade\x12\x16\n" +
+	"\x06codigo\x18\x03 \x01(\tR\x06codigo\x12\x12\n" +
+	"\x04item\x18\x04 \x01(\x05R\x04item\x12\x14\n" +
+	"\x05grupo\x18\x05 \x01(\tR\x05grupo\x12\x1a\n" +
+	"\bmensagem\x18\x06 \x01(\tR\bmensagem\"\xa8\x03\n" +
+	"\x10ValidationResult\x12%\n" +
+	"\x0eschema_version\x18\x01 \x01(\tR\rschemaVersion\x12\x12\n" +
+	"\x04nome\x18\x02 \x01(\tR\x04nome\x12\x12\n" +
+	"\x04tipo\x18\x03 \x01(\tR\x04tipo\x12!\n" +
+	"\fchave_acesso\x18\x04 \x01(\tR\vchaveAcesso\x12\x1a\n" +
+	"\baprovado\x18\x05 \x01(\bR\baprovado\x12!\n" +
+	"\fcodigo_saida\x18\x06 \x01(\x05R\vcodigoSaida\x12-\n" +
+	"\x05fases\x18\a \x01(\v2\x17.nfe.validator.v1.FasesR\x05fases\x123\n" +
+	"\x05sefaz\x18\b \x01(\v2\x1d.nfe.validator.v1.SefazStatusR\x05sefaz\x127\n" +
+	"\tdados_xml\x18\t \x01(\v2\x1a.nfe.validator.v1.DadosXMLR\bdadosXml\x122\n" +
+	"\aachados\x18\n" +
+	" \x03(\v2\x18.nfe.validator.v1.AchadoR\aachados\x12\x12\n" +
+	"\x04erro\x18\v \x01(\tR\x04erro\"\xc3\x02\n" +
+	"\x10ChaveComponentes\x12\x11\n" +
+	"\x04c_uf\x18\x01 \x01(\tR\x03cUf\x12\x0e\n" +
+	"\x02uf\x18\x02 \x01(\tR\x02uf\x12\x18\n" +
+	"\aperiodo\x18\x03 \x01(\tR\aperiodo\x12\x1a\n" +
+	"\bemitente\x18\x04 \x01(\tR\bemitente\x12\x16\n" +
+	"\x06modelo\x18\x05 \x01(\tR\x06modelo\x12\x14\n" +
+	"\x05serie\x18\x06 \x01(\tR\x05serie\x12\x16\n" +
+	"\x06numero\x18\a \x01(\tR\x06numero\x12!\n" +
+	"\ftipo_emissao\x18\b \x01(\tR\vtipoEmissao\x124\n" +
+	"\x16descricao_tipo_emissao\x18\t \x01(\tR\x14descricaoTipoEmissao\x12'\n" +
+	"\x0fcodigo_numerico\x18\n" +
+	" \x01(\tR\x0ecodigoNumerico\x12\x0e\n" +
+	"\x02dv\x18\v \x01(\tR\x02dv\"\xd7\x01\n" +
+	"\vChaveResult\x12!\n" +
+	"\fchave_acesso\x18\x01 \x01(\tR\vchaveAcesso\x12\x16\n" +
+	"\x06valida\x18\x02 \x01(\bR\x06valida\x12D\n" +
+	"\vcomponentes\x18\x03 \x01(\v2\".nfe.validator.v1.ChaveComponentesR\vcomponentes\x123\n" +
+	"\x05sefaz\x18\x04 \x01(\v2\x1d.nfe.validator.v1.SefazStatusR\x05sefaz\x12\x12\n" +
+	"\x04erro\x18\x05 \x01(\tR\x04erro2\xa2\x02\n" +
+	"\x10ValidatorService\x12W\n" +
+	"\vValidateXML\x12$.nfe.validator.v1.ValidateXMLRequest\x1a\".nfe.validator.v1.ValidationResult\x12V\n" +
+	"\rValidateChave\x12&.nfe.validator.v1.ValidateChaveRequest\x1a\x1d.nfe.validator.v1.ChaveResult\x12]\n" +
+	"\rValidateBatch\x12$.nfe.validator.v1.ValidateXMLRequest\x1a\".nfe.validator.v1.ValidationResult(\x010\x01B3Z1github.com/fabyo/go-nfe-validator/pkg/nfepb;nfepbb\x06proto3"
+
+var (
+	file_nfe_validator_v1_validator_proto_rawDescOnce sync.Once
+	file_nfe_validator_v1_validator_proto_rawDescData []byte
+)
+
+func file_nfe_validator_v1_validator_proto_rawDescGZIP() []byte {
+	file_nfe_validator_v1_validator_proto_rawDescOnce.Do(func() {
+		file_nfe_validator_v1_validator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_nfe_validator_v1_validator_proto_rawDesc), len(file_nfe_validator_v1_validator_proto_rawDesc)))
+	})
+	return file_nfe_validator_v1_validator_proto_rawDescData
+}
+
+var file_nfe_validator_v1_validator_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_nfe_validator_v1_validator_proto_goTypes = []any{
+	(*Opcoes)(nil),               // 0: nfe.validator.v1.Opcoes
+	(*ValidateXMLRequest)(nil),   // 1: nfe.validator.v1.ValidateXMLRequest
+	(*ValidateChaveRequest)(nil), // 2: nfe.validator.v1.ValidateChaveRequest
+	(*Fases)(nil),                // 3: nfe.validator.v1.Fases
+	(*SefazStatus)(nil),          // 4: nfe.validator.v1.SefazStatus
+	(*DadosXML)(nil),             // 5: nfe.validator.v1.DadosXML
+	(*Achado)(nil),               // 6: nfe.validator.v1.Achado
+	(*ValidationResult)(nil),     // 7: nfe.validator.v1.ValidationResult
+	(*ChaveComponentes)(nil),     // 8: nfe.validator.v1.ChaveComponentes
+	(*ChaveResult)(nil),          // 9: nfe.validator.v1.ChaveResult
+}
+var file_nfe_validator_v1_validator_proto_depIdxs = []int32{
+	0,  // 0: nfe.validator.v1.ValidateXMLRequest.opcoes:type_name -> nfe.validator.v1.Opcoes
+	3,  // 1: nfe.validator.v1.ValidationResult.fases:type_name -> nfe.validator.v1.Fases
+	4,  // 2: nfe.validator.v1.ValidationResult.sefaz:type_name -> nfe.validator.v1.SefazStatus
+	5,  // 3: nfe.validator.v1.ValidationResult.dados_xml:type_name -> nfe.validator.v1.DadosXML
+	6,  // 4: nfe.validator.v1.ValidationResult.achados:type_name -> nfe.validator.v1.Achado
+	8,  // 5: nfe.validator.v1.ChaveResult.componentes:type_name -> nfe.validator.v1.ChaveComponentes
+	4,  // 6: nfe.validator.v1.ChaveResult.sefaz:type_name -> nfe.validator.v1.SefazStatus
+	1,  // 7: nfe.validator.v1.ValidatorService.ValidateXML:input_type -> nfe.validator.v1.ValidateXMLRequest
+	2,  // 8: nfe.validator.v1.ValidatorService.ValidateChave:input_type -> nfe.validator.v1.ValidateChaveRequest
+	1,  // 9: nfe.validator.v1.ValidatorService.ValidateBatch:input_type -> nfe.validator.v1.ValidateXMLRequest
+	7,  // 10: nfe.validator.v1.ValidatorService.ValidateXML:output_type -> nfe.validator.v1.ValidationResult
+	9,  // 11: nfe.validator.v1.ValidatorService.ValidateChave:output_type -> nfe.validator.v1.ChaveResult
+	7,  // 12: nfe.validator.v1.ValidatorService.ValidateBatch:output_type -> nfe.validator.v1.ValidationResult
+	10, // [10:13] is the sub-list for method output_type
+	7,  // [7:10] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_nfe_validator_v1_validator_proto_init() }
+func file_nfe_validator_v1_validator_proto_init() {
+	if File_nfe_validator_v1_validator_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_nfe_validator_v1_validator_proto_rawDesc), len(file_nfe_validator_v1_validator_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_nfe_validator_v1_validator_proto_goTypes,
+		DependencyIndexes: file_nfe_validator_v1_validator_proto_depIdxs,
+		MessageInfos:      file_nfe_validator_v1_validator_proto_msgTypes,
+	}.Build()
+	File_nfe_validator_v1_validator_proto = out.File
+	file_nfe_validator_v1_validator_proto_goTypes = nil
+	file_nfe_validator_v1_validator_proto_depIdxs = nil
+}
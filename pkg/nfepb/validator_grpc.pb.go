@@ -0,0 +1,204 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: nfe/validator/v1/validator.proto
+
+package nfepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ValidatorService_ValidateXML_FullMethodName   = "/nfe.validator.v1.ValidatorService/ValidateXML"
+	ValidatorService_ValidateChave_FullMethodName = "/nfe.validator.v1.ValidatorService/ValidateChave"
+	ValidatorService_ValidateBatch_FullMethodName = "/nfe.validator.v1.ValidatorService/ValidateBatch"
+)
+
+// ValidatorServiceClient is the client API for ValidatorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ValidatorService valida XMLs de NF-e/NFC-e e chaves de acesso.
+type ValidatorServiceClient interface {
+	// ValidateXML executa as fases de validação (XSD, parse, regras, SEFAZ) sobre um XML.
+	ValidateXML(ctx context.Context, in *ValidateXMLRequest, opts ...grpc.CallOption) (*ValidationResult, error)
+	// ValidateChave confere o dígito verificador, decompõe a chave e, se pedido, consulta a SEFAZ.
+	ValidateChave(ctx context.Context, in *ValidateChaveRequest, opts ...grpc.CallOption) (*ChaveResult, error)
+	// ValidateBatch valida um fluxo de XMLs; os resultados voltam na ordem de envio.
+	// As opções valem as da primeira mensagem do fluxo.
+	ValidateBatch(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ValidateXMLRequest, ValidationResult], error)
+}
+
+type validatorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewValidatorServiceClient(cc grpc.ClientConnInterface) ValidatorServiceClient {
+	return &validatorServiceClient{cc}
+}
+
+func (c *validatorServiceClient) ValidateXML(ctx context.Context, in *ValidateXMLRequest, opts ...grpc.CallOption) (*ValidationResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidationResult)
+	err := c.cc.Invoke(ctx, ValidatorService_ValidateXML_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *validatorServiceClient) ValidateChave(ctx context.Context, in *ValidateChaveRequest, opts ...grpc.CallOption) (*ChaveResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChaveResult)
+	err := c.cc.Invoke(ctx, ValidatorService_ValidateChave_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *validatorServiceClient) ValidateBatch(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ValidateXMLRequest, ValidationResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ValidatorService_ServiceDesc.Streams[0], ValidatorService_ValidateBatch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ValidateXMLRequest, ValidationResult]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ValidatorService_ValidateBatchClient = grpc.BidiStreamingClient[ValidateXMLRequest, ValidationResult]
+
+// ValidatorServiceServer is the server API for ValidatorService service.
+// All implementations must embed UnimplementedValidatorServiceServer
+// for forward compatibility.
+//
+// ValidatorService valida XMLs de NF-e/NFC-e e chaves de acesso.
+type ValidatorServiceServer interface {
+	// ValidateXML executa as fases de validação (XSD, parse, regras, SEFAZ) sobre um XML.
+	ValidateXML(context.Context, *ValidateXMLRequest) (*ValidationResult, error)
+	// ValidateChave confere o dígito verificador, decompõe a chave e, se pedido, consulta a SEFAZ.
+	ValidateChave(context.Context, *ValidateChaveRequest) (*ChaveResult, error)
+	// ValidateBatch valida um fluxo de XMLs; os resultados voltam na ordem de envio.
+	// As opções valem as da primeira mensagem do fluxo.
+	ValidateBatch(grpc.BidiStreamingServer[ValidateXMLRequest, ValidationResult]) error
+	mustEmbedUnimplementedValidatorServiceServer()
+}
+
+// UnimplementedValidatorServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedValidatorServiceServer struct{}
+
+func (UnimplementedValidatorServiceServer) ValidateXML(context.Context, *ValidateXMLRequest) (*ValidationResult, error) {
+	return nil, status.Error(codes.Unimplemented, "method ValidateXML not implemented")
+}
+func (UnimplementedValidatorServiceServer) ValidateChave(context.Context, *ValidateChaveRequest) (*ChaveResult, error) {
+	return nil, status.Error(codes.Unimplemented, "method ValidateChave not implemented")
+}
+func (UnimplementedValidatorServiceServer) ValidateBatch(grpc.BidiStreamingServer[ValidateXMLRequest, ValidationResult]) error {
+	return status.Error(codes.Unimplemented, "method ValidateBatch not implemented")
+}
+func (UnimplementedValidatorServiceServer) mustEmbedUnimplementedValidatorServiceServer() {}
+func (UnimplementedValidatorServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeValidatorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ValidatorServiceServer will
+// result in compilation errors.
+type UnsafeValidatorServiceServer interface {
+	mustEmbedUnimplementedValidatorServiceServer()
+}
+
+func RegisterValidatorServiceServer(s grpc.ServiceRegistrar, srv ValidatorServiceServer) {
+	// If the following call panics, it indicates UnimplementedValidatorServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ValidatorService_ServiceDesc, srv)
+}
+
+func _ValidatorService_ValidateXML_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateXMLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ValidatorServiceServer).ValidateXML(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ValidatorService_ValidateXML_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ValidatorServiceServer).ValidateXML(ctx, req.(*ValidateXMLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ValidatorService_ValidateChave_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateChaveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ValidatorServiceServer).ValidateChave(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ValidatorService_ValidateChave_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ValidatorServiceServer).ValidateChave(ctx, req.(*ValidateChaveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ValidatorService_ValidateBatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ValidatorServiceServer).ValidateBatch(&grpc.GenericServerStream[ValidateXMLRequest, ValidationResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ValidatorService_ValidateBatchServer = grpc.BidiStreamingServer[ValidateXMLRequest, ValidationResult]
+
+// ValidatorService_ServiceDesc is the grpc.ServiceDesc for ValidatorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ValidatorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "nfe.validator.v1.ValidatorService",
+	HandlerType: (*ValidatorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ValidateXML",
+			Handler:    _ValidatorService_ValidateXML_Handler,
+		},
+		{
+			MethodName: "ValidateChave",
+			Handler:    _ValidatorService_ValidateChave_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ValidateBatch",
+			Handler:       _ValidatorService_ValidateBatch_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "nfe/validator/v1/validator.proto",
+}
@@ -0,0 +1,97 @@
+// Package nfeqr lê o QR Code de uma imagem do DANFE NFC-e (cupom) e consulta a nota na SEFAZ
+//
+// A imagem (PNG, JPEG ou GIF: foto do celular ou cupom escaneado) é
+// decodificada, o conteúdo do QR Code é interpretado por nfe.LerQRCode e a
+// chave de acesso segue para nfe.Client.ValidarChave. Fica fora do pacote
+// nfe para que só quem lê imagens dependa do decodificador de QR Code.
+//
+// Exemplo:
+//
+//	f, _ := os.Open("cupom.jpg")
+//	defer f.Close()
+//	result, err := nfeqr.ValidarImagem(ctx, client, f)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(result.ChaveAcesso, result.Autorizado)
+package nfeqr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // decodificadores registrados para image.Decode
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// ErrSemQRCode indica que nenhum QR Code foi encontrado na imagem
+var ErrSemQRCode = errors.New("nenhum QR Code encontrado na imagem")
+
+// ErrQRCodeIlegivel indica um QR Code localizado, mas que não pôde ser lido (borrado, cortado ou danificado)
+var ErrQRCodeIlegivel = errors.New("QR Code ilegível: tente uma imagem mais nítida e sem cortes")
+
+// LerImagem decodifica a imagem e lê o QR Code da NFC-e (ver LerImagemDecodificada)
+func LerImagem(r io.Reader) (*nfe.QRCodeNFCe, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler imagem (use PNG, JPEG ou GIF): %w", err)
+	}
+	return LerImagemDecodificada(img)
+}
+
+// LerImagemDecodificada localiza o QR Code na imagem e interpreta o conteúdo com nfe.LerQRCode
+//
+// Retorna ErrSemQRCode ou ErrQRCodeIlegivel quando o QR Code não é lido, e o
+// erro de nfe.LerQRCode quando o conteúdo não traz uma chave de acesso válida.
+func LerImagemDecodificada(img image.Image) (*nfe.QRCodeNFCe, error) {
+	conteudo, err := decodificar(img)
+	if err != nil {
+		return nil, err
+	}
+	return nfe.LerQRCode(conteudo)
+}
+
+// ValidarImagem lê o QR Code da imagem e consulta a situação da nota com client.ValidarChave
+//
+// Sem ambiente informado, consulta no ambiente do tpAmb do QR Code (um cupom
+// de homologação é consultado em homologação); se o QR Code não trouxer
+// tpAmb, vale o ambiente do client.
+func ValidarImagem(ctx context.Context, client *nfe.Client, r io.Reader, ambiente ...nfe.Ambiente) (*nfe.ValidationResult, error) {
+	qr, err := LerImagem(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(ambiente) == 0 {
+		ambiente = []nfe.Ambiente{qr.Ambiente()}
+	}
+	return client.ValidarChaveContext(ctx, qr.Chave, ambiente...)
+}
+
+// decodificar devolve o texto do QR Code da imagem
+func decodificar(img image.Image) (string, error) {
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return "", fmt.Errorf("erro ao preparar imagem: %w", err)
+	}
+
+	dicas := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+	resultado, err := qrcode.NewQRCodeReader().Decode(bmp, dicas)
+	if err != nil {
+		var naoEncontrado gozxing.NotFoundException
+		if errors.As(err, &naoEncontrado) {
+			return "", ErrSemQRCode
+		}
+		return "", fmt.Errorf("%w (%v)", ErrQRCodeIlegivel, err)
+	}
+	return resultado.GetText(), nil
+}
@@ -0,0 +1,295 @@
+package nfetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// ModoCassete define se o Cassete grava as chamadas reais à SEFAZ ou as reproduz
+type ModoCassete int
+
+const (
+	// ModoAutomatico reproduz o cassete se o arquivo existe; senão, grava
+	ModoAutomatico ModoCassete = iota
+	// ModoGravar chama a SEFAZ e regrava o cassete do zero
+	ModoGravar
+	// ModoReproduzir responde apenas com o cassete, sem rede nem certificado
+	ModoReproduzir
+)
+
+// ErrSemGravacao indica uma chamada sem interação correspondente no cassete (ModoReproduzir)
+var ErrSemGravacao = errors.New("nfetest: chamada sem gravação no cassete")
+
+// Interacao é uma chamada gravada no cassete: a requisição SOAP e a resposta da SEFAZ, já sanitizadas
+type Interacao struct {
+	URL          string    `json:"url"`
+	Acao         string    `json:"acao,omitempty"`  // SOAP action do Content-Type
+	Chave        string    `json:"chave,omitempty"` // chNFe da requisição, quando houver
+	Requisicao   string    `json:"requisicao"`
+	Status       int       `json:"status"`
+	TipoConteudo string    `json:"tipo_conteudo,omitempty"`
+	Resposta     string    `json:"resposta"`
+	GravadaEm    time.Time `json:"gravada_em"`
+}
+
+// arquivoCassete é o formato do arquivo JSON do Cassete
+type arquivoCassete struct {
+	Interacoes []Interacao `json:"interacoes"`
+}
+
+// Cassete grava as chamadas do nfe.Client à SEFAZ real em um arquivo JSON e as reproduz depois (record/replay)
+//
+// Os testes da camada SOAP ficam reproduzíveis: a gravação é feita uma vez,
+// com certificado e rede, e o arquivo vai para o repositório (testdata). Na
+// reprodução, nenhuma conexão é aberta e o certificado não é necessário (ver
+// Cliente). Os dados pessoais das requisições e respostas são mascarados
+// antes da gravação (nfe.MascararTexto; certificados e assinaturas viram
+// "***"), então a reprodução devolve a resposta sanitizada.
+//
+// Na reprodução, cada chamada usa a próxima interação ainda não usada com a
+// mesma URL, SOAP action e chave de acesso; esgotadas, a última se repete.
+// Falhas de rede não são gravadas.
+//
+// Exemplo:
+//
+//	modo := nfetest.ModoReproduzir
+//	if os.Getenv("NFE_GRAVAR") != "" {
+//	    modo = nfetest.ModoGravar // com o certificado de homologação
+//	}
+//	cassete, err := nfetest.NewCassete("testdata/consulta.json", modo)
+//	...
+//	client, err := cassete.Cliente(cfg) // ou nfe.NewClient(cfg, nfe.WithTransporte(cassete.Transporte))
+type Cassete struct {
+	caminho string
+	modo    ModoCassete // ModoGravar ou ModoReproduzir (ModoAutomatico já resolvido)
+
+	mu         sync.Mutex
+	interacoes []Interacao
+	usadas     []bool
+}
+
+// NewCassete abre o cassete do arquivo caminho no modo informado
+//
+// Em ModoReproduzir, o arquivo precisa existir; em ModoGravar, ele é criado
+// (com os diretórios) na primeira chamada gravada.
+func NewCassete(caminho string, modo ModoCassete) (*Cassete, error) {
+	c := &Cassete{caminho: caminho, modo: modo}
+	if modo == ModoAutomatico {
+		c.modo = ModoReproduzir
+		if _, err := os.Stat(caminho); errors.Is(err, os.ErrNotExist) {
+			c.modo = ModoGravar
+		}
+	}
+	if c.modo == ModoGravar {
+		return c, nil
+	}
+
+	dados, err := os.ReadFile(caminho)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao ler o cassete: %w", err)
+	}
+	var arq arquivoCassete
+	if err := json.Unmarshal(dados, &arq); err != nil {
+		return nil, fmt.Errorf("cassete %s inválido: %w", caminho, err)
+	}
+	c.interacoes, c.usadas = arq.Interacoes, make([]bool, len(arq.Interacoes))
+	return c, nil
+}
+
+// Modo devolve o modo em uso: ModoGravar ou ModoReproduzir (ModoAutomatico resolvido pela existência do arquivo)
+func (c *Cassete) Modo() ModoCassete {
+	return c.modo
+}
+
+// Interacoes devolve as interações do cassete: as lidas do arquivo ou as gravadas até agora
+func (c *Cassete) Interacoes() []Interacao {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interacao(nil), c.interacoes...)
+}
+
+// Transporte envolve o transporte do nfe.Client (ver nfe.WithTransporte); base só é usado na gravação
+func (c *Cassete) Transporte(base http.RoundTripper) http.RoundTripper {
+	return transporteCassete{cassete: c, base: base}
+}
+
+// Cliente cria um nfe.Client que passa pelo cassete
+//
+// Na reprodução, o certificado de cfg é trocado por um descartável (a
+// conexão nunca é aberta): não informe nfe.WithCertPFX. Use na reprodução a
+// mesma URL de consulta da gravação.
+func (c *Cassete) Cliente(cfg nfe.Config, opcoes ...nfe.Opcao) (*nfe.Client, error) {
+	opcoes = append(opcoes, nfe.WithTransporte(c.Transporte))
+	if c.modo == ModoGravar {
+		return nfe.NewClient(cfg, opcoes...)
+	}
+
+	// O certificado é lido em NewClient: o diretório pode ser removido em seguida
+	dir, err := os.MkdirTemp("", "nfetest")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := gerarCertificados(dir, nil); err != nil {
+		return nil, fmt.Errorf("nfetest: falha ao gerar o certificado: %w", err)
+	}
+	cfg.CertDir, cfg.CertKeyFile, cfg.CertPubFile = dir, ArquivoChave, ArquivoCertificado
+	return nfe.NewClient(cfg, opcoes...)
+}
+
+// transporteCassete é o http.RoundTripper do Cassete
+type transporteCassete struct {
+	cassete *Cassete
+	base    http.RoundTripper
+}
+
+// RoundTrip grava ou reproduz a chamada, conforme o modo do cassete
+func (t transporteCassete) RoundTrip(req *http.Request) (*http.Response, error) {
+	var corpo []byte
+	if req.Body != nil {
+		var err error
+		corpo, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	i := Interacao{URL: req.URL.String(), Acao: acaoSOAP(req.Header)}
+	if m := chaveConsulta.FindSubmatch(corpo); m != nil {
+		i.Chave = string(m[1])
+	}
+
+	if t.cassete.modo == ModoReproduzir {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		gravada, ok := t.cassete.buscar(i)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s %s (chave %q)", ErrSemGravacao, i.Acao, i.URL, i.Chave)
+		}
+		return resposta(req, gravada.Status, gravada.TipoConteudo, []byte(gravada.Resposta)), nil
+	}
+
+	envio := req.Clone(req.Context())
+	envio.Body = io.NopCloser(bytes.NewReader(corpo))
+	resp, err := t.base.RoundTrip(envio)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	recebido, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	i.Requisicao, i.Resposta = sanitizar(string(corpo)), sanitizar(string(recebido))
+	i.Status, i.TipoConteudo, i.GravadaEm = resp.StatusCode, resp.Header.Get("Content-Type"), time.Now().UTC().Truncate(time.Second)
+	if err := t.cassete.gravar(i); err != nil {
+		return nil, err
+	}
+	return resposta(req, resp.StatusCode, i.TipoConteudo, recebido), nil
+}
+
+// buscar devolve a próxima interação não usada que corresponde a i (ou a última, se todas já foram usadas)
+func (c *Cassete) buscar(i Interacao) (Interacao, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ultima := -1
+	for n, g := range c.interacoes {
+		if g.URL != i.URL || g.Acao != i.Acao || g.Chave != i.Chave {
+			continue
+		}
+		if !c.usadas[n] {
+			c.usadas[n] = true
+			return g, true
+		}
+		ultima = n
+	}
+	if ultima < 0 {
+		return Interacao{}, false
+	}
+	return c.interacoes[ultima], true
+}
+
+// gravar acrescenta a interação e regrava o arquivo (atômico: arquivo temporário + rename)
+func (c *Cassete) gravar(i Interacao) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interacoes = append(c.interacoes, i)
+	var dados bytes.Buffer
+	enc := json.NewEncoder(&dados)
+	enc.SetEscapeHTML(false) // O XML fica legível no arquivo
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(arquivoCassete{Interacoes: c.interacoes}); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.caminho), 0o755); err != nil {
+		return fmt.Errorf("falha ao gravar o cassete: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.caminho), ".cassete-*")
+	if err != nil {
+		return fmt.Errorf("falha ao gravar o cassete: %w", err)
+	}
+	_, err = tmp.Write(dados.Bytes())
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.caminho)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("falha ao gravar o cassete: %w", err)
+	}
+	return nil
+}
+
+// resposta monta a http.Response devolvida ao cliente
+func resposta(req *http.Request, status int, tipo string, corpo []byte) *http.Response {
+	h := make(http.Header)
+	if tipo != "" {
+		h.Set("Content-Type", tipo)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader(corpo)),
+		ContentLength: int64(len(corpo)),
+		Request:       req,
+	}
+}
+
+// acaoSOAP extrai a SOAP action do Content-Type (SOAP 1.2) ou do cabeçalho SOAPAction (SOAP 1.1)
+func acaoSOAP(h http.Header) string {
+	if _, params, err := mime.ParseMediaType(h.Get("Content-Type")); err == nil && params["action"] != "" {
+		return params["action"]
+	}
+	return strings.Trim(h.Get("SOAPAction"), `"`)
+}
+
+// tagsAssinatura são os campos da assinatura digital que identificam o certificado do emitente
+var tagsAssinatura = regexp.MustCompile(`(<(?:[\w-]+:)?(?:X509Certificate|SignatureValue)>)[^<]*`)
+
+// sanitizar mascara os dados pessoais (nfe.MascararTexto) e o certificado da assinatura
+func sanitizar(s string) string {
+	return tagsAssinatura.ReplaceAllString(nfe.MascararTexto(s), "${1}***")
+}
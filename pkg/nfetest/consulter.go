@@ -0,0 +1,120 @@
+package nfetest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// FakeConsulter é um nfe.Consulter em memória: a SEFAZ falsa do Servidor, sem HTTP nem certificado
+//
+// Cada chave responde o cStat programado em Responder (sem programação,
+// RespostaPadrao) ou o erro de Falhar; as chamadas ficam em Consultas. É
+// seguro para uso concorrente e pode ser reprogramado durante o teste.
+//
+// Exemplo:
+//
+//	fake := nfetest.NewFakeConsulter()
+//	fake.Responder(cancelada, nfetest.Resposta{CStat: "101"})
+//	fake.Falhar(fora, context.DeadlineExceeded)
+//
+//	client, err := fake.Cliente() // ou nfe.NewClient(cfg, nfe.WithConsulter(fake))
+type FakeConsulter struct {
+	mu        sync.Mutex
+	respostas map[string]Resposta // Por chave; "" = todas as chaves sem resposta própria
+	erros     map[string]error    // Idem; vale sobre respostas
+	consultas []string
+}
+
+// NewFakeConsulter cria o FakeConsulter; as chaves sem programação respondem RespostaPadrao (autorizada)
+func NewFakeConsulter() *FakeConsulter {
+	return &FakeConsulter{respostas: map[string]Resposta{"": RespostaPadrao}, erros: make(map[string]error)}
+}
+
+// Responder define a resposta da chave (com chave vazia, a de todas as chaves sem resposta própria)
+//
+// Resposta.Falha produz o que o nfe.Client recebe de um SOAP Fault: Status
+// 999 ("Resposta da SEFAZ não parseada.") e a nota não autorizada.
+func (f *FakeConsulter) Responder(chave string, r Resposta) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.respostas[chave] = r
+}
+
+// Falhar faz a consulta da chave devolver err (com chave vazia, a de todas as chaves sem erro próprio); err nil desfaz
+//
+// O nfe.Client classifica o erro como qualquer falha de consulta (ex:
+// context.DeadlineExceeded = NFE-SEFAZ-TIMEOUT). Resposta.Atraso continua
+// valendo antes do erro.
+func (f *FakeConsulter) Falhar(chave string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.erros, chave)
+		return
+	}
+	f.erros[chave] = err
+}
+
+// Consultas devolve as chaves consultadas até agora, na ordem das chamadas (ex: para conferir o Cache)
+func (f *FakeConsulter) Consultas() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.consultas...)
+}
+
+// Cliente cria um nfe.Client que consulta o FakeConsulter, com as opções informadas
+func (f *FakeConsulter) Cliente(opcoes ...nfe.Opcao) (*nfe.Client, error) {
+	return nfe.NewClient(nfe.Config{UF: UF, Env: "homologacao"}, append(opcoes, nfe.WithConsulter(f))...)
+}
+
+// ConsultarSituacao devolve a situação programada para a chave (nfe.Consulter)
+//
+// A Resposta traz o retConsSitNFe montado como o do Servidor (ver
+// nfe.WithRespostaSefaz). Com Resposta.Atraso, espera antes de responder ou
+// devolve o erro do contexto se ele terminar antes.
+func (f *FakeConsulter) ConsultarSituacao(ctx context.Context, chave string) (nfe.ConsultaSefaz, error) {
+	resp, falha := f.registrar(chave)
+
+	if resp.Atraso > 0 {
+		select {
+		case <-time.After(resp.Atraso):
+		case <-ctx.Done():
+			return nfe.ConsultaSefaz{}, ctx.Err()
+		}
+	}
+	if falha != nil {
+		return nfe.ConsultaSefaz{}, falha
+	}
+
+	cStat, motivo := resp.CStat, resp.motivo()
+	if resp.Falha {
+		cStat, motivo = "999", "Resposta da SEFAZ não parseada."
+	}
+	return nfe.ConsultaSefaz{
+		// Como o cliente SEFAZ: apenas 100 e 150 contam como autorizada (110 é denegação)
+		Autorizado:   cStat == "100" || cStat == "150",
+		Status:       nfe.StatusSefaz{Codigo: cStat, Mensagem: motivo},
+		ConsultadoEm: time.Now(),
+		Resposta:     retConsSitNFe(chave, cStat, motivo),
+	}, nil
+}
+
+// registrar anota a consulta e devolve a Resposta e o erro programados para a chave
+func (f *FakeConsulter) registrar(chave string) (Resposta, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.consultas = append(f.consultas, chave)
+
+	resp, ok := f.respostas[chave]
+	if !ok {
+		resp = f.respostas[""]
+	}
+	falha, ok := f.erros[chave]
+	if !ok {
+		falha = f.erros[""]
+	}
+	return resp, falha
+}
@@ -0,0 +1,352 @@
+package nfetest
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// Gerador produz NF-e falsas e realistas: válidas no XSD (procNFe 4.00) e sem achados nas regras de negócio
+//
+// A chave de acesso tem DV correto e é coerente com a nota (UF, mês,
+// emitente, modelo, série e número); CPF e CNPJ têm dígitos verificadores
+// válidos e os totais, tributos, duplicatas e pagamento fecham com os itens.
+// A assinatura é apenas estrutural (não confere com RegraAssinatura) e o
+// protocolo é de homologação.
+//
+// A mesma semente e a mesma ConfigGerador produzem as mesmas notas. O
+// Gerador não é seguro para uso concorrente: use um por goroutine.
+//
+// Exemplo (massa para teste de carga):
+//
+//	g := nfetest.NewGerador(42)
+//	for i := range 1000 {
+//	    nota := g.Gerar(nfetest.ConfigGerador{Emissao: inicio})
+//	    os.WriteFile(fmt.Sprintf("carga/%04d-%s.xml", i, nota.Chave), nota.XML, 0o644)
+//	}
+type Gerador struct {
+	rnd *rand.Rand
+}
+
+// ConfigGerador ajusta as notas produzidas por Gerador.Gerar (valor zero = NF-e de SP, 1 a 5 itens, emitida agora)
+type ConfigGerador struct {
+	// Modelo é "55" (NF-e, padrão) ou "65" (NFC-e: consumidor final, sem destinatário nem duplicatas)
+	Modelo string
+
+	// UF é o código IBGE do emitente (padrão "35"); a nota é interna à UF
+	UF string
+
+	// Itens é o número de produtos (zero = de 1 a 5, sorteado)
+	Itens int
+
+	// Emissao é o dhEmi (zero = agora); informe um horário fixo para notas reproduzíveis
+	Emissao time.Time
+}
+
+// NotaGerada é uma NF-e produzida pelo Gerador
+type NotaGerada struct {
+	// XML é o procNFe completo (NFe + protNFe)
+	XML []byte
+
+	// Chave é a chave de acesso de 44 dígitos
+	Chave string
+
+	// Dados são os campos principais da nota, como nfe.ParsearXML os extrai
+	Dados nfe.DadosNFe
+}
+
+// NewGerador cria o Gerador com a semente informada
+func NewGerador(semente uint64) *Gerador {
+	return &Gerador{rnd: rand.New(rand.NewPCG(semente, semente^0x9e3779b97f4a7c15))}
+}
+
+// municipio é a capital de uma UF (cMun e xMun do endereço)
+type municipio struct {
+	sigla, codigo, nome string
+}
+
+// capitais são as capitais por código IBGE da UF
+var capitais = map[string]municipio{
+	"11": {"RO", "1100205", "Porto Velho"},
+	"12": {"AC", "1200401", "Rio Branco"},
+	"13": {"AM", "1302603", "Manaus"},
+	"14": {"RR", "1400100", "Boa Vista"},
+	"15": {"PA", "1501402", "Belém"},
+	"16": {"AP", "1600303", "Macapá"},
+	"17": {"TO", "1721000", "Palmas"},
+	"21": {"MA", "2111300", "São Luís"},
+	"22": {"PI", "2211001", "Teresina"},
+	"23": {"CE", "2304400", "Fortaleza"},
+	"24": {"RN", "2408102", "Natal"},
+	"25": {"PB", "2507507", "João Pessoa"},
+	"26": {"PE", "2611606", "Recife"},
+	"27": {"AL", "2704302", "Maceió"},
+	"28": {"SE", "2800308", "Aracaju"},
+	"29": {"BA", "2927408", "Salvador"},
+	"31": {"MG", "3106200", "Belo Horizonte"},
+	"32": {"ES", "3205309", "Vitória"},
+	"33": {"RJ", "3304557", "Rio de Janeiro"},
+	"35": {"SP", "3550308", "São Paulo"},
+	"41": {"PR", "4106902", "Curitiba"},
+	"42": {"SC", "4205407", "Florianópolis"},
+	"43": {"RS", "4314902", "Porto Alegre"},
+	"50": {"MS", "5002704", "Campo Grande"},
+	"51": {"MT", "5103403", "Cuiabá"},
+	"52": {"GO", "5208707", "Goiânia"},
+	"53": {"DF", "5300108", "Brasília"},
+}
+
+// produto é um item do catálogo sorteado pelo Gerador (preço em centavos)
+type produto struct {
+	descricao, ncm string
+	preco          int64
+}
+
+var catalogo = []produto{
+	{"NOTEBOOK 15 POL 8GB", "84713012", 349900},
+	{"SMARTPHONE 128GB", "85171300", 189900},
+	{"MESA DE MADEIRA 4 LUGARES", "94036000", 89990},
+	{"CAMISETA ALGODAO", "61091000", 4990},
+	{"TENIS ESPORTIVO", "64039990", 29990},
+	{"AGUA MINERAL 500ML", "22021000", 250},
+	{"IMPRESSORA MULTIFUNCIONAL", "84433299", 79900},
+	{"MONITOR LED 24 POL", "85285920", 99900},
+	{"CAIXA ORGANIZADORA PLASTICA", "39241000", 3990},
+	{"LIVRO TECNICO", "49011000", 12000},
+	{"BRINQUEDO DE MONTAR", "95030099", 15990},
+	{"PNEU ARO 15", "40111000", 42900},
+	{"LIQUIDIFICADOR 600W", "85094050", 18990},
+	{"BISCOITO RECHEADO 140G", "19053100", 399},
+	{"CAFE TORRADO 500G", "09012100", 2190},
+}
+
+var nomesEmpresas = []string{"COMERCIAL", "DISTRIBUIDORA", "ATACADO", "MAGAZINE", "SUPERMERCADO", "LOJAS"}
+var sobrenomesEmpresas = []string{"ALVORADA", "BOA VISTA", "CENTRAL", "DO SUL", "ESTRELA", "HORIZONTE", "PAULISTA", "UNIAO"}
+var nomesPessoas = []string{"ANA SOUZA", "BRUNO LIMA", "CARLA MENDES", "DIEGO ROCHA", "ELISA COSTA", "FABIO ALVES"}
+
+// Gerar produz uma nota nova
+func (g *Gerador) Gerar(cfg ConfigGerador) NotaGerada {
+	modelo := cfg.Modelo
+	if modelo != "65" {
+		modelo = "55"
+	}
+	uf := cfg.UF
+	mun, ok := capitais[uf]
+	if !ok {
+		uf, mun = "35", capitais["35"]
+	}
+	emissao := cfg.Emissao
+	if emissao.IsZero() {
+		emissao = time.Now().Add(-time.Minute)
+	}
+	emissao = emissao.In(fusoBrasilia).Truncate(time.Second)
+	itens := cfg.Itens
+	if itens <= 0 {
+		itens = 1 + g.rnd.IntN(5)
+	}
+
+	emitente := nfe.Empresa{
+		Documento: g.cnpj(),
+		Nome:      fmt.Sprintf("%s %s LTDA", g.sortear(nomesEmpresas), g.sortear(sobrenomesEmpresas)),
+	}
+	serie := fmt.Sprint(1 + g.rnd.IntN(9))
+	numero := fmt.Sprint(1 + g.rnd.IntN(999999))
+	cNF := fmt.Sprintf("%08d", g.rnd.IntN(100000000))
+	base := uf + emissao.Format("0601") + emitente.Documento + modelo + fmt.Sprintf("%03s%09s", serie, numero) + "1" + cNF
+	chave := base + digitoChave(base)
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<nfeProc xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00"><NFe xmlns="http://www.portalfiscal.inf.br/nfe">`)
+	fmt.Fprintf(&b, `<infNFe Id="NFe%s" versao="4.00">`, chave)
+
+	// ide
+	tpImp, indFinal, indPres := "1", "0", "9"
+	if modelo == "65" {
+		tpImp, indFinal, indPres = "4", "1", "1"
+	}
+	fmt.Fprintf(&b, `<ide><cUF>%s</cUF><cNF>%s</cNF><natOp>VENDA DE MERCADORIA</natOp><mod>%s</mod><serie>%s</serie><nNF>%s</nNF><dhEmi>%s</dhEmi>`,
+		uf, cNF, modelo, serie, numero, dataHora(emissao))
+	if modelo == "55" {
+		fmt.Fprintf(&b, `<dhSaiEnt>%s</dhSaiEnt>`, dataHora(emissao))
+	}
+	fmt.Fprintf(&b, `<tpNF>1</tpNF><idDest>1</idDest><cMunFG>%s</cMunFG><tpImp>%s</tpImp><tpEmis>1</tpEmis><cDV>%s</cDV><tpAmb>2</tpAmb><finNFe>1</finNFe><indFinal>%s</indFinal><indPres>%s</indPres><procEmi>0</procEmi><verProc>nfetest</verProc></ide>`,
+		mun.codigo, tpImp, chave[43:], indFinal, indPres)
+
+	// emit e dest
+	fmt.Fprintf(&b, `<emit><CNPJ>%s</CNPJ><xNome>%s</xNome>%s<IE>%s</IE><CRT>3</CRT></emit>`,
+		emitente.Documento, emitente.Nome, g.endereco("enderEmit", mun), g.digitos(12))
+	var destinatario nfe.Empresa
+	if modelo == "55" {
+		destinatario = nfe.Empresa{Documento: g.cpf(), Nome: g.sortear(nomesPessoas)}
+		fmt.Fprintf(&b, `<dest><CPF>%s</CPF><xNome>%s</xNome>%s<indIEDest>9</indIEDest></dest>`,
+			destinatario.Documento, destinatario.Nome, g.endereco("enderDest", mun))
+	}
+
+	// det, com os tributos calculados sobre o valor de cada item
+	var tot totais
+	dados := nfe.DadosNFe{Modelo: modelo, Serie: serie, Numero: numero, Emitente: emitente, Destinatario: destinatario}
+	for i := 1; i <= itens; i++ {
+		p := catalogo[g.rnd.IntN(len(catalogo))]
+		qtd := int64(1 + g.rnd.IntN(10))
+		vProd := p.preco * qtd
+		vICMS, vIPI := percentual(vProd, 1800), int64(0)
+		if modelo == "55" {
+			vIPI = percentual(vProd, 1000)
+		}
+		vPIS, vCOFINS := percentual(vProd, 165), percentual(vProd, 760)
+		tot.somar(vProd, vICMS, vIPI, vPIS, vCOFINS)
+
+		codigo := fmt.Sprintf("%03d", 1+g.rnd.IntN(999))
+		fmt.Fprintf(&b, `<det nItem="%d"><prod><cProd>%s</cProd><cEAN>SEM GTIN</cEAN><xProd>%s</xProd><NCM>%s</NCM><CFOP>5102</CFOP><uCom>UN</uCom><qCom>%d.0000</qCom><vUnCom>%s00000000</vUnCom><vProd>%s</vProd><cEANTrib>SEM GTIN</cEANTrib><uTrib>UN</uTrib><qTrib>%d.0000</qTrib><vUnTrib>%s00000000</vUnTrib><indTot>1</indTot></prod>`,
+			i, codigo, p.descricao, p.ncm, qtd, reais(p.preco), reais(vProd), qtd, reais(p.preco))
+		fmt.Fprintf(&b, `<imposto><ICMS><ICMS00><orig>0</orig><CST>00</CST><modBC>3</modBC><vBC>%s</vBC><pICMS>18.00</pICMS><vICMS>%s</vICMS></ICMS00></ICMS>`,
+			reais(vProd), reais(vICMS))
+		if modelo == "55" {
+			fmt.Fprintf(&b, `<IPI><cEnq>999</cEnq><IPITrib><CST>50</CST><vBC>%s</vBC><pIPI>10.00</pIPI><vIPI>%s</vIPI></IPITrib></IPI>`,
+				reais(vProd), reais(vIPI))
+		}
+		fmt.Fprintf(&b, `<PIS><PISAliq><CST>01</CST><vBC>%s</vBC><pPIS>1.65</pPIS><vPIS>%s</vPIS></PISAliq></PIS><COFINS><COFINSAliq><CST>01</CST><vBC>%s</vBC><pCOFINS>7.60</pCOFINS><vCOFINS>%s</vCOFINS></COFINSAliq></COFINS></imposto></det>`,
+			reais(vProd), reais(vPIS), reais(vProd), reais(vCOFINS))
+
+		dados.Itens = append(dados.Itens, nfe.ItemNFe{Item: i, Codigo: codigo, Descricao: p.descricao, NCM: p.ncm, CFOP: "5102", Valor: reais(vProd)})
+	}
+	vNF := tot.vProd + tot.vIPI
+	dados.ValorTotal = reais(vNF)
+
+	fmt.Fprintf(&b, `<total><ICMSTot><vBC>%s</vBC><vICMS>%s</vICMS><vICMSDeson>0.00</vICMSDeson><vFCP>0.00</vFCP><vBCST>0.00</vBCST><vST>0.00</vST><vFCPST>0.00</vFCPST><vFCPSTRet>0.00</vFCPSTRet><vProd>%s</vProd><vFrete>0.00</vFrete><vSeg>0.00</vSeg><vDesc>0.00</vDesc><vII>0.00</vII><vIPI>%s</vIPI><vIPIDevol>0.00</vIPIDevol><vPIS>%s</vPIS><vCOFINS>%s</vCOFINS><vOutro>0.00</vOutro><vNF>%s</vNF></ICMSTot></total><transp><modFrete>9</modFrete></transp>`,
+		reais(tot.vProd), reais(tot.vICMS), reais(tot.vProd), reais(tot.vIPI), reais(tot.vPIS), reais(tot.vCOFINS), reais(vNF))
+
+	// cobr (NF-e a prazo, em até 3 parcelas mensais) e pag
+	tPag := "01"
+	if modelo == "55" {
+		tPag = "15"
+		parcelas := int64(1 + g.rnd.IntN(3))
+		fmt.Fprintf(&b, `<cobr><fat><nFat>%s</nFat><vOrig>%s</vOrig><vDesc>0.00</vDesc><vLiq>%s</vLiq></fat>`, numero, reais(vNF), reais(vNF))
+		for i := int64(1); i <= parcelas; i++ {
+			valor := vNF / parcelas
+			if i == parcelas {
+				valor = vNF - valor*(parcelas-1)
+			}
+			fmt.Fprintf(&b, `<dup><nDup>%03d</nDup><dVenc>%s</dVenc><vDup>%s</vDup></dup>`, i, emissao.AddDate(0, int(i), 0).Format("2006-01-02"), reais(valor))
+		}
+		b.WriteString(`</cobr>`)
+	}
+	fmt.Fprintf(&b, `<pag><detPag><tPag>%s</tPag><vPag>%s</vPag></detPag></pag></infNFe>`, tPag, reais(vNF))
+
+	if modelo == "65" {
+		fmt.Fprintf(&b, `<infNFeSupl><qrCode><![CDATA[https://www.homologacao.nfce.fazenda.sp.gov.br/qrcode?p=%s|2|2|1|%s]]></qrCode><urlChave>https://www.homologacao.nfce.fazenda.sp.gov.br/consulta</urlChave></infNFeSupl>`,
+			chave, strings.ToUpper(g.hex(40)))
+	}
+
+	// Assinatura estrutural (válida no XSD) e protocolo de homologação
+	fmt.Fprintf(&b, `<Signature xmlns="http://www.w3.org/2000/09/xmldsig#"><SignedInfo><CanonicalizationMethod Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"/><SignatureMethod Algorithm="http://www.w3.org/2000/09/xmldsig#rsa-sha1"/><Reference URI="#NFe%s"><Transforms><Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"/><Transform Algorithm="http://www.w3.org/TR/2001/REC-xml-c14n-20010315"/></Transforms><DigestMethod Algorithm="http://www.w3.org/2000/09/xmldsig#sha1"/><DigestValue>AAAAAAAAAAAAAAAAAAAAAAAAAAA=</DigestValue></Reference></SignedInfo><SignatureValue>AAAA</SignatureValue><KeyInfo><X509Data><X509Certificate>AAAA</X509Certificate></X509Data></KeyInfo></Signature></NFe>`, chave)
+	fmt.Fprintf(&b, `<protNFe versao="4.00"><infProt><tpAmb>2</tpAmb><verAplic>nfetest</verAplic><chNFe>%s</chNFe><dhRecbto>%s</dhRecbto><nProt>%s%s%s</nProt><digVal>AAAAAAAAAAAAAAAAAAAAAAAAAAA=</digVal><cStat>100</cStat><xMotivo>Autorizado o uso da NF-e</xMotivo></infProt></protNFe></nfeProc>`+"\n",
+		chave, dataHora(emissao.Add(time.Second)), "1", uf, emissao.Format("06")+g.digitos(10))
+
+	return NotaGerada{XML: []byte(b.String()), Chave: chave, Dados: dados}
+}
+
+// fusoBrasilia é o fuso das datas da nota (-03:00, sem horário de verão)
+var fusoBrasilia = time.FixedZone("BRT", -3*60*60)
+
+// dataHora formata no padrão UTC da NF-e (AAAA-MM-DDThh:mm:ss-03:00)
+func dataHora(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05-07:00")
+}
+
+// totais acumula os valores dos itens, em centavos
+type totais struct {
+	vProd, vICMS, vIPI, vPIS, vCOFINS int64
+}
+
+func (t *totais) somar(vProd, vICMS, vIPI, vPIS, vCOFINS int64) {
+	t.vProd += vProd
+	t.vICMS += vICMS
+	t.vIPI += vIPI
+	t.vPIS += vPIS
+	t.vCOFINS += vCOFINS
+}
+
+// percentual aplica a alíquota (em centésimos de ponto percentual: 1800 = 18%) sobre centavos, arredondando
+func percentual(centavos, aliquota int64) int64 {
+	return (centavos*aliquota + 5000) / 10000
+}
+
+// reais formata centavos com duas casas (ex: 12345 -> "123.45")
+func reais(centavos int64) string {
+	return fmt.Sprintf("%d.%02d", centavos/100, centavos%100)
+}
+
+// endereco monta o grupo de endereço na capital da UF
+func (g *Gerador) endereco(tag string, m municipio) string {
+	return fmt.Sprintf(`<%s><xLgr>RUA %s</xLgr><nro>%d</nro><xBairro>CENTRO</xBairro><cMun>%s</cMun><xMun>%s</xMun><UF>%s</UF><CEP>%s</CEP><cPais>1058</cPais><xPais>BRASIL</xPais></%s>`,
+		tag, g.sortear(sobrenomesEmpresas), 1+g.rnd.IntN(9999), m.codigo, m.nome, m.sigla, g.digitos(8), tag)
+}
+
+// cnpj sorteia um CNPJ de matriz (0001) com dígitos verificadores válidos
+func (g *Gerador) cnpj() string {
+	base := g.digitos(8) + "0001"
+	base += digitoDocumento(base, []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2})
+	return base + digitoDocumento(base, []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2})
+}
+
+// cpf sorteia um CPF com dígitos verificadores válidos
+func (g *Gerador) cpf() string {
+	base := g.digitos(9)
+	base += digitoDocumento(base, []int{10, 9, 8, 7, 6, 5, 4, 3, 2})
+	return base + digitoDocumento(base, []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2})
+}
+
+// digitoDocumento calcula um dígito verificador de CPF/CNPJ (módulo 11) com os pesos informados
+func digitoDocumento(base string, pesos []int) string {
+	soma := 0
+	for i, p := range pesos {
+		soma += int(base[i]-'0') * p
+	}
+	if r := soma % 11; r >= 2 {
+		return fmt.Sprint(11 - r)
+	}
+	return "0"
+}
+
+// digitoChave calcula o DV da chave de acesso (módulo 11, pesos de 2 a 9 da direita para a esquerda)
+func digitoChave(base string) string {
+	soma, peso := 0, 2
+	for i := len(base) - 1; i >= 0; i-- {
+		soma += int(base[i]-'0') * peso
+		if peso++; peso > 9 {
+			peso = 2
+		}
+	}
+	if r := soma % 11; r >= 2 {
+		return fmt.Sprint(11 - r)
+	}
+	return "0"
+}
+
+// digitos sorteia n dígitos decimais
+func (g *Gerador) digitos(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + g.rnd.IntN(10))
+	}
+	return string(b)
+}
+
+// hex sorteia n dígitos hexadecimais
+func (g *Gerador) hex(n int) string {
+	const hexa = "0123456789abcdef"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = hexa[g.rnd.IntN(16)]
+	}
+	return string(b)
+}
+
+func (g *Gerador) sortear(opcoes []string) string {
+	return opcoes[g.rnd.IntN(len(opcoes))]
+}
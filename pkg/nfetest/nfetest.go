@@ -0,0 +1,278 @@
+// Package nfetest sobe uma SEFAZ falsa para testes de integração, sem certificado digital nem rede
+//
+// O Servidor é um httptest.Server com TLS que responde ao NfeConsultaProtocolo4
+// com um retConsSitNFe montado a partir do cStat configurado para cada chave;
+// também simula lentidão (Resposta.Atraso) e SOAP Fault (Resposta.Falha). O
+// certificado de cliente e a CA do servidor são gerados em um diretório
+// temporário, removido em Close: Config e Cliente já apontam para eles, e o
+// nfe.Client percorre o mesmo caminho da SEFAZ real (mTLS, SOAP, parse da
+// resposta, Cache, Observador).
+//
+// O pacote traz também o FakeConsulter, a mesma SEFAZ falsa em memória (sem
+// HTTP), o Gerador, de notas falsas válidas no XSD, e o Cassete, que grava as
+// chamadas à SEFAZ real e as reproduz nos testes.
+//
+// Exemplo:
+//
+//	func TestImportacao(t *testing.T) {
+//	    sefaz := nfetest.New()
+//	    defer sefaz.Close()
+//	    sefaz.Responder("35250732409620000175550010000037471011544648", nfetest.Resposta{CStat: "101"})
+//
+//	    client, err := sefaz.Cliente(nfe.WithTimeout(time.Second))
+//	    if err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    result, err := client.ValidarChave("35250732409620000175550010000037471011544648")
+//	    ...
+//	}
+package nfetest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fabyo/go-nfe-validator/pkg/nfe"
+)
+
+// Arquivos gerados no diretório do Servidor (Config.CertDir)
+const (
+	ArquivoChave       = "key.pem"  // Chave privada do certificado de cliente
+	ArquivoCertificado = "cert.pem" // Certificado de cliente (autoassinado)
+	ArquivoCA          = "ca.crt"   // Certificado do servidor, aceito como CA pelo cliente
+)
+
+// UF é o código IBGE informado em Config e nas respostas (SP)
+const UF = "35"
+
+// Resposta é o que o Servidor devolve à consulta de uma chave
+type Resposta struct {
+	// CStat é o cStat da situação (ex: "100" autorizada, "101" cancelada, "217" não encontrada)
+	CStat string
+
+	// XMotivo é o xMotivo; vazio = o texto usual do cStat (ver Motivo)
+	XMotivo string
+
+	// Atraso é a espera antes de responder (ex: acima do WithTimeout, para testar o prazo)
+	Atraso time.Duration
+
+	// Falha responde um SOAP Fault (HTTP 500) no lugar do retConsSitNFe
+	//
+	// Como na SEFAZ real, o nfe.Client recebe uma resposta sem cStat: Status
+	// 999 ("Resposta da SEFAZ não parseada.") e a nota não autorizada.
+	Falha bool
+}
+
+// RespostaPadrao é a resposta das chaves sem Responder: nota autorizada
+var RespostaPadrao = Resposta{CStat: "100"}
+
+// motivos são os xMotivo usuais dos cStat mais comuns da consulta
+var motivos = map[string]string{
+	"100": "Autorizado o uso da NF-e",
+	"101": "Cancelamento de NF-e homologado",
+	"110": "Uso Denegado",
+	"150": "Autorizado o uso da NF-e, autorização concedida fora de prazo",
+	"217": "Rejeição: NF-e não consta na base de dados da SEFAZ",
+	"562": "Rejeição: Código Numérico informado na Chave de Acesso difere do Código Numérico da NF-e",
+}
+
+// Motivo devolve o xMotivo usual do cStat (vazio para um cStat desconhecido)
+func Motivo(cStat string) string {
+	return motivos[cStat]
+}
+
+// chaveConsulta extrai a chave do consSitNFe recebido
+var chaveConsulta = regexp.MustCompile(`<chNFe>(\d+)</chNFe>`)
+
+// Servidor é a SEFAZ falsa; use New e, ao final, Close
+type Servidor struct {
+	*httptest.Server
+
+	dir string // Certificados gerados (Config.CertDir)
+
+	mu        sync.Mutex
+	respostas map[string]Resposta // Por chave; "" = todas as chaves sem resposta própria
+	consultas []string
+}
+
+// New sobe o Servidor com TLS e gera os certificados; entra em pânico se não conseguir (como httptest.NewServer)
+func New() *Servidor {
+	s := &Servidor{respostas: map[string]Resposta{"": RespostaPadrao}}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.atender))
+
+	dir, err := os.MkdirTemp("", "nfetest")
+	if err == nil {
+		err = gerarCertificados(dir, s.Certificate())
+	}
+	if err != nil {
+		s.Server.Close()
+		os.RemoveAll(dir)
+		panic(fmt.Sprintf("nfetest: falha ao gerar os certificados: %v", err))
+	}
+	s.dir = dir
+	return s
+}
+
+// Close encerra o servidor e remove os certificados gerados
+func (s *Servidor) Close() {
+	s.Server.Close()
+	os.RemoveAll(s.dir)
+}
+
+// Responder define a resposta da chave; com chave vazia, a de todas as chaves sem resposta própria
+//
+// Pode ser chamado com o servidor em uso: vale para as consultas seguintes.
+func (s *Servidor) Responder(chave string, r Resposta) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.respostas[chave] = r
+}
+
+// Consultas devolve as chaves consultadas até agora, na ordem de chegada (ex: para conferir o Cache)
+func (s *Servidor) Consultas() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.consultas...)
+}
+
+// Config aponta o nfe.Config para o Servidor: certificados gerados, UF 35 e homologação
+func (s *Servidor) Config() nfe.Config {
+	return nfe.Config{
+		CertDir:     s.dir,
+		CertKeyFile: ArquivoChave,
+		CertPubFile: ArquivoCertificado,
+		UF:          UF,
+		ConsultaURL: s.URL,
+		Env:         "homologacao",
+	}
+}
+
+// Cliente cria um nfe.Client ligado ao Servidor (ver Config), com as opções informadas
+func (s *Servidor) Cliente(opcoes ...nfe.Opcao) (*nfe.Client, error) {
+	return nfe.NewClient(s.Config(), opcoes...)
+}
+
+// atender responde ao consSitNFe com a Resposta da chave
+func (s *Servidor) atender(w http.ResponseWriter, r *http.Request) {
+	corpo, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	m := chaveConsulta.FindSubmatch(corpo)
+	if r.Method != http.MethodPost || m == nil {
+		responderFalha(w, "soap:Sender", "requisição sem consSitNFe")
+		return
+	}
+	chave := string(m[1])
+	resp := s.registrar(chave)
+
+	if resp.Atraso > 0 {
+		select {
+		case <-time.After(resp.Atraso):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if resp.Falha {
+		responderFalha(w, "soap:Receiver", "Serviço indisponível (nfetest)")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><nfeResultMsg xmlns="http://www.portalfiscal.inf.br/nfe/wsdl/NFeConsultaProtocolo4">%s</nfeResultMsg></soap:Body></soap:Envelope>`,
+		retConsSitNFe(chave, resp.CStat, resp.motivo()))
+}
+
+// motivo devolve o XMotivo, ou o texto usual do cStat
+func (r Resposta) motivo() string {
+	if r.XMotivo == "" {
+		return Motivo(r.CStat)
+	}
+	return r.XMotivo
+}
+
+// retConsSitNFe monta o retorno da consulta de situação, como a SEFAZ de homologação da UF o devolve
+func retConsSitNFe(chave, cStat, motivo string) string {
+	return fmt.Sprintf(`<retConsSitNFe xmlns="http://www.portalfiscal.inf.br/nfe" versao="4.00"><tpAmb>2</tpAmb><verAplic>nfetest</verAplic><cStat>%s</cStat><xMotivo>%s</xMotivo><cUF>%s</cUF><dhRecbto>%s</dhRecbto><chNFe>%s</chNFe></retConsSitNFe>`,
+		escapar(cStat), escapar(motivo), UF, time.Now().Format("2006-01-02T15:04:05-07:00"), escapar(chave))
+}
+
+// registrar anota a consulta e devolve a Resposta da chave
+func (s *Servidor) registrar(chave string) Resposta {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.consultas = append(s.consultas, chave)
+	if r, ok := s.respostas[chave]; ok {
+		return r
+	}
+	return s.respostas[""]
+}
+
+// responderFalha devolve um SOAP 1.2 Fault com HTTP 500
+func responderFalha(w http.ResponseWriter, codigo, motivo string) {
+	w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><soap:Fault><soap:Code><soap:Value>%s</soap:Value></soap:Code><soap:Reason><soap:Text xml:lang="pt-BR">%s</soap:Text></soap:Reason></soap:Fault></soap:Body></soap:Envelope>`,
+		codigo, escapar(motivo))
+}
+
+// escapar prepara s para o conteúdo de uma tag XML
+func escapar(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// gerarCertificados grava em dir um certificado de cliente autoassinado e, se informado, o certificado do servidor (como CA)
+func gerarCertificados(dir string, servidor *x509.Certificate) error {
+	chave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	modelo := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "NFETEST LTDA:12345678000195"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, modelo, modelo, &chave.PublicKey, chave)
+	if err != nil {
+		return err
+	}
+	chaveDER, err := x509.MarshalPKCS8PrivateKey(chave)
+	if err != nil {
+		return err
+	}
+
+	arquivos := map[string]*pem.Block{
+		ArquivoChave:       {Type: "PRIVATE KEY", Bytes: chaveDER},
+		ArquivoCertificado: {Type: "CERTIFICATE", Bytes: der},
+	}
+	if servidor != nil {
+		arquivos[ArquivoCA] = &pem.Block{Type: "CERTIFICATE", Bytes: servidor.Raw}
+	}
+	for nome, bloco := range arquivos {
+		if err := os.WriteFile(filepath.Join(dir, nome), pem.EncodeToMemory(bloco), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}